@@ -0,0 +1,43 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+)
+
+// legacyRef is a reference of a code file to the legacy ID of a requirement,
+// resolved with the aliases of the configuration, see RepoConfig.Aliases.
+type legacyRef struct {
+	Line  int
+	ID    string // The legacy ID.
+	Alias string // The current ID.
+}
+
+// resolveAlias returns the current ID of the requirement with the given ID,
+// which is legacy when it has an alias.
+func resolveAlias(id string) (string, bool) {
+	alias, ok := repoConfig.Aliases[id]
+	if !ok {
+		return id, false
+	}
+	return alias, true
+}
+
+// CheckAliases returns the references of the code files to legacy IDs, to be
+// migrated to the current ones.
+func (rg reqGraph) CheckAliases() []error {
+	var files []*Req
+	for _, r := range rg {
+		if len(r.LegacyRefs) > 0 {
+			files = append(files, r)
+		}
+	}
+	sort.Sort(byIDs(files))
+	var errs []error
+	for _, r := range files {
+		for _, l := range r.LegacyRefs {
+			errs = append(errs, fmt.Errorf("File '%s' references the legacy ID '%s' on line %d, instead of '%s'.\n", r.ID, l.ID, l.Line, l.Alias))
+		}
+	}
+	return errs
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/daedaleanai/reqtraq/config"
+)
+
+// The markers of the summaries of the requirements implemented by the code
+// files, generated at their top by the annotate command.
+const (
+	summaryBegin = "reqtraq:summary"
+	summaryEnd   = "reqtraq:end"
+)
+
+// commentPrefix returns the prefix of the line comments of the given code file.
+func commentPrefix(fileName string) string {
+	if isHWDesignFile(fileName) {
+		return "#"
+	}
+	switch strings.ToLower(path.Ext(fileName)) {
+	case ".py", ".sh", ".cmake", ".bzl", ".rb", ".pl", ".tcl":
+		return "#"
+	}
+	return "//"
+}
+
+// fileSummary returns the summary of the requirements implemented by the code
+// file, as comment lines.
+func (rg reqGraph) fileSummary(r *Req) []string {
+	c := commentPrefix(r.Path)
+	ids := map[string]bool{}
+	for _, id := range r.ParentIds {
+		ids[id] = true
+	}
+	var sorted []string
+	for id := range ids {
+		sorted = append(sorted, id)
+	}
+	sort.Strings(sorted)
+	lines := []string{c + " " + summaryBegin + ` Generated by "reqtraq annotate", do not edit.`, c + " Implements:"}
+	for _, id := range sorted {
+		title := "(unknown requirement)"
+		if p, ok := rg[id]; ok {
+			title = p.Expand(p.Title)
+		}
+		lines = append(lines, c+"   "+id+" "+title)
+	}
+	return append(lines, c+" "+summaryEnd)
+}
+
+// summaryRange returns the first and the last lines of the summary of the
+// given code lines, -1 when there is none.
+func summaryRange(lines []string) (int, int, error) {
+	for i, l := range lines {
+		if !strings.Contains(l, summaryBegin) {
+			continue
+		}
+		for j := i + 1; j < len(lines); j++ {
+			if strings.Contains(lines[j], summaryEnd) {
+				return i, j, nil
+			}
+		}
+		return 0, 0, fmt.Errorf("the summary starting on line %d does not end with %s", i+1, summaryEnd)
+	}
+	return -1, -1, nil
+}
+
+// annotateContents returns the contents of a code file with the given summary,
+// replacing the existing one, or at the top of the file, after the #! line.
+func annotateContents(contents []byte, summary []string) ([]byte, error) {
+	eol := "\n"
+	if bytes.Contains(contents, []byte("\r\n")) {
+		eol = "\r\n"
+	}
+	lines := strings.Split(string(contents), eol)
+	first, last, err := summaryRange(lines)
+	if err != nil {
+		return nil, err
+	}
+	var res []string
+	if first >= 0 {
+		res = append(append(append(res, lines[:first]...), summary...), lines[last+1:]...)
+	} else {
+		at := 0
+		if len(lines) > 0 && strings.HasPrefix(lines[0], "#!") {
+			at = 1
+		}
+		res = append(append(append(res, lines[:at]...), summary...), lines[at:]...)
+	}
+	return []byte(strings.Join(res, eol)), nil
+}
+
+// Annotate writes the summary of the requirements implemented by the given
+// code file, with the absolute path, at its top.
+func (rg reqGraph) Annotate(fileName string) error {
+	r, ok := rg[fileName]
+	if !ok || r.Level != config.CODE {
+		return fmt.Errorf("%s is not a code file referencing requirements", fileName)
+	}
+	contents, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return err
+	}
+	annotated, err := annotateContents(contents, rg.fileSummary(r))
+	if err != nil {
+		return fmt.Errorf("%s: %v", fileName, err)
+	}
+	return ioutil.WriteFile(fileName, annotated, 0644)
+}
+
+// CheckSummaries checks that the summaries of the code files having one list
+// the requirements they implement, see Annotate.
+func (rg reqGraph) CheckSummaries() []error {
+	var files []*Req
+	for _, r := range rg {
+		if r.Level == config.CODE {
+			files = append(files, r)
+		}
+	}
+	sort.Sort(byIDs(files))
+	var errs []error
+	for _, r := range files {
+		contents, err := ioutil.ReadFile(r.Path)
+		if err != nil || !bytes.Contains(contents, []byte(summaryBegin)) {
+			continue
+		}
+		annotated, err := annotateContents(contents, rg.fileSummary(r))
+		if err != nil {
+			errs = append(errs, fmt.Errorf("File '%s' has an invalid requirements summary: %v.\n", r.ID, err))
+		} else if !bytes.Equal(annotated, contents) {
+			errs = append(errs, fmt.Errorf("File '%s' has an outdated requirements summary, run \"reqtraq annotate %s\".\n", r.ID, strings.TrimPrefix(r.ID, "/")))
+		}
+	}
+	return errs
+}
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/daedaleanai/reqtraq/config"
+)
+
+// The types of the attributes, see AttributeSpec.Type. The values of the
+// enumerations are given by AttributeSpec.Values, the dates are written as
+// 2006-01-02, and the references are lists of requirement IDs.
+const (
+	attributeText  = "text"
+	attributeInt   = "int"
+	attributeFloat = "float"
+	attributeEnum  = "enum"
+	attributeDate  = "date"
+	attributeRef   = "ref"
+)
+
+// attributeTypes are the types of the attributes, the empty one being text.
+var attributeTypes = []string{"", attributeText, attributeInt, attributeFloat, attributeEnum, attributeDate, attributeRef}
+
+// attributeDateLayout is the layout of the values of the date attributes.
+const attributeDateLayout = "2006-01-02"
+
+func isAttributeType(typ string) bool {
+	for _, t := range attributeTypes {
+		if t == typ {
+			return true
+		}
+	}
+	return false
+}
+
+// AttributeValue is the value of an attribute, parsed according to its type.
+type AttributeValue struct {
+	Type   string
+	Values []string  // The values of the enumeration.
+	Text   string    // As written, without the surrounding spaces.
+	Number float64   // Of the int and float values, and the index of the enum values.
+	Date   time.Time // Of the date values.
+	Refs   []string  // Of the ref values.
+	Err    error     // Why the text is not a value of the type, see parseTypedAttributes.
+}
+
+// parseAttributeValue parses the text of an attribute of the given type, with
+// the given values for an enumeration. The error describes the expected value.
+func parseAttributeValue(typ string, values []string, text string) (AttributeValue, error) {
+	text = strings.TrimSpace(text)
+	v := AttributeValue{Type: typ, Values: values, Text: text}
+	var err error
+	switch typ {
+	case attributeInt:
+		var n int64
+		if n, err = strconv.ParseInt(text, 10, 64); err != nil {
+			return v, fmt.Errorf("an integer")
+		}
+		v.Number = float64(n)
+	case attributeFloat:
+		if v.Number, err = strconv.ParseFloat(text, 64); err != nil {
+			return v, fmt.Errorf("a number")
+		}
+	case attributeEnum:
+		for i, e := range values {
+			if strings.EqualFold(e, text) {
+				v.Number = float64(i)
+				return v, nil
+			}
+		}
+		return v, fmt.Errorf("one of %s", strings.Join(values, ", "))
+	case attributeDate:
+		if v.Date, err = time.Parse(attributeDateLayout, text); err != nil {
+			return v, fmt.Errorf("a date as %s", attributeDateLayout)
+		}
+	case attributeRef:
+		v.Refs = ReReqID.FindAllString(text, -1)
+		rest := strings.TrimFunc(ReReqID.ReplaceAllString(text, ""), func(r rune) bool { return r == ',' || r == ' ' })
+		if len(v.Refs) == 0 || rest != "" {
+			return v, fmt.Errorf("requirement IDs separated by commas")
+		}
+	}
+	return v, nil
+}
+
+// configAttribute returns the specification of the given attribute in the
+// configuration, if any.
+func configAttribute(name string) (AttributeSpec, bool) {
+	for _, a := range repoConfig.Attributes {
+		if strings.EqualFold(a.Name, name) {
+			return a, true
+		}
+	}
+	return AttributeSpec{}, false
+}
+
+// invalidAttributeValue returns the error of the requirement having a value
+// of the given attribute which is not the expected one.
+func invalidAttributeValue(r *Req, name, value string, expected interface{}) error {
+	return newFinding("attribute", "Requirement '%s' has invalid value '%s' in attribute '%s'. Expected %v.\n", r.ID, value, name, expected)
+}
+
+// parseTypedAttributes sets the Typed attributes of the requirement, own or
+// inherited, having a type in the given attribute specification.
+func (r *Req) parseTypedAttributes(as []map[string]string) {
+	r.Typed = nil
+	for _, a := range as {
+		if a["type"] == "" {
+			continue
+		}
+		name := strings.ToUpper(a["name"])
+		text, ok := r.attribute(name)
+		if !ok {
+			continue
+		}
+		var values []string
+		if a["values"] != "" {
+			values = strings.Split(a["values"], "\n")
+		}
+		r.setTyped(name, a["type"], values, text)
+	}
+}
+
+// setTyped sets the Typed value of the given attribute of the requirement,
+// parsing its text according to the type and the values of an enumeration.
+func (r *Req) setTyped(name, typ string, values []string, text string) {
+	v, err := parseAttributeValue(typ, values, text)
+	if err != nil {
+		v.Err = invalidAttributeValue(r, name, text, err)
+	}
+	if r.Typed == nil {
+		r.Typed = map[string]AttributeValue{}
+	}
+	r.Typed[name] = v
+}
+
+// parseTypedAttributes sets the Typed attributes of the requirements, as
+// specified by as overridden by the configuration fragments of their
+// directories.
+func (rg reqGraph) parseTypedAttributes(configs configTree, as []map[string]string) {
+	for _, r := range rg {
+		if r.Level != config.CODE {
+			r.parseTypedAttributes(configs.attributes(r.Path, as))
+		}
+	}
+}
+
+// TypedAttribute returns the value of the given attribute of the requirement,
+// own or inherited, parsed according to its type in the attribute
+// specification, text by default, and whether the requirement has it.
+func (r *Req) TypedAttribute(name string) (AttributeValue, bool, error) {
+	name = strings.ToUpper(name)
+	text, ok := r.attribute(name)
+	if !ok {
+		return AttributeValue{}, false, nil
+	}
+	if v, ok := r.Typed[name]; ok {
+		return v, true, v.Err
+	}
+	v, _ := parseAttributeValue(attributeText, nil, text)
+	return v, true, nil
+}
+
+// Compare returns -1, 0 or 1 when v is lower, equal or higher than other,
+// comparing the numbers, the positions of the enum values, the dates, or the
+// texts of the other types.
+func (v AttributeValue) Compare(other AttributeValue) int {
+	switch v.Type {
+	case attributeInt, attributeFloat, attributeEnum:
+		switch {
+		case v.Number < other.Number:
+			return -1
+		case v.Number > other.Number:
+			return 1
+		}
+		return 0
+	case attributeDate:
+		return v.Date.Compare(other.Date)
+	}
+	return strings.Compare(v.Text, other.Text)
+}
+
+// sortByAttribute sorts the requirements by the value of the given attribute,
+// keeping the order of the requirements with equal values, and putting last
+// those without the attribute or with an invalid value.
+func sortByAttribute(reqs []*Req, name string) {
+	type key struct {
+		v     AttributeValue
+		valid bool
+	}
+	keys := map[*Req]key{}
+	for _, r := range reqs {
+		v, ok, err := r.TypedAttribute(name)
+		keys[r] = key{v, ok && err == nil}
+	}
+	sort.SliceStable(reqs, func(i, j int) bool {
+		a, b := keys[reqs[i]], keys[reqs[j]]
+		if a.valid != b.valid {
+			return a.valid
+		}
+		return a.valid && a.v.Compare(b.v) < 0
+	})
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/daedaleanai/reqtraq/config"
+	"github.com/daedaleanai/reqtraq/git"
+)
+
+// Blame is the last change of the text of a requirement in its certdoc.
+type Blame struct {
+	Commit  string // The all-zero ID when the change is not committed yet.
+	Author  string
+	Time    time.Time
+	Summary string // The first line of the commit message.
+}
+
+// String returns a one line description of the change, e.g. for list.
+func (b *Blame) String() string {
+	if strings.Trim(b.Commit, "0") == "" {
+		return "not committed yet"
+	}
+	return fmt.Sprintf("%s on %s in %.12s %s", b.Author, b.Time.Format("2006-01-02"), b.Commit, b.Summary)
+}
+
+// reqLineRanges returns the lines of the requirements in the given certdoc,
+// by ID, as [first, last) indexes. In Markdown a requirement spans from its
+// heading to the next heading of the same or a higher level, in LyX from its
+// "req:" note to its "/req" note.
+func reqLineRanges(fileName string, lines []string) map[string][2]int {
+	res := map[string][2]int{}
+	if strings.ToLower(path.Ext(fileName)) == ".lyx" {
+		start := -1
+		for i, l := range lines {
+			switch {
+			case reStart.MatchString(l):
+				start = i
+			case reEnd.MatchString(l) && start >= 0:
+				for _, t := range lines[start:i] {
+					if id := ReReqID.FindString(t); id != "" {
+						res[id] = [2]int{start, i + 1}
+						break
+					}
+				}
+				start = -1
+			}
+		}
+		return res
+	}
+
+	id, level, start := "", 0, 0
+	for i, l := range lines {
+		parts := reATXHeading.FindStringSubmatch(l)
+		if parts == nil {
+			continue
+		}
+		if id != "" && len(parts[1]) <= level {
+			res[id] = [2]int{start, i}
+			id = ""
+		}
+		if headingID := ReReqID.FindString(parts[3]); headingID != "" {
+			id, level, start = headingID, len(parts[1]), i
+		}
+	}
+	if id != "" {
+		res[id] = [2]int{start, len(lines)}
+	}
+	return res
+}
+
+// blameCertdoc returns the last change of each of the requirements defined in
+// the given certdoc, relative to the repo root, at the given commit, or in the
+// working tree when empty.
+func blameCertdoc(commit, fileName string) (map[string]*Blame, error) {
+	lines, err := git.Blame(commit, fileName)
+	if err != nil {
+		return nil, err
+	}
+	texts := make([]string, len(lines))
+	for i, l := range lines {
+		texts[i] = l.Text
+	}
+	res := map[string]*Blame{}
+	for id, r := range reqLineRanges(fileName, texts) {
+		var last *git.BlameLine
+		for i := r[0]; i < r[1]; i++ {
+			if last == nil || lines[i].Time.After(last.Time) {
+				last = &lines[i]
+			}
+		}
+		if last != nil {
+			res[id] = &Blame{Commit: last.Commit, Author: last.Author, Time: last.Time, Summary: last.Summary}
+		}
+	}
+	return res, nil
+}
+
+// SetBlame sets the Blame of the requirements, from their certdocs at the
+// given commit, or in the working tree when empty.
+func (rg reqGraph) SetBlame(commit string) error {
+	byDoc := map[string][]*Req{}
+	for _, r := range rg {
+		if r.Level != config.CODE {
+			byDoc[r.Path] = append(byDoc[r.Path], r)
+		}
+	}
+	var docs []string
+	for d := range byDoc {
+		docs = append(docs, d)
+	}
+	sort.Strings(docs)
+	for _, d := range docs {
+		blames, err := blameCertdoc(commit, strings.TrimPrefix(d, "/"))
+		if err != nil {
+			return err
+		}
+		for _, r := range byDoc[d] {
+			r.Blame = blames[r.ID]
+		}
+	}
+	return nil
+}
+
+// setBlame sets the Blame of the requirements of the graph built by buildGraph
+// for the given commit, except for snapshots which have no history.
+func setBlame(rg reqGraph, commit string) error {
+	if commit == "" && *fSnapshot != "" {
+		slog.Warn("the snapshots have no history, the last changes of the requirements are not shown")
+		return nil
+	}
+	return rg.SetBlame(commit)
+}
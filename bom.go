@@ -0,0 +1,128 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/daedaleanai/reqtraq/config"
+)
+
+// BOM is a requirements bill of materials, modeled after the SPDX 2.3 json
+// documents: the code artifacts are SPDX files, and the requirements, which
+// have no SPDX equivalent, list the files implementing them, for the
+// programs attesting the specified behavior of the software in the same way
+// as its supply chain.
+type BOM struct {
+	SPDXVersion       string           `json:"spdxVersion"`
+	DataLicense       string           `json:"dataLicense"`
+	SPDXID            string           `json:"SPDXID"`
+	Name              string           `json:"name"`
+	DocumentNamespace string           `json:"documentNamespace"`
+	CreationInfo      bomCreationInfo  `json:"creationInfo"`
+	Requirements      []BOMRequirement `json:"requirements"`
+	Files             []BOMFile        `json:"files"`
+}
+
+type bomCreationInfo struct {
+	Created  string   `json:"created"`
+	Creators []string `json:"creators"`
+}
+
+// BOMRequirement is a requirement, with the document defining it.
+type BOMRequirement struct {
+	SPDXID           string   `json:"SPDXID"`
+	ID               string   `json:"id"`
+	Title            string   `json:"title"`
+	Document         string   `json:"document"`
+	DocumentRevision string   `json:"documentRevision,omitempty"`
+	Status           string   `json:"status"`
+	ImplementedBy    []string `json:"implementedBy,omitempty"` // The SPDXIDs of the files.
+}
+
+// BOMFile is a code artifact implementing requirements.
+type BOMFile struct {
+	SPDXID    string        `json:"SPDXID"`
+	FileName  string        `json:"fileName"`
+	Kind      string        `json:"kind,omitempty"` // See Req.Kind.
+	Checksums []bomChecksum `json:"checksums,omitempty"`
+}
+
+type bomChecksum struct {
+	Algorithm     string `json:"algorithm"`
+	ChecksumValue string `json:"checksumValue"`
+}
+
+// reSPDXIDChars matches the characters not allowed in SPDX identifiers.
+var reSPDXIDChars = regexp.MustCompile(`[^A-Za-z0-9.-]`)
+
+// spdxID returns an SPDX identifier for the given element.
+func spdxID(prefix, name string) string {
+	return "SPDXRef-" + prefix + "-" + reSPDXIDChars.ReplaceAllString(name, "-")
+}
+
+// BOM returns the requirements bill of materials of the graph of the given
+// repo, created at the given time.
+func (rg reqGraph) BOM(repoName string, created time.Time) (*BOM, error) {
+	hash, err := rg.Hash()
+	if err != nil {
+		return nil, err
+	}
+	bom := &BOM{
+		SPDXVersion:       "SPDX-2.3",
+		DataLicense:       "CC0-1.0",
+		SPDXID:            "SPDXRef-DOCUMENT",
+		Name:              repoName + " requirements",
+		DocumentNamespace: fmt.Sprintf("https://spdx.org/spdxdocs/%s-requirements-%s", repoName, hash),
+		CreationInfo:      bomCreationInfo{Created: created.UTC().Format(time.RFC3339), Creators: []string{"Tool: reqtraq"}},
+		Requirements:      []BOMRequirement{},
+		Files:             []BOMFile{},
+	}
+	var reqs, code []*Req
+	for _, r := range rg {
+		if r.Level == config.CODE {
+			code = append(code, r)
+		} else {
+			reqs = append(reqs, r)
+		}
+	}
+	sort.Sort(byIDs(reqs))
+	sort.Sort(byIDs(code))
+	for _, c := range code {
+		f := BOMFile{SPDXID: spdxID("File", c.ID), FileName: "./" + c.ID, Kind: c.Kind}
+		if c.FileHash != "" {
+			f.Checksums = []bomChecksum{{Algorithm: "SHA1", ChecksumValue: fmt.Sprintf("%x", c.FileHash)}}
+		}
+		bom.Files = append(bom.Files, f)
+	}
+	for _, r := range reqs {
+		br := BOMRequirement{SPDXID: spdxID("Req", r.ID), ID: r.ID, Title: r.Expand(r.Title), Document: strings.TrimPrefix(r.Path, "/"), Status: r.Status.String()}
+		if r.Document != nil {
+			br.DocumentRevision = r.Document.Revision
+		}
+		var files []string
+		for _, c := range r.Children {
+			if c.Level == config.CODE {
+				files = append(files, spdxID("File", c.ID))
+			}
+		}
+		sort.Strings(files)
+		br.ImplementedBy = files
+		bom.Requirements = append(bom.Requirements, br)
+	}
+	return bom, nil
+}
+
+// Write writes the bill of materials as indented json.
+func (bom *BOM) Write(w io.Writer) error {
+	b, err := json.MarshalIndent(bom, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(b, '\n'))
+	return err
+}
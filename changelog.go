@@ -0,0 +1,216 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/daedaleanai/reqtraq/config"
+)
+
+// ReqChange describes how a requirement or a code file changed, see Req.ChangedSince.
+type ReqChange struct {
+	ID      string   `json:"id"`
+	Title   string   `json:"title,omitempty"`
+	Changes []string `json:"changes,omitempty"`
+}
+
+// Changelog lists the requirements and code files which changed between two versions.
+type Changelog struct {
+	From     string      `json:"from"`
+	To       string      `json:"to"`
+	Added    []ReqChange `json:"added"`
+	Removed  []ReqChange `json:"removed"` // Including the requirements marked as deleted.
+	Modified []ReqChange `json:"modified"`
+	Code     []ReqChange `json:"code"` // The added, removed and modified code files.
+}
+
+// NewChangelog returns the changes from prg, named from, to rg, named to.
+func NewChangelog(rg, prg reqGraph, from, to string) *Changelog {
+	c := &Changelog{From: from, To: to, Added: []ReqChange{}, Removed: []ReqChange{}, Modified: []ReqChange{}, Code: []ReqChange{}}
+	diffs := rg.ChangedSince(prg)
+	ids := make([]string, 0, len(diffs))
+	for k := range diffs {
+		ids = append(ids, k)
+	}
+	sort.Strings(ids)
+	for _, k := range ids {
+		r := rg[k]
+		if r == nil {
+			r = prg[k]
+		}
+		if r.Level == config.CODE {
+			c.Code = append(c.Code, ReqChange{ID: r.ID, Changes: diffs[k]})
+			continue
+		}
+		change := ReqChange{ID: r.ID, Title: r.Title}
+		switch diffs[k][0] {
+		case "ADDED", "UNDELETED":
+			c.Added = append(c.Added, change)
+		case "MISSING", "DELETED":
+			c.Removed = append(c.Removed, change)
+		default:
+			change.Changes = diffs[k]
+			c.Modified = append(c.Modified, change)
+		}
+	}
+	return c
+}
+
+// Empty returns whether nothing changed.
+func (c *Changelog) Empty() bool {
+	return len(c.Added)+len(c.Removed)+len(c.Modified)+len(c.Code) == 0
+}
+
+// WriteMarkdown writes the changelog as a Markdown section, e.g. for the
+// change section of the Software Accomplishment Summary.
+func (c *Changelog) WriteMarkdown(w io.Writer) {
+	fmt.Fprintf(w, "# Requirement changes from %s to %s\n\n", c.From, c.To)
+	if c.Empty() {
+		fmt.Fprintf(w, "No changes.\n\n")
+		return
+	}
+	section := func(title string, changes []ReqChange) {
+		if len(changes) == 0 {
+			return
+		}
+		fmt.Fprintf(w, "## %s\n\n", title)
+		for _, ch := range changes {
+			if ch.Title != "" {
+				fmt.Fprintf(w, "- %s %s\n", ch.ID, ch.Title)
+			} else {
+				fmt.Fprintf(w, "- %s\n", ch.ID)
+			}
+			for _, d := range ch.Changes {
+				fmt.Fprintf(w, "    - %s\n", d)
+			}
+		}
+		fmt.Fprintln(w)
+	}
+	section("Added", c.Added)
+	section("Removed", c.Removed)
+	section("Modified", c.Modified)
+	section("Code files", c.Code)
+}
+
+// documentGraph returns the requirements of the certification document at the
+// given path, see Req.Path.
+func (rg reqGraph) documentGraph(docPath string) reqGraph {
+	res := reqGraph{}
+	for k, r := range rg {
+		if r.Level != config.CODE && r.Path == docPath {
+			res[k] = r
+		}
+	}
+	return res
+}
+
+// changeRows returns the rows of the requirement change log of a document:
+// the ID, title and change of each requirement.
+func (c *Changelog) changeRows() [][3]string {
+	var rows [][3]string
+	for _, ch := range c.Added {
+		rows = append(rows, [3]string{ch.ID, ch.Title, "Added"})
+	}
+	for _, ch := range c.Removed {
+		rows = append(rows, [3]string{ch.ID, ch.Title, "Removed"})
+	}
+	for _, ch := range c.Modified {
+		rows = append(rows, [3]string{ch.ID, ch.Title, strings.Join(ch.Changes, "; ")})
+	}
+	sort.Slice(rows, func(i, j int) bool { return rows[i][0] < rows[j][0] })
+	return rows
+}
+
+// changeLogTitle is the title of the section appended to the published documents, see WriteMarkdownTable.
+const changeLogTitle = "Requirement Change Log"
+
+// WriteMarkdownTable writes the changelog of the requirements of a document as
+// a Markdown section with a table, to be appended to the published document.
+func (c *Changelog) WriteMarkdownTable(w io.Writer) {
+	fmt.Fprintf(w, "## %s\n\n", changeLogTitle)
+	fmt.Fprintf(w, "Changes of the requirements from %s to %s.\n\n", c.From, c.To)
+	rows := c.changeRows()
+	if len(rows) == 0 {
+		fmt.Fprintf(w, "No changes.\n")
+		return
+	}
+	escape := strings.NewReplacer("|", `\|`, "\n", " ")
+	fmt.Fprintf(w, "| ID | Title | Change |\n|----|-------|--------|\n")
+	for _, row := range rows {
+		fmt.Fprintf(w, "| %s | %s | %s |\n", row[0], escape.Replace(row[1]), escape.Replace(row[2]))
+	}
+}
+
+// WriteLyxTable writes the changelog of the requirements of a document as a
+// LyX section with a table, to be inserted at the end of the body of the
+// published document.
+func (c *Changelog) WriteLyxTable(w io.Writer) {
+	escape := strings.NewReplacer("\\", "\n\\backslash\n", "\n", " ")
+	paragraph := func(layout, text string) {
+		fmt.Fprintf(w, "\\begin_layout %s\n%s\n\\end_layout\n\n", layout, escape.Replace(text))
+	}
+	paragraph("Section", changeLogTitle)
+	paragraph("Standard", fmt.Sprintf("Changes of the requirements from %s to %s.", c.From, c.To))
+	rows := c.changeRows()
+	if len(rows) == 0 {
+		paragraph("Standard", "No changes.")
+		return
+	}
+	rows = append([][3]string{{"ID", "Title", "Change"}}, rows...)
+	fmt.Fprintf(w, "\\begin_layout Standard\n\\begin_inset Tabular\n")
+	fmt.Fprintf(w, "<lyxtabular version=\"3\" rows=\"%d\" columns=\"3\">\n<features tabularvalignment=\"middle\">\n", len(rows))
+	for i := 0; i < 3; i++ {
+		fmt.Fprintf(w, "<column alignment=\"left\" valignment=\"top\">\n")
+	}
+	for _, row := range rows {
+		fmt.Fprintf(w, "<row>\n")
+		for _, cell := range row {
+			fmt.Fprintf(w, "<cell alignment=\"left\" valignment=\"top\" topline=\"true\" bottomline=\"true\" leftline=\"true\" rightline=\"true\" usebox=\"none\">\n")
+			fmt.Fprintf(w, "\\begin_inset Text\n\n")
+			paragraph("Plain Layout", cell)
+			fmt.Fprintf(w, "\\end_inset\n</cell>\n")
+		}
+		fmt.Fprintf(w, "</row>\n")
+	}
+	fmt.Fprintf(w, "</lyxtabular>\n\n\\end_inset\n\n\n\\end_layout\n\n")
+}
+
+// appendChangeLog returns the linkified document with the given contents and
+// format, md or lyx, with the changelog of its requirements appended.
+func appendChangeLog(contents []byte, format string, c *Changelog) []byte {
+	var b bytes.Buffer
+	if format == "md" {
+		b.Write(bytes.TrimRight(contents, "\n"))
+		b.WriteString("\n\n")
+		c.WriteMarkdownTable(&b)
+		return b.Bytes()
+	}
+	end := bytes.LastIndex(contents, []byte("\\end_body"))
+	if end < 0 {
+		end = len(contents)
+	}
+	b.Write(contents[:end])
+	var table bytes.Buffer
+	c.WriteLyxTable(&table)
+	if bytes.Contains(contents, []byte("\r\n")) {
+		b.Write(bytes.ReplaceAll(table.Bytes(), []byte("\n"), []byte("\r\n")))
+	} else {
+		b.Write(table.Bytes())
+	}
+	b.Write(contents[end:])
+	return b.Bytes()
+}
+
+// WriteChangelogsJSON writes the changelogs as json.
+func WriteChangelogsJSON(w io.Writer, cc []*Changelog) error {
+	b, err := json.MarshalIndent(cc, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(b, '\n'))
+	return err
+}
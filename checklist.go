@@ -0,0 +1,116 @@
+package main
+
+import (
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/daedaleanai/reqtraq/config"
+)
+
+// ChecklistConfig configures the review checklists written by the checklist command.
+type ChecklistConfig struct {
+	// Template is the path of a text/template replacing defaultChecklistTemplate.
+	Template string `yaml:"template,omitempty"`
+	// Items are the items the reviewer checks, defaultChecklistItems when empty.
+	Items []string `yaml:"items,omitempty"`
+}
+
+// defaultChecklistItems are the review items of the requirements, following
+// the objectives of DO-178C Table A-3 and A-4.
+var defaultChecklistItems = []string{
+	"The requirement complies with its parent requirements.",
+	"The requirement is accurate, unambiguous and consistent with the other requirements.",
+	"The requirement is verifiable.",
+	"The requirement conforms to the requirements standards.",
+	"The requirement is traceable to its parents, or is justified as derived.",
+	"The algorithms are accurate and their behavior is specified for all inputs.",
+	"The attributes are complete and correct.",
+}
+
+// defaultChecklistTemplate is the Markdown template of the checklists, see checklistData.
+const defaultChecklistTemplate = `# Review checklist: {{ .Req.ID }}
+
+## {{ .Req.ID }} {{ .Req.Title }}
+
+Document: {{ .Req.Path }}
+
+{{ with markdown .Req.Body }}{{ . }}
+
+{{ end }}###### Attributes:
+{{ range $k, $v := .Req.Attributes }}- {{ $k }}: {{ $v }}
+{{ else }}- None
+{{ end }}
+###### Parents:
+{{ range .Req.Parents }}- {{ .ID }} {{ .Title }}
+{{ else }}- None
+{{ end }}
+###### Code:
+{{ range .Code }}- {{ .ID }}
+{{ else }}- None
+{{ end }}
+## Checklist
+
+{{ range .Items }}- [ ] {{ . }}
+{{ end }}
+Reviewer:
+
+Date:
+
+Comments:
+`
+
+// checklistData is the data the checklist templates are executed with.
+type checklistData struct {
+	Req   *Req
+	Code  []*Req   // The code files implementing the requirement, by name.
+	Items []string // The items to check.
+}
+
+// checklistFuncs are the functions available to the checklist templates.
+var checklistFuncs = template.FuncMap{
+	"markdown": func(body htmltemplate.HTML) string { return strings.TrimSpace(formatHTMLAsMarkdown(body)) },
+}
+
+// LoadChecklistTemplate returns the checklist template in the given file, or
+// the default one when fileName is empty.
+func LoadChecklistTemplate(fileName string) (*template.Template, error) {
+	text := defaultChecklistTemplate
+	if fileName != "" {
+		b, err := ioutil.ReadFile(fileName)
+		if err != nil {
+			return nil, err
+		}
+		text = string(b)
+	}
+	name := fileName
+	if name == "" {
+		name = "checklist"
+	}
+	return template.New(name).Funcs(checklistFuncs).Parse(text)
+}
+
+// Checklist writes to w the review checklist of the given requirement,
+// executing the template with its text, attributes, parents and code files,
+// followed by the items to check.
+func (rg reqGraph) Checklist(w io.Writer, tmpl *template.Template, id string, items []string) error {
+	r, ok := rg[id]
+	if !ok || r.Level == config.CODE {
+		return fmt.Errorf("Requirement %s does not exist", id)
+	}
+	if len(items) == 0 {
+		items = defaultChecklistItems
+	}
+	data := checklistData{Req: r, Items: items}
+	for _, c := range r.Children {
+		if c.Level == config.CODE {
+			data.Code = append(data.Code, c)
+		}
+	}
+	sort.Sort(byIDs(data.Code))
+	return tmpl.Execute(w, data)
+}
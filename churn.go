@@ -0,0 +1,182 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/daedaleanai/reqtraq/config"
+	"github.com/daedaleanai/reqtraq/git"
+)
+
+// Churn is the recent change of the code tagged with @llr references to
+// requirements which did not change for long.
+type Churn struct {
+	File     string // Relative to the repo root.
+	Line     int    // The line of the first @llr tag.
+	Function string // The first line of code after the tags, usually a declaration.
+	Reqs     []*Req // The requirements referenced by the tags.
+	Commits  int    // The number of commits which recently changed the code.
+	Lines    int    // The number of lines of the code which were recently changed.
+	ReqTime  time.Time
+}
+
+// taggedRegion is the code following one or more consecutive @llr tags, up to the next tag.
+type taggedRegion struct {
+	start, end int // The lines, as [first, last) indexes.
+	ids        []string
+}
+
+// taggedRegions returns the regions of the given code tagged with @llr references.
+func taggedRegions(lines []string) []taggedRegion {
+	var res []taggedRegion
+	tags := tagReader{re: reLLRReference}
+	for i, l := range lines {
+		ids := tags.ids(l)
+		if ids == nil {
+			continue
+		}
+		if n := len(res); n > 0 && res[n-1].end == i {
+			// The tags of the same code.
+			res[n-1].ids = append(res[n-1].ids, ids...)
+			res[n-1].end = i + 1
+			continue
+		}
+		if n := len(res); n > 0 {
+			res[n-1].end = i
+		}
+		res = append(res, taggedRegion{start: i, end: i + 1, ids: ids})
+	}
+	if n := len(res); n > 0 {
+		res[n-1].end = len(lines)
+	}
+	return res
+}
+
+// regionFunction returns the first line of code after the tags of the region.
+func regionFunction(lines []string, r taggedRegion) string {
+	for _, l := range lines[r.start:r.end] {
+		l = strings.TrimSpace(l)
+		if l != "" && !strings.HasPrefix(l, "//") && !strings.HasPrefix(l, "/*") && !strings.HasPrefix(l, "*") {
+			return l
+		}
+	}
+	return ""
+}
+
+// Churn returns the tagged code changed since the given time whose
+// requirements were all last modified before unchangedSince, the most changed
+// first. The code is read at HEAD and the requirements in the working tree.
+// The requirements of such code are candidates for a review.
+func (rg reqGraph) Churn(since, unchangedSince time.Time) ([]Churn, error) {
+	if err := rg.SetBlame(""); err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, r := range rg {
+		if r.Level == config.CODE {
+			files = append(files, strings.TrimPrefix(r.ID, "/"))
+		}
+	}
+	if len(files) == 0 {
+		return nil, nil
+	}
+	sort.Strings(files)
+	// The code files which are not committed yet have no history.
+	files, err := git.ListFiles("HEAD", files...)
+	if err != nil {
+		return nil, err
+	}
+
+	var res []Churn
+	for _, f := range files {
+		lines, err := git.Blame("HEAD", f)
+		if err != nil {
+			return nil, err
+		}
+		texts := make([]string, len(lines))
+		for i, l := range lines {
+			texts[i] = l.Text
+		}
+	regions:
+		for _, region := range taggedRegions(texts) {
+			c := Churn{File: f, Line: region.start + 1, Function: regionFunction(texts, region)}
+			for _, id := range region.ids {
+				r, ok := rg[id]
+				if !ok || r.Blame == nil || !r.Blame.Time.Before(unchangedSince) {
+					continue regions
+				}
+				c.Reqs = append(c.Reqs, r)
+				if r.Blame.Time.After(c.ReqTime) {
+					c.ReqTime = r.Blame.Time
+				}
+			}
+			for _, l := range lines[region.start:region.end] {
+				if !l.Time.Before(since) {
+					c.Lines++
+				}
+			}
+			if c.Lines == 0 {
+				continue
+			}
+			commits, err := git.LineLog("HEAD", f, region.start+1, region.end, since)
+			if err != nil {
+				return nil, err
+			}
+			c.Commits = len(commits)
+			res = append(res, c)
+		}
+	}
+	sort.SliceStable(res, func(i, j int) bool {
+		if res[i].Commits != res[j].Commits {
+			return res[i].Commits > res[j].Commits
+		}
+		return res[i].Lines > res[j].Lines
+	})
+	return res, nil
+}
+
+// WriteChurnMarkdown writes the churn as a Markdown table.
+func WriteChurnMarkdown(w io.Writer, churn []Churn, since, unchangedSince time.Time) {
+	fmt.Fprintf(w, "# Code churn of unchanged requirements\n\n")
+	if len(churn) == 0 {
+		fmt.Fprintf(w, "No code changed since %s implementing requirements unchanged since %s.\n",
+			since.Format("2006-01-02"), unchangedSince.Format("2006-01-02"))
+		return
+	}
+	fmt.Fprintf(w, "Code changed since %s implementing requirements unchanged since %s.\n\n",
+		since.Format("2006-01-02"), unchangedSince.Format("2006-01-02"))
+	fmt.Fprintf(w, "| Code | Function | Commits | Lines | Requirements | Modified |\n")
+	fmt.Fprintf(w, "|---|---|---|---|---|---|\n")
+	for _, c := range churn {
+		fmt.Fprintf(w, "| %s:%d | %s | %d | %d | %s | %s |\n", c.File, c.Line,
+			strings.ReplaceAll(c.Function, "|", "\\|"), c.Commits, c.Lines,
+			strings.Join(reqIDsOf(c.Reqs), ", "), c.ReqTime.Format("2006-01-02"))
+	}
+}
+
+// WriteChurnJSON writes the churn as json, with the IDs of the requirements.
+func WriteChurnJSON(w io.Writer, churn []Churn) error {
+	type entry struct {
+		File     string
+		Line     int
+		Function string
+		Commits  int
+		Lines    int
+		Reqs     []string
+		ReqTime  time.Time
+	}
+	res := []entry{}
+	for _, c := range churn {
+		res = append(res, entry{c.File, c.Line, c.Function, c.Commits, c.Lines, reqIDsOf(c.Reqs), c.ReqTime})
+	}
+	b, err := json.MarshalIndent(res, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(b, '\n'))
+	return err
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/daedaleanai/reqtraq/git"
+)
+
+// CommitRules configures which changed files require the commit message to
+// reference a requirement, by path relative to the repo root, for example:
+//
+//	{
+//		"paths": {"certdocs": true, "scripts": true, "third_party": false}
+//	}
+//
+// The longest configured path containing a file applies to it. The files not
+// covered by any path require a reference when they are certification
+// documents or code files with @llr references.
+type CommitRules struct {
+	Paths map[string]bool
+}
+
+// ReadCommitRules reads the commit message rules from the given json file.
+func ReadCommitRules(fileName string) (*CommitRules, error) {
+	b, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+	var rules CommitRules
+	if err := json.Unmarshal(b, &rules); err != nil {
+		return nil, fmt.Errorf("Error while parsing commit rules %s: %v", fileName, err)
+	}
+	return &rules, nil
+}
+
+// rule returns whether the file requires a reference according to the
+// longest path containing it, and whether such a path is configured at all.
+func (c *CommitRules) rule(file string) (required, found bool) {
+	longest := -1
+	for p, req := range c.Paths {
+		p = strings.Trim(p, "/")
+		if (p == "" || file == p || strings.HasPrefix(file, p+"/")) && len(p) > longest {
+			longest, required, found = len(p), req, true
+		}
+	}
+	return required, found
+}
+
+// filesRequiringReference returns the files, relative to the repo root, which
+// require the commit message to reference a requirement. contents are the
+// contents of the code files, to find their @llr references.
+func (c *CommitRules) filesRequiringReference(files []string, contents map[string][]byte) []string {
+	var res []string
+	for _, f := range files {
+		required, found := c.rule(f)
+		if !found {
+			switch strings.ToLower(path.Ext(f)) {
+			case ".lyx", ".md":
+				required = IsValidDocName(f) == nil
+			default:
+				reReference, _ := referenceRegexp(f)
+				required = isCodeFile(f) && reReference.Match(contents[f])
+			}
+		}
+		if required {
+			res = append(res, f)
+		}
+	}
+	return res
+}
+
+// commitMessageText returns the commit message without the comments added by git.
+func commitMessageText(msg string) string {
+	var lines []string
+	scan := bufio.NewScanner(strings.NewReader(msg))
+	for scan.Scan() {
+		line := scan.Text()
+		if strings.HasPrefix(line, "# ------------------------ >8 ------------------------") {
+			break
+		}
+		if !strings.HasPrefix(line, "#") {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// CheckCommitMessage checks that the commit message references at least one
+// existing requirement which is not deleted, when any of the given files
+// requires it.
+func (rg reqGraph) CheckCommitMessage(msg string, files []string) error {
+	if len(files) == 0 {
+		return nil
+	}
+	var unknown []string
+	for _, id := range ReReqID.FindAllString(commitMessageText(msg), -1) {
+		if r := rg[id]; r != nil && !r.IsDeleted() {
+			return nil
+		}
+		unknown = append(unknown, id)
+	}
+	sort.Strings(files)
+	err := fmt.Sprintf("Commit message references no requirement, which is required by the changes to: %s.", strings.Join(files, ", "))
+	if len(unknown) > 0 {
+		err += fmt.Sprintf(" Unknown or deleted requirements: %s.", strings.Join(unknown, ", "))
+	}
+	return newFinding("commit_message", "%s\n", err)
+}
+
+// checkCommitMessage checks the message in the given file against the changes
+// staged for the commit, as a git commit-msg hook.
+func checkCommitMessage(msgFile, certdocPath, codePath, rulesFile string) error {
+	msg, err := ioutil.ReadFile(msgFile)
+	if err != nil {
+		return err
+	}
+	rules := &CommitRules{}
+	if r, err := ReadCommitRules(rulesFile); err == nil {
+		rules = r
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+	changed, deleted, err := git.FilesChangedInIndex()
+	if err != nil {
+		return err
+	}
+	// The staged contents of the files are the ones being committed, and the
+	// deleted files are read as last committed, to find their references.
+	contents := map[string][]byte{}
+	read := func(commit string, files []string) error {
+		var code []string
+		for _, f := range files {
+			if isCodeFile(f) {
+				code = append(code, f)
+			}
+		}
+		return git.ReadFiles(commit, code, func(file string, b []byte) error {
+			contents[file] = b
+			return nil
+		})
+	}
+	if err := read("", changed); err != nil {
+		return err
+	}
+	if err := read("HEAD", deleted); err != nil {
+		return err
+	}
+	files := rules.filesRequiringReference(append(changed, deleted...), contents)
+	if len(files) == 0 {
+		return nil
+	}
+	// The problems found in the documents are reported by precommit.
+	rg, _ := CreateReqGraph(certdocPath, codePath)
+	return rg.CheckCommitMessage(string(msg), files)
+}
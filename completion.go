@@ -0,0 +1,223 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/daedaleanai/reqtraq/git"
+)
+
+// The commands offered by the shell completion, see usage.
+var commands = []string{"annotate", "apply", "bom", "check", "checklinks", "checklist", "churn", "commitmsg", "completion", "config", "convert", "dashboard", "deporder", "diff", "doctrace", "export", "extract", "extract-graph", "fmt", "gen-tests", "grpc", "hash", "help", "idheader", "import", "linkify", "list", "manifest", "merge-graph", "newdoc", "nextid",
+	"package", "precommit", "prepush", "publish", "query", "reportdown", "reportissues", "reportowners", "reporttargets", "reportup", "rollup", "similar", "snapshot", "staleness", "suggest", "testcases", "trend", "tui", "updatetasks", "validate", "version", "view", "web"}
+
+// The shell completion scripts call "reqtraq __complete <words>" with the words
+// of the command line following "reqtraq", the last one being the word being
+// completed, and offer the printed candidates.
+const bashCompletion = `# bash completion for reqtraq, install with:
+#	reqtraq completion bash > /etc/bash_completion.d/reqtraq
+_reqtraq() {
+	local line="${COMP_LINE:0:$COMP_POINT}"
+	local cur="${line##*[[:space:]]}"
+	local words=(${line})
+	[ -z "$cur" ] && words+=("")
+	local IFS=$'\n'
+	COMPREPLY=($(reqtraq __complete "${words[@]:1}" 2>/dev/null))
+	# bash considers what follows the "=" of "--flag=value" as a separate word.
+	if [[ "$cur" == *=* && "$COMP_WORDBREAKS" == *=* ]]; then
+		COMPREPLY=("${COMPREPLY[@]#"${cur%=*}="}")
+	fi
+}
+complete -o default -F _reqtraq reqtraq
+`
+
+const zshCompletion = `#compdef reqtraq
+# zsh completion for reqtraq, install with:
+#	reqtraq completion zsh > "${fpath[1]}/_reqtraq"
+_reqtraq() {
+	local -a candidates
+	candidates=("${(@f)$(reqtraq __complete "${(@)words[2,CURRENT]}" 2>/dev/null)}")
+	if [[ -n "${candidates[1]}" ]]; then
+		compadd -S '' -- "${candidates[@]}"
+	else
+		_files
+	fi
+}
+compdef _reqtraq reqtraq
+`
+
+const fishCompletion = `# fish completion for reqtraq, install with:
+#	reqtraq completion fish > ~/.config/fish/completions/reqtraq.fish
+complete -c reqtraq -a '(reqtraq __complete (commandline -opc)[2..-1] (commandline -ct) 2>/dev/null)'
+`
+
+// CompletionScript returns the completion script for the given shell.
+func CompletionScript(shell string) (string, error) {
+	switch shell {
+	case "bash":
+		return bashCompletion, nil
+	case "zsh":
+		return zshCompletion, nil
+	case "fish":
+		return fishCompletion, nil
+	}
+	return "", fmt.Errorf("Unknown shell %q, expected bash, zsh or fish", shell)
+}
+
+// Complete returns the candidates for the last of the given command line
+// words. The flags already present on the command line, e.g. --certdoc_path,
+// are taken into account when looking for certification documents.
+func Complete(words []string) []string {
+	if len(words) == 0 {
+		words = []string{""}
+	}
+	cur := words[len(words)-1]
+	var positional []string
+	for _, w := range words[:len(words)-1] {
+		if !strings.HasPrefix(w, "-") {
+			positional = append(positional, w)
+			continue
+		}
+		if i := strings.Index(w, "="); i >= 0 {
+			if f := flag.Lookup(strings.TrimLeft(w[:i], "-")); f != nil {
+				f.Value.Set(w[i+1:])
+			}
+		}
+	}
+
+	var candidates []string
+	switch {
+	case strings.HasPrefix(cur, "-") && strings.Contains(cur, "="):
+		name := cur[:strings.Index(cur, "=")+1]
+		var values []string
+		switch strings.TrimLeft(name, "-") {
+		case "doc=":
+			for _, p := range certdocPaths() {
+				values = append(values, filepath.Base(p))
+			}
+		case "id_filter=":
+			values = reqIDs()
+		case "tag=":
+			values = repoConfig.Tags
+		case "format=":
+			values = []string{"md", "pdf"}
+		case "type=":
+			for t := range docNameConventions {
+				values = append(values, t)
+			}
+			sort.Strings(values)
+		case "lang=":
+			for l := range catalogs {
+				values = append(values, l)
+			}
+			sort.Strings(values)
+		}
+		for _, v := range values {
+			candidates = append(candidates, name+v)
+		}
+	case strings.HasPrefix(cur, "-"):
+		flag.VisitAll(func(f *flag.Flag) {
+			if b, ok := f.Value.(interface {
+				IsBoolFlag() bool
+			}); ok && b.IsBoolFlag() {
+				candidates = append(candidates, "--"+f.Name)
+			} else {
+				candidates = append(candidates, "--"+f.Name+"=")
+			}
+		})
+	case len(positional) == 0:
+		candidates = commands
+	case positional[0] == "gen-tests":
+		candidates = reqIDs()
+	case len(positional) == 1:
+		switch positional[0] {
+		case "help":
+			candidates = commands
+		case "completion":
+			candidates = []string{"bash", "fish", "zsh"}
+		case "config":
+			candidates = []string{"migrate", "validate"}
+		case "export", "apply":
+			candidates = []string{"xlsx"}
+		case "import":
+			candidates = []string{"csv"}
+		case "manifest":
+			candidates = []string{"verify"}
+		case "rollup":
+			candidates = repoConfig.numericAttributes()
+		case "view":
+			for name := range repoConfig.Views {
+				candidates = append(candidates, name)
+			}
+			sort.Strings(candidates)
+		case "convert", "fmt", "linkify", "list", "nextid":
+			cwd, err := os.Getwd()
+			if err != nil {
+				return nil
+			}
+			for _, p := range certdocPaths() {
+				if rel, err := filepath.Rel(cwd, p); err == nil {
+					candidates = append(candidates, rel)
+				}
+			}
+		}
+	}
+
+	var res []string
+	for _, c := range candidates {
+		if strings.HasPrefix(c, cur) {
+			res = append(res, c)
+		}
+	}
+	return res
+}
+
+// certdocPaths returns the paths of the certification documents in the certdoc paths.
+func certdocPaths() []string {
+	repoPath, err := git.RepoPath()
+	if err != nil {
+		// Nothing to complete outside of a repository.
+		return nil
+	}
+	var res []string
+	for _, root := range certdocRoots(*fCertdocPath) {
+		_ = filepath.Walk(filepath.Join(repoPath, root),
+			func(fileName string, info os.FileInfo, err error) error {
+				switch strings.ToLower(path.Ext(fileName)) {
+				case ".lyx", ".md":
+					if !isGenerated(repoPath, fileName) {
+						res = append(res, fileName)
+					}
+				}
+				return nil
+			})
+	}
+	return res
+}
+
+// reqIDs returns the IDs found in the certification documents. The documents
+// are only scanned for IDs instead of being parsed, to keep completion fast.
+func reqIDs() []string {
+	seen := map[string]bool{}
+	var res []string
+	for _, p := range certdocPaths() {
+		b, err := ioutil.ReadFile(p)
+		if err != nil {
+			continue
+		}
+		for _, id := range ReReqID.FindAllString(string(b), -1) {
+			if !seen[id] {
+				seen[id] = true
+				res = append(res, id)
+			}
+		}
+	}
+	sort.Strings(res)
+	return res
+}
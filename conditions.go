@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// reAttributeCondition matches the conditions making an attribute mandatory,
+// e.g. "Safety Impact != None", or "Verification =~ Test" for a regular
+// expression.
+var reAttributeCondition = regexp.MustCompile(`^\s*([^=!~]*[^=!~\s])\s*(==|!=|=~|!~)\s*(.*?)\s*$`)
+
+// attributeCondition is the condition under which an attribute is mandatory,
+// see the "when" of the attribute specification.
+type attributeCondition struct {
+	name  string // The attribute the condition is on, upper case.
+	op    string // One of ==, !=, =~ and !~.
+	value string
+	re    *regexp.Regexp // For =~ and !~.
+}
+
+// parseAttributeCondition parses a condition such as "Safety Impact != None".
+func parseAttributeCondition(s string) (*attributeCondition, error) {
+	m := reAttributeCondition.FindStringSubmatch(s)
+	if m == nil {
+		return nil, fmt.Errorf("invalid condition %q, expected e.g. \"Safety Impact != None\"", s)
+	}
+	c := &attributeCondition{name: strings.ToUpper(m[1]), op: m[2], value: m[3]}
+	if c.op == "=~" || c.op == "!~" {
+		re, err := regexp.Compile(c.value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid condition %q: %v", s, err)
+		}
+		c.re = re
+	}
+	return c, nil
+}
+
+// holds returns whether the condition holds for the requirement. A condition
+// on an attribute the requirement does not have does not hold.
+func (c *attributeCondition) holds(r *Req) bool {
+	v, ok := r.attribute(c.name)
+	if !ok {
+		return false
+	}
+	switch c.op {
+	case "==":
+		return strings.EqualFold(v, c.value)
+	case "!=":
+		return !strings.EqualFold(v, c.value)
+	case "=~":
+		return c.re.MatchString(v)
+	default:
+		return !c.re.MatchString(v)
+	}
+}
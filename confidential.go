@@ -0,0 +1,151 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"html/template"
+	"io"
+	"io/ioutil"
+	"os/exec"
+	"regexp"
+	"strings"
+
+	"github.com/daedaleanai/reqtraq/config"
+)
+
+// encryptedHeaders are the starts of the files encrypted by git-crypt and by
+// age, in binary and in armored form.
+var encryptedHeaders = []string{"\x00GITCRYPT\x00", "age-encryption.org/v1\n", "-----BEGIN AGE ENCRYPTED FILE-----"}
+
+func isEncrypted(contents []byte) bool {
+	for _, h := range encryptedHeaders {
+		if bytes.HasPrefix(contents, []byte(h)) {
+			return true
+		}
+	}
+	return false
+}
+
+// decrypt returns the contents of the certification document, decrypted by
+// the command configured in reqtraq.yaml when encrypted, see isEncrypted. The
+// command reads the encrypted contents on stdin and writes them decrypted on
+// stdout.
+func decrypt(fileName string, contents []byte) ([]byte, error) {
+	if !isEncrypted(contents) {
+		return contents, nil
+	}
+	if repoConfig.Decrypt == "" {
+		return nil, fmt.Errorf("%s is encrypted, set the decrypt command in %s", fileName, repoConfigName)
+	}
+	cmd := exec.Command("sh", "-c", repoConfig.Decrypt)
+	cmd.Stdin = bytes.NewReader(contents)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("Failed to decrypt %s: %v: %s", fileName, err, strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
+
+// readCertdoc returns the contents of the certification document, decrypted
+// if needed, see decrypt.
+func readCertdoc(fileName string) ([]byte, error) {
+	b, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+	return decrypt(fileName, b)
+}
+
+// openCertdoc returns a reader of the contents of the certification document,
+// decrypted if needed, see decrypt.
+func openCertdoc(fileName string) (io.Reader, error) {
+	b, err := readCertdoc(fileName)
+	if err != nil {
+		return nil, err
+	}
+	return bytes.NewReader(b), nil
+}
+
+// exportControlledTag is the tag of the requirements whose bodies are omitted
+// from the reports for external parties, see OmitExportControlled.
+const exportControlledTag = "EXPORT-CONTROLLED"
+
+var reExportControlled = regexp.MustCompile("(?i)^" + exportControlledTag + "$")
+
+// isExportControlled returns whether the requirement has the EXPORT-CONTROLLED tag.
+func (r *Req) isExportControlled() bool {
+	return r.Level != config.CODE && r.hasTag(reExportControlled)
+}
+
+// ExportControlledDocuments returns the paths, relative to the repository root,
+// of the certification documents defining export-controlled requirements.
+func (rg reqGraph) ExportControlledDocuments() map[string]bool {
+	docs := map[string]bool{}
+	for _, r := range rg {
+		if r.isExportControlled() {
+			docs[repoRelative(r.Path)] = true
+		}
+	}
+	return docs
+}
+
+// OmitExportControlled replaces the bodies of the requirements tagged
+// EXPORT-CONTROLLED, and their translations, with a notice, so the reports can
+// be given to external parties.
+func (rg reqGraph) OmitExportControlled() {
+	for _, r := range rg {
+		if !r.isExportControlled() {
+			continue
+		}
+		r.Body = template.HTML("<p><em>" + template.HTMLEscapeString(translate(*fLang, "Omitted, export-controlled.")) + "</em></p>")
+		for a := range r.Attributes {
+			if strings.HasPrefix(a, "BODY (") {
+				delete(r.Attributes, a)
+			}
+		}
+	}
+}
+
+// redactedHash returns the hash replacing a redacted text, the same for the
+// same text, so the changes can still be told apart.
+func redactedHash(text string) string {
+	sum := sha256.Sum256([]byte(text))
+	return "sha256:" + hex.EncodeToString(sum[:8])
+}
+
+// Redact replaces the bodies of the requirements, their translations and the
+// values of the attributes having "sensitive: true" in the configuration with
+// their hashes, see redactedHash, so the traceability status can be shared
+// without revealing the requirements. The titles, the links and the coverage
+// are kept.
+func (rg reqGraph) Redact() {
+	for _, r := range rg {
+		if r.Level == config.CODE {
+			continue
+		}
+		if r.Body != "" {
+			r.Body = template.HTML("<p><code>" + redactedHash(string(r.Body)) + "</code></p>")
+		}
+		for a, v := range r.Attributes {
+			if strings.HasPrefix(a, "BODY (") {
+				r.Attributes[a] = redactedHash(v)
+			}
+		}
+		for _, a := range repoConfig.Attributes {
+			if !a.Sensitive {
+				continue
+			}
+			key := normalizeAttribute(a.Name)
+			if v, ok := r.Attributes[key]; ok && v != "" {
+				r.Attributes[key] = redactedHash(v)
+			}
+			if v, ok := r.Inherited[key]; ok && v != "" {
+				r.Inherited[key] = redactedHash(v)
+			}
+		}
+	}
+}
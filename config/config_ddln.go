@@ -4,8 +4,12 @@
 
 package config
 
-// Project name
-const ProjectName = "Reqtraq"
+// Project name, can be changed in reqtraq.yaml.
+var ProjectName = "Reqtraq"
+
+// Base URL of the published certification documents, used for the links to requirements added by linkify.
+// Can be changed in reqtraq.yaml.
+var DocsURL = "http://a.daedalean.ai/docs"
 
 type RequirementLevel int
 
@@ -109,3 +113,26 @@ var DocTypeToDocId = map[string]string{
 	"TPSFHA": "211",
 	"TPFFPA": "212",
 }
+
+// DocRelation is a relationship between the documents of two types, e.g. the
+// SDD implements the SRD, as shown by the document-level trace.
+type DocRelation struct {
+	From, Kind, To string
+}
+
+// Relationships between the documents of a project, by document type.
+var DocTypeRelations = []DocRelation{
+	{"SRD", "implements", "ORD"},
+	{"HRD", "implements", "ORD"},
+	{"SDD", "implements", "SRD"},
+	{"HDD", "implements", "HRD"},
+	{"SVCP", "verifies", "SRD"},
+	{"SVCP", "verifies", "SDD"},
+	{"SRD", "satisfies", "SDP"},
+	{"SDD", "satisfies", "SDP"},
+	{"SVCP", "satisfies", "SVP"},
+	{"SDP", "satisfies", "PSAC"},
+	{"SVP", "satisfies", "PSAC"},
+	{"SCMP", "satisfies", "PSAC"},
+	{"SQAP", "satisfies", "PSAC"},
+}
@@ -0,0 +1,655 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/daedaleanai/reqtraq/config"
+	"github.com/daedaleanai/reqtraq/git"
+	"github.com/daedaleanai/reqtraq/taskmgr"
+	"go.yaml.in/yaml/v3"
+)
+
+// repoConfigName is the name of the configuration file at the root of the
+// repo, and of the fragments overriding it in the subdirectories.
+const repoConfigName = "reqtraq.yaml"
+
+// repoConfigVersion is the version of the reqtraq.yaml schema, to be increased
+// with a new entry in configMigrations when the schema changes.
+const repoConfigVersion = 1
+
+// RepoConfig is the configuration of reqtraq for a repository, read from
+// reqtraq.yaml at its root, for example:
+//
+//	version: 1
+//	project: Reqtraq
+//	docs_url: http://a.daedalean.ai/docs
+//	certdoc_path: certdocs
+//	attributes:
+//	  - name: Verification
+//	    value: (Demonstration|Unit [Tt]est|[Tt]est)
+//	  - name: Safety Impact
+//	  - name: DAL
+//	    inherit: true
+//	  - name: Mitigation
+//	    when: Safety Impact != None
+//	roster: certdocs/roster.json
+//	sources: ["*.go", "*.py"]
+//	tags: [navigation, datalink]
+//	taskmgr_token: ${PHABRICATOR_TOKEN}
+//
+// The settings are the defaults of the flags with the same names, which still
+// take precedence. The paths are relative to the repo root. The values can
+// refer to environment variables, see expandConfigVars, so the same file
+// works in the development environments, in CI and on the web server.
+//
+// The subdirectories of the certification documents and of the code can have
+// their own reqtraq.yaml, overriding the attributes and the sources for the
+// files below them, see configTree.
+type RepoConfig struct {
+	Version     int             `yaml:"version"`
+	Project     string          `yaml:"project,omitempty"`
+	DocsURL     string          `yaml:"docs_url,omitempty"`
+	CertdocPath string          `yaml:"certdoc_path,omitempty"`
+	CodePath    string          `yaml:"code_path,omitempty"`
+	Attributes  []AttributeSpec `yaml:"attributes,omitempty"`
+	Roster      string          `yaml:"roster,omitempty"`
+	Waivers     string          `yaml:"waivers,omitempty"`
+	CommitRules string          `yaml:"commit_rules,omitempty"`
+	Lang        string          `yaml:"lang,omitempty"`
+	// CertdocPaths are more directories of certification documents, e.g. of
+	// subcomponents, whose documents are merged into one graph with those of
+	// CertdocPath.
+	CertdocPaths []string `yaml:"certdoc_paths,omitempty"`
+	// Sources are the patterns of the names of the code files, e.g. *.go.
+	// By default the C, C++ and Go files and the hardware design artifacts,
+	// see isCodeFile and isHWDesignFile.
+	Sources []string `yaml:"sources,omitempty"`
+	// Generated are the patterns of the generated certification documents,
+	// e.g. certdocs/*-linked.lyx, which are not parsed, see isGenerated.
+	Generated []string `yaml:"generated,omitempty"`
+	// Tags are the tags the requirements can have, any when empty, see Req.Tags.
+	Tags []string `yaml:"tags,omitempty"`
+	// TaskmgrToken is the API token of the task manager, better given with a
+	// variable than committed.
+	TaskmgrToken string `yaml:"taskmgr_token,omitempty"`
+	// StaleDays is the default of --stale-days, see the staleness command.
+	StaleDays int `yaml:"stale_days,omitempty"`
+	// MinCoverage and MinBranchCoverage are the defaults of --min-coverage and
+	// --min-branch-coverage, in percent.
+	MinCoverage       float64 `yaml:"min_coverage,omitempty"`
+	MinBranchCoverage float64 `yaml:"min_branch_coverage,omitempty"`
+	// Checklist configures the review checklists, see the checklist command.
+	Checklist ChecklistConfig `yaml:"checklist,omitempty"`
+	// Style configures the checks of the structure of the requirements, see CheckStyle.
+	Style StyleConfig `yaml:"style,omitempty"`
+	// InferParents is whether the requirements of the Markdown documents
+	// nested under the heading of a parent requirement have it as parent,
+	// see headingParents.
+	InferParents bool `yaml:"infer_parents,omitempty"`
+	// Aliases are the current IDs of the requirements by their legacy IDs,
+	// which the code can still reference, see CheckAliases.
+	Aliases map[string]string `yaml:"aliases,omitempty"`
+	// TagPlacement are the placements allowed for the @llr tags of the code,
+	// header and function, any when empty, see CheckTagPlacement.
+	TagPlacement []string `yaml:"tag_placement,omitempty"`
+	// Terminology maps the canonical terms to their synonyms, whose use is
+	// reported by precommit, see CheckTerminology.
+	Terminology map[string][]string `yaml:"terminology,omitempty"`
+	// Suggest configures the endpoint drafting the children of the
+	// requirements, see the suggest command.
+	Suggest SuggestConfig `yaml:"suggest,omitempty"`
+	// Views are the named queries, see the view command.
+	Views map[string]ViewConfig `yaml:"views,omitempty"`
+	// Priorities are the values of the PRIORITY attribute, the highest first,
+	// see PriorityStats. Any value is allowed when empty.
+	Priorities []string `yaml:"priorities,omitempty"`
+	// COTS configures the off-the-shelf and previously developed code, see COTSReqs.
+	COTS COTSConfig `yaml:"cots,omitempty"`
+	// Publish configures the conversion of the certification documents to
+	// PDF, see the publish command.
+	Publish PublishConfig `yaml:"publish,omitempty"`
+	// Decrypt is the shell command decrypting the encrypted certification
+	// documents, e.g. "age -d -i key.txt", see decrypt.
+	Decrypt string `yaml:"decrypt,omitempty"`
+}
+
+// repoConfig is the configuration at the root of the repo, see applyRepoConfig.
+var repoConfig = &RepoConfig{Version: repoConfigVersion}
+
+// AttributeSpec specifies an attribute every requirement must have, see JsonConf.
+type AttributeSpec struct {
+	Name string `yaml:"name"`
+	// Value is the regular expression the value must match, if any.
+	Value string `yaml:"value,omitempty"`
+	// Optional is whether the requirements can omit the attribute, e.g. to
+	// relax in a subdirectory an attribute required by the parent directory.
+	Optional bool `yaml:"optional,omitempty"`
+	// Inherit is whether the requirements without the attribute take the
+	// value of their parents, see InheritAttributes.
+	Inherit bool `yaml:"inherit,omitempty"`
+	// When is the condition under which the attribute is mandatory, e.g.
+	// "Safety Impact != None", see parseAttributeCondition.
+	When string `yaml:"when,omitempty"`
+	// Type is the type of the values, text by default, int or float for the
+	// numbers aggregated by the rollup command, enum, date or ref, see
+	// parseAttributeValue.
+	Type string `yaml:"type,omitempty"`
+	// Values are the values of an enum, in their order.
+	Values []string `yaml:"values,omitempty"`
+	// Normalize is whether the values are normalized when parsed and by the
+	// fmt command, see normalizeAttributeValue.
+	Normalize bool `yaml:"normalize,omitempty"`
+	// Sensitive is whether the values are replaced by their hashes in the
+	// redacted reports, see Redact.
+	Sensitive bool `yaml:"sensitive,omitempty"`
+}
+
+// configMigrations upgrade a configuration, by version, to the next version.
+var configMigrations = map[int]func(m map[string]interface{}){
+	// Version 0 is the attribute specification in JSON, e.g. certdocs/attributes.json,
+	// the other settings being given on the command line.
+	0: func(m map[string]interface{}) {
+		// The paths cannot be made relative outside of a repository, they are dropped.
+		repoPath, _ := git.RepoPath()
+		defaults := map[string]string{
+			"project":      config.ProjectName,
+			"docs_url":     config.DocsURL,
+			"certdoc_path": *fCertdocPath,
+			"code_path":    *fCodePath,
+			"roster":       relativePathToRepo(*fRoster, repoPath),
+			"commit_rules": relativePathToRepo(*fCommitRules, repoPath),
+			"lang":         *fLang,
+		}
+		for k, v := range defaults {
+			if _, ok := m[k]; !ok && v != "" {
+				m[k] = v
+			}
+		}
+	},
+}
+
+// configError returns an error pointing at the line of the configuration file.
+func configError(fileName string, line int, format string, args ...interface{}) error {
+	return fmt.Errorf("%s:%d: %s", fileName, line, fmt.Sprintf(format, args...))
+}
+
+// LoadRepoConfig reads and validates the configuration file. The errors
+// point at the lines of the file, e.g. for unknown keys or invalid regular
+// expressions. An outdated configuration is refused, see MigrateRepoConfig.
+func LoadRepoConfig(fileName string) (*RepoConfig, error) {
+	b, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+	var root yaml.Node
+	if err := yaml.Unmarshal(b, &root); err != nil {
+		return nil, fmt.Errorf("%s: %v", fileName, err)
+	}
+	if len(root.Content) == 0 || root.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("%s: the configuration must be a mapping", fileName)
+	}
+	doc := root.Content[0]
+	version := configValue(doc, "version")
+	switch {
+	case version == nil:
+		return nil, fmt.Errorf("%s: missing version, run \"reqtraq config migrate\" to upgrade it", fileName)
+	case version.Value != fmt.Sprint(repoConfigVersion):
+		return nil, configError(fileName, version.Line, "version %s is not supported, this reqtraq reads version %d, run \"reqtraq config migrate\" to upgrade it",
+			version.Value, repoConfigVersion)
+	}
+
+	c, err := decodeRepoConfig(fileName, b)
+	if err != nil {
+		return nil, err
+	}
+	// The keys have been checked on the text as written, with their lines.
+	if errs := expandConfigVars(fileName, doc); len(errs) > 0 {
+		return nil, fmt.Errorf("%s", strings.Join(errs, "\n"))
+	}
+	c = &RepoConfig{}
+	if err := doc.Decode(c); err != nil {
+		return nil, fmt.Errorf("%s: %v", fileName, err)
+	}
+
+	var errs []string
+	if attrs := configValue(doc, "attributes"); attrs != nil {
+		for i, a := range c.Attributes {
+			n := attrs.Content[i]
+			if a.Name == "" {
+				errs = append(errs, configError(fileName, n.Line, "attribute without name").Error())
+			}
+			if _, err := regexp.Compile(a.Value); err != nil {
+				if v := configValue(n, "value"); v != nil {
+					n = v
+				}
+				errs = append(errs, configError(fileName, n.Line, "invalid value of attribute %q: %v", a.Name, err).Error())
+			}
+			if a.When != "" {
+				if _, err := parseAttributeCondition(a.When); err != nil {
+					errs = append(errs, configError(fileName, configValue(attrs.Content[i], "when").Line, "attribute %q has %v", a.Name, err).Error())
+				}
+			}
+			if !isAttributeType(a.Type) {
+				errs = append(errs, configError(fileName, configValue(attrs.Content[i], "type").Line, "attribute %q has unknown type %q, expected %s",
+					a.Name, a.Type, strings.Join(attributeTypes[1:], ", ")).Error())
+			} else if a.Type == attributeEnum && len(a.Values) == 0 {
+				errs = append(errs, configError(fileName, configValue(attrs.Content[i], "type").Line, "attribute %q of type enum without values", a.Name).Error())
+			} else if a.Type != attributeEnum && len(a.Values) > 0 {
+				errs = append(errs, configError(fileName, configValue(attrs.Content[i], "values").Line, "attribute %q has values but is not of type enum", a.Name).Error())
+			}
+		}
+	}
+	if sources := configValue(doc, "sources"); sources != nil {
+		for i, p := range c.Sources {
+			if _, err := path.Match(p, ""); err != nil {
+				errs = append(errs, configError(fileName, sources.Content[i].Line, "invalid source pattern %q: %v", p, err).Error())
+			}
+		}
+	}
+	if generated := configValue(doc, "generated"); generated != nil {
+		for i, p := range c.Generated {
+			if _, err := path.Match(p, ""); err != nil {
+				errs = append(errs, configError(fileName, generated.Content[i].Line, "invalid generated pattern %q: %v", p, err).Error())
+			}
+		}
+	}
+	if style := configValue(doc, "style"); style != nil {
+		if forbidden := configValue(style, "forbidden"); forbidden != nil {
+			for i, f := range c.Style.Forbidden {
+				if _, err := regexp.Compile(f); err != nil {
+					errs = append(errs, configError(fileName, forbidden.Content[i].Line, "invalid forbidden phrase %q: %v", f, err).Error())
+				}
+			}
+		}
+	}
+	if aliases := configValue(doc, "aliases"); aliases != nil {
+		for i := 0; i+1 < len(aliases.Content); i += 2 {
+			for _, n := range aliases.Content[i : i+2] {
+				if !ReReqID.MatchString(n.Value) || ReReqID.FindString(n.Value) != n.Value {
+					errs = append(errs, configError(fileName, n.Line, "invalid requirement ID %q in aliases", n.Value).Error())
+				}
+			}
+		}
+	}
+	if placement := configValue(doc, "tag_placement"); placement != nil {
+		for i, p := range c.TagPlacement {
+			if _, ok := tagPlacementDescriptions[p]; !ok {
+				errs = append(errs, configError(fileName, placement.Content[i].Line, "invalid tag placement %q, expected %s or %s", p, placementHeader, placementFunction).Error())
+			}
+		}
+	}
+	if views := configValue(doc, "views"); views != nil {
+		for i := 0; i+1 < len(views.Content); i += 2 {
+			name, n := views.Content[i].Value, views.Content[i+1]
+			v := c.Views[name]
+			if v.Query == "" {
+				errs = append(errs, configError(fileName, n.Line, "view %q without query", name).Error())
+			} else if _, err := reqGraph(nil).ParseQuery(v.Query); err != nil {
+				errs = append(errs, configError(fileName, configValue(n, "query").Line, "view %q has %v", name, err).Error())
+			}
+			if !isViewFormat(v.Format) {
+				errs = append(errs, configError(fileName, configValue(n, "format").Line, "view %q has unknown format %q, expected %s", name, v.Format, strings.Join(viewFormats, ", ")).Error())
+			}
+		}
+	}
+	if priorities := configValue(doc, "priorities"); priorities != nil {
+		seen := map[string]bool{}
+		for i, p := range c.Priorities {
+			if p == "" || seen[p] {
+				errs = append(errs, configError(fileName, priorities.Content[i].Line, "empty or duplicate priority %q", p).Error())
+			}
+			seen[p] = true
+		}
+	}
+	if c.Lang != "" {
+		if err := checkLang(c.Lang); err != nil {
+			errs = append(errs, configError(fileName, configValue(doc, "lang").Line, "%v", err).Error())
+		}
+	}
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("%s", strings.Join(errs, "\n"))
+	}
+	return c, nil
+}
+
+// reConfigVar matches the references to environment variables in the
+// configuration values, e.g. ${CI_PAGES_URL} or ${LANG:-en} with a default.
+var reConfigVar = regexp.MustCompile(`\$\{(\w+)(:-([^}]*))?\}`)
+
+// expandConfigVars replaces in the values of the configuration the references
+// to environment variables with their values. An undefined variable without
+// default is an error, and "$${" is an escaped "${".
+func expandConfigVars(fileName string, n *yaml.Node) []string {
+	var errs []string
+	switch n.Kind {
+	case yaml.ScalarNode:
+		parts := strings.Split(n.Value, "$${")
+		for i, p := range parts {
+			parts[i] = reConfigVar.ReplaceAllStringFunc(p, func(ref string) string {
+				m := reConfigVar.FindStringSubmatch(ref)
+				if v, ok := os.LookupEnv(m[1]); ok {
+					return v
+				}
+				if m[2] == "" {
+					errs = append(errs, configError(fileName, n.Line, "environment variable %s is not set", m[1]).Error())
+				}
+				return m[3]
+			})
+		}
+		n.Value = strings.Join(parts, "${")
+	case yaml.MappingNode:
+		// The keys are left as they are.
+		for i := 1; i < len(n.Content); i += 2 {
+			errs = append(errs, expandConfigVars(fileName, n.Content[i])...)
+		}
+	default:
+		for _, c := range n.Content {
+			errs = append(errs, expandConfigVars(fileName, c)...)
+		}
+	}
+	return errs
+}
+
+// reUnknownKey matches the errors of the yaml decoder about unknown keys.
+var reUnknownKey = regexp.MustCompile(`line (\d+): field (\S+) not found in type \S+`)
+
+// decodeRepoConfig decodes the configuration, refusing the unknown keys.
+func decodeRepoConfig(fileName string, b []byte) (*RepoConfig, error) {
+	var c RepoConfig
+	dec := yaml.NewDecoder(bytes.NewReader(b))
+	dec.KnownFields(true)
+	if err := dec.Decode(&c); err != nil {
+		var errs []string
+		if te, ok := err.(*yaml.TypeError); ok {
+			for _, e := range te.Errors {
+				if m := reUnknownKey.FindStringSubmatch(e); m != nil {
+					e = fmt.Sprintf("%s:%s: unknown key %q", fileName, m[1], m[2])
+				} else {
+					e = fmt.Sprintf("%s: %s", fileName, e)
+				}
+				errs = append(errs, e)
+			}
+			return nil, fmt.Errorf("%s", strings.Join(errs, "\n"))
+		}
+		return nil, fmt.Errorf("%s: %v", fileName, strings.TrimPrefix(err.Error(), "yaml: "))
+	}
+	return &c, nil
+}
+
+// configValue returns the value of the key of the mapping node, nil if missing.
+func configValue(n *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		if n.Content[i].Value == key {
+			return n.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// MigrateRepoConfig returns the configuration in the given file, in YAML or
+// JSON, upgraded to the current version.
+func MigrateRepoConfig(fileName string) ([]byte, error) {
+	b, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+	m := map[string]interface{}{}
+	if err := yaml.Unmarshal(b, &m); err != nil {
+		return nil, fmt.Errorf("%s: %v", fileName, err)
+	}
+	version := 0
+	if v, ok := m["version"]; ok {
+		if version, ok = v.(int); !ok {
+			return nil, fmt.Errorf("%s: invalid version %v", fileName, v)
+		}
+	}
+	if version > repoConfigVersion {
+		return nil, fmt.Errorf("%s: version %d is newer than the version %d this reqtraq reads", fileName, version, repoConfigVersion)
+	}
+	for ; version < repoConfigVersion; version++ {
+		configMigrations[version](m)
+	}
+	m["version"] = repoConfigVersion
+
+	// The keys are written in the order of RepoConfig.
+	b, err = yaml.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	c, err := decodeRepoConfig(fileName, b)
+	if err != nil {
+		return nil, err
+	}
+	var out bytes.Buffer
+	enc := yaml.NewEncoder(&out)
+	enc.SetIndent(2)
+	if err := enc.Encode(c); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), enc.Close()
+}
+
+// AttributeMaps returns the attribute specification in the format of JsonConf.
+func (c *RepoConfig) AttributeMaps() []map[string]string {
+	var res []map[string]string
+	for _, a := range c.Attributes {
+		m := map[string]string{"name": a.Name}
+		if a.Value != "" {
+			m["value"] = a.Value
+		}
+		if a.Optional {
+			m["optional"] = "true"
+		}
+		if a.Inherit {
+			m["inherit"] = "true"
+		}
+		if a.When != "" {
+			m["when"] = a.When
+		}
+		if a.Type != "" {
+			m["type"] = a.Type
+		}
+		if len(a.Values) > 0 {
+			m["values"] = strings.Join(a.Values, "\n")
+		}
+		res = append(res, m)
+	}
+	return res
+}
+
+// applyRepoConfig makes the settings of the configuration file the defaults
+// of the corresponding flags, unless given on the command line.
+func applyRepoConfig(fileName string) error {
+	c, err := LoadRepoConfig(fileName)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	set := map[string]bool{}
+	flag.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	roots := c.CertdocPaths
+	if c.CertdocPath != "" {
+		roots = append([]string{c.CertdocPath}, roots...)
+	}
+	settings := []struct {
+		flag, value string
+	}{
+		{"certdoc_path", strings.Join(roots, ",")},
+		{"code_path", c.CodePath},
+		{"roster", c.Roster},
+		{"waivers", c.Waivers},
+		{"commit-rules", c.CommitRules},
+		{"lang", c.Lang},
+	}
+	for _, s := range settings {
+		if s.value == "" || set[s.flag] {
+			continue
+		}
+		v := s.value
+		if s.flag == "roster" || s.flag == "waivers" || s.flag == "commit-rules" {
+			repoPath, err := git.RepoPath()
+			if err != nil {
+				return err
+			}
+			v = filepath.Join(repoPath, filepath.FromSlash(v))
+		}
+		if err := flag.Set(s.flag, v); err != nil {
+			return err
+		}
+	}
+	if len(c.Attributes) > 0 && !set["attributes"] {
+		// ReadJsonConf reads the attributes from the configuration file.
+		*fReportJsonConfPath = fileName
+	}
+	if c.StaleDays > 0 && !set["stale-days"] {
+		*fStaleDays = c.StaleDays
+	}
+	if c.MinCoverage > 0 && !set["min-coverage"] {
+		*fMinCoverage = c.MinCoverage
+	}
+	if c.MinBranchCoverage > 0 && !set["min-branch-coverage"] {
+		*fMinBranchCoverage = c.MinBranchCoverage
+	}
+	repoConfig = c
+	if c.TaskmgrToken != "" {
+		taskmgr.APIToken = c.TaskmgrToken
+	}
+	if c.Project != "" {
+		config.ProjectName = c.Project
+	}
+	if c.DocsURL != "" {
+		config.DocsURL = c.DocsURL
+	}
+	return nil
+}
+
+// fragmentKeys are the settings which can be overridden in the subdirectories.
+var fragmentKeys = map[string]bool{"version": true, "attributes": true, "sources": true}
+
+// loadConfigFragment reads and validates the configuration file of a subdirectory.
+func loadConfigFragment(fileName string) (*RepoConfig, error) {
+	c, err := LoadRepoConfig(fileName)
+	if err != nil {
+		return nil, err
+	}
+	b, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+	var root yaml.Node
+	if err := yaml.Unmarshal(b, &root); err != nil {
+		return nil, fmt.Errorf("%s: %v", fileName, err)
+	}
+	var errs []string
+	doc := root.Content[0]
+	for i := 0; i+1 < len(doc.Content); i += 2 {
+		if k := doc.Content[i]; !fragmentKeys[k.Value] {
+			errs = append(errs, configError(fileName, k.Line, "%s can be set only in the %s at the root of the repo", k.Value, repoConfigName).Error())
+		}
+	}
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("%s", strings.Join(errs, "\n"))
+	}
+	return c, nil
+}
+
+// configTree holds the configuration fragments of the subdirectories, by
+// directory relative to the repo root. A fragment overrides the settings of
+// the fragments in the parent directories, and of the root configuration:
+// its attributes replace those with the same names, and its sources replace
+// all the sources.
+type configTree map[string]*RepoConfig
+
+// loadConfigTree reads the configuration fragments found in the given paths,
+// relative to repoPath.
+func loadConfigTree(repoPath string, paths ...string) (configTree, error) {
+	t := configTree{}
+	var errs []string
+	for _, p := range paths {
+		_ = filepath.Walk(filepath.Join(repoPath, p), func(fileName string, info os.FileInfo, err error) error {
+			if err != nil || info.IsDir() || info.Name() != repoConfigName {
+				return nil
+			}
+			// The configuration at the root is read by applyRepoConfig.
+			dir := relativePathToRepo(filepath.Dir(fileName), repoPath)
+			if _, ok := t[dir]; dir == "" || ok {
+				return nil
+			}
+			c, err := loadConfigFragment(fileName)
+			if err != nil {
+				errs = append(errs, err.Error())
+				return nil
+			}
+			t[dir] = c
+			return nil
+		})
+	}
+	if len(errs) > 0 {
+		return t, fmt.Errorf("%s\n", strings.Join(errs, "\n"))
+	}
+	return t, nil
+}
+
+// fragments returns the fragments applying to the given file, relative to the
+// repo root, from the outermost.
+func (t configTree) fragments(fileName string) []*RepoConfig {
+	var res []*RepoConfig
+	dir := ""
+	for _, part := range strings.Split(path.Dir(strings.TrimPrefix(fileName, "/")), "/") {
+		dir = path.Join(dir, part)
+		if c := t[dir]; c != nil {
+			res = append(res, c)
+		}
+	}
+	return res
+}
+
+// isSource returns whether the given file, relative to the repo root, is a
+// code file which can contain references to requirements.
+func (t configTree) isSource(fileName string) bool {
+	sources := repoConfig.Sources
+	for _, c := range t.fragments(fileName) {
+		if len(c.Sources) > 0 {
+			sources = c.Sources
+		}
+	}
+	if len(sources) == 0 {
+		return isCodeFile(fileName)
+	}
+	for _, p := range sources {
+		if ok, _ := path.Match(p, path.Base(fileName)); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// attributes returns the attribute specification of the requirements in the
+// given file, relative to the repo root, overriding as with the fragments.
+func (t configTree) attributes(fileName string, as []map[string]string) []map[string]string {
+	for _, c := range t.fragments(fileName) {
+		merged := append([]map[string]string{}, as...)
+	next:
+		for _, a := range c.AttributeMaps() {
+			for i, m := range merged {
+				if strings.EqualFold(m["name"], a["name"]) {
+					merged[i] = a
+					continue next
+				}
+			}
+			merged = append(merged, a)
+		}
+		as = merged
+	}
+	return as
+}
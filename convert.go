@@ -0,0 +1,124 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"sort"
+	"strings"
+)
+
+// The formats of the certification documents, by file extension without dot.
+var certdocFormats = []string{"lyx", "md"}
+
+func isCertdocFormat(format string) bool {
+	for _, f := range certdocFormats {
+		if f == format {
+			return true
+		}
+	}
+	return false
+}
+
+// docFormat returns the format of the given certification document.
+func docFormat(fileName string) string {
+	return strings.TrimPrefix(strings.ToLower(path.Ext(fileName)), ".")
+}
+
+// attributeNames returns the names of the given attributes, as written in
+// the documents, the parents first and the others by name, those of the
+// configuration in its order, see orderAttributes.
+func attributeNames(attributes map[string]string) []string {
+	var keys []string
+	for k := range attributes {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if pi, pj := keys[i] == "PARENTS", keys[j] == "PARENTS"; pi != pj {
+			return pi
+		}
+		return keys[i] < keys[j]
+	})
+	res := make([]string, len(keys))
+	for i, j := range orderAttributes(repoConfig.AttributeMaps(), keys) {
+		res[i] = canonicalAttributeName(keys[j])
+	}
+	return res
+}
+
+// ConvertCertdoc converts the given certification document to Markdown, see
+// certdocFormats, the requirements keeping their IDs, titles, bodies and
+// attributes, in their sections, and returns the converted document.
+func ConvertCertdoc(fileName, to string) ([]byte, error) {
+	if to != "md" {
+		return nil, fmt.Errorf("Converting to %s is not supported, expected md", to)
+	}
+	contents, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+	txts, err := ParseCertdoc(fileName)
+	if err != nil {
+		return nil, fmt.Errorf("Error parsing %s: %v", fileName, err)
+	}
+	title := ParseDocument(fileName, contents).Title
+	if title == "" {
+		title = strings.TrimSuffix(path.Base(fileName), path.Ext(fileName))
+	}
+	sections := docSections(contents)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n", title)
+	section := "\x00"
+	for _, txt := range txts {
+		r, err := ParseReq(txt)
+		if err != nil {
+			return nil, fmt.Errorf("Error parsing %s: %v", fileName, err)
+		}
+		if s := sections[r.ID].Path; s != section {
+			section = s
+			if s == "" {
+				s = "Requirements"
+			}
+			fmt.Fprintf(&b, "\n## %s\n", s)
+		}
+		attributes := map[string]string{}
+		names := attributeNames(r.Attributes)
+		for _, name := range names {
+			attributes[name] = r.Attributes[strings.ToUpper(name)]
+		}
+		b.WriteString("\n")
+		b.WriteString(strings.TrimSuffix(FormatMarkdownReq(r.ID, r.Title, formatHTMLAsMarkdown(r.Body), names, attributes), "\n"))
+	}
+	return []byte(b.String()), nil
+}
+
+// convertedName returns the name of the document converted to the given format.
+func convertedName(fileName, to string) string {
+	return strings.TrimSuffix(fileName, path.Ext(fileName)) + "." + to
+}
+
+// ConvertCertdocs converts the given certification documents from and to the
+// given formats, writing the converted documents next to them. The existing
+// documents are not overwritten.
+func ConvertCertdocs(fileNames []string, from, to string) error {
+	for _, f := range fileNames {
+		if docFormat(f) != from {
+			return fmt.Errorf("%s is not a %s document", f, from)
+		}
+		out := convertedName(f, to)
+		if _, err := os.Stat(out); err == nil {
+			return fmt.Errorf("%s already exists", out)
+		}
+		b, err := ConvertCertdoc(f, to)
+		if err != nil {
+			return err
+		}
+		logFileCreate(out)
+		if err := ioutil.WriteFile(out, b, 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
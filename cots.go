@@ -0,0 +1,96 @@
+package main
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/daedaleanai/reqtraq/config"
+)
+
+// COTSConfig configures the commercial off-the-shelf and previously developed
+// code, whose requirements are credited through the justification of their
+// reuse rather than through their development, see COTSReqs.
+type COTSConfig struct {
+	// Roots are the directories of the code, e.g. third_party/.
+	Roots []string `yaml:"roots,omitempty"`
+	// Attributes are those justifying the reuse, e.g. Service History,
+	// required of the requirements implemented only by this code.
+	Attributes []string `yaml:"attributes,omitempty"`
+}
+
+// isCOTS returns whether the given code file, see Req.Path, is under one of
+// the COTS roots.
+func (c COTSConfig) isCOTS(path string) bool {
+	path = repoRelative(path)
+	for _, root := range c.Roots {
+		root = strings.Trim(filepath.ToSlash(root), "/")
+		if root != "" && (path == root || strings.HasPrefix(path, root+"/")) {
+			return true
+		}
+	}
+	return false
+}
+
+// CodeFiles returns the paths of the code files implementing the requirement.
+func (r *Req) CodeFiles() []string {
+	var files []string
+	for _, c := range r.Children {
+		if c.Level == config.CODE {
+			files = append(files, repoRelative(c.Path))
+		}
+	}
+	sort.Strings(files)
+	return files
+}
+
+// COTSReqs returns the requirements whose code files are all in the COTS roots
+// configured in reqtraq.yaml, by ID.
+func (rg reqGraph) COTSReqs() []*Req {
+	var res []*Req
+	for _, r := range rg {
+		if r.Level == config.CODE || r.IsDeleted() {
+			continue
+		}
+		files := r.CodeFiles()
+		cots := len(files) > 0
+		for _, f := range files {
+			cots = cots && repoConfig.COTS.isCOTS(f)
+		}
+		if cots {
+			res = append(res, r)
+		}
+	}
+	sort.Sort(byIDs(res))
+	return res
+}
+
+// COTSJustification is the value of a reuse justification attribute of a
+// requirement, see COTSConfig.Attributes.
+type COTSJustification struct {
+	Name, Value string
+}
+
+// COTSJustifications returns the values of the reuse justification attributes
+// of the requirement, empty when missing.
+func (r *Req) COTSJustifications() []COTSJustification {
+	var res []COTSJustification
+	for _, a := range repoConfig.COTS.Attributes {
+		res = append(res, COTSJustification{a, strings.TrimSpace(r.Attributes[normalizeAttribute(a)])})
+	}
+	return res
+}
+
+// CheckCOTS checks that the requirements implemented only by COTS code, see
+// COTSReqs, have all the reuse justification attributes.
+func (rg reqGraph) CheckCOTS() []error {
+	var errs []error
+	for _, r := range rg.COTSReqs() {
+		for _, j := range r.COTSJustifications() {
+			if j.Value == "" {
+				errs = append(errs, newFinding("cots", "Requirement '%s' implemented only by COTS code is missing the reuse justification attribute '%s'.\n", r.ID, j.Name))
+			}
+		}
+	}
+	return errs
+}
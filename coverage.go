@@ -0,0 +1,340 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/daedaleanai/reqtraq/config"
+	"github.com/daedaleanai/reqtraq/git"
+)
+
+// Coverage is the structural coverage of the code implementing a requirement,
+// see SetCoverage.
+type Coverage struct {
+	Statements, CoveredStatements int
+	Branches, CoveredBranches     int
+}
+
+// add adds the coverage of other code.
+func (c *Coverage) add(o *Coverage) {
+	c.Statements += o.Statements
+	c.CoveredStatements += o.CoveredStatements
+	c.Branches += o.Branches
+	c.CoveredBranches += o.CoveredBranches
+}
+
+// coveragePercent returns the percentage of covered, 100 when there is nothing to cover.
+func coveragePercent(covered, total int) float64 {
+	if total == 0 {
+		return 100
+	}
+	return percent(covered, total)
+}
+
+// StatementPercent returns the percentage of covered statements.
+func (c *Coverage) StatementPercent() float64 {
+	return coveragePercent(c.CoveredStatements, c.Statements)
+}
+
+// BranchPercent returns the percentage of covered branches.
+func (c *Coverage) BranchPercent() float64 { return coveragePercent(c.CoveredBranches, c.Branches) }
+
+// lineCoverage is the coverage of the statements and the branches on a line.
+type lineCoverage struct {
+	statements, covered       int
+	branches, coveredBranches int
+}
+
+// merge keeps the highest coverage of the line found in several reports.
+func (l *lineCoverage) merge(o *lineCoverage) {
+	max := func(a, b int) int {
+		if a > b {
+			return a
+		}
+		return b
+	}
+	l.statements = max(l.statements, o.statements)
+	l.covered = max(l.covered, o.covered)
+	l.branches = max(l.branches, o.branches)
+	l.coveredBranches = max(l.coveredBranches, o.coveredBranches)
+}
+
+// CoverageData is the coverage of the source files, by the path found in the
+// reports, then by line number.
+type CoverageData map[string]map[int]*lineCoverage
+
+// line returns the coverage of the given line, added when missing.
+func (d CoverageData) line(file string, lno int) *lineCoverage {
+	if d[file] == nil {
+		d[file] = map[int]*lineCoverage{}
+	}
+	if d[file][lno] == nil {
+		d[file][lno] = &lineCoverage{}
+	}
+	return d[file][lno]
+}
+
+// lookup returns the coverage of the given file, relative to the repo root,
+// whose paths in the reports can be absolute, or prefixed, e.g. with the Go
+// module path.
+func (d CoverageData) lookup(file string) map[int]*lineCoverage {
+	var res map[int]*lineCoverage
+	for p, lines := range d {
+		if !isReportedPath(p, file) {
+			continue
+		}
+		if res == nil {
+			res = map[int]*lineCoverage{}
+		}
+		for lno, l := range lines {
+			if res[lno] == nil {
+				res[lno] = &lineCoverage{}
+			}
+			res[lno].merge(l)
+		}
+	}
+	return res
+}
+
+// ReadCoverage reads the given comma separated coverage reports. A line
+// covered in one of them is covered. The supported formats are the lcov
+// tracefiles, e.g. created by lcov from gcov or by llvm-cov export
+// -format=lcov, the gcov files and the go cover profiles.
+func ReadCoverage(fileNames string) (CoverageData, error) {
+	d := CoverageData{}
+	for _, fileName := range splitList(fileNames) {
+		f, err := os.Open(fileName)
+		if err != nil {
+			return nil, err
+		}
+		fd, err := readCoverage(bufio.NewReader(f))
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("Error while reading coverage %s: %v", fileName, err)
+		}
+		for file, lines := range fd {
+			for lno, l := range lines {
+				d.line(file, lno).merge(l)
+			}
+		}
+	}
+	return d, nil
+}
+
+var (
+	reGoCoverBlock = regexp.MustCompile(`^(.+):(\d+)\.\d+,\d+\.\d+ (\d+) (\d+)$`)
+	reGcovLine     = regexp.MustCompile(`^\s*([^:]+):\s*(\d+):(.*)$`)
+	reGcovBranch   = regexp.MustCompile(`^branch\s+\d+\s+(taken (\d+)|never executed)`)
+)
+
+// readCoverage reads a coverage report, guessing its format from its first line.
+func readCoverage(r *bufio.Reader) (CoverageData, error) {
+	first, err := r.Peek(64)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	firstLine := strings.SplitN(string(first), "\n", 2)[0]
+	d := CoverageData{}
+	scan := newLineReader(r)
+	switch {
+	case strings.HasPrefix(firstLine, "mode:"):
+		// A go cover profile: the statements of a block are counted on its first line.
+		type block struct {
+			file        string
+			line, stmts int
+			covered     bool
+		}
+		// The blocks are repeated when several packages are tested.
+		blocks := map[string]*block{}
+		for scan.Scan() {
+			parts := reGoCoverBlock.FindStringSubmatch(scan.Text())
+			if parts == nil {
+				continue
+			}
+			key := strings.TrimSuffix(scan.Text(), " "+parts[4])
+			b := blocks[key]
+			if b == nil {
+				b = &block{file: parts[1]}
+				b.line, _ = strconv.Atoi(parts[2])
+				b.stmts, _ = strconv.Atoi(parts[3])
+				blocks[key] = b
+			}
+			if hits, _ := strconv.Atoi(parts[4]); hits > 0 {
+				b.covered = true
+			}
+		}
+		for _, b := range blocks {
+			l := d.line(b.file, b.line)
+			l.statements += b.stmts
+			if b.covered {
+				l.covered += b.stmts
+			}
+		}
+	case reGcovLine.MatchString(firstLine):
+		// A gcov file, starting with "        -:    0:Source:main.c".
+		var (
+			file string
+			l    *lineCoverage // The last line, the branches below it belong to.
+		)
+		for scan.Scan() {
+			if parts := reGcovBranch.FindStringSubmatch(scan.Text()); parts != nil {
+				if l != nil {
+					l.branches++
+					if n, _ := strconv.Atoi(parts[2]); n > 0 {
+						l.coveredBranches++
+					}
+				}
+				continue
+			}
+			parts := reGcovLine.FindStringSubmatch(scan.Text())
+			if parts == nil {
+				continue
+			}
+			count := strings.TrimSpace(parts[1])
+			lno, _ := strconv.Atoi(parts[2])
+			if lno == 0 {
+				if strings.HasPrefix(parts[3], "Source:") {
+					file = strings.TrimPrefix(parts[3], "Source:")
+				}
+				continue
+			}
+			if count == "-" || file == "" {
+				l = nil
+				continue
+			}
+			l = d.line(file, lno)
+			l.statements = 1
+			if n, err := strconv.Atoi(strings.TrimSuffix(count, "*")); err == nil && n > 0 {
+				l.covered = 1
+			}
+		}
+	default:
+		// An lcov tracefile.
+		var file string
+		for scan.Scan() {
+			line := strings.TrimSpace(scan.Text())
+			key, value := line, ""
+			if i := strings.Index(line, ":"); i >= 0 {
+				key, value = line[:i], line[i+1:]
+			}
+			fields := strings.Split(value, ",")
+			switch key {
+			case "SF":
+				file = value
+			case "DA":
+				lno, err := strconv.Atoi(fields[0])
+				if err != nil || len(fields) < 2 || file == "" {
+					return nil, fmt.Errorf("malformed line %q", line)
+				}
+				l := d.line(file, lno)
+				l.statements = 1
+				if n, _ := strconv.Atoi(fields[1]); n > 0 {
+					l.covered = 1
+				}
+			case "BRDA":
+				lno, err := strconv.Atoi(fields[0])
+				if err != nil || len(fields) < 4 || file == "" {
+					return nil, fmt.Errorf("malformed line %q", line)
+				}
+				l := d.line(file, lno)
+				l.branches++
+				if n, _ := strconv.Atoi(fields[3]); n > 0 {
+					l.coveredBranches++
+				}
+			case "end_of_record":
+				file = ""
+			}
+		}
+	}
+	return d, scan.Err()
+}
+
+// SetCoverage sets the Coverage of the requirements implemented by the code
+// in the working tree, from the coverage of the code following their @llr
+// tags, up to the next tags.
+func (rg reqGraph) SetCoverage(d CoverageData) error {
+	repoPath, err := git.RepoPath()
+	if err != nil {
+		return err
+	}
+	var files []string
+	for _, r := range rg {
+		if r.Level == config.CODE && r.Kind == "" {
+			files = append(files, strings.TrimPrefix(r.ID, "/"))
+		}
+	}
+	sort.Strings(files)
+	for _, f := range files {
+		lines := d.lookup(f)
+		if lines == nil {
+			continue
+		}
+		b, err := os.ReadFile(filepath.Join(repoPath, f))
+		if err != nil {
+			return err
+		}
+		texts := strings.Split(string(b), "\n")
+		for _, region := range taggedRegions(texts) {
+			var c Coverage
+			for i := region.start; i < region.end; i++ {
+				if l := lines[i+1]; l != nil {
+					c.add(&Coverage{l.statements, l.covered, l.branches, l.coveredBranches})
+				}
+			}
+			for _, id := range region.ids {
+				r, ok := rg[id]
+				if !ok {
+					continue
+				}
+				if r.Coverage == nil {
+					r.Coverage = &Coverage{}
+				}
+				r.Coverage.add(&c)
+			}
+		}
+	}
+	return nil
+}
+
+// CheckCoverage checks that the statement and branch coverage of the
+// requirements with coverage is at least the given percentages.
+func (rg reqGraph) CheckCoverage(minStatements, minBranches float64) []error {
+	var reqs []*Req
+	for _, r := range rg {
+		if r.Level != config.CODE && r.Coverage != nil {
+			reqs = append(reqs, r)
+		}
+	}
+	sort.Sort(byIDs(reqs))
+	var errs []error
+	for _, r := range reqs {
+		if p := r.Coverage.StatementPercent(); p < minStatements {
+			errs = append(errs, newFinding("coverage", "Requirement '%s' has statement coverage %.1f%% (%d/%d), below %g%%.\n",
+				r.ID, p, r.Coverage.CoveredStatements, r.Coverage.Statements, minStatements))
+		}
+		if p := r.Coverage.BranchPercent(); p < minBranches {
+			errs = append(errs, newFinding("coverage", "Requirement '%s' has branch coverage %.1f%% (%d/%d), below %g%%.\n",
+				r.ID, p, r.Coverage.CoveredBranches, r.Coverage.Branches, minBranches))
+		}
+	}
+	return errs
+}
+
+// setCoverage sets the Coverage of the requirements from the reports given with --coverage, if any.
+func setCoverage(rg reqGraph) error {
+	if *fCoverage == "" {
+		return nil
+	}
+	d, err := ReadCoverage(*fCoverage)
+	if err != nil {
+		return err
+	}
+	return rg.SetCoverage(d)
+}
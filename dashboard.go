@@ -0,0 +1,151 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"regexp"
+
+	"github.com/daedaleanai/reqtraq/config"
+	"github.com/daedaleanai/reqtraq/git"
+)
+
+// reTestFile matches the names of the code files containing tests, e.g. req_test.go or test_parser.py.
+var reTestFile = regexp.MustCompile(`(^test_|_test\.\w+$|_unittest\.\w+$)`)
+
+// LevelStats are the progress numbers of the requirements of a level. A
+// requirement has code or tests when they are found among its descendants,
+// and passing tests when the test cases verifying it have results, see
+// SetTestResults, and they all passed.
+type LevelStats struct {
+	Level            string  `json:"level"`
+	Total            int     `json:"total"`
+	WithChildren     int     `json:"with_children"`
+	WithCode         int     `json:"with_code"`
+	WithTests        int     `json:"with_tests"`
+	WithPassingTests int     `json:"with_passing_tests,omitempty"`
+	PctChildren      float64 `json:"pct_with_children"`
+	PctCode          float64 `json:"pct_with_code"`
+	PctTests         float64 `json:"pct_with_tests"`
+	PctPassingTests  float64 `json:"pct_with_passing_tests,omitempty"`
+}
+
+// Baseline are the progress numbers of the requirements at a commit.
+type Baseline struct {
+	Name       string          `json:"name"` // The tag, or "current" for the working tree.
+	Levels     []LevelStats    `json:"levels"`
+	Priorities []PriorityStats `json:"priorities,omitempty"`
+}
+
+// Dashboard is the progress of the requirements, now and at the previous baselines, the most recent first.
+type Dashboard struct {
+	Baselines []Baseline `json:"baselines"`
+	// TestResults is whether the results of the tests of the working tree
+	// are known, counting the requirements with passing tests.
+	TestResults bool     `json:"test_results"`
+	current     reqGraph // The graph of the working tree.
+}
+
+// Stats returns the progress numbers of the requirements of each level.
+// Deleted requirements are not counted.
+func (rg reqGraph) Stats() []LevelStats {
+	var res []LevelStats
+	for _, level := range []config.RequirementLevel{config.SYSTEM, config.HIGH, config.LOW} {
+		s := LevelStats{Level: levelNames[level]}
+		for _, r := range rg {
+			if r.Level != level || r.IsDeleted() {
+				continue
+			}
+			s.Total++
+			if len(r.Children) > 0 {
+				s.WithChildren++
+			}
+			code, tests := r.tracedToCode()
+			if code {
+				s.WithCode++
+			}
+			if tests {
+				s.WithTests++
+			}
+			if _, passed := r.testResults(); passed {
+				s.WithPassingTests++
+			}
+		}
+		if s.Total > 0 {
+			s.PctChildren = percent(s.WithChildren, s.Total)
+			s.PctCode = percent(s.WithCode, s.Total)
+			s.PctTests = percent(s.WithTests, s.Total)
+			s.PctPassingTests = percent(s.WithPassingTests, s.Total)
+		}
+		res = append(res, s)
+	}
+	return res
+}
+
+// tracedToCode returns whether code files and test files are found among the descendants of r.
+func (r *Req) tracedToCode() (code, tests bool) {
+	for _, c := range r.Children {
+		if c.Level == config.CODE {
+			if c.Kind == testKind || reTestFile.MatchString(filepath.Base(c.Path)) {
+				tests = true
+			} else {
+				code = true
+			}
+			continue
+		}
+		cc, ct := c.tracedToCode()
+		code = code || cc
+		tests = tests || ct
+	}
+	return code, tests
+}
+
+func percent(n, total int) float64 {
+	return float64(int(float64(n)*1000/float64(total)+0.5)) / 10
+}
+
+// BuildDashboard returns the progress of the requirements in the working
+// tree and at the n most recent tags. The problems found in the requirements
+// are logged, the requirements which could be parsed being counted anyway.
+// The results of the tests, if any, are those of the working tree.
+func BuildDashboard(n int, results TestResults) (*Dashboard, error) {
+	tags, err := git.Tags(n)
+	if err != nil {
+		return nil, err
+	}
+	d := &Dashboard{TestResults: results != nil}
+	for _, commit := range append([]string{""}, tags...) {
+		rg, err := buildGraph(commit)
+		if rg == nil {
+			return nil, err
+		}
+		name := commit
+		if commit == "" {
+			name = "current"
+			d.current = rg
+			rg.SetTestResults(results)
+		}
+		if err != nil {
+			slog.Warn("problems found in the requirements, counting the ones which could be parsed",
+				"baseline", name, "findings", Summarize(command, exitFindings, err).Findings)
+		}
+		d.Baselines = append(d.Baselines, Baseline{name, rg.Stats(), rg.PriorityStats(repoConfig.Priorities)})
+	}
+	return d, nil
+}
+
+// WriteJSON writes the dashboard as json.
+func (d *Dashboard) WriteJSON(w io.Writer) error {
+	b, err := json.MarshalIndent(d, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(b, '\n'))
+	return err
+}
+
+// WriteHTML writes the dashboard as an HTML page.
+func (d *Dashboard) WriteHTML(w io.Writer) error {
+	return reportTmpl.ExecuteTemplate(w, "DASHBOARD", d)
+}
@@ -0,0 +1,269 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/daedaleanai/reqtraq/config"
+)
+
+// DepOrder is an implementation order of the low-level requirements following
+// their DEPENDS-ON links, see linkKinds.
+type DepOrder struct {
+	// Steps are the requirements which can be implemented in parallel, each
+	// step depending only on the previous ones.
+	Steps [][]*Req
+	// Cycles are the circular dependencies, which cannot be ordered.
+	Cycles [][]*Req
+	// Blocked are the requirements depending, directly or not, on a cycle.
+	Blocked []*Req
+}
+
+// dependencies returns the low-level requirements the given one depends on.
+func (r *Req) dependencies() []*Req {
+	var res []*Req
+	for _, l := range r.Links {
+		if l.Kind == "DEPENDS-ON" && l.Req.Level == config.LOW && !l.Req.IsDeleted() {
+			res = append(res, l.Req)
+		}
+	}
+	return res
+}
+
+// DependencyOrder returns the implementation order of the low-level requirements.
+func (rg reqGraph) DependencyOrder() DepOrder {
+	var reqs []*Req
+	for _, r := range rg {
+		if r.Level == config.LOW && !r.IsDeleted() {
+			reqs = append(reqs, r)
+		}
+	}
+	sort.Sort(byIDs(reqs))
+
+	var order DepOrder
+	done := map[*Req]bool{}
+	for {
+		var step []*Req
+	next:
+		for _, r := range reqs {
+			if done[r] {
+				continue
+			}
+			for _, d := range r.dependencies() {
+				if !done[d] {
+					continue next
+				}
+			}
+			step = append(step, r)
+		}
+		if len(step) == 0 {
+			break
+		}
+		for _, r := range step {
+			done[r] = true
+		}
+		order.Steps = append(order.Steps, step)
+	}
+
+	// The remaining requirements are in cycles or depend on them.
+	var remaining []*Req
+	for _, r := range reqs {
+		if !done[r] {
+			remaining = append(remaining, r)
+		}
+	}
+	inCycle := map[*Req]bool{}
+	for _, c := range stronglyConnected(remaining) {
+		if len(c) > 1 {
+			order.Cycles = append(order.Cycles, c)
+			for _, r := range c {
+				inCycle[r] = true
+			}
+		}
+	}
+	for _, r := range remaining {
+		if !inCycle[r] {
+			order.Blocked = append(order.Blocked, r)
+		}
+	}
+	return order
+}
+
+// stronglyConnected returns the strongly connected components of the
+// dependency graph of the given requirements, using Tarjan's algorithm. The
+// components are sorted by their first requirement, each component starting
+// with its smallest ID and following the dependencies.
+func stronglyConnected(reqs []*Req) [][]*Req {
+	index := map[*Req]int{}
+	low := map[*Req]int{}
+	onStack := map[*Req]bool{}
+	var stack []*Req
+	var res [][]*Req
+	var visit func(r *Req)
+	visit = func(r *Req) {
+		index[r] = len(index)
+		low[r] = index[r]
+		stack = append(stack, r)
+		onStack[r] = true
+		for _, d := range r.dependencies() {
+			if _, ok := index[d]; !ok {
+				visit(d)
+				if low[d] < low[r] {
+					low[r] = low[d]
+				}
+			} else if onStack[d] && index[d] < low[r] {
+				low[r] = index[d]
+			}
+		}
+		if low[r] == index[r] {
+			var c []*Req
+			for {
+				n := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				onStack[n] = false
+				c = append(c, n)
+				if n == r {
+					break
+				}
+			}
+			res = append(res, orderCycle(c))
+		}
+	}
+	for _, r := range reqs {
+		if _, ok := index[r]; !ok {
+			visit(r)
+		}
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i][0].ID < res[j][0].ID })
+	return res
+}
+
+// orderCycle returns the requirements of the component starting with the
+// smallest ID, each followed by one of its dependencies in the component.
+func orderCycle(c []*Req) []*Req {
+	in := map[*Req]bool{}
+	first := c[0]
+	for _, r := range c {
+		in[r] = true
+		if r.ID < first.ID {
+			first = r
+		}
+	}
+	res := []*Req{first}
+	seen := map[*Req]bool{first: true}
+	for r := first; ; {
+		var next *Req
+		for _, d := range r.dependencies() {
+			if in[d] && !seen[d] {
+				next = d
+				break
+			}
+		}
+		if next == nil {
+			break
+		}
+		seen[next] = true
+		res = append(res, next)
+		r = next
+	}
+	// The requirements not on the path, in larger components.
+	var rest []*Req
+	for _, r := range c {
+		if !seen[r] {
+			rest = append(rest, r)
+		}
+	}
+	sort.Sort(byIDs(rest))
+	return append(res, rest...)
+}
+
+// closes returns whether the requirements form a simple cycle, in order.
+func closes(c []*Req) bool {
+	for i, r := range c {
+		found := false
+		for _, d := range r.dependencies() {
+			found = found || d == c[(i+1)%len(c)]
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// Err returns the circular dependencies as an error, nil if there are none.
+func (o DepOrder) Err() error {
+	var problems findingList
+	for _, c := range o.Cycles {
+		problems.add(newFinding("circular_dependency", "Circular dependency between requirements %s.\n", strings.Join(reqIDsOf(c), ", ")))
+	}
+	return problems.err()
+}
+
+// reqIDsOf returns the IDs of the given requirements.
+func reqIDsOf(reqs []*Req) []string {
+	var res []string
+	for _, r := range reqs {
+		res = append(res, r.ID)
+	}
+	return res
+}
+
+// WriteDepOrderMarkdown writes the implementation order as a Markdown table,
+// followed by the circular dependencies.
+func WriteDepOrderMarkdown(w io.Writer, o DepOrder) {
+	fmt.Fprintf(w, "# Implementation order\n\n")
+	fmt.Fprintf(w, "| Step | Requirement | Title | Depends on |\n")
+	fmt.Fprintf(w, "|---|---|---|---|\n")
+	row := func(step string, r *Req) {
+		deps := strings.Join(reqIDsOf(r.dependencies()), ", ")
+		if deps == "" {
+			deps = "-"
+		}
+		fmt.Fprintf(w, "| %s | %s | %s | %s |\n", step, r.ID, strings.ReplaceAll(r.Title, "|", "\\|"), deps)
+	}
+	for i, step := range o.Steps {
+		for _, r := range step {
+			row(fmt.Sprint(i+1), r)
+		}
+	}
+	for _, r := range o.Blocked {
+		row("blocked", r)
+	}
+	if len(o.Cycles) > 0 {
+		fmt.Fprintf(w, "\n## Circular dependencies\n\n")
+		for _, c := range o.Cycles {
+			if closes(c) {
+				fmt.Fprintf(w, "- %s → %s\n", strings.Join(reqIDsOf(c), " → "), c[0].ID)
+			} else {
+				fmt.Fprintf(w, "- %s\n", strings.Join(reqIDsOf(c), ", "))
+			}
+		}
+	}
+	fmt.Fprintln(w)
+}
+
+// WriteDepOrderJSON writes the implementation order as json, with the IDs of the requirements.
+func WriteDepOrderJSON(w io.Writer, o DepOrder) error {
+	var res struct {
+		Steps   [][]string
+		Cycles  [][]string `json:",omitempty"`
+		Blocked []string   `json:",omitempty"`
+	}
+	for _, s := range o.Steps {
+		res.Steps = append(res.Steps, reqIDsOf(s))
+	}
+	for _, c := range o.Cycles {
+		res.Cycles = append(res.Cycles, reqIDsOf(c))
+	}
+	res.Blocked = reqIDsOf(o.Blocked)
+	b, err := json.MarshalIndent(res, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(b, '\n'))
+	return err
+}
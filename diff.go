@@ -64,7 +64,7 @@ func (r *Req) ChangedSince(pr *Req) (diffs []string) {
 		if rd {
 			return []string{"DELETED"}
 		}
-		if rd {
+		if prd {
 			return []string{"UNDELETED"}
 			// no point in comparing all the other attributes
 		}
@@ -102,7 +102,9 @@ func (r *Req) ChangedSince(pr *Req) (diffs []string) {
 		keys = append(keys, k)
 	}
 	for k, _ := range r.Attributes {
-		keys = append(keys, k)
+		if _, ok := pr.Attributes[k]; !ok {
+			keys = append(keys, k)
+		}
 	}
 	sort.Strings(keys)
 	for _, k := range keys {
@@ -115,7 +117,7 @@ func (r *Req) ChangedSince(pr *Req) (diffs []string) {
 			} else {
 				diffs = append(diffs, fmt.Sprintf("Removed %q", k))
 			}
-		} else if p, c := onlyLetters(v), onlyLetters(pv); p != c {
+		} else if p, c := onlyLetters(pv), onlyLetters(v); p != c {
 			diffs = append(diffs, fmt.Sprintf("Changed %q from %q to %q", k, p, c))
 		}
 	}
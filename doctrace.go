@@ -0,0 +1,158 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/daedaleanai/reqtraq/config"
+	"github.com/daedaleanai/reqtraq/git"
+)
+
+// DocNode is a certification document in the document-level trace.
+type DocNode struct {
+	*Document
+	Type         string // The document type, e.g. SRD.
+	Requirements int
+	Links        []DocLink
+}
+
+// DocLink is a relationship of a document to another one, see config.DocTypeRelations.
+type DocLink struct {
+	Kind     string // E.g. implements.
+	To       string // The ID of the related document.
+	Revision string // The revision of the related document.
+	Missing  bool   // Whether the related document does not exist.
+	// Traces is the number of links from the requirements of the document to
+	// parents defined in the related document.
+	Traces int
+}
+
+// docType returns the project, e.g. 0-DDLN, and the type, e.g. SRD, of the document with the given ID.
+func docType(id string) (project, typ string) {
+	parts := reCertdoc.FindStringSubmatch(id)
+	if parts == nil {
+		return "", ""
+	}
+	return parts[1] + "-" + parts[2], parts[4]
+}
+
+// readDocuments returns the headers of the certification documents found in certdocPath.
+func readDocuments(certdocPath string) ([]*Document, error) {
+	repoPath, err := git.RepoPath()
+	if err != nil {
+		return nil, err
+	}
+	var docs []*Document
+	err = filepath.Walk(certdocPath, func(fileName string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || IsValidDocName(fileName) != nil || isGenerated(repoPath, fileName) {
+			return nil
+		}
+		contents, err := ioutil.ReadFile(fileName)
+		if err != nil {
+			return err
+		}
+		doc := ParseDocument(fileName, contents)
+		doc.Path = filepath.ToSlash(strings.TrimPrefix(fileName, repoPath))
+		docs = append(docs, doc)
+		return nil
+	})
+	return docs, err
+}
+
+// BuildDocTrace returns the given documents as the nodes of the document-level
+// trace, sorted by ID, with their relationships configured by document type
+// and the number of requirement traces supporting them. The relationships to
+// documents which do not exist are kept, marked as missing.
+func BuildDocTrace(docs []*Document, rg reqGraph) []*DocNode {
+	byID := map[string]*DocNode{}
+	var nodes []*DocNode
+	for _, d := range docs {
+		_, typ := docType(d.ID)
+		n := &DocNode{Document: d, Type: typ}
+		byID[d.ID] = n
+		nodes = append(nodes, n)
+	}
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].ID < nodes[j].ID })
+
+	// The number of parent links between the requirements of each pair of documents.
+	traces := map[[2]string]int{}
+	for _, r := range rg {
+		if r.Document == nil || r.IsDeleted() {
+			continue
+		}
+		if n := byID[r.Document.ID]; n != nil {
+			n.Requirements++
+		}
+		for _, p := range r.Parents {
+			if p.Document != nil {
+				traces[[2]string{r.Document.ID, p.Document.ID}]++
+			}
+		}
+	}
+
+	for _, n := range nodes {
+		project, _ := docType(n.ID)
+		for _, rel := range config.DocTypeRelations {
+			if rel.From != n.Type {
+				continue
+			}
+			found := false
+			for _, to := range nodes {
+				if p, typ := docType(to.ID); p == project && typ == rel.To {
+					found = true
+					n.Links = append(n.Links, DocLink{Kind: rel.Kind, To: to.ID, Revision: to.Revision, Traces: traces[[2]string{n.ID, to.ID}]})
+				}
+			}
+			if !found {
+				n.Links = append(n.Links, DocLink{Kind: rel.Kind, To: project + "-" + docNameConventions[rel.To] + "-" + rel.To, Missing: true})
+			}
+		}
+	}
+	return nodes
+}
+
+// WriteDocTraceMarkdown writes the document-level trace as a Markdown table,
+// e.g. for the SOI#1 audit.
+func WriteDocTraceMarkdown(w io.Writer, nodes []*DocNode) {
+	fmt.Fprintf(w, "# Document traceability\n\n")
+	fmt.Fprintf(w, "| Document | Revision | Requirements | Relationship | Document | Revision | Requirement traces |\n")
+	fmt.Fprintf(w, "|---|---|---|---|---|---|---|\n")
+	cell := func(s string) string {
+		if s == "" {
+			return "-"
+		}
+		return strings.ReplaceAll(s, "|", "\\|")
+	}
+	for _, n := range nodes {
+		if len(n.Links) == 0 {
+			fmt.Fprintf(w, "| %s | %s | %d | - | - | - | - |\n", n.ID, cell(n.Revision), n.Requirements)
+		}
+		for _, l := range n.Links {
+			rev, traces := cell(l.Revision), fmt.Sprint(l.Traces)
+			if l.Missing {
+				rev, traces = "missing", "-"
+			}
+			fmt.Fprintf(w, "| %s | %s | %d | %s | %s | %s | %s |\n", n.ID, cell(n.Revision), n.Requirements, l.Kind, l.To, rev, traces)
+		}
+	}
+	fmt.Fprintln(w)
+}
+
+// WriteDocTraceJSON writes the document-level trace as json.
+func WriteDocTraceJSON(w io.Writer, nodes []*DocNode) error {
+	b, err := json.MarshalIndent(nodes, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(b, '\n'))
+	return err
+}
@@ -0,0 +1,228 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/daedaleanai/reqtraq/config"
+	"github.com/daedaleanai/reqtraq/git"
+)
+
+var (
+	// reDocField matches the fields of a document header, e.g. "Revision: 3" or "**Version:** 1.2".
+	reDocField = regexp.MustCompile(`(?i)^\W*(title|revision|version|date|document approvals?|approvals?|approvers?)\W*:\W*(.*?)\W*$`)
+	// reBodyStart matches the first section heading, which ends the document header.
+	reBodyStart = regexp.MustCompile(`^(##\s|\\begin_layout (Section|Chapter))`)
+	// reLyxLayout matches the start of a LyX paragraph, e.g. "\begin_layout Title".
+	reLyxLayout = regexp.MustCompile(`^\\begin_layout (\w+)`)
+)
+
+// Document is the information found in the header of a certification
+// document, before its first section: the Markdown front matter and first
+// lines, or the LyX title block, for example:
+//
+//	# Software Requirements Document for Reqtraq
+//
+//	Revision: 2
+//	Date: 2024-01-31
+//
+//	Document Approval:
+//	- Engineering, Engineer: Daniel Danciu
+type Document struct {
+	ID        string // The file name without extension, e.g. 0-DDLN-211-SRD.
+	Path      string // The document, relative to the repo root, as Req.Path.
+	Title     string
+	Revision  string // From the Revision or Version field.
+	Date      string
+	Approvers []string
+}
+
+// docLine is a line of a document header, or a whole paragraph of a LyX document.
+type docLine struct {
+	kind string // "title", "date", "item" or "text".
+	text string
+}
+
+// lyxLineKinds are the kinds of the lines of the LyX paragraphs, by layout, "text" for the others.
+var lyxLineKinds = map[string]string{"Title": "title", "Date": "date", "Itemize": "item", "Enumerate": "item"}
+
+// headerLines returns the lines of the header of the given document contents.
+func headerLines(contents []byte) []docLine {
+	var lines []docLine
+	lyx := bytes.Contains(contents, []byte("\\begin_body"))
+	frontMatter := false
+	layout := ""
+	var paragraph []string
+	scan := newLineReader(bytes.NewReader(contents))
+	for lno := 0; scan.Scan(); lno++ {
+		line := toUTF8(strings.TrimRight(scan.Text(), "\r"))
+		if reBodyStart.MatchString(line) {
+			break
+		}
+		if lyx {
+			switch {
+			case reLyxLayout.MatchString(line):
+				layout = reLyxLayout.FindStringSubmatch(line)[1]
+				paragraph = nil
+			case line == "\\end_layout" && layout != "":
+				kind := lyxLineKinds[layout]
+				if kind == "" {
+					kind = "text"
+				}
+				lines = append(lines, docLine{kind, strings.TrimSpace(strings.Join(paragraph, ""))})
+				layout = ""
+			case layout != "" && !strings.HasPrefix(line, "\\"):
+				paragraph = append(paragraph, lyxText(line))
+			}
+			continue
+		}
+		if line == "---" && (lno == 0 || frontMatter) {
+			frontMatter = !frontMatter
+			continue
+		}
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "":
+		case strings.HasPrefix(line, "# "):
+			lines = append(lines, docLine{"title", strings.TrimSpace(line[2:])})
+		case strings.HasPrefix(line, "- "), strings.HasPrefix(line, "* "):
+			lines = append(lines, docLine{"item", strings.TrimSpace(line[2:])})
+		default:
+			lines = append(lines, docLine{"text", line})
+		}
+	}
+	return lines
+}
+
+// ParseDocument returns the information found in the header of the document
+// with the given file name and contents.
+func ParseDocument(fileName string, contents []byte) *Document {
+	base := path.Base(filepath.ToSlash(fileName))
+	doc := &Document{ID: strings.TrimSuffix(base, path.Ext(base))}
+	approvals := false
+	for _, l := range headerLines(contents) {
+		if l.kind == "item" {
+			if approvals {
+				doc.Approvers = append(doc.Approvers, l.text)
+			}
+			continue
+		}
+		approvals = false
+		field, value := "", l.text
+		if parts := reDocField.FindStringSubmatch(l.text); parts != nil {
+			field, value = strings.ToLower(parts[1]), parts[2]
+		}
+		switch {
+		case l.kind == "title" || field == "title":
+			if doc.Title == "" {
+				doc.Title = value
+			}
+		case l.kind == "date" || field == "date":
+			doc.Date = value
+		case field == "revision" || field == "version":
+			doc.Revision = value
+		case field != "":
+			// The approvers are listed on the following lines.
+			approvals = true
+			if value != "" {
+				doc.Approvers = append(doc.Approvers, value)
+			}
+		}
+	}
+	return doc
+}
+
+// readFileAt returns the contents of the certification document, relative to
+// the repo root, at the given commit or in the working tree when commit is
+// empty, decrypted if needed, see decrypt. It returns nil if the file does not
+// exist.
+func readFileAt(commit, file string) ([]byte, error) {
+	if commit == "" {
+		repoPath, err := git.RepoPath()
+		if err != nil {
+			return nil, err
+		}
+		b, err := ioutil.ReadFile(filepath.Join(repoPath, filepath.FromSlash(file)))
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil, nil
+			}
+			return nil, err
+		}
+		return decrypt(file, b)
+	}
+	files, err := git.ListFiles(commit, file)
+	if err != nil || len(files) == 0 {
+		return nil, err
+	}
+	var res []byte
+	err = git.ReadFiles(commit, files[:1], func(_ string, contents []byte) error {
+		res = contents
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return decrypt(file, res)
+}
+
+// CheckRevisionBumps checks that the documents whose requirements changed from
+// prg, at commit since, to rg, at commit at, also have a new revision.
+func (rg reqGraph) CheckRevisionBumps(prg reqGraph, since, at string) error {
+	changed := map[string][]string{}
+	for k := range rg.ChangedSince(prg) {
+		for _, r := range []*Req{prg[k], rg[k]} {
+			if r != nil && r.Level != config.CODE {
+				doc := strings.TrimPrefix(r.Path, "/")
+				changed[doc] = append(changed[doc], r.ID)
+			}
+		}
+	}
+	var docs []string
+	for doc := range changed {
+		docs = append(docs, doc)
+	}
+	sort.Strings(docs)
+
+	var problems findingList
+	for _, doc := range docs {
+		before, err := readFileAt(since, doc)
+		if err != nil {
+			return err
+		}
+		after, err := readFileAt(at, doc)
+		if err != nil {
+			return err
+		}
+		if before == nil || after == nil {
+			// The document was added or removed.
+			continue
+		}
+		ids := uniqueSorted(changed[doc])
+		rev := ParseDocument(doc, after).Revision
+		switch {
+		case rev == "":
+			problems.add(newFinding("document_revision", "Document %s has no revision in its header, required as %s changed.\n", path.Base(doc), strings.Join(ids, ", ")))
+		case rev == ParseDocument(doc, before).Revision:
+			problems.add(newFinding("document_revision", "Document %s changes %s but its revision is still %q.\n", path.Base(doc), strings.Join(ids, ", "), rev))
+		}
+	}
+	return problems.err()
+}
+
+func uniqueSorted(ss []string) []string {
+	sort.Strings(ss)
+	var res []string
+	for i, s := range ss {
+		if i == 0 || s != ss[i-1] {
+			res = append(res, s)
+		}
+	}
+	return res
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/daedaleanai/reqtraq/config"
+)
+
+// ReadReqIDs returns the requirement IDs found in the given file, in the order
+// in which they appear. Anything else in the file is ignored, so it can be a
+// plain list or e.g. the minutes of the meeting which decided what to review.
+func ReadReqIDs(fileName string) ([]string, error) {
+	b, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+	seen := map[string]bool{}
+	var ids []string
+	for _, id := range ReReqID.FindAllString(string(b), -1) {
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("No requirement IDs found in %s", fileName)
+	}
+	return ids, nil
+}
+
+// Extract writes a standalone Markdown document to w, containing the given
+// requirements defined in the certdoc doc together with their attributes and
+// their trace context: parents, children and code files.
+func (rg reqGraph) Extract(w io.Writer, doc string, ids []string) error {
+	docName := strings.TrimSuffix(filepath.Base(doc), filepath.Ext(doc))
+	var reqs []*Req
+	for _, id := range ids {
+		r, ok := rg[id]
+		if !ok {
+			return fmt.Errorf("Requirement %s does not exist", id)
+		}
+		if filepath.Base(r.Path) != filepath.Base(doc) {
+			return fmt.Errorf("Requirement %s is defined in %s, not in %s", id, r.Path, doc)
+		}
+		reqs = append(reqs, r)
+	}
+	sort.Sort(byPosition(reqs))
+
+	fmt.Fprintf(w, "# Requirements extracted from %s\n\n", docName)
+	for _, r := range reqs {
+		fmt.Fprintf(w, "## %s %s\n\n", r.ID, r.Title)
+		if body := strings.TrimSpace(formatHTMLAsMarkdown(r.Body)); body != "" {
+			fmt.Fprintf(w, "%s\n\n", body)
+		}
+
+		fmt.Fprintf(w, "###### Attributes:\n")
+		var keys []string
+		for k := range r.Attributes {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			fmt.Fprintf(w, "- %s: %s\n", k, r.Attributes[k])
+		}
+
+		fmt.Fprintf(w, "\n###### Trace:\n")
+		for _, p := range r.Parents {
+			fmt.Fprintf(w, "- Parent: %s %s\n", p.ID, p.Title)
+		}
+		for _, c := range r.Children {
+			if c.Level == config.CODE {
+				fmt.Fprintf(w, "- Code: %s\n", c.ID)
+			} else {
+				fmt.Fprintf(w, "- Child: %s %s\n", c.ID, c.Title)
+			}
+		}
+		if len(r.Parents) == 0 && len(r.Children) == 0 {
+			fmt.Fprintf(w, "- None\n")
+		}
+		fmt.Fprintf(w, "\n")
+	}
+	return nil
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadReqIDs(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "minutes.txt")
+	assert.Nil(t, os.WriteFile(fileName, []byte("Review of REQ-0-TEST-SWH-003 and REQ-0-TEST-SWH-001.\n"+
+		"REQ-0-TEST-SWH-003 needs a rationale.\n"), 0644))
+	ids, err := ReadReqIDs(fileName)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"REQ-0-TEST-SWH-003", "REQ-0-TEST-SWH-001"}, ids)
+
+	assert.Nil(t, os.WriteFile(fileName, []byte("Nothing to review.\n"), 0644))
+	_, err = ReadReqIDs(fileName)
+	assert.EqualError(t, err, "No requirement IDs found in "+fileName)
+}
+
+func TestReqGraph_Extract(t *testing.T) {
+	rg := reqGraph{}
+	for _, f := range []string{"0-TEST-100-ORD.md", "0-TEST-211-SRD.md", "0-TEST-212-SDD.md"} {
+		assert.Empty(t, parseCertdocToGraph(filepath.Join("testdata/TestExtract", f), rg))
+	}
+	code := filepath.Join(t.TempDir(), "lines.go")
+	assert.Nil(t, os.WriteFile(code, []byte("// @"+"llr REQ-0-TEST-SWL-001\nfunc checkLength() {}\n"), 0644))
+	assert.Nil(t, parseCode("lines.go", code, rg))
+	assert.Nil(t, rg.Resolve())
+
+	srd, sdd := "testdata/TestExtract/0-TEST-211-SRD.md", "testdata/TestExtract/0-TEST-212-SDD.md"
+	for _, tc := range []struct {
+		name string
+		doc  string
+		ids  []string
+		want string
+		err  string
+	}{
+		{
+			name: "sorted by position",
+			doc:  srd,
+			ids:  []string{"REQ-0-TEST-SWH-003", "REQ-0-TEST-SWH-001"},
+			want: `# Requirements extracted from 0-TEST-211-SRD
+
+## REQ-0-TEST-SWH-001 Reject the long lines
+
+The lines longer than 80 characters are rejected.
+
+###### Attributes:
+- PARENTS: REQ-0-TEST-SYS-001
+- RATIONALE: The lines are read into fixed buffers.
+- SAFETY IMPACT: None.
+- VERIFICATION: Test.
+
+###### Trace:
+- Parent: REQ-0-TEST-SYS-001 Parse the flight plans
+- Child: REQ-0-TEST-SWL-001 Check the line length
+
+## REQ-0-TEST-SWH-003 Report the errors
+
+The errors are reported with their line.
+
+###### Attributes:
+- PARENTS: REQ-0-TEST-SYS-001
+- RATIONALE: The flight plans are fixed by hand.
+- SAFETY IMPACT: None.
+- VERIFICATION: Test.
+
+###### Trace:
+- Parent: REQ-0-TEST-SYS-001 Parse the flight plans
+
+`,
+		},
+		{
+			name: "code",
+			doc:  sdd,
+			ids:  []string{"REQ-0-TEST-SWL-001"},
+			want: `# Requirements extracted from 0-TEST-212-SDD
+
+## REQ-0-TEST-SWL-001 Check the line length
+
+The length of each line is checked before copying it.
+
+###### Attributes:
+- PARENTS: REQ-0-TEST-SWH-001
+- RATIONALE: The copy would overflow.
+- SAFETY IMPACT: None.
+- VERIFICATION: Test.
+
+###### Trace:
+- Parent: REQ-0-TEST-SWH-001 Reject the long lines
+- Code: lines.go
+
+`,
+		},
+		{
+			name: "children",
+			doc:  "testdata/TestExtract/0-TEST-100-ORD.md",
+			ids:  []string{"REQ-0-TEST-SYS-001"},
+			want: `# Requirements extracted from 0-TEST-100-ORD
+
+## REQ-0-TEST-SYS-001 Parse the flight plans
+
+The flight plans are parsed.
+
+###### Attributes:
+- RATIONALE: The flight plans are the input.
+- SAFETY IMPACT: None.
+- VERIFICATION: Test.
+
+###### Trace:
+- Child: REQ-0-TEST-SWH-001 Reject the long lines
+- Child: REQ-0-TEST-SWH-002 Skip the comments
+- Child: REQ-0-TEST-SWH-003 Report the errors
+
+`,
+		},
+		{
+			name: "nonexistent",
+			doc:  srd,
+			ids:  []string{"REQ-0-TEST-SWH-001", "REQ-0-TEST-SWH-009"},
+			err:  "Requirement REQ-0-TEST-SWH-009 does not exist",
+		},
+		{
+			name: "other document",
+			doc:  srd,
+			ids:  []string{"REQ-0-TEST-SWL-001"},
+			err:  "Requirement REQ-0-TEST-SWL-001 is defined in " + sdd + ", not in " + srd,
+		},
+	} {
+		var b bytes.Buffer
+		err := rg.Extract(&b, tc.doc, tc.ids)
+		if tc.err != "" {
+			assert.EqualError(t, err, tc.err, tc.name)
+			continue
+		}
+		assert.Nil(t, err, tc.name)
+		assert.Equal(t, tc.want, b.String(), tc.name)
+	}
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/daedaleanai/reqtraq/config"
+	"github.com/daedaleanai/reqtraq/git"
+)
+
+// Finding is a problem reported by a static analysis tool in the code
+// implementing a requirement, see SetFindings.
+type Finding struct {
+	Tool    string
+	Rule    string // The check which found the problem, e.g. bugprone-use-after-move.
+	Level   string // The severity, e.g. warning or error.
+	Message string
+	File    string // As found in the report.
+	Line    int
+}
+
+// sarifLog is the part of a SARIF 2.1 log reqtraq reads.
+type sarifLog struct {
+	Runs []struct {
+		Tool struct {
+			Driver struct {
+				Name string `json:"name"`
+			} `json:"driver"`
+		} `json:"tool"`
+		Results []struct {
+			RuleID  string `json:"ruleId"`
+			Level   string `json:"level"`
+			Message struct {
+				Text string `json:"text"`
+			} `json:"message"`
+			Locations []struct {
+				PhysicalLocation struct {
+					ArtifactLocation struct {
+						URI string `json:"uri"`
+					} `json:"artifactLocation"`
+					Region struct {
+						StartLine int `json:"startLine"`
+					} `json:"region"`
+				} `json:"physicalLocation"`
+			} `json:"locations"`
+		} `json:"results"`
+	} `json:"runs"`
+}
+
+// reClangTidy matches the findings in the output of clang-tidy, e.g.
+// "src/nav.cc:12:3: warning: use of a moved object [bugprone-use-after-move]".
+var reClangTidy = regexp.MustCompile(`^(.+):(\d+):\d+: (warning|error): (.*) \[([^\]]+)\]$`)
+
+// ReadFindings reads the given comma separated static analysis reports, in
+// SARIF, e.g. from clang-tidy via clang-tidy-sarif, or the output of clang-tidy.
+func ReadFindings(fileNames string) ([]Finding, error) {
+	var res []Finding
+	for _, fileName := range splitList(fileNames) {
+		b, err := ioutil.ReadFile(fileName)
+		if err != nil {
+			return nil, err
+		}
+		if strings.HasPrefix(strings.TrimSpace(string(b)), "{") {
+			var log sarifLog
+			if err := json.Unmarshal(b, &log); err != nil {
+				return nil, fmt.Errorf("Error while parsing SARIF log %s: %v", fileName, err)
+			}
+			for _, run := range log.Runs {
+				for _, r := range run.Results {
+					if len(r.Locations) == 0 {
+						continue
+					}
+					l := r.Locations[0].PhysicalLocation
+					level := r.Level
+					if level == "" {
+						// The default level of SARIF.
+						level = "warning"
+					}
+					res = append(res, Finding{Tool: run.Tool.Driver.Name, Rule: r.RuleID, Level: level, Message: r.Message.Text,
+						File: strings.TrimPrefix(l.ArtifactLocation.URI, "file://"), Line: l.Region.StartLine})
+				}
+			}
+			continue
+		}
+		scan := bufio.NewScanner(strings.NewReader(string(b)))
+		for scan.Scan() {
+			if parts := reClangTidy.FindStringSubmatch(scan.Text()); parts != nil {
+				line, _ := strconv.Atoi(parts[2])
+				res = append(res, Finding{Tool: "clang-tidy", Rule: parts[5], Level: parts[3], Message: parts[4], File: parts[1], Line: line})
+			}
+		}
+	}
+	return res, nil
+}
+
+// isReportedPath returns whether the path found in a report, which can be
+// absolute or prefixed, e.g. with the Go module path, is the given file,
+// relative to the repo root.
+func isReportedPath(p, file string) bool {
+	return p == file || strings.HasSuffix(filepath.ToSlash(p), "/"+file)
+}
+
+// SetFindings sets the Findings of the requirements implemented by the code
+// in the working tree with the findings located in the code following their
+// @llr tags, up to the next tags.
+func (rg reqGraph) SetFindings(findings []Finding) error {
+	repoPath, err := git.RepoPath()
+	if err != nil {
+		return err
+	}
+	var files []string
+	for _, r := range rg {
+		if r.Level == config.CODE && r.Kind == "" {
+			files = append(files, strings.TrimPrefix(r.ID, "/"))
+		}
+	}
+	sort.Strings(files)
+	for _, f := range files {
+		var inFile []Finding
+		for _, finding := range findings {
+			if isReportedPath(finding.File, f) {
+				inFile = append(inFile, finding)
+			}
+		}
+		if len(inFile) == 0 {
+			continue
+		}
+		b, err := os.ReadFile(filepath.Join(repoPath, f))
+		if err != nil {
+			return err
+		}
+		for _, region := range taggedRegions(strings.Split(string(b), "\n")) {
+			for _, finding := range inFile {
+				if finding.Line <= region.start || finding.Line > region.end {
+					continue
+				}
+				for _, id := range region.ids {
+					if r, ok := rg[id]; ok {
+						r.Findings = append(r.Findings, finding)
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// WithFindings returns the requirements having static analysis findings, by position.
+func (rg reqGraph) WithFindings() []*Req {
+	var res []*Req
+	for _, r := range rg {
+		if len(r.Findings) > 0 {
+			res = append(res, r)
+		}
+	}
+	sort.Sort(byPosition(res))
+	return res
+}
+
+// setFindings sets the Findings of the requirements from the reports given with --findings, if any.
+func setFindings(rg reqGraph) error {
+	if *fFindings == "" {
+		return nil
+	}
+	findings, err := ReadFindings(*fFindings)
+	if err != nil {
+		return err
+	}
+	return rg.SetFindings(findings)
+}
@@ -0,0 +1,229 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/daedaleanai/reqtraq/git"
+)
+
+// reFence matches the lines opening or closing a fenced code block, whose
+// lines are not formatted.
+var reFence = regexp.MustCompile("^ {0,3}(```|~~~)")
+
+// FormatMarkdownCertdoc returns the Markdown certification document in its
+// canonical form, see formatReqHeadings, formatAttributes and
+// formatBlankLines, with the line endings of its first line. The attributes
+// are ordered as in the given attribute specification.
+func FormatMarkdownCertdoc(fileName string, contents []byte, as []map[string]string) []byte {
+	eol := "\n"
+	if i := strings.Index(string(contents), "\n"); i > 0 && contents[i-1] == '\r' {
+		eol = "\r\n"
+	}
+	lines := strings.Split(strings.ReplaceAll(string(contents), "\r\n", "\n"), "\n")
+	lines = formatReqHeadings(fileName, lines)
+	lines = formatAttributes(lines, as)
+	lines = formatBlankLines(lines)
+	if len(lines) == 0 {
+		return nil
+	}
+	return []byte(strings.Join(lines, eol) + eol)
+}
+
+// markdownHeading returns the level and the text of the heading on the given
+// line, outside the fenced code blocks, or 0.
+func markdownHeading(line string, inFence bool) (int, string) {
+	if inFence {
+		return 0, ""
+	}
+	parts := reATXHeading.FindStringSubmatch(line)
+	if parts == nil {
+		return 0, ""
+	}
+	return len(parts[1]), parts[3]
+}
+
+// formatReqHeadings puts the heading of each requirement one level below the
+// heading of its section, with the headings inside the requirement moved as
+// much. The headings of the parent requirements, see isParentHeading, are
+// section headings.
+func formatReqHeadings(fileName string, lines []string) []string {
+	reqType := docReqType(fileName)
+	sectionLevel, reqLevel, delta := 0, 0, 0
+	inFence := false
+	for i, l := range lines {
+		level, text := markdownHeading(l, inFence)
+		if reFence.MatchString(l) {
+			inFence = !inFence
+		}
+		if level == 0 || strings.TrimSpace(l) == "###### Attributes:" {
+			continue
+		}
+		id := ReReqID.FindString(text)
+		switch {
+		case id != "" && !isParentHeading(id, reqType):
+			reqLevel, delta = level, sectionLevel+1-level
+		case reqLevel > 0 && level > reqLevel:
+			// A heading inside the requirement.
+		default:
+			sectionLevel, reqLevel, delta = level, 0, 0
+			continue
+		}
+		if moved := level + delta; delta != 0 && moved >= 1 && moved <= 5 {
+			lines[i] = strings.Repeat("#", moved) + strings.TrimLeft(strings.TrimSpace(l), "#")
+		}
+	}
+	return lines
+}
+
+// formatAttributes names the attributes of the requirements as configured,
+// see canonicalAttributeName, orders those of the given specification, see
+// orderAttributes, and normalizes the values of those having
+// "normalize: true" on a single line.
+func formatAttributes(lines []string, as []map[string]string) []string {
+	type attribute struct {
+		name  string
+		lines []string
+	}
+	var out []string
+	reqLevel, inFence := 0, false
+	for i := 0; i < len(lines); i++ {
+		l := lines[i]
+		level, text := markdownHeading(l, inFence)
+		if reFence.MatchString(l) {
+			inFence = !inFence
+		}
+		out = append(out, l)
+		if level == 0 {
+			continue
+		}
+		if strings.TrimSpace(l) != "###### Attributes:" {
+			if ReReqID.MatchString(text) {
+				reqLevel = level
+			} else if level <= reqLevel {
+				reqLevel = 0
+			}
+			continue
+		}
+		if reqLevel == 0 {
+			continue
+		}
+
+		// The attributes, after the empty lines formatBlankLines removes, up to
+		// the next empty line or heading.
+		var attrs []attribute
+		j := i + 1
+		for j < len(lines) && strings.TrimSpace(lines[j]) == "" {
+			j++
+		}
+		for ; j < len(lines); j++ {
+			next := lines[j]
+			if strings.TrimSpace(next) == "" || reATXHeading.MatchString(next) {
+				break
+			}
+			m := keywordsRegexp().FindStringSubmatchIndex(next)
+			if m == nil || m[0] != 0 {
+				if len(attrs) == 0 {
+					attrs = append(attrs, attribute{})
+				}
+				// The continuation of the value.
+				attrs[len(attrs)-1].lines = append(attrs[len(attrs)-1].lines, next)
+				continue
+			}
+			name := canonicalAttributeName(next[m[4]:m[5]])
+			value := next[m[1]:]
+			attrs = append(attrs, attribute{name, []string{fmt.Sprintf("- %s:%s", name, value)}})
+		}
+		names := make([]string, len(attrs))
+		for k, a := range attrs {
+			names[k] = a.name
+		}
+		for _, k := range orderAttributes(as, names) {
+			a := attrs[k]
+			if spec, ok := configAttribute(a.name); ok && spec.Normalize {
+				value := strings.TrimPrefix(strings.Join(a.lines, " "), "- "+a.name+":")
+				out = append(out, fmt.Sprintf("- %s: %s", a.name, normalizeAttributeValue(spec, value)))
+				continue
+			}
+			out = append(out, a.lines...)
+		}
+		i = j - 1
+	}
+	return out
+}
+
+// formatBlankLines separates the headings from the other lines by one empty
+// line, except the attributes heading from the attributes, and collapses the
+// other runs of empty lines, outside the fenced code blocks.
+func formatBlankLines(lines []string) []string {
+	var out []string
+	blank := func() bool { return len(out) > 0 && strings.TrimSpace(out[len(out)-1]) == "" }
+	afterHeading, afterAttributes, inFence := false, false, false
+	for _, l := range lines {
+		if inFence {
+			out = append(out, l)
+			inFence = !reFence.MatchString(l)
+			continue
+		}
+		if strings.TrimSpace(l) == "" {
+			if !blank() && !afterAttributes && len(out) > 0 {
+				out = append(out, "")
+			}
+			continue
+		}
+		level, _ := markdownHeading(l, false)
+		if (level > 0 || afterHeading) && !blank() && len(out) > 0 {
+			out = append(out, "")
+		}
+		out = append(out, l)
+		afterAttributes = strings.TrimSpace(l) == "###### Attributes:"
+		afterHeading = level > 0 && !afterAttributes
+		inFence = reFence.MatchString(l)
+	}
+	for blank() {
+		out = out[:len(out)-1]
+	}
+	return out
+}
+
+// FormatCertdocs rewrites the given Markdown certification documents in their
+// canonical form, see FormatMarkdownCertdoc, with the attribute specification
+// as completed by the configuration fragments of their directories, and
+// returns those which changed, or would change when check is true, in which
+// case nothing is written.
+func FormatCertdocs(fileNames []string, configs configTree, as []map[string]string, check bool) ([]string, error) {
+	var changed []string
+	for _, f := range fileNames {
+		if docFormat(f) != "md" {
+			return changed, fmt.Errorf("Formatting %s documents is not supported: %s", docFormat(f), f)
+		}
+		b, err := ioutil.ReadFile(f)
+		if err != nil {
+			return changed, err
+		}
+		abs, err := filepath.Abs(f)
+		if err != nil {
+			return changed, err
+		}
+		repoPath, err := git.RepoPath()
+		if err != nil {
+			return changed, err
+		}
+		rel := filepath.ToSlash(strings.TrimPrefix(abs, repoPath))
+		formatted := FormatMarkdownCertdoc(f, b, configs.attributes(rel, as))
+		if string(formatted) == string(b) {
+			continue
+		}
+		changed = append(changed, f)
+		if check {
+			continue
+		}
+		if err := ioutil.WriteFile(f, formatted, 0644); err != nil {
+			return changed, err
+		}
+	}
+	return changed, nil
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"os"
+	"path"
+	"strings"
+)
+
+// generatedMarker marks the generated certification documents, e.g. the
+// linkified ones, when found in their first lines.
+const generatedMarker = "reqtraq: generated"
+
+// generatedMarkerLines is the number of lines where generatedMarker is looked for.
+const generatedMarkerLines = 10
+
+// hasGeneratedMarker returns whether the given contents have generatedMarker in their first lines.
+func hasGeneratedMarker(r io.Reader) bool {
+	scan := bufio.NewScanner(r)
+	for i := 0; i < generatedMarkerLines && scan.Scan(); i++ {
+		if strings.Contains(scan.Text(), generatedMarker) {
+			return true
+		}
+	}
+	return false
+}
+
+// isGeneratedName returns whether the given file, relative to the repo root,
+// matches one of the generated patterns of the configuration. The patterns
+// without a slash match the base name of the file.
+func isGeneratedName(fileName string) bool {
+	fileName = strings.TrimPrefix(fileName, "/")
+	for _, p := range repoConfig.Generated {
+		name := fileName
+		if !strings.Contains(p, "/") {
+			name = path.Base(fileName)
+		}
+		if ok, _ := path.Match(p, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// isGenerated returns whether the given certification document in repoPath
+// is a generated artifact which must not be parsed, so its requirements are
+// not reported as duplicates of those of its source: either its name matches
+// the generated patterns of the configuration, or it has generatedMarker.
+func isGenerated(repoPath, fileName string) bool {
+	if isGeneratedName(relativePathToRepo(fileName, repoPath)) {
+		return true
+	}
+	f, err := os.Open(fileName)
+	if err != nil {
+		// The errors are reported by the parsing.
+		return false
+	}
+	defer f.Close()
+	return hasGeneratedMarker(f)
+}
+
+// isGeneratedContents is isGenerated for a document read from the git objects.
+func isGeneratedContents(fileName string, contents []byte) bool {
+	return isGeneratedName(fileName) || hasGeneratedMarker(bytes.NewReader(contents))
+}
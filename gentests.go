@@ -0,0 +1,126 @@
+package main
+
+import (
+	"fmt"
+	"go/token"
+	"html"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/daedaleanai/reqtraq/config"
+)
+
+// testSkeletonRequirements returns the low-level requirements with the given
+// IDs, in the given order, for which test skeletons are generated.
+func (rg reqGraph) testSkeletonRequirements(ids []string) ([]*Req, error) {
+	var reqs []*Req
+	for _, id := range ids {
+		r, ok := rg[id]
+		if !ok || r.IsDeleted() {
+			return nil, fmt.Errorf("Requirement %s does not exist", id)
+		}
+		if r.Level != config.LOW {
+			return nil, fmt.Errorf("Requirement %s is not a low-level requirement", id)
+		}
+		reqs = append(reqs, r)
+	}
+	return reqs, nil
+}
+
+// plainText returns the words of the given body without the HTML tags,
+// wrapped to lines of at most width characters.
+func plainText(body string, width int) []string {
+	var lines []string
+	line := ""
+	for _, w := range strings.Fields(html.UnescapeString(reHTMLTag.ReplaceAllString(body, " "))) {
+		if line != "" && len(line)+1+len(w) > width {
+			lines = append(lines, line)
+			line = ""
+		}
+		if line != "" {
+			line += " "
+		}
+		line += w
+	}
+	if line != "" {
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// placeholders returns the attributes of the requirement to be covered by
+// its test, sorted by name, e.g. "VERIFICATION: Test", without the parents.
+func (r *Req) placeholders() []string {
+	var res []string
+	for k, v := range r.Attributes {
+		if k != "PARENTS" && strings.TrimSpace(v) != "" {
+			res = append(res, fmt.Sprintf("%s: %s", k, strings.Join(strings.Fields(v), " ")))
+		}
+	}
+	sort.Strings(res)
+	return res
+}
+
+// testName returns the name of the Go test of the requirement, e.g.
+// TestREQ_0_PROJ_SWL_001.
+func testName(r *Req) string {
+	return "Test" + reNonIdent.ReplaceAllString(r.ID, "_")
+}
+
+// WriteGoTestSkeleton writes a Go test file of the given package with a test
+// per requirement, tagged with @tests @llr so the test file traces to it, its
+// title and body in the comments, and a TODO per attribute. The tests are
+// skipped until they are written.
+func WriteGoTestSkeleton(w io.Writer, pkg string, reqs []*Req) error {
+	if !token.IsIdentifier(pkg) {
+		return fmt.Errorf("Invalid Go package name %q", pkg)
+	}
+	var b strings.Builder
+	b.WriteString("// Skeleton generated by reqtraq gen-tests, to be completed.\n\n")
+	fmt.Fprintf(&b, "package %s\n\nimport \"testing\"\n", pkg)
+	for _, r := range reqs {
+		fmt.Fprintf(&b, "\n// @tests @llr %s\n", r.ID)
+		fmt.Fprintf(&b, "// %s verifies: %s\n", testName(r), r.Title)
+		if body := plainText(string(r.Body), 100); len(body) > 0 {
+			b.WriteString("//\n")
+			for _, l := range body {
+				fmt.Fprintf(&b, "// %s\n", l)
+			}
+		}
+		fmt.Fprintf(&b, "func %s(t *testing.T) {\n", testName(r))
+		for _, p := range r.placeholders() {
+			fmt.Fprintf(&b, "\t// TODO %s\n", p)
+		}
+		fmt.Fprintf(&b, "\tt.Skip(%q)\n}\n", "not implemented: "+r.ID)
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteRobotSkeleton writes a Robot Framework suite with a test case per
+// requirement, tagged with its ID, its title and body as the documentation,
+// and a comment per attribute. The test cases are skipped until they are
+// written.
+func WriteRobotSkeleton(w io.Writer, reqs []*Req) error {
+	var b strings.Builder
+	b.WriteString("*** Settings ***\nDocumentation    Skeleton generated by reqtraq gen-tests, to be completed.\n\n")
+	b.WriteString("*** Test Cases ***\n")
+	for i, r := range reqs {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "%s %s\n", r.ID, r.Title)
+		fmt.Fprintf(&b, "    [Documentation]    %s\n", r.Title)
+		for _, l := range plainText(string(r.Body), 100) {
+			fmt.Fprintf(&b, "    ...    %s\n", l)
+		}
+		fmt.Fprintf(&b, "    [Tags]    %s\n", r.ID)
+		for _, p := range r.placeholders() {
+			fmt.Fprintf(&b, "    # TODO %s\n", p)
+		}
+		fmt.Fprintf(&b, "    Skip    not implemented: %s\n", r.ID)
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
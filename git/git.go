@@ -2,12 +2,17 @@
 package git
 
 import (
+	"bufio"
+	"bytes"
 	"fmt"
+	"io"
 	"io/ioutil"
-	"log"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/daedaleanai/reqtraq/linepipes"
 )
@@ -15,82 +20,103 @@ import (
 var repoNames = make(map[string]string)
 
 // RepoName returns the name for the current git repository (i.e. the repository for the current working directory)
-func RepoName() string {
+func RepoName() (string, error) {
 	cwd, err := os.Getwd()
 	if err != nil {
-		log.Fatal(err)
+		return "", err
 	}
 	path, ok := repoNames[cwd]
 	if ok {
-		return path
+		return path, nil
 	}
 
 	var name string
 	// See details about "working directory" in https://git-scm.com/docs/githooks
 	bare, err := linepipes.Single(linepipes.Run("git", "rev-parse", "--is-bare-repository"))
 	if err != nil {
-		log.Fatal(err)
+		return "", err
 	}
 	if bare == "true" {
 		// A bare repository is a dir identical in structure to the usual .git dir, but
 		// never associated with a working tree.
-		currentDir, err := os.Getwd()
-		if err != nil {
-			log.Fatalf("Failed to get current dir: %r", err)
-		}
-		name = filepath.Base(currentDir)
+		name = filepath.Base(cwd)
 	} else {
 		toplevel, err := linepipes.Single(linepipes.Run("git", "rev-parse", "--show-toplevel"))
 		if err != nil {
-			log.Fatal(err)
+			return "", err
 		}
 		name = filepath.Base(toplevel)
 	}
 	name = strings.TrimSuffix(name, ".git")
 	repoNames[cwd] = name
-	return name
+	return name, nil
 }
 
 var repoPaths = make(map[string]string)
 
 // RepoPath returns the full path of the current git repository's root.
-func RepoPath() string {
+func RepoPath() (string, error) {
 	cwd, err := os.Getwd()
 	if err != nil {
-		log.Fatal(err)
+		return "", err
 	}
 	path, ok := repoPaths[cwd]
 	if ok {
-		return path
+		return path, nil
 	}
 
 	// See details about "working directory" in https://git-scm.com/docs/githooks
 	bare, err := linepipes.Single(linepipes.Run("git", "rev-parse", "--is-bare-repository"))
 	if err != nil {
-		log.Fatal("Failed to check Git repository type. Are you running reqtraq in a Git repo?\n", err)
+		return "", fmt.Errorf("Failed to check Git repository type. Are you running reqtraq in a Git repo?\n%s", err)
 	}
 	if bare == "true" {
-		log.Fatal("Bare repository.")
+		return "", fmt.Errorf("Bare repository.")
 	}
 
 	toplevel, err := linepipes.Single(linepipes.Run("git", "rev-parse", "--show-toplevel"))
 	if err != nil {
-		log.Fatal(err)
+		return "", err
 	}
+	// On Windows git prints e.g. C:/src/repo, make it comparable to the paths found with filepath.Walk.
+	toplevel = filepath.Clean(filepath.FromSlash(toplevel))
 	repoPaths[cwd] = toplevel
-	return toplevel
+	return toplevel, nil
 }
 
 func CurrentBranch() (string, error) {
 	return linepipes.Single(linepipes.Run("git", "rev-parse", "--abbrev-ref", "HEAD"))
 }
 
+// ResolveCommit returns the hash of the commit the given revision refers to, e.g. HEAD or a tag.
+func ResolveCommit(rev string) (string, error) {
+	if strings.HasPrefix(rev, "-") {
+		// Not to be taken for an option of rev-parse.
+		return "", fmt.Errorf("Invalid revision: %s", rev)
+	}
+	return linepipes.Single(linepipes.Run("git", "rev-parse", "--verify", rev+"^{commit}"))
+}
+
+// Describe returns a human readable name of the given commit, from the most
+// recent tag reachable from it, e.g. v1.2-3-g1a2b3c4. When commit is empty,
+// HEAD is described, with a -dirty suffix if the working tree is modified.
+func Describe(commit string) (string, error) {
+	args := []string{"describe", "--tags", "--always"}
+	if commit == "" {
+		args = append(args, "--dirty")
+	} else {
+		args = append(args, commit)
+	}
+	return linepipes.Single(linepipes.Run("git", args...))
+}
+
 func CheckIsAncestor(oldCommit string, newCommit string) error {
 	return linepipes.Out(linepipes.Run("git", "merge-base", "--is-ancestor", oldCommit, newCommit))
 }
 
+// PathInRepo returns the path of the given file relative to the repo root dir, with forward slashes.
 func PathInRepo(localpath string) (string, error) {
-	return linepipes.Single(linepipes.Run("git", "ls-tree", "--full-name", "--name-only", "HEAD", localpath))
+	return linepipes.Single(linepipes.Run("git", "ls-tree", "--full-name", "--name-only", "HEAD", filepath.ToSlash(localpath)))
 }
 
 func FilesChangedInIndex() ([]string, []string, error) {
@@ -185,7 +211,10 @@ func CommitsBetween(commit1, commit2 string) ([]string, error) {
 
 // Clone clones the repo in a new temporary directory and returns it.
 func Clone() (string, error) {
-	repo := RepoPath()
+	repo, err := RepoPath()
+	if err != nil {
+		return "", err
+	}
 	cloneDir, err := ioutil.TempDir("", "clone")
 	if err != nil {
 		return "", err
@@ -203,3 +232,278 @@ func Clone() (string, error) {
 func Checkout(commit string) error {
 	return linepipes.Out(linepipes.Run("git", "checkout", commit))
 }
+
+// Tags returns the names of the n most recently created tags, the most recent first.
+func Tags(n int) ([]string, error) {
+	tags := make([]string, 0)
+	lines, errs := linepipes.Run("git", "tag", "--list", "--sort=-creatordate")
+	for line := range lines {
+		if len(tags) < n {
+			tags = append(tags, line)
+		}
+	}
+	if err := <-errs; err != nil {
+		return tags, fmt.Errorf("Failed to get the list of tags: %s", err)
+	}
+	return tags, nil
+}
+
+// CommitBefore returns the last commit of HEAD made before the given date, or
+// the empty string if there is none.
+func CommitBefore(date time.Time) (string, error) {
+	var commit string
+	lines, errs := linepipes.Run("git", "rev-list", "-1", "--before="+date.Format(time.RFC3339), "HEAD")
+	for line := range lines {
+		commit = line
+	}
+	if err := <-errs; err != nil {
+		return "", fmt.Errorf("Failed to get the last commit before %s: %s", date.Format("2006-01-02"), err)
+	}
+	return commit, nil
+}
+
+// ListFiles returns the files found at the given commit in the given
+// directories, relative to the repo root, with forward slashes.
+func ListFiles(commit string, dirs ...string) ([]string, error) {
+	repo, err := RepoPath()
+	if err != nil {
+		return nil, err
+	}
+	args := []string{"-C", repo, "ls-tree", "-r", "--name-only", "--full-tree", commit, "--"}
+	for _, d := range dirs {
+		d = strings.Trim(filepath.ToSlash(d), "/")
+		if d == "" {
+			d = "."
+		}
+		args = append(args, d)
+	}
+	files := make([]string, 0)
+	lines, errs := linepipes.Run("git", args...)
+	for line := range lines {
+		files = append(files, line)
+	}
+	if err := <-errs; err != nil {
+		return files, fmt.Errorf("Failed to list the files at %s: %s %s", commit, err, strings.Join(files, "\n"))
+	}
+	return files, nil
+}
+
+// ReadFiles reads the given files, relative to the repo root, at the given
+// commit from the git objects, without checking it out, calling fn with the
+// contents of each.
+func ReadFiles(commit string, files []string, fn func(file string, contents []byte) error) error {
+	// The contents are read with a single "git cat-file --batch" instead of
+	// with linepipes, which would split them in lines.
+	repo, err := RepoPath()
+	if err != nil {
+		return err
+	}
+	cmd := exec.Command("git", "-C", repo, "cat-file", "--batch")
+	var stdin bytes.Buffer
+	for _, f := range files {
+		fmt.Fprintf(&stdin, "%s:%s\n", commit, f)
+	}
+	cmd.Stdin = &stdin
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	defer cmd.Wait()
+
+	r := bufio.NewReader(stdout)
+	for _, f := range files {
+		// Each object is written as "<sha1> <type> <size>\n<contents>\n".
+		header, err := r.ReadString('\n')
+		if err != nil {
+			return fmt.Errorf("Failed to read %s at %s: %s", f, commit, err)
+		}
+		fields := strings.Fields(header)
+		if len(fields) != 3 {
+			return fmt.Errorf("Failed to read %s at %s: %s", f, commit, strings.TrimSpace(header))
+		}
+		size, err := strconv.Atoi(fields[2])
+		if err != nil {
+			return fmt.Errorf("Failed to read %s at %s: %s", f, commit, strings.TrimSpace(header))
+		}
+		contents := make([]byte, size+1)
+		if _, err := io.ReadFull(r, contents); err != nil {
+			return fmt.Errorf("Failed to read %s at %s: %s", f, commit, err)
+		}
+		if err := fn(f, contents[:size]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Commit is a commit in the history, see Log.
+type Commit struct {
+	ID      string
+	Time    time.Time
+	Message string
+}
+
+// Log returns the commits reachable from the given commit, HEAD when empty,
+// the most recent first. When grep is not empty, only the commits whose
+// message matches the regular expression are returned, and when paths are
+// given, only the commits changing them.
+func Log(commit, grep string, paths ...string) ([]Commit, error) {
+	if commit == "" {
+		commit = "HEAD"
+	}
+	repo, err := RepoPath()
+	if err != nil {
+		return nil, err
+	}
+	args := []string{"-C", repo, "log", "-z", "--format=%H%n%ct%n%B", "-E"}
+	if grep != "" {
+		args = append(args, "--grep="+grep)
+	}
+	args = append(append(args, commit, "--"), paths...)
+	out, err := exec.Command("git", args...).Output()
+	if err != nil {
+		return nil, fmt.Errorf("Failed to get the history of %s: %s", commit, err)
+	}
+	return parseLog(commit, out)
+}
+
+// LastChange returns the last commit reachable from the given commit changing
+// the number of occurrences of the given string in the given paths, relative
+// to the repo root, as found by git log -S, or empty when none did.
+func LastChange(commit, s string, paths ...string) (string, error) {
+	repo, err := RepoPath()
+	if err != nil {
+		return "", err
+	}
+	args := []string{"-C", repo, "log", "-1", "--format=%H", "-S" + s, commit, "--"}
+	for _, p := range paths {
+		p = strings.Trim(filepath.ToSlash(p), "/")
+		if p == "" {
+			p = "."
+		}
+		args = append(args, p)
+	}
+	out, err := exec.Command("git", args...).Output()
+	if err != nil {
+		return "", fmt.Errorf("Failed to get the history of %s: %s", s, err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// RangeLog returns the commits in the given range, e.g. v1.0..HEAD, the
+// oldest first. When merges is true, only the merge commits are returned.
+func RangeLog(rangeSpec string, merges bool) ([]Commit, error) {
+	repo, err := RepoPath()
+	if err != nil {
+		return nil, err
+	}
+	args := []string{"-C", repo, "log", "-z", "--format=%H%n%ct%n%B", "--reverse"}
+	if merges {
+		args = append(args, "--merges")
+	}
+	args = append(args, rangeSpec, "--")
+	out, err := exec.Command("git", args...).Output()
+	if err != nil {
+		return nil, fmt.Errorf("Failed to get the history of %s: %s", rangeSpec, err)
+	}
+	return parseLog(rangeSpec, out)
+}
+
+// parseLog parses the output of git log -z --format=%H%n%ct%n%B.
+func parseLog(commit string, out []byte) ([]Commit, error) {
+	var commits []Commit
+	for _, record := range strings.Split(string(out), "\x00") {
+		parts := strings.SplitN(record, "\n", 3)
+		if len(parts) < 2 {
+			continue
+		}
+		t, err := strconv.ParseInt(parts[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to get the history of %s: invalid time %q", commit, parts[1])
+		}
+		c := Commit{ID: parts[0], Time: time.Unix(t, 0)}
+		if len(parts) > 2 {
+			c.Message = parts[2]
+		}
+		commits = append(commits, c)
+	}
+	return commits, nil
+}
+
+// BlameLine is a line of a file, with the commit which last changed it.
+type BlameLine struct {
+	Commit  string // The all-zero ID when the line is not committed yet.
+	Author  string
+	Time    time.Time
+	Summary string // The first line of the commit message.
+	Text    string
+}
+
+// Blame returns the lines of the given file, relative to the repo root, at
+// the given commit, or in the working tree when empty, with the commit which
+// last changed each of them.
+func Blame(commit, file string) ([]BlameLine, error) {
+	repo, err := RepoPath()
+	if err != nil {
+		return nil, err
+	}
+	args := []string{"-C", repo, "blame", "--line-porcelain"}
+	if commit != "" {
+		args = append(args, commit)
+	}
+	args = append(args, "--", file)
+	out, err := exec.Command("git", args...).Output()
+	if err != nil {
+		return nil, fmt.Errorf("Failed to blame %s: %s", file, err)
+	}
+	var (
+		res  []BlameLine
+		line BlameLine
+	)
+	header := true
+	for _, l := range strings.Split(string(out), "\n") {
+		switch {
+		case header:
+			// "<sha1> <original line> <final line> [<lines in group>]"
+			line = BlameLine{Commit: strings.SplitN(l, " ", 2)[0]}
+			header = l == ""
+		case strings.HasPrefix(l, "\t"):
+			line.Text = l[1:]
+			res = append(res, line)
+			header = true
+		case strings.HasPrefix(l, "author "):
+			line.Author = strings.TrimPrefix(l, "author ")
+		case strings.HasPrefix(l, "author-time "):
+			t, err := strconv.ParseInt(strings.TrimPrefix(l, "author-time "), 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("Failed to blame %s: invalid time %q", file, l)
+			}
+			line.Time = time.Unix(t, 0)
+		case strings.HasPrefix(l, "summary "):
+			line.Summary = strings.TrimPrefix(l, "summary ")
+		}
+	}
+	return res, nil
+}
+
+// LineLog returns the commits reachable from the given commit, HEAD when
+// empty, and made since the given time, which changed the given lines of the
+// file, the first being 1, following their history. The most recent first.
+func LineLog(commit, file string, first, last int, since time.Time) ([]Commit, error) {
+	if commit == "" {
+		commit = "HEAD"
+	}
+	repo, err := RepoPath()
+	if err != nil {
+		return nil, err
+	}
+	out, err := exec.Command("git", "-C", repo, "log", "-z", "--format=%H%n%ct%n%B", "--no-patch",
+		fmt.Sprintf("--since=%d", since.Unix()), fmt.Sprintf("-L%d,%d:%s", first, last, file), commit).Output()
+	if err != nil {
+		return nil, fmt.Errorf("Failed to get the history of %s:%d-%d: %s", file, first, last, err)
+	}
+	return parseLog(commit, out)
+}
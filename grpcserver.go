@@ -0,0 +1,191 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/daedaleanai/reqtraq/config"
+	"github.com/daedaleanai/reqtraq/reqtraqpb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// grpcServer implements the gRPC service defined in reqtraqpb/reqtraq.proto.
+type grpcServer struct {
+	reqtraqpb.UnimplementedReqtraqServer
+}
+
+// serveGRPC runs the gRPC server until it receives SIGINT or SIGTERM, then
+// waits for the calls being handled, as serve does.
+func serveGRPC(addr string) error {
+	if strings.HasPrefix(addr, ":") {
+		addr = "localhost" + addr
+	}
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	srv := grpc.NewServer()
+	reqtraqpb.RegisterReqtraqServer(srv, &grpcServer{})
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	errs := make(chan error, 1)
+	go func() {
+		errs <- srv.Serve(ln)
+	}()
+	fmt.Printf("gRPC server started on %s\n", addr)
+
+	select {
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+	}
+	slog.Info("shutting down", "timeout", shutdownTimeout)
+	done := make(chan struct{})
+	go func() {
+		srv.GracefulStop()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(shutdownTimeout):
+		srv.Stop()
+	}
+	return nil
+}
+
+// graphAt returns the requirement graph at the given commit, see buildGraph,
+// failing when the commit does not resolve or the graph cannot be built at all.
+func graphAt(commit string) (reqGraph, error) {
+	commit, err := resolveAt(commit)
+	if err != nil {
+		return nil, err
+	}
+	rg, err := buildGraph(commit)
+	if rg == nil {
+		return nil, status.Errorf(codes.FailedPrecondition, "cannot build the requirement graph: %v", err)
+	}
+	return rg, nil
+}
+
+// resolveAt returns the ID of the commit given in a request, or empty for the
+// working tree. Only the refs known to git are accepted.
+func resolveAt(commit string) (string, error) {
+	key, err := graphKey(commit)
+	if err != nil {
+		return "", status.Errorf(codes.InvalidArgument, "unknown commit %q: %v", commit, err)
+	}
+	return key, nil
+}
+
+// requirementMessage returns the requirement, or the code file, as a message.
+func requirementMessage(r *Req) *reqtraqpb.Requirement {
+	m := &reqtraqpb.Requirement{
+		Id:         r.ID,
+		Type:       r.ReqType(),
+		Title:      r.Title,
+		Body:       string(r.Body),
+		Attributes: r.Attributes,
+		ParentIds:  r.ParentIds,
+		Path:       repoRelative(r.Path),
+		Status:     r.Status.String(),
+		Deleted:    r.IsDeleted(),
+	}
+	for _, c := range r.Children {
+		m.ChildIds = append(m.ChildIds, c.ID)
+	}
+	sort.Strings(m.ChildIds)
+	return m
+}
+
+func (s *grpcServer) GetRequirement(ctx context.Context, req *reqtraqpb.GetRequirementRequest) (*reqtraqpb.Requirement, error) {
+	rg, err := graphAt(req.At)
+	if err != nil {
+		return nil, err
+	}
+	r := rg[req.Id]
+	if r == nil || r.Level == config.CODE {
+		return nil, status.Errorf(codes.NotFound, "requirement %s not found", req.Id)
+	}
+	return requirementMessage(r), nil
+}
+
+func (s *grpcServer) Query(req *reqtraqpb.QueryRequest, stream reqtraqpb.Reqtraq_QueryServer) error {
+	rg, err := graphAt(req.At)
+	if err != nil {
+		return err
+	}
+	reqs, err := rg.Query(req.Query)
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	for _, r := range reqs {
+		if err := stream.Send(requirementMessage(r)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *grpcServer) Diff(req *reqtraqpb.DiffRequest, stream reqtraqpb.Reqtraq_DiffServer) error {
+	if req.Since == "" {
+		return status.Error(codes.InvalidArgument, "missing since")
+	}
+	prg, err := graphAt(req.Since)
+	if err != nil {
+		return err
+	}
+	rg, err := graphAt(req.At)
+	if err != nil {
+		return err
+	}
+	c := NewChangelog(rg, prg, req.Since, req.At)
+	for _, kc := range []struct {
+		kind    reqtraqpb.Change_Kind
+		changes []ReqChange
+	}{
+		{reqtraqpb.Change_ADDED, c.Added},
+		{reqtraqpb.Change_REMOVED, c.Removed},
+		{reqtraqpb.Change_MODIFIED, c.Modified},
+		{reqtraqpb.Change_CODE, c.Code},
+	} {
+		for _, ch := range kc.changes {
+			if err := stream.Send(&reqtraqpb.Change{Kind: kc.kind, Id: ch.ID, Title: ch.Title, Changes: ch.Changes}); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (s *grpcServer) Check(req *reqtraqpb.CheckRequest, stream reqtraqpb.Reqtraq_CheckServer) error {
+	at, err := resolveAt(req.At)
+	if err != nil {
+		return err
+	}
+	var problems error
+	if at == "" && *fSnapshot == "" {
+		problems = precommit(*fCertdocPath, *fCodePath, *fReportJsonConfPath)
+	} else {
+		var rg reqGraph
+		rg, problems = buildGraph(at)
+		if rg == nil {
+			return status.Errorf(codes.FailedPrecondition, "cannot build the requirement graph: %v", problems)
+		}
+	}
+	for _, f := range findingsOf(problems) {
+		if err := stream.Send(&reqtraqpb.Finding{Type: findingType(f), Message: strings.TrimSpace(f.Error())}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
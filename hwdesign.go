@@ -0,0 +1,42 @@
+package main
+
+import (
+	"path"
+	"regexp"
+	"strings"
+)
+
+// hwDesignKind is the Kind of the hardware design artifacts in the graph.
+const hwDesignKind = "HW-DESIGN"
+
+// reHWLReference matches the references of the hardware design artifacts to
+// the HWL requirements they implement. The tag can be anywhere on the line,
+// e.g. in a comment of a constraint file, "# @llr REQ-0-DDLN-HWL-001", or in
+// a field of a component exported in a netlist,
+// `(field (name "Req") "@llr REQ-0-DDLN-HWL-001")`.
+var reHWLReference = regexp.MustCompile(`@llr\s*(REQ-\d+-\w+-HWL-\d+(?:\.\d+)?(?:(?:\s*,\s*|\s+)REQ-\d+-\w+-HWL-\d+(?:\.\d+)?)*)`)
+
+// isHWDesignFile returns whether the file is a hardware design artifact, by
+// its extension: a KiCad or Altium netlist, or a FPGA/PLD constraint file.
+func isHWDesignFile(fileName string) bool {
+	switch strings.ToLower(path.Ext(fileName)) {
+	case ".net", ".xdc", ".sdc", ".ucf", ".pcf", ".qsf", ".lpf", ".pdc":
+		return true
+	}
+	return false
+}
+
+// referenceRegexp returns the regexp matching the references to requirements
+// in the given code file, and the kind of artifact of the file.
+func referenceRegexp(fileName string) (*regexp.Regexp, string) {
+	if isHWDesignFile(fileName) {
+		return reHWLReference, hwDesignKind
+	}
+	if re := testSuiteReference(fileName); re != nil {
+		return re, testKind
+	}
+	if isCTestFile(fileName) || isGoTestFile(fileName) {
+		return reTestsReference, ""
+	}
+	return reLLRReference, ""
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"fmt"
+	"go/token"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/daedaleanai/reqtraq/config"
+)
+
+// SatisfiedIDs returns the IDs of the requirements whose graph is complete,
+// as in the manifest, of the given component, see ExtractComponent, or of the
+// whole graph when empty, sorted.
+func (rg reqGraph) SatisfiedIDs(component string) ([]string, error) {
+	ids := []string{}
+	inComponent := false
+	for _, r := range rg {
+		if r.Level == config.CODE || r.IsDeleted() || component != "" && !r.inComponent(component) {
+			continue
+		}
+		inComponent = true
+		if r.Status == COMPLETED {
+			ids = append(ids, r.ID)
+		}
+	}
+	if component != "" && !inComponent {
+		return nil, fmt.Errorf("No requirement of component %s", component)
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
+
+// IDHeader lists the requirements implemented by a build, to be compiled into
+// its binary, so the fielded software can report its requirement baseline.
+// It has no generation time, so the builds stay reproducible.
+type IDHeader struct {
+	Component string   // Empty for the whole graph.
+	Ref       string   // The commit as described by git, see git.Describe.
+	GraphHash string   // See reqGraph.Hash.
+	IDs       []string // See SatisfiedIDs.
+}
+
+// idHeaderFormat returns the format of the given header file: go for the Go
+// files, c for the others.
+func idHeaderFormat(fileName string) string {
+	if filepath.Ext(fileName) == ".go" {
+		return "go"
+	}
+	return "c"
+}
+
+// rePackageClause matches the package clause of a Go file.
+var rePackageClause = regexp.MustCompile(`(?m)^package (\w+)`)
+
+// goPackage returns the package of the Go files of the given directory, the
+// name of the directory when it has none.
+func goPackage(dir string) string {
+	files, _ := filepath.Glob(filepath.Join(dir, "*.go"))
+	for _, f := range files {
+		if strings.HasSuffix(f, "_test.go") {
+			continue
+		}
+		b, err := ioutil.ReadFile(f)
+		if err != nil {
+			continue
+		}
+		if m := rePackageClause.FindSubmatch(b); m != nil {
+			return string(m[1])
+		}
+	}
+	abs, _ := filepath.Abs(dir)
+	return filepath.Base(abs)
+}
+
+// reNonIdent matches the characters which cannot be part of an identifier.
+var reNonIdent = regexp.MustCompile(`[^A-Za-z0-9_]`)
+
+// WriteGo writes the header as a Go file of the given package.
+func (h *IDHeader) WriteGo(w io.Writer, pkg string) error {
+	if !token.IsIdentifier(pkg) {
+		return fmt.Errorf("Invalid Go package name %q", pkg)
+	}
+	var b strings.Builder
+	b.WriteString("// Code generated by reqtraq idheader. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&b, "package %s\n\n", pkg)
+	fmt.Fprintf(&b, "// Component is the component whose requirements are listed, empty for all.\nconst Component = %q\n\n", h.Component)
+	fmt.Fprintf(&b, "// Ref is the commit the requirements were read from, as described by git.\nconst Ref = %q\n\n", h.Ref)
+	fmt.Fprintf(&b, "// GraphHash is the hash of the requirement graph, see \"reqtraq help hash\".\nconst GraphHash = %q\n\n", h.GraphHash)
+	b.WriteString("// Requirements are the IDs of the requirements implemented, sorted.\nvar Requirements = []string{\n")
+	for _, id := range h.IDs {
+		fmt.Fprintf(&b, "\t%q,\n", id)
+	}
+	b.WriteString("}\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteC writes the header as a C header file, guarded by the given name.
+func (h *IDHeader) WriteC(w io.Writer, guard string) error {
+	guard = strings.ToUpper(reNonIdent.ReplaceAllString(guard, "_"))
+	var b strings.Builder
+	b.WriteString("/* Generated by reqtraq idheader. DO NOT EDIT. */\n")
+	fmt.Fprintf(&b, "#ifndef %s\n#define %s\n\n", guard, guard)
+	b.WriteString("/* The component whose requirements are listed, empty for all. */\n")
+	fmt.Fprintf(&b, "#define REQTRAQ_COMPONENT %q\n", h.Component)
+	b.WriteString("/* The commit the requirements were read from, as described by git. */\n")
+	fmt.Fprintf(&b, "#define REQTRAQ_REF %q\n", h.Ref)
+	b.WriteString("/* The hash of the requirement graph, see \"reqtraq help hash\". */\n")
+	fmt.Fprintf(&b, "#define REQTRAQ_GRAPH_HASH %q\n\n", h.GraphHash)
+	b.WriteString("/* The IDs of the requirements implemented, sorted. */\n")
+	fmt.Fprintf(&b, "#define REQTRAQ_REQUIREMENT_COUNT %d\n", len(h.IDs))
+	b.WriteString("static const char *const reqtraq_requirements[REQTRAQ_REQUIREMENT_COUNT + 1] = {\n")
+	for _, id := range h.IDs {
+		fmt.Fprintf(&b, "\t%q,\n", id)
+	}
+	b.WriteString("\t0,\n};\n\n")
+	fmt.Fprintf(&b, "#endif /* %s */\n", guard)
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/daedaleanai/reqtraq/config"
+)
+
+// CsvMapping describes which CSV columns hold which parts of the requirements.
+// Columns are identified by their header. ID is optional: when missing, the
+// requirements are numbered in the order in which they appear.
+//
+// For example:
+//
+//	{
+//		"id": "Req ID",
+//		"title": "Name",
+//		"body": "Description",
+//		"parents": "Traces to",
+//		"attributes": {"Rationale": "Why", "Verification": "Method", "Safety Impact": "Safety"}
+//	}
+type CsvMapping struct {
+	ID         string
+	Title      string
+	Body       string
+	Parents    string
+	Attributes map[string]string
+}
+
+// ReadCsvMapping reads the column mapping from the given json file.
+func ReadCsvMapping(fileName string) (*CsvMapping, error) {
+	b, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+	var m CsvMapping
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil, fmt.Errorf("Error while parsing column mapping %s: %v", fileName, err)
+	}
+	if m.Title == "" {
+		return nil, fmt.Errorf("Column mapping %s does not specify the title column", fileName)
+	}
+	for k := range m.Attributes {
+		if !keywordsRegexp().MatchString(k + ":") {
+			return nil, fmt.Errorf("Column mapping %s contains unknown attribute %q", fileName, k)
+		}
+	}
+	return &m, nil
+}
+
+// ImportCsv reads the requirements from the CSV file in and writes them as
+// the Markdown certification document out, or appends them to it when it
+// exists and force is true. The document type and thus the type of the
+// generated requirement IDs are taken from the name of out. The document is
+// written only when it is valid.
+func ImportCsv(in, out string, m *CsvMapping, force bool) error {
+	if err := IsValidDocName(out); err != nil {
+		return err
+	}
+	if strings.ToLower(path.Ext(out)) != ".md" {
+		return fmt.Errorf("Requirements can only be imported into Markdown documents, not %s", out)
+	}
+	r, err := os.Open(in)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	records, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return fmt.Errorf("Error while reading %s: %v", in, err)
+	}
+	if len(records) == 0 {
+		return fmt.Errorf("%s is empty", in)
+	}
+
+	columns := map[string]int{}
+	for i, h := range records[0] {
+		columns[strings.TrimSpace(h)] = i
+	}
+	column := func(record []string, name string) (string, error) {
+		if name == "" {
+			return "", nil
+		}
+		i, ok := columns[name]
+		if !ok {
+			return "", fmt.Errorf("Column %q not found in %s", name, in)
+		}
+		if i >= len(record) {
+			return "", nil
+		}
+		return strings.TrimSpace(record[i]), nil
+	}
+
+	docName := strings.TrimSuffix(filepath.Base(out), filepath.Ext(out))
+	fNameComps := strings.Split(docName, "-")
+	reqType := config.DocTypeToReqType[fNameComps[len(fNameComps)-1]]
+	if reqType == "" {
+		return fmt.Errorf("Document %s cannot contain requirements", out)
+	}
+
+	// The requirements are appended to an existing document, numbered after its
+	// requirements, leaving the rest of it untouched.
+	doc := NewMarkdownDoc(docName)
+	first := 1
+	// The IDs of the document and of the CSV, which the generated IDs skip.
+	used := map[string]bool{}
+	if _, err := os.Stat(out); err == nil {
+		if !force {
+			return fmt.Errorf("%s already exists, use --force to append the requirements to it", out)
+		}
+		if doc, err = ReadMarkdownDoc(out); err != nil {
+			return err
+		}
+		next, err := NextId(out)
+		if err != nil {
+			return err
+		}
+		parts := ReReqID.FindStringSubmatch(next)
+		if first, err = strconv.Atoi(parts[len(parts)-1]); err != nil {
+			return err
+		}
+		b, err := ioutil.ReadFile(out)
+		if err != nil {
+			return err
+		}
+		for _, id := range ReReqID.FindAllString(string(b), -1) {
+			used[id] = true
+		}
+	}
+	for _, record := range records[1:] {
+		id, err := column(record, m.ID)
+		if err != nil {
+			return err
+		}
+		used[id] = true
+	}
+	var attrs []string
+	for k := range m.Attributes {
+		attrs = append(attrs, k)
+	}
+	sort.Strings(attrs)
+	next := first
+	for _, record := range records[1:] {
+		values := map[string]string{}
+		for k, name := range map[string]string{"id": m.ID, "title": m.Title, "body": m.Body, "parents": m.Parents} {
+			if values[k], err = column(record, name); err != nil {
+				return err
+			}
+		}
+		id := values["id"]
+		for ; id == ""; next++ {
+			if generated := fmt.Sprintf("REQ-%s-%s-%s-%03d", fNameComps[0], fNameComps[1], reqType, next); !used[generated] {
+				id = generated
+			}
+		}
+		names := attrs
+		attributes := map[string]string{}
+		for _, k := range attrs {
+			if attributes[k], err = column(record, m.Attributes[k]); err != nil {
+				return err
+			}
+		}
+		if parents := ReReqID.FindAllString(values["parents"], -1); len(parents) > 0 {
+			names = append(names[:len(names):len(names)], "Parents")
+			attributes["Parents"] = strings.Join(parents, ", ")
+		}
+		doc.AppendReq(FormatMarkdownReq(id, values["title"], values["body"], names, attributes))
+	}
+	// The document is written next to out, with the same name so it can be
+	// parsed, and replaces it only when valid.
+	dir, err := ioutil.TempDir(filepath.Dir(out), ".import")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+	tmp := filepath.Join(dir, filepath.Base(out))
+	if err := doc.WriteFile(tmp); err != nil {
+		return err
+	}
+	if errs := parseCertdocToGraph(tmp, reqGraph{}); len(errs) > 0 {
+		msg := fmt.Sprintf("Problems found while parsing the imported %s:\n", out)
+		for _, e := range errs {
+			msg += "\t" + strings.ReplaceAll(e.Error(), tmp, out) + "\n"
+		}
+		return fmt.Errorf(msg)
+	}
+	return os.Rename(tmp, out)
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestImportCsv(t *testing.T) {
+	dir, err := ioutil.TempDir("", "TestImportCsv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	in := filepath.Join(dir, "in.csv")
+	err = ioutil.WriteFile(in, []byte(`Name,Description,Why,Method,Traces to
+First,"Line one
+line two",Because,Test,REQ-0-TEST-SYS-001
+Second,,Because,Demonstration,"REQ-0-TEST-SYS-001; REQ-0-TEST-SYS-002"
+`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := &CsvMapping{Title: "Name", Body: "Description", Parents: "Traces to",
+		Attributes: map[string]string{"Rationale": "Why", "Verification": "Method"}}
+
+	out := filepath.Join(dir, "0-TEST-211-SRD.md")
+	if err := ImportCsv(in, out, m, false); err != nil {
+		t.Fatal(err)
+	}
+	b, err := ioutil.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, `# 0-TEST-211-SRD
+
+## Requirements
+
+### REQ-0-TEST-SWH-001 First
+
+Line one
+line two
+
+###### Attributes:
+- Rationale: Because
+- Verification: Test
+- Parents: REQ-0-TEST-SYS-001
+
+### REQ-0-TEST-SWH-002 Second
+
+###### Attributes:
+- Rationale: Because
+- Verification: Demonstration
+- Parents: REQ-0-TEST-SYS-001, REQ-0-TEST-SYS-002
+
+`, string(b))
+
+	// The requirements are appended to the existing document.
+	err = ioutil.WriteFile(in, []byte(`Name,Description,Why,Method,Traces to
+Third,,Because,Test,
+`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(out, append(b, "## Appendix\n"...), 0644); err != nil {
+		t.Fatal(err)
+	}
+	err = ImportCsv(in, out, m, false)
+	assert.EqualError(t, err, out+" already exists, use --force to append the requirements to it")
+	if err := ImportCsv(in, out, m, true); err != nil {
+		t.Fatal(err)
+	}
+	b2, err := ioutil.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, string(b)+`### REQ-0-TEST-SWH-003 Third
+
+###### Attributes:
+- Rationale: Because
+- Verification: Test
+
+## Appendix
+`, string(b2))
+
+	m.Title = "Missing"
+	err = ImportCsv(in, out, m, true)
+	assert.EqualError(t, err, `Column "Missing" not found in `+in)
+
+	m.Title = "Name"
+	err = ImportCsv(in, filepath.Join(dir, "0-TEST-211-SRD.lyx"), m, false)
+	assert.Contains(t, err.Error(), "can only be imported into Markdown documents")
+
+	// The document is kept as it was when the result is invalid.
+	err = ioutil.WriteFile(in, []byte("Name,Description,Why,Method,Traces to\nFourth,,,,\nFourth,,,,\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.ID = "Name"
+	err = ImportCsv(in, out, m, true)
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "Problems found while parsing the imported "+out)
+	}
+	b3, err := ioutil.ReadFile(out)
+	assert.Nil(t, err)
+	assert.Equal(t, string(b2), string(b3))
+	files, err := ioutil.ReadDir(dir)
+	assert.Nil(t, err)
+	assert.Len(t, files, 2)
+}
+
+func TestImportCsv_GeneratedIDs(t *testing.T) {
+	dir := t.TempDir()
+	in := filepath.Join(dir, "in.csv")
+	err := ioutil.WriteFile(in, []byte(`ID,Name,Why
+,First,Because
+REQ-0-TEST-SWH-005,Second,Because
+,Third,Because
+REQ-0-TEST-SWH-003,Fourth,Because
+,Fifth,Because
+,Sixth,Because
+`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := &CsvMapping{ID: "ID", Title: "Name", Attributes: map[string]string{"Rationale": "Why"}}
+	out := filepath.Join(dir, "0-TEST-211-SRD.md")
+	if err := ImportCsv(in, out, m, false); err != nil {
+		t.Fatal(err)
+	}
+	rg := reqGraph{}
+	assert.Empty(t, parseCertdocToGraph(out, rg))
+	var reqs []*Req
+	for _, r := range rg {
+		reqs = append(reqs, r)
+	}
+	sort.Sort(byPosition(reqs))
+	var ids []string
+	for _, r := range reqs {
+		ids = append(ids, r.ID+" "+r.Title)
+	}
+	assert.Equal(t, []string{"REQ-0-TEST-SWH-001 First", "REQ-0-TEST-SWH-005 Second", "REQ-0-TEST-SWH-002 Third",
+		"REQ-0-TEST-SWH-003 Fourth", "REQ-0-TEST-SWH-004 Fifth", "REQ-0-TEST-SWH-006 Sixth"}, ids)
+}
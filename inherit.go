@@ -0,0 +1,83 @@
+package main
+
+import (
+	"os"
+	"strings"
+
+	"github.com/daedaleanai/reqtraq/config"
+	"github.com/daedaleanai/reqtraq/git"
+)
+
+// attribute returns the value of the given attribute of the requirement, its
+// own or inherited.
+func (r *Req) attribute(name string) (string, bool) {
+	if v, ok := r.Attributes[name]; ok {
+		return v, true
+	}
+	v, ok := r.Inherited[name]
+	return v, ok
+}
+
+// InheritAttributes sets the Inherited attributes of the requirements missing
+// the attributes specified with "inherit", as overridden by the configuration
+// fragments of their directories, to the values of their closest ancestors.
+// With several parents, the first having a value wins. The Typed attributes
+// are parsed again to include the inherited ones.
+func (rg reqGraph) InheritAttributes(configs configTree, as []map[string]string) {
+	done := map[*Req]bool{}
+	var inherit func(r *Req)
+	inherit = func(r *Req) {
+		if done[r] {
+			return
+		}
+		done[r] = true
+		for _, p := range r.Parents {
+			inherit(p)
+		}
+		spec := configs.attributes(r.Path, as)
+		for _, a := range spec {
+			if a["inherit"] != "true" {
+				continue
+			}
+			name := strings.ToUpper(a["name"])
+			if _, ok := r.Attributes[name]; ok {
+				continue
+			}
+			for _, p := range r.Parents {
+				if v, ok := p.attribute(name); ok {
+					if r.Inherited == nil {
+						r.Inherited = map[string]string{}
+					}
+					r.Inherited[name] = v
+					break
+				}
+			}
+		}
+		r.parseTypedAttributes(spec)
+	}
+	for _, r := range rg {
+		if r.Level != config.CODE {
+			inherit(r)
+		}
+	}
+}
+
+// inheritAttributes sets the Inherited attributes of the requirements as
+// specified by --attributes, if it exists.
+func inheritAttributes(rg reqGraph) error {
+	reportConf, err := ReadJsonConf(*fReportJsonConfPath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	repoPath, err := git.RepoPath()
+	if err != nil {
+		return err
+	}
+	// The fragments have been validated when building the graph.
+	configs, _ := loadConfigTree(repoPath, append(certdocRoots(*fCertdocPath), *fCodePath)...)
+	rg.InheritAttributes(configs, reportConf.Attributes)
+	return nil
+}
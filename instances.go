@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// instanceOfAttribute is set on the requirements instantiated from a generic
+// requirement, to its ID.
+const instanceOfAttribute = "INSTANCE OF"
+
+// Instances returns the generic requirement r followed by the concrete
+// requirements instantiated from it, one for each row of its parameter table,
+// or r alone when it has none. The table is the Instances attribute, with the
+// rows separated by semicolons, e.g.
+// "Instances: channel=1, address=0x10; channel=2, address=0x20".
+// The placeholders in the title and the body, see rePlaceholder, are replaced
+// with the values of the row. The IDs of the instances are the ID of r
+// followed by a dot and the row number, e.g. REQ-0-DDLN-HWH-010.2 for the
+// second row of REQ-0-DDLN-HWH-010, so they can be referenced independently
+// without taking the IDs of the other requirements. Their parent is r, which
+// stays in the graph, so the references to it still resolve.
+func (r *Req) Instances() ([]*Req, error) {
+	table, ok := r.Attributes["INSTANCES"]
+	if !ok {
+		return []*Req{r}, nil
+	}
+	var res []*Req
+	for i, row := range strings.Split(table, ";") {
+		if strings.TrimSpace(row) == "" {
+			continue
+		}
+		inst := *r
+		inst.ID = fmt.Sprintf("%s.%d", r.ID, i+1)
+		inst.ParentIds = []string{r.ID}
+		inst.Attributes = map[string]string{instanceOfAttribute: r.ID}
+		for k, v := range r.Attributes {
+			if k != "INSTANCES" {
+				inst.Attributes[k] = v
+			}
+		}
+		inst.Attributes["PARENTS"] = r.ID
+		for _, p := range strings.Split(row, ",") {
+			parts := strings.SplitN(p, "=", 2)
+			name := strings.TrimSpace(parts[0])
+			if len(parts) != 2 || !reParamName.MatchString(name) {
+				return nil, newFinding("malformed_requirement", "requirement %s contains malformed instance %q, expected name=value pairs separated by commas", r.ID, strings.TrimSpace(row))
+			}
+			inst.Attributes[paramAttribute(name)] = strings.TrimSpace(parts[1])
+		}
+		inst.Title = inst.Expand(r.Title)
+		inst.Body = inst.ExpandedBody()
+		res = append(res, &inst)
+	}
+	if len(res) == 0 {
+		return nil, newFinding("malformed_requirement", "requirement %s contains no instances", r.ID)
+	}
+	return append([]*Req{r}, res...), nil
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"fmt"
+	"html/template"
+	"sort"
+	"strings"
+)
+
+// catalogs are the translations of the labels and headings of the reports, by
+// language. The English labels are used as message IDs, and when a message is
+// missing from a catalog.
+var catalogs = map[string]map[string]string{
+	"en": {},
+	"de": {
+		"Reqtraq Report":                  "Reqtraq-Bericht",
+		"Top Down Tracing":                "Top-down-Rückverfolgung",
+		"Bottom Up Tracing":               "Bottom-up-Rückverfolgung",
+		"Issues":                          "Probleme",
+		"Dangling Requirements:":          "Nicht verknüpfte Anforderungen:",
+		"No dangling HLRs or LLRs found.": "Keine nicht verknüpften HLRs oder LLRs gefunden.",
+		"Requirements by Owner":           "Anforderungen nach Verantwortlichen",
+		"No owner":                        "Kein Verantwortlicher",
+		"Requirements by Team":            "Anforderungen nach Teams",
+		"No team":                         "Kein Team",
+		"Requirement":                     "Anforderung",
+		"Requirements":                    "Anforderungen",
+		"Reviewers":                       "Prüfer",
+		"None":                            "Keine",
+		"Empty graph":                     "Leerer Graph",
+		"Dashboard":                       "Übersicht",
+		"Previous baselines":              "Frühere Baselines",
+		"Level":                           "Ebene",
+		"With children":                   "Mit Kindern",
+		"With code":                       "Mit Code",
+		"With tests":                      "Mit Tests",
+		"With passing tests":              "Mit bestandenen Tests",
+		"Filter Criteria:":                "Filterkriterien:",
+		"No children":                     "Keine Kinder",
+		"No parents":                      "Keine Eltern",
+		"Code Files:":                     "Codedateien:",
+		"No code files":                   "Keine Codedateien",
+		"Changelists:":                    "Änderungslisten:",
+		"No changelist":                   "Keine Änderungsliste",
+		"Problem Reports:":                "Problemberichte:",
+		"No problem reports":              "Keine Problemberichte",
+		"Status":                          "Status",
+		"Status:":                         "Status:",
+		"Document":                        "Dokument",
+		"Title":                           "Titel",
+		"Revision":                        "Revision",
+		"Date":                            "Datum",
+		"Approvers":                       "Freigaben",
+		"NOT STARTED":                     "NICHT BEGONNEN",
+		"STARTED":                         "BEGONNEN",
+		"COMPLETED":                       "ABGESCHLOSSEN",
+		"satisfies":                       "erfüllt",
+		"satisfied by":                    "erfüllt durch",
+		"refines":                         "verfeinert",
+		"refined by":                      "verfeinert durch",
+		"conflicts with":                  "steht im Konflikt mit",
+		"depends on":                      "hängt ab von",
+		"needed by":                       "benötigt von",
+		"references":                      "referenziert",
+		"referenced by":                   "referenziert von",
+		"mentions":                        "erwähnt",
+		"mentioned by":                    "erwähnt von",
+		"Suspect links:":                  "Verdächtige Verknüpfungen:",
+		"Suspect Links:":                  "Verdächtige Verknüpfungen:",
+		"suspect":                         "verdächtig",
+		"Last changed by":                 "Zuletzt geändert von",
+		"Statement coverage":              "Anweisungsüberdeckung",
+		"branch coverage":                 "Zweigüberdeckung",
+		"Analysis findings:":              "Befunde der Analyse:",
+		"Analysis Findings:":              "Befunde der Analyse:",
+		"Requirements by Target":          "Anforderungen nach Build-Ziel",
+		"No targets":                      "Keine Build-Ziele",
+		"inherited":                       "geerbt",
+		"Section":                         "Abschnitt",
+		"Suppressed Findings:":            "Unterdrückte Befunde:",
+		"COTS Requirements:":              "COTS-Anforderungen:",
+		"Code Files":                      "Code-Dateien",
+		"Reuse Justification":             "Begründung der Wiederverwendung",
+		"Missing":                         "Fehlt",
+		"Omitted, export-controlled.":     "Ausgelassen, exportkontrolliert.",
+		"Check":                           "Prüfung",
+		"Argument":                        "Argument",
+		"Line":                            "Zeile",
+		"Priority":                        "Priorität",
+		"Fully traced":                    "Vollständig verfolgt",
+		"Partially traced":                "Teilweise verfolgt",
+		"Untraced":                        "Nicht verfolgt",
+	},
+}
+
+// checkLang returns an error if there is no catalog for the given language.
+func checkLang(lang string) error {
+	if _, ok := catalogs[lang]; ok {
+		return nil
+	}
+	var langs []string
+	for l := range catalogs {
+		langs = append(langs, l)
+	}
+	sort.Strings(langs)
+	return fmt.Errorf("Unknown language %q, expected one of %s", lang, strings.Join(langs, ", "))
+}
+
+// translate returns the message in the given language.
+func translate(lang, msg string) string {
+	if t, ok := catalogs[lang][msg]; ok {
+		return t
+	}
+	return msg
+}
+
+// translationAttribute returns the name of the attribute holding the body of
+// a requirement translated in the given language, upper case as the keys of
+// Attributes, e.g. "BODY (DE)" for "- Body (de):" in the certdocs.
+func translationAttribute(lang string) string {
+	return "BODY (" + strings.ToUpper(lang) + ")"
+}
+
+// isTranslationAttribute returns whether the attribute holds a translated body.
+func isTranslationAttribute(key string) bool {
+	return strings.HasPrefix(key, "BODY (")
+}
+
+// Translation returns the body of the requirement in the given language, as
+// found in the translation attribute, e.g. "BODY (DE)", if any.
+func (r *Req) Translation(lang string) string {
+	return r.Attributes[translationAttribute(lang)]
+}
+
+// l10nFuncs allow the report templates to translate their labels to the language given with --lang.
+var l10nFuncs = template.FuncMap{
+	"T":             func(msg string) string { return translate(*fLang, msg) },
+	"lang":          func() string { return *fLang },
+	"isTranslation": isTranslationAttribute,
+}
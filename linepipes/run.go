@@ -4,21 +4,16 @@ import (
 	"bufio"
 	"bytes"
 	"fmt"
-	"log"
+	"log/slog"
 	"os"
 	"os/exec"
 	"strings"
 )
 
-// global flag controlling debug output
-var Verbose = false
-
 func Run(prog string, args ...string) (lines chan string, errors chan error) {
 	lines = make(chan string)
 	errors = make(chan error, 1)
-	if Verbose {
-		log.Println("Executing:", prog, strings.Join(args, " "))
-	}
+	slog.Debug("executing", "cmd", prog+" "+strings.Join(args, " "))
 	cmd := exec.Command(prog, args...)
 	cmd.Stdin = os.Stdin
 	pipeReader, pipeWriter, err := os.Pipe()
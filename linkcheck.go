@@ -0,0 +1,178 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// reHref matches the targets of the links of the HTML reports.
+var reHref = regexp.MustCompile(`<a\s[^>]*?href="([^"]*)"`)
+
+// reHTMLAnchor matches the anchors of the HTML reports, named or with an ID.
+var reHTMLAnchor = regexp.MustCompile(`\s(?:name|id)="([^"]*)"`)
+
+// linkChecker resolves the links of the reports, reading each target once.
+type linkChecker struct {
+	http    bool
+	client  *http.Client
+	anchors map[string]map[string]bool // By HTML or PDF file, nil when missing.
+	status  map[string]error           // By http(s) URL.
+}
+
+// anchorsOf returns the anchors of the given HTML report or the named
+// destinations of the given PDF, nil when the file does not exist, and an
+// empty map for the other files.
+func (c *linkChecker) anchorsOf(path string) (map[string]bool, error) {
+	if a, ok := c.anchors[path]; ok {
+		return a, nil
+	}
+	b, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		c.anchors[path] = nil
+		return nil, nil
+	}
+	if err != nil {
+		// E.g. a directory, which the browsers list.
+		if fi, err := os.Stat(path); err == nil && fi.IsDir() {
+			c.anchors[path] = map[string]bool{}
+			return c.anchors[path], nil
+		}
+		return nil, err
+	}
+	a := map[string]bool{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".html", ".htm":
+		for _, m := range reHTMLAnchor.FindAllSubmatch(b, -1) {
+			a[html.UnescapeString(string(m[1]))] = true
+		}
+	case ".pdf":
+		for name, page := range pdfDestinations(b) {
+			a[name] = page
+		}
+	}
+	c.anchors[path] = a
+	return a, nil
+}
+
+// checkHTTP requests the given URL, without its fragment, with HEAD, or GET
+// when the server does not allow HEAD.
+func (c *linkChecker) checkHTTP(u *url.URL) error {
+	v := *u
+	v.Fragment = ""
+	key := v.String()
+	if err, ok := c.status[key]; ok {
+		return err
+	}
+	resp, err := c.client.Head(key)
+	if err == nil && resp.StatusCode == http.StatusMethodNotAllowed {
+		resp.Body.Close()
+		resp, err = c.client.Get(key)
+	}
+	if err == nil {
+		resp.Body.Close()
+		if resp.StatusCode >= 400 {
+			err = fmt.Errorf("%s", resp.Status)
+		}
+	}
+	c.status[key] = err
+	return err
+}
+
+// check returns why the given link of the given report is broken, or nil.
+func (c *linkChecker) check(report, href string) error {
+	u, err := url.Parse(href)
+	if err != nil {
+		return fmt.Errorf("invalid URL")
+	}
+	var path string
+	switch u.Scheme {
+	case "http", "https":
+		if !c.http {
+			return nil
+		}
+		return c.checkHTTP(u)
+	case "file":
+		path = u.Path
+	case "":
+		path = report
+		if strings.HasPrefix(u.Path, "/") {
+			// E.g. the code files, which the reports link by their absolute path.
+			path = filepath.FromSlash(u.Path)
+		} else if u.Path != "" {
+			path = filepath.Join(filepath.Dir(report), filepath.FromSlash(u.Path))
+		}
+	default:
+		// E.g. mailto.
+		return nil
+	}
+	anchors, err := c.anchorsOf(path)
+	if err != nil {
+		return err
+	}
+	if anchors == nil {
+		return fmt.Errorf("%s does not exist", repoRelative(path))
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".html", ".htm", ".pdf":
+	default:
+		// Only the anchors of the reports and the PDFs are known.
+		return nil
+	}
+	if u.Fragment == "" {
+		return nil
+	}
+	page, ok := anchors[u.Fragment]
+	if !ok {
+		return fmt.Errorf("no anchor %s in %s", u.Fragment, repoRelative(path))
+	}
+	if !page {
+		return fmt.Errorf("the named destination %s in %s does not point to a page", u.Fragment, repoRelative(path))
+	}
+	return nil
+}
+
+// CheckReportLinks resolves the links of the given HTML reports: the anchors
+// in the reports, the code files and the documents they link, the named
+// destinations of the linked PDFs and, when checkHTTP is true, the http(s)
+// links with HEAD requests. It returns the broken links, once per report.
+func CheckReportLinks(reports []string, checkHTTP bool) ([]error, error) {
+	c := &linkChecker{
+		http:    checkHTTP,
+		client:  &http.Client{Timeout: 10 * time.Second},
+		anchors: map[string]map[string]bool{},
+		status:  map[string]error{},
+	}
+	var res []error
+	for _, report := range reports {
+		abs, err := filepath.Abs(report)
+		if err != nil {
+			return nil, err
+		}
+		b, err := ioutil.ReadFile(abs)
+		if err != nil {
+			return nil, err
+		}
+		seen := map[string]bool{}
+		for _, m := range reHref.FindAllSubmatch(b, -1) {
+			href := html.UnescapeString(string(m[1]))
+			if seen[href] {
+				continue
+			}
+			seen[href] = true
+			if err := c.check(abs, href); err != nil {
+				res = append(res, newFinding("broken_link", "Report %s has a broken link to %s: %v.\n", report, href, err))
+			}
+		}
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Error() < res[j].Error() })
+	return res, nil
+}
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"html/template"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/daedaleanai/reqtraq/config"
+)
+
+// linkKinds are the attributes holding typed links to other requirements,
+// beyond the parents, with the label of the link as seen from the linked
+// requirement. For example an LLR partially implementing two HLRs in
+// different documents can have "Satisfies: REQ-0-DDLN-SWH-012" in addition to
+// its parent.
+var linkKinds = []struct {
+	Attribute string
+	Inverse   string
+}{
+	{"SATISFIES", "SATISFIED BY"},
+	{"REFINES", "REFINED BY"},
+	{"CONFLICTS-WITH", "CONFLICTS WITH"},
+	{"DEPENDS-ON", "NEEDED BY"},
+}
+
+// referencesKind is the kind of the links of the code files to the requirements
+// their comments mention without @llr tag, e.g. in design notes, which do not
+// count as implementing them.
+const referencesKind = "REFERENCES"
+
+// mentionsKind is the kind of the links of the requirements to those their
+// title or body mentions, beyond their parents and typed links, showing their
+// implicit coupling.
+const mentionsKind = "MENTIONS"
+
+// reCodeComment matches the comment of a line of code, after //, /*, # or the *
+// continuing a block comment.
+var reCodeComment = regexp.MustCompile(`(?://|/\*|#|^\s*\*)(.*)`)
+
+// commentMentions returns the IDs mentioned in the comment of the line of code.
+func commentMentions(line string) []string {
+	parts := reCodeComment.FindStringSubmatch(line)
+	if parts == nil {
+		return nil
+	}
+	return ReReqID.FindAllString(parts[1], -1)
+}
+
+// Link is a typed link between two requirements, see linkKinds.
+type Link struct {
+	Kind string // The attribute defining the link, e.g. SATISFIES.
+	Req  *Req   // The linked requirement.
+	// Inverse is whether the link is defined by Req, found in its Backlinks.
+	Inverse bool
+}
+
+// Label returns the label of the link, e.g. "depends on" or "needed by".
+func (l Link) Label() string {
+	label := l.Kind
+	if l.Inverse && l.Kind == referencesKind {
+		label = "REFERENCED BY"
+	} else if l.Inverse && l.Kind == mentionsKind {
+		label = "MENTIONED BY"
+	} else if l.Inverse {
+		for _, k := range linkKinds {
+			if k.Attribute == l.Kind {
+				label = k.Inverse
+			}
+		}
+	}
+	return strings.ToLower(strings.ReplaceAll(label, "-", " "))
+}
+
+// isLinkAttribute returns whether the attribute holds typed links.
+func isLinkAttribute(key string) bool {
+	for _, k := range linkKinds {
+		if k.Attribute == key {
+			return true
+		}
+	}
+	return false
+}
+
+// linkFuncs allow the report templates to render the typed links apart from the other attributes.
+var linkFuncs = template.FuncMap{"isLink": isLinkAttribute}
+
+// resolveLinks sets the Links and the Backlinks of the requirements from
+// their link attributes and their mentions, see mentionsKind, returning the
+// links to requirements which do not exist or are deleted, and the references
+// of the code files from their mentions, see referencesKind.
+func (rg reqGraph) resolveLinks() error {
+	var reqs, code []*Req
+	for _, r := range rg {
+		r.Links, r.Backlinks = nil, nil
+		if r.Level != config.CODE {
+			reqs = append(reqs, r)
+		} else {
+			code = append(code, r)
+		}
+	}
+	sort.Sort(byIDs(reqs))
+	sort.Sort(byIDs(code))
+
+	var problems findingList
+	for _, r := range reqs {
+		for _, k := range linkKinds {
+			v, ok := r.Attributes[k.Attribute]
+			if !ok {
+				continue
+			}
+			ids := ReReqID.FindAllString(v, -1)
+			if len(ids) == 0 {
+				problems.add(newFinding("invalid_link", "Invalid %s link of requirement %s: %q is not a list of requirement IDs.\n", strings.ToLower(k.Attribute), r.ID, v))
+			}
+			for _, id := range ids {
+				to := rg[id]
+				switch {
+				case to == nil:
+					problems.add(newFinding("invalid_link", "Invalid %s link of requirement %s: %s does not exist.\n", strings.ToLower(k.Attribute), r.ID, id))
+				case to == r:
+					problems.add(newFinding("invalid_link", "Invalid %s link of requirement %s: it links to itself.\n", strings.ToLower(k.Attribute), r.ID))
+				case to.IsDeleted() && !r.IsDeleted():
+					problems.add(newFinding("invalid_link", "Invalid %s link of requirement %s: %s is deleted.\n", strings.ToLower(k.Attribute), r.ID, id))
+				default:
+					r.Links = append(r.Links, Link{Kind: k.Attribute, Req: to})
+					to.Backlinks = append(to.Backlinks, Link{Kind: k.Attribute, Req: r, Inverse: true})
+				}
+			}
+		}
+	}
+	for _, r := range reqs {
+		// The mentions of the unknown or deleted requirements are reported
+		// with their line by checkReqReferences.
+		for _, id := range r.mentions() {
+			if to := rg[id]; to != nil && !to.IsDeleted() {
+				r.Links = append(r.Links, Link{Kind: mentionsKind, Req: to})
+				to.Backlinks = append(to.Backlinks, Link{Kind: mentionsKind, Req: r, Inverse: true})
+			}
+		}
+	}
+	for _, c := range code {
+		// The mentions are notes, those of the unknown or implemented
+		// requirements are ignored.
+		seen := map[string]bool{}
+		for _, id := range c.ParentIds {
+			seen[id] = true
+		}
+		for _, id := range c.Mentions {
+			if to := rg[id]; to != nil && !to.IsDeleted() && !seen[id] {
+				seen[id] = true
+				c.Links = append(c.Links, Link{Kind: referencesKind, Req: to})
+				to.Backlinks = append(to.Backlinks, Link{Kind: referencesKind, Req: c, Inverse: true})
+			}
+		}
+	}
+	return problems.err()
+}
+
+// mentions returns the IDs mentioned in the title and the body of the
+// requirement, but its own, those of its parents and of its typed links. The
+// deleted requirements mention none.
+func (r *Req) mentions() []string {
+	if r.IsDeleted() {
+		return nil
+	}
+	seen := map[string]bool{r.ID: true}
+	for _, id := range r.ParentIds {
+		seen[id] = true
+	}
+	for _, k := range linkKinds {
+		for _, id := range ReReqID.FindAllString(r.Attributes[k.Attribute], -1) {
+			seen[id] = true
+		}
+	}
+	var res []string
+	for _, id := range ReReqID.FindAllString(r.Title+"\n"+string(r.Body), -1) {
+		if !seen[id] {
+			seen[id] = true
+			res = append(res, id)
+		}
+	}
+	return res
+}
+
+// reHTMLLinkOrTag matches the links and the other tags of the HTML bodies of
+// the requirements, in which the mentions are not linked.
+var reHTMLLinkOrTag = regexp.MustCompile(`(?is)<a\b.*?</a>|<[^>]*>`)
+
+// linkMentions returns the given HTML with the requirements mentioned by r,
+// see mentionsKind, linked to their anchor in the reports, but those already
+// in a link.
+func (r *Req) linkMentions(body template.HTML) template.HTML {
+	mentioned := map[string]bool{}
+	for _, l := range r.Links {
+		if l.Kind == mentionsKind {
+			mentioned[l.Req.ID] = true
+		}
+	}
+	if len(mentioned) == 0 {
+		return body
+	}
+	link := func(text string) string {
+		return ReReqID.ReplaceAllStringFunc(text, func(id string) string {
+			if !mentioned[id] {
+				return id
+			}
+			return `<a href="#` + id + `">` + id + `</a>`
+		})
+	}
+	var b strings.Builder
+	s := string(body)
+	last := 0
+	for _, loc := range reHTMLLinkOrTag.FindAllStringIndex(s, -1) {
+		b.WriteString(link(s[last:loc[0]]))
+		b.WriteString(s[loc[0]:loc[1]])
+		last = loc[1]
+	}
+	b.WriteString(link(s[last:]))
+	return template.HTML(b.String())
+}
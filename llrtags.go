@@ -0,0 +1,40 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+)
+
+// reTagContinuation matches the comment lines continuing a list of @llr tags
+// whose previous line ends with a comma, e.g. a line with only
+// "REQ-0-DDLN-SWL-003" after "@llr REQ-0-DDLN-SWL-001, REQ-0-DDLN-SWL-002,".
+var reTagContinuation = regexp.MustCompile(`^\s*(?://+|#+|\*)\s*(` + reReqIdStr + `(?:(?:\s*,\s*|\s+)` + reReqIdStr + `)*)\s*,?\s*$`)
+
+// tagReader finds the IDs referenced by the @llr tags of the lines of a code
+// file, several per line separated by commas or spaces, and on the
+// continuation lines.
+type tagReader struct {
+	re        *regexp.Regexp // The tags, see referenceRegexp.
+	continued bool           // Whether the previous line ends a list with a comma.
+	// robot is whether the tags are the Robot Framework settings of
+	// reRobotReference, always continued on the "..." lines which follow.
+	robot bool
+}
+
+// ids returns the IDs referenced on the next line.
+func (t *tagReader) ids(line string) []string {
+	parts := t.re.FindStringSubmatch(line)
+	if parts == nil && t.continued {
+		if t.robot {
+			parts = reRobotContinuation.FindStringSubmatch(line)
+		} else {
+			parts = reTagContinuation.FindStringSubmatch(line)
+		}
+	}
+	if parts == nil {
+		t.continued = false
+		return nil
+	}
+	t.continued = t.robot || strings.HasSuffix(strings.TrimSpace(line), ",")
+	return ReReqID.FindAllString(parts[1], -1)
+}
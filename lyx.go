@@ -3,15 +3,15 @@
 package main
 
 import (
-	"bufio"
 	"bytes"
 	"fmt"
 	"io"
-	"os"
-	"path/filepath"
+	"io/ioutil"
+	"path"
 	"regexp"
 	"strings"
 
+	"github.com/daedaleanai/reqtraq/config"
 	"github.com/daedaleanai/reqtraq/git"
 )
 
@@ -19,8 +19,65 @@ var (
 	reCertdoc = regexp.MustCompile(`(\d+)-(\w+)-(\d+)-(\w+)`) // project number, project abbreviation, certdoc type number, certdoc type
 	reStart   = regexp.MustCompile(`(?i)^\s*req:\s*$`)        // 'req:' standalone on a line
 	reEnd     = regexp.MustCompile(`(?i)^\s*/req\s*$`)        // '/req' standalone on a line
+	// reLyxAccent matches the LaTeX escapes of accented letters, e.g. \'e, \'{e}, \"{\i} or \c c.
+	reLyxAccent = regexp.MustCompile(`\\(?:(['"` + "`" + `^~=.])(\{(?:\\i|[A-Za-z])\}|\\i|[A-Za-z])|([cvuHrk])(\{(?:\\i|[A-Za-z])\}| [A-Za-z]))`)
+	// reLyxSymbol matches the LaTeX escapes of special characters, e.g. \ss{} or \textmu.
+	reLyxSymbol = regexp.MustCompile(`\\(ss|ae|AE|oe|OE|aa|AA|o|O|l|L|textmu|textdegree|textperthousand|texteuro)(?:\{\}| |\b)`)
 )
 
+// lyxBinaryInsets are the insets whose contents are not text, e.g. embedded
+// images, and are skipped when parsing.
+var lyxBinaryInsets = map[string]bool{"Graphics": true, "External": true, "Preview": true}
+
+// lyxAccents are the letters which can be accented with each LaTeX accent
+// command, and the corresponding accented letters.
+var lyxAccents = map[string][2]string{
+	"'": {"acegiklmnoprsuwyzACEGIKLMNOPRSUWYZ", "áćéǵíḱĺḿńóṕŕśúẃýźÁĆÉǴÍḰĹḾŃÓṔŔŚÚẂÝŹ"},
+	`"`: {"aehiotuwxyAEHIOUWXY", "äëḧïöẗüẅẍÿÄËḦÏÖÜẄẌŸ"},
+	"`": {"aeinouwyAEINOUWY", "àèìǹòùẁỳÀÈÌǸÒÙẀỲ"},
+	"^": {"aceghijosuwyzACEGHIJOSUWYZ", "âĉêĝĥîĵôŝûŵŷẑÂĈÊĜĤÎĴÔŜÛŴŶẐ"},
+	"~": {"aeinouvyAEINOUVY", "ãẽĩñõũṽỹÃẼĨÑÕŨṼỸ"},
+	"=": {"aegiouyAEGIOUY", "āēḡīōūȳĀĒḠĪŌŪȲ"},
+	".": {"abcdefghmnoprstwxyzABCDEFGHIMNOPRSTWXYZ", "ȧḃċḋėḟġḣṁṅȯṗṙṡṫẇẋẏżȦḂĊḊĖḞĠḢİṀṄȮṖṘṠṪẆẊẎŻ"},
+	"c": {"cdeghklnrstCDEGHKLNRST", "çḑȩģḩķļņŗşţÇḐȨĢḨĶĻŅŖŞŢ"},
+	"v": {"acdeghijklnorstuzACDEGHIKLNORSTUZ", "ǎčďěǧȟǐǰǩľňǒřšťǔžǍČĎĚǦȞǏǨĽŇǑŘŠŤǓŽ"},
+	"u": {"aegiouAEGIOU", "ăĕğĭŏŭĂĔĞĬŎŬ"},
+	"H": {"ouOU", "őűŐŰ"},
+	"r": {"auwyAU", "åůẘẙÅŮ"},
+	"k": {"aeiouAEIOU", "ąęįǫųĄĘĮǪŲ"},
+}
+
+// lyxSymbols are the characters escaped by LaTeX commands, by command.
+var lyxSymbols = map[string]string{
+	"ss": "ß", "ae": "æ", "AE": "Æ", "oe": "œ", "OE": "Œ", "aa": "å", "AA": "Å", "o": "ø", "O": "Ø", "l": "ł", "L": "Ł",
+	"textmu": "µ", "textdegree": "°", "textperthousand": "‰", "texteuro": "€",
+}
+
+// lyxText returns the line of a LyX file as UTF-8, with the LaTeX escapes of
+// accented and special characters replaced by the characters. The lines which
+// are not valid UTF-8 are read as Latin-1, the encoding of old LyX files.
+func lyxText(line string) string {
+	line = toUTF8(line)
+	if !strings.Contains(line, `\`) {
+		return line
+	}
+	line = reLyxAccent.ReplaceAllStringFunc(line, func(m string) string {
+		parts := reLyxAccent.FindStringSubmatch(m)
+		accent, letter := parts[1]+parts[3], strings.Trim(parts[2]+parts[4], "{} ")
+		if letter == `\i` {
+			letter = "i"
+		}
+		letters := lyxAccents[accent]
+		if i := strings.Index(letters[0], letter); i >= 0 {
+			return string([]rune(letters[1])[i])
+		}
+		return m
+	})
+	return reLyxSymbol.ReplaceAllStringFunc(line, func(m string) string {
+		return lyxSymbols[reLyxSymbol.FindStringSubmatch(m)[1]]
+	})
+}
+
 // lyxState is the information needed to keep around on a stack to parse the
 // nested inset/layout structure of a .lyx file
 type lyxState struct {
@@ -38,9 +95,12 @@ func (s *lyxStack) push(lno int, line, arg string) {
 }
 func (s *lyxStack) pop(lno int, line string) error {
 	element := strings.SplitN(line[len(`\end_`):], " ", 2)[0]
+	if len(*s) == 0 {
+		return newFinding("document_structure", "lyx file malformed: \\end_%s on line %d without \\begin_%s", element, lno, element)
+	}
 	top := s.top()
 	if top.element != element {
-		return fmt.Errorf("lyx file malformed: begin %s line %d ended by end %s line %d", top.element, top.lineNo, element, lno)
+		return newFinding("document_structure", "lyx file malformed: begin %s line %d ended by end %s line %d", top.element, top.lineNo, element, lno)
 	}
 	if len(*s) > 0 {
 		*s = (*s)[:len(*s)-1]
@@ -80,24 +140,47 @@ func ParseLyx(f string, w io.Writer) ([]string, error) {
 		aftertitle    bool
 		reqstart      int
 		reqbuf        bytes.Buffer
+		eol           = "\n" // The line ending of the added lines.
 	)
-	r, err := os.Open(f)
+	r, err := openCertdoc(f)
 	if err != nil {
 		return nil, err
 	}
-	scan := bufio.NewScanner(r)
-
-	// Cache some info related to the git repo context.
-	repo := git.RepoName()
-	pathInRepo, err := git.PathInRepo(f)
-	if err != nil {
-		return nil, fmt.Errorf("File %s not found in repo.", f)
+	scan := newLineReader(r)
+
+	// Cache some info related to the git repo context, only needed when the
+	// linkified file is kept, e.g. not when parsing a file read from a commit.
+	var repo, dirInRepo string
+	if w != ioutil.Discard {
+		repo, err = git.RepoName()
+		if err != nil {
+			return nil, err
+		}
+		pathInRepo, err := git.PathInRepo(f)
+		if err != nil {
+			return nil, fmt.Errorf("File %s not found in repo.", f)
+		}
+		dirInRepo = path.Dir(pathInRepo)
 	}
-	dirInRepo := filepath.Dir(pathInRepo)
 
 	for lno := 1; scan.Scan(); lno++ {
 		outline := scan.Text()
-		line := outline
+		// With --preserve the lines are written with their original endings,
+		// so only the modified lines differ from the original.
+		ending := "\n"
+		if *fPreserve {
+			if ending = scan.Ending(); ending != "" {
+				eol = ending
+			}
+		}
+		if top := state.top(); top.element == "inset" && lyxBinaryInsets[top.arg] && !strings.HasPrefix(outline, `\end_inset`) {
+			// The payload of the inset is kept as it is.
+			if _, err := io.WriteString(w, outline+ending); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		line := lyxText(outline)
 		istext := line != "" && !strings.HasPrefix(line, `\`) && !strings.HasPrefix(line, `#`)
 		fields := strings.Fields(line)
 		arg := ""
@@ -145,6 +228,7 @@ hypertarget{%s}
 
 \end_inset
 `, outline, reqid)
+				outline = strings.ReplaceAll(outline, "\n", eol)
 			}
 
 		case strings.HasPrefix(line, `\begin_inset`):
@@ -162,15 +246,15 @@ hypertarget{%s}
 
 		case istext && state.inNoteLayout() && reStart.Match(scan.Bytes()):
 			if inreq {
-				return nil, fmt.Errorf("malformed requirement tag: 'req:' on line %d comes after previous unclosed one at line %d\n", lno, reqstart)
+				return nil, newFinding("malformed_requirement", "malformed requirement tag: 'req:' on line %d comes after previous unclosed one at line %d\n", lno, reqstart)
 			}
 			reqstart = lno
 			inreq = true
 			aftertitle = true
 
-		case istext && inreq && state.inNoteLayout() && reEnd.Match(scan.Bytes()):
+		case istext && state.inNoteLayout() && reEnd.Match(scan.Bytes()):
 			if !inreq {
-				return nil, fmt.Errorf("malformed requirement tag: '/req' on line %d has no corresponding opening req:\n", lno)
+				return nil, newFinding("malformed_requirement", "malformed requirement tag: '/req' on line %d has no corresponding opening req:\n", lno)
 			}
 			inreq = false
 			reqs = append(reqs, reqbuf.String())
@@ -180,6 +264,10 @@ hypertarget{%s}
 			// an empty line means that a Lyx zparagraph has ended. simply append a \n to the previously parsed line and go to the next line
 			if line == "" {
 				reqbuf.WriteByte('\n')
+				if *fPreserve {
+					// The empty line is kept in the output.
+					break
+				}
 				continue
 			}
 			isFirstLine := reqbuf.Len() == 0
@@ -187,11 +275,11 @@ hypertarget{%s}
 				reqIDs := ReReqID.FindAllString(outline, -1)
 				switch len(reqIDs) {
 				case 0:
-					return nil, fmt.Errorf("malformed requirement title: missing ID on line %d: %q", lno, outline)
+					return nil, newFinding("malformed_requirement", "malformed requirement title: missing ID on line %d: %q", lno, outline)
 				case 1:
 					reqid = reqIDs[0]
 				default:
-					return nil, fmt.Errorf("malformed requirement title: too many IDs on line %d: %q", lno, outline)
+					return nil, newFinding("malformed_requirement", "malformed requirement title: too many IDs on line %d: %q", lno, outline)
 				}
 			} else {
 				count := len(ReReqID.FindAllString(reqbuf.String(), -1))
@@ -205,8 +293,9 @@ hypertarget{%s}
 					line = r[indexes[count][0]:] + line
 				}
 				if outline, err = linkify(outline, repo, dirInRepo); err != nil {
-					return nil, fmt.Errorf("malformed requirement: cannot linkify ID on line %d: %q because: %s", lno, outline, err)
+					return nil, newFinding("malformed_requirement", "malformed requirement: cannot linkify ID on line %d: %q because: %s", lno, outline, err)
 				}
+				outline = strings.ReplaceAll(outline, "\n", eol)
 			}
 
 			reqbuf.WriteString(line)
@@ -215,7 +304,7 @@ hypertarget{%s}
 		if _, err := w.Write([]byte(outline)); err != nil {
 			return nil, err
 		}
-		if _, err := w.Write([]byte("\n")); err != nil {
+		if _, err := w.Write([]byte(ending)); err != nil {
 			return nil, err
 		}
 	}
@@ -223,6 +312,13 @@ hypertarget{%s}
 	if err := scan.Err(); err != nil {
 		return nil, err
 	}
+	if inreq {
+		return nil, newFinding("malformed_requirement", "malformed requirement tag: 'req:' on line %d is never closed by '/req'", reqstart)
+	}
+	if len(state) > 0 {
+		top := state.top()
+		return nil, newFinding("document_structure", "lyx file malformed: %s on line %d is never ended", strings.TrimSpace(`\begin_`+top.element+" "+top.arg), top.lineNo)
+	}
 
 	return reqs, nil
 }
@@ -298,25 +394,13 @@ func linkify(s, repo, dirInRepo string) (string, error) {
 	var res bytes.Buffer
 	parsedTo := 0
 	for _, ids := range parmatch {
-		// For example: ["REQ-0-DDLN-SYS-006" "0" "DDLN" "SYS" "006"]
 		res.WriteString(s[parsedTo:ids[0]])
 		reqID := s[ids[0]:ids[1]]
 		parsedTo = ids[1]
-		// As per REQ-0-DDLN-SWH-002:
-		// REQ-[project/system number]-[project/system abbreviation]-[SSS or SWH or SWL or HWH or HWL]-[a unique alphanumeric sequence],
-		numberAbbrev := s[ids[2]:ids[5]]
-		reqType := s[ids[6]:ids[7]]
-		if len(ids) != 10 {
-			// This should not happen.
-			return "", fmt.Errorf("regexp cannot be used, please file a bug in Devtools: %q", ids)
-		}
-		docType, ok := docNamePerReqIDType[reqType]
-		if !ok {
-			return "", fmt.Errorf("unknown requirement type: %q (in %q)", reqType, reqID)
+		url, err := reqURL(reqID, repo, dirInRepo)
+		if err != nil {
+			return "", err
 		}
-		// For example: 0-DDLN-0-ORD
-		name := fmt.Sprintf("%s-%s", numberAbbrev, docType)
-		url := fmt.Sprintf("http://a.daedalean.ai/docs/%s/%s/%s.pdf#%s", repo, dirInRepo, name, reqID)
 		res.WriteString(fmt.Sprintf(`
 \begin_inset CommandInset href
 LatexCommand href
@@ -330,3 +414,29 @@ target "%s"
 	res.WriteString(s[parsedTo:len(s)])
 	return res.String(), nil
 }
+
+// reqURL returns the URL of the named destination of the given requirement in
+// the published document defining it.
+func reqURL(reqID, repo, dirInRepo string) (string, error) {
+	// For example: ["REQ-0-DDLN-SYS-006" "0" "DDLN" "SYS" "006"]
+	parts := ReReqID.FindStringSubmatch(reqID)
+	if len(parts) != 5 {
+		// This should not happen.
+		return "", fmt.Errorf("regexp cannot be used, please file a bug in Devtools: %q", parts)
+	}
+	// As per REQ-0-DDLN-SWH-002:
+	// REQ-[project/system number]-[project/system abbreviation]-[SSS or SWH or SWL or HWH or HWL]-[a unique alphanumeric sequence],
+	numberAbbrev := parts[1] + "-" + parts[2]
+	reqType := parts[3]
+	docType, ok := docNamePerReqIDType[reqType]
+	if !ok {
+		return "", fmt.Errorf("unknown requirement type: %q (in %q)", reqType, reqID)
+	}
+	// For example: 0-DDLN-100-ORD
+	name := fmt.Sprintf("%s-%s", numberAbbrev, docType)
+	if *fOffline {
+		// The published documents cannot be reached, link to the ones next to the linkified document.
+		return fmt.Sprintf("%s.pdf#%s", name, reqID), nil
+	}
+	return fmt.Sprintf("%s/%s/%s/%s.pdf#%s", config.DocsURL, repo, dirInRepo, name, reqID), nil
+}
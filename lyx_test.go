@@ -0,0 +1,176 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// lyxReq returns a LyX requirement block with the given title and body lines.
+func lyxReq(title, body string) string {
+	return "\\begin_layout Subsection\n\\begin_inset Note Note\nstatus collapsed\n\n\\begin_layout Plain Layout\nreq:\n\\end_layout\n\n\\end_inset\n\n" +
+		title + "\n\\end_layout\n\n\\begin_layout Standard\n" + body + "\n\\end_layout\n\n" +
+		"\\begin_layout Standard\n\\begin_inset Note Note\nstatus collapsed\n\n\\begin_layout Plain Layout\n/req\n\\end_layout\n\n\\end_inset\n\n\n\\end_layout\n"
+}
+
+// parseLyxString parses the LyX contents written to a temporary certification document.
+func parseLyxString(t testing.TB, contents string) ([]string, error) {
+	f := filepath.Join(t.TempDir(), "0-TEST-100-ORD.lyx")
+	if err := os.WriteFile(f, []byte(contents), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return ParseLyx(f, ioutil.Discard)
+}
+
+func FuzzParseLyx(f *testing.F) {
+	for _, seed := range []string{"testdata/valid_system_requirement/123-TEST-100-ORD.lyx", "testdata/TestPreCommitCreateReqGraph/0-TEST-211-SRD.lyx"} {
+		if b, err := os.ReadFile(seed); err == nil {
+			f.Add(string(b))
+		}
+	}
+	f.Add("\\begin_body\n" + lyxReq("REQ-0-TEST-SYS-001 Title", "Body.\nRationale: R") + "\\end_body\n")
+	f.Fuzz(func(t *testing.T, contents string) {
+		reqs, err := parseLyxString(t, contents)
+		if err != nil {
+			return
+		}
+		for _, r := range reqs {
+			// The requirements may be malformed, but must not crash the parser.
+			_, _ = ParseReq(r)
+		}
+	})
+}
+
+func TestParseLyx_Malformed(t *testing.T) {
+	valid := lyxReq("REQ-0-TEST-SYS-001 Title", "Body.\nRationale: R")
+	reqs, err := parseLyxString(t, "\\begin_body\n"+valid+"\\end_body\n")
+	assert.Nil(t, err)
+	assert.Len(t, reqs, 1)
+
+	for _, tc := range []struct{ contents, err string }{
+		{valid + "\\end_layout\n", `lyx file malformed: \end_layout on line 31 without \begin_layout`},
+		{valid + "\\begin_layout Standard\nUnfinished\n", `lyx file malformed: \begin_layout Standard on line 31 is never ended`},
+		{valid + "\\begin_inset Note Note\n", `lyx file malformed: \begin_inset Note on line 31 is never ended`},
+		{valid + "\\end_inset\n", `lyx file malformed: \end_inset on line 31 without \begin_inset`},
+		{valid[:len(valid)-len("/req\n\\end_layout\n\n\\end_inset\n\n\n\\end_layout\n")] + "\\end_layout\n\n\\end_inset\n\n\n\\end_layout\n",
+			`malformed requirement tag: 'req:' on line 6 is never closed by '/req'`},
+		{"\\begin_layout Standard\n\\begin_inset Note Note\nstatus collapsed\n\n\\begin_layout Plain Layout\n/req\n\\end_layout\n\n\\end_inset\n\n\\end_layout\n",
+			"malformed requirement tag: '/req' on line 6 has no corresponding opening req:\n"},
+	} {
+		_, err := parseLyxString(t, tc.contents)
+		if assert.NotNil(t, err, tc.contents) {
+			assert.Equal(t, tc.err, err.Error())
+		}
+	}
+}
+
+func TestParseLyx_Preserve(t *testing.T) {
+	*fPreserve, *fOffline = true, true
+	defer func() { *fPreserve, *fOffline = false, false }()
+
+	// Without requirements, the file is written as it is.
+	const dir = "testdata/TestLinkifyLyxPreserve"
+	original, err := os.ReadFile(filepath.Join(dir, "0-TEST-150-SP.lyx"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	_, err = ParseLyx(filepath.Join(dir, "0-TEST-150-SP.lyx"), &out)
+	assert.Nil(t, err)
+	assert.Equal(t, string(original), out.String())
+
+	// Otherwise only the anchors and the links are added.
+	out.Reset()
+	reqs, err := ParseLyx(filepath.Join(dir, "0-TEST-100-ORD.lyx"), &out)
+	assert.Nil(t, err)
+	assert.Len(t, reqs, 2)
+	golden, err := os.ReadFile(filepath.Join(dir, "0-TEST-100-ORD.lyx.golden"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, string(golden), out.String())
+}
+
+func TestDocSections_Lyx(t *testing.T) {
+	sections := docSections([]byte(`\begin_body
+
+\begin_layout Section
+Requirements
+\end_layout
+
+\begin_layout Subsection
+\begin_inset Note Note
+status collapsed
+
+\begin_layout Plain Layout
+req:
+\end_layout
+
+\end_inset
+
+REQ-0-TEST-SYS-001 Speed
+\end_layout
+
+\begin_layout Standard
+The speed shall be known, see REQ-0-TEST-SYS-003.
+\end_layout
+
+\begin_layout Section
+Interfaces
+\end_layout
+
+\begin_layout Standard
+\begin_inset Note Note
+status collapsed
+
+\begin_layout Plain Layout
+req:
+\end_layout
+
+\end_inset
+
+
+\end_layout
+
+\begin_layout Standard
+REQ-0-TEST-SYS-002 Display
+\end_layout
+
+\end_body
+`))
+	assert.Equal(t, map[string]docSection{
+		"REQ-0-TEST-SYS-001": {Path: "Requirements", Number: "1"},
+		"REQ-0-TEST-SYS-002": {Path: "Interfaces", Number: "2"},
+	}, sections)
+}
+
+func TestConvertCertdoc_Lyx(t *testing.T) {
+	in := "testdata/TestPreCommitCreateReqGraph/0-TEST-211-SRD.lyx"
+	b, err := ConvertCertdoc(in, "md")
+	if !assert.NoError(t, err) {
+		return
+	}
+	out := filepath.Join(t.TempDir(), "0-TEST-211-SRD.md")
+	if err := ioutil.WriteFile(out, b, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	lyx, md := reqGraph{}, reqGraph{}
+	assert.Empty(t, parseCertdocToGraph(in, lyx))
+	assert.Empty(t, parseCertdocToGraph(out, md))
+	assert.Equal(t, len(lyx), len(md))
+	for id, r := range lyx {
+		if assert.Contains(t, md, id) {
+			assert.Equal(t, r.Title, md[id].Title, id)
+			assert.Equal(t, r.Attributes, md[id].Attributes, id)
+			assert.Equal(t, r.Section, md[id].Section, id)
+		}
+	}
+
+	_, err = ConvertCertdoc(in, "lyx")
+	assert.EqualError(t, err, "Converting to lyx is not supported, expected md")
+}
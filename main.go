@@ -6,17 +6,24 @@
 package main
 
 import (
+	"bytes"
 	"encoding/json"
 	"flag"
 	"fmt"
 	"io/ioutil"
 	"log"
+	"log/slog"
 	"os"
+	"path/filepath"
 	"regexp"
+	"runtime"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/daedaleanai/reqtraq/git"
 	"github.com/daedaleanai/reqtraq/linepipes"
+	"github.com/daedaleanai/reqtraq/taskmgr"
 )
 
 var (
@@ -24,13 +31,65 @@ var (
 	fReportTitleFilterString = flag.String("title_filter", "", "regular expression to filter by requirement title.")
 	fReportIdFilterString    = flag.String("id_filter", "", "regular expression to filter by requirement id.")
 	fReportBodyFilterString  = flag.String("body_filter", "", "regular expression to filter by requirement body.")
-	fReportJsonConfPath      = flag.String("attributes", git.RepoPath()+"/certdocs/attributes.json", "path to json with requirement attribute specification.")
+	fReportSectionFilter     = flag.String("section_filter", "", "regular expression to filter by the section of the document the requirement is under.")
+	fReportJsonConfPath      = flag.String("attributes", repoFile("certdocs", "attributes.json"), "path to json with requirement attribute specification.")
 	addr                     = flag.String("addr", ":8080", "The ip:port where to serve.")
-	since                    = flag.String("since", "", "The commit representing the start of the range.")
+	fCacheSize               = flag.Int("cache-size", 8, "The number of requirement graphs the web server keeps, 0 to rebuild them for each request.")
+	since                    = flag.String("since", "", "The commit, or for trend the date, representing the start of the range.")
 	at                       = flag.String("at", "", "The commit representing the end of the range.")
-	fCertdocPath             = flag.String("certdoc_path", "certdocs", "Location of certification documents within the *root* of the current repository.")
+	fRange                   = flag.String("range", "", "The range of commits to check, e.g. v1.0..HEAD.")
+	fMerges                  = flag.Bool("merges", false, "Only check the merge commits of the --range.")
+	fCertdocPath             = flag.String("certdoc_path", "certdocs", "Location of certification documents within the *root* of the current repository, or locations separated by commas.")
 	fCodePath                = flag.String("code_path", "", "Location of code files within the current repository")
-	fVerbose                 = flag.Bool("v", false, "Enable verbose logs.")
+	fVerbose                 = flag.Bool("v", false, "Enable verbose logs, same as --log-level=debug.")
+	fLogLevel                = flag.String("log-level", "info", "Minimum level of the logged messages: debug, info, warn or error.")
+	fLogFormat               = flag.String("log-format", "text", "Format of the logged messages: text or json.")
+	fDoc                     = flag.String("doc", "", "Certification document the command operates on.")
+	fIdsFrom                 = flag.String("ids-from", "", "File containing the requirement IDs the command operates on.")
+	fFormat                  = flag.String("format", "", "Input or output format, see the help of each command.")
+	fDocType                 = flag.String("type", "", "The type of the certification document, e.g. SDD.")
+	fProject                 = flag.String("project", "", "The project of the certification documents, e.g. 0-DDLN.")
+	fOutDir                  = flag.String("out-dir", "", "The directory where to write the output files.")
+	fJobs                    = flag.Int("jobs", runtime.NumCPU(), "The number of files processed in parallel.")
+	fMapping                 = flag.String("mapping", "", "path to json with the mapping of imported columns to requirement fields.")
+	fPreserve                = flag.Bool("preserve", false, "Keep the linkified files byte-identical to the originals outside the modified lines, e.g. their line endings.")
+	fOffline                 = flag.Bool("offline", os.Getenv("REQTRAQ_OFFLINE") != "", "Do not access the network, e.g. the task manager. Enabled by default when REQTRAQ_OFFLINE is set.")
+	fRoster                  = flag.String("roster", repoFile("certdocs", "roster.json"), "path to json with the team members who can own and review requirements.")
+	fWaivers                 = flag.String("waivers", repoFile("certdocs", "waivers.yaml"), "path to YAML with the waivers of the intentional traceability gaps, see precommit.")
+	fCommitRules             = flag.String("commit-rules", repoFile("certdocs", "commitmsg.json"), "path to json with the paths whose changes require the commit message to reference a requirement.")
+	fOwner                   = flag.String("owner", "", "Only consider the requirements owned by the given person.")
+	fTag                     = flag.String("tag", "", "Only consider the requirements having one of the given comma separated tags.")
+	fTeam                    = flag.String("team", "", "Only consider the requirements owned in CODEOWNERS by one of the given comma separated teams.")
+	fSuspect                 = flag.Bool("suspect", false, "Mark in the reports the links to parents changed since the requirement, from the git history.")
+	fBlame                   = flag.Bool("blame", false, "Show the last author and commit which changed each requirement, from git blame.")
+	fCoverage                = flag.String("coverage", "", "Comma separated coverage reports of the code: lcov tracefiles, gcov files or go cover profiles.")
+	fMinCoverage             = flag.Float64("min-coverage", 0, "Minimum statement coverage in percent of the code of each requirement, checked by precommit with --coverage.")
+	fMinBranchCoverage       = flag.Float64("min-branch-coverage", 0, "Minimum branch coverage in percent of the code of each requirement, checked by precommit with --coverage.")
+	fFindings                = flag.String("findings", "", "Comma separated static analysis reports of the code: SARIF logs or clang-tidy outputs.")
+	fSimilarity              = flag.Float64("similarity", 0.8, "Minimum similarity, from 0 to 1, of the requirements listed by similar.")
+	fStaleDays               = flag.Int("stale-days", 365, "Number of days after which the requirements are considered stale, see staleness and churn.")
+	fBaselines               = flag.Int("baselines", 5, "Number of most recent tags the dashboard shows the trend over.")
+	fTestResults             = flag.String("test-results", "", "Comma separated results of the tests: go test -json outputs or JUnit XML reports.")
+	fStep                    = flag.String("step", "weekly", "Interval between the points of the trend: daily, weekly or monthly.")
+	fSnapshot                = flag.String("snapshot", "", "path to a snapshot created by the snapshot command, used instead of parsing the current documents.")
+	fBase                    = flag.String("base", "", "The commit the changes are compared to, for hash also a hash, a snapshot or a manifest.")
+	fTarget                  = flag.String("target", "", "The commit whose changes are listed, the working tree when empty.")
+	fWorktree                = flag.Bool("worktree", false, "Also list the changes of the working tree since the --target.")
+	fConfig                  = flag.String("config", repoFile("reqtraq.yaml"), "path to the reqtraq configuration file, providing the defaults of the flags.")
+	fLang                    = flag.String("lang", "en", "Language of the labels of the reports: en or de.")
+	fKey                     = flag.String("key", "", "path to the PEM ed25519 private key signing the manifest, or public key verifying it.")
+	fSummaryFile             = flag.String("summary-file", "", "path to json file where to write the exit code and the number of findings of each type.")
+	fFrom                    = flag.String("from", "", "Format of the certification documents to convert: lyx or md.")
+	fTo                      = flag.String("to", "md", "Format the certification documents are converted to.")
+	fStdin                   = flag.Bool("stdin", false, "Read the certification document validate checks from stdin.")
+	fHTTP                    = flag.Bool("http", false, "Also check the http and https links with HEAD requests, see checklinks.")
+	fVerboseVersion          = flag.Bool("verbose", false, "Print the provenance of the binary, see version.")
+	fCheck                   = flag.Bool("check", false, "Only check, see the help of fmt and publish.")
+	fForce                   = flag.Bool("force", false, "Write into the existing files, see import.")
+	fExternal                = flag.Bool("external", false, "The reports are for external parties: the bodies of the requirements tagged EXPORT-CONTROLLED are omitted.")
+	fRedact                  = flag.Bool("redact", false, "The bodies of the requirements and their sensitive attributes are replaced by their hashes in the reports.")
+	fComponent               = flag.String("component", "", "The component whose requirements extract-graph extracts, as in their Component attribute.")
+	fSort                    = flag.String("sort", "", "Attribute by whose values the requirements are sorted, see query.")
 )
 
 const usage = `
@@ -45,36 +104,707 @@ usually in a git repo.  The certification documents are scanned for requirements
 and the source code for references to them.
 
 command is one of:
+	annotate	writes at the top of a code file a summary of the requirements it implements
+	bom		creates an SPDX-like json bill of materials of the requirements and the code implementing them
+	check		validates the requirements at each commit of a range, reporting when they became invalid
+	checklinks	lists the broken links of the generated HTML reports
+	checklist	creates the review checklists of the selected requirements
+	churn		lists the code changed recently whose requirements did not change for long
+	commitmsg	checks that the commit message references a requirement, as a commit-msg hook
+	completion	prints the shell completion script for bash, zsh or fish
+	config		validates or upgrades the reqtraq.yaml configuration file
+	convert		converts certification documents to another format, e.g. from LyX to Markdown
+	dashboard	creates an HTML or json dashboard with the progress of the requirements of each level
+	diff		prints the changes of the requirements between two commits, e.g. for the accomplishment summary
+	deporder	prints an implementation order of the low-level requirements following their Depends-On links
+	doctrace	prints the relationships between the certification documents, e.g. for the SOI#1 audit
+	extract		creates a document containing only the selected requirements, for reviews
+	extract-graph	saves the requirements of a component and their ancestors to a snapshot, for a supplier
+	apply		updates the certification documents with the changes made to an exported spreadsheet
+	export		exports the requirements to a spreadsheet, for editing their attributes
+	grpc		starts a gRPC server exposing the requirement graph to the tools written in other languages
+	gen-tests	prints skeleton tests of low-level requirements, tagged with their IDs, for Go or Robot Framework
+	fmt		rewrites the Markdown certification documents in their canonical form, e.g. the attribute names
+	hash		prints the hash of the requirement graph, to check the traceability data is identical to a baseline
 	help		prints this help message
-	linkify		changes the lyx content by adding named destinations and links to parent requirements
+	idheader	creates a Go or C file listing the requirements implemented, to be compiled into the binaries
+	import		creates a certification document from requirements kept in another format, e.g. CSV
+	linkify		changes the certdoc content by adding named destinations and links to parent requirements
 	list    	parses and lists the requirements found in certification documents
+	merge-graph	merges the requirements of a component developed by a supplier back into the graph
+	manifest	creates a signed json manifest of the traceability of a release, to archive with the binaries
+	newdoc		creates the skeleton of a new certification document, named and structured as the others
 	nextid		generates the next requirement id for the given document
+	package		creates a zip with the changes between two baselines, for the stage of involvement audits
 	precommit	runs the precommit checks for the requirement documents in the current repository
 	prepush		runs the prepush checks for the requirement documents in the current repository
+	publish		linkifies the certification documents and converts them to PDF, checking their anchors
+	query		prints the requirements matching a query, e.g. the LLRs of a system requirement without code
 	reportdown 	creates an HTML traceability report from system requirements down to code
 	reportissues	creates an HTML report with all issues found in the requirement documents
+	reportowners	creates an HTML report listing the requirements owned by each person
+	reporttargets	creates an HTML report listing the requirements implemented by each Bazel or CMake target
 	reportup 	creates an HTML traceability report from code, to LLRs, to HLRs and to system requirements
+	rollup		prints the totals of a numeric attribute, e.g. the effort, over the descendants of each requirement
+	similar		lists the pairs of similar requirements, e.g. copy-pasted ones
+	snapshot	saves the parsed requirements to a file which the other commands can use with --snapshot
+	staleness	lists the requirements and the code implementing them which were modified long apart
+	suggest		prints drafts of children of a requirement returned by a configured service, for the author to edit
+	testcases	prints the test cases of the C, C++ and Go test files verifying each low-level requirement
+	trend		creates a CSV file with the progress of the requirements of each level over time
+	tui		starts an interactive terminal browser of the requirements
+	view		prints the requirements matching a named query of the configuration, e.g. for a recurring audit
+	validate	checks a certification document being edited, e.g. unsaved, printing the findings as json
+	version		prints the version of reqtraq and the provenance of the binary, for the tool qualification records
 	updatetasks	updates the tasks associated with the given requirements (requires a Phabricator/JIRA/Bugzilla instance)
 	web		starts a local web server to facilitate interaction with reqtraq
 
 
 
 Invoking reqtraq without arguments prints a short help message.
+
+The exit code is 0 when the command succeeds, 1 when problems are found in the requirements, 2 when the
+command line is invalid and 3 when the command cannot be run, e.g. because of I/O errors. With
+--summary-file=<path> the exit code and the number of problems of each type are also written to a json file.
+
+With --snapshot=<path>, the commands use the requirements saved by the snapshot command instead of parsing the
+certification documents and the code of the working tree. The --at and --since flags only take commits.
+
+With --offline, or when the REQTRAQ_OFFLINE environment variable is set, reqtraq does not access the network:
+the task manager is not queried, the reports do not load resources from the internet, and linkify links to
+the PDF documents next to the linkified one instead of the published ones.
+
 Run
 	reqtraq help <command>
 for more information on a specific command`
 
-const linkifyUsage = `Changes the lyx content by adding named destinations and links to parent requirements. Usage:
-	reqtraq linkify <input_lyx_filename> <output_lyx_filename>
+const applyUsage = `Updates the attributes of the requirements in the certification documents with the changes made
+to a spreadsheet created by the export command. Usage:
+	reqtraq apply xlsx <input_xlsx_filename> --attributes=<path_to_attributes_json> --certdoc_path=<path>
+Parameters:
+	<input_xlsx_filename>	the edited spreadsheet
+	--attributes: path to json with requirement attribute specification.
+	--certdoc_path: location of certification documents within the current repository
+
+Every changed value is checked against the attribute specification and nothing is modified if any is invalid.
+Only Markdown certification documents can be updated. Changes to the ID, Document and Title columns are ignored.
+`
+
+const annotateUsage = `Writes at the top of a code file a comment summarizing the requirements it implements, with their titles,
+or updates it. Usage:
+	reqtraq annotate <code_filename> --certdoc_path=<path> --code_path=<path>
+Parameters:
+	<code_filename>	the code file, referencing requirements with @llr tags
+	--certdoc_path: location of certification documents within the current repository
+	--code_path: location of code files within the current repository
+
+The summary is between the "reqtraq:summary" and "reqtraq:end" comment lines. precommit checks that the
+summaries of the code files having one match their @llr tags and the titles of the requirements.
+`
+
+const bomUsage = `Creates a requirements bill of materials, a json document modeled after the SPDX 2.3 documents,
+listing every requirement with its document and revision, and the code files implementing it, for attesting
+the specified behavior of the software in the same way as its supply chain. Usage:
+	reqtraq bom <output_json_filename> --at=<commit> --certdoc_path=<path> --code_path=<path>
+Parameters:
+	<output_json_filename>	the bill of materials to be created
+	--at: the commit whose requirements are listed, the working tree when empty.
+	--certdoc_path: location of certification documents within the current repository
+	--code_path: location of code files within the current repository
+
+The code files are SPDX files, with the sha1 of their contents. The document namespace contains the hash
+of the requirement graph, see the hash command.
+`
+
+const commitmsgUsage = `Checks that the commit message references at least one existing requirement when the staged
+changes touch the certification documents or code with @llr references, as a git commit-msg hook. Usage:
+	reqtraq commitmsg <commit_msg_file> --commit-rules=<path_to_rules_json> --certdoc_path=<path>
+		--code_path=<path>
+Parameters:
+	<commit_msg_file>	the file containing the commit message, passed by git to the hook
+	--commit-rules: path to json configuring which paths require a reference, for example:
+		{"paths": {"certdocs": true, "scripts": true, "third_party": false}}
+		The longest configured path containing a changed file applies to it. The other files require
+		a reference when they are certification documents or code files with @llr references.
+	--certdoc_path: location of certification documents within the current repository
+	--code_path: location of code files within the current repository
+
+To install the hook:
+	printf '#!/bin/sh\nexec reqtraq commitmsg "$1"\n' > .git/hooks/commit-msg
+	chmod +x .git/hooks/commit-msg
+`
+
+const completionUsage = `Prints the shell completion script for the given shell. Usage:
+	reqtraq completion <bash|zsh|fish>
+Parameters:
+	<bash|zsh|fish>	the shell to complete the reqtraq commands for
+
+The script completes the commands, the flags, the certification documents e.g. for list and --doc,
+and the requirement IDs e.g. for --id_filter. The comments at its top show how to install it.
+`
+
+const configUsage = `Validates or upgrades the reqtraq.yaml configuration file of the repository. Usage:
+	reqtraq config validate --config=<path_to_config_yaml>
+	reqtraq config migrate [<old_config_file>] --config=<path_to_config_yaml>
+Parameters:
+	<old_config_file>	the configuration to upgrade, --config by default, or --attributes when --config
+				does not exist, e.g. certdocs/attributes.json
+	--config: path to the configuration file, reqtraq.yaml at the root of the repository by default.
+
+The configuration file provides the defaults of the flags, which take precedence when given, for example:
+	version: 1
+	project: Reqtraq
+	docs_url: http://a.daedalean.ai/docs
+	certdoc_path: certdocs
+	certdoc_paths: [gps/certdocs]
+	code_path: ""
+	attributes:
+	  - name: Verification
+	    value: (Demonstration|Unit [Tt]est|[Tt]est)
+	  - name: Safety Impact
+	  - name: DAL
+	    value: "[A-E]"
+	    inherit: true
+	  - name: Mitigation
+	    when: Safety Impact != None
+	    sensitive: true
+	  - name: Effort
+	    type: float
+	    optional: true
+	  - name: Maturity
+	    type: enum
+	    values: [Draft, Reviewed, Approved]
+	    optional: true
+	    normalize: true
+	roster: certdocs/roster.json
+	waivers: certdocs/waivers.yaml
+	commit_rules: certdocs/commitmsg.json
+	lang: en
+	sources: ["*.go", "*.cc", "*.h"]
+	tags: [navigation, datalink]
+	generated: ["*-linked.lyx"]
+	taskmgr_token: ${PHABRICATOR_TOKEN}
+	stale_days: 180
+	min_coverage: 80
+	min_branch_coverage: 60
+	checklist:
+	  template: certdocs/checklist.md.tmpl
+	  items:
+	    - The requirement is verifiable.
+	style:
+	  max_title_length: 80
+	  max_body_words: 120
+	  single_shall: true
+	  forbidden: ["and/or", "etc\\.", "\\bTBD\\b"]
+	infer_parents: true
+	aliases:
+	  REQ-PROJ-SWL-17: REQ-PROJ-SWL-117
+	tag_placement: [header, function]
+	terminology:
+	  airspeed sensor: [ADS probe, pitot unit]
+	suggest:
+	  url: ${SUGGEST_URL:-}
+	  token: ${SUGGEST_TOKEN:-}
+	  export_controlled: false
+	views:
+	  untested-critical:
+	    description: The critical LLRs without tests.
+	    query: level(SWL) & attr(DAL, "^[AB]$") & !attr(Verification, "[Tt]est")
+	    format: md
+	priorities: [High, Medium, Low]
+	cots:
+	  roots: [third_party/]
+	  attributes: [Service History, Reuse Justification]
+	decrypt: age -d -i ${AGE_KEY_FILE}
+	publish:
+	  output: build/docs
+	  commands:
+	    md: pandoc "$INPUT" --toc -o "$OUTPUT"
+The paths are relative to the root of the repository. By default the sources are the C, C++ and Go files,
+and the hardware design artifacts: the KiCad and Altium netlists (.net) and the FPGA/PLD constraint files
+(.xdc, .sdc, .ucf, .pcf, .qsf, .lpf, .pdc), which reference HWL requirements with "@llr REQ-..." anywhere on a
+line, e.g. in a comment or in a field of a component. A tag can reference several requirements separated by
+commas or spaces, e.g. "// @llr REQ-PROJ-SWL-1, REQ-PROJ-SWL-2", continued on the following comment lines
+when ending with a comma. The Robot Framework suites (.robot) and the pytest files (test_*.py, *_test.py) are
+sources too, verifying the requirements of any level they reference with their tags: "[Tags]    REQ-..." or
+"Test Tags    REQ-..." for Robot Framework, continued on the "..." lines, @pytest.mark.req("REQ-...", ...) for
+pytest; the dashboard counts
+them as tests. The IDs mentioned without tag in the comments of the code files having tags,
+e.g. in design notes, are references: the reports show the links, but the code does not implement the
+requirements. Unknown keys, invalid regular expressions and
+outdated versions are reported with their line. migrate writes the upgraded configuration to --config.
+
+The certification documents of certdoc_path and of certdoc_paths, e.g. of subcomponents, are merged into one
+graph. On the command line the directories are separated by commas, e.g. --certdoc_path=certdocs,gps/certdocs.
+
+The tags are those the requirements can have in their Tags attribute, checked by precommit. The generated
+certification documents are not parsed, see "reqtraq help linkify".
+
+The requirements without an attribute having "inherit: true", e.g. DAL or Allocation, take the value of
+their closest ancestor having it, so it is not duplicated by hand. The reports show the inherited values
+distinctly, and precommit does not report the inherited attributes as missing.
+
+An attribute having a "when" condition is mandatory only for the requirements satisfying it. The condition
+compares the value of another attribute, own or inherited, with ==, != or, for a regular expression, =~ and
+!~, e.g. "Safety Impact != None" or "Verification =~ [Tt]est". The values are compared ignoring the case,
+and a condition on an attribute the requirement does not have does not hold.
+
+An attribute having "normalize: true" has its values normalized when parsed and by "reqtraq fmt": their
+spaces collapsed, without trailing period, and for an enum written as configured, e.g. "None" for "none.".
+The names of the configured attributes are recognized in the requirements, in addition to the usual ones,
+e.g. Rationale, Parents, Verification or Safety Impact.
+
+The values of an attribute having "sensitive: true" are replaced by their hashes in the reports created with
+--redact, along with the bodies of the requirements, see "reqtraq help reportdown".
+
+The values of an attribute having a type are checked by precommit: int and float for the numbers, added
+over the descendants of the requirements by "reqtraq rollup <attribute>", enum for one of the given values,
+date for the dates written as 2006-01-02, and ref for the requirement IDs separated by commas. The typed
+values are compared as such by the attrCmp function of the queries and by --sort, see "reqtraq help query",
+e.g. the enum values in their order.
+
+The style settings enable the checks keeping the requirements atomic and testable, reported by precommit as
+warnings: the maximum number of characters of the titles and of words of the bodies, a single "shall" per
+requirement, and the regular expressions of the forbidden phrases, e.g. compound conjunctions, matched
+ignoring the case.
+
+With infer_parents, the requirements of a Markdown document nested under a heading titled with the ID of
+a requirement of another level, e.g. "## REQ-PROJ-SWH-12 Navigation" in an SDD, have it as parent, in
+addition to those of their Parents attribute, which can then be omitted. The headings of the parents are
+not requirements of the document.
+
+The aliases map the legacy IDs of requirements to their current IDs, e.g. after a renumbering. The
+references of the code to the legacy IDs are resolved to the current ones, and reported by precommit as
+warnings until migrated.
+
+The tag placement restricts where the @llr tags of the code can be, checked by precommit: in the header,
+the comments at the top of the file, or above a function, the comments immediately followed by a line which
+is not indented, e.g. a function declaration. The tags elsewhere, e.g. in the middle of a function, are
+easily missed in the reviews.
+
+The terminology maps the canonical terms to their synonyms, e.g. from the terminology of a supplier, whose
+use in the requirements precommit reports as warnings. The terms are matched ignoring the case.
+
+The suggest endpoint drafts children of the requirements, see "reqtraq help suggest". Without url, nothing
+is sent anywhere. The bodies of the EXPORT-CONTROLLED requirements are only sent with export_controlled.
+
+The views are named queries, printed by "reqtraq view <name>", with their description, query and default
+output format, see "reqtraq help query".
+
+The priorities are the values of the Priority attribute of the requirements, the highest first, by which the
+dashboard counts the traced requirements. When given, precommit reports the other values.
+
+The cots roots are the directories of the commercial off-the-shelf and previously developed code. The
+requirements implemented only by code files under them are listed separately at the end of the reportdown and
+reportup reports, with the values of the cots attributes justifying their reuse, which precommit requires.
+
+The decrypt command decrypts the certification documents stored encrypted in the repository, by git-crypt or age,
+which are recognized by their header. It is run by sh with the encrypted contents on stdin, and must write the
+decrypted contents on stdout, e.g. "git-crypt smudge" or "age -d -i key.txt".
+
+The publish output is the directory of the PDFs created by the publish command, and the publish commands
+convert the linkified documents to PDF, by format, see "reqtraq help publish".
+
+The values can refer to environment variables, as ${NAME}, or ${NAME:-default} when the variable is
+optional. $${ is written as ${. An undefined variable without default is an error.
+
+The subdirectories of certdoc_path and code_path can have their own reqtraq.yaml, with only version,
+attributes and sources, overriding for the files below them those of the parent directories: the
+attributes replace those with the same names, and can be made optional with "optional: true", and
+the sources replace all the sources. validate checks them too.
+`
+
+const convertUsage = `Converts certification documents to another format, e.g. when migrating from LyX to Markdown. Usage:
+	reqtraq convert --from=<lyx|md> --to=md <certdoc>...
+Parameters:
+	<certdoc>	the certification documents to convert, whose format is given by --from
+	--from: the format of the documents, lyx or md.
+	--to: the format of the converted documents, md by default, the only one supported.
+
+The converted documents are written next to the originals, with the extension of their format, e.g.
+certdocs/0-PROJ-211-SRD.lyx is converted to certdocs/0-PROJ-211-SRD.md. Existing files are not overwritten.
+The requirements keep their IDs, titles, bodies and attributes, under the headings of their sections. The
+rest of the documents, e.g. the introduction, is not converted. The original documents are to be deleted
+once the converted ones have been reviewed, so the requirements are not defined twice.
+`
+
+const fmtUsage = `Rewrites the Markdown certification documents in their canonical form, so the diffs are small and the
+reviews focus on the content. Usage:
+	reqtraq fmt [<certdoc>...] --check --certdoc_path=<path> --attributes=<path_to_attributes_json>
+Parameters:
+	<certdoc>	the Markdown certification documents to rewrite, all those of --certdoc_path by default
+	--check: only print the documents which are not in their canonical form, and exit with 1 when any, e.g. in CI.
+	--certdoc_path: location of certification documents within the current repository
+	--attributes: the attribute specification, as json or reqtraq.yaml, giving the order of the attributes
+
+In the canonical form:
+	- the heading of each requirement is one level below the heading of its section, the headings inside
+	  the requirement being moved as much,
+	- the attributes are named as in reqtraq.yaml, or else in sentence case, e.g. "- Safety impact:" for
+	  "- SAFETY IMPACT:", and those of the attribute specification, completed by the reqtraq.yaml
+	  fragments of the directories, are ordered as in it, the others keeping their place,
+	- the values of the attributes having "normalize: true" are normalized, see "reqtraq help config",
+	- the headings are surrounded by one empty line, except the attributes heading, directly followed by
+	  the attributes, and there are no consecutive empty lines.
+The fenced code blocks are kept as they are. The rewritten documents are printed.
+`
+
+const dashboardUsage = `Creates a dashboard with the progress of the requirements of each level: their number and how
+many have children, code and tests, now and at the most recent tags. Usage:
+	reqtraq dashboard --pfx=<reportfile-prefix> --format=<html|json> --baselines=<n> --test-results=<files>
+		--certdoc_path=<path> --code_path=<path>
+Parameters:
+	--pfx: path and filename prefix for the created dashboard.html or dashboard.json.
+	--format: html (default) or json.
+	--baselines: number of most recent tags to show the trend over.
+	--test-results: comma separated results of the tests of the working tree: outputs of go test -json or
+	  JUnit XML reports, e.g. from GoogleTest with --gtest_output=xml or from Catch2 with --reporter junit.
+	--certdoc_path: location of certification documents within the current repository
+	--code_path: location of code files within the current repository
+
+A requirement has code when a code file traces to it or to one of its descendants, and tests when such a
+code file is a test, e.g. parser_test.go or test_parser.py. With --test-results, the requirements now are also
+counted as having passing tests when the test cases verifying them or their descendants, see
+"reqtraq help testcases", have results, matched by name, and they all passed. The skipped test cases have
+no result.
+
+When the requirements have a Priority attribute, the system requirements and HLRs are also counted by priority:
+fully traced when they have code and tests, partially traced when they have only children, code or tests. The
+priorities are ordered as configured in reqtraq.yaml, see "reqtraq help config".
+`
+
+const diffUsage = `Prints the requirements added, removed and modified between two commits, with the changes of their
+attributes and of their parents, and the code files referencing requirements which changed. Usage:
+	reqtraq diff --base=<commit> --target=<commit> --worktree --format=<md|json> --certdoc_path=<path>
+		--code_path=<path>
+Parameters:
+	--base: the commit, e.g. a release tag, the changes are compared to.
+	--target: the commit whose changes are listed. The working tree when missing.
+	--worktree: also list the changes of the working tree since --target.
+	--format: md (default), a Markdown section to be included e.g. in the Software Accomplishment Summary,
+		or json.
+	--certdoc_path: location of certification documents within the current repository
+	--code_path: location of code files within the current repository
+`
+
+const deporderUsage = `Prints an implementation order of the low-level requirements, following their Depends-On links
+to other low-level requirements, e.g. "Depends-On: REQ-0-DDLN-SWL-003", to plan the incremental development.
+The requirements of each step only depend on those of the previous steps. Usage:
+	reqtraq deporder --format=<md|json> --certdoc_path=<path> --code_path=<path>
+Parameters:
+	--format: md (default), a Markdown table, or json.
+	--certdoc_path: location of certification documents within the current repository
+	--code_path: location of code files within the current repository
+
+The circular dependencies are reported as findings, and the requirements depending on them as blocked.
+`
+
+const doctraceUsage = `Prints the document-level trace: the relationships between the certification documents, e.g. the
+SDD implements the SRD and the SVCP verifies the SDD, configured by document type in config.DocTypeRelations,
+with the revision of each document and the number of requirement traces between them. Usage:
+	reqtraq doctrace --format=<md|json> --certdoc_path=<path> --code_path=<path>
+Parameters:
+	--format: md (default), a Markdown table to be included e.g. in the SOI#1 audit material, or json.
+	--certdoc_path: location of certification documents within the current repository
+	--code_path: location of code files within the current repository
+
+The related documents which do not exist in --certdoc_path are listed as missing.
+`
+
+const exportUsage = `Exports the requirements to a spreadsheet with one column per attribute, to be edited e.g. by
+non-engineers and then applied to the certification documents with the apply command. Usage:
+	reqtraq export xlsx <output_xlsx_filename> --attributes=<path_to_attributes_json> --certdoc_path=<path>
+Parameters:
+	<output_xlsx_filename>	the spreadsheet to be created
+	--attributes: path to json with requirement attribute specification, listing the attribute columns.
+	--certdoc_path: location of certification documents within the current repository
+`
+
+const extractUsage = `Creates a standalone document containing only the selected requirements of a certification
+document, with their attributes and trace context, for focused reviews. Usage:
+	reqtraq extract --doc=<certdoc> --ids-from=<ids_file> --pfx=<reportfile-prefix> --format=<md|pdf>
+		--certdoc_path=<path> --code_path=<path>
+Parameters:
+	--doc: the certification document defining the requirements, e.g. 0-DDLN-212-SDD.md
+	--ids-from: file containing the IDs of the requirements to extract; any text other than IDs is ignored
+	--pfx: path and filename prefix for the created document.
+	--format: md (default) or pdf, which requires pandoc with a LaTeX engine.
+	--certdoc_path: location of certification documents within the current repository
+	--code_path: location of code files within the current repository
+`
+
+const extractGraphUsage = `Saves the requirements of a component, their ancestors and the code files referencing them to a
+snapshot, a self-consistent graph which a supplier developing only the component can use. Usage:
+	reqtraq extract-graph <output_json_filename> --component=<name> --certdoc_path=<path> --code_path=<path>
+Parameters:
+	<output_json_filename>	the snapshot to be created
+	--component: the component, as in the Component attribute of its requirements, e.g. "Component: fms".
+		A requirement can be part of several components, separated by commas.
+	--certdoc_path: location of certification documents within the current repository
+	--code_path: location of code files within the current repository
+
+The ancestors which are not part of the component are marked External in the snapshot: they are references
+to the requirements of the integrator, which the supplier traces to but does not change. The snapshot can be
+used with --snapshot like those of the snapshot command, and records the problems of the whole graph.
+`
+
+const mergeGraphUsage = `Merges the graph of a component delivered by a supplier, extracted by extract-graph, back into the
+requirement graph, and saves the result to a snapshot. Usage:
+	reqtraq merge-graph <supplier_json_filename> <output_json_filename> --at=<commit> --certdoc_path=<path>
+		--code_path=<path>
+Parameters:
+	<supplier_json_filename>	the snapshot delivered by the supplier
+	<output_json_filename>	the merged snapshot to be created
+	--at: the commit of the requirement graph the component is merged into, the working tree when empty.
+	--certdoc_path: location of certification documents within the current repository
+	--code_path: location of code files within the current repository
+
+The requirements of the component are replaced by those of the supplier, and the code files of the supplier are
+added. The conflicts are reported for manual resolution, and nothing is saved:
+	- a requirement of the supplier whose ID is used by another component,
+	- an external requirement of the supplier which no longer exists, or whose title or body changed since the
+	  extraction,
+	- a requirement of the component which the supplier lacks, e.g. added after the extraction,
+	- a code file of the supplier which differs from the one of the repository.
+The merged snapshot can be used with --snapshot, e.g. for the reports of the integrated system.
+`
+
+const checkUsage = `Validates the requirements at each commit of a range, or only at its merge commits, and reports
+the commit at which they became invalid, to find when the traceability broke. Usage:
+	reqtraq check --range=<range> --merges --certdoc_path=<path> --code_path=<path>
+Parameters:
+	--range: the commits to check, as understood by git log, e.g. v1.0..HEAD
+	--merges: only check the merge commits, e.g. of the main branch
+	--certdoc_path: location of certification documents within the current repository
+	--code_path: location of code files within the current repository
+
+A line is printed for each commit, the oldest first, with the number of problems found. The problems
+at the commit which made the requirements invalid are reported as findings.
+
+The IDs of the deleted requirements, removed or marked DELETED, which are reused by a requirement with a
+different title or body are reported below the commit reusing them, and as findings, since the IDs must not
+be reused. The deletions before the range are found in the history with git log -S, as by precommit.
+`
+
+const checklinksUsage = `Lists the broken links of the HTML reports, once they are generated, before they reach the auditors.
+Usage:
+	reqtraq checklinks [<report>...] --pfx=<reportfile-prefix> --http
+Parameters:
+	<report>	HTML report to be checked, all the reports with the --pfx prefix when none is given, e.g.
+			req-down.html
+	--pfx: path and filename prefix of the reports.
+	--http: also check the http and https links, e.g. to the published documents and the problem reports,
+		with HEAD requests.
+
+The links to anchors of the reports, to the code files, and to the documents are resolved, and the links
+to a named destination of a local PDF, e.g. those of the reports created with --offline, are checked with
+the destinations of the PDF, see "reqtraq help publish". The broken links are reported as findings.
+`
+
+const churnUsage = `Lists the code tagged with @llr references which changed recently while its requirements did not
+change for --stale-days, the most changed first: the requirements are candidates for a review. Usage:
+	reqtraq churn --since=<yyyy-mm-dd> --stale-days=<days> --format=<md|json> --certdoc_path=<path>
+		--code_path=<path>
+Parameters:
+	--since: the start of the recent changes, 90 days ago by default.
+	--stale-days: the number of days, 365 by default or stale_days in reqtraq.yaml.
+	--format: md (default), a Markdown table, or json.
+	--certdoc_path: location of certification documents within the current repository
+	--code_path: location of code files within the current repository
+
+The code of a tag extends to the next tag, so it is usually the tagged function. Its commits since --since are
+counted following the history of its lines at HEAD, as git log -L does, and its lines changed since --since with
+git blame. A requirement was last modified when one of its lines in the certification document was last changed,
+see "reqtraq help list".
+`
+
+const checklistUsage = `Creates a review checklist for each of the selected requirements, with its text, attributes,
+parents and code files, followed by the items the reviewer checks. Usage:
+	reqtraq checklist --ids-from=<ids_file> --pfx=<reportfile-prefix> --format=<md|pdf>
+		--certdoc_path=<path> --code_path=<path>
+Parameters:
+	--ids-from: file containing the IDs of the requirements to review; any text other than IDs is ignored
+	--pfx: path and filename prefix for the created checklists, named checklist-<ID>.md
+	--format: md (default) or pdf, which requires pandoc with a LaTeX engine.
+	--certdoc_path: location of certification documents within the current repository
+	--code_path: location of code files within the current repository
+
+The checklist key of reqtraq.yaml can give the items to check, and the path of a Go text/template
+replacing the default Markdown template. The template is executed with .Req, the requirement, .Code,
+its code files, and .Items, the items to check; markdown converts .Req.Body to Markdown.
+`
+
+const hashUsage = `Prints the sha256 of the resolved requirement graph, which only depends on the contents of the
+requirements and the code referencing them, and on their links. Usage:
+	reqtraq hash --at=<commit> --base=<hash|commit|snapshot|manifest> --certdoc_path=<path> --code_path=<path>
+Parameters:
+	--at: the commit whose graph is hashed, the working tree when empty.
+	--base: the baseline the hash is compared to: a hash, the commit or the snapshot file whose graph is hashed,
+		or a manifest created by the manifest command, whose graph hash is used.
+	--certdoc_path: location of certification documents within the current repository
+	--code_path: location of code files within the current repository
+
+With --base, the exit code is 1 when the hashes differ, e.g. to prove in CI that the traceability data used
+for the verification of a release branch is identical to the archived baseline.
+`
+
+const genTestsUsage = `Prints the skeleton of a test file verifying the given low-level requirements, with a test per
+requirement tagged with its ID, its title and body in the comments, and a TODO per attribute, e.g. the
+Verification method or the parameters, to be completed. Usage:
+	reqtraq gen-tests <requirement_id>... --format=<gotest|robot> --at=<commit> --certdoc_path=<path>
+		--code_path=<path>
+Parameters:
+	<requirement_id>	the low-level requirements to be verified
+	--format: gotest for a Go test file, by default, or robot for a Robot Framework suite.
+	--at: the commit of the requirements, the working tree when empty.
+	--certdoc_path: location of certification documents within the current repository
+	--code_path: location of code files within the current repository
+
+The Go tests are named after the IDs, e.g. TestREQ_0_PROJ_SWL_001, in the package of the Go files of the
+current directory, and tagged with "// @tests @llr REQ-...", so the test file traces to the requirements once saved
+with a test file name, e.g. parser_test.go. The Robot Framework test cases are tagged with the IDs with
+[Tags]. The tests are skipped until they are written. Example:
+	reqtraq gen-tests REQ-0-PROJ-SWL-001 REQ-0-PROJ-SWL-002 > parser_test.go
+`
+
+const idheaderUsage = `Creates a Go or C header file listing the IDs of the requirements implemented by a component, with
+the hash of the requirement graph, to be compiled into its binary, so the fielded software can report exactly
+which requirement baseline it implements. Usage:
+	reqtraq idheader <output_filename> --component=<name> --format=<go|c> --at=<commit> --certdoc_path=<path>
+		--code_path=<path>
+Parameters:
+	<output_filename>	the file to be created, e.g. reqids.go or reqids.h
+	--component: the component, as in the Component attribute of its requirements, see extract-graph, all the
+		requirements when empty.
+	--format: go or c, by default go for the .go files and c for the others.
+	--at: the commit of the requirements, the working tree when empty.
+	--certdoc_path: location of certification documents within the current repository
+	--code_path: location of code files within the current repository
+
+The requirements listed are those whose graph is complete, as in the manifest, see "reqtraq help manifest".
+The Go file defines the Component, Ref, GraphHash constants and the Requirements variable, in the package of
+the other Go files of its directory. The C header defines REQTRAQ_COMPONENT, REQTRAQ_REF, REQTRAQ_GRAPH_HASH,
+REQTRAQ_REQUIREMENT_COUNT and the null-terminated reqtraq_requirements array. The file has no generation time,
+so the builds stay reproducible. It is not created when problems are found in the requirements.
+`
+
+const importUsage = `Creates a Markdown certification document from requirements kept in another format. Usage:
+	reqtraq import csv <input_csv_filename> <output_md_filename> --mapping=<path_to_mapping_json> [--force]
 Parameters:
-	<input_lyx_filename>	Lyx file to be linkified
-	<output_lyx_filename>	linkified Lyx file
+	<input_csv_filename>	CSV file with a header row and one requirement per row
+	<output_md_filename>	Markdown certification document to be created, e.g. 0-DDLN-100-ORD.md
+				When it exists, it is changed only with --force: the requirements are appended
+				after its last requirement, numbered after its requirements, and the rest of it
+				is kept as it is
+	--mapping: path to json specifying the column holding each requirement field, for example:
+		{
+			"id": "Req ID",
+			"title": "Name",
+			"body": "Description",
+			"parents": "Traces to",
+			"attributes": {"Rationale": "Why", "Verification": "Method", "Safety Impact": "Safety"}
+		}
+		When "id" is missing, the requirements are numbered in the order they appear in the CSV file.
+
+The created document is parsed and checked the same way as the other certification documents, and written
+only when valid.
+`
+
+const linkifyUsage = `Changes the certdoc content by adding named destinations and links to parent requirements. Usage:
+	reqtraq linkify <input_filename> <output_filename> --preserve --base=<baseline>
+	reqtraq linkify [<input_filename>...] --out-dir=<dir> --jobs=<n> --preserve --base=<baseline>
+Parameters:
+	<input_filename>	Lyx or Markdown file to be linkified, all the certification documents in the certdoc
+				path when none is given with --out-dir
+	<output_filename>	linkified Lyx or Markdown file
+	--out-dir: the directory where to write the linkified documents, with the names of the input files.
+	--jobs: the number of documents linkified in parallel, by default the number of CPUs. The documents
+		which could not be linkified are all reported at the end, the others being written.
+	--preserve: keep the original line endings, including a missing newline at the end of the file, so the
+		output only differs from the input in the lines where anchors and links are added.
+	--base: the commit of the last published version of the document. A "Requirement Change
+		Log" section is appended, with a table of the requirements of the document added, removed and
+		modified since, so it does not have to be maintained by hand.
+
+Markdown requirement headings get a {#REQ-...} identifier, which pandoc turns into an HTML anchor or
+a PDF named destination, matching the hypertargets added to Lyx files.
+
+The linkified Markdown files start with a "reqtraq: generated" comment, so they are not parsed when kept
+next to their sources. Any certification document having this marker in its first lines is skipped, as
+are those matching the generated patterns of reqtraq.yaml, e.g. for the Lyx files:
+	generated: ["*-linked.lyx", "certdocs/out/*"]
 `
 
 const listUsage = `Parses and lists all requirements found in certification documents. Usage:
-	reqtraq list <input_lyx_filename>
+	reqtraq list <input_lyx_filename> --owner=<name> --tag=<tags> --blame
+	reqtraq list --owner=<name> --tag=<tags> --team=<teams> --blame --certdoc_path=<path>
 Parameters:
 	<input_lyx_filename>	Lyx file to be parsed
+	--owner: only list the requirements having the given name in their Owner attribute. Without
+		<input_lyx_filename> the requirements of all the certification documents are considered.
+	--tag: only list the requirements having one of the given comma separated tags in their Tags
+		attribute, e.g. --tag=navigation,datalink.
+	--team: only list the requirements owned by one of the given comma separated teams in the CODEOWNERS
+		file, see "reqtraq help precommit", e.g. --team=@daedalean/display.
+	--blame: also print the last author and commit which changed each requirement, from git blame
+		over its lines in the certification document, including the uncommitted changes.
+	--certdoc_path: location of certification documents within the current repository
+`
+
+const packageUsage = `Creates a zip with the changes of the requirements between two baselines, for submission to the
+certification authorities at each stage of involvement audit. Usage:
+	reqtraq package --base=<commit> --target=<commit> --key=<private_key_pem> --pfx=<reportfile-prefix>
+		--coverage=<reports> --certdoc_path=<path> --code_path=<path>
+Parameters:
+	--base: the baseline of the previous audit, a commit, e.g. the SOI2 tag.
+	--target: the baseline of this audit, a commit, e.g. the SOI3 tag, the working tree when empty.
+	--key: the PEM PKCS #8 private key signing the manifest, unsigned when empty, see "reqtraq help manifest".
+	--external: the bodies of the requirements tagged EXPORT-CONTROLLED are omitted from the changes and the
+		reports, see "reqtraq help reportdown", and the documents defining them are not packaged.
+	--pfx: path and filename prefix for the created package.zip.
+	--coverage: comma separated coverage reports of the code, recorded in the manifest.
+	--certdoc_path: location of certification documents within the current repository
+	--code_path: location of code files within the current repository
+
+The package contains:
+	changes.md, changes.json	the changes of the requirements, as printed by the diff command
+	trace-down.html, trace-up.html	the top down and bottom up traceability reports of the target
+	documents/			the certification documents of the target defining changed requirements
+	manifest.json			the manifest of the target, see "reqtraq help manifest"
+`
+
+const manifestUsage = `Creates a json manifest of the traceability of a release, signed with an ed25519 key, to be
+archived along with the built binaries as configuration management evidence. Usage:
+	reqtraq manifest <output_json_filename> --key=<private_key_pem> --at=<commit> --coverage=<reports>
+		--certdoc_path=<path> --code_path=<path>
+	reqtraq manifest verify <manifest_json_filename> --key=<public_key_pem>
+Parameters:
+	<output_json_filename>	the manifest to be created
+	--key: the PEM PKCS #8 private key signing the manifest, e.g. created with
+		"openssl genpkey -algorithm ed25519 -out key.pem", or to verify it, the public key, e.g. extracted
+		with "openssl pkey -in key.pem -pubout".
+	--at: the released commit, the working tree when empty.
+	--coverage: comma separated coverage reports of the code, see reportup.
+	--certdoc_path: location of certification documents within the current repository
+	--code_path: location of code files within the current repository
+
+The manifest records the released commit, the hash of the requirement graph, the coverage of the code of
+each requirement and the IDs of the requirements whose graph is complete. It is not created when problems
+are found in the requirements.
+`
+
+const newdocUsage = `Creates the skeleton of a new Markdown certification document in the certdoc path, named after
+its type and project, with the header, the sections and the references to the related documents of the
+existing documents, to be filled in. Usage:
+	reqtraq newdoc --type=<type> --project=<project> --certdoc_path=<path>
+Parameters:
+	--type: the type of the document, e.g. SDD, which determines its number, e.g. 0-DDLN-212-SDD.md.
+	--project: the project of the document, e.g. 0-DDLN, by default that of the existing documents.
+	--certdoc_path: location of certification documents within the current repository, the first one when
+		several.
+
+The requirement documents, e.g. SRD or SDD, get the sections of the requirements, their IDs being generated
+by "reqtraq nextid". The path of the created document is printed.
 `
 
 const nextidUsage = `Generates the next requirement id for the given document. Usage:
@@ -83,41 +813,370 @@ Parameters:
 	<input_lyx_filename>	Lyx file to generate the next requirement id for
 `
 
+const publishUsage = `Publishes the certification documents: linkifies them, see "reqtraq help linkify", converts them
+to PDF in parallel, and checks that the named destination of each requirement survived the conversion, so
+the links of the reports and of the other documents find it. Usage:
+	reqtraq publish [<input_filename>...] --out-dir=<dir> --jobs=<n> --base=<baseline> --check
+Parameters:
+	<input_filename>	Lyx or Markdown file to be published, all the certification documents in the certdoc
+				path when none is given
+	--out-dir: the directory where to write the PDFs, named after the documents, by default the
+		publish output of reqtraq.yaml.
+	--jobs: the number of documents processed in parallel, by default the number of CPUs.
+	--base: append to the documents the changes of their requirements since this commit.
+
+The conversion commands are configured by format in reqtraq.yaml, see "reqtraq help config", e.g. to
+convert the LyX documents with latexmk:
+	publish:
+	  output: build/docs
+	  commands:
+	    lyx: lyx --export-to pdflatex "$OUTPUT.tex" "$INPUT" && latexmk -pdf -cd "$OUTPUT.tex" && mv "$OUTPUT.pdf" "$OUTPUT"
+	    md: pandoc "$INPUT" --pdf-engine=xelatex -o "$OUTPUT"
+The commands run in the directory of the document, so the relative paths of its images are found, with
+the linkified document in INPUT and the PDF to create in OUTPUT. By default the LyX documents are
+converted with "lyx --export-to pdf4" and the Markdown documents with pandoc. The stamp of the documents,
+see "reqtraq help reportdown", is the subject of the PDFs, for the LyX documents when they use hyperref,
+and is in REQTRAQ_STAMP for the commands.
+
+The documents which could not be published are all reported at the end. Then the PDFs are parsed, and the
+requirements whose links would not find their named destination are reported as findings: the PDF named
+after their type, e.g. 0-DDLN-211-SRD.pdf for the SWH requirements, was not published, or it has no named
+destination for them, or one not pointing to a page. With --check, the PDFs already in the output
+directory are only checked.
+`
+
 const precommitUsage = `Runs the pre-commit checks for the requirement documents in the current repository. Usage:
-	reqtraq precommit --certdoc_path=<path>
+	reqtraq precommit --certdoc_path=<path> --roster=<path_to_roster_json>
 Parameters:
 	--certdoc_path: location of certification documents within the current repository
+	--roster: path to json listing the team members, e.g. {"members": ["alice", "bob"]}. When it exists,
+		the names in the Owner and Reviewer attributes must be in it, and nobody can review their own
+		requirements.
+	--coverage: comma separated coverage reports of the code in the working tree: lcov tracefiles, e.g. from
+		lcov for gcov or from llvm-cov export -format=lcov, gcov files or go cover profiles.
+	--min-coverage, --min-branch-coverage: with --coverage, the minimum statement and branch coverage, in
+		percent, of the code implementing each requirement, the code following its @llr tags up to the next tags.
+	--waivers: path to YAML listing the intentional traceability gaps, certdocs/waivers.yaml by default, e.g.
+		- id: REQ-0-DDLN-SWH-007
+		  finding: missing_parent
+		  justification: Derived requirement of the display hardware.
+		  owner: alice
+		  expires: 2025-12-31
+		The findings of the given type about the requirement, as counted in the --summary-file, are not
+		reported through the expiry date. The expired waivers are reported.
+
+The style and the terminology of the requirements are checked as configured in reqtraq.yaml, see "reqtraq help
+config", and the problems are printed as warnings, which do not change the exit code.
+
+The @llr tags referencing requirements which do not exist, e.g. mistyped or deleted, are reported with their
+file and line.
+
+The requirements reusing the ID of a requirement deleted in the history of HEAD, removed or marked DELETED,
+with a different title or body are reported, see "reqtraq help check".
+
+The requirements mentioned in the text of the documents, e.g. "as computed by REQ-0-DDLN-SWH-003" in the
+body of another requirement, must exist and not be deleted, and are reported with their file and line
+otherwise. The reports link the mentions, and show the requirements each one mentions and is mentioned by, so
+the implicit coupling between requirements can be reviewed.
+
+The findings about a single requirement can be suppressed by a pragma preceding it in the document, e.g.
+"<!-- reqtraq:ignore missing-attribute RATIONALE -->" in Markdown, or a LyX note containing
+"reqtraq:ignore missing-attribute RATIONALE". The check is a finding type, as counted in the --summary-file,
+or missing-attribute, and the optional argument must appear in the finding. The suppressions are listed in
+the appendix of the reports.
+
+When the repository has a CODEOWNERS file, in .github, at its root or in docs, every requirement must be owned
+by at least one team: the owners of the last pattern matching its certification document or one of the code
+files implementing it, e.g. with "certdocs/*-SRD.md @daedalean/systems". The owners need not be GitHub teams.
 
 If the binary exits with a 0 exitcode, the requirement documents are correct. A non-zero exit code signals one or more
 problems, which are printed to stderr.
 `
 
 const prepushUsage = `Runs the pre-push checks for the requirement documents in the current repository. Usage:
-	reqtraq prepush --certdoc_path=<path>
+	reqtraq prepush --since=<start_commit> --at=<end_commit> --certdoc_path=<path>
 Parameters:
+	--since: the commit representing the start of the pushed range, e.g. the remote commit.
+	--at: the commit representing the end of the pushed range, the working tree when missing.
 	--certdoc_path: location of certification documents within the current repository
 
+With --since, the certification documents whose requirements changed in the range must have a new revision,
+e.g. "Revision: 3" or "**Version:** 1.2" in their header, before the first section.
+
 If the binary exits with a 0 exitcode, the pre-push ran successfully. A non-zero exit code signals one or more
 problems, which are printed to stderr.
 `
 
+const queryUsage = `Prints the IDs of the requirements and of the code files matching a query, for ad-hoc audits. Usage:
+	reqtraq query <query> --format=<ids|md|json> --sort=<attribute> --at=<commit> --certdoc_path=<path>
+		--code_path=<path>
+Parameters:
+	<query>	the query, e.g. 'descendants(REQ-0-DDLN-SYS-006) & level(SWL) & !hasCodeRef()'
+	--format: ids (default), one per line, md, a Markdown table with their titles, documents and status, or json.
+	--sort: the attribute by whose values, as typed in reqtraq.yaml, the requirements are sorted instead of
+		by ID, those without a valid value last.
+	--at: the commit whose requirements are queried, the working tree when empty.
+	--certdoc_path: location of certification documents within the current repository
+	--code_path: location of code files within the current repository
+
+The queries combine with & (and), | (or), ! (not) and parentheses the functions:
+	ancestors(ID), parents(ID)	the requirements the given one traces to, recursively or directly
+	descendants(ID), children(ID)	the requirements and code tracing to the given one
+	level(TYPE, ...)		the requirements of the given types, e.g. SWL, or CODE for the code files
+	hasParents(), hasChildren()	the requirements having parents or children
+	hasCodeRef()			the requirements implemented by code
+	deleted()			the deleted requirements
+	status(STATUS)			the requirements of the given status: NOT_STARTED, STARTED or COMPLETED
+	id(REGEXP), title(REGEXP), doc(REGEXP)	the IDs, titles or document paths matching a regular expression
+	attr(NAME[, REGEXP])		the requirements having an attribute, own or inherited, matching a regular expression
+	attrCmp(NAME, OP, VALUE)	the requirements whose attribute compares to the value with ==, !=, <, <=, > or >=,
+					as numbers, enum positions, dates or texts, as typed in reqtraq.yaml
+	owner(NAME), tag(TAG)		the requirements owned by a person, or having a tag
+The arguments can be quoted, e.g. title("(?i)speed, altitude").
+`
+
+const viewUsage = `Prints the requirements matching a named query of reqtraq.yaml, see "reqtraq help config", so the
+recurring audits are reproducible and reviewed. Usage:
+	reqtraq view <name> --format=<ids|md|json> --sort=<attribute> --at=<commit> --certdoc_path=<path>
+		--code_path=<path>
+Parameters:
+	<name>	the name of the view, listed when missing
+	--format: overrides the format of the view, see "reqtraq help query".
+	--sort: the attribute by whose values the requirements are sorted, see "reqtraq help query".
+	--at: the commit whose requirements are queried, the working tree when empty.
+	--certdoc_path: location of certification documents within the current repository
+	--code_path: location of code files within the current repository
+`
+
+const validateUsage = `Checks a certification document being edited, e.g. not yet saved in an editor, against the other
+documents and the code of the working tree, and prints its findings as json, for the editor plugins. Usage:
+	reqtraq validate <path> --stdin --format=<md|lyx> --certdoc_path=<path> --code_path=<path>
+		--attributes=<path_to_attributes_json>
+Parameters:
+	<path>	the path of the document, giving its name, e.g. certdocs/0-PROJ-211-SRD.md
+	--stdin: the contents of the document are read from stdin rather than from <path>.
+	--format: the format of the contents, md or lyx, by default that of <path>.
+	--certdoc_path: location of certification documents within the current repository
+	--code_path: location of code files within the current repository
+	--attributes: path to json with requirement attribute specification.
+
+The findings are listed with their type, as in the --summary-file, their severity, error or warning, and
+their message, for example:
+	[
+	  {
+	    "type": "attribute",
+	    "severity": "error",
+	    "message": "Requirement 'REQ-0-PROJ-SWH-003' is missing attribute 'Verification'."
+	  }
+	]
+The errors are those of precommit concerning the requirements of the document, the references to them
+included, and the warnings those of its style and terminology checks. The working tree is not changed.
+It exits with 1 when there are errors.
+`
+
+const versionUsage = `Prints the version of reqtraq. Usage:
+	reqtraq version --verbose
+Parameters:
+	--verbose: also print the provenance of the binary, to be recorded with the outputs of each run for the tool
+		qualification: the version of the module, the commit it was built from, marked modified when the working
+		tree had changes, the date of the commit, the build date, the Go version, and the versions of the
+		reqtraq.yaml schema, of the snapshots and of the manifests it reads.
+
+The version is the module version when reqtraq was installed with go install, else the commit it was built
+from. The build date is only known when set at link time, e.g. with
+	go build -ldflags "-X main.buildDate=$(date -u -Iseconds)"
+`
+
 const reportUsage = `
 	reportdown 	creates an HTML traceability report from system requirements down to code
 	reportissues	creates an HTML report with all issues found in the requirement documents
+	reportowners	creates an HTML report listing the requirements owned by each person
+	reporttargets	creates an HTML report listing the requirements implemented by each Bazel or CMake target
 	reportup 	creates an HTML traceability report from code, to LLRs, to HLRs and to system requirements
 Usage:
 	reqtraq report<type> --pfx=<reportfile-prefix> --title_filter=<regexp> --id_filter=<regexp>
-		--body_filter=<regexp> --attributes=<path_to_attributes_json> --since=<start_commid> --at=<end_commit>
-		--certdoc_path=<path>
+		--body_filter=<regexp> --section_filter=<regexp> --tag=<tags> --team=<teams>
+		--attributes=<path_to_attributes_json> --external --redact
+		--since=<start_commid> --at=<end_commit> --certdoc_path=<path>
 Parameters:
 	--pfx: path and filename prefix for reports.
 	--title_filter: regular expression to filter by requirement title.
 	--id_filter: regular expression to filter by requirement id.
 	--body_filter: regular expression to filter by requirement body.
+	--section_filter: regular expression to filter by the section of the document the requirement is under,
+		the titles of its enclosing headings separated by " > ", e.g. "Requirements > Functional".
+	--tag: comma separated tags, to filter by the tags of the requirements, e.g. --tag=navigation.
+	--team: comma separated teams, to filter by the teams owning the requirements in the CODEOWNERS file, e.g.
+		--team=@daedalean/display, giving the report of each team.
 	--attributes: path to json with requirement attribute specification.
 	--since: the Git commit SHA-1 representing the start of the range.
-	--at: the commit representing the end of the range.
+	--at: the commit representing the end of the range, e.g. a release tag.
+	--certdoc_path: location of certification documents within the current repository
+	--suspect: mark the suspect links, see below.
+	--blame: show the last author and commit which changed each requirement, from git blame.
+	--coverage: comma separated coverage reports, see "reqtraq help precommit".
+	--findings: comma separated static analysis reports of the code in the working tree: SARIF logs, e.g. from
+		clang-tidy via clang-tidy-sarif, or clang-tidy outputs. The findings in the code following the @llr tags
+		of a requirement, up to the next tags, are shown with the requirement and listed in the issues report.
+	--external: the reports are for external parties, the bodies of the requirements having the
+		EXPORT-CONTROLLED tag, and their translations, are replaced with a notice.
+	--redact: the bodies of the requirements, their translations and the values of the attributes having
+		"sensitive: true" in reqtraq.yaml are replaced by their hashes, keeping the titles, the links and the
+		coverage, to share the traceability status with partners.
+
+The requirements at --at and --since are read from the git objects, without checking out the commits, so the
+reports of any baseline can be created whatever the state of the working tree.
+
+The top down report groups the system requirements by the numbered sections of their document they are under,
+and each requirement shows its section.
+
+The owners report lists the requirements by the names in their Owner attribute, with their reviewers, and
+when the repository has a CODEOWNERS file, by the teams owning them, see "reqtraq help precommit".
+
+The targets report lists, for each binary, library and test defined in the Bazel BUILD files and the
+CMakeLists.txt files of the working tree, the requirements implemented by its sources and their ancestors, to
+scope the verification per deliverable. Only the sources listed literally are found, not those found with glob
+or given with variables.
+
+With --lang=de the labels and headings of the reports are in German. The requirements having their body
+translated in an attribute, e.g. "Body (de): Die Software muss ...", are shown with both bodies side by side.
+
+The placeholders in the title and the body of the requirements, e.g. "The {component} shall ...", are replaced
+with the values of their parameter attributes, e.g. "Param (component): left wing", so families of similar
+requirements can share their text. precommit reports the placeholders without value. A generic requirement
+with a parameter table, e.g. "Instances: channel=1, address=0x10; channel=2, address=0x20", is the parent of one
+requirement per row, with the ID of the generic one followed by a dot and the row number, e.g. REQ-0-DDLN-HWH-010.2.
+
+With --suspect the links to parents whose title or body changed, according to the git history, after the
+requirement was last modified are marked suspect, and listed in the issues report. Changing the requirement
+clears the mark, e.g. setting its Confirmed attribute, as does a commit with a "Confirms: <ID>" line in its
+message.
+
+The reports, as the dashboard, end with a stamp identifying their source, so the archived reports are
+self-describing: the --at commit as described by git, e.g. v2.1-3-g1a2b3c4 with a -dirty suffix for a modified
+working tree, the hash of the requirement graph, see "reqtraq help hash", the generation time and the version
+of reqtraq.
+`
+
+const testcasesUsage = `Prints the requirement to test case matrix: the GoogleTest and Catch2 test cases of the C and C++
+test files, e.g. parser_test.cc, and the test functions of the Go test files, verifying each low-level
+requirement, for the verification traceability of the SVCP. Usage:
+	reqtraq testcases --format=<md|json> --at=<commit> --certdoc_path=<path> --code_path=<path>
+Parameters:
+	--format: md (default), a Markdown table with a row per requirement and test case, or json.
+	--at: the commit of the requirements, the working tree when empty, or the --snapshot if any.
+	--certdoc_path: location of certification documents within the current repository
+	--code_path: location of code files within the current repository
+
+A test case is started by a TEST, TEST_F, TEST_P, TYPED_TEST or TYPED_TEST_P macro, named Suite.Name, or by a
+TEST_CASE, SCENARIO, TEST_CASE_METHOD, TEMPLATE_TEST_CASE or TEMPLATE_PRODUCT_TEST_CASE macro, named by its
+string, whose arguments can span several lines, or by a "func TestXxx(t *testing.T)" function. It verifies the requirements of the tags in the comments
+immediately above the macro or in its body, "// @llr REQ-..." or, in the test files, "// @tests REQ-..." or
+"// @tests @llr REQ-...", of any level. The tags above the first test case, but those immediately above it, are
+those of the whole file, verified by all its test cases. The requirements without test case are listed with none.
+`
+
+const stalenessUsage = `Lists the requirements and the code files implementing them which were last modified more than
+--stale-days apart according to git, hinting that one diverged from the other: the code modified long
+after its requirement, or the requirement modified long after its code. Usage:
+	reqtraq staleness --stale-days=<days> --at=<commit> --format=<md|json> --certdoc_path=<path> --code_path=<path>
+Parameters:
+	--stale-days: the number of days, 365 by default or stale_days in reqtraq.yaml.
+	--at: the commit whose history is considered, the working tree when empty.
+	--format: md (default), a Markdown table, or json.
+	--certdoc_path: location of certification documents within the current repository
+	--code_path: location of code files within the current repository
+
+A requirement was last modified when one of its lines in the certification document was last changed,
+see --blame, and a code file by the last commit changing it.
+`
+
+const suggestUsage = `Prints drafts of children of a requirement, returned by the endpoint configured in reqtraq.yaml, e.g. an
+internal language model service, for the author to edit. Usage:
+	reqtraq suggest <requirement_id> --certdoc_path=<path> --code_path=<path>
+Parameters:
+	<requirement_id>	the requirement whose children are drafted
+	--certdoc_path: location of certification documents within the current repository
+	--code_path: location of code files within the current repository
+
+Nothing is sent unless suggest is configured, see "reqtraq help config", nor with --offline or when
+REQTRAQ_OFFLINE is set. The requirement, with its title, body and attributes, and the titles of its children
+are posted as json, the body of an EXPORT-CONTROLLED requirement being omitted unless export_controlled is
+set in the configuration:
+	{"parent": {"id": "...", "title": "...", "body": "...", "attributes": {...}}, "children": [{"id": "...", "title": "..."}]}
+and the endpoint returns the drafts:
+	{"requirements": [{"title": "...", "body": "...", "attributes": {...}}]}
+The drafts are printed as Markdown requirements with DRAFT IDs, to be replaced with those of "reqtraq nextid".
+`
+
+const rollupUsage = `Prints the values of a numeric attribute of the requirements, e.g. Effort or Complexity, with their
+totals over the requirements and their descendants, for planning from the requirement tree. Usage:
+	reqtraq rollup <attribute> --at=<commit> --format=<md|json> --certdoc_path=<path>
+Parameters:
+	<attribute>	an attribute declared with "type: int" or "type: float" in reqtraq.yaml, see "reqtraq help config"
+	--at: the commit whose requirements are listed, the working tree when empty.
+	--format: md (default), a Markdown table, or json.
+	--certdoc_path: location of certification documents within the current repository
+
+The descendants shared by several children, e.g. an LLR satisfying two HLRs, are counted once in each total.
+The requirements without the attribute count as zero, and the deleted requirements are not counted.
+`
+
+const similarUsage = `Lists the pairs of requirements whose texts are similar, often copy-pasted requirements to be merged,
+or one to be the parent of the other. Usage:
+	reqtraq similar --similarity=<0..1> --at=<commit> --format=<md|json> --certdoc_path=<path>
+Parameters:
+	--similarity: the minimum similarity of the listed pairs, 0.8 by default.
+	--at: the commit whose requirements are compared, the working tree when empty.
+	--format: md (default), a Markdown table, or json.
+	--certdoc_path: location of certification documents within the current repository
+
+The similarity is the cosine similarity of the sequences of three consecutive words of the titles and the
+bodies, ignoring the case and the punctuation. The deleted requirements are not compared. The pairs where
+one requirement is the parent of the other are marked as linked.
+`
+
+const snapshotUsage = `Parses the requirements and the code and saves the resolved requirement graph to a json file,
+which the other commands use instead of parsing again when given with --snapshot, e.g. on another machine. Usage:
+	reqtraq snapshot <output_json_filename> --certdoc_path=<path> --code_path=<path>
+Parameters:
+	<output_json_filename>	the snapshot to be created
+	--certdoc_path: location of certification documents within the current repository
+	--code_path: location of code files within the current repository
+
+The problems found in the requirements are saved in the snapshot too, and reported again when it is used.
+The snapshot has a format version, a snapshot created by an incompatible version of reqtraq is refused.
+`
+
+const trendUsage = `Creates a CSV file with the progress of the requirements of each level over time, for plotting
+the traceability progress e.g. for program reviews. Usage:
+	reqtraq trend --since=<yyyy-mm-dd> --step=<daily|weekly|monthly> --pfx=<reportfile-prefix>
+		--certdoc_path=<path> --code_path=<path>
+Parameters:
+	--since: the date of the first point of the trend.
+	--step: the interval between the points of the trend: daily, weekly (default) or monthly.
+	--pfx: path and filename prefix for the created trend.csv.
+	--certdoc_path: location of certification documents within the current repository
+	--code_path: location of code files within the current repository
+
+Each point is computed at the last commit of the current branch before its date, checked out in a temporary
+clone of the repository. The CSV file has one row per date and level, with the same numbers as the dashboard,
+and the stamp of the last point in the stamp column, see "reqtraq help reportdown".
+`
+
+const tuiUsage = `Starts an interactive terminal browser of the requirements and code files. Usage:
+	reqtraq tui --certdoc_path=<path> --code_path=<path>
+Parameters:
 	--certdoc_path: location of certification documents within the current repository
+	--code_path: location of code files within the current repository
+
+Keys:
+	up/down, j/k	move the selection
+	enter, right	show the selected requirement with its parents, children and code files
+	left, backspace	go back
+	/		fuzzy search requirement IDs and titles
+	o		open the selected requirement or code file in $EDITOR at the right line
+	q		quit
 `
 
 const updateTaskUsage = `Updates the tasks associated with the given requirements (requires a Phabricator/JIRA/Bugzilla instance). Usage:
@@ -137,97 +1196,273 @@ For each requirement the method will:
       		Parents: the first parent task (Phabricator doesn't yet support multiple parents in the api)
 `
 
+const grpcUsage = `Starts a gRPC server exposing the requirement graph, so the tools written in other languages can embed
+the checks of reqtraq with the generated clients. Usage:
+	reqtraq grpc --addr="hostport" --certdoc_path=<path> --code_path=<path> --attributes=<path_to_attributes_json>
+Parameters:
+	--addr: the ip:port where to serve. Use e.g. 0.0.0.0:8080 to serve on all interfaces, as in a container.
+	--certdoc_path: location of certification documents within the current repository.
+	--code_path: location of code files within the current repository.
+	--attributes: path to json with requirement attribute specification.
+
+The service Reqtraq is defined in reqtraqpb/reqtraq.proto:
+	GetRequirement	returns a requirement, by ID
+	Query		streams the requirements and the code files matching a query, see "reqtraq help query"
+	Diff		streams the changes of the requirements and the code files between two commits
+	Check		streams the problems found: all those of precommit in the working tree, or those found
+			while building the requirement graph at a commit
+Each call takes the commit in its "at" field, the working tree when empty, or the --snapshot if any. The
+requirements are read from the git objects, whatever the state of the working tree.
+On SIGINT or SIGTERM it stops accepting calls and exits once the calls being handled are done.
+`
+
 const webUsage = `Starts a local web server to facilitate interaction with reqtraq. Usage:
-	reqtraq web --addr="hostport" --certdoc_path=<path>
+	reqtraq web --addr="hostport" --certdoc_path=<path> --suspect --blame --cache-size=<n>
 Parameters:
-	--addr: the ip:port where to serve.
+	--addr: the ip:port where to serve. Use e.g. 0.0.0.0:8080 to serve on all interfaces, as in a container.
 	--certdoc_path: location of certification documents within the current repository.
+	--suspect: mark the suspect links in the reports, see "reqtraq help reportdown".
+	--blame: show the last author and commit which changed each requirement in the reports.
+	--cache-size: the number of requirement graphs kept, 8 by default, 0 to rebuild them for each request.
+
+The requirement graphs are kept by commit, the least recently used being dropped, so browsing the reports
+of the same versions does not rebuild them. The graphs of the branches are rebuilt when they move. The
+graph of the working tree is kept as well, and rebuilt when the size or the modification time of one of the
+certification documents or code files, or of the --snapshot, changes. A POST to /cache/invalidate?at=worktree
+drops it, /cache/invalidate?at=<commit> drops the graph of a commit, and /cache/invalidate without "at"
+drops them all.
+
+The reports have an ETag derived from the hashes of the requirement graphs and from the report and filters
+selected, and a Last-Modified date, when the graphs were built. They are revalidated by the browsers and
+proxies each time, and only downloaded again when the requirements changed.
+
+Besides the reports, the server answers liveness probes on /healthz and readiness probes on /readyz, and
+exposes Prometheus metrics on /metrics: the size of the last built requirement graph and the problems found
+while building it, the time spent building graphs, and the duration of the HTTP requests.
+On SIGINT or SIGTERM it stops accepting requests, /readyz fails, and it exits once the requests being
+handled are done.
 `
 
+// command is the reqtraq command being run, e.g. precommit.
+var command string
+
 type JsonConf struct {
 	Attributes []map[string]string
 }
 
-func showHelp() {
-	subCommand := ""
-	if len(os.Args) > 1 {
-		subCommand = os.Args[1]
-	}
+func showHelp(subCommand string) {
 	switch subCommand {
 	case "help", "": // general help
 		fmt.Println(usage)
+	case "apply":
+		fmt.Print(applyUsage)
+	case "annotate":
+		fmt.Print(annotateUsage)
+	case "bom":
+		fmt.Print(bomUsage)
+	case "commitmsg":
+		fmt.Print(commitmsgUsage)
+	case "completion":
+		fmt.Print(completionUsage)
+	case "config":
+		fmt.Print(configUsage)
+	case "convert":
+		fmt.Print(convertUsage)
+	case "dashboard":
+		fmt.Print(dashboardUsage)
+	case "fmt":
+		fmt.Print(fmtUsage)
+	case "diff":
+		fmt.Print(diffUsage)
+	case "deporder":
+		fmt.Print(deporderUsage)
+	case "doctrace":
+		fmt.Print(doctraceUsage)
+	case "export":
+		fmt.Print(exportUsage)
+	case "extract":
+		fmt.Print(extractUsage)
+	case "extract-graph":
+		fmt.Print(extractGraphUsage)
+	case "merge-graph":
+		fmt.Print(mergeGraphUsage)
+	case "check":
+		fmt.Print(checkUsage)
+	case "checklist":
+		fmt.Print(checklistUsage)
+	case "checklinks":
+		fmt.Print(checklinksUsage)
+	case "churn":
+		fmt.Print(churnUsage)
+	case "gen-tests":
+		fmt.Print(genTestsUsage)
+	case "grpc":
+		fmt.Print(grpcUsage)
+	case "hash":
+		fmt.Print(hashUsage)
+	case "idheader":
+		fmt.Print(idheaderUsage)
+	case "import":
+		fmt.Print(importUsage)
 	case "linkify":
-		fmt.Println(linkifyUsage)
+		fmt.Print(linkifyUsage)
 	case "list":
-		fmt.Println(listUsage)
+		fmt.Print(listUsage)
+	case "manifest":
+		fmt.Print(manifestUsage)
+	case "package":
+		fmt.Print(packageUsage)
+	case "publish":
+		fmt.Print(publishUsage)
+	case "newdoc":
+		fmt.Print(newdocUsage)
 	case "nextid":
-		fmt.Println(nextidUsage)
+		fmt.Print(nextidUsage)
 	case "precommit":
-		fmt.Println(precommitUsage)
+		fmt.Print(precommitUsage)
 	case "prepush":
-		fmt.Println(prepushUsage)
-	case "reportup", "reportdown", "reportissues":
-		fmt.Println(reportUsage)
+		fmt.Print(prepushUsage)
+	case "query":
+		fmt.Print(queryUsage)
+	case "view":
+		fmt.Print(viewUsage)
+	case "validate":
+		fmt.Print(validateUsage)
+	case "reportup", "reportdown", "reportissues", "reportowners", "reporttargets":
+		fmt.Print(reportUsage)
+	case "rollup":
+		fmt.Print(rollupUsage)
+	case "similar":
+		fmt.Print(similarUsage)
+	case "suggest":
+		fmt.Print(suggestUsage)
+	case "snapshot":
+		fmt.Print(snapshotUsage)
+	case "staleness":
+		fmt.Print(stalenessUsage)
+	case "testcases":
+		fmt.Print(testcasesUsage)
+	case "trend":
+		fmt.Print(trendUsage)
+	case "tui":
+		fmt.Print(tuiUsage)
 	case "updatetasks":
-		fmt.Println(updateTaskUsage)
+		fmt.Print(updateTaskUsage)
+	case "version":
+		fmt.Print(versionUsage)
 	case "web":
-		fmt.Println(webUsage)
+		fmt.Print(webUsage)
 	default:
 		fmt.Printf("Unknown command '%s'", subCommand)
 		fmt.Println(usage)
 	}
 }
 
-func main() {
-	flag.Parse()
-	command := flag.Arg(0)
-	if command == "" {
-		command = "help"
+// parseArgs parses the flags wherever they appear on the command line, e.g.
+// before or after the command, and returns the remaining positional arguments.
+func parseArgs(args []string) []string {
+	var positional []string
+	for {
+		if err := flag.CommandLine.Parse(args); err != nil {
+			os.Exit(2)
+		}
+		args = flag.Args()
+		if len(args) == 0 {
+			return positional
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
 	}
+}
 
-	var err error
+// argAt returns the i-th positional argument or the empty string if missing.
+func argAt(args []string, i int) string {
+	if i < len(args) {
+		return args[i]
+	}
+	return ""
+}
 
-	linepipes.Verbose = *fVerbose
+func main() {
+	// The words to be completed are not parsed as flags, they can be incomplete.
+	if len(os.Args) > 1 && os.Args[1] == "__complete" {
+		for _, c := range Complete(os.Args[2:]) {
+			fmt.Println(c)
+		}
+		return
+	}
 
-	// check to see if the command has a second parameter, e.g. list <filename>
-	f := ""
-	remainingArgs := flag.Args()
-	if len(remainingArgs) > 1 {
-		if !strings.HasPrefix(remainingArgs[1], "-") {
-			f = remainingArgs[1]
+	args := parseArgs(os.Args[1:])
+	command = argAt(args, 0)
+	if command == "" {
+		command = "help"
+	}
+
+	if err := setupLogging(*fLogLevel, *fLogFormat, *fVerbose); err != nil {
+		usageError(err)
+	}
+
+	repoPath, err := git.RepoPath()
+	if err != nil && command != "help" && command != "version" {
+		fatal(err)
+	}
+
+	if command != "config" && command != "version" {
+		if err := applyRepoConfig(*fConfig); err != nil {
+			fatal(err)
 		}
-		// See maybe there are more flags after the `action`.
-		os.Args = append(os.Args[:1], remainingArgs[1:]...)
-		flag.Parse()
 	}
 
+	if err := checkLang(*fLang); err != nil {
+		usageError(err)
+	}
+
+	if *fOffline {
+		taskmgr.TaskMgr = taskmgr.OfflineTaskManager{}
+	}
+
+	// check to see if the command has a second parameter, e.g. list <filename>
+	f := argAt(args, 1)
+
 	filter := ReqFilter{} // Filter for report generation
 	switch command {
 	case "reportdown", "reportup", "reportissues":
 		if len(*fReportTitleFilterString) > 0 {
 			filter[TitleFilter], err = regexp.Compile(*fReportTitleFilterString)
 			if err != nil {
-				log.Fatal(err)
+				fatal(err)
 			}
 		}
 		if len(*fReportIdFilterString) > 0 {
 			filter[IdFilter], err = regexp.Compile(*fReportIdFilterString)
 			if err != nil {
-				log.Fatal(err)
+				fatal(err)
 			}
 		}
 		if len(*fReportBodyFilterString) > 0 {
 			filter[BodyFilter], err = regexp.Compile(*fReportBodyFilterString)
 			if err != nil {
-				log.Fatal(err)
+				fatal(err)
+			}
+		}
+		if len(*fReportSectionFilter) > 0 {
+			filter[SectionFilter], err = regexp.Compile(*fReportSectionFilter)
+			if err != nil {
+				fatal(err)
 			}
 		}
+		if len(*fTag) > 0 {
+			filter[TagFilter] = tagFilter(*fTag)
+		}
+		if len(*fTeam) > 0 {
+			filter[TeamFilter] = tagFilter(*fTeam)
+		}
 	case "help":
-		showHelp()
+		showHelp(f)
 		os.Exit(0)
-	case "linkify", "list", "nextid":
-		if f == "" {
-			log.Fatal("Missing file name")
+	case "annotate", "bom", "commitmsg", "extract-graph", "idheader", "linkify", "list", "merge-graph", "nextid", "snapshot", "validate":
+		if f == "" && !(command == "list" && (*fOwner != "" || *fTag != "" || *fTeam != "")) && !(command == "linkify" && *fOutDir != "") {
+			usageError("Missing file name")
 		}
 	}
 
@@ -236,233 +1471,1582 @@ func main() {
 		diffs   map[string][]string
 	)
 	switch command {
-	case "reportdown", "reportup", "reportissues", "prepush":
-		var dir string
-		rg, dir, err = buildGraph(*at)
+	case "reportdown", "reportup", "reportissues", "reportowners", "reporttargets", "prepush":
+		rg, err = buildGraph(*at)
+		if rg == nil {
+			fatal(err)
+		}
 		if err != nil {
-			log.Fatal(err)
+			findings(err)
+		}
+		if command != "prepush" {
+			if artifactStamp, err = NewStamp(rg, *at); err != nil {
+				fatal(err)
+			}
 		}
-		defer os.RemoveAll(dir)
 
 		if *since != "" {
-			var dir string
-			prg, dir, err = buildGraph(*since)
+			prg, err = buildGraph(*since)
 			if err != nil {
-				log.Println(err)
+				slog.Warn("cannot build the requirement graph", "commit", *since, "err", err)
 			}
-			defer os.RemoveAll(dir)
 		}
 		diffs = rg.ChangedSince(prg)
+		if *fSuspect {
+			if err := markSuspectLinks(rg, *at); err != nil {
+				fatal(err)
+			}
+		}
+		if *fBlame {
+			if err := setBlame(rg, *at); err != nil {
+				fatal(err)
+			}
+		}
+		if err := setCoverage(rg); err != nil {
+			fatal(err)
+		}
+		if err := setFindings(rg); err != nil {
+			fatal(err)
+		}
+		if err := inheritAttributes(rg); err != nil {
+			fatal(err)
+		}
+		if ok, err := setTeams(rg); err != nil {
+			fatal(err)
+		} else if !ok && *fTeam != "" {
+			usageError("--team needs a CODEOWNERS file")
+		}
+		if *fExternal {
+			rg.OmitExportControlled()
+		}
+		if *fRedact {
+			rg.Redact()
+			prg.Redact()
+			// Again, so the changes do not show the sensitive values.
+			diffs = rg.ChangedSince(prg)
+		}
 	}
 
 	switch command {
+	case "idheader":
+		format := *fFormat
+		if format == "" {
+			format = idHeaderFormat(f)
+		}
+		if format != "go" && format != "c" {
+			usageError(fmt.Sprintf("Unknown format %q", format))
+		}
+		rg, err := buildGraph(*at)
+		if err != nil {
+			findings(err)
+		}
+		ids, err := rg.SatisfiedIDs(*fComponent)
+		if err != nil {
+			fatal(err)
+		}
+		hash, err := rg.Hash()
+		if err != nil {
+			fatal(err)
+		}
+		ref, err := git.Describe(*at)
+		if err != nil {
+			fatal(err)
+		}
+		h := &IDHeader{Component: *fComponent, Ref: ref, GraphHash: hash, IDs: ids}
+		var b bytes.Buffer
+		if format == "go" {
+			err = h.WriteGo(&b, goPackage(filepath.Dir(f)))
+		} else {
+			err = h.WriteC(&b, filepath.Base(f))
+		}
+		if err != nil {
+			fatal(err)
+		}
+		logFileCreate(f)
+		if err := ioutil.WriteFile(f, b.Bytes(), 0644); err != nil {
+			fatal(err)
+		}
+	case "manifest":
+		if *fKey == "" {
+			usageError("Missing --key")
+		}
+		if f == "verify" {
+			fileName := argAt(args, 2)
+			if fileName == "" {
+				usageError("Missing file name")
+			}
+			key, err := ReadVerifyingKey(*fKey)
+			if err != nil {
+				fatal(err)
+			}
+			m, err := ReadManifest(fileName)
+			if err != nil {
+				fatal(err)
+			}
+			if err := m.Verify(key); err != nil {
+				findings(err)
+			}
+			fmt.Printf("Manifest %s of %s is signed with %s\n", fileName, m.Ref, *fKey)
+			break
+		}
+		if f == "" {
+			usageError("Missing file name")
+		}
+		key, err := ReadSigningKey(*fKey)
+		if err != nil {
+			fatal(err)
+		}
+		rg, err := buildGraph(*at)
+		if err != nil {
+			findings(err)
+		}
+		if err := setCoverage(rg); err != nil {
+			fatal(err)
+		}
+		rev := *at
+		if rev == "" {
+			rev = "HEAD"
+		}
+		commit, err := git.ResolveCommit(rev)
+		if err != nil {
+			fatal(err)
+		}
+		ref, err := git.Describe(*at)
+		if err != nil {
+			fatal(err)
+		}
+		m, err := rg.NewManifest(commit, ref)
+		if err != nil {
+			fatal(err)
+		}
+		if err := m.Sign(key); err != nil {
+			fatal(err)
+		}
+		of, err := os.Create(f)
+		if err != nil {
+			fatal(err)
+		}
+		logFileCreate(of.Name())
+		if err := m.Write(of); err != nil {
+			fatal(err)
+		}
+		of.Close()
+	case "newdoc":
+		if *fDocType == "" {
+			usageError("Missing --type")
+		}
+		dir := filepath.Join(repoPath, certdocRoots(*fCertdocPath)[0])
+		project := *fProject
+		if project == "" {
+			docs, err := readDocuments(dir)
+			if err != nil {
+				fatal(err)
+			}
+			projects := map[string]bool{}
+			for _, d := range docs {
+				p, _ := docType(d.ID)
+				projects[p] = true
+				project = p
+			}
+			if len(projects) != 1 {
+				usageError("Missing --project")
+			}
+		}
+		fileName, err := NewDocument(dir, *fDocType, project)
+		if err != nil {
+			fatal(err)
+		}
+		fmt.Println(fileName)
 	case "nextid":
 		nextID, err := NextId(f)
 		if err != nil {
-			log.Fatal(err)
+			fatal(err)
 		}
 		fmt.Println(nextID)
 	case "list":
+		tags, teams := tagFilter(*fTag), tagFilter(*fTeam)
+		listed := func(r *Req) bool {
+			return (*fOwner == "" || r.isOwnedBy(*fOwner)) && (*fTag == "" || r.hasTag(tags)) && (*fTeam == "" || r.hasTeam(teams))
+		}
+		if f == "" {
+			rg, err := CreateReqGraph(*fCertdocPath, *fCodePath)
+			if err != nil {
+				findings(err)
+			}
+			if ok, err := setTeams(rg); err != nil {
+				fatal(err)
+			} else if !ok && *fTeam != "" {
+				usageError("--team needs a CODEOWNERS file")
+			}
+			if *fBlame {
+				if err := rg.SetBlame(""); err != nil {
+					fatal(err)
+				}
+			}
+			reqs := rg.OwnedBy(*fOwner)
+			if *fOwner == "" && *fTag != "" {
+				reqs = rg.Tagged(tags)
+			} else if *fOwner == "" {
+				reqs = rg.OwnedByTeam(teams)
+			}
+			for _, r := range reqs {
+				if listed(r) {
+					printReq(r)
+				}
+			}
+			break
+		}
+		if *fTeam != "" {
+			usageError("--team needs the requirements of all the certification documents, omit <input_lyx_filename>")
+		}
 		reqs, err := ParseCertdoc(f)
 		if err != nil {
-			log.Fatal(err)
+			fatal(err)
+		}
+		var blames map[string]*Blame
+		if *fBlame {
+			fileName, err := git.PathInRepo(f)
+			if err != nil {
+				fatal(err)
+			}
+			if blames, err = blameCertdoc("", fileName); err != nil {
+				fatal(err)
+			}
 		}
 		failureCount := 0
+		var problems findingList
 		for _, v := range reqs {
 			r, err2 := ParseReq(v)
 			if err2 != nil {
-				log.Printf("Requirement failed to parse: %q\n%s", err2, v)
+				slog.Error("requirement failed to parse", "file", f, "err", err2, "text", v)
 				failureCount++
+				problems.addLine(err2)
 				continue
 			}
-			body := make([]string, 0)
-			lines := strings.Split(string(r.Body), "\n")
-			for _, line := range lines {
-				if line == "" {
-					continue
-				}
-				body = append(body, line)
+			r.Blame = blames[r.ID]
+			if listed(r) {
+				printReq(r)
 			}
-			fmt.Printf("Requirement %s %s\n%s…\n\n", r.ID, r.Title, body[0])
 		}
 		if failureCount > 0 {
-			log.Fatalf("Requirements failed to parse: %d", failureCount)
+			problems.add(findingHeader(fmt.Sprintf("Requirements failed to parse: %d", failureCount)))
+			findings(problems)
+		}
+	case "completion":
+		script, err := CompletionScript(f)
+		if err != nil {
+			fatal(err)
+		}
+		fmt.Print(script)
+	case "fmt":
+		fileNames := args[1:]
+		if len(fileNames) == 0 {
+			for _, p := range certdocPaths() {
+				if docFormat(p) == "md" {
+					fileNames = append(fileNames, p)
+				}
+			}
+		}
+		reportConf, err := ReadJsonConf(*fReportJsonConfPath)
+		if err != nil && !os.IsNotExist(err) {
+			fatal(err)
+		}
+		configs, err := loadConfigTree(repoPath, append(certdocRoots(*fCertdocPath), *fCodePath)...)
+		if err != nil {
+			findings(err)
+		}
+		changed, err := FormatCertdocs(fileNames, configs, reportConf.Attributes, *fCheck)
+		for _, f := range changed {
+			fmt.Println(f)
+		}
+		if err != nil {
+			fatal(err)
+		}
+		if *fCheck && len(changed) > 0 {
+			findings(fmt.Errorf("%d certification documents are not in their canonical form, run reqtraq fmt", len(changed)))
+		}
+	case "convert":
+		if f == "" {
+			usageError("Missing file name")
+		}
+		if *fFrom == "" {
+			usageError("Missing --from")
+		}
+		for _, format := range []string{*fFrom, *fTo} {
+			if !isCertdocFormat(format) {
+				usageError(fmt.Sprintf("Unknown format %q, expected %s", format, strings.Join(certdocFormats, " or ")))
+			}
+		}
+		if err := ConvertCertdocs(args[1:], *fFrom, *fTo); err != nil {
+			fatal(err)
+		}
+	case "config":
+		switch f {
+		case "validate":
+			if err := applyRepoConfig(*fConfig); err != nil {
+				findings(err)
+			}
+			if _, err := loadConfigTree(repoPath, append(certdocRoots(*fCertdocPath), *fCodePath)...); err != nil {
+				findings(err)
+			}
+		case "migrate":
+			old := argAt(args, 2)
+			if old == "" {
+				old = *fConfig
+				if _, err := os.Stat(old); os.IsNotExist(err) {
+					old = *fReportJsonConfPath
+				}
+			}
+			b, err := MigrateRepoConfig(old)
+			if err != nil {
+				fatal(err)
+			}
+			logFileCreate(*fConfig)
+			if err := ioutil.WriteFile(*fConfig, b, 0644); err != nil {
+				fatal(err)
+			}
+		default:
+			usageError(fmt.Sprintf("Unknown config command %q, expected validate or migrate", f))
+		}
+	case "export", "apply":
+		if f != "xlsx" {
+			usageError(fmt.Sprintf("Unknown %s format %q", command, f))
+		}
+		fileName := argAt(args, 2)
+		if fileName == "" {
+			usageError("Missing file name")
+		}
+		reportConf, err := ReadJsonConf(*fReportJsonConfPath)
+		if err != nil {
+			fatal(err)
+		}
+		rg, err := CreateReqGraph(*fCertdocPath, *fCodePath)
+		if err != nil {
+			findings(err)
+		}
+		if command == "apply" {
+			if err := rg.ApplyXlsx(fileName, reportConf.Attributes); err != nil {
+				fatal(err)
+			}
+			break
+		}
+		of, err := os.Create(fileName)
+		if err != nil {
+			fatal(err)
+		}
+		logFileCreate(of.Name())
+		if err := rg.ExportXlsx(of, reportConf.Attributes); err != nil {
+			fatal(err)
+		}
+		of.Close()
+	case "annotate":
+		rg, err := CreateReqGraph(*fCertdocPath, *fCodePath)
+		if rg == nil {
+			fatal(err)
+		}
+		if err != nil {
+			slog.Warn("the requirement graph has problems", "err", err)
+		}
+		fileName, err := filepath.Abs(f)
+		if err != nil {
+			fatal(err)
+		}
+		if err := rg.Annotate(fileName); err != nil {
+			fatal(err)
+		}
+	case "bom":
+		rg, err := buildGraph(*at)
+		if err != nil {
+			findings(err)
+		}
+		repoName, err := git.RepoName()
+		if err != nil {
+			fatal(err)
+		}
+		bom, err := rg.BOM(repoName, time.Now())
+		if err != nil {
+			fatal(err)
+		}
+		of, err := os.Create(f)
+		if err != nil {
+			fatal(err)
+		}
+		logFileCreate(of.Name())
+		if err := bom.Write(of); err != nil {
+			fatal(err)
+		}
+		of.Close()
+	case "hash":
+		rg, problems := buildGraph(*at)
+		if rg == nil {
+			fatal(problems)
+		}
+		hash, err := rg.Hash()
+		if err != nil {
+			fatal(err)
+		}
+		fmt.Println(hash)
+		if *fBase != "" {
+			base, err := baselineHash(*fBase)
+			if err != nil {
+				fatal(err)
+			}
+			if base != hash {
+				findings(fmt.Errorf("Graph hash %s differs from the hash %s of the baseline %s", hash, base, *fBase))
+			}
+		}
+		if problems != nil {
+			findings(problems)
+		}
+	case "import":
+		if f != "csv" {
+			usageError(fmt.Sprintf("Unknown import format %q", f))
+		}
+		in, out := argAt(args, 2), argAt(args, 3)
+		if in == "" || out == "" {
+			usageError("Missing input or output file name")
+		}
+		if *fMapping == "" {
+			usageError("Missing --mapping")
+		}
+		m, err := ReadCsvMapping(*fMapping)
+		if err != nil {
+			fatal(err)
+		}
+		logFileCreate(out)
+		if err := ImportCsv(in, out, m, *fForce); err != nil {
+			fatal(err)
 		}
 	case "linkify":
-		output := flag.Arg(1)
-		if output == "" {
-			log.Fatal("Missing output file name")
+		output := argAt(args, 2)
+		if output == "" && *fOutDir == "" {
+			usageError("Missing output file name")
+		}
+		var base *publishBaseline
+		if *fBase != "" {
+			var err error
+			if base, err = newPublishBaseline(*fBase); err != nil {
+				fatal(err)
+			}
+		}
+		if *fOutDir == "" {
+			if err := LinkifyDocument(f, output, base); err != nil {
+				fatal(err)
+			}
+			break
+		}
+		docs := publishedDocuments()
+		if f != "" {
+			docs = args[1:]
+		}
+		if err := os.MkdirAll(*fOutDir, 0755); err != nil {
+			fatal(err)
+		}
+		if err := LinkifyDocuments(docs, *fOutDir, *fJobs, base); err != nil {
+			fatal(err)
 		}
-		o, err := os.Create(output)
+	case "extract":
+		if *fDoc == "" || *fIdsFrom == "" {
+			usageError("Missing --doc or --ids-from")
+		}
+		if *fFormat != "" && *fFormat != "md" && *fFormat != "pdf" {
+			usageError(fmt.Sprintf("Unknown format %q", *fFormat))
+		}
+		ids, err := ReadReqIDs(*fIdsFrom)
 		if err != nil {
-			log.Fatal(err)
+			fatal(err)
 		}
-		_, err = ParseLyx(f, o)
-
+		rg, err := CreateReqGraph(*fCertdocPath, *fCodePath)
+		if err != nil {
+			findings(err)
+		}
+		of, err := os.Create(*fReportPrefix + "extract.md")
+		if err != nil {
+			fatal(err)
+		}
+		logFileCreate(of.Name())
+		if err := rg.Extract(of, *fDoc, ids); err != nil {
+			fatal(err)
+		}
+		of.Close()
+		if *fFormat == "pdf" {
+			pdf := *fReportPrefix + "extract.pdf"
+			logFileCreate(pdf)
+			if err := linepipes.Out(linepipes.Run("pandoc", "-o", pdf, of.Name())); err != nil {
+				fatal(err)
+			}
+		}
+	case "check":
+		if *fRange == "" {
+			usageError("Missing --range")
+		}
+		checks, err := CheckRange(*fRange, *fMerges, *fCertdocPath, *fCodePath)
+		if err != nil {
+			fatal(err)
+		}
+		WriteRangeCheck(os.Stdout, checks)
+		var problems findingList
+		if i := FirstInvalid(checks); i >= 0 {
+			problems.add(checks[i].Err)
+		}
+		problems = append(problems, Reused(checks)...)
+		if len(problems) > 0 {
+			findings(problems)
+		}
+	case "checklist":
+		if *fIdsFrom == "" {
+			usageError("Missing --ids-from")
+		}
+		if *fFormat != "" && *fFormat != "md" && *fFormat != "pdf" {
+			usageError(fmt.Sprintf("Unknown format %q", *fFormat))
+		}
+		ids, err := ReadReqIDs(*fIdsFrom)
+		if err != nil {
+			fatal(err)
+		}
+		tmplPath := repoConfig.Checklist.Template
+		if tmplPath != "" {
+			tmplPath = filepath.Join(repoPath, filepath.FromSlash(tmplPath))
+		}
+		tmpl, err := LoadChecklistTemplate(tmplPath)
+		if err != nil {
+			fatal(err)
+		}
+		rg, err := CreateReqGraph(*fCertdocPath, *fCodePath)
 		if err != nil {
-			log.Fatal(err)
+			findings(err)
+		}
+		for _, id := range ids {
+			of, err := os.Create(*fReportPrefix + "checklist-" + id + ".md")
+			if err != nil {
+				fatal(err)
+			}
+			logFileCreate(of.Name())
+			if err := rg.Checklist(of, tmpl, id, repoConfig.Checklist.Items); err != nil {
+				fatal(err)
+			}
+			of.Close()
+			if *fFormat == "pdf" {
+				pdf := strings.TrimSuffix(of.Name(), ".md") + ".pdf"
+				logFileCreate(pdf)
+				if err := linepipes.Out(linepipes.Run("pandoc", "-o", pdf, of.Name())); err != nil {
+					fatal(err)
+				}
+			}
 		}
 	case "reportdown":
 		of, err := os.Create(*fReportPrefix + "down.html")
 		if err != nil {
-			log.Fatal(err)
+			fatal(err)
 		}
 		logFileCreate(of.Name())
 		if err := rg.ReportDown(of); err != nil {
-			log.Fatal(err)
+			fatal(err)
 		}
 		of.Close()
 
 		if len(filter) > 0 || diffs != nil {
 			of, err := os.Create(*fReportPrefix + "down-filtered.html")
 			if err != nil {
-				log.Fatal(err)
+				fatal(err)
 			}
 			logFileCreate(of.Name())
 			if err := rg.ReportDownFiltered(of, filter, diffs); err != nil {
-				log.Fatal(err)
+				fatal(err)
 			}
 			of.Close()
 		}
 	case "reportup":
 		of, err := os.Create(*fReportPrefix + "up.html")
 		if err != nil {
-			log.Fatal(err)
+			fatal(err)
 		}
 		logFileCreate(of.Name())
 		if err = rg.ReportUp(of); err != nil {
-			log.Fatal(err)
+			fatal(err)
 		}
 		of.Close()
 
 		if len(filter) > 0 || diffs != nil {
 			of, err := os.Create(*fReportPrefix + "up-filtered.html")
 			if err != nil {
-				log.Fatal(err)
+				fatal(err)
 			}
 			logFileCreate(of.Name())
 			if err := rg.ReportUpFiltered(of, filter, diffs); err != nil {
-				log.Fatal(err)
+				fatal(err)
 			}
 			of.Close()
 		}
 	case "reportissues":
 		of, err := os.Create(*fReportPrefix + "issues.html")
 		if err != nil {
-			log.Fatal(err)
+			fatal(err)
 		}
 		logFileCreate(of.Name())
 		if err := rg.ReportIssues(of); err != nil {
-			log.Fatal(err)
+			fatal(err)
 		}
 		of.Close()
 		if len(filter) > 0 || diffs != nil {
 			of, err := os.Create(*fReportPrefix + "issues-filtered.html")
 			if err != nil {
-				log.Fatal(err)
+				fatal(err)
 			}
 			logFileCreate(of.Name())
 			if err := rg.ReportIssuesFiltered(of, filter, diffs); err != nil {
-				log.Fatal(err)
+				fatal(err)
 			}
 			of.Close()
 		}
-	case "web":
-		err := serve(*addr)
-		if err != nil {
-			log.Fatal(err)
+	case "dashboard":
+		format := *fFormat
+		if format == "" {
+			format = "html"
 		}
-	case "precommit":
-		err := precommit(*fCertdocPath, *fCodePath, *fReportJsonConfPath)
+		if format != "html" && format != "json" {
+			usageError(fmt.Sprintf("Unknown format %q", format))
+		}
+		var results TestResults
+		if *fTestResults != "" {
+			if results, err = ReadTestResults(*fTestResults); err != nil {
+				fatal(err)
+			}
+		}
+		d, err := BuildDashboard(*fBaselines, results)
 		if err != nil {
-			log.Fatal(err)
+			fatal(err)
 		}
-	case "prepush":
-		changedReqIds := map[string]bool{}
-		for k := range diffs {
-			changedReqIds[k] = true
-			fmt.Println("Changed requirement ", k)
+		if artifactStamp, err = NewStamp(d.current, ""); err != nil {
+			fatal(err)
 		}
-		if err := rg.UpdateTasks(changedReqIds); err != nil {
-			log.Fatal(err)
+		of, err := os.Create(*fReportPrefix + "dashboard." + format)
+		if err != nil {
+			fatal(err)
+		}
+		logFileCreate(of.Name())
+		if format == "json" {
+			err = d.WriteJSON(of)
+		} else {
+			err = d.WriteHTML(of)
 		}
-	case "updatetasks": // update all task title/descriptions/attributes based on the requirement documents
-		rg, err := CreateReqGraph(*fCertdocPath, *fCodePath)
 		if err != nil {
-			log.Fatal(err)
+			fatal(err)
 		}
-		reqIds := map[string]bool{}
-		for k := range rg {
-			reqIds[k] = true
+		of.Close()
+	case "diff":
+		if *fBase == "" {
+			usageError("Missing --base")
 		}
-		if err := rg.UpdateTasks(reqIds); err != nil {
-			log.Fatal(err)
+		if *fFormat != "" && *fFormat != "md" && *fFormat != "json" {
+			usageError(fmt.Sprintf("Unknown format %q", *fFormat))
 		}
-	}
-}
-
-func logFileCreate(fileName string) {
-	log.Print("Creating ", fileName, " (this may take a while)...")
-}
-
-func precommit(certdocPath, codePath, reportJsonConfPath string) error {
-	var reportConf JsonConf
-	b, err := ioutil.ReadFile(reportJsonConfPath)
-	if err != nil {
-		fmt.Printf("Can't find attributes.json in '%s'. Attributes won't be checked.\n",
-			reportJsonConfPath)
-		reportConf = JsonConf{}
-	} else {
-		if err := json.Unmarshal(b, &reportConf); err != nil {
-			return fmt.Errorf("Error while parsing attributes: ", err)
+		versions := []string{*fBase, *fTarget}
+		if *fWorktree && *fTarget != "" {
+			versions = append(versions, "")
 		}
+		var changelogs []*Changelog
+		var prev reqGraph
+		for i, v := range versions {
+			rg, err := buildGraph(v)
+			if rg == nil {
+				fatal(err)
+			}
+			if err != nil {
+				slog.Warn("problems found in the requirements, comparing the ones which could be parsed",
+					"version", versionName(v), "findings", Summarize(command, exitFindings, err).Findings)
+			}
+			if i > 0 {
+				changelogs = append(changelogs, NewChangelog(rg, prev, versionName(versions[i-1]), versionName(v)))
+			}
+			prev = rg
+		}
+		if *fFormat == "json" {
+			if err := WriteChangelogsJSON(os.Stdout, changelogs); err != nil {
+				fatal(err)
+			}
+			break
+		}
+		for _, c := range changelogs {
+			c.WriteMarkdown(os.Stdout)
+		}
+	case "query", "view":
+		query, format, title, description := f, *fFormat, f, ""
+		if command == "view" {
+			if f == "" {
+				var names []string
+				for name := range repoConfig.Views {
+					names = append(names, name)
+				}
+				sort.Strings(names)
+				for _, name := range names {
+					fmt.Printf("%s\t%s\n", name, repoConfig.Views[name].Description)
+				}
+				return
+			}
+			v, ok := repoConfig.Views[f]
+			if !ok {
+				usageError(fmt.Sprintf("Unknown view %q", f))
+			}
+			query, title, description = v.Query, f, v.Description
+			if format == "" {
+				format = v.Format
+			}
+		}
+		if query == "" {
+			usageError("Missing query")
+		}
+		if !isViewFormat(format) {
+			usageError(fmt.Sprintf("Unknown format %q", format))
+		}
+		rg, err := buildGraph(*at)
+		if rg == nil {
+			fatal(err)
+		}
+		if err != nil {
+			slog.Warn("problems found in the requirements, querying the requirements which could be parsed",
+				"findings", Summarize(command, exitFindings, err).Findings)
+		}
+		if err := inheritAttributes(rg); err != nil {
+			fatal(err)
+		}
+		reqs, err := rg.Query(query)
+		if err != nil {
+			usageError(err.Error())
+		}
+		if *fSort != "" {
+			sortByAttribute(reqs, *fSort)
+		}
+		if err := WriteView(os.Stdout, reqs, format, title, description); err != nil {
+			fatal(err)
+		}
+	case "testcases":
+		if *fFormat != "" && *fFormat != "md" && *fFormat != "json" {
+			usageError(fmt.Sprintf("Unknown format %q", *fFormat))
+		}
+		rg, err := buildGraph(*at)
+		if rg == nil {
+			fatal(err)
+		}
+		if err != nil {
+			slog.Warn("problems found in the requirements, listing the test cases which could be parsed",
+				"findings", Summarize(command, exitFindings, err).Findings)
+		}
+		matrix := rg.TestCaseMatrix()
+		if *fFormat == "json" {
+			if err := WriteTestCaseMatrixJSON(os.Stdout, matrix); err != nil {
+				fatal(err)
+			}
+		} else {
+			WriteTestCaseMatrixMarkdown(os.Stdout, matrix)
+		}
+	case "deporder":
+		if *fFormat != "" && *fFormat != "md" && *fFormat != "json" {
+			usageError(fmt.Sprintf("Unknown format %q", *fFormat))
+		}
+		rg, err := buildGraph("")
+		if rg == nil {
+			fatal(err)
+		}
+		if err != nil {
+			slog.Warn("problems found in the requirements, ordering the links which could be parsed",
+				"findings", Summarize(command, exitFindings, err).Findings)
+		}
+		order := rg.DependencyOrder()
+		if *fFormat == "json" {
+			if err := WriteDepOrderJSON(os.Stdout, order); err != nil {
+				fatal(err)
+			}
+		} else {
+			WriteDepOrderMarkdown(os.Stdout, order)
+		}
+		if err := order.Err(); err != nil {
+			findings(err)
+		}
+	case "churn":
+		if *fFormat != "" && *fFormat != "md" && *fFormat != "json" {
+			usageError(fmt.Sprintf("Unknown format %q", *fFormat))
+		}
+		start := time.Now().AddDate(0, 0, -90)
+		if *since != "" {
+			start, err = time.ParseInLocation("2006-01-02", *since, time.Local)
+			if err != nil {
+				usageError(fmt.Sprintf("Invalid --since %q, expected a date such as 2024-01-31", *since))
+			}
+		}
+		unchangedSince := time.Now().AddDate(0, 0, -*fStaleDays)
+		rg, err := CreateReqGraph(*fCertdocPath, *fCodePath)
+		if rg == nil {
+			fatal(err)
+		}
+		if err != nil {
+			slog.Warn("problems found in the requirements, checking the references which could be parsed",
+				"findings", Summarize(command, exitFindings, err).Findings)
+		}
+		churn, err := rg.Churn(start, unchangedSince)
+		if err != nil {
+			fatal(err)
+		}
+		if *fFormat == "json" {
+			if err := WriteChurnJSON(os.Stdout, churn); err != nil {
+				fatal(err)
+			}
+		} else {
+			WriteChurnMarkdown(os.Stdout, churn, start, unchangedSince)
+		}
+	case "gen-tests":
+		if f == "" {
+			usageError("Missing requirement ID")
+		}
+		if *fFormat != "" && *fFormat != "gotest" && *fFormat != "robot" {
+			usageError(fmt.Sprintf("Unknown format %q", *fFormat))
+		}
+		rg, err := buildGraph(*at)
+		if rg == nil {
+			fatal(err)
+		}
+		if err != nil {
+			slog.Warn("problems found in the requirements", "findings", Summarize(command, exitFindings, err).Findings)
+		}
+		reqs, err := rg.testSkeletonRequirements(args[1:])
+		if err != nil {
+			fatal(err)
+		}
+		if *fFormat == "robot" {
+			err = WriteRobotSkeleton(os.Stdout, reqs)
+		} else {
+			err = WriteGoTestSkeleton(os.Stdout, goPackage("."), reqs)
+		}
+		if err != nil {
+			fatal(err)
+		}
+	case "suggest":
+		if f == "" {
+			usageError("Missing requirement ID")
+		}
+		if *fOffline {
+			usageError("The suggestions need the network, they are not available offline")
+		}
+		rg, err := CreateReqGraph(*fCertdocPath, *fCodePath)
+		if rg == nil {
+			fatal(err)
+		}
+		if err != nil {
+			slog.Warn("problems found in the requirements", "findings", Summarize(command, exitFindings, err).Findings)
+		}
+		drafts, err := rg.Suggest(repoConfig.Suggest, f)
+		if err != nil {
+			fatal(err)
+		}
+		WriteDrafts(os.Stdout, rg[f], drafts)
+	case "rollup":
+		if f == "" {
+			usageError("Missing attribute")
+		}
+		if *fFormat != "" && *fFormat != "md" && *fFormat != "json" {
+			usageError(fmt.Sprintf("Unknown format %q", *fFormat))
+		}
+		attribute := strings.ToUpper(f)
+		numeric := repoConfig.numericAttributes()
+		if i := sort.SearchStrings(numeric, attribute); i == len(numeric) || numeric[i] != attribute {
+			usageError(fmt.Sprintf("Attribute %q is not declared as a number in the configuration", f))
+		}
+		rg, err := buildGraph(*at)
+		if rg == nil {
+			fatal(err)
+		}
+		if err != nil {
+			slog.Warn("problems found in the requirements, adding the requirements which could be parsed",
+				"findings", Summarize(command, exitFindings, err).Findings)
+		}
+		rollups := rg.Rollups(attribute)
+		if *fFormat == "json" {
+			if err := WriteRollupsJSON(os.Stdout, rollups); err != nil {
+				fatal(err)
+			}
+		} else {
+			WriteRollupsMarkdown(os.Stdout, attribute, rollups)
+		}
+	case "similar":
+		if *fFormat != "" && *fFormat != "md" && *fFormat != "json" {
+			usageError(fmt.Sprintf("Unknown format %q", *fFormat))
+		}
+		if *fSimilarity < 0 || *fSimilarity > 1 {
+			usageError(fmt.Sprintf("Invalid --similarity %v, expected a number from 0 to 1", *fSimilarity))
+		}
+		rg, err := buildGraph(*at)
+		if rg == nil {
+			fatal(err)
+		}
+		if err != nil {
+			slog.Warn("problems found in the requirements, comparing the requirements which could be parsed",
+				"findings", Summarize(command, exitFindings, err).Findings)
+		}
+		similar := rg.Similarities(*fSimilarity)
+		if *fFormat == "json" {
+			if err := WriteSimilaritiesJSON(os.Stdout, similar); err != nil {
+				fatal(err)
+			}
+		} else {
+			WriteSimilaritiesMarkdown(os.Stdout, similar, *fSimilarity)
+		}
+	case "staleness":
+		if *fFormat != "" && *fFormat != "md" && *fFormat != "json" {
+			usageError(fmt.Sprintf("Unknown format %q", *fFormat))
+		}
+		if *fSnapshot != "" {
+			usageError("The snapshots have no history")
+		}
+		rg, err := buildGraph(*at)
+		if rg == nil {
+			fatal(err)
+		}
+		if err != nil {
+			slog.Warn("problems found in the requirements, checking the links which could be parsed",
+				"findings", Summarize(command, exitFindings, err).Findings)
+		}
+		threshold := time.Duration(*fStaleDays) * 24 * time.Hour
+		stale, err := rg.Staleness(*at, threshold)
+		if err != nil {
+			fatal(err)
+		}
+		if *fFormat == "json" {
+			if err := WriteStalenessJSON(os.Stdout, stale); err != nil {
+				fatal(err)
+			}
+		} else {
+			WriteStalenessMarkdown(os.Stdout, stale, threshold)
+		}
+	case "doctrace":
+		if *fFormat != "" && *fFormat != "md" && *fFormat != "json" {
+			usageError(fmt.Sprintf("Unknown format %q", *fFormat))
+		}
+		rg, err := buildGraph("")
+		if rg == nil {
+			fatal(err)
+		}
+		if err != nil {
+			slog.Warn("problems found in the requirements, counting the traces which could be parsed",
+				"findings", Summarize(command, exitFindings, err).Findings)
+		}
+		var docs []*Document
+		for _, root := range certdocRoots(*fCertdocPath) {
+			rootDocs, err := readDocuments(filepath.Join(repoPath, root))
+			if err != nil {
+				fatal(err)
+			}
+			docs = append(docs, rootDocs...)
+		}
+		nodes := BuildDocTrace(docs, rg)
+		if *fFormat == "json" {
+			if err := WriteDocTraceJSON(os.Stdout, nodes); err != nil {
+				fatal(err)
+			}
+			break
+		}
+		WriteDocTraceMarkdown(os.Stdout, nodes)
+	case "reportowners":
+		of, err := os.Create(*fReportPrefix + "owners.html")
+		if err != nil {
+			fatal(err)
+		}
+		logFileCreate(of.Name())
+		if err := rg.ReportOwners(of); err != nil {
+			fatal(err)
+		}
+		of.Close()
+	case "reporttargets":
+		targets, err := ReadTargets(repoPath)
+		if err != nil {
+			fatal(err)
+		}
+		of, err := os.Create(*fReportPrefix + "targets.html")
+		if err != nil {
+			fatal(err)
+		}
+		logFileCreate(of.Name())
+		if err := rg.ReportTargets(of, targets); err != nil {
+			fatal(err)
+		}
+		of.Close()
+	case "version":
+		WriteVersion(os.Stdout, *fVerboseVersion)
+	case "validate":
+		if *fFormat != "" && *fFormat != "md" && *fFormat != "lyx" {
+			usageError(fmt.Sprintf("Unknown format %q", *fFormat))
+		}
+		var contents []byte
+		if *fStdin {
+			contents, err = ioutil.ReadAll(os.Stdin)
+		} else {
+			contents, err = ioutil.ReadFile(f)
+		}
+		if err != nil {
+			fatal(err)
+		}
+		reportConf, err := ReadJsonConf(*fReportJsonConfPath)
+		if err != nil && !os.IsNotExist(err) {
+			fatal(err)
+		}
+		res, err := ValidateDocument(f, contents, *fFormat, *fCertdocPath, *fCodePath, reportConf.Attributes)
+		if err != nil {
+			fatal(err)
+		}
+		if err := WriteValidationJSON(os.Stdout, res); err != nil {
+			fatal(err)
+		}
+		if err := validationErrors(res); err != nil {
+			finish(exitFindings, err)
+		}
+	case "grpc":
+		if err := serveGRPC(*addr); err != nil {
+			fatal(err)
+		}
+	case "web":
+		err := serve(*addr)
+		if err != nil {
+			fatal(err)
+		}
+	case "commitmsg":
+		if err := checkCommitMessage(f, *fCertdocPath, *fCodePath, *fCommitRules); err != nil {
+			findings(err)
+		}
+	case "precommit":
+		err := precommit(*fCertdocPath, *fCodePath, *fReportJsonConfPath)
+		waivers, werr := ReadWaivers(*fWaivers)
+		if werr != nil && !os.IsNotExist(werr) {
+			fatal(werr)
+		}
+		if err != nil || len(waivers) > 0 {
+			remaining, n := ApplyWaivers(err, waivers, time.Now())
+			if n > 0 {
+				slog.Info("findings waived", "count", n, "file", *fWaivers)
+			}
+			if remaining != nil {
+				findings(remaining)
+			}
+		}
+	case "prepush":
+		if *since != "" && *fSnapshot == "" {
+			if err := rg.CheckRevisionBumps(prg, *since, *at); err != nil {
+				findings(err)
+			}
+		}
+		changedReqIds := map[string]bool{}
+		for k := range diffs {
+			changedReqIds[k] = true
+			fmt.Println("Changed requirement ", k)
+		}
+		if *fOffline {
+			slog.Warn("offline, the tasks of the changed requirements are not updated")
+			break
+		}
+		if err := rg.UpdateTasks(changedReqIds); err != nil {
+			fatal(err)
+		}
+	case "extract-graph":
+		if *fComponent == "" {
+			usageError("Missing --component")
+		}
+		rg, problems := CreateReqGraph(*fCertdocPath, *fCodePath)
+		if rg == nil {
+			fatal(problems)
+		}
+		sub, err := rg.ExtractComponent(*fComponent)
+		if err != nil {
+			fatal(err)
+		}
+		of, err := os.Create(f)
+		if err != nil {
+			fatal(err)
+		}
+		logFileCreate(of.Name())
+		if err := sub.WriteComponentSnapshot(of, *fComponent, problems); err != nil {
+			fatal(err)
+		}
+		of.Close()
+		if problems != nil {
+			findings(problems)
+		}
+	case "publish":
+		outDir := *fOutDir
+		if outDir == "" {
+			outDir = repoConfig.Publish.Output
+		}
+		if outDir == "" {
+			usageError("Missing --out-dir")
+		}
+		docs := publishedDocuments()
+		if f != "" {
+			docs = args[1:]
+		}
+		var base *publishBaseline
+		if *fBase != "" && !*fCheck {
+			var err error
+			if base, err = newPublishBaseline(*fBase); err != nil {
+				fatal(err)
+			}
+		}
+		if !*fCheck {
+			var rg reqGraph
+			var err error
+			if base != nil {
+				rg = base.rg
+			} else if rg, err = buildGraph(""); rg == nil {
+				fatal(err)
+			}
+			if artifactStamp, err = NewStamp(rg, ""); err != nil {
+				fatal(err)
+			}
+		}
+		var missing []error
+		var err error
+		if *fCheck {
+			missing, err = CheckAnchors(docs, outDir)
+		} else {
+			missing, err = Publish(docs, outDir, repoConfig.Publish.Commands, *fJobs, base)
+		}
+		if err != nil {
+			fatal(err)
+		}
+		if len(missing) > 0 {
+			findings(findingList(missing))
+		}
+	case "checklinks":
+		reports := args[1:]
+		if len(reports) == 0 {
+			var err error
+			if reports, err = filepath.Glob(*fReportPrefix + "*.html"); err != nil {
+				usageError(err)
+			}
+			if len(reports) == 0 {
+				usageError(fmt.Sprintf("No reports matching %s*.html", *fReportPrefix))
+			}
+		}
+		broken, err := CheckReportLinks(reports, *fHTTP && !*fOffline)
+		if err != nil {
+			fatal(err)
+		}
+		if len(broken) > 0 {
+			findings(findingList(broken))
+		}
+	case "package":
+		if *fBase == "" {
+			usageError("Missing --base")
+		}
+		if *fTarget == "" && *fSnapshot != "" {
+			usageError("The --target must be a commit, the documents are not in the snapshots")
+		}
+		prg, err := buildGraph(*fBase)
+		if prg == nil {
+			fatal(err)
+		}
+		rg, err := buildGraph(*fTarget)
+		if rg == nil {
+			fatal(err)
+		}
+		if err != nil {
+			slog.Warn("problems found in the requirements of the target, packaging the ones which could be parsed",
+				"findings", Summarize(command, exitFindings, err).Findings)
+		}
+		if err := setCoverage(rg); err != nil {
+			fatal(err)
+		}
+		changed := rg.ChangedDocuments(prg)
+		if *fExternal {
+			controlled := rg.ExportControlledDocuments()
+			var kept []string
+			for _, d := range changed {
+				if controlled[d] {
+					slog.Warn("the document defines export-controlled requirements, it is not packaged", "document", d)
+					continue
+				}
+				kept = append(kept, d)
+			}
+			changed = kept
+		}
+		docs, err := readFilesAt(*fTarget, changed)
+		if err != nil {
+			fatal(err)
+		}
+		rev := *fTarget
+		if rev == "" {
+			rev = "HEAD"
+		}
+		commit, err := git.ResolveCommit(rev)
+		if err != nil {
+			fatal(err)
+		}
+		ref, err := git.Describe(*fTarget)
+		if err != nil {
+			fatal(err)
+		}
+		m, err := rg.NewManifest(commit, ref)
+		if err != nil {
+			fatal(err)
+		}
+		if *fKey != "" {
+			key, err := ReadSigningKey(*fKey)
+			if err != nil {
+				fatal(err)
+			}
+			if err := m.Sign(key); err != nil {
+				fatal(err)
+			}
+		}
+		if *fExternal {
+			// After the manifest, which records the graph hash.
+			rg.OmitExportControlled()
+			prg.OmitExportControlled()
+		}
+		p := &Package{Changelog: NewChangelog(rg, prg, versionName(*fBase), versionName(*fTarget)), Graph: rg, Documents: docs, Manifest: m}
+		of, err := os.Create(*fReportPrefix + "package.zip")
+		if err != nil {
+			fatal(err)
+		}
+		logFileCreate(of.Name())
+		if err := p.Write(of); err != nil {
+			fatal(err)
+		}
+		of.Close()
+	case "merge-graph":
+		out := argAt(args, 2)
+		if out == "" {
+			usageError("Missing file name")
+		}
+		supplier, component, err := ReadComponentSnapshot(f)
+		if supplier == nil {
+			fatal(err)
+		}
+		if component == "" {
+			fatal(fmt.Errorf("Snapshot %s was not created by extract-graph", f))
+		}
+		rg, err := buildGraph(*at)
+		if rg == nil {
+			fatal(err)
+		}
+		merged, conflicts, problems := rg.MergeComponent(supplier, component)
+		if len(conflicts) > 0 {
+			findings(findingList(conflicts))
+		}
+		of, err := os.Create(out)
+		if err != nil {
+			fatal(err)
+		}
+		logFileCreate(of.Name())
+		if err := merged.WriteSnapshot(of, problems); err != nil {
+			fatal(err)
+		}
+		of.Close()
+		if problems != nil {
+			findings(problems)
+		}
+	case "snapshot":
+		rg, problems := CreateReqGraph(*fCertdocPath, *fCodePath)
+		of, err := os.Create(f)
+		if err != nil {
+			fatal(err)
+		}
+		logFileCreate(of.Name())
+		if err := rg.WriteSnapshot(of, problems); err != nil {
+			fatal(err)
+		}
+		of.Close()
+		if problems != nil {
+			findings(problems)
+		}
+	case "trend":
+		start, err := time.ParseInLocation("2006-01-02", *since, time.Local)
+		if err != nil {
+			usageError(fmt.Sprintf("Invalid --since %q, expected a date such as 2024-01-31", *since))
+		}
+		dates, err := trendDates(start, time.Now(), *fStep)
+		if err != nil {
+			usageError(err)
+		}
+		points, last, err := Trend(dates)
+		if err != nil {
+			fatal(err)
+		}
+		if last != nil {
+			if artifactStamp, err = NewStamp(last, points[len(points)-1].Commit); err != nil {
+				fatal(err)
+			}
+		}
+		of, err := os.Create(*fReportPrefix + "trend.csv")
+		if err != nil {
+			fatal(err)
+		}
+		logFileCreate(of.Name())
+		if err := WriteTrendCSV(of, points); err != nil {
+			fatal(err)
+		}
+		of.Close()
+	case "tui":
+		rg, err := CreateReqGraph(*fCertdocPath, *fCodePath)
+		if err != nil {
+			findings(err)
+		}
+		if err := rg.Browse(); err != nil {
+			fatal(err)
+		}
+	case "updatetasks": // update all task title/descriptions/attributes based on the requirement documents
+		if *fOffline {
+			usageError("updatetasks needs the task manager, which cannot be reached with --offline")
+		}
+		rg, err := CreateReqGraph(*fCertdocPath, *fCodePath)
+		if err != nil {
+			findings(err)
+		}
+		reqIds := map[string]bool{}
+		for k := range rg {
+			reqIds[k] = true
+		}
+		if err := rg.UpdateTasks(reqIds); err != nil {
+			fatal(err)
+		}
+	default:
+		showHelp(command)
+		finish(exitUsage, nil)
 	}
-
-	rg, err := CreateReqGraph(certdocPath, codePath)
-	if err != nil {
-		return err
-	}
-	errorResult := ""
-	err = rg.checkReqReferences(certdocPath)
-	if err != nil {
-		errorResult += err.Error()
+	finish(exitOK, nil)
+}
+
+// usageError reports an invalid command line and exits.
+func usageError(v ...interface{}) {
+	log.Print(v...)
+	finish(exitUsage, nil)
+}
+
+// repoFile returns the path of the given file in the repository, for the
+// defaults of the flags. Not being in a repository is reported by main.
+func repoFile(elem ...string) string {
+	repoPath, _ := git.RepoPath()
+	return filepath.Join(append([]string{repoPath}, elem...)...)
+}
+
+// fatal reports an error preventing the command from running and exits.
+func fatal(v ...interface{}) {
+	log.Print(v...)
+	finish(exitInternal, nil)
+}
+
+// findings reports the problems found in the requirements and exits.
+func findings(err error) {
+	log.Print(err)
+	finish(exitFindings, err)
+}
+
+// finish writes the --summary-file, if requested, and exits with the given code.
+func finish(exitCode int, findings error) {
+	if *fSummaryFile != "" {
+		if err := WriteSummary(*fSummaryFile, Summarize(command, exitCode, findings)); err != nil {
+			log.Print(err)
+			exitCode = exitInternal
+		}
 	}
+	os.Exit(exitCode)
+}
 
-	if errs := rg.CheckAttributes(reportConf.Attributes); len(errs) > 0 {
-		for _, e := range errs {
-			errorResult += e.Error()
+// printReq prints the ID, the title and the first line of the body of the requirement.
+func printReq(r *Req) {
+	body := make([]string, 0)
+	lines := strings.Split(string(r.Body), "\n")
+	for _, line := range lines {
+		if line == "" {
+			continue
 		}
+		body = append(body, line)
+	}
+	if len(body) == 0 {
+		body = append(body, "")
 	}
-	if errorResult == "" {
-		return nil
-	} else {
-		return fmt.Errorf(errorResult)
+	fmt.Printf("Requirement %s %s\n%s…\n", r.ID, r.Title, body[0])
+	if r.Blame != nil {
+		fmt.Printf("Last changed by %s\n", r.Blame)
 	}
+	fmt.Println()
 }
 
-func buildGraph(commit string) (reqGraph, string, error) {
-	if commit == "" {
-		rg, err := CreateReqGraph(*fCertdocPath, *fCodePath)
-		return rg, "", err
+// setupLogging configures the default logger to write the messages of at
+// least the given level to stderr, as text or as json objects, one per line.
+// The messages of the log package, e.g. from log.Fatal, are always shown.
+func setupLogging(level, format string, verbose bool) error {
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(level)); err != nil {
+		return fmt.Errorf("Invalid --log-level %q, expected debug, info, warn or error", level)
+	}
+	if verbose {
+		l = slog.LevelDebug
+	}
+	opts := &slog.HandlerOptions{Level: l}
+	var h slog.Handler
+	switch format {
+	case "text":
+		h = slog.NewTextHandler(os.Stderr, opts)
+	case "json":
+		h = slog.NewJSONHandler(os.Stderr, opts)
+	default:
+		return fmt.Errorf("Invalid --log-format %q, expected text or json", format)
+	}
+	slog.SetDefault(slog.New(h))
+	slog.SetLogLoggerLevel(slog.LevelError)
+	return nil
+}
+
+func logFileCreate(fileName string) {
+	slog.Info("creating file, this may take a while", "file", fileName)
+}
+
+// ReadJsonConf reads the requirement attribute specification, from a json
+// file or from the attributes of a reqtraq.yaml configuration file.
+func ReadJsonConf(reportJsonConfPath string) (JsonConf, error) {
+	var reportConf JsonConf
+	if strings.HasSuffix(reportJsonConfPath, ".yaml") {
+		c, err := LoadRepoConfig(reportJsonConfPath)
+		if err != nil {
+			return reportConf, err
+		}
+		reportConf.Attributes = c.AttributeMaps()
+		return reportConf, nil
+	}
+	b, err := ioutil.ReadFile(reportJsonConfPath)
+	if err != nil {
+		return reportConf, err
+	}
+	if err := json.Unmarshal(b, &reportConf); err != nil {
+		return reportConf, fmt.Errorf("Error while parsing attributes: %v", err)
+	}
+	return reportConf, nil
+}
+
+func precommit(certdocPath, codePath, reportJsonConfPath string) error {
+	reportConf, err := ReadJsonConf(reportJsonConfPath)
+	if os.IsNotExist(err) {
+		slog.Warn("attribute specification not found, attributes won't be checked", "file", reportJsonConfPath)
+	} else if err != nil {
+		return err
 	}
 
-	cwd, err := os.Getwd()
+	rg, err := CreateReqGraph(certdocPath, codePath)
+	if rg == nil {
+		return err
+	}
+	suppressed := 0
 	if err != nil {
-		return nil, "", err
+		remaining, n := rg.Suppress(err)
+		suppressed += n
+		if remaining != nil {
+			return remaining
+		}
 	}
-	dir, err := git.Clone()
+	var problems findingList
+	problems.add(rg.checkReqReferences(certdocPath))
+
+	repoPath, err := git.RepoPath()
 	if err != nil {
-		return nil, dir, err
+		return err
 	}
-	if err = git.Checkout(commit); err != nil {
-		return nil, dir, err
+	// The fragments have been validated by CreateReqGraph.
+	configs, _ := loadConfigTree(repoPath, append(certdocRoots(certdocPath), codePath)...)
+	rg.InheritAttributes(configs, reportConf.Attributes)
+	problems = append(problems, rg.CheckAttributesIn(configs, reportConf.Attributes)...)
+	if len(repoConfig.Tags) > 0 {
+		problems = append(problems, rg.CheckTags(repoConfig.Tags)...)
 	}
-	rg, err := CreateReqGraph(*fCertdocPath, *fCodePath)
+	problems = append(problems, rg.CheckPlaceholders()...)
+	problems = append(problems, rg.CheckCOTS()...)
+	problems = append(problems, rg.CheckPriorities(repoConfig.Priorities)...)
+	problems = append(problems, rg.CheckTagPlacement(repoConfig.TagPlacement)...)
+	problems = append(problems, rg.CheckSummaries()...)
+	reused, err := rg.CheckReusedIDs(certdocPath, codePath)
 	if err != nil {
-		return nil, dir, err
+		return err
+	}
+	problems = append(problems, reused...)
+	warnings, err := rg.CheckStyle(repoConfig.Style)
+	if err != nil {
+		return err
+	}
+	warnings = append(warnings, rg.CheckTerminology(repoConfig.Terminology)...)
+	for _, w := range append(warnings, rg.CheckAliases()...) {
+		slog.Warn(strings.TrimSuffix(w.Error(), ".\n"))
+	}
+	if err := setCoverage(rg); err != nil {
+		return err
+	}
+	problems = append(problems, rg.CheckCoverage(*fMinCoverage, *fMinBranchCoverage)...)
+
+	roster, err := ReadRoster(*fRoster)
+	if err == nil {
+		problems = append(problems, rg.CheckOwners(roster)...)
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+	if ok, err := setTeams(rg); err != nil {
+		return err
+	} else if ok {
+		problems = append(problems, rg.CheckTeams()...)
 	}
-	if err := os.Chdir(cwd); err != nil {
-		return nil, dir, err
+	remaining, n := rg.Suppress(problems)
+	if suppressed += n; suppressed > 0 {
+		slog.Info("findings suppressed by pragmas", "count", suppressed)
+	}
+	return remaining
+}
+
+// versionName returns the name of the given commit, as passed to buildGraph.
+func versionName(commit string) string {
+	if commit == "" {
+		return "working tree"
+	}
+	return commit
+}
+
+// reHash matches the hashes printed by the hash command.
+var reHash = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+// baselineHash returns the graph hash of the given baseline: a hash, a
+// manifest or a snapshot file, or a commit whose graph is built.
+func baselineHash(base string) (string, error) {
+	if reHash.MatchString(base) {
+		return base, nil
+	}
+	if _, err := os.Stat(base); err == nil && strings.HasSuffix(base, ".json") {
+		b, err := ioutil.ReadFile(base)
+		if err != nil {
+			return "", err
+		}
+		var m struct{ GraphHash string }
+		if err := json.Unmarshal(b, &m); err != nil {
+			return "", fmt.Errorf("Error while parsing %s: %v", base, err)
+		}
+		if m.GraphHash != "" {
+			return m.GraphHash, nil
+		}
+		rg, err := ReadSnapshot(base)
+		if rg == nil {
+			return "", err
+		}
+		return rg.Hash()
+	}
+	rg, err := buildGraph(base)
+	if rg == nil {
+		return "", err
+	}
+	return rg.Hash()
+}
+
+// buildGraph returns the requirement graph at the given commit. The graph of
+// the working tree is built when commit is empty, or read from the --snapshot
+// if any, which is the only way to use a snapshot.
+// The graph is returned with the problems found, if any, e.g. for the dashboard.
+func buildGraph(commit string) (reqGraph, error) {
+	if commit == "" {
+		if *fSnapshot != "" {
+			return ReadSnapshot(*fSnapshot)
+		}
+		return CreateReqGraph(*fCertdocPath, *fCodePath)
 	}
-	return rg, dir, nil
+	return CreateReqGraphAt(commit, *fCertdocPath, *fCodePath)
 }
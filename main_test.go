@@ -1,15 +1,34 @@
 package main
 
 import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/daedaleanai/reqtraq/config"
 	"github.com/daedaleanai/reqtraq/git"
+	"github.com/daedaleanai/reqtraq/reqtraqpb"
 	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
 )
 
 func TestPreCommitCreateReqGraph(t *testing.T) {
-	err := precommit("/testdata/TestPreCommitCreateReqGraph", "/testdata/TestPreCommitCreateReqGraph", git.RepoPath()+"/certdocs/attributes.json")
+	err := precommit("/testdata/TestPreCommitCreateReqGraph", "/testdata/TestPreCommitCreateReqGraph", repoFile("certdocs", "attributes.json"))
 	assert.NotNil(t, err, "Expected some errors but got 0.")
 
 	nLines := strings.Count(err.Error(), "\n")
@@ -42,7 +61,7 @@ func TestPreCommitCreateReqGraph(t *testing.T) {
 }
 
 func TestPreCommitCreateReqGraphMarkdown(t *testing.T) {
-	err := precommit("/testdata/TestPreCommitCreateReqGraphMarkdown", "/testdata/TestPreCommitCreateReqGraphMarkdown", git.RepoPath()+"/certdocs/attributes.json")
+	err := precommit("/testdata/TestPreCommitCreateReqGraphMarkdown", "/testdata/TestPreCommitCreateReqGraphMarkdown", repoFile("certdocs", "attributes.json"))
 	assert.NotNil(t, err, "Expected some errors but got 0.")
 
 	nLines := strings.Count(err.Error(), "\n")
@@ -71,7 +90,7 @@ func TestPreCommitCreateReqGraphMarkdown(t *testing.T) {
 }
 
 func TestPreCommitCheckReqReferences(t *testing.T) {
-	err := precommit("/testdata/TestPreCommitCheckReqReferences", "/testdata/TestPreCommitCheckReqReferences", git.RepoPath()+"/certdocs/attributes.json")
+	err := precommit("/testdata/TestPreCommitCheckReqReferences", "/testdata/TestPreCommitCheckReqReferences", repoFile("certdocs", "attributes.json"))
 	assert.NotNil(t, err, "Errors expected")
 
 	nLines := strings.Count(err.Error(), "\n")
@@ -85,7 +104,7 @@ func TestPreCommitCheckReqReferences(t *testing.T) {
 }
 
 func TestPreCommitCheckReqReferencesMarkdown(t *testing.T) {
-	err := precommit("/testdata/TestPreCommitCheckReqReferencesMarkdown", "/testdata/TestPreCommitCheckReqReferencesMarkdown", git.RepoPath()+"/certdocs/attributes.json")
+	err := precommit("/testdata/TestPreCommitCheckReqReferencesMarkdown", "/testdata/TestPreCommitCheckReqReferencesMarkdown", repoFile("certdocs", "attributes.json"))
 	assert.NotNil(t, err, "Errors expected")
 
 	nLines := strings.Count(err.Error(), "\n")
@@ -97,3 +116,709 @@ func TestPreCommitCheckReqReferencesMarkdown(t *testing.T) {
 	assert.Contains(t, err.Error(), "Requirement 'REQ-0-TEST-SWH-008' has invalid value 'gibberish.' in attribute 'VERIFICATION'.")
 	assert.Contains(t, err.Error(), "Requirement 'REQ-0-TEST-SWH-007' is missing attribute 'Safety Impact'.")
 }
+
+func TestPreCommitNotInRepo(t *testing.T) {
+	cwd, err := os.Getwd()
+	assert.Nil(t, err)
+	dir := t.TempDir()
+	assert.Nil(t, os.Chdir(dir))
+	defer os.Chdir(cwd)
+	// Make sure git does not find the repository of the temporary dir.
+	t.Setenv("GIT_CEILING_DIRECTORIES", filepath.Dir(dir))
+
+	_, err = git.RepoPath()
+	assert.NotNil(t, err)
+	_, err = git.RepoName()
+	assert.NotNil(t, err)
+	assert.NotNil(t, precommit("certdocs", "", filepath.Join(dir, "attributes.json")))
+}
+
+func TestComplete(t *testing.T) {
+	assert.Equal(t, []string{"precommit", "prepush"}, Complete([]string{"pre"}))
+	assert.Equal(t, []string{"xlsx"}, Complete([]string{"export", ""}))
+	assert.Equal(t, []string{"--format=md", "--format=pdf"}, Complete([]string{"extract", "--format="}))
+
+	ids := Complete([]string{"reportdown", "--certdoc_path=testdata/TestPreCommitCreateReqGraph", "--id_filter=REQ-0-TEST-SYS-00"})
+	assert.Contains(t, ids, "--id_filter=REQ-0-TEST-SYS-001")
+	assert.NotContains(t, ids, "--id_filter=REQ-0-TEST-SWH-001")
+
+	docs := Complete([]string{"--certdoc_path=testdata/TestPreCommitCreateReqGraph", "extract", "--doc=0-TEST-211"})
+	assert.Equal(t, []string{"--doc=0-TEST-211-SRD.lyx"}, docs)
+	*fCertdocPath = "certdocs"
+}
+
+func TestSummarize(t *testing.T) {
+	err := precommit("/testdata/TestPreCommitCreateReqGraph", "/testdata/TestPreCommitCreateReqGraph", repoFile("certdocs", "attributes.json"))
+	s := Summarize("precommit", exitFindings, err)
+	assert.Equal(t, 21-3, s.Findings)
+	assert.Equal(t, 3, s.Counts["malformed_requirement"])
+	assert.Equal(t, 3, s.Counts["requirement_id"])
+	assert.Equal(t, 2, s.Counts["sequence_number"])
+	assert.Equal(t, 0, s.Counts["other"])
+}
+
+func TestApplyWaivers(t *testing.T) {
+	dir := t.TempDir()
+	f := filepath.Join(dir, "waivers.yaml")
+	assert.NoError(t, ioutil.WriteFile(f, []byte(`- id: REQ-0-TEST-SWH-001
+  finding: missing_parent
+  justification: Derived requirement.
+  owner: alice
+  expires: 2025-12-31
+- id: REQ-0-TEST-SWH-002
+  finding: missing_parent
+  justification: Derived requirement.
+  owner: bob
+  expires: 2025-06-30
+`), 0644))
+	waivers, err := ReadWaivers(f)
+	if !assert.NoError(t, err) {
+		return
+	}
+	findings := findingList{
+		newFinding("missing_parent", "Requirement REQ-0-TEST-SWH-001 in file /a.md has no parents.\n"),
+		newFinding("missing_parent", "Requirement REQ-0-TEST-SWH-002 in file /a.md has no parents.\n"),
+		newFinding("attribute", "Requirement 'REQ-0-TEST-SWH-001' is missing attribute 'RATIONALE'.\n"),
+	}
+	now := time.Date(2025, 12, 31, 12, 0, 0, 0, time.Local)
+	remaining, n := ApplyWaivers(findings, waivers, now)
+	assert.Equal(t, 1, n)
+	assert.EqualError(t, remaining, "Requirement REQ-0-TEST-SWH-002 in file /a.md has no parents.\n"+
+		"Requirement 'REQ-0-TEST-SWH-001' is missing attribute 'RATIONALE'.\n"+
+		"Waiver of the missing_parent findings of 'REQ-0-TEST-SWH-002' by bob expired on 2025-06-30.\n")
+	assert.Equal(t, 1, Summarize("precommit", exitFindings, remaining).Counts["waiver"])
+
+	remaining, n = ApplyWaivers(findings[:1], waivers[:1], now)
+	assert.Nil(t, remaining)
+	assert.Equal(t, 1, n)
+
+	assert.NoError(t, ioutil.WriteFile(f, []byte("- id: REQ-0-TEST-SWH-001\n  finding: missing_child\n  owner: alice\n  expires: 31.12.2025\n"), 0644))
+	_, err = ReadWaivers(f)
+	assert.EqualError(t, err, f+":1: waiver without justification\n"+
+		f+`:2: unknown finding type "missing_child"`+"\n"+
+		f+`:4: invalid expiry date "31.12.2025", expected e.g. 2025-12-31`)
+}
+
+func TestServerMetrics(t *testing.T) {
+	m := &serverMetrics{nodes: map[string]int{}, parse: newHistogram(parseBuckets), findings: map[string]int{},
+		requests: map[httpKey]*histogram{}}
+	m.observeBuild(time.Now(), reqGraph{"REQ-0-TEST-SYS-001": &Req{Level: config.SYSTEM}}, nil)
+	m.observeBuild(time.Now(), nil, newFinding("missing_parent", "Requirement REQ-0-TEST-SWH-001 in file /a.md has no parents.\n"))
+	m.observeRequest("/report", 200, 2*time.Second)
+	m.observeRequest("/favicon.ico", 404, time.Millisecond)
+
+	var b bytes.Buffer
+	m.Write(&b)
+	out := b.String()
+	assert.Contains(t, out, "reqtraq_graph_nodes{level=\"system\"} 1\n")
+	assert.Contains(t, out, "reqtraq_graph_builds_total 2\n")
+	assert.Contains(t, out, "reqtraq_graph_build_failures_total 1\n")
+	assert.Contains(t, out, "reqtraq_findings{type=\"missing_parent\"} 1\n")
+	assert.Contains(t, out, "reqtraq_graph_parse_duration_seconds_count 2\n")
+	assert.Contains(t, out, "reqtraq_http_request_duration_seconds_bucket{path=\"/report\",code=\"200\",le=\"1\"} 0\n")
+	assert.Contains(t, out, "reqtraq_http_request_duration_seconds_bucket{path=\"/report\",code=\"200\",le=\"5\"} 1\n")
+	assert.Contains(t, out, "reqtraq_http_request_duration_seconds_sum{path=\"/report\",code=\"200\"} 2\n")
+	assert.Contains(t, out, "reqtraq_http_request_duration_seconds_count{path=\"other\",code=\"404\"} 1\n")
+}
+
+func TestGraphCache(t *testing.T) {
+	c := newGraphCache(2)
+	builds := 0
+	build := func(id string) func() (*webGraph, error) {
+		return func() (*webGraph, error) {
+			builds++
+			if id == "" {
+				return nil, fmt.Errorf("Requirement REQ-0-TEST-SWH-001 in file /a.md has no parents.\n")
+			}
+			return &webGraph{rg: reqGraph{id: &Req{ID: id}}}, nil
+		}
+	}
+	for _, key := range []string{"a", "b", "a", "c", "a", "b"} {
+		g, err := c.get(key, build(key))
+		assert.NoError(t, err)
+		assert.Contains(t, g.rg, key)
+	}
+	// b was evicted by c, then c by b.
+	assert.Equal(t, 4, builds)
+	_, err := c.get("x", build(""))
+	assert.Error(t, err)
+	_, err = c.get("x", build(""))
+	assert.Error(t, err)
+	assert.Equal(t, 6, builds, "the graphs which could not be built are not kept")
+
+	assert.Equal(t, 1, c.invalidate("a", false))
+	assert.Equal(t, 0, c.invalidate("a", false))
+	assert.Equal(t, 1, c.invalidate("", true))
+	c.get("b", build("b"))
+	assert.Equal(t, 7, builds)
+
+	webGraphs = newGraphCache(2)
+	defer func() { webGraphs = newGraphCache(0) }()
+	webGraphs.get("", build("worktree"))
+	w := httptest.NewRecorder()
+	invalidateCache(w, httptest.NewRequest("GET", "/cache/invalidate?at=worktree", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+	w = httptest.NewRecorder()
+	invalidateCache(w, httptest.NewRequest("POST", "/cache/invalidate?at=worktree", nil))
+	assert.Equal(t, "1 graphs removed\n", w.Body.String())
+}
+
+func TestGraphCache_Worktree(t *testing.T) {
+	webGraphs = newGraphCache(2)
+	defer func() { webGraphs, *fSnapshot = newGraphCache(0), "" }()
+	*fSnapshot = filepath.Join(t.TempDir(), "snapshot.json")
+	write := func(rg reqGraph) {
+		f, err := os.Create(*fSnapshot)
+		assert.NoError(t, err)
+		assert.NoError(t, rg.WriteSnapshot(f, nil))
+		assert.NoError(t, f.Close())
+	}
+	write(reqGraph{"REQ-0-TEST-SYS-001": &Req{ID: "REQ-0-TEST-SYS-001", Level: config.SYSTEM}})
+	g, err := cachedBuildGraph("")
+	assert.NoError(t, err)
+	cached, err := cachedBuildGraph("")
+	assert.NoError(t, err)
+	assert.Same(t, g, cached)
+
+	write(reqGraph{"REQ-0-TEST-SYS-002": &Req{ID: "REQ-0-TEST-SYS-002", Level: config.SYSTEM}})
+	rebuilt, err := cachedBuildGraph("")
+	assert.NoError(t, err)
+	assert.Contains(t, rebuilt.rg, "REQ-0-TEST-SYS-002", "the graph is rebuilt when the files change")
+	assert.NotEqual(t, g.hash, rebuilt.hash)
+}
+
+func TestGraphKey(t *testing.T) {
+	key, err := graphKey("")
+	assert.NoError(t, err)
+	assert.Equal(t, "", key)
+
+	// The requests cannot make the server read other files than the repository.
+	snapshot := filepath.Join(t.TempDir(), "snapshot.json")
+	assert.NoError(t, os.WriteFile(snapshot, []byte("{}"), 0644))
+	for _, commit := range []string{snapshot, "--git-dir=/tmp", "no-such-branch"} {
+		_, err := graphKey(commit)
+		assert.Error(t, err, commit)
+	}
+	_, err = cachedBuildGraph(snapshot)
+	assert.Error(t, err)
+
+	head, err := graphKey("HEAD")
+	assert.NoError(t, err)
+	assert.Regexp(t, "^[0-9a-f]{40}$", head)
+}
+
+func TestReportHeaders(t *testing.T) {
+	built := time.Date(2024, 5, 1, 12, 0, 0, 500, time.UTC)
+	g := &webGraph{hash: "1234", built: built}
+	request := func(url string, header map[string]string, prev *webGraph) (*httptest.ResponseRecorder, bool) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest("GET", url, nil)
+		for k, v := range header {
+			r.Header.Set(k, v)
+		}
+		return w, reportHeaders(w, r, g, prev)
+	}
+
+	w, cached := request("/report?report-type=Issues", nil, nil)
+	assert.False(t, cached)
+	etag := w.Header().Get("ETag")
+	assert.Regexp(t, `^"[0-9a-f]{32}"$`, etag)
+	assert.Equal(t, "Wed, 01 May 2024 12:00:00 GMT", w.Header().Get("Last-Modified"))
+
+	w, cached = request("/report?report-type=Issues", map[string]string{"If-None-Match": `"other", W/` + etag}, nil)
+	assert.True(t, cached)
+	assert.Equal(t, http.StatusNotModified, w.Code)
+	_, cached = request("/report?report-type=Top+Down", map[string]string{"If-None-Match": etag}, nil)
+	assert.False(t, cached, "another report")
+	_, cached = request("/report?report-type=Issues", map[string]string{"If-None-Match": etag}, &webGraph{hash: "5678", built: built})
+	assert.False(t, cached, "changed since another graph")
+	g.hash = "4321"
+	_, cached = request("/report?report-type=Issues", map[string]string{"If-None-Match": etag}, nil)
+	assert.False(t, cached, "the requirements changed")
+
+	_, cached = request("/report", map[string]string{"If-Modified-Since": "Wed, 01 May 2024 12:00:00 GMT"}, nil)
+	assert.True(t, cached)
+	_, cached = request("/report", map[string]string{"If-Modified-Since": "Wed, 01 May 2024 11:59:59 GMT"}, nil)
+	assert.False(t, cached)
+	_, cached = request("/report", map[string]string{"If-Modified-Since": "Wed, 01 May 2024 11:59:59 GMT"},
+		&webGraph{built: built.Add(-time.Hour)})
+	assert.False(t, cached, "the newest graph is used")
+}
+
+func TestCheckReportLinks(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/doc.pdf" {
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	dir := t.TempDir()
+	write := func(name, contents string) {
+		assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, name), []byte(contents), 0644))
+	}
+	write("code.go", "package main\n")
+	write("other.html", `<h3 id="x">X</h3>`)
+	write("doc.pdf", string(testPDF(map[string]bool{"REQ-0-TEST-SYS-001": true, "REQ-0-TEST-SYS-002": false}, false)))
+	write("report.html", `<h3><a name="REQ-0-TEST-SYS-001"></a>REQ-0-TEST-SYS-001</h3>
+<a href="#REQ-0-TEST-SYS-001">up</a> <a href="#REQ-0-TEST-SYS-003">down</a> <a href="#REQ-0-TEST-SYS-003">again</a>
+<a href="other.html#x">x</a> <a href="other.html#y">y</a> <a href="missing.html">missing</a>
+<a href="file://`+dir+`/code.go" target="_blank">code</a> <a href="file://`+dir+`/gone.go">gone</a>
+<a href="doc.pdf#REQ-0-TEST-SYS-001">pdf</a> <a href="doc.pdf#REQ-0-TEST-SYS-002">annot</a>
+<a href="`+server.URL+`/doc.pdf#REQ-0-TEST-SYS-001">published</a> <a href="`+server.URL+`/task?id=1&amp;x=2">task</a>
+<a href="mailto:someone@example.com">mail</a>`)
+
+	report := filepath.Join(dir, "report.html")
+	broken, err := CheckReportLinks([]string{report}, false)
+	assert.Nil(t, err)
+	pfx := "Report " + report + " has a broken link to "
+	expected := []error{
+		newFinding("broken_link", "%s#REQ-0-TEST-SYS-003: no anchor REQ-0-TEST-SYS-003 in %s.\n", pfx, repoRelative(report)),
+		newFinding("broken_link", "%sdoc.pdf#REQ-0-TEST-SYS-002: the named destination REQ-0-TEST-SYS-002 in %s does not point to a page.\n", pfx, repoRelative(filepath.Join(dir, "doc.pdf"))),
+		newFinding("broken_link", "%sfile://%s/gone.go: %s does not exist.\n", pfx, dir, repoRelative(filepath.Join(dir, "gone.go"))),
+		newFinding("broken_link", "%smissing.html: %s does not exist.\n", pfx, repoRelative(filepath.Join(dir, "missing.html"))),
+		newFinding("broken_link", "%sother.html#y: no anchor y in %s.\n", pfx, repoRelative(filepath.Join(dir, "other.html"))),
+	}
+	assert.Equal(t, expected, broken)
+
+	broken, err = CheckReportLinks([]string{report}, true)
+	assert.Nil(t, err)
+	assert.Equal(t, append(expected[:3:3], append([]error{newFinding("broken_link", "%s%s/task?id=1&x=2: 404 Not Found.\n", pfx, server.URL)}, expected[3:]...)...), broken)
+}
+
+func TestCheckReportLinks_CodeFiles(t *testing.T) {
+	dir := t.TempDir()
+	code := filepath.Join(dir, "src", "a.go")
+	assert.Nil(t, os.MkdirAll(filepath.Dir(code), 0755))
+	assert.Nil(t, ioutil.WriteFile(code, []byte("// @"+"llr REQ-0-TEST-SWL-001\n"), 0644))
+	// The reports list the commits changing the code files.
+	run := func(args ...string) {
+		out, err := exec.Command("git", append([]string{"-C", dir}, args...)...).CombinedOutput()
+		assert.Nil(t, err, string(out))
+	}
+	run("init", "-q")
+	run("config", "user.email", "test@example.com")
+	run("config", "user.name", "Test")
+	run("add", ".")
+	run("commit", "-q", "-m", "Code")
+	rg := reqGraph{"REQ-0-TEST-SWL-001": &Req{ID: "REQ-0-TEST-SWL-001", Level: config.LOW, Path: "/certdocs/0-TEST-212-SDD.md"}}
+	assert.Nil(t, parseCode("src/a.go", code, rg))
+	rg.Resolve() // The requirement has no parents.
+
+	var b bytes.Buffer
+	assert.Nil(t, rg.ReportUp(&b))
+	assert.Contains(t, b.String(), `href="`+code+`"`)
+	report := filepath.Join(dir, "out", "up.html")
+	assert.Nil(t, os.MkdirAll(filepath.Dir(report), 0755))
+	assert.Nil(t, ioutil.WriteFile(report, b.Bytes(), 0644))
+	broken, err := CheckReportLinks([]string{report}, false)
+	assert.Nil(t, err)
+	for _, e := range broken {
+		assert.NotContains(t, e.Error(), code)
+	}
+
+	assert.Nil(t, os.Remove(code))
+	broken, err = CheckReportLinks([]string{report}, false)
+	assert.Nil(t, err)
+	assert.Contains(t, broken, newFinding("broken_link", "Report %s has a broken link to %s: %s does not exist.\n", report, code, repoRelative(code)))
+}
+
+func TestTrendCSV(t *testing.T) {
+	since := time.Date(2024, 1, 30, 0, 0, 0, 0, time.UTC)
+	dates, err := trendDates(since, since.AddDate(0, 0, 14), "weekly")
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(dates))
+	assert.Equal(t, "2024-02-13", dates[2].Format("2006-01-02"))
+	_, err = trendDates(since, since, "yearly")
+	assert.NotNil(t, err)
+
+	var b bytes.Buffer
+	err = WriteTrendCSV(&b, []TrendPoint{{Date: since, Commit: "abc123", Levels: []LevelStats{
+		{Level: "low", Total: 2, WithChildren: 2, WithCode: 2, WithTests: 1, PctChildren: 100, PctCode: 100, PctTests: 50},
+	}}})
+	assert.Nil(t, err)
+	assert.Equal(t, "date,commit,level,total,with_children,with_code,with_tests,pct_with_children,pct_with_code,pct_with_tests,stamp\n"+
+		"2024-01-30,abc123,low,2,2,2,1,100.0,100.0,50.0,\n", b.String())
+}
+
+func TestStamp(t *testing.T) {
+	rg := reqGraph{"REQ-0-TEST-SYS-001": &Req{ID: "REQ-0-TEST-SYS-001", Level: config.SYSTEM, Title: "Speed"}}
+	s, err := NewStamp(rg, "HEAD")
+	assert.Nil(t, err)
+	hash, _ := rg.Hash()
+	ref, _ := git.Describe("HEAD")
+	assert.Equal(t, hash, s.GraphHash)
+	assert.Equal(t, ref, s.Ref)
+	assert.NotEmpty(t, s.Version)
+
+	s = &Stamp{Ref: "v1.0-2-gabc", GraphHash: "1234", Generated: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC), Version: "v0.9.0"}
+	text := "Generated by reqtraq v0.9.0 from v1.0-2-gabc, requirement graph 1234, on 2024-05-01T12:00:00Z"
+	assert.Equal(t, text, s.String())
+	assert.Equal(t, "# Title\n\n---\nsubject: '"+text+"'\n...\n", string(stampDocument([]byte("# Title\n"), "md", s)))
+	assert.Equal(t, "\\use_hyperref true\r\n\\pdf_subject \""+text+"\"\r\n\\end_header\r\n",
+		string(stampDocument([]byte("\\use_hyperref true\r\n\\pdf_subject \"old\"\r\n\\end_header\r\n"), "lyx", s)))
+
+	artifactStamp = s
+	defer func() { artifactStamp = nil }()
+	var b bytes.Buffer
+	assert.Nil(t, rg.ReportIssues(&b))
+	assert.Contains(t, b.String(), "<footer class=\"text-muted\"><small>"+text+"</small></footer>")
+	b.Reset()
+	assert.Nil(t, WriteTrendCSV(&b, []TrendPoint{{Date: s.Generated, Commit: "abc123", Levels: []LevelStats{{Level: "low"}}}}))
+	assert.Equal(t, "date,commit,level,total,with_children,with_code,with_tests,pct_with_children,pct_with_code,pct_with_tests,stamp\n"+
+		"2024-05-01,abc123,low,0,0,0,0,0.0,0.0,0.0,\""+text+"\"\n", b.String())
+}
+
+func TestWriteVersion(t *testing.T) {
+	var b bytes.Buffer
+	WriteVersion(&b, false)
+	assert.Equal(t, "reqtraq "+reqtraqVersion()+"\n", b.String())
+
+	buildDate = "2024-05-01T12:00:00+00:00"
+	defer func() { buildDate = "" }()
+	b.Reset()
+	WriteVersion(&b, true)
+	assert.Contains(t, b.String(), "\nbuild date:      2024-05-01T12:00:00+00:00\n")
+	assert.Contains(t, b.String(), fmt.Sprintf("\nconfig schema:   %d, the older versions upgraded by config migrate\n", repoConfigVersion))
+	assert.Contains(t, b.String(), fmt.Sprintf("\nsnapshot format: %d\nmanifest format: %d\n", snapshotVersion, manifestVersion))
+	assert.Equal(t, 9, strings.Count(b.String(), "\n"))
+}
+
+func TestCheckCommitMessage(t *testing.T) {
+	rules := &CommitRules{Paths: map[string]bool{"third_party": false, "third_party/reqs": true, "scripts/": true}}
+	files := rules.filesRequiringReference([]string{
+		"certdocs/0-TEST-211-SRD.md", "README.md", "a/b.go", "a/c.go", "third_party/x.go", "third_party/reqs/y.txt", "scripts/z.sh",
+	}, map[string][]byte{
+		// Split so that this file is not seen as referencing the requirement.
+		"a/b.go":           []byte("// @" + "llr REQ-0-TEST-SWL-001\n"),
+		"a/c.go":           []byte("package a\n"),
+		"third_party/x.go": []byte("// @" + "llr REQ-0-TEST-SWL-001\n"),
+	})
+	assert.Equal(t, []string{"certdocs/0-TEST-211-SRD.md", "a/b.go", "third_party/reqs/y.txt", "scripts/z.sh"}, files)
+
+	rg := reqGraph{
+		"REQ-0-TEST-SWL-001": &Req{ID: "REQ-0-TEST-SWL-001", Level: config.LOW},
+		"REQ-0-TEST-SWL-002": &Req{ID: "REQ-0-TEST-SWL-002", Level: config.LOW, Title: "DELETED"},
+	}
+	assert.Nil(t, rg.CheckCommitMessage("Fix the parser\n\nREQ-0-TEST-SWL-001\n", files))
+	assert.Nil(t, rg.CheckCommitMessage("Update the readme\n", nil))
+	err := rg.CheckCommitMessage("Fix the parser\n\nREQ-0-TEST-SWL-002\n# REQ-0-TEST-SWL-001\n", []string{"a/b.go"})
+	assert.Equal(t, "Commit message references no requirement, which is required by the changes to: a/b.go. "+
+		"Unknown or deleted requirements: REQ-0-TEST-SWL-002.\n", err.Error())
+	assert.Equal(t, map[string]int{"commit_message": 1}, Summarize("commitmsg", exitFindings, err).Counts)
+}
+
+func TestCheckCommitMessage_Deleted(t *testing.T) {
+	dir := t.TempDir()
+	cwd, err := os.Getwd()
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Nil(t, os.Chdir(dir))
+	defer os.Chdir(cwd)
+	run := func(args ...string) {
+		out, err := exec.Command("git", args...).CombinedOutput()
+		assert.Nil(t, err, string(out))
+	}
+	run("init", "-q")
+	run("config", "user.email", "test@example.com")
+	run("config", "user.name", "Test")
+	assert.Nil(t, os.MkdirAll(filepath.Join(dir, "code"), 0755))
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "code", "a.go"), []byte("// @"+"llr REQ-0-TEST-SWL-001\nfunc a() {}\n"), 0644))
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "code", "b.go"), []byte("package code\n"), 0644))
+	run("add", ".")
+	run("commit", "-q", "-m", "Add the code")
+
+	// The deleted code file referenced a requirement, the other one did not.
+	run("rm", "-q", "code/a.go", "code/b.go")
+	msg := filepath.Join(dir, "msg")
+	assert.Nil(t, ioutil.WriteFile(msg, []byte("Remove the code\n"), 0644))
+	err = checkCommitMessage(msg, "certdocs", "code", filepath.Join(dir, "commitmsg.json"))
+	if assert.NotNil(t, err) {
+		assert.Equal(t, "Commit message references no requirement, which is required by the changes to: code/a.go.\n", err.Error())
+	}
+}
+
+func TestCheckRevisionBumps(t *testing.T) {
+	revision := func(contents string) string { return ParseDocument("0-TEST-211-SRD.md", []byte(contents)).Revision }
+	assert.Equal(t, "3", revision("# SRD\n\nRevision: 3\n\n## Introduction\n\nRevision: 4\n"))
+	assert.Equal(t, "1.2", revision("---\ntitle: SRD\n---\n**Version:** 1.2\n"))
+	assert.Equal(t, "B", revision("\\begin_body\n\\begin_layout Standard\nRevision: B\n\\end_layout\n"))
+	assert.Equal(t, "", revision("# SRD\n\n## Introduction\n\nRevision: 4\n"))
+
+	const doc = "/certdocs/0-DDLN-211-SRD.md"
+	prg := reqGraph{"REQ-0-DDLN-SWH-001": &Req{ID: "REQ-0-DDLN-SWH-001", Level: config.HIGH, Path: doc, Title: "Old"}}
+	rg := reqGraph{"REQ-0-DDLN-SWH-001": &Req{ID: "REQ-0-DDLN-SWH-001", Level: config.HIGH, Path: doc, Title: "New"}}
+	assert.Nil(t, rg.CheckRevisionBumps(rg, "", ""))
+	// Both versions are read from the working tree, so the revision did not change.
+	err := rg.CheckRevisionBumps(prg, "", "")
+	assert.Equal(t, "Document 0-DDLN-211-SRD.md changes REQ-0-DDLN-SWH-001 but its revision is still \"1\".\n", err.Error())
+	assert.Equal(t, map[string]int{"document_revision": 1}, Summarize("prepush", exitFindings, err).Counts)
+}
+
+func TestParseDocument(t *testing.T) {
+	doc := ParseDocument("/certdocs/0-TEST-211-SRD.md", []byte("---\ntitle: Front matter\ndate: 2024-01-31\n---\n"+
+		"# Software Requirements\n\nRevision: 2\n\nDocument Approval:\n- Engineering, Engineer: A\n- Quality, Engineer: B\n\n"+
+		"## Introduction\n\n- Not an approver\n"))
+	assert.Equal(t, &Document{ID: "0-TEST-211-SRD", Title: "Front matter", Revision: "2", Date: "2024-01-31",
+		Approvers: []string{"Engineering, Engineer: A", "Quality, Engineer: B"}}, doc)
+
+	doc = ParseDocument("0-TEST-211-SRD.lyx", []byte("\\begin_body\n\\begin_layout Title\nSoftware \nRequirements\n\\end_layout\n"+
+		"\\begin_layout Date\n2024-01-31\n\\end_layout\n\\begin_layout Standard\nApprovers: A\n\\end_layout\n"+
+		"\\begin_layout Section\nRevision: 3\n\\end_layout\n"))
+	assert.Equal(t, &Document{ID: "0-TEST-211-SRD", Title: "Software Requirements", Date: "2024-01-31", Approvers: []string{"A"}}, doc)
+
+	rg, err := CreateReqGraph("/certdocs", "/")
+	assert.Nil(t, err)
+	var paths []string
+	for _, d := range rg.Documents() {
+		paths = append(paths, d.Path)
+		assert.Equal(t, "1", d.Revision)
+		assert.NotEmpty(t, d.Approvers)
+	}
+	assert.Equal(t, []string{"/certdocs/0-DDLN-100-ORD.md", "/certdocs/0-DDLN-211-SRD.md", "/certdocs/0-DDLN-212-SDD.md"}, paths)
+
+	// The documents are listed once, at the top of the reports.
+	for _, report := range []func(io.Writer) error{rg.ReportDown, rg.ReportUp, rg.ReportIssues} {
+		var b bytes.Buffer
+		assert.Nil(t, report(&b))
+		assert.Equal(t, 1, strings.Count(b.String(), "<th>Approvers</th>"))
+		assert.Less(t, strings.Index(b.String(), "0-DDLN-211-SRD"), strings.Index(b.String(), "<h2>"))
+	}
+}
+
+func TestBuildDocTrace(t *testing.T) {
+	srd := &Document{ID: "0-TEST-211-SRD", Revision: "2"}
+	sdd := &Document{ID: "0-TEST-212-SDD", Revision: "1"}
+	other := &Document{ID: "1-OTHR-211-SRD"}
+	hlr := &Req{ID: "REQ-0-TEST-SWH-001", Level: config.HIGH, Document: srd}
+	rg := reqGraph{
+		hlr.ID:               hlr,
+		"REQ-0-TEST-SWL-001": &Req{ID: "REQ-0-TEST-SWL-001", Level: config.LOW, Document: sdd, Parents: []*Req{hlr}},
+		"REQ-0-TEST-SWL-002": &Req{ID: "REQ-0-TEST-SWL-002", Level: config.LOW, Document: sdd, Parents: []*Req{hlr}},
+	}
+	nodes := BuildDocTrace([]*Document{sdd, other, srd}, rg)
+	if assert.Len(t, nodes, 3) {
+		assert.Equal(t, "0-TEST-212-SDD", nodes[1].ID)
+		assert.Equal(t, 2, nodes[1].Requirements)
+		assert.Equal(t, []DocLink{
+			{Kind: "implements", To: "0-TEST-211-SRD", Revision: "2", Traces: 2},
+			{Kind: "satisfies", To: "0-TEST-203-SDP", Missing: true},
+		}, nodes[1].Links)
+		// The documents of other projects are not related.
+		assert.Equal(t, DocLink{Kind: "implements", To: "1-OTHR-100-ORD", Missing: true}, nodes[2].Links[0])
+	}
+
+	var b bytes.Buffer
+	WriteDocTraceMarkdown(&b, nodes)
+	assert.Contains(t, b.String(), "| 0-TEST-212-SDD | 1 | 2 | implements | 0-TEST-211-SRD | 2 | 2 |\n")
+	assert.Contains(t, b.String(), "| 0-TEST-212-SDD | 1 | 2 | satisfies | 0-TEST-203-SDP | missing | - |\n")
+}
+
+func TestRepoConfig(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, contents string) string {
+		fileName := filepath.Join(dir, name)
+		assert.NoError(t, ioutil.WriteFile(fileName, []byte(contents), 0644))
+		return fileName
+	}
+
+	f := write("ok.yaml", "version: 1\nproject: Test\nattributes:\n  - name: Verification\n    value: (Test|Review)\n  - name: Safety Impact\n")
+	c, err := LoadRepoConfig(f)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "Test", c.Project)
+		assert.Equal(t, []map[string]string{{"name": "Verification", "value": "(Test|Review)"}, {"name": "Safety Impact"}}, c.AttributeMaps())
+	}
+	conf, err := ReadJsonConf(f)
+	assert.NoError(t, err)
+	assert.Len(t, conf.Attributes, 2)
+
+	f = write("unknown.yaml", "version: 1\nprojekt: Test\n")
+	_, err = LoadRepoConfig(f)
+	assert.EqualError(t, err, f+`:2: unknown key "projekt"`)
+
+	f = write("regexp.yaml", "version: 1\nattributes:\n  - name: Verification\n    value: (Test\n  - value: Review\n")
+	_, err = LoadRepoConfig(f)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), f+`:4: invalid value of attribute "Verification"`)
+		assert.Contains(t, err.Error(), f+":5: attribute without name")
+	}
+
+	f = write("when.yaml", "version: 1\nattributes:\n  - name: Mitigation\n    when: Safety Impact None\n")
+	_, err = LoadRepoConfig(f)
+	assert.EqualError(t, err, f+`:4: attribute "Mitigation" has invalid condition "Safety Impact None", expected e.g. "Safety Impact != None"`)
+
+	f = write("style.yaml", "version: 1\nstyle:\n  single_shall: true\n  forbidden: [\"and/or\", \"(TBD\"]\n")
+	_, err = LoadRepoConfig(f)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), f+`:4: invalid forbidden phrase "(TBD"`)
+	}
+
+	f = write("aliases.yaml", "version: 1\naliases:\n  REQ-0-TEST-SWL-017: REQ-0-TEST-SWL-117\n  REQ-0-TEST-SWL-018: SWL-118\n")
+	_, err = LoadRepoConfig(f)
+	assert.EqualError(t, err, f+`:4: invalid requirement ID "SWL-118" in aliases`)
+
+	f = write("placement.yaml", "version: 1\ntag_placement: [header, body]\n")
+	_, err = LoadRepoConfig(f)
+	assert.EqualError(t, err, f+`:2: invalid tag placement "body", expected header or function`)
+
+	f = write("views.yaml", "version: 1\nviews:\n  critical:\n    query: level(SWL) &\n    format: html\n  empty:\n    format: md\n")
+	_, err = LoadRepoConfig(f)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), f+`:4: view "critical" has invalid query at position 13: unexpected end`)
+		assert.Contains(t, err.Error(), f+`:5: view "critical" has unknown format "html", expected ids, md, json`)
+		assert.Contains(t, err.Error(), f+`:7: view "empty" without query`)
+	}
+
+	f = write("types.yaml", "version: 1\nattributes:\n  - name: Effort\n    type: days\n")
+	_, err = LoadRepoConfig(f)
+	assert.EqualError(t, err, f+`:4: attribute "Effort" has unknown type "days", expected text, int, float, enum, date, ref`)
+
+	f = write("enum.yaml", "version: 1\nattributes:\n  - name: Priority\n    type: enum\n  - name: Effort\n    values: [1, 2]\n")
+	_, err = LoadRepoConfig(f)
+	assert.EqualError(t, err, f+`:4: attribute "Priority" of type enum without values`+"\n"+f+`:6: attribute "Effort" has values but is not of type enum`)
+
+	f = write("priorities.yaml", "version: 1\npriorities:\n  - High\n  - Low\n  - High\n")
+	_, err = LoadRepoConfig(f)
+	assert.EqualError(t, err, f+`:5: empty or duplicate priority "High"`)
+
+	f = write("old.yaml", "project: Test\n")
+	_, err = LoadRepoConfig(f)
+	assert.EqualError(t, err, f+`: missing version, run "reqtraq config migrate" to upgrade it`)
+	f = write("new.yaml", "version: 2\n")
+	_, err = LoadRepoConfig(f)
+	assert.EqualError(t, err, f+`:1: version 2 is not supported, this reqtraq reads version 1, run "reqtraq config migrate" to upgrade it`)
+	_, err = MigrateRepoConfig(f)
+	assert.Error(t, err)
+
+	// The attribute specification in JSON is the version 0.
+	f = write("attributes.json", `{"attributes": [{"name": "Verification", "value": "Test"}]}`)
+	b, err := MigrateRepoConfig(f)
+	if assert.NoError(t, err) {
+		assert.Contains(t, string(b), "version: 1\nproject: "+config.ProjectName+"\n")
+		assert.Contains(t, string(b), "attributes:\n  - name: Verification\n    value: Test\n")
+		c, err = LoadRepoConfig(write("migrated.yaml", string(b)))
+		if assert.NoError(t, err) {
+			assert.Equal(t, []AttributeSpec{{Name: "Verification", Value: "Test"}}, c.Attributes)
+		}
+	}
+}
+
+func TestConfigTree(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, contents string) {
+		fileName := filepath.Join(dir, filepath.FromSlash(name))
+		assert.NoError(t, os.MkdirAll(filepath.Dir(fileName), 0755))
+		assert.NoError(t, ioutil.WriteFile(fileName, []byte(contents), 0644))
+	}
+	write("reqtraq.yaml", "version: 1\nproject: Ignored\n")
+	write("certdocs/comp/reqtraq.yaml", "version: 1\nattributes:\n  - name: verification\n    optional: true\n  - name: ASIL\n")
+	write("code/py/reqtraq.yaml", "version: 1\nsources: [\"*.py\"]\n")
+	write("code/py/gen/reqtraq.yaml", "version: 1\nsources: [\"*.py\", \"*.pyx\"]\n")
+
+	configs, err := loadConfigTree(dir, "certdocs", "code", "")
+	assert.NoError(t, err)
+	assert.Len(t, configs, 3)
+
+	assert.True(t, configs.isSource("code/main.go"))
+	assert.False(t, configs.isSource("code/main.py"))
+	assert.True(t, configs.isSource("code/py/main.py"))
+	assert.False(t, configs.isSource("code/py/main.go"))
+	assert.True(t, configs.isSource("code/py/gen/fast.pyx"))
+
+	as := []map[string]string{{"name": "Verification", "value": "Test"}, {"name": "Rationale"}}
+	assert.Equal(t, as, configs.attributes("/certdocs/0-TEST-100-ORD.md", as))
+	assert.Equal(t, []map[string]string{{"name": "verification", "optional": "true"}, {"name": "Rationale"}, {"name": "ASIL"}},
+		configs.attributes("/certdocs/comp/0-TEST-211-SRD.md", as))
+
+	r := &Req{ID: "REQ-0-TEST-SWH-001", Level: config.HIGH, Attributes: map[string]string{"RATIONALE": "R", "ASIL": "B"}}
+	assert.Empty(t, r.CheckAttributes(configs.attributes("/certdocs/comp/0-TEST-211-SRD.md", as)))
+	assert.Len(t, r.CheckAttributes(as), 1)
+
+	// Only the attributes and the sources can be overridden.
+	write("code/other/reqtraq.yaml", "version: 1\nsources: [\"*.c\"]\nproject: Other\n")
+	_, err = loadConfigTree(dir, "code")
+	assert.EqualError(t, err, filepath.Join(dir, "code", "other", "reqtraq.yaml")+":3: project can be set only in the reqtraq.yaml at the root of the repo\n")
+}
+
+func TestRepoConfig_Vars(t *testing.T) {
+	t.Setenv("REQTRAQ_TEST_URL", "https://ci.example.com/docs")
+	t.Setenv("REQTRAQ_TEST_TOKEN", "api-123")
+	fileName := filepath.Join(t.TempDir(), "reqtraq.yaml")
+	write := func(contents string) {
+		assert.NoError(t, ioutil.WriteFile(fileName, []byte(contents), 0644))
+	}
+
+	write("version: 1\ndocs_url: ${REQTRAQ_TEST_URL}/reqtraq\nlang: ${REQTRAQ_TEST_UNSET:-de}\n" +
+		"taskmgr_token: ${REQTRAQ_TEST_TOKEN}\nattributes:\n  - name: Verification\n    value: ^$${REQTRAQ_TEST_URL}$\n")
+	c, err := LoadRepoConfig(fileName)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "https://ci.example.com/docs/reqtraq", c.DocsURL)
+		assert.Equal(t, "de", c.Lang)
+		assert.Equal(t, "api-123", c.TaskmgrToken)
+		assert.Equal(t, "^${REQTRAQ_TEST_URL}$", c.Attributes[0].Value)
+	}
+
+	write("version: 1\nproject: Test\ndocs_url: ${REQTRAQ_TEST_UNSET}\n")
+	_, err = LoadRepoConfig(fileName)
+	assert.EqualError(t, err, fileName+":3: environment variable REQTRAQ_TEST_UNSET is not set")
+
+	// The references are kept when migrating.
+	write("project: Test\ndocs_url: ${REQTRAQ_TEST_URL}\n")
+	b, err := MigrateRepoConfig(fileName)
+	if assert.NoError(t, err) {
+		assert.Contains(t, string(b), "docs_url: ${REQTRAQ_TEST_URL}\n")
+	}
+}
+
+func TestGRPCServer(t *testing.T) {
+	ln := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer()
+	reqtraqpb.RegisterReqtraqServer(srv, &grpcServer{})
+	go srv.Serve(ln)
+	defer srv.Stop()
+	conn, err := grpc.NewClient("passthrough:///bufnet", grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return ln.DialContext(ctx) }))
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer conn.Close()
+	client := reqtraqpb.NewReqtraqClient(conn)
+	ctx := context.Background()
+
+	r, err := client.GetRequirement(ctx, &reqtraqpb.GetRequirementRequest{Id: "REQ-0-DDLN-SYS-001"})
+	if assert.Nil(t, err) {
+		assert.Equal(t, "SYS", r.Type)
+		assert.Equal(t, "certdocs/0-DDLN-100-ORD.md", r.Path)
+		assert.NotEmpty(t, r.ChildIds)
+	}
+	_, err = client.GetRequirement(ctx, &reqtraqpb.GetRequirementRequest{Id: "REQ-0-DDLN-SYS-999"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	stream, err := client.Query(ctx, &reqtraqpb.QueryRequest{Query: "level(SYS)"})
+	if assert.Nil(t, err) {
+		var ids []string
+		for {
+			r, err := stream.Recv()
+			if err == io.EOF {
+				break
+			}
+			if !assert.Nil(t, err) {
+				break
+			}
+			ids = append(ids, r.Id)
+		}
+		assert.Contains(t, ids, "REQ-0-DDLN-SYS-001")
+		assert.NotContains(t, ids, "REQ-0-DDLN-SWH-001")
+	}
+	stream, err = client.Query(ctx, &reqtraqpb.QueryRequest{Query: "level("})
+	if assert.Nil(t, err) {
+		_, err = stream.Recv()
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	}
+
+	diffs, err := client.Diff(ctx, &reqtraqpb.DiffRequest{})
+	if assert.Nil(t, err) {
+		_, err = diffs.Recv()
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	}
+}
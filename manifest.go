@@ -0,0 +1,173 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+
+	"github.com/daedaleanai/reqtraq/config"
+)
+
+// manifestVersion is the version of the manifest format.
+const manifestVersion = 1
+
+// Manifest summarizes the traceability of a release, to be archived along
+// with the built binaries as configuration management evidence.
+type Manifest struct {
+	Version   int
+	Commit    string // The hash of the released commit.
+	Ref       string // The released commit as described by git, e.g. v1.2-3-g1a2b3c4.
+	GraphHash string // See reqGraph.Hash.
+	// Coverage is the coverage of the code of each requirement, when coverage reports are given.
+	Coverage map[string]*Coverage `json:",omitempty"`
+	// TotalCoverage is the sum of the coverage of the requirements.
+	TotalCoverage *Coverage `json:",omitempty"`
+	// Satisfied are the IDs of the requirements whose graph is complete, sorted.
+	Satisfied []string
+	// Signature is the base64 ed25519 signature of the manifest without signature.
+	Signature string `json:",omitempty"`
+}
+
+// NewManifest returns the unsigned manifest of the graph of the given commit.
+func (rg reqGraph) NewManifest(commit, ref string) (*Manifest, error) {
+	hash, err := rg.Hash()
+	if err != nil {
+		return nil, err
+	}
+	m := &Manifest{Version: manifestVersion, Commit: commit, Ref: ref, GraphHash: hash, Satisfied: []string{}}
+	for _, r := range rg {
+		if r.Level == config.CODE {
+			continue
+		}
+		if r.Status == COMPLETED {
+			m.Satisfied = append(m.Satisfied, r.ID)
+		}
+		if r.Coverage != nil {
+			if m.Coverage == nil {
+				m.Coverage = map[string]*Coverage{}
+				m.TotalCoverage = &Coverage{}
+			}
+			m.Coverage[r.ID] = r.Coverage
+			m.TotalCoverage.add(r.Coverage)
+		}
+	}
+	sort.Strings(m.Satisfied)
+	return m, nil
+}
+
+// signedBytes returns the contents covered by the signature.
+func (m *Manifest) signedBytes() ([]byte, error) {
+	unsigned := *m
+	unsigned.Signature = ""
+	return json.Marshal(unsigned)
+}
+
+// Sign sets the Signature of the manifest.
+func (m *Manifest) Sign(key ed25519.PrivateKey) error {
+	b, err := m.signedBytes()
+	if err != nil {
+		return err
+	}
+	m.Signature = base64.StdEncoding.EncodeToString(ed25519.Sign(key, b))
+	return nil
+}
+
+// Verify checks the Signature of the manifest.
+func (m *Manifest) Verify(key ed25519.PublicKey) error {
+	if m.Signature == "" {
+		return fmt.Errorf("Manifest is not signed")
+	}
+	sig, err := base64.StdEncoding.DecodeString(m.Signature)
+	if err != nil {
+		return fmt.Errorf("Manifest has malformed signature: %v", err)
+	}
+	b, err := m.signedBytes()
+	if err != nil {
+		return err
+	}
+	if !ed25519.Verify(key, b, sig) {
+		return fmt.Errorf("Manifest signature is invalid, the manifest was modified or signed with another key")
+	}
+	return nil
+}
+
+// Write writes the manifest as indented json.
+func (m *Manifest) Write(w io.Writer) error {
+	b, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(b, '\n'))
+	return err
+}
+
+// ReadManifest reads a manifest written by Write.
+func ReadManifest(fileName string) (*Manifest, error) {
+	b, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+	var m Manifest
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil, fmt.Errorf("Error while parsing manifest %s: %v", fileName, err)
+	}
+	if m.Version != manifestVersion {
+		return nil, fmt.Errorf("Manifest %s has version %d, this reqtraq reads version %d", fileName, m.Version, manifestVersion)
+	}
+	return &m, nil
+}
+
+// readPEM returns the key in the given PEM file, e.g. created with
+// "openssl genpkey -algorithm ed25519" and "openssl pkey -pubout".
+func readPEM(fileName string) (interface{}, error) {
+	b, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(b)
+	if block == nil {
+		return nil, fmt.Errorf("Key %s is not in PEM format", fileName)
+	}
+	switch block.Type {
+	case "PRIVATE KEY":
+		return x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		return x509.ParsePKIXPublicKey(block.Bytes)
+	}
+	return nil, fmt.Errorf("Key %s has unexpected type %s", fileName, block.Type)
+}
+
+// ReadSigningKey reads an ed25519 private key from a PKCS #8 PEM file.
+func ReadSigningKey(fileName string) (ed25519.PrivateKey, error) {
+	key, err := readPEM(fileName)
+	if err != nil {
+		return nil, err
+	}
+	k, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("Key %s is not an ed25519 private key", fileName)
+	}
+	return k, nil
+}
+
+// ReadVerifyingKey reads an ed25519 public key from a PKIX PEM file, or the
+// public part of a private key.
+func ReadVerifyingKey(fileName string) (ed25519.PublicKey, error) {
+	key, err := readPEM(fileName)
+	if err != nil {
+		return nil, err
+	}
+	switch k := key.(type) {
+	case ed25519.PublicKey:
+		return k, nil
+	case ed25519.PrivateKey:
+		return k.Public().(ed25519.PublicKey), nil
+	}
+	return nil, fmt.Errorf("Key %s is not an ed25519 key", fileName)
+}
@@ -1,21 +1,89 @@
 package main
 
 import (
-	"bufio"
 	"bytes"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"os"
+	"path"
+	"path/filepath"
 	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/daedaleanai/reqtraq/config"
+	"github.com/daedaleanai/reqtraq/git"
 )
 
 var (
 	// For detecting ATX Headings, see http://spec.commonmark.org/0.27/#atx-headings
 	reATXHeading = regexp.MustCompile(`(?m)^ {0,3}(#{1,6})( +(.*)( #* *)?)?$`)
+	// reATXClosing matches the optional closing sequence of an ATX heading,
+	// which must be preceded by a space, unlike a # ending the title, e.g. C#.
+	reATXClosing = regexp.MustCompile(`[ \t]+#*[ \t]*$`)
 )
 
+// docReqType returns the type of the requirements of the certification
+// document with the given file name, e.g. SWL for an SDD.
+func docReqType(fileName string) string {
+	base := path.Base(filepath.ToSlash(fileName))
+	parts := strings.Split(strings.TrimSuffix(base, path.Ext(base)), "-")
+	return config.DocTypeToReqType[parts[len(parts)-1]]
+}
+
+// isParentHeading returns whether the heading with the given requirement ID,
+// in a document of the given requirement type, is the heading of a parent
+// requirement, under which the requirements are nested when infer_parents is
+// set in the configuration, see headingParents.
+func isParentHeading(id, reqType string) bool {
+	if !repoConfig.InferParents || reqType == "" {
+		return false
+	}
+	parts := ReReqID.FindStringSubmatch(id)
+	return parts != nil && parts[3] != reqType
+}
+
+// headingParents returns the IDs of the parent requirements whose headings the
+// requirements of the given Markdown document are nested under, by ID.
+func headingParents(fileName string, contents []byte) map[string][]string {
+	res := map[string][]string{}
+	reqType := docReqType(fileName)
+	var parents []string // The IDs of the enclosing parent headings, by level.
+	scan := newLineReader(bytes.NewReader(contents))
+	for scan.Scan() {
+		parts := reATXHeading.FindStringSubmatch(toUTF8(scan.Text()))
+		if parts == nil {
+			continue
+		}
+		level := len(parts[1])
+		for len(parents) <= level {
+			parents = append(parents, "")
+		}
+		parents = parents[:level+1]
+		id := ReReqID.FindString(parts[3])
+		if id == "" || !strings.HasPrefix(strings.TrimSpace(parts[3]), id) {
+			parents[level] = ""
+			continue
+		}
+		if isParentHeading(id, reqType) {
+			parents[level] = id
+			continue
+		}
+		parents[level] = ""
+		for _, p := range parents[:level] {
+			if p != "" {
+				res[id] = append(res[id], p)
+			}
+		}
+	}
+	return res
+}
+
 // ParseMarkdown parses a certification document and returns the found
 // requirements.
 func ParseMarkdown(f string) ([]string, error) {
+	reqType := docReqType(f)
 	var (
 		reqs []string
 
@@ -27,14 +95,14 @@ func ParseMarkdown(f string) ([]string, error) {
 		reqBuf           bytes.Buffer
 	)
 
-	r, err := os.Open(f)
+	r, err := openCertdoc(f)
 	if err != nil {
 		return nil, err
 	}
-	scan := bufio.NewScanner(r)
+	scan := newLineReader(r)
 
 	for lno := 1; scan.Scan(); lno++ {
-		line := scan.Text()
+		line := toUTF8(scan.Text())
 
 		var level int
 		parts := reATXHeading.FindStringSubmatch(line)
@@ -44,9 +112,10 @@ func ParseMarkdown(f string) ([]string, error) {
 			title := parts[3]
 			reqIDs := ReReqID.FindAllString(title, -1)
 			if len(reqIDs) > 1 {
-				return nil, fmt.Errorf("malformed requirement title: too many IDs on line %d: %q", lno, line)
+				return nil, newFinding("malformed_requirement", "malformed requirement title: too many IDs on line %d: %q", lno, line)
 			}
-			headingHasReqID := len(reqIDs) == 1
+			// The headings of the parents are not requirements of the document.
+			headingHasReqID := len(reqIDs) == 1 && !isParentHeading(reqIDs[0], reqType)
 			// Figure out what to do with this heading.
 			end := false
 			start := false
@@ -56,7 +125,7 @@ func ParseMarkdown(f string) ([]string, error) {
 					// This is a requirement heading.
 					// The level must be the same as the current requirement.
 					if level != reqLevel {
-						return nil, fmt.Errorf("requirement heading on line %d must be at same level as requirement heading on line %d (%d != %d): %q", lno, reqLine, level, reqLevel, line)
+						return nil, newFinding("document_structure", "requirement heading on line %d must be at same level as requirement heading on line %d (%d != %d): %q", lno, reqLine, level, reqLevel, line)
 					}
 					// Besides starting a requirement, this heading also ends the current one.
 					end = true
@@ -67,7 +136,7 @@ func ParseMarkdown(f string) ([]string, error) {
 					// requirement's heading level. We don't want to mix requirements
 					// with other headings of the same level, in the same section.
 					if level == reqLevel {
-						return nil, fmt.Errorf("non-requirement heading on line %d at same level as requirement heading on line %d (%d): %q", lno, reqLine, level, line)
+						return nil, newFinding("document_structure", "non-requirement heading on line %d at same level as requirement heading on line %d (%d): %q", lno, reqLine, level, line)
 					}
 					if level < reqLevel {
 						// Higher-level heading.
@@ -81,7 +150,7 @@ func ParseMarkdown(f string) ([]string, error) {
 				if headingHasReqID {
 					// Can be the first one or the first one in another section.
 					if level == lastHeadingLevel {
-						return nil, fmt.Errorf("requirement heading on line %d at same level as previous heading on line %d (%d): %q", lno, lastHeadingLine, level, line)
+						return nil, newFinding("document_structure", "requirement heading on line %d at same level as previous heading on line %d (%d): %q", lno, lastHeadingLine, level, line)
 					}
 					start = true
 				} else {
@@ -123,3 +192,194 @@ func ParseMarkdown(f string) ([]string, error) {
 
 	return reqs, nil
 }
+
+// LinkifyMarkdown writes the content of the Markdown certification document f
+// to w, adding a named destination to each requirement heading and turning
+// the references to other requirements into links, the same way ParseLyx does
+// for .lyx files. Pandoc turns the heading identifiers into hypertargets
+// when converting the result to PDF.
+func LinkifyMarkdown(f string, w io.Writer) error {
+	r, err := os.Open(f)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	pathInRepo, err := git.PathInRepo(f)
+	if err != nil {
+		return fmt.Errorf("File %s not found in repo.", f)
+	}
+	// The linkified document is not parsed when kept next to its source.
+	if _, err := io.WriteString(w, "<!-- "+generatedMarker+" from "+path.Base(f)+" -->\n"); err != nil {
+		return err
+	}
+	repo, err := git.RepoName()
+	if err != nil {
+		return err
+	}
+	return linkifyMarkdown(r, w, repo, path.Dir(pathInRepo))
+}
+
+func linkifyMarkdown(r io.Reader, w io.Writer, repo, dirInRepo string) error {
+	scan := newLineReader(r)
+	for lno := 1; scan.Scan(); lno++ {
+		line := scan.Text()
+		parts := reATXHeading.FindStringSubmatch(line)
+		if parts != nil {
+			if reqID := ReReqID.FindString(parts[3]); reqID != "" && !strings.HasSuffix(strings.TrimSpace(line), "}") {
+				// The heading defines a requirement, give it a named destination.
+				line = reATXClosing.ReplaceAllString(line, "") + " {#" + reqID + "}"
+			}
+		} else {
+			var err error
+			if line, err = linkifyMarkdownLine(line, repo, dirInRepo); err != nil {
+				return newFinding("malformed_requirement", "malformed requirement: cannot linkify ID on line %d: %q because: %s", lno, line, err)
+			}
+		}
+		ending := "\n"
+		if *fPreserve {
+			ending = scan.Ending()
+		}
+		if _, err := io.WriteString(w, line+ending); err != nil {
+			return err
+		}
+	}
+	return scan.Err()
+}
+
+// linkifyMarkdownLine replaces the requirement IDs in s with Markdown links to
+// their definitions. IDs which are already part of a link or an HTML
+// attribute are left untouched.
+func linkifyMarkdownLine(s, repo, dirInRepo string) (string, error) {
+	var res bytes.Buffer
+	parsedTo := 0
+	for _, ids := range ReReqID.FindAllStringIndex(s, -1) {
+		if ids[0] > 0 && strings.ContainsRune(`/#"'[>`, rune(s[ids[0]-1])) {
+			continue
+		}
+		reqID := s[ids[0]:ids[1]]
+		url, err := reqURL(reqID, repo, dirInRepo)
+		if err != nil {
+			return "", err
+		}
+		res.WriteString(s[parsedTo:ids[0]])
+		fmt.Fprintf(&res, "[%s](%s)", reqID, url)
+		parsedTo = ids[1]
+	}
+	res.WriteString(s[parsedTo:])
+	return res.String(), nil
+}
+
+// SetMarkdownAttributes rewrites the Markdown certification document f,
+// setting attributes of its requirements. changes maps requirement IDs to
+// attribute names to the new values. Attributes set to the empty string are
+// removed, missing attributes are appended to the attributes of the
+// requirement. The rest of the document is left untouched.
+func SetMarkdownAttributes(f string, changes map[string]map[string]string) error {
+	b, err := ioutil.ReadFile(f)
+	if err != nil {
+		return err
+	}
+	lines := strings.SplitAfter(string(b), "\n")
+	eol := "\n"
+	if strings.Contains(string(b), "\r\n") {
+		eol = "\r\n"
+	}
+
+	var (
+		out      []string
+		reqID    string // The requirement being parsed.
+		reqLevel int
+		inAttrs  bool                // Whether in the attributes section of the requirement.
+		inAttr   bool                // Whether in the value of an attribute being replaced.
+		done     map[string]bool     // The attributes of the requirement already set.
+		found    = map[string]bool{} // The requirements found, by ID.
+		hasAttrs = map[string]bool{} // The requirements having an attributes section, by ID.
+	)
+	// flush appends the attributes which have not been found in the
+	// attributes section of the current requirement.
+	flush := func() {
+		if reqID == "" || !inAttrs {
+			return
+		}
+		var keys []string
+		for k := range changes[reqID] {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if v := changes[reqID][k]; !done[normalizeAttribute(k)] && v != "" {
+				out = append(out, fmt.Sprintf("- %s: %s%s", k, v, eol))
+			}
+		}
+		inAttrs = false
+	}
+	for _, line := range lines {
+		text := strings.TrimRight(line, "\r\n")
+		if parts := reATXHeading.FindStringSubmatch(text); parts != nil {
+			level := len(parts[1])
+			if id := ReReqID.FindString(parts[3]); id != "" || level <= reqLevel {
+				inAttr = false
+				flush()
+				reqID, reqLevel = id, level
+				if _, ok := changes[id]; ok {
+					found[id] = true
+				}
+				done = map[string]bool{}
+				if id == "" {
+					reqLevel = 0
+				}
+			} else if reqID != "" && strings.TrimSpace(parts[0]) == "###### Attributes:" {
+				inAttrs = true
+				hasAttrs[reqID] = true
+				out = append(out, line)
+				continue
+			}
+		} else if inAttrs {
+			if m := keywordsRegexp().FindStringSubmatchIndex(text); m != nil && m[0] == 0 {
+				key := normalizeAttribute(text[m[4]:m[5]])
+				inAttr = false
+				for k, v := range changes[reqID] {
+					if normalizeAttribute(k) == key {
+						done[key] = true
+						inAttr = true
+						if v != "" {
+							out = append(out, text[:m[1]]+" "+v+eol)
+						}
+					}
+				}
+				if inAttr {
+					continue
+				}
+			} else if strings.TrimSpace(text) == "" {
+				inAttr = false
+				flush()
+			} else if inAttr {
+				// Continuation of a replaced attribute value.
+				continue
+			}
+		}
+		out = append(out, line)
+	}
+	flush()
+
+	for id := range changes {
+		if !found[id] {
+			return fmt.Errorf("Requirement %s not found in %s", id, f)
+		}
+		if !hasAttrs[id] {
+			return fmt.Errorf("Requirement %s in %s has no attributes section", id, f)
+		}
+	}
+	return ioutil.WriteFile(f, []byte(strings.Join(out, "")), 0644)
+}
+
+// normalizeAttribute returns the key under which the named attribute is
+// stored in Req.Attributes.
+func normalizeAttribute(name string) string {
+	key := strings.ToUpper(strings.TrimSpace(name))
+	if key == "PARENT" {
+		key = "PARENTS"
+	}
+	return key
+}
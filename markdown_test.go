@@ -1,11 +1,18 @@
 package main
 
 import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"reflect"
+	"sort"
+	"strings"
 	"testing"
 
+	"github.com/daedaleanai/reqtraq/git"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -46,6 +53,112 @@ REQ-0-TEST-SYS-002
 		"requirement heading on line 3 at same level as previous heading on line 2 (1):")
 }
 
+// TestParseMarkdownCRLF checks that documents with Windows line endings are
+// parsed the same way.
+func TestParseMarkdownCRLF(t *testing.T) {
+	checkParse(t, "# Title\r\n#### REQ-0-TEST-SYS-005\r\nContent\r\n#### REQ-0-TEST-SYS-006 Title\r\n",
+		"",
+		"REQ-0-TEST-SYS-005\nContent\n",
+		"REQ-0-TEST-SYS-006 Title\n")
+}
+
+func TestParseMarkdown_Encrypted(t *testing.T) {
+	defer func(c *RepoConfig) { repoConfig = c }(repoConfig)
+	f := filepath.Join(t.TempDir(), "0-TEST-100-ORD.md")
+	doc := "-----BEGIN AGE ENCRYPTED FILE-----\n# Title\n#### REQ-0-TEST-SYS-001 Speed\nContent\n"
+	if err := ioutil.WriteFile(f, []byte(doc), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	repoConfig = &RepoConfig{Version: repoConfigVersion}
+	_, err := ParseMarkdown(f)
+	assert.EqualError(t, err, f+" is encrypted, set the decrypt command in reqtraq.yaml")
+
+	// Drops the header, as if decrypting.
+	repoConfig.Decrypt = "sed 1d"
+	reqs, err := ParseMarkdown(f)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"REQ-0-TEST-SYS-001 Speed\nContent\n"}, reqs)
+
+	repoConfig.Decrypt = "echo bad key >&2; exit 1"
+	_, err = ParseMarkdown(f)
+	assert.EqualError(t, err, "Failed to decrypt "+f+": exit status 1: bad key")
+}
+
+// TestLinkifyMarkdown checks that requirement headings get named destinations
+// and references get linked to their definitions.
+func TestLinkifyMarkdown(t *testing.T) {
+	var out bytes.Buffer
+	err := linkifyMarkdown(strings.NewReader(`# Title
+### REQ-0-TEST-SWH-001 Title ##
+### REQ-0-TEST-SWH-002 Parse C#
+### REQ-0-TEST-SWH-003 Fix issue # ##  
+Mentions REQ-0-TEST-SYS-002 and <a href="https://a/REQ-0-TEST-SYS-003">REQ-0-TEST-SYS-003</a>
+- Parents: REQ-0-TEST-SYS-001
+`), &out, "repo", "certdocs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, `# Title
+### REQ-0-TEST-SWH-001 Title {#REQ-0-TEST-SWH-001}
+### REQ-0-TEST-SWH-002 Parse C# {#REQ-0-TEST-SWH-002}
+### REQ-0-TEST-SWH-003 Fix issue # {#REQ-0-TEST-SWH-003}
+Mentions [REQ-0-TEST-SYS-002](http://a.daedalean.ai/docs/repo/certdocs/0-TEST-100-ORD.pdf#REQ-0-TEST-SYS-002) and <a href="https://a/REQ-0-TEST-SYS-003">REQ-0-TEST-SYS-003</a>
+- Parents: [REQ-0-TEST-SYS-001](http://a.daedalean.ai/docs/repo/certdocs/0-TEST-100-ORD.pdf#REQ-0-TEST-SYS-001)
+`, out.String())
+}
+
+func TestSetMarkdownAttributes(t *testing.T) {
+	f, err := createTempFile(`# Title
+### REQ-0-TEST-SWH-001 First
+Body
+###### Attributes:
+- Rationale: Spanning
+two lines.
+- Parents: REQ-0-TEST-SYS-001
+- Verification: Test
+
+### REQ-0-TEST-SWH-002 Second
+###### Attributes:
+- Safety Impact: None
+## Appendix
+`, "TestSetMarkdownAttributes")
+	if f != nil {
+		defer os.Remove(f.Name())
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = SetMarkdownAttributes(f.Name(), map[string]map[string]string{
+		"REQ-0-TEST-SWH-001": {"RATIONALE": "Single line.", "Verification": "", "Safety Impact": "High"},
+		"REQ-0-TEST-SWH-002": {"Verification": "Demonstration"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := ioutil.ReadFile(f.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, `# Title
+### REQ-0-TEST-SWH-001 First
+Body
+###### Attributes:
+- Rationale: Single line.
+- Parents: REQ-0-TEST-SYS-001
+- Safety Impact: High
+
+### REQ-0-TEST-SWH-002 Second
+###### Attributes:
+- Safety Impact: None
+- Verification: Demonstration
+## Appendix
+`, string(b))
+
+	err = SetMarkdownAttributes(f.Name(), map[string]map[string]string{"REQ-0-TEST-SWH-003": {"Verification": "Test"}})
+	assert.EqualError(t, err, "Requirement REQ-0-TEST-SWH-003 not found in "+f.Name())
+}
+
 func checkParse(t *testing.T, content, expectedError string, expectedReqs ...string) {
 	f, err := createTempFile(content, "checkParse")
 	if f != nil {
@@ -86,3 +199,421 @@ func createTempFile(content, prefix string) (*os.File, error) {
 	}
 	return f, nil
 }
+
+func TestLinkifyMarkdownOffline(t *testing.T) {
+	*fOffline = true
+	defer func() { *fOffline = false }()
+	var w bytes.Buffer
+	err := linkifyMarkdown(strings.NewReader("See REQ-0-DDLN-SYS-006.\n"), &w, "repo", "certdocs")
+	assert.NoError(t, err)
+	assert.Equal(t, "See [REQ-0-DDLN-SYS-006](0-DDLN-100-ORD.pdf#REQ-0-DDLN-SYS-006).\n", w.String())
+}
+
+func TestSetMarkdownAttributesCRLF(t *testing.T) {
+	f, err := createTempFile("### REQ-0-TEST-SWH-001 First\r\n###### Attributes:\r\n- Rationale: Old\r\n\r\n",
+		"TestSetMarkdownAttributesCRLF")
+	if f != nil {
+		defer os.Remove(f.Name())
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = SetMarkdownAttributes(f.Name(), map[string]map[string]string{
+		"REQ-0-TEST-SWH-001": {"Rationale": "New", "Verification": "Test"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := ioutil.ReadFile(f.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "### REQ-0-TEST-SWH-001 First\r\n###### Attributes:\r\n- Rationale: New\r\n- Verification: Test\r\n\r\n", string(b))
+}
+
+func FuzzParseMarkdown(f *testing.F) {
+	for _, seed := range []string{"certdocs/0-DDLN-211-SRD.md", "testdata/valid_system_requirement/123-TEST-100-ORD.md"} {
+		if b, err := os.ReadFile(seed); err == nil {
+			f.Add(string(b))
+		}
+	}
+	f.Add("# Title\n#### REQ-0-TEST-SYS-005\n##### Heading\n#### REQ-0-TEST-SYS-006\nBody\n###### Attributes:\n- Rationale: R\n")
+	f.Add("  # REQ-0-0-SYS-0SAfetY impACt:")
+	f.Fuzz(func(t *testing.T, contents string) {
+		file := filepath.Join(t.TempDir(), "0-TEST-100-ORD.md")
+		if err := os.WriteFile(file, []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+		reqs, err := ParseMarkdown(file)
+		if err != nil {
+			return
+		}
+		for _, r := range reqs {
+			// The requirements may be malformed, but must not crash the parser.
+			_, _ = ParseReq(r)
+		}
+		var out bytes.Buffer
+		_ = linkifyMarkdown(strings.NewReader(contents), &out, "repo", "certdocs")
+	})
+}
+
+func TestParseReq_MarkdownAttributes(t *testing.T) {
+	r, err := ParseReq("REQ-0-TEST-SYS-001 Title\n\nThe mode: normal.\n\n###### Attributes:\n- Mode: Flight\n- Rationale: R\n")
+	if assert.Nil(t, err) {
+		assert.Equal(t, map[string]string{"MODE": "Flight", "RATIONALE": "R"}, r.Attributes)
+	}
+	_, err = ParseReq("REQ-0-TEST-SYS-001 Title\n\nRationale: R\n")
+	assert.EqualError(t, err, "requirement REQ-0-TEST-SYS-001 contains no '###### Attributes:' heading")
+	_, err = ParseReq("REQ-0-TEST-SYS-001 Title\n\nRationale: R\n\n###### Attributes:\n")
+	assert.EqualError(t, err, "requirement REQ-0-TEST-SYS-001 contains no attributes")
+}
+
+func TestParseReq_KeywordsMidSentence(t *testing.T) {
+	// Only the keywords at the start of a line begin an attribute.
+	r, err := ParseReq("REQ-0-TEST-SYS-001 Title\n\nThe body.\n\n###### Attributes:\n" +
+		"- Rationale: Keeping the mode: normal is safer.\n- Verification: Test\n")
+	if assert.Nil(t, err) {
+		assert.Equal(t, map[string]string{"RATIONALE": "Keeping the mode: normal is safer.", "VERIFICATION": "Test"}, r.Attributes)
+	}
+	r, err = ParseReq("\nREQ-0-TEST-SYS-001 Title\nThe mode: normal shall be kept.\nRationale: R\n")
+	if assert.Nil(t, err) {
+		assert.Equal(t, map[string]string{"RATIONALE": "R"}, r.Attributes)
+		assert.Contains(t, string(r.Body), "The mode: normal shall be kept.")
+	}
+}
+
+func TestDocSections_Markdown(t *testing.T) {
+	sections := docSections([]byte(`# Software Requirements Document
+
+## Introduction
+
+## Requirements
+
+### Functional
+
+#### REQ-0-TEST-SWH-001. Speed
+
+###### Attributes:
+
+#### REQ-0-TEST-SWH-002. Display
+
+### Performance
+
+##### REQ-0-TEST-SWH-003. Latency
+`))
+	assert.Equal(t, map[string]docSection{
+		"REQ-0-TEST-SWH-001": {Path: "Requirements > Functional", Number: "2.1"},
+		"REQ-0-TEST-SWH-002": {Path: "Requirements > Functional", Number: "2.1"},
+		"REQ-0-TEST-SWH-003": {Path: "Requirements > Performance", Number: "2.2"},
+	}, sections)
+}
+
+func TestParseMarkdown_InferParents(t *testing.T) {
+	defer func(c *RepoConfig) { repoConfig = c }(repoConfig)
+	c := *repoConfig
+	c.InferParents = true
+	repoConfig = &c
+	f := filepath.Join(t.TempDir(), "0-TEST-212-SDD.md")
+	if err := os.WriteFile(f, []byte(`# Software Design Document
+
+## REQ-0-TEST-SWH-001 Navigation
+
+### REQ-0-TEST-SWL-001 Position
+
+The position shall be computed.
+
+###### Attributes:
+- Rationale: R
+- Verification: Test
+- Safety Impact: None
+
+## Other
+
+### REQ-0-TEST-SWL-002 Speed
+
+The speed shall be computed.
+
+###### Attributes:
+- Parents: REQ-0-TEST-SWH-002
+- Rationale: R
+- Verification: Test
+- Safety Impact: None
+`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	reqs, err := ParseMarkdown(f)
+	if assert.NoError(t, err) {
+		assert.Len(t, reqs, 2)
+	}
+	rg := reqGraph{}
+	assert.Empty(t, parseCertdocToGraph(f, rg))
+	if assert.Contains(t, rg, "REQ-0-TEST-SWL-001") {
+		assert.Equal(t, []string{"REQ-0-TEST-SWH-001"}, rg["REQ-0-TEST-SWL-001"].ParentIds)
+	}
+	if assert.Contains(t, rg, "REQ-0-TEST-SWL-002") {
+		assert.Equal(t, []string{"REQ-0-TEST-SWH-002"}, rg["REQ-0-TEST-SWL-002"].ParentIds)
+	}
+	assert.NotContains(t, rg, "REQ-0-TEST-SWH-001")
+}
+
+func TestDocPragmas_Markdown(t *testing.T) {
+	pragmas, errs := docPragmas("0-TEST-211-SRD.md", strings.Split(`# Software Requirements Document
+
+<!-- reqtraq:ignore missing-attribute RATIONALE -->
+<!-- reqtraq:ignore owner -->
+## REQ-0-TEST-SWH-001 Speed
+
+The speed shall be displayed.
+
+<!-- reqtraq:ignore missing_parent -->
+
+## REQ-0-TEST-SWH-002 Altitude
+
+<!-- reqtraq:ignore everything -->
+<!-- reqtraq:ignore owner -->
+`, "\n"))
+	assert.Equal(t, map[string][]Suppression{
+		"REQ-0-TEST-SWH-001": {{Check: "missing-attribute", Arg: "RATIONALE", Line: 3}, {Check: "owner", Line: 4}},
+		"REQ-0-TEST-SWH-002": {{Check: "missing_parent", Line: 9}},
+	}, pragmas)
+	assert.Equal(t, []error{
+		fmt.Errorf(`Unknown check "everything" in the reqtraq:ignore pragma on line 13 of 0-TEST-211-SRD.md`),
+		fmt.Errorf("The reqtraq:ignore pragma on line 14 of 0-TEST-211-SRD.md is followed by no requirement"),
+	}, errs)
+}
+
+func TestFormatMarkdownCertdoc(t *testing.T) {
+	defer func(c *RepoConfig) { repoConfig = c }(repoConfig)
+	repoConfig = &RepoConfig{Version: repoConfigVersion, Attributes: []AttributeSpec{
+		{Name: "Safety Impact", Type: "enum", Values: []string{"None", "Minor", "Major"}, Normalize: true},
+		{Name: "DAL"},
+	}}
+	doc := "# SRD\r\n\r\n## REQ-0-TEST-SWH-001 Speed\r\n\r\nRATIONALE: not an attribute.\r\n\r\n###### Attributes:\r\n" +
+		"- RATIONALE: The speed\r\n  is needed.\r\n- dal: C\r\n- safety impact:  none.\r\n- VERIFICATION: Test\r\n\r\n# Other\r\n\r\n- Rationale: kept\r\n"
+	assert.Equal(t, "# SRD\r\n\r\n## REQ-0-TEST-SWH-001 Speed\r\n\r\nRATIONALE: not an attribute.\r\n\r\n###### Attributes:\r\n"+
+		"- Rationale: The speed\r\n  is needed.\r\n- Safety Impact: None\r\n- DAL: C\r\n- Verification: Test\r\n\r\n# Other\r\n\r\n- Rationale: kept\r\n",
+		string(FormatMarkdownCertdoc("0-TEST-211-SRD.md", []byte(doc), repoConfig.AttributeMaps())))
+	assert.Equal(t, "# SRD\r\n\r\n## REQ-0-TEST-SWH-001 Speed\r\n\r\nRATIONALE: not an attribute.\r\n\r\n###### Attributes:\r\n"+
+		"- Rationale: The speed\r\n  is needed.\r\n- DAL: C\r\n- Safety Impact: None\r\n- Verification: Test\r\n\r\n# Other\r\n\r\n- Rationale: kept\r\n",
+		string(FormatMarkdownCertdoc("0-TEST-211-SRD.md", []byte(doc), nil)), "without specification")
+
+	f := filepath.Join(t.TempDir(), "0-TEST-211-SRD.md")
+	if err := os.WriteFile(f, []byte(strings.ReplaceAll(doc, "\r\n", "\n")), 0644); err != nil {
+		t.Fatal(err)
+	}
+	rg := reqGraph{}
+	assert.Empty(t, parseCertdocToGraph(f, rg))
+	if assert.Contains(t, rg, "REQ-0-TEST-SWH-001") {
+		assert.Equal(t, map[string]string{"RATIONALE": "The speed\n  is needed.", "SAFETY IMPACT": "None", "DAL": "C", "VERIFICATION": "Test"},
+			rg["REQ-0-TEST-SWH-001"].Attributes)
+	}
+	as := repoConfig.AttributeMaps()
+	changed, err := FormatCertdocs([]string{f}, nil, as, true)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{f}, changed)
+	changed, err = FormatCertdocs([]string{f}, nil, as, false)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{f}, changed)
+	changed, err = FormatCertdocs([]string{f}, nil, as, true)
+	assert.Nil(t, err)
+	assert.Empty(t, changed)
+}
+
+func TestFormatCertdocs_Repo(t *testing.T) {
+	repoPath, err := git.RepoPath()
+	if !assert.Nil(t, err) {
+		return
+	}
+	reportConf, err := ReadJsonConf(filepath.Join(repoPath, "certdocs", "attributes.json"))
+	if !assert.Nil(t, err) {
+		return
+	}
+	fileNames, err := filepath.Glob(filepath.Join(repoPath, "certdocs", "*.md"))
+	assert.Nil(t, err)
+	assert.Len(t, fileNames, 3)
+	configs, err := loadConfigTree(repoPath, "certdocs")
+	assert.Nil(t, err)
+	changed, err := FormatCertdocs(fileNames, configs, reportConf.Attributes, true)
+	assert.Nil(t, err)
+	assert.Empty(t, changed, "fmt --check passes")
+}
+
+func TestFormatMarkdownCertdoc_Layout(t *testing.T) {
+	doc := `# SRD
+
+
+## Functional
+#### REQ-0-TEST-SWH-001 Speed
+The speed shall be shown.
+##### Notes
+
+` + "```" + `
+# not a heading
+
+
+` + "```" + `
+###### Attributes:
+
+- Verification: Test
+- Parents: REQ-0-TEST-SYS-001
+#### REQ-0-TEST-SWH-002 Altitude
+
+###### Attributes:
+- Parents: REQ-0-TEST-SYS-001
+
+
+`
+	assert.Equal(t, `# SRD
+
+## Functional
+
+### REQ-0-TEST-SWH-001 Speed
+
+The speed shall be shown.
+
+#### Notes
+
+`+"```"+`
+# not a heading
+
+
+`+"```"+`
+
+###### Attributes:
+- Parents: REQ-0-TEST-SYS-001
+- Verification: Test
+
+### REQ-0-TEST-SWH-002 Altitude
+
+###### Attributes:
+- Parents: REQ-0-TEST-SYS-001
+`, string(FormatMarkdownCertdoc("0-TEST-211-SRD.md", []byte(doc), []map[string]string{{"name": "Parents"}, {"name": "Verification"}})))
+}
+
+func TestNewDocument(t *testing.T) {
+	dir := t.TempDir()
+	fileName, err := NewDocument(dir, "SDD", "0-TEST")
+	assert.Nil(t, err)
+	assert.Equal(t, filepath.Join(dir, "0-TEST-212-SDD.md"), fileName)
+	assert.Nil(t, IsValidDocName(fileName))
+
+	contents, err := ioutil.ReadFile(fileName)
+	assert.Nil(t, err)
+	doc := ParseDocument(fileName, contents)
+	assert.Equal(t, "Software Design Description for Reqtraq", doc.Title)
+	assert.Equal(t, "1", doc.Revision)
+	assert.Equal(t, 3, len(doc.Approvers))
+	assert.Contains(t, string(contents), "**0-TEST-211-SRD** Software Requirements Document\n")
+	assert.Contains(t, string(contents), "### Functional Requirements\n")
+	reqs, err := ParseMarkdown(fileName)
+	assert.Nil(t, err)
+	assert.Empty(t, reqs)
+	id, err := NextId(fileName)
+	assert.Nil(t, err)
+	assert.Equal(t, "REQ-0-TEST-SWL-001", id)
+	assert.Equal(t, string(contents), string(FormatMarkdownCertdoc(fileName, contents, nil)), "in the canonical form")
+
+	_, err = NewDocument(dir, "SDD", "0-TEST")
+	assert.EqualError(t, err, fileName+" exists already")
+	fileName, err = NewDocument(dir, "PSAC", "0-TEST")
+	assert.Nil(t, err)
+	contents, err = ioutil.ReadFile(fileName)
+	assert.Nil(t, err)
+	assert.NotContains(t, string(contents), "Requirements\n")
+
+	_, err = NewDocument(dir, "XYZ", "0-TEST")
+	assert.Error(t, err)
+	_, err = NewDocument(dir, "SDD", "TEST")
+	assert.EqualError(t, err, "Invalid project: 'TEST'. Must be e.g. 0-DDLN")
+}
+
+func TestLinkifyDocuments(t *testing.T) {
+	out := t.TempDir()
+	missing := filepath.Join(out, "0-TEST-211-SRD.md")
+	assert.Nil(t, ioutil.WriteFile(missing, []byte("# SRD\n"), 0644))
+	docs := []string{"certdocs/0-DDLN-100-ORD.md", "certdocs/0-DDLN-211-SRD.md", missing, "certdocs/0-DDLN-212-SDD.md"}
+	err := LinkifyDocuments(docs, out, 2, nil)
+	assert.EqualError(t, err, "1 of 4 documents could not be linkified:\n"+
+		strings.TrimPrefix(missing, "/")+": File "+missing+" not found in repo.")
+	for _, doc := range docs[:2] {
+		contents, err := ioutil.ReadFile(filepath.Join(out, filepath.Base(doc)))
+		assert.Nil(t, err)
+		assert.Contains(t, string(contents), generatedMarker)
+	}
+	assert.Nil(t, LinkifyDocuments(docs[:1], out, 0, nil))
+}
+
+// testPDF returns a PDF with the given named destinations, pointing to its
+// page or, when false, to an annotation, in an object stream when compressed.
+func testPDF(dests map[string]bool, compressed bool) []byte {
+	var names []string
+	for name := range dests {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var tree bytes.Buffer
+	tree.WriteString("<</Names[")
+	for _, name := range names {
+		if dests[name] {
+			fmt.Fprintf(&tree, "(%s)[3 0 R/XYZ 0 0 null]", name)
+		} else {
+			fmt.Fprintf(&tree, "(%s)<</D[4 0 R/Fit]>>", name)
+		}
+	}
+	tree.WriteString("]>>")
+	pdf := "%PDF-1.5\n%\xe2\xe3\n" +
+		"1 0 obj\n<</Type/Catalog/Pages 2 0 R/Names<</Dests 5 0 R>>>>\nendobj\n" +
+		"2 0 obj\n<</Type/Pages/Kids[3 0 R]/Count 1>>\nendobj\n" +
+		"3 0 obj\n<</Type/Page/Parent 2 0 R/MediaBox[0 0 612 792]>>\nendobj\n" +
+		"4 0 obj\n<</Type/Annot/Subtype/Text>>\nendobj\n" +
+		"5 0 obj\n<</Kids[6 0 R]>>\nendobj\n"
+	if !compressed {
+		return []byte(pdf + "6 0 obj\n" + tree.String() + "\nendobj\n%%EOF\n")
+	}
+	var stream bytes.Buffer
+	z := zlib.NewWriter(&stream)
+	z.Write([]byte("6 0 " + tree.String()))
+	z.Close()
+	return []byte(pdf + fmt.Sprintf("7 0 obj\n<</Type/ObjStm/N 1/First 4/Filter/FlateDecode/Length %d>>stream\n", stream.Len()) +
+		stream.String() + "\nendstream\nendobj\n%%EOF\n")
+}
+
+func TestPdfDestinations(t *testing.T) {
+	dests := map[string]bool{"REQ-0-TEST-SWH-001": true, "REQ-0-TEST-SWH-002": false, "section*.1": true}
+	assert.Equal(t, dests, pdfDestinations(testPDF(dests, false)))
+	assert.Equal(t, dests, pdfDestinations(testPDF(dests, true)))
+	assert.Equal(t, map[string]bool{"a(b)": true, "\u00e9": true, "x": true}, pdfDestinations([]byte(
+		"1 0 obj <</Type /Catalog /Dests 2 0 R>> endobj\n"+
+			"2 0 obj <</x [3 0 R /Fit] /a#28b#29 [3 0 R]>> endobj\n"+
+			"3 0 obj <</Type/Page>> endobj\n"+
+			"4 0 obj <</Type/Catalog/Names<</Dests<</Names[<FEFF00E9> [3 0 R]]>>>>>> endobj\n")))
+}
+
+func TestPublish(t *testing.T) {
+	out := t.TempDir()
+	fixture := filepath.Join(out, "fixture.pdf")
+	t.Setenv("FIXTURE", fixture)
+	commands := map[string]string{"md": `cp "$FIXTURE" "$OUTPUT"`}
+	// Only the ORD is published, with a broken destination and one missing.
+	assert.Nil(t, ioutil.WriteFile(fixture, testPDF(map[string]bool{
+		"REQ-0-DDLN-SYS-001": true, "REQ-0-DDLN-SYS-002": true, "REQ-0-DDLN-SYS-003": true,
+		"REQ-0-DDLN-SYS-004": false, "REQ-0-DDLN-SYS-005": true}, true), 0644))
+	missing, err := Publish([]string{"certdocs/0-DDLN-100-ORD.md"}, out, commands, 2, nil)
+	assert.Nil(t, err)
+	pdf := strings.TrimPrefix(filepath.Join(out, "0-DDLN-100-ORD.pdf"), "/")
+	assert.Equal(t, []error{
+		newFinding("anchor", "Requirement 'REQ-0-DDLN-SYS-004' has a named destination in %s not pointing to a page.\n", pdf),
+		newFinding("anchor", "Requirement 'REQ-0-DDLN-SYS-006' has no named destination in %s.\n", pdf),
+	}, missing)
+
+	missing, err = CheckAnchors([]string{"certdocs/0-DDLN-211-SRD.md"}, out)
+	assert.Nil(t, err)
+	assert.Contains(t, missing, newFinding("anchor", "Requirement 'REQ-0-DDLN-SWH-001' has no named destination in %s, which was not published.\n",
+		strings.TrimPrefix(filepath.Join(out, "0-DDLN-211-SRD.pdf"), "/")))
+
+	commands["md"] = "exit 1"
+	_, err = Publish([]string{"certdocs/0-DDLN-100-ORD.md", "certdocs/0-DDLN-211-SRD.md"}, out, commands, 2, nil)
+	assert.EqualError(t, err, "2 of 2 documents could not be published:\n"+
+		"certdocs/0-DDLN-100-ORD.md: exit 1: exit status 1\n"+
+		"certdocs/0-DDLN-211-SRD.md: exit 1: exit status 1")
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+)
+
+// MarkdownDoc is a Markdown certification document being edited, e.g. by
+// import. The lines which are not edited are written back byte for byte as
+// they were read, so the changes made by the tool produce minimal diffs.
+type MarkdownDoc struct {
+	lines []string // With their line endings.
+	eol   string   // The line ending of the added lines.
+}
+
+// NewMarkdownDoc returns a new document with the given title and an empty
+// requirements section.
+func NewMarkdownDoc(title string) *MarkdownDoc {
+	d := &MarkdownDoc{eol: "\n"}
+	d.insert(0, fmt.Sprintf("# %s\n\n## Requirements\n\n", title))
+	return d
+}
+
+// ReadMarkdownDoc reads the given Markdown document for editing. The added
+// lines use the line endings of the document.
+func ReadMarkdownDoc(fileName string) (*MarkdownDoc, error) {
+	b, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+	d := &MarkdownDoc{eol: "\n"}
+	if len(b) > 0 {
+		d.lines = strings.SplitAfter(string(b), "\n")
+		if d.lines[len(d.lines)-1] == "" {
+			d.lines = d.lines[:len(d.lines)-1]
+		}
+	}
+	if strings.Contains(string(b), "\r\n") {
+		d.eol = "\r\n"
+	}
+	return d, nil
+}
+
+// Bytes returns the contents of the document.
+func (d *MarkdownDoc) Bytes() []byte { return []byte(strings.Join(d.lines, "")) }
+
+// WriteFile writes the document to the given file.
+func (d *MarkdownDoc) WriteFile(fileName string) error {
+	return ioutil.WriteFile(fileName, d.Bytes(), 0644)
+}
+
+// reqRange returns the lines of the given requirement as [first, last) indexes.
+func (d *MarkdownDoc) reqRange(id string) (int, int, bool) {
+	texts := make([]string, len(d.lines))
+	for i, l := range d.lines {
+		texts[i] = strings.TrimRight(l, "\r\n")
+	}
+	r, ok := reqLineRanges(".md", texts)[id]
+	return r[0], r[1], ok
+}
+
+// insert inserts the given text at line i, with the line endings of the document.
+func (d *MarkdownDoc) insert(i int, text string) {
+	if text == "" {
+		return
+	}
+	if i > 0 && !strings.HasSuffix(d.lines[i-1], "\n") {
+		// The last line of a document not ending with a newline.
+		d.lines[i-1] += d.eol
+	}
+	lines := strings.SplitAfter(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	for j, l := range lines {
+		if strings.HasSuffix(l, "\n") {
+			lines[j] = strings.TrimSuffix(l, "\n") + d.eol
+		}
+	}
+	d.lines = append(d.lines[:i], append(lines, d.lines[i:]...)...)
+}
+
+// Req returns the text of the given requirement, from its heading up to the
+// next heading of the same or a higher level.
+func (d *MarkdownDoc) Req(id string) (string, bool) {
+	first, last, ok := d.reqRange(id)
+	if !ok {
+		return "", false
+	}
+	return strings.Join(d.lines[first:last], ""), true
+}
+
+// AppendReq appends the text of a requirement, see FormatMarkdownReq, after
+// the last requirement of the document, or at its end when it has none.
+func (d *MarkdownDoc) AppendReq(text string) {
+	i := len(d.lines)
+	texts := make([]string, len(d.lines))
+	for j, l := range d.lines {
+		texts[j] = strings.TrimRight(l, "\r\n")
+	}
+	last := -1
+	for _, r := range reqLineRanges(".md", texts) {
+		if r[1] > last {
+			last = r[1]
+		}
+	}
+	if last >= 0 {
+		i = last
+	}
+	// Keep the requirements separated by an empty line.
+	if i > 0 && strings.TrimSpace(d.lines[i-1]) != "" {
+		text = "\n" + text
+	}
+	d.insert(i, text)
+}
+
+// ReplaceReq replaces the text of the given requirement.
+func (d *MarkdownDoc) ReplaceReq(id, text string) error {
+	first, last, ok := d.reqRange(id)
+	if !ok {
+		return fmt.Errorf("Requirement %s not found", id)
+	}
+	d.lines = append(d.lines[:first], d.lines[last:]...)
+	d.insert(first, text)
+	return nil
+}
+
+// RemoveReq removes the given requirement and returns its text, e.g. to
+// move it to another document.
+func (d *MarkdownDoc) RemoveReq(id string) (string, error) {
+	text, ok := d.Req(id)
+	if !ok {
+		return "", fmt.Errorf("Requirement %s not found", id)
+	}
+	return text, d.ReplaceReq(id, "")
+}
+
+// RenameID replaces the references to the oldID requirement, including its
+// definition, with newID and returns how many were replaced.
+func (d *MarkdownDoc) RenameID(oldID, newID string) int {
+	n := 0
+	for i, l := range d.lines {
+		d.lines[i] = ReReqID.ReplaceAllStringFunc(l, func(id string) string {
+			if id != oldID {
+				return id
+			}
+			n++
+			return newID
+		})
+	}
+	return n
+}
+
+// FormatMarkdownReq returns the Markdown of a requirement with the given
+// attributes, in the order of names, followed by an empty line.
+func FormatMarkdownReq(id, title, body string, names []string, attributes map[string]string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "### %s %s\n\n", id, title)
+	if body = strings.TrimSpace(body); body != "" {
+		fmt.Fprintf(&b, "%s\n\n", body)
+	}
+	fmt.Fprintf(&b, "###### Attributes:\n")
+	for _, k := range names {
+		fmt.Fprintf(&b, "- %s: %s\n", k, attributes[k])
+	}
+	b.WriteString("\n")
+	return b.String()
+}
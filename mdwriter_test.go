@@ -0,0 +1,47 @@
+package main
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMarkdownDoc(t *testing.T) {
+	f := filepath.Join(t.TempDir(), "0-TEST-100-ORD.md")
+	// CRLF line endings, trailing spaces and no final newline are kept.
+	orig := "# 0-TEST-100-ORD\r\n\r\n## Requirements  \r\n\r\n" +
+		"### REQ-0-TEST-SYS-001 First\r\n\r\nBody one.\r\n\r\n###### Attributes:\r\n- Rationale: Because\r\n\r\n" +
+		"### REQ-0-TEST-SYS-002 Second\r\n\r\nSee REQ-0-TEST-SYS-001.\r\n\r\n" +
+		"## Appendix\r\n\r\nREQ-0-TEST-SYS-001 is important."
+	if err := ioutil.WriteFile(f, []byte(orig), 0644); err != nil {
+		t.Fatal(err)
+	}
+	d, err := ReadMarkdownDoc(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, orig, string(d.Bytes()))
+
+	text, ok := d.Req("REQ-0-TEST-SYS-002")
+	assert.True(t, ok)
+	assert.Equal(t, "### REQ-0-TEST-SYS-002 Second\r\n\r\nSee REQ-0-TEST-SYS-001.\r\n\r\n", text)
+
+	d.AppendReq(FormatMarkdownReq("REQ-0-TEST-SYS-003", "Third", "Body three.", []string{"Rationale"}, map[string]string{"Rationale": "Why not"}))
+	assert.Equal(t, 3, d.RenameID("REQ-0-TEST-SYS-001", "REQ-0-TEST-SYS-004"))
+	text, err = d.RemoveReq("REQ-0-TEST-SYS-002")
+	assert.NoError(t, err)
+	assert.Equal(t, "### REQ-0-TEST-SYS-002 Second\r\n\r\nSee REQ-0-TEST-SYS-004.\r\n\r\n", text)
+	_, err = d.RemoveReq("REQ-0-TEST-SYS-002")
+	assert.EqualError(t, err, "Requirement REQ-0-TEST-SYS-002 not found")
+
+	assert.Equal(t, "# 0-TEST-100-ORD\r\n\r\n## Requirements  \r\n\r\n"+
+		"### REQ-0-TEST-SYS-004 First\r\n\r\nBody one.\r\n\r\n###### Attributes:\r\n- Rationale: Because\r\n\r\n"+
+		"### REQ-0-TEST-SYS-003 Third\r\n\r\nBody three.\r\n\r\n###### Attributes:\r\n- Rationale: Why not\r\n\r\n"+
+		"## Appendix\r\n\r\nREQ-0-TEST-SYS-004 is important.", string(d.Bytes()))
+
+	d = NewMarkdownDoc("0-TEST-100-ORD")
+	d.AppendReq(FormatMarkdownReq("REQ-0-TEST-SYS-001", "First", "", nil, nil))
+	assert.Equal(t, "# 0-TEST-100-ORD\n\n## Requirements\n\n### REQ-0-TEST-SYS-001 First\n\n###### Attributes:\n\n", string(d.Bytes()))
+}
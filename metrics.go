@@ -0,0 +1,214 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/daedaleanai/reqtraq/config"
+)
+
+// The upper bounds of the buckets of the duration histograms, in seconds.
+var (
+	parseBuckets = []float64{0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 120}
+	httpBuckets  = []float64{0.005, 0.01, 0.05, 0.1, 0.5, 1, 5, 10, 30, 60}
+)
+
+var levelNames = map[config.RequirementLevel]string{
+	config.SYSTEM: "system",
+	config.HIGH:   "high",
+	config.LOW:    "low",
+	config.CODE:   "code",
+}
+
+// The paths for which the HTTP metrics are kept, any other is counted as "other".
+var metricsPaths = map[string]bool{"/": true, "/report": true, "/healthz": true, "/readyz": true, "/metrics": true, "/cache/invalidate": true}
+
+// histogram counts observations in buckets, as a Prometheus histogram.
+type histogram struct {
+	buckets []float64
+	counts  []uint64 // The number of observations in each bucket, not cumulative.
+	sum     float64
+	count   uint64
+}
+
+func newHistogram(buckets []float64) *histogram {
+	return &histogram{buckets: buckets, counts: make([]uint64, len(buckets))}
+}
+
+func (h *histogram) observe(v float64) {
+	for i, b := range h.buckets {
+		if v <= b {
+			h.counts[i]++
+			break
+		}
+	}
+	h.sum += v
+	h.count++
+}
+
+// write writes the samples of the histogram, labels being e.g. `path="/",` or empty.
+func (h *histogram) write(w io.Writer, name, labels string) {
+	var cumulative uint64
+	for i, b := range h.buckets {
+		cumulative += h.counts[i]
+		fmt.Fprintf(w, "%s_bucket{%sle=\"%g\"} %d\n", name, labels, b, cumulative)
+	}
+	fmt.Fprintf(w, "%s_bucket{%sle=\"+Inf\"} %d\n", name, labels, h.count)
+	labels = trimComma(labels)
+	fmt.Fprintf(w, "%s_sum%s %g\n", name, labels, h.sum)
+	fmt.Fprintf(w, "%s_count%s %d\n", name, labels, h.count)
+}
+
+// trimComma turns the labels prefix of the buckets into the labels of the other samples.
+func trimComma(labels string) string {
+	if labels == "" {
+		return ""
+	}
+	return "{" + labels[:len(labels)-1] + "}"
+}
+
+type httpKey struct {
+	path string
+	code int
+}
+
+// serverMetrics are the metrics of the web server, exposed on /metrics.
+type serverMetrics struct {
+	sync.Mutex
+	nodes         map[string]int // The nodes of the last built graph, by level.
+	parse         *histogram
+	builds        uint64
+	buildFailures uint64
+	cacheHits     uint64
+	findings      map[string]int // The findings of the last built graph, by type.
+	requests      map[httpKey]*histogram
+}
+
+var webMetrics = &serverMetrics{
+	nodes:    map[string]int{},
+	parse:    newHistogram(parseBuckets),
+	findings: map[string]int{},
+	requests: map[httpKey]*histogram{},
+}
+
+// observeBuild records the building of a requirement graph which started at start.
+func (m *serverMetrics) observeBuild(start time.Time, rg reqGraph, err error) {
+	m.Lock()
+	defer m.Unlock()
+	m.parse.observe(time.Since(start).Seconds())
+	m.builds++
+	m.findings = map[string]int{}
+	if err != nil {
+		m.buildFailures++
+		m.findings = Summarize("web", exitFindings, err).Counts
+		return
+	}
+	m.nodes = map[string]int{}
+	for _, r := range rg {
+		m.nodes[levelNames[r.Level]]++
+	}
+}
+
+// observeCacheHit records a requirement graph found in the cache instead of being built.
+func (m *serverMetrics) observeCacheHit() {
+	m.Lock()
+	defer m.Unlock()
+	m.cacheHits++
+}
+
+func (m *serverMetrics) observeRequest(path string, code int, d time.Duration) {
+	if !metricsPaths[path] {
+		path = "other"
+	}
+	m.Lock()
+	defer m.Unlock()
+	k := httpKey{path, code}
+	if m.requests[k] == nil {
+		m.requests[k] = newHistogram(httpBuckets)
+	}
+	m.requests[k].observe(d.Seconds())
+}
+
+// Write writes the metrics in the Prometheus text format.
+func (m *serverMetrics) Write(w io.Writer) {
+	m.Lock()
+	defer m.Unlock()
+
+	fmt.Fprintln(w, "# HELP reqtraq_graph_nodes Number of requirements and code files in the last built graph, by level.")
+	fmt.Fprintln(w, "# TYPE reqtraq_graph_nodes gauge")
+	for _, k := range sortedKeys(m.nodes) {
+		fmt.Fprintf(w, "reqtraq_graph_nodes{level=%q} %d\n", k, m.nodes[k])
+	}
+
+	fmt.Fprintln(w, "# HELP reqtraq_graph_parse_duration_seconds Time spent building requirement graphs.")
+	fmt.Fprintln(w, "# TYPE reqtraq_graph_parse_duration_seconds histogram")
+	m.parse.write(w, "reqtraq_graph_parse_duration_seconds", "")
+
+	fmt.Fprintln(w, "# HELP reqtraq_graph_builds_total Number of requirement graphs built.")
+	fmt.Fprintln(w, "# TYPE reqtraq_graph_builds_total counter")
+	fmt.Fprintf(w, "reqtraq_graph_builds_total %d\n", m.builds)
+	fmt.Fprintln(w, "# HELP reqtraq_graph_build_failures_total Number of requirement graphs which could not be built because of problems.")
+	fmt.Fprintln(w, "# TYPE reqtraq_graph_build_failures_total counter")
+	fmt.Fprintf(w, "reqtraq_graph_build_failures_total %d\n", m.buildFailures)
+	fmt.Fprintln(w, "# HELP reqtraq_graph_cache_hits_total Number of requirement graphs reused instead of being built.")
+	fmt.Fprintln(w, "# TYPE reqtraq_graph_cache_hits_total counter")
+	fmt.Fprintf(w, "reqtraq_graph_cache_hits_total %d\n", m.cacheHits)
+
+	fmt.Fprintln(w, "# HELP reqtraq_findings Number of problems found while building the last graph, by type.")
+	fmt.Fprintln(w, "# TYPE reqtraq_findings gauge")
+	for _, k := range sortedKeys(m.findings) {
+		fmt.Fprintf(w, "reqtraq_findings{type=%q} %d\n", k, m.findings[k])
+	}
+
+	fmt.Fprintln(w, "# HELP reqtraq_http_request_duration_seconds Time spent handling HTTP requests, by path and status code.")
+	fmt.Fprintln(w, "# TYPE reqtraq_http_request_duration_seconds histogram")
+	var keys []httpKey
+	for k := range m.requests {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].path < keys[j].path || keys[i].path == keys[j].path && keys[i].code < keys[j].code
+	})
+	for _, k := range keys {
+		m.requests[k].write(w, "reqtraq_http_request_duration_seconds", fmt.Sprintf("path=%q,code=\"%d\",", k.path, k.code))
+	}
+}
+
+func sortedKeys(m map[string]int) []string {
+	var keys []string
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// statusRecorder remembers the status code of the response.
+type statusRecorder struct {
+	http.ResponseWriter
+	code int
+}
+
+func (r *statusRecorder) WriteHeader(code int) {
+	r.code = code
+	r.ResponseWriter.WriteHeader(code)
+}
+
+// instrument records the duration and the status code of the requests handled by h.
+func instrument(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{w, http.StatusOK}
+		h.ServeHTTP(rec, r)
+		webMetrics.observeRequest(r.URL.Path, rec.code, time.Since(start))
+	})
+}
+
+func metrics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	webMetrics.Write(w)
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/daedaleanai/reqtraq/config"
+)
+
+// reProject matches a project as it appears in the document names, e.g. 0-DDLN.
+var reProject = regexp.MustCompile(`^\d+-\w+$`)
+
+// docTypeTitles are the titles of the documents, by type. The documents of the
+// other types are titled with their type.
+var docTypeTitles = map[string]string{
+	"ORD":  "Overall Requirements Document",
+	"SRD":  "Software Requirements Document",
+	"SDD":  "Software Design Description",
+	"HRD":  "Hardware Requirements Document",
+	"HDD":  "Hardware Design Description",
+	"SRS":  "Software Requirements Standards",
+	"SDS":  "Software Design Standards",
+	"SCS":  "Software Code Standards",
+	"PSAC": "Plan for Software Aspects of Certification",
+	"SCMP": "Software Configuration Management Plan",
+	"SQAP": "Software Quality Assurance Plan",
+	"SDP":  "Software Development Plan",
+	"SVP":  "Software Verification Plan",
+	"TQP":  "Tool Qualification Plan",
+	"SAS":  "Software Accomplishment Summary",
+	"SVCP": "Software Verification Cases and Procedures",
+}
+
+// docTitle returns the title of the documents of the given type.
+func docTitle(typ string) string {
+	if t, ok := docTypeTitles[typ]; ok {
+		return t
+	}
+	return typ
+}
+
+// NewDocument creates in dir the skeleton of a Markdown certification document
+// of the given type, e.g. SDD, for the given project, e.g. 0-DDLN, and returns
+// its file name, named after docNameConventions. It has the header and the
+// sections of the existing documents, the related documents, see
+// config.DocTypeRelations, and for the requirement documents the sections of
+// the requirements, to be filled in.
+func NewDocument(dir, typ, project string) (string, error) {
+	number, ok := docNameConventions[typ]
+	if !ok {
+		var types []string
+		for t := range docNameConventions {
+			types = append(types, t)
+		}
+		sort.Strings(types)
+		return "", fmt.Errorf("Invalid document type: '%s'. Must be one of %s", typ, strings.Join(types, ", "))
+	}
+	if !reProject.MatchString(project) {
+		return "", fmt.Errorf("Invalid project: '%s'. Must be e.g. 0-DDLN", project)
+	}
+	fileName := filepath.Join(dir, project+"-"+number+"-"+typ+".md")
+	if _, err := os.Stat(fileName); err == nil {
+		return "", fmt.Errorf("%s exists already", fileName)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s for %s\n\n", docTitle(typ), config.ProjectName)
+	b.WriteString("Revision: 1\n\n")
+	b.WriteString("Document Approval:\n")
+	b.WriteString("- Engineering, Program Manager: *[TODO]*\n")
+	b.WriteString("- Engineering, Engineer: *[TODO]*\n")
+	b.WriteString("- Quality, Quality Engineer: *[TODO]*\n\n")
+	b.WriteString("## Introduction\n\n")
+	b.WriteString("### Purpose\n\n*[TODO: The purpose of the document.]*\n\n")
+	b.WriteString("### Scope\n\n*[TODO: The topics discussed by the document.]*\n\n")
+	b.WriteString("### Applicable Documents\n\n")
+	b.WriteString("#### External Documents\n\n")
+	if strings.HasPrefix(typ, "H") {
+		b.WriteString("**RTCA DO-254 / EUROCAE ED-80** Design Assurance Guidance for Airborne Electronic Hardware.\n\n")
+	} else {
+		b.WriteString("**RTCA DO-178C / EUROCAE ED-12C** Software Considerations in Airborne Systems and Equipment Certification.\n\n")
+	}
+	b.WriteString("#### Internal Documents\n\n")
+	related := false
+	for _, rel := range config.DocTypeRelations {
+		if rel.From == typ {
+			fmt.Fprintf(&b, "**%s-%s-%s** %s\n\n", project, docNameConventions[rel.To], rel.To, docTitle(rel.To))
+			related = true
+		}
+	}
+	if !related {
+		b.WriteString("*[TODO: The documents of the project this document refers to.]*\n\n")
+	}
+	b.WriteString("### Definitions of Acronyms and Terms\n\n")
+	b.WriteString("#### Description of Terms\n\n*[TODO: The terms used in the document.]*\n\n")
+	if reqType, ok := config.DocTypeToReqType[typ]; ok {
+		b.WriteString("## Requirements\n\n")
+		fmt.Fprintf(&b, "### Functional Requirements\n\n*[TODO: The %s requirements, each under a level 4 heading starting with its ID, see \"reqtraq nextid\".]*\n\n", reqType)
+		b.WriteString("### Interface Requirements\n\n")
+		b.WriteString("### Other Assumptions\n")
+	}
+	if err := ioutil.WriteFile(fileName, []byte(strings.TrimSuffix(b.String(), "\n")+"\n"), 0644); err != nil {
+		return "", err
+	}
+	return fileName, nil
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+var (
+	keywordsMu      sync.Mutex
+	keywordsNames   string
+	keywordsCompile *regexp.Regexp
+)
+
+// keywordsRegexp returns the regular expression matching the names of the
+// attributes followed by a colon, see reReqKWD, including the attributes of
+// the configuration, e.g. DAL or Effort.
+func keywordsRegexp() *regexp.Regexp {
+	var names []string
+	for _, a := range repoConfig.Attributes {
+		names = append(names, regexp.QuoteMeta(strings.TrimSpace(a.Name)))
+	}
+	joined := strings.Join(names, "|")
+	if joined == "" {
+		return reReqKWD
+	}
+	keywordsMu.Lock()
+	defer keywordsMu.Unlock()
+	if keywordsCompile == nil || keywordsNames != joined {
+		keywordsCompile = regexp.MustCompile(`(?im)^(- )?(` + joined + `|` + reqKeywords + `):`)
+		keywordsNames = joined
+	}
+	return keywordsCompile
+}
+
+// normalizeAttributeValue returns the canonical form of the value of an
+// attribute having "normalize: true": its spaces collapsed, without trailing
+// period, and for an enum the value as configured.
+func normalizeAttributeValue(spec AttributeSpec, value string) string {
+	value = strings.TrimSuffix(strings.Join(strings.Fields(value), " "), ".")
+	if spec.Type == attributeEnum {
+		for _, v := range spec.Values {
+			if strings.EqualFold(v, value) {
+				return v
+			}
+		}
+	}
+	return value
+}
+
+// normalizeAttributes normalizes the values of the attributes of the
+// requirement having "normalize: true" in the configuration.
+func (r *Req) normalizeAttributes() {
+	for _, a := range repoConfig.Attributes {
+		key := normalizeAttribute(a.Name)
+		if v, ok := r.Attributes[key]; ok && a.Normalize {
+			r.Attributes[key] = normalizeAttributeValue(a, v)
+		}
+	}
+}
+
+// canonicalAttributeName returns the name of the attribute as written by fmt:
+// as configured, or else in sentence case, e.g. Safety impact for SAFETY IMPACT.
+func canonicalAttributeName(name string) string {
+	if a, ok := configAttribute(name); ok {
+		return a.Name
+	}
+	name = strings.ToLower(strings.Join(strings.Fields(normalizeAttribute(name)), " "))
+	return strings.ToUpper(name[:1]) + name[1:]
+}
+
+// attributeRank returns the position of the named attribute in the given
+// attribute specification, or -1.
+func attributeRank(as []map[string]string, name string) int {
+	key := normalizeAttribute(name)
+	for i, a := range as {
+		if normalizeAttribute(a["name"]) == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// orderAttributes returns the indexes of the given attribute names in the
+// order of the documents written by reqtraq: the attributes of the given
+// specification ordered as in it, in the places they occupy together, the
+// others keeping theirs. Without specification, nothing moves.
+func orderAttributes(as []map[string]string, names []string) []int {
+	order := make([]int, len(names))
+	var specified []int
+	for i, name := range names {
+		order[i] = i
+		if attributeRank(as, name) >= 0 {
+			specified = append(specified, i)
+		}
+	}
+	sorted := append([]int{}, specified...)
+	sort.SliceStable(sorted, func(a, b int) bool {
+		return attributeRank(as, names[sorted[a]]) < attributeRank(as, names[sorted[b]])
+	})
+	for k, i := range specified {
+		order[i] = sorted[k]
+	}
+	return order
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"sort"
+
+	"github.com/daedaleanai/reqtraq/config"
+)
+
+// Roster lists the team members who can own and review requirements, for example:
+//
+//	{
+//		"members": ["alice", "bob"]
+//	}
+type Roster struct {
+	Members []string
+}
+
+// ReadRoster reads the team roster from the given json file.
+func ReadRoster(fileName string) (*Roster, error) {
+	b, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+	var roster Roster
+	if err := json.Unmarshal(b, &roster); err != nil {
+		return nil, fmt.Errorf("Error while parsing roster %s: %v", fileName, err)
+	}
+	return &roster, nil
+}
+
+func (roster *Roster) has(name string) bool {
+	for _, m := range roster.Members {
+		if m == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Owners returns the comma separated names in the OWNER attribute of the requirement.
+func (r *Req) Owners() []string { return r.listAttribute("OWNER") }
+
+// Reviewers returns the comma separated names in the REVIEWER attribute of the requirement.
+func (r *Req) Reviewers() []string { return r.listAttribute("REVIEWER") }
+
+// CheckOwners checks that the owners and reviewers of the requirements are
+// members of the team and that nobody reviews their own requirements.
+func (rg reqGraph) CheckOwners(roster *Roster) []error {
+	var reqs []*Req
+	for _, r := range rg {
+		if r.Level != config.CODE {
+			reqs = append(reqs, r)
+		}
+	}
+	sort.Sort(byIDs(reqs))
+	var errs []error
+	for _, r := range reqs {
+		owners := map[string]bool{}
+		for _, name := range r.Owners() {
+			owners[name] = true
+			if !roster.has(name) {
+				errs = append(errs, newFinding("owner", "Requirement '%s' has owner '%s' who is not in the roster.\n", r.ID, name))
+			}
+		}
+		for _, name := range r.Reviewers() {
+			if !roster.has(name) {
+				errs = append(errs, newFinding("owner", "Requirement '%s' has reviewer '%s' who is not in the roster.\n", r.ID, name))
+			}
+			if owners[name] {
+				errs = append(errs, newFinding("owner", "Requirement '%s' has '%s' both as owner and reviewer.\n", r.ID, name))
+			}
+		}
+	}
+	return errs
+}
+
+// OwnedBy returns the requirements owned by the given person, by ID.
+func (rg reqGraph) OwnedBy(name string) []*Req {
+	var res []*Req
+	for _, r := range rg {
+		if r.Level != config.CODE && r.isOwnedBy(name) {
+			res = append(res, r)
+		}
+	}
+	sort.Sort(byIDs(res))
+	return res
+}
+
+func (r *Req) isOwnedBy(name string) bool {
+	for _, o := range r.Owners() {
+		if o == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Ownership lists the requirements owned by a person.
+type Ownership struct {
+	Owner string // Empty for the requirements without owner.
+	Reqs  []*Req
+}
+
+// ByOwner returns the requirements grouped by owner, sorted by owner name,
+// followed by the requirements without owner.
+func (rg reqGraph) ByOwner() []Ownership {
+	byOwner := map[string][]*Req{}
+	for _, r := range rg {
+		if r.Level == config.CODE || r.IsDeleted() {
+			continue
+		}
+		owners := r.Owners()
+		if len(owners) == 0 {
+			owners = []string{""}
+		}
+		for _, o := range owners {
+			byOwner[o] = append(byOwner[o], r)
+		}
+	}
+	var res []Ownership
+	for o, reqs := range byOwner {
+		sort.Sort(byIDs(reqs))
+		res = append(res, Ownership{o, reqs})
+	}
+	sort.Slice(res, func(i, j int) bool {
+		// The requirements without owner go last.
+		if res[i].Owner == "" || res[j].Owner == "" {
+			return res[j].Owner == ""
+		}
+		return res[i].Owner < res[j].Owner
+	})
+	return res
+}
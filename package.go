@@ -0,0 +1,119 @@
+package main
+
+import (
+	"archive/zip"
+	"io"
+	"io/ioutil"
+	"path"
+	"path/filepath"
+	"sort"
+
+	"github.com/daedaleanai/reqtraq/config"
+	"github.com/daedaleanai/reqtraq/git"
+)
+
+// ChangedDocuments returns the paths, relative to the repository root, of the
+// certification documents of rg defining the requirements changed since prg,
+// sorted.
+func (rg reqGraph) ChangedDocuments(prg reqGraph) []string {
+	seen := map[string]bool{}
+	var docs []string
+	for k := range rg.ChangedSince(prg) {
+		r := rg[k]
+		if r == nil || r.Level == config.CODE {
+			continue
+		}
+		if p := repoRelative(r.Path); !seen[p] {
+			seen[p] = true
+			docs = append(docs, p)
+		}
+	}
+	sort.Strings(docs)
+	return docs
+}
+
+// readFilesAt returns the contents of the given files, relative to the
+// repository root, at the given commit, or in the working tree when empty.
+func readFilesAt(commit string, files []string) (map[string][]byte, error) {
+	res := map[string][]byte{}
+	if commit == "" {
+		repoPath, err := git.RepoPath()
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range files {
+			b, err := ioutil.ReadFile(filepath.Join(repoPath, filepath.FromSlash(f)))
+			if err != nil {
+				return nil, err
+			}
+			res[f] = b
+		}
+		return res, nil
+	}
+	err := git.ReadFiles(commit, files, func(f string, contents []byte) error {
+		res[f] = contents
+		return nil
+	})
+	return res, err
+}
+
+// Package is the delta package of the changes between two baselines, for
+// the stage of involvement audits of the certification authorities.
+type Package struct {
+	Changelog *Changelog
+	// Graph is the requirement graph of the target baseline.
+	Graph reqGraph
+	// Documents are the contents of the changed certification documents at the
+	// target baseline, by path relative to the repository root.
+	Documents map[string][]byte
+	// Manifest is the manifest of the target baseline, see NewManifest.
+	Manifest *Manifest
+}
+
+// Write writes the package as a zip containing:
+//
+//	changes.md, changes.json  the changes of the requirements, as by the diff command
+//	trace-down.html           the top down traceability report of the target
+//	trace-up.html             the bottom up traceability report of the target
+//	documents/...             the changed certification documents, at their paths in the repository
+//	manifest.json             the manifest of the target
+func (p *Package) Write(w io.Writer) error {
+	z := zip.NewWriter(w)
+	add := func(name string, write func(w io.Writer) error) error {
+		f, err := z.Create(name)
+		if err != nil {
+			return err
+		}
+		return write(f)
+	}
+	if err := add("changes.md", func(w io.Writer) error { p.Changelog.WriteMarkdown(w); return nil }); err != nil {
+		return err
+	}
+	if err := add("changes.json", func(w io.Writer) error { return WriteChangelogsJSON(w, []*Changelog{p.Changelog}) }); err != nil {
+		return err
+	}
+	if err := add("trace-down.html", p.Graph.ReportDown); err != nil {
+		return err
+	}
+	if err := add("trace-up.html", p.Graph.ReportUp); err != nil {
+		return err
+	}
+	var docs []string
+	for d := range p.Documents {
+		docs = append(docs, d)
+	}
+	sort.Strings(docs)
+	for _, d := range docs {
+		contents := p.Documents[d]
+		if err := add(path.Join("documents", d), func(w io.Writer) error {
+			_, err := w.Write(contents)
+			return err
+		}); err != nil {
+			return err
+		}
+	}
+	if err := add("manifest.json", p.Manifest.Write); err != nil {
+		return err
+	}
+	return z.Close()
+}
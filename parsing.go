@@ -2,35 +2,118 @@
 package main
 
 import (
+	"bufio"
 	"fmt"
 	"html/template"
 	"io"
-	"log"
 	"os/exec"
 	"regexp"
 	"strings"
 	"unicode"
+	"unicode/utf8"
 
 	"github.com/daedaleanai/reqtraq/config"
 )
 
 var (
-	// REQ, project number, project abbreviation, req type, req number
-	// For example: REQ-0-DDLN-SWH-004
-	reReqIdStr   = `REQ-(\d+)-(\w+)-(SYS|SWH|SWL|HWH|HWL)-(\d+)`
+	// REQ, project number, project abbreviation, req type, req number and,
+	// for the instances of a generic requirement, the instance number
+	// For example: REQ-0-DDLN-SWH-004 or REQ-0-DDLN-SWH-004.2, see Req.Instances
+	reReqIdStr   = `REQ-(\d+)-(\w+)-(SYS|SWH|SWL|HWH|HWL)-(\d+)(?:\.\d+)?`
 	ReReqID      = regexp.MustCompile(reReqIdStr)
 	ReReqDeleted = regexp.MustCompile(reReqIdStr + ` DELETED`)
 	reReqIDBad   = regexp.MustCompile(`(?i)REQ(-(\w+))+`)
-	reReqKWD     = regexp.MustCompile(`(?i)(- )?(rationale|parent|parents|safety impact|verification|urgent|important|mode|provenance):`)
+	reReqKWD     = regexp.MustCompile(`(?im)^(- )?(` + reqKeywords + `):`)
 )
 
+// reqKeywords are the names of the attributes known without configuration, see keywordsRegexp.
+const reqKeywords = `rationale|parent|parents|safety impact|verification|urgent|important|mode|provenance|owner|reviewer|tags|component|confirmed|satisfies|refines|conflicts-with|depends-on|body \(\w+\)|param \(\w+\)|instances`
+
+// toUTF8 returns the text unchanged if it is valid UTF-8, otherwise it reads it as Latin-1.
+func toUTF8(s string) string {
+	if utf8.ValidString(s) {
+		return s
+	}
+	runes := make([]rune, len(s))
+	for i := 0; i < len(s); i++ {
+		runes[i] = rune(s[i])
+	}
+	return string(runes)
+}
+
+// lineReader reads a file line by line like bufio.Scanner, but whatever the
+// length of the lines, e.g. embedded base64 images, where bufio.Scanner fails
+// on lines longer than 64KB.
+type lineReader struct {
+	r      *bufio.Reader
+	line   string
+	ending string
+	err    error
+}
+
+func newLineReader(r io.Reader) *lineReader {
+	return &lineReader{r: bufio.NewReader(r)}
+}
+
+// Scan reads the next line, returning false at the end of the file or on error.
+func (l *lineReader) Scan() bool {
+	line, err := l.r.ReadString('\n')
+	if err != nil && (err != io.EOF || line == "") {
+		if err != io.EOF {
+			l.err = err
+		}
+		return false
+	}
+	l.line = strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r")
+	l.ending = line[len(l.line):]
+	return true
+}
+
+// Ending returns the line ending of the last line read: "\n", "\r\n", or
+// the empty string for the last line of a file not ending with a newline.
+func (l *lineReader) Ending() string { return l.ending }
+
+// Text returns the last line read, without the line ending.
+func (l *lineReader) Text() string { return l.line }
+
+// Bytes returns the last line read, without the line ending.
+func (l *lineReader) Bytes() []byte { return []byte(l.line) }
+
+// Err returns the error which stopped the reading, if any.
+func (l *lineReader) Err() error { return l.err }
+
 // @llr REQ-0-DDLN-SWL-019
 // Given a string containing markdown, convert it to HTML using pandoc
 func formatBodyAsHTML(txt string) (template.HTML) {
-	cmd := exec.Command("pandoc", "--mathjax")
+	return template.HTML(pandoc(txt, "--mathjax"))
+}
+
+// Given the HTML body of a requirement, convert it back to markdown using pandoc
+func formatHTMLAsMarkdown(body template.HTML) string {
+	return string(pandoc(string(body), "-f", "html", "-t", "markdown"))
+}
+
+// Given the HTML body of a requirement, convert it to plain text using pandoc
+func formatHTMLAsText(body template.HTML) string {
+	return string(pandoc(string(body), "-f", "html", "-t", "plain"))
+}
+
+// pandoc runs pandoc with the given arguments on txt and returns the output,
+// exiting when it fails, see runPandoc.
+func pandoc(txt string, args ...string) []byte {
+	out, err := runPandoc(txt, args...)
+	if err != nil {
+		fatal(err)
+	}
+	return out
+}
+
+// runPandoc runs pandoc with the given arguments on txt and returns the output.
+func runPandoc(txt string, args ...string) ([]byte, error) {
+	cmd := exec.Command("pandoc", args...)
 	stdin, err := cmd.StdinPipe()
 	if err != nil {
-		log.Fatal("Couldn't get input pipe for pandoc: ", err)
+		return nil, fmt.Errorf("Couldn't get input pipe for pandoc: %v", err)
 	}
 
 	go func() {
@@ -40,10 +123,10 @@ func formatBodyAsHTML(txt string) (template.HTML) {
 
 	out, err := cmd.CombinedOutput()
 	if err != nil {
-		log.Fatal("Error while running pandoc: ", err)
+		return nil, fmt.Errorf("Error while running pandoc: %v", err)
 	}
 
-	return template.HTML(out)
+	return out, nil
 }
 
 // ParseReq finds the first REQ-XXX tag and the reserved words and distills a Req from it.
@@ -58,6 +141,9 @@ func formatBodyAsHTML(txt string) (template.HTML) {
 //            [Important:...]
 //            [Mode:...]
 //            [Provenance:...]
+//            [Body (de):...]  the body translated in the given language
+//            [Param (component):...]  the value of the {component} placeholder
+//            [Instances:...]  the parameter table of a generic requirement, see Req.Instances
 //
 // ParseReq does NOT validate the values or check if the mandatory attributes are set; use
 // the Req.Check() method for that.
@@ -67,24 +153,24 @@ func formatBodyAsHTML(txt string) (template.HTML) {
 func ParseReq(txt string) (*Req, error) {
 	lyx := strings.HasPrefix(txt, "\n")
 	head := txt
-	if len(head) > 40 {
-		head = head[:40]
+	if r := []rune(head); len(r) > 40 {
+		head = string(r[:40])
 	}
 	defid := ReReqID.FindStringSubmatchIndex(txt)
 	if len(defid) == 0 {
 		if reReqIDBad.MatchString(head) {
-			return nil, fmt.Errorf("malformed requirement: found only malformed ID: %q (doesn't match %q)", head, ReReqID)
+			return nil, newFinding("malformed_requirement", "malformed requirement: found only malformed ID: %q (doesn't match %q)", head, ReReqID)
 		}
-		return nil, fmt.Errorf("malformed requirement: missing ID in first 40 characters: %q", head)
+		return nil, newFinding("malformed_requirement", "malformed requirement: missing ID in first 40 characters: %q", head)
 	}
 
 	if lyx {
 		if defid[0] > 20 {
-			return nil, fmt.Errorf("malformed requirement: too much heading garbage before ID: %q", head)
+			return nil, newFinding("malformed_requirement", "malformed requirement: too much heading garbage before ID: %q", head)
 		}
 	} else {
 		if defid[0] > 0 {
-			return nil, fmt.Errorf("malformed requirement: ID must be at the start of the title: %q", head)
+			return nil, newFinding("malformed_requirement", "malformed requirement: ID must be at the start of the title: %q", head)
 		}
 	}
 
@@ -98,14 +184,24 @@ func ParseReq(txt string) (*Req, error) {
 	txt = strings.TrimLeftFunc(txt, unicode.IsSpace)
 
 	var attributesStart int
-	kwdMatches := reReqKWD.FindAllStringSubmatchIndex(txt, -1)
+	kwdMatches := keywordsRegexp().FindAllStringSubmatchIndex(txt, -1)
 	if len(kwdMatches) == 0 {
-		return nil, fmt.Errorf("requirement %s contains no attributes", r.ID)
+		return nil, newFinding("malformed_requirement", "requirement %s contains no attributes", r.ID)
 	}
 	if lyx {
 		attributesStart = kwdMatches[0][0]
 	} else {
 		attributesStart = strings.Index(txt, "\n###### Attributes:\n")
+		if attributesStart < 0 {
+			return nil, newFinding("malformed_requirement", "requirement %s contains no '###### Attributes:' heading", r.ID)
+		}
+		// The keywords found in the body are not attributes.
+		for len(kwdMatches) > 0 && kwdMatches[0][0] < attributesStart {
+			kwdMatches = kwdMatches[1:]
+		}
+		if len(kwdMatches) == 0 {
+			return nil, newFinding("malformed_requirement", "requirement %s contains no attributes", r.ID)
+		}
 	}
 	for i, v := range kwdMatches {
 		key := strings.ToUpper(txt[v[4]:v[5]])
@@ -117,7 +213,7 @@ func ParseReq(txt string) (*Req, error) {
 			e = kwdMatches[i+1][0]
 		}
 		if _, ok := r.Attributes[key]; ok {
-			return nil, fmt.Errorf("requirement %s contains duplicate attribute: %q", r.ID, key)
+			return nil, newFinding("malformed_requirement", "requirement %s contains duplicate attribute: %q", r.ID, key)
 		}
 		r.Attributes[key] = strings.TrimSpace(txt[v[1]:e])
 	}
@@ -134,19 +230,21 @@ func ParseReq(txt string) (*Req, error) {
 		if i > 0 {
 			sep := parents[parmatch[i-1][1]:ids[0]]
 			if strings.TrimFunc(sep, func(r rune) bool { return unicode.IsSpace(r) || unicode.IsPunct(r) }) != "" {
-				return nil, fmt.Errorf("requirement %s parents: unparseable as list of requirement ids: %q in %q", r.ID, sep, parents)
+				return nil, newFinding("malformed_requirement", "requirement %s parents: unparseable as list of requirement ids: %q in %q", r.ID, sep, parents)
 			}
 		}
 	}
 
 	level, ok := config.ReqTypeToReqLevel[r.ReqType()]
 	if !ok {
-		return nil, fmt.Errorf("Invalid request type: %q", r.ReqType())
+		return nil, newFinding("malformed_requirement", "Invalid request type: %q", r.ReqType())
 	}
 	r.Level = level
 
 	parts := strings.SplitN(strings.TrimSpace(txt), "\n", 2)
 	r.Title = parts[0]
-	r.Body = formatBodyAsHTML(parts[1])
+	if len(parts) > 1 {
+		r.Body = formatBodyAsHTML(parts[1])
+	}
 	return r, nil
 }
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/daedaleanai/reqtraq/config"
+)
+
+// components returns the comma separated names in the COMPONENT attribute of
+// the requirement.
+func (r *Req) components() []string { return r.listAttribute("COMPONENT") }
+
+func (r *Req) inComponent(component string) bool {
+	for _, c := range r.components() {
+		if strings.EqualFold(c, component) {
+			return true
+		}
+	}
+	return false
+}
+
+// ExtractComponent returns the sub-graph of the requirements of the given
+// component, those having it in their Component attribute, with their
+// ancestors and the code files referencing them, so it can be delivered to a
+// supplier of the component. The ancestors not in the component are marked
+// External, and only have their links to the other requirements of the
+// sub-graph. The requirements are copies, the graph is not changed.
+func (rg reqGraph) ExtractComponent(component string) (reqGraph, error) {
+	keys := map[*Req]string{}
+	for k, r := range rg {
+		keys[r] = k
+	}
+	sub := reqGraph{}
+	copies := map[*Req]*Req{}
+	var add func(r *Req, external bool)
+	add = func(r *Req, external bool) {
+		if c := copies[r]; c != nil {
+			c.External = c.External && external
+			return
+		}
+		c := *r
+		c.External = external
+		copies[r] = &c
+		sub[keys[r]] = &c
+		for _, p := range r.Parents {
+			add(p, true)
+		}
+	}
+	for _, r := range rg {
+		if r.Level != config.CODE && !r.IsDeleted() && r.inComponent(component) {
+			add(r, false)
+			for _, ch := range r.Children {
+				if ch.Level == config.CODE {
+					add(ch, false)
+				}
+			}
+		}
+	}
+	if len(sub) == 0 {
+		return nil, fmt.Errorf("No requirement of component %s", component)
+	}
+	linked := func(reqs []*Req) []*Req {
+		var res []*Req
+		for _, r := range reqs {
+			if c := copies[r]; c != nil {
+				res = append(res, c)
+			}
+		}
+		return res
+	}
+	for _, c := range sub {
+		c.Parents, c.Children = linked(c.Parents), linked(c.Children)
+	}
+	return sub, nil
+}
+
+// MergeComponent returns the graph with the requirements of the given
+// component replaced by those of the supplier's graph, extracted by
+// ExtractComponent and developed by the supplier, and with the code files of
+// the supplier, along with the problems of the merged graph as for
+// CreateReqGraph. The conflicts to be resolved manually are returned instead
+// of the graph: the requirements of the supplier whose IDs are used by other
+// components, the external requirements which no longer exist or whose text
+// diverged, the requirements of the component the supplier lacks and the
+// code files differing from those of the repository.
+func (rg reqGraph) MergeComponent(supplier reqGraph, component string) (reqGraph, []error, error) {
+	var conflicts []error
+	codeByID := map[string]*Req{}
+	for _, r := range rg {
+		if r.Level == config.CODE {
+			codeByID[r.ID] = r
+		}
+	}
+	var supplied []*Req
+	for _, s := range supplier {
+		if s.Level != config.CODE {
+			supplied = append(supplied, s)
+		}
+	}
+	sort.Sort(byIDs(supplied))
+	for _, s := range supplied {
+		r := rg[s.ID]
+		switch {
+		case s.External && r == nil:
+			conflicts = append(conflicts, newFinding("merge_conflict", "External requirement '%s' of the supplier does not exist.\n", s.ID))
+		case s.External && (r.Title != s.Title || r.Body != s.Body):
+			conflicts = append(conflicts, newFinding("merge_conflict", "External requirement '%s' of the supplier diverged from the one in %s.\n", s.ID, r.Path))
+		case !s.External && r != nil && !r.inComponent(component):
+			conflicts = append(conflicts, newFinding("merge_conflict", "Requirement '%s' of the supplier collides with the one in %s, which is not of component %s.\n", s.ID, r.Path, component))
+		}
+	}
+	var missing []*Req
+	for _, r := range rg {
+		if r.Level != config.CODE && r.inComponent(component) && (supplier[r.ID] == nil || supplier[r.ID].External) {
+			missing = append(missing, r)
+		}
+	}
+	sort.Sort(byIDs(missing))
+	for _, r := range missing {
+		conflicts = append(conflicts, newFinding("merge_conflict", "Requirement '%s' of component %s in %s is missing from the supplier graph.\n", r.ID, component, r.Path))
+	}
+	var code []string
+	for k, s := range supplier {
+		if s.Level == config.CODE {
+			code = append(code, k)
+		}
+	}
+	sort.Strings(code)
+	for _, k := range code {
+		if r := codeByID[supplier[k].ID]; r != nil && !sameFileHash(r.FileHash, supplier[k].FileHash) {
+			conflicts = append(conflicts, newFinding("merge_conflict", "Code file '%s' of the supplier differs from the one of the repository.\n", supplier[k].ID))
+		}
+	}
+	if len(conflicts) > 0 {
+		return nil, conflicts, nil
+	}
+
+	merged := reqGraph{}
+	add := func(k string, r *Req) {
+		c := *r
+		c.Parents, c.Children, c.Seen, c.Status, c.External = nil, nil, false, NOT_STARTED, false
+		merged[k] = &c
+	}
+	for k, r := range rg {
+		add(k, r)
+	}
+	for _, s := range supplied {
+		if !s.External {
+			add(s.ID, s)
+		}
+	}
+	for _, k := range code {
+		if codeByID[supplier[k].ID] == nil {
+			add(k, supplier[k])
+		}
+	}
+	return merged, nil, merged.Resolve()
+}
+
+// sameFileHash returns whether the given hashes of code files are equal as far
+// as the snapshots record them: the binary hashes are json strings, whose
+// invalid UTF-8 bytes are replaced.
+func sameFileHash(a, b string) bool {
+	ja, _ := json.Marshal(a)
+	jb, _ := json.Marshal(b)
+	return string(ja) == string(jb)
+}
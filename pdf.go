@@ -0,0 +1,403 @@
+package main
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"strconv"
+	"unicode/utf16"
+)
+
+// The PDF objects are parsed as nil, bool, float64, string, pdfName,
+// []interface{}, pdfDict or pdfRef.
+type (
+	pdfName string
+	pdfDict map[pdfName]interface{}
+	pdfRef  struct{ num, gen int }
+)
+
+// pdfParser parses the objects of a PDF, only as much as needed to find its
+// named destinations, see pdfDestinations.
+type pdfParser struct {
+	b   []byte
+	pos int
+}
+
+func isPDFSpace(c byte) bool {
+	return c == ' ' || c == '\n' || c == '\r' || c == '\t' || c == '\f' || c == 0
+}
+
+func isPDFDelimiter(c byte) bool {
+	return isPDFSpace(c) || bytes.IndexByte([]byte("()<>[]{}/%"), c) >= 0
+}
+
+// skipSpace skips the white space and the comments.
+func (p *pdfParser) skipSpace() {
+	for p.pos < len(p.b) {
+		switch c := p.b[p.pos]; {
+		case isPDFSpace(c):
+			p.pos++
+		case c == '%':
+			for p.pos < len(p.b) && p.b[p.pos] != '\n' && p.b[p.pos] != '\r' {
+				p.pos++
+			}
+		default:
+			return
+		}
+	}
+}
+
+// token returns the regular characters starting at the current position.
+func (p *pdfParser) token() string {
+	start := p.pos
+	for p.pos < len(p.b) && !isPDFDelimiter(p.b[p.pos]) {
+		p.pos++
+	}
+	return string(p.b[start:p.pos])
+}
+
+// parse returns the object starting at the current position.
+func (p *pdfParser) parse() (interface{}, error) {
+	p.skipSpace()
+	if p.pos >= len(p.b) {
+		return nil, fmt.Errorf("unexpected end of PDF")
+	}
+	switch c := p.b[p.pos]; {
+	case bytes.HasPrefix(p.b[p.pos:], []byte("<<")):
+		p.pos += 2
+		d := pdfDict{}
+		for {
+			p.skipSpace()
+			if bytes.HasPrefix(p.b[p.pos:], []byte(">>")) {
+				p.pos += 2
+				return d, nil
+			}
+			key, err := p.parse()
+			if err != nil {
+				return nil, err
+			}
+			name, ok := key.(pdfName)
+			if !ok {
+				return nil, fmt.Errorf("invalid PDF dictionary key at %d", p.pos)
+			}
+			if d[name], err = p.parse(); err != nil {
+				return nil, err
+			}
+		}
+	case c == '[':
+		p.pos++
+		var a []interface{}
+		for {
+			p.skipSpace()
+			if p.pos < len(p.b) && p.b[p.pos] == ']' {
+				p.pos++
+				return a, nil
+			}
+			v, err := p.parse()
+			if err != nil {
+				return nil, err
+			}
+			a = append(a, v)
+		}
+	case c == '(':
+		return p.literalString()
+	case c == '<':
+		end := bytes.IndexByte(p.b[p.pos:], '>')
+		if end < 0 {
+			return nil, fmt.Errorf("unterminated PDF hex string at %d", p.pos)
+		}
+		hex := bytes.Map(func(r rune) rune {
+			if isPDFSpace(byte(r)) {
+				return -1
+			}
+			return r
+		}, p.b[p.pos+1:p.pos+end])
+		p.pos += end + 1
+		if len(hex)%2 == 1 {
+			hex = append(hex, '0')
+		}
+		s := make([]byte, len(hex)/2)
+		for i := range s {
+			v, err := strconv.ParseUint(string(hex[2*i:2*i+2]), 16, 8)
+			if err != nil {
+				return nil, fmt.Errorf("invalid PDF hex string at %d", p.pos)
+			}
+			s[i] = byte(v)
+		}
+		return pdfText(s), nil
+	case c == '/':
+		p.pos++
+		tok := p.token()
+		// The names can have #xx escapes.
+		var name []byte
+		for i := 0; i < len(tok); i++ {
+			if tok[i] == '#' && i+2 < len(tok) {
+				if v, err := strconv.ParseUint(tok[i+1:i+3], 16, 8); err == nil {
+					name = append(name, byte(v))
+					i += 2
+					continue
+				}
+			}
+			name = append(name, tok[i])
+		}
+		return pdfName(name), nil
+	default:
+		tok := p.token()
+		if tok == "" {
+			return nil, fmt.Errorf("unexpected %q in PDF at %d", c, p.pos)
+		}
+		switch tok {
+		case "true", "false":
+			return tok == "true", nil
+		case "null":
+			return nil, nil
+		}
+		n, err := strconv.ParseFloat(tok, 64)
+		if err != nil {
+			return nil, fmt.Errorf("unexpected %q in PDF at %d", tok, p.pos)
+		}
+		// An indirect reference is two integers followed by R.
+		if num, err := strconv.Atoi(tok); err == nil {
+			save := p.pos
+			p.skipSpace()
+			if gen, err := strconv.Atoi(p.token()); err == nil {
+				p.skipSpace()
+				if p.token() == "R" {
+					return pdfRef{num, gen}, nil
+				}
+			}
+			p.pos = save
+		}
+		return n, nil
+	}
+}
+
+// literalString parses a string in parentheses.
+func (p *pdfParser) literalString() (interface{}, error) {
+	var s []byte
+	depth := 0
+	for p.pos++; p.pos < len(p.b); p.pos++ {
+		c := p.b[p.pos]
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			if depth == 0 {
+				p.pos++
+				return pdfText(s), nil
+			}
+			depth--
+		case '\\':
+			p.pos++
+			if p.pos >= len(p.b) {
+				break
+			}
+			switch e := p.b[p.pos]; e {
+			case 'n':
+				c = '\n'
+			case 'r':
+				c = '\r'
+			case 't':
+				c = '\t'
+			case 'b':
+				c = '\b'
+			case 'f':
+				c = '\f'
+			case '\r', '\n':
+				// A line continuation.
+				if e == '\r' && p.pos+1 < len(p.b) && p.b[p.pos+1] == '\n' {
+					p.pos++
+				}
+				continue
+			default:
+				if e >= '0' && e <= '7' {
+					v := 0
+					for i := 0; i < 3 && p.pos < len(p.b) && p.b[p.pos] >= '0' && p.b[p.pos] <= '7'; i++ {
+						v = v*8 + int(p.b[p.pos]-'0')
+						p.pos++
+					}
+					p.pos--
+					c = byte(v)
+				} else {
+					c = e
+				}
+			}
+		}
+		s = append(s, c)
+	}
+	return nil, fmt.Errorf("unterminated PDF string")
+}
+
+// pdfText returns the text of a PDF string, in UTF-16 when it starts with its
+// byte order mark.
+func pdfText(s []byte) string {
+	if len(s) < 2 || s[0] != 0xfe || s[1] != 0xff {
+		return string(s)
+	}
+	u := make([]uint16, (len(s)-2)/2)
+	for i := range u {
+		u[i] = uint16(s[2+2*i])<<8 | uint16(s[3+2*i])
+	}
+	return string(utf16.Decode(u))
+}
+
+// rePDFObj matches the start of an indirect object, e.g. "12 0 obj".
+var rePDFObj = regexp.MustCompile(`(\d+)\s+(\d+)\s+obj\b`)
+
+// pdfObjects returns the objects of the given PDF by number, including those
+// kept in its object streams, e.g. by pdflatex.
+func pdfObjects(pdf []byte) map[int]interface{} {
+	objs := map[int]interface{}{}
+	type objStm struct {
+		data     []byte
+		n, first int
+	}
+	var objStms []objStm
+	for pos := 0; pos < len(pdf); {
+		loc := rePDFObj.FindSubmatchIndex(pdf[pos:])
+		if loc == nil {
+			break
+		}
+		num, _ := strconv.Atoi(string(pdf[pos+loc[2] : pos+loc[3]]))
+		p := &pdfParser{b: pdf, pos: pos + loc[1]}
+		v, err := p.parse()
+		if err != nil {
+			pos += loc[1]
+			continue
+		}
+		objs[num] = v
+		pos = p.pos
+		p.skipSpace()
+		if !bytes.HasPrefix(pdf[p.pos:], []byte("stream")) {
+			continue
+		}
+		// The streams are skipped, but those of the object streams.
+		start := p.pos + len("stream")
+		if bytes.HasPrefix(pdf[start:], []byte("\r\n")) {
+			start += 2
+		} else if start < len(pdf) && pdf[start] == '\n' {
+			start++
+		}
+		end := -1
+		d, _ := v.(pdfDict)
+		if n, ok := d["Length"].(float64); ok && start+int(n) <= len(pdf) {
+			end = start + int(n)
+		} else if i := bytes.Index(pdf[start:], []byte("endstream")); i >= 0 {
+			end = start + i
+		}
+		if end < 0 {
+			break
+		}
+		n, _ := d["N"].(float64)
+		first, _ := d["First"].(float64)
+		if d["Type"] == pdfName("ObjStm") {
+			if data := pdfStreamData(d, pdf[start:end]); data != nil {
+				objStms = append(objStms, objStm{data, int(n), int(first)})
+			}
+		}
+		pos = end
+	}
+	// The object streams start with the numbers and offsets of their objects.
+	for _, stm := range objStms {
+		p := &pdfParser{b: stm.data}
+		for j := 0; j < stm.n; j++ {
+			num, err1 := p.parse()
+			off, err2 := p.parse()
+			n, ok1 := num.(float64)
+			o, ok2 := off.(float64)
+			if err1 != nil || err2 != nil || !ok1 || !ok2 || stm.first+int(o) >= len(stm.data) {
+				break
+			}
+			if v, err := (&pdfParser{b: stm.data, pos: stm.first + int(o)}).parse(); err == nil {
+				objs[int(n)] = v
+			}
+		}
+	}
+	return objs
+}
+
+// pdfStreamData returns the decoded data of a stream, nil when it is not
+// compressed with Flate without predictor.
+func pdfStreamData(d pdfDict, raw []byte) []byte {
+	filter := d["Filter"]
+	if a, ok := filter.([]interface{}); ok && len(a) == 1 {
+		filter = a[0]
+	}
+	if filter != pdfName("FlateDecode") || d["DecodeParms"] != nil {
+		return nil
+	}
+	r, err := zlib.NewReader(bytes.NewReader(raw))
+	if err != nil {
+		return nil
+	}
+	// A truncated stream still has its first objects.
+	b, _ := ioutil.ReadAll(r)
+	return b
+}
+
+// pdfDestinations returns the named destinations of the given PDF, from the
+// Dests name tree of its catalog or the older Dests dictionary, and whether
+// each one points to a page of the document, as the links to it need.
+func pdfDestinations(pdf []byte) map[string]bool {
+	objs := pdfObjects(pdf)
+	resolve := func(v interface{}) interface{} {
+		for i := 0; i < 32; i++ {
+			ref, ok := v.(pdfRef)
+			if !ok {
+				break
+			}
+			v = objs[ref.num]
+		}
+		return v
+	}
+	isPage := func(dest interface{}) bool {
+		dest = resolve(dest)
+		if d, ok := dest.(pdfDict); ok {
+			dest = resolve(d["D"])
+		}
+		a, ok := dest.([]interface{})
+		if !ok || len(a) == 0 {
+			return false
+		}
+		page, ok := resolve(a[0]).(pdfDict)
+		return ok && page["Type"] == pdfName("Page")
+	}
+
+	dests := map[string]bool{}
+	var walk func(node interface{}, depth int)
+	walk = func(node interface{}, depth int) {
+		n, ok := resolve(node).(pdfDict)
+		if !ok || depth > 32 {
+			return
+		}
+		if names, ok := resolve(n["Names"]).([]interface{}); ok {
+			for i := 0; i+1 < len(names); i += 2 {
+				if name, ok := resolve(names[i]).(string); ok {
+					dests[name] = isPage(names[i+1])
+				}
+			}
+		}
+		if kids, ok := resolve(n["Kids"]).([]interface{}); ok {
+			for _, k := range kids {
+				walk(k, depth+1)
+			}
+		}
+	}
+	for _, o := range objs {
+		catalog, ok := o.(pdfDict)
+		if !ok || catalog["Type"] != pdfName("Catalog") {
+			continue
+		}
+		if names, ok := resolve(catalog["Names"]).(pdfDict); ok {
+			walk(names["Dests"], 0)
+		}
+		if old, ok := resolve(catalog["Dests"]).(pdfDict); ok {
+			for name, dest := range old {
+				dests[string(name)] = isPage(dest)
+			}
+		}
+	}
+	return dests
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"html/template"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/daedaleanai/reqtraq/config"
+)
+
+// rePlaceholder matches the placeholders in the title and the body of the
+// requirements, e.g. "{component}", replaced in the reports with the values
+// of their parameter attributes, e.g. "Param (component): Left wing", so the
+// families of near-identical requirements share the same text.
+var rePlaceholder = regexp.MustCompile(`\{(\w+)\}`)
+
+// reParamName matches the names of the parameters.
+var reParamName = regexp.MustCompile(`^\w+$`)
+
+// paramAttribute returns the name of the attribute holding the value of the
+// given placeholder, e.g. "Param (component)".
+func paramAttribute(name string) string {
+	return "PARAM (" + strings.ToUpper(name) + ")"
+}
+
+// isParamAttribute returns whether the attribute holds the value of a placeholder.
+func isParamAttribute(key string) bool {
+	return strings.HasPrefix(key, "PARAM (")
+}
+
+// hasParams returns whether the requirement has parameter attributes. The
+// text of the requirements without any is used as it is, so it can contain
+// braces, e.g. in code samples.
+func (r *Req) hasParams() bool {
+	for k := range r.Attributes {
+		if isParamAttribute(k) {
+			return true
+		}
+	}
+	return false
+}
+
+// Expand returns the text with the placeholders replaced with the values of
+// the parameter attributes of the requirement. The placeholders without
+// value are kept as they are, see CheckPlaceholders.
+func (r *Req) Expand(s string) string {
+	if !r.hasParams() {
+		return s
+	}
+	return rePlaceholder.ReplaceAllStringFunc(s, func(p string) string {
+		if v, ok := r.Attributes[paramAttribute(p[1:len(p)-1])]; ok {
+			return v
+		}
+		return p
+	})
+}
+
+// ExpandedBody returns the body of the requirement with the placeholders
+// replaced with the HTML-escaped values of the parameter attributes, and the
+// requirements it mentions linked, see linkMentions.
+func (r *Req) ExpandedBody() template.HTML {
+	if !r.hasParams() {
+		return r.linkMentions(r.Body)
+	}
+	return r.linkMentions(template.HTML(rePlaceholder.ReplaceAllStringFunc(string(r.Body), func(p string) string {
+		if v, ok := r.Attributes[paramAttribute(p[1:len(p)-1])]; ok {
+			return template.HTMLEscapeString(v)
+		}
+		return p
+	})))
+}
+
+// CheckPlaceholders checks that the placeholders in the title and the body of
+// the requirements having parameter attributes all have a value.
+func (rg reqGraph) CheckPlaceholders() []error {
+	var reqs []*Req
+	for _, r := range rg {
+		if r.Level != config.CODE && r.hasParams() {
+			reqs = append(reqs, r)
+		}
+	}
+	sort.Sort(byIDs(reqs))
+	var errs []error
+	for _, r := range reqs {
+		seen := map[string]bool{}
+		for _, m := range rePlaceholder.FindAllStringSubmatch(r.Title+"\n"+string(r.Body), -1) {
+			if _, ok := r.Attributes[paramAttribute(m[1])]; !ok && !seen[m[1]] {
+				seen[m[1]] = true
+				errs = append(errs, newFinding("placeholder", "Requirement '%s' has placeholder '%s' without value. Expected attribute 'Param (%s)'.\n", r.ID, m[0], m[1]))
+			}
+		}
+	}
+	return errs
+}
+
+// paramFuncs allow the report templates to skip the parameter attributes,
+// which are shown in the text of the requirements.
+var paramFuncs = template.FuncMap{"isParam": isParamAttribute}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/daedaleanai/reqtraq/config"
+)
+
+// The placements of the @llr tags, see RepoConfig.TagPlacement.
+const (
+	// placementHeader is in the comments at the top of the file.
+	placementHeader = "header"
+	// placementFunction is in the comments immediately above a top level
+	// declaration, e.g. a function, not indented.
+	placementFunction = "function"
+)
+
+// tagPlacementDescriptions describe the placements in the problems.
+var tagPlacementDescriptions = map[string]string{
+	placementHeader:   "in the header of the file",
+	placementFunction: "immediately above a function",
+}
+
+// reCPreprocessor matches the C preprocessor directives, which unlike the
+// other lines starting with # are not comments.
+var reCPreprocessor = regexp.MustCompile(`^#\s*(include|import|define|undef|if|ifdef|ifndef|elif|else|endif|pragma|error|line)\b`)
+
+// isCommentLine returns whether the given line is only a comment.
+func isCommentLine(line string) bool {
+	line = strings.TrimSpace(line)
+	for _, p := range []string{"//", "/*", "*", "--"} {
+		if strings.HasPrefix(line, p) {
+			return true
+		}
+	}
+	return strings.HasPrefix(line, "#") && !reCPreprocessor.MatchString(line)
+}
+
+// tagPlacements returns the placements of the tags of the given lines of a
+// code file, by line number: a tag is in the header when only comments and
+// blank lines precede it, and above a function when the comment block it is
+// in is followed by a line which is not indented.
+func tagPlacements(lines []string, tagLines []int) map[int]map[string]bool {
+	isTag := map[int]bool{}
+	for _, l := range tagLines {
+		isTag[l] = true
+	}
+	res := map[int]map[string]bool{}
+	header := true
+	var pending []int // The tags of the current comment block.
+	for i, line := range lines {
+		lno := i + 1
+		comment := isCommentLine(line)
+		if isTag[lno] {
+			res[lno] = map[string]bool{placementHeader: header && comment}
+			if comment {
+				pending = append(pending, lno)
+			}
+		}
+		switch {
+		case comment:
+		case strings.TrimSpace(line) == "":
+			pending = nil
+		default:
+			header = false
+			if line[0] != ' ' && line[0] != '\t' {
+				for _, l := range pending {
+					res[l][placementFunction] = true
+				}
+			}
+			pending = nil
+		}
+	}
+	return res
+}
+
+// CheckTagPlacement checks that the @llr tags of the code files are in one of
+// the given placements, so the reviewers do not miss them, e.g. in the middle
+// of a function. The hardware design artifacts and the test suites, see
+// isTestSuiteFile, are not checked.
+func (rg reqGraph) CheckTagPlacement(placements []string) []error {
+	if len(placements) == 0 {
+		return nil
+	}
+	var files []*Req
+	for _, r := range rg {
+		if r.Level == config.CODE && r.Kind == "" && len(r.RefLines) > 0 {
+			files = append(files, r)
+		}
+	}
+	sort.Sort(byIDs(files))
+	var descriptions []string
+	for _, p := range placements {
+		descriptions = append(descriptions, tagPlacementDescriptions[p])
+	}
+	var errs []error
+	for _, r := range files {
+		b, err := os.ReadFile(r.Path)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("File '%s' cannot be read: %v.\n", r.ID, err))
+			continue
+		}
+		found := tagPlacements(strings.Split(strings.ReplaceAll(toUTF8(string(b)), "\r\n", "\n"), "\n"), r.RefLines)
+	tags:
+		for i, l := range r.RefLines {
+			for _, p := range placements {
+				if found[l][p] {
+					continue tags
+				}
+			}
+			errs = append(errs, fmt.Errorf("File '%s' has the tag of '%s' on line %d, expected %s.\n", r.ID, r.ParentIds[i], l, strings.Join(descriptions, " or ")))
+		}
+	}
+	return errs
+}
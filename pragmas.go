@@ -0,0 +1,124 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/daedaleanai/reqtraq/config"
+)
+
+// rePragma matches the pragmas suppressing findings of the next requirement of
+// a certification document, e.g. "<!-- reqtraq:ignore missing-attribute
+// RATIONALE -->" in Markdown, or "reqtraq:ignore missing-attribute RATIONALE"
+// in a LyX note.
+var rePragma = regexp.MustCompile(`reqtraq:ignore\s+([\w-]+)(?:[ \t]+([^<>]*?))?\s*(?:-->|$)`)
+
+// Suppression is a pragma suppressing the findings of a requirement of the
+// given check, a finding type, see findingTypes, or missing-attribute, and
+// when given, mentioning the argument, e.g. the name of the attribute.
+type Suppression struct {
+	Check string
+	Arg   string
+	Line  int // In the certification document.
+}
+
+// findingType returns the finding type of the check, which can be written
+// with dashes.
+func (s Suppression) findingType() string {
+	if t := strings.ReplaceAll(s.Check, "-", "_"); t != "missing_attribute" {
+		return t
+	}
+	return "attribute"
+}
+
+// matches returns whether the suppression of the requirement with the given ID
+// matches the given finding.
+func (s Suppression) matches(id string, f error) bool {
+	line := f.Error()
+	if findingType(f) != s.findingType() || ReReqID.FindString(line) != id {
+		return false
+	}
+	if strings.ReplaceAll(s.Check, "-", "_") == "missing_attribute" && !strings.Contains(line, "is missing attribute") {
+		return false
+	}
+	return s.Arg == "" || strings.Contains(strings.ToUpper(line), strings.ToUpper(s.Arg))
+}
+
+// docPragmas returns the suppressions of the requirements of the given
+// certification document, by ID. A pragma applies to the next requirement.
+func docPragmas(fileName string, lines []string) (map[string][]Suppression, []error) {
+	types := map[string]bool{}
+	for _, t := range findingTypes {
+		types[t] = true
+	}
+	type start struct {
+		line int
+		id   string
+	}
+	var starts []start
+	for id, r := range reqLineRanges(fileName, lines) {
+		starts = append(starts, start{r[0], id})
+	}
+	sort.Slice(starts, func(i, j int) bool { return starts[i].line < starts[j].line })
+
+	res := map[string][]Suppression{}
+	var errs []error
+	for i, l := range lines {
+		parts := rePragma.FindStringSubmatch(toUTF8(l))
+		if parts == nil {
+			continue
+		}
+		s := Suppression{Check: parts[1], Arg: strings.TrimSpace(parts[2]), Line: i + 1}
+		if !types[s.findingType()] {
+			errs = append(errs, fmt.Errorf("Unknown check %q in the reqtraq:ignore pragma on line %d of %s", s.Check, i+1, fileName))
+			continue
+		}
+		n := sort.Search(len(starts), func(j int) bool { return starts[j].line > i })
+		if n == len(starts) {
+			errs = append(errs, fmt.Errorf("The reqtraq:ignore pragma on line %d of %s is followed by no requirement", i+1, fileName))
+			continue
+		}
+		res[starts[n].id] = append(res[starts[n].id], s)
+	}
+	return res, errs
+}
+
+// Suppress removes from the findings reported by a check those suppressed by
+// the pragmas of the requirements. It returns the remaining findings, nil
+// when none, and the number of suppressed ones.
+func (rg reqGraph) Suppress(findings error) (error, int) {
+	var res findingList
+	n := 0
+next:
+	for _, f := range flattenFindings(findings) {
+		if r, ok := rg[ReReqID.FindString(f.Error())]; ok {
+			for _, s := range r.Suppressions {
+				if s.matches(r.ID, f) {
+					n++
+					continue next
+				}
+			}
+		}
+		res = append(res, f)
+	}
+	if len(findingsOf(res)) == 0 {
+		// Only headers such as "Problems found while parsing ..." are left.
+		return nil, n
+	}
+	return res, n
+}
+
+// SuppressedReqs returns the requirements with suppressions, sorted by ID,
+// for the appendix of the reports.
+func (rg reqGraph) SuppressedReqs() []*Req {
+	var res []*Req
+	for _, r := range rg {
+		if r.Level != config.CODE && len(r.Suppressions) > 0 {
+			res = append(res, r)
+		}
+	}
+	sort.Sort(byIDs(res))
+	return res
+}
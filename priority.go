@@ -0,0 +1,101 @@
+package main
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/daedaleanai/reqtraq/config"
+)
+
+// PriorityStats are the progress numbers of the requirements of a priority,
+// their PRIORITY attribute, empty for the requirements without it. A
+// requirement is fully traced when it has both code and tests among its
+// descendants, and partially traced when it has children, code or tests, but
+// not both code and tests.
+type PriorityStats struct {
+	Priority   string  `json:"priority"`
+	Total      int     `json:"total"`
+	Traced     int     `json:"fully_traced"`
+	Partial    int     `json:"partially_traced"`
+	Untraced   int     `json:"untraced"`
+	PctTraced  float64 `json:"pct_fully_traced"`
+	PctPartial float64 `json:"pct_partially_traced"`
+}
+
+// PriorityStats returns the progress numbers of the system requirements and
+// HLRs by priority, in the given order, the highest first, followed by the
+// other priorities found, sorted, and by the requirements without priority.
+// Nothing is returned when no requirement has a priority.
+func (rg reqGraph) PriorityStats(priorities []string) []PriorityStats {
+	stats := map[string]*PriorityStats{}
+	for _, r := range rg {
+		if (r.Level != config.SYSTEM && r.Level != config.HIGH) || r.IsDeleted() {
+			continue
+		}
+		p := r.Attributes["PRIORITY"]
+		s, ok := stats[p]
+		if !ok {
+			s = &PriorityStats{Priority: p}
+			stats[p] = s
+		}
+		s.Total++
+		switch code, tests := r.tracedToCode(); {
+		case code && tests:
+			s.Traced++
+		case code || tests || len(r.Children) > 0:
+			s.Partial++
+		default:
+			s.Untraced++
+		}
+	}
+	if len(stats) == 0 || (len(stats) == 1 && stats[""] != nil) {
+		return nil
+	}
+
+	rank := map[string]int{}
+	for i, p := range priorities {
+		rank[p] = i + 1
+	}
+	var res []PriorityStats
+	for _, s := range stats {
+		s.PctTraced = percent(s.Traced, s.Total)
+		s.PctPartial = percent(s.Partial, s.Total)
+		res = append(res, *s)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		a, b := res[i].Priority, res[j].Priority
+		if (a == "") != (b == "") {
+			return b == ""
+		}
+		if ra, rb := rank[a], rank[b]; ra != rb {
+			return rb == 0 || (ra != 0 && ra < rb)
+		}
+		return a < b
+	})
+	return res
+}
+
+// CheckPriorities returns the errors of the requirements whose PRIORITY is not
+// one of the given priorities, when any.
+func (rg reqGraph) CheckPriorities(priorities []string) []error {
+	if len(priorities) == 0 {
+		return nil
+	}
+	known := map[string]bool{}
+	for _, p := range priorities {
+		known[p] = true
+	}
+	var reqs []*Req
+	for _, r := range rg {
+		if p, ok := r.Attributes["PRIORITY"]; ok && r.Level != config.CODE && !known[p] {
+			reqs = append(reqs, r)
+		}
+	}
+	sort.Sort(byIDs(reqs))
+	var errs []error
+	for _, r := range reqs {
+		errs = append(errs, newFinding("attribute", "Requirement '%s' has invalid value '%s' in attribute 'PRIORITY'. Expected one of %s.\n",
+			r.ID, r.Attributes["PRIORITY"], strings.Join(priorities, ", ")))
+	}
+	return errs
+}
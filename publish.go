@@ -0,0 +1,281 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/daedaleanai/reqtraq/git"
+)
+
+// publishBaseline is the baseline the linkified documents list the changes
+// of their requirements since, see appendChangeLog.
+type publishBaseline struct {
+	name    string // The commit, see versionName.
+	rg, prg reqGraph
+}
+
+// newPublishBaseline builds the graphs of the working tree and of the given
+// baseline commit, once for all the documents being published.
+func newPublishBaseline(base string) (*publishBaseline, error) {
+	b := &publishBaseline{name: base}
+	var err error
+	if b.prg, err = buildGraph(base); b.prg == nil {
+		return nil, err
+	}
+	if b.rg, err = buildGraph(""); b.rg == nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// changes returns the changes of the requirements of the given document.
+func (b *publishBaseline) changes(fileName string) (*Changelog, error) {
+	abs, err := filepath.Abs(fileName)
+	if err != nil {
+		return nil, err
+	}
+	docPath := "/" + repoRelative(abs)
+	return NewChangelog(b.rg.documentGraph(docPath), b.prg.documentGraph(docPath), versionName(b.name), versionName("")), nil
+}
+
+// LinkifyDocument writes to output the given Markdown or LyX certification
+// document with named destinations and links to the parent requirements, when
+// base is not nil with the changes of its requirements appended, and when the
+// artifacts are stamped with the stamp in the metadata of its PDF.
+func LinkifyDocument(fileName, output string, base *publishBaseline) error {
+	var b bytes.Buffer
+	var err error
+	if docFormat(fileName) == "md" {
+		err = LinkifyMarkdown(fileName, &b)
+	} else {
+		_, err = ParseLyx(fileName, &b)
+	}
+	if err != nil {
+		return err
+	}
+	contents := b.Bytes()
+	if base != nil {
+		c, err := base.changes(fileName)
+		if err != nil {
+			return err
+		}
+		contents = appendChangeLog(contents, docFormat(fileName), c)
+	}
+	if artifactStamp != nil {
+		contents = stampDocument(contents, docFormat(fileName), artifactStamp)
+	}
+	return ioutil.WriteFile(output, contents, 0644)
+}
+
+// forEachDocument calls f for each of the given documents with the given
+// number of workers, and returns the problems of all the documents for which
+// it failed, one per line, by document, the action failing being e.g.
+// "linkified".
+func forEachDocument(docs []string, jobs int, action string, f func(doc string) error) error {
+	if jobs < 1 {
+		jobs = 1
+	}
+	// The git package caches the repository by working directory, fill the
+	// caches before they are read concurrently.
+	if _, err := git.RepoPath(); err != nil {
+		return err
+	}
+	if _, err := git.RepoName(); err != nil {
+		return err
+	}
+
+	queue := make(chan string)
+	var mu sync.Mutex
+	var problems []string
+	var wg sync.WaitGroup
+	for i := 0; i < jobs; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for doc := range queue {
+				err := f(doc)
+				if err == nil {
+					slog.Debug(action, "doc", doc)
+					continue
+				}
+				mu.Lock()
+				problems = append(problems, fmt.Sprintf("%s: %v", repoRelative(doc), strings.TrimSpace(err.Error())))
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, doc := range docs {
+		queue <- doc
+	}
+	close(queue)
+	wg.Wait()
+
+	if len(problems) == 0 {
+		return nil
+	}
+	sort.Strings(problems)
+	return fmt.Errorf("%d of %d documents could not be %s:\n%s", len(problems), len(docs), action, strings.Join(problems, "\n"))
+}
+
+// LinkifyDocuments linkifies the given documents into outDir, keeping their
+// file names, with the given number of workers. It returns the problems of all
+// the documents which could not be linkified, one per line, by document.
+func LinkifyDocuments(docs []string, outDir string, jobs int, base *publishBaseline) error {
+	return forEachDocument(docs, jobs, "linkified", func(doc string) error {
+		return LinkifyDocument(doc, filepath.Join(outDir, filepath.Base(doc)), base)
+	})
+}
+
+// PublishConfig configures the publish command.
+type PublishConfig struct {
+	// Output is the directory of the published documents, the default of --out-dir.
+	Output string `yaml:"output,omitempty"`
+	// Commands are the shell commands converting the linkified documents to
+	// PDF, by format, lyx or md, see defaultPublishCommands.
+	Commands map[string]string `yaml:"commands,omitempty"`
+}
+
+// defaultPublishCommands are the commands converting the documents to PDF by
+// default. They get the linkified document in INPUT, the PDF to create in
+// OUTPUT, and run in the directory of the source document, so the paths of
+// the images are resolved as when editing it.
+var defaultPublishCommands = map[string]string{
+	"lyx": `lyx --export-to pdf4 "$OUTPUT" "$INPUT"`,
+	"md":  `pandoc "$INPUT" -o "$OUTPUT"`,
+}
+
+// pdfFileName returns the name of the PDF of the given document.
+func pdfFileName(doc string) string {
+	base := filepath.Base(doc)
+	return strings.TrimSuffix(base, filepath.Ext(base)) + ".pdf"
+}
+
+// convertDocument converts the linkified document to the given PDF with the
+// configured command, in the directory of the source document.
+func convertDocument(doc, linked, pdf string, commands map[string]string) error {
+	command := commands[docFormat(doc)]
+	if command == "" {
+		command = defaultPublishCommands[docFormat(doc)]
+	}
+	cmd := exec.Command("sh", "-c", command)
+	cmd.Dir = filepath.Dir(doc)
+	cmd.Env = append(os.Environ(), "INPUT="+linked, "OUTPUT="+pdf)
+	if artifactStamp != nil {
+		cmd.Env = append(cmd.Env, "REQTRAQ_STAMP="+artifactStamp.String())
+	}
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		if out := strings.TrimSpace(string(out)); out != "" {
+			return fmt.Errorf("%s: %v: %s", command, err, out)
+		}
+		return fmt.Errorf("%s: %v", command, err)
+	}
+	if _, err := os.Stat(pdf); err != nil {
+		return fmt.Errorf("%s did not create %s", command, pdf)
+	}
+	return nil
+}
+
+// reqPDF returns the name of the published document the links to the given
+// requirement point to, see reqURL.
+func reqPDF(reqID string) string {
+	parts := ReReqID.FindStringSubmatch(reqID)
+	if parts == nil {
+		return ""
+	}
+	return parts[1] + "-" + parts[2] + "-" + docNamePerReqIDType[parts[3]] + ".pdf"
+}
+
+// CheckAnchors returns the requirements of the given documents whose links,
+// see reqURL, would not find their named destination in the PDFs published in
+// outDir: the PDF named after their type was not published, e.g. because their
+// document is not named after it, or it has no destination for them, or one
+// not pointing to a page.
+func CheckAnchors(docs []string, outDir string) ([]error, error) {
+	dests := map[string]map[string]bool{} // By PDF, nil when not published.
+	var res []error
+	for _, doc := range docs {
+		txts, err := ParseCertdoc(doc)
+		if err != nil {
+			return nil, fmt.Errorf("Error parsing %s: %v", doc, err)
+		}
+		for _, txt := range txts {
+			r, err := ParseReq(txt)
+			if err != nil {
+				return nil, fmt.Errorf("Error parsing %s: %v", doc, err)
+			}
+			pdf := filepath.Join(outDir, reqPDF(r.ID))
+			if _, ok := dests[pdf]; !ok {
+				b, err := ioutil.ReadFile(pdf)
+				switch {
+				case os.IsNotExist(err):
+					dests[pdf] = nil
+				case err != nil:
+					return nil, err
+				default:
+					dests[pdf] = pdfDestinations(b)
+				}
+			}
+			page, ok := dests[pdf][r.ID]
+			switch {
+			case dests[pdf] == nil:
+				res = append(res, newFinding("anchor", "Requirement '%s' has no named destination in %s, which was not published.\n", r.ID, repoRelative(pdf)))
+			case !ok:
+				res = append(res, newFinding("anchor", "Requirement '%s' has no named destination in %s.\n", r.ID, repoRelative(pdf)))
+			case !page:
+				res = append(res, newFinding("anchor", "Requirement '%s' has a named destination in %s not pointing to a page.\n", r.ID, repoRelative(pdf)))
+			}
+		}
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Error() < res[j].Error() })
+	return res, nil
+}
+
+// Publish linkifies the given documents, see LinkifyDocuments, converts them
+// to PDF into outDir with the given commands, see defaultPublishCommands, and
+// checks that the named destinations of their requirements survived, see
+// CheckAnchors. It returns the requirements without destination, or the
+// problems of all the documents which could not be published.
+func Publish(docs []string, outDir string, commands map[string]string, jobs int, base *publishBaseline) ([]error, error) {
+	work, err := ioutil.TempDir("", "reqtraq-publish")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(work)
+	if err := LinkifyDocuments(docs, work, jobs, base); err != nil {
+		return nil, err
+	}
+	if outDir, err = filepath.Abs(outDir); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(outDir, 0755); err != nil {
+		return nil, err
+	}
+	err = forEachDocument(docs, jobs, "published", func(doc string) error {
+		return convertDocument(doc, filepath.Join(work, filepath.Base(doc)), filepath.Join(outDir, pdfFileName(doc)), commands)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return CheckAnchors(docs, outDir)
+}
+
+// publishedDocuments returns the certification documents in the certdoc
+// paths, those being generated excluded.
+func publishedDocuments() []string {
+	var res []string
+	for _, p := range certdocPaths() {
+		if IsValidDocName(p) == nil {
+			res = append(res, p)
+		}
+	}
+	return res
+}
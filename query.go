@@ -0,0 +1,365 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/daedaleanai/reqtraq/config"
+)
+
+// queryPredicate is a compiled query, see ParseQuery.
+type queryPredicate func(r *Req) bool
+
+// queryFunction describes the arguments of a function of the queries.
+type queryFunction struct {
+	args     string // The description of the arguments.
+	min, max int    // The numbers of arguments, max is -1 when unlimited.
+	regexp   bool   // Whether the last argument is a regular expression.
+}
+
+// queryFunctions are the functions of the queries, compiled to predicates by
+// queryParser.call.
+var queryFunctions = map[string]queryFunction{
+	"ancestors":   {args: "ID", min: 1, max: 1},
+	"attr":        {args: "NAME[, REGEXP]", min: 1, max: 2, regexp: true},
+	"attrCmp":     {args: "NAME, OP, VALUE", min: 3, max: 3},
+	"children":    {args: "ID", min: 1, max: 1},
+	"deleted":     {},
+	"descendants": {args: "ID", min: 1, max: 1},
+	"doc":         {args: "REGEXP", min: 1, max: 1, regexp: true},
+	"hasChildren": {},
+	"hasCodeRef":  {},
+	"hasParents":  {},
+	"id":          {args: "REGEXP", min: 1, max: 1, regexp: true},
+	"level":       {args: "TYPE, ...", min: 1, max: -1},
+	"owner":       {args: "NAME", min: 1, max: 1},
+	"parents":     {args: "ID", min: 1, max: 1},
+	"status":      {args: "STATUS", min: 1, max: 1},
+	"tag":         {args: "TAG", min: 1, max: 1},
+	"title":       {args: "REGEXP", min: 1, max: 1, regexp: true},
+}
+
+// attributeComparisons are the comparisons of attrCmp, by the result of
+// AttributeValue.Compare.
+var attributeComparisons = map[string]func(c int) bool{
+	"==": func(c int) bool { return c == 0 },
+	"!=": func(c int) bool { return c != 0 },
+	"<":  func(c int) bool { return c < 0 },
+	"<=": func(c int) bool { return c <= 0 },
+	">":  func(c int) bool { return c > 0 },
+	">=": func(c int) bool { return c >= 0 },
+}
+
+// queryParser parses the queries, following the grammar:
+//
+//	expr   = term { "|" term }
+//	term   = factor { "&" factor }
+//	factor = "!" factor | "(" expr ")" | name "(" [ arg { "," arg } ] ")"
+//
+// The arguments are the text up to the next comma or parenthesis, or quoted
+// strings, e.g. for the regular expressions containing them.
+type queryParser struct {
+	rg  reqGraph
+	s   string
+	pos int
+}
+
+// ParseQuery compiles the given query, e.g.
+// "descendants(REQ-0-DDLN-SYS-006) & level(SWL) & !hasCodeRef()". The IDs are
+// not checked when the graph is nil, for checking only the syntax.
+func (rg reqGraph) ParseQuery(q string) (queryPredicate, error) {
+	p := &queryParser{rg: rg, s: q}
+	pred, err := p.expr()
+	if err != nil {
+		return nil, err
+	}
+	if p.skipSpace(); p.pos < len(p.s) {
+		return nil, p.errorf("unexpected %q", p.s[p.pos:])
+	}
+	return pred, nil
+}
+
+// Query returns the requirements and the code files matching the given query,
+// sorted by ID.
+func (rg reqGraph) Query(q string) ([]*Req, error) {
+	pred, err := rg.ParseQuery(q)
+	if err != nil {
+		return nil, err
+	}
+	var res []*Req
+	for _, r := range rg {
+		if pred(r) {
+			res = append(res, r)
+		}
+	}
+	sort.Sort(byIDs(res))
+	return res, nil
+}
+
+func (p *queryParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("invalid query at position %d: %s", p.pos+1, fmt.Sprintf(format, args...))
+}
+
+func (p *queryParser) skipSpace() {
+	for p.pos < len(p.s) && unicode.IsSpace(rune(p.s[p.pos])) {
+		p.pos++
+	}
+}
+
+// accept consumes the given character when it is next.
+func (p *queryParser) accept(c byte) bool {
+	p.skipSpace()
+	if p.pos < len(p.s) && p.s[p.pos] == c {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *queryParser) expr() (queryPredicate, error) {
+	left, err := p.term()
+	for err == nil && p.accept('|') {
+		var right queryPredicate
+		if right, err = p.term(); err == nil {
+			l := left
+			left = func(r *Req) bool { return l(r) || right(r) }
+		}
+	}
+	return left, err
+}
+
+func (p *queryParser) term() (queryPredicate, error) {
+	left, err := p.factor()
+	for err == nil && p.accept('&') {
+		var right queryPredicate
+		if right, err = p.factor(); err == nil {
+			l := left
+			left = func(r *Req) bool { return l(r) && right(r) }
+		}
+	}
+	return left, err
+}
+
+func (p *queryParser) factor() (queryPredicate, error) {
+	if p.accept('!') {
+		pred, err := p.factor()
+		if err != nil {
+			return nil, err
+		}
+		return func(r *Req) bool { return !pred(r) }, nil
+	}
+	if p.accept('(') {
+		pred, err := p.expr()
+		if err != nil {
+			return nil, err
+		}
+		if !p.accept(')') {
+			return nil, p.errorf("missing )")
+		}
+		return pred, nil
+	}
+	start := p.pos
+	for p.pos < len(p.s) && (unicode.IsLetter(rune(p.s[p.pos])) || p.s[p.pos] == '_') {
+		p.pos++
+	}
+	name := p.s[start:p.pos]
+	if name == "" {
+		if p.pos == len(p.s) {
+			return nil, p.errorf("unexpected end")
+		}
+		return nil, p.errorf("unexpected %q", p.s[p.pos:p.pos+1])
+	}
+	if _, ok := queryFunctions[name]; !ok {
+		p.pos = start
+		return nil, p.errorf("unknown function %q", name)
+	}
+	if !p.accept('(') {
+		return nil, p.errorf("missing ( after %s", name)
+	}
+	args, err := p.args()
+	if err != nil {
+		return nil, err
+	}
+	return p.call(name, args)
+}
+
+// args parses the arguments of a call, up to the closing parenthesis.
+func (p *queryParser) args() ([]string, error) {
+	var args []string
+	if p.accept(')') {
+		return nil, nil
+	}
+	for {
+		p.skipSpace()
+		if p.pos < len(p.s) && p.s[p.pos] == '"' {
+			end := p.pos + 1
+			for end < len(p.s) && p.s[end] != '"' {
+				if p.s[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(p.s) {
+				return nil, p.errorf("unterminated string")
+			}
+			a, err := strconv.Unquote(p.s[p.pos : end+1])
+			if err != nil {
+				return nil, p.errorf("invalid string: %v", err)
+			}
+			args = append(args, a)
+			p.pos = end + 1
+		} else {
+			end := strings.IndexAny(p.s[p.pos:], ",)")
+			if end < 0 {
+				return nil, p.errorf("missing )")
+			}
+			args = append(args, strings.TrimSpace(p.s[p.pos:p.pos+end]))
+			p.pos += end
+		}
+		if p.accept(')') {
+			return args, nil
+		}
+		if !p.accept(',') {
+			return nil, p.errorf("missing , or )")
+		}
+	}
+}
+
+// call compiles the call of a query function.
+func (p *queryParser) call(name string, args []string) (queryPredicate, error) {
+	f := queryFunctions[name]
+	if len(args) < f.min || f.max >= 0 && len(args) > f.max {
+		return nil, p.errorf("expected %s(%s)", name, f.args)
+	}
+	var re *regexp.Regexp
+	if f.regexp && len(args) == f.max {
+		var err error
+		if re, err = regexp.Compile(args[f.max-1]); err != nil {
+			return nil, p.errorf("invalid regular expression %q: %v", args[f.max-1], err)
+		}
+	}
+	var related map[*Req]bool
+	if f.args == "ID" && p.rg != nil {
+		r, ok := p.rg[args[0]]
+		if !ok {
+			return nil, p.errorf("unknown requirement %s", args[0])
+		}
+		related = relatedReqs(r, name)
+	}
+
+	switch name {
+	case "ancestors", "children", "descendants", "parents":
+		return func(r *Req) bool { return related[r] }, nil
+	case "attr":
+		attr := strings.ToUpper(args[0])
+		return func(r *Req) bool {
+			v, ok := r.attribute(attr)
+			return ok && (re == nil || re.MatchString(v))
+		}, nil
+	case "attrCmp":
+		// The value is parsed as each type the attribute has, see Req.Typed,
+		// the attribute specification being overridable by directory.
+		values := map[string]AttributeValue{}
+		key := func(v AttributeValue) string { return v.Type + ":" + strings.Join(v.Values, "\n") }
+		for _, r := range p.rg {
+			v, ok, err := r.TypedAttribute(args[0])
+			if !ok || err != nil {
+				continue
+			}
+			if _, ok := values[key(v)]; ok {
+				continue
+			}
+			value, err := parseAttributeValue(v.Type, v.Values, args[2])
+			if err != nil {
+				return nil, p.errorf("invalid value %q of %s, expected %v", args[2], args[0], err)
+			}
+			values[key(v)] = value
+		}
+		holds, ok := attributeComparisons[args[1]]
+		if !ok {
+			return nil, p.errorf("unknown comparison %q, expected one of ==, !=, <, <=, >, >=", args[1])
+		}
+		return func(r *Req) bool {
+			v, ok, err := r.TypedAttribute(args[0])
+			if !ok || err != nil {
+				return false
+			}
+			value, ok := values[key(v)]
+			return ok && holds(v.Compare(value))
+		}, nil
+	case "deleted":
+		return (*Req).IsDeleted, nil
+	case "doc":
+		return func(r *Req) bool { return re.MatchString(r.Path) }, nil
+	case "hasChildren":
+		return func(r *Req) bool { return len(r.Children) > 0 }, nil
+	case "hasCodeRef":
+		return func(r *Req) bool {
+			for _, c := range r.Children {
+				if c.Level == config.CODE {
+					return true
+				}
+			}
+			return false
+		}, nil
+	case "hasParents":
+		return func(r *Req) bool { return len(r.Parents) > 0 }, nil
+	case "id":
+		return func(r *Req) bool { return re.MatchString(r.ID) }, nil
+	case "level":
+		levels := map[string]bool{}
+		for _, a := range args {
+			levels[strings.ToUpper(a)] = true
+		}
+		return func(r *Req) bool {
+			if r.Level == config.CODE {
+				return levels["CODE"]
+			}
+			return levels[r.ReqType()]
+		}, nil
+	case "owner":
+		return func(r *Req) bool { return r.isOwnedBy(args[0]) }, nil
+	case "status":
+		status := strings.ReplaceAll(args[0], "_", " ")
+		return func(r *Req) bool { return strings.EqualFold(r.Status.String(), status) }, nil
+	case "tag":
+		tags := tagFilter(args[0])
+		return func(r *Req) bool { return r.hasTag(tags) }, nil
+	default: // title
+		return func(r *Req) bool { return re.MatchString(r.Title) }, nil
+	}
+}
+
+// relatedReqs returns the requirements related to r as given by the name of
+// a query function, e.g. its descendants.
+func relatedReqs(r *Req, name string) map[*Req]bool {
+	res := map[*Req]bool{}
+	var visit func(r *Req, recursive bool, next func(*Req) []*Req)
+	visit = func(r *Req, recursive bool, next func(*Req) []*Req) {
+		for _, n := range next(r) {
+			if !res[n] {
+				res[n] = true
+				if recursive {
+					visit(n, recursive, next)
+				}
+			}
+		}
+	}
+	parents := func(r *Req) []*Req { return r.Parents }
+	children := func(r *Req) []*Req { return r.Children }
+	switch name {
+	case "ancestors":
+		visit(r, true, parents)
+	case "parents":
+		visit(r, false, parents)
+	case "children":
+		visit(r, false, children)
+	default:
+		visit(r, true, children)
+	}
+	return res
+}
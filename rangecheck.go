@@ -0,0 +1,264 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/daedaleanai/reqtraq/config"
+	"github.com/daedaleanai/reqtraq/git"
+)
+
+// CommitCheck is the outcome of validating the requirements at a commit.
+type CommitCheck struct {
+	Commit git.Commit
+	Err    error // The problems found, nil when the requirements are valid.
+	// Reused are the IDs of deleted requirements reused by other requirements
+	// at the commit, see idHistory.
+	Reused []error
+}
+
+// Subject returns the first line of the commit message.
+func (c CommitCheck) Subject() string {
+	return strings.SplitN(strings.TrimSpace(c.Commit.Message), "\n", 2)[0]
+}
+
+// CheckRange validates the requirements at each commit of the given range,
+// e.g. v1.0..HEAD, or only at the merge commits, the oldest first, and finds
+// the IDs reused after the deletion of their requirement, within the range or
+// before it.
+func CheckRange(rangeSpec string, merges bool, certdocPath, codePath string) ([]CommitCheck, error) {
+	commits, err := git.RangeLog(rangeSpec, merges)
+	if err != nil {
+		return nil, err
+	}
+	var res []CommitCheck
+	h := newIDHistory()
+	if len(commits) > 0 {
+		// The history starts with the requirements before the range, unless
+		// it starts with the first commit.
+		if parent, err := git.ResolveCommit(commits[0].ID + "^"); err == nil {
+			rg, err := CreateReqGraphAt(parent, certdocPath, codePath)
+			if rg == nil {
+				return nil, err
+			}
+			if _, err := h.update(rg); err != nil {
+				return nil, err
+			}
+		}
+	}
+	d := newDeletions(certdocPath, codePath)
+	for _, c := range commits {
+		rg, err := CreateReqGraphAt(c.ID, certdocPath, codePath)
+		if rg == nil {
+			return nil, err
+		}
+		h.before = func(id string) (*Req, error) {
+			return d.last(c.ID+"^@", id)
+		}
+		reused, herr := h.update(rg)
+		if herr != nil {
+			return nil, herr
+		}
+		res = append(res, CommitCheck{Commit: c, Err: err, Reused: reused})
+	}
+	return res, nil
+}
+
+// CheckReusedIDs returns the requirements of the graph of the working tree
+// reusing the ID of a requirement deleted in the history of HEAD, see
+// CheckRange. Only the IDs which are not in the certification documents at
+// HEAD are looked up in the history.
+func (rg reqGraph) CheckReusedIDs(certdocPath, codePath string) ([]error, error) {
+	if _, err := git.ResolveCommit("HEAD"); err != nil {
+		// Nothing committed yet.
+		return nil, nil
+	}
+	files, err := git.ListFiles("HEAD", certdocRoots(certdocPath)...)
+	if err != nil {
+		return nil, err
+	}
+	var certdocs []string
+	for _, f := range files {
+		switch strings.ToLower(path.Ext(f)) {
+		case ".lyx", ".md":
+			certdocs = append(certdocs, f)
+		}
+	}
+	committed := map[string]bool{}
+	err = git.ReadFiles("HEAD", certdocs, func(f string, contents []byte) error {
+		for _, id := range ReReqID.FindAllString(string(contents), -1) {
+			committed[id] = true
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	d := newDeletions(certdocPath, codePath)
+	h := newIDHistory()
+	h.before = func(id string) (*Req, error) {
+		if committed[id] {
+			return nil, nil
+		}
+		return d.last("HEAD", id)
+	}
+	return h.update(rg)
+}
+
+// deletions finds the requirements deleted in the history, building the graph
+// before each commit deleting them once.
+type deletions struct {
+	certdocPath, codePath string
+	graphs                map[string]reqGraph // Before the commits, by commit.
+}
+
+func newDeletions(certdocPath, codePath string) *deletions {
+	return &deletions{certdocPath: certdocPath, codePath: codePath, graphs: map[string]reqGraph{}}
+}
+
+// last returns the last version of the requirement with the given ID in the
+// history of the given revisions, if it was deleted there: that before the
+// last commit changing the number of occurrences of the ID in the
+// certification documents. It is nil when the ID was never used.
+func (d *deletions) last(revs, id string) (*Req, error) {
+	commit, err := git.LastChange(revs, id, certdocRoots(d.certdocPath)...)
+	if err != nil || commit == "" {
+		return nil, err
+	}
+	rg, ok := d.graphs[commit]
+	if !ok {
+		// No graph when the commit is the first one, the problems of the
+		// requirements at the time do not matter.
+		rg, _ = CreateReqGraphAt(commit+"^", d.certdocPath, d.codePath)
+		d.graphs[commit] = rg
+	}
+	if r := rg[id]; r != nil && r.Level != config.CODE {
+		return r, nil
+	}
+	return nil, nil
+}
+
+// idHistory follows the requirements over the commits, to find the IDs reused
+// after the deletion of their requirement, removed or marked DELETED, by a
+// requirement with a different title or body.
+type idHistory struct {
+	live, deleted map[string]*Req // The last version of the requirements, by ID.
+	// before returns the last version of the requirement with the given ID
+	// if it was deleted before the history, see deletions.last. Optional.
+	before func(id string) (*Req, error)
+}
+
+func newIDHistory() *idHistory {
+	return &idHistory{live: map[string]*Req{}, deleted: map[string]*Req{}}
+}
+
+// sameContent returns whether the requirements have the same title and body,
+// modulo spaces and punctuation, as compared by ChangedSince, and the DELETED
+// mark.
+func sameContent(r, o *Req) bool {
+	return onlyLetters(undeletedTitle(r)) == onlyLetters(undeletedTitle(o)) && onlyLetters(string(r.Body)) == onlyLetters(string(o.Body))
+}
+
+// undeletedTitle returns the title of the requirement without the DELETED mark.
+func undeletedTitle(r *Req) string {
+	return strings.TrimSpace(strings.TrimPrefix(r.Title, "DELETED"))
+}
+
+// update records the requirements of the graph at the next commit, and
+// returns the IDs reused since the previous one.
+func (h *idHistory) update(rg reqGraph) ([]error, error) {
+	var reused []*Req
+	previous := map[*Req]*Req{}
+	seen := map[string]bool{}
+	for _, r := range rg {
+		if r.Level == config.CODE {
+			continue
+		}
+		if r.IsDeleted() {
+			// Deleted before the history, or the live version is kept.
+			if h.live[r.ID] == nil && h.deleted[r.ID] == nil {
+				h.deleted[r.ID] = r
+			}
+			continue
+		}
+		seen[r.ID] = true
+		d := h.deleted[r.ID]
+		if d == nil && h.live[r.ID] == nil && h.before != nil {
+			var err error
+			if d, err = h.before(r.ID); err != nil {
+				return nil, err
+			}
+		}
+		if d != nil {
+			delete(h.deleted, r.ID)
+			if !sameContent(r, d) {
+				reused = append(reused, r)
+				previous[r] = d
+			}
+		}
+		h.live[r.ID] = r
+	}
+	for id, r := range h.live {
+		if !seen[id] {
+			h.deleted[id] = r
+			delete(h.live, id)
+		}
+	}
+	sort.Sort(byIDs(reused))
+	var errs []error
+	for _, r := range reused {
+		errs = append(errs, newFinding("id_reuse", "Requirement '%s' in %s reuses the ID of the deleted requirement '%s'.\n", r.ID, r.Path, undeletedTitle(previous[r])))
+	}
+	return errs, nil
+}
+
+// FirstInvalid returns the index of the commit at which the requirements
+// became invalid, after which they are invalid at all the checked commits,
+// or -1 when they are valid at the last one.
+func FirstInvalid(checks []CommitCheck) int {
+	res := -1
+	for i, c := range checks {
+		switch {
+		case c.Err == nil:
+			res = -1
+		case res == -1:
+			res = i
+		}
+	}
+	return res
+}
+
+// Reused returns the IDs reused after the deletion of their requirement, at
+// all the checked commits.
+func Reused(checks []CommitCheck) []error {
+	var res []error
+	for _, c := range checks {
+		res = append(res, c.Reused...)
+	}
+	return res
+}
+
+// WriteRangeCheck writes a line with the outcome of each check, with the IDs
+// reused at the commit, followed by the commit at which the requirements became
+// invalid, if they are.
+func WriteRangeCheck(w io.Writer, checks []CommitCheck) {
+	for _, c := range checks {
+		status := "ok"
+		if c.Err != nil {
+			status = fmt.Sprintf("%d problems", Summarize("check", exitFindings, c.Err).Findings)
+		}
+		fmt.Fprintf(w, "%.12s %-12s %s\n", c.Commit.ID, status, c.Subject())
+		for _, e := range c.Reused {
+			fmt.Fprintf(w, "\t%s", e)
+		}
+	}
+	if i := FirstInvalid(checks); i == 0 {
+		fmt.Fprintf(w, "\nThe requirements are invalid since the first checked commit %.12s.\n", checks[i].Commit.ID)
+	} else if i > 0 {
+		fmt.Fprintf(w, "\nThe requirements became invalid at %.12s %s, after %.12s.\n",
+			checks[i].Commit.ID, checks[i].Subject(), checks[i-1].Commit.ID)
+	}
+}
@@ -13,62 +13,100 @@ func (o Oncer) Once(r *Req) *Req {
 	if !ok {
 		return r
 	}
-	return &Req{ID: r.ID, Title: r.Title, Body: r.Body, Level: -1}
+	return &Req{ID: r.ID, Title: r.Title, Body: r.Body, Attributes: r.Attributes, Level: -1}
 }
 
-var reportTmpl = template.Must(template.New("").Parse(`
+var reportTmpl = template.Must(template.New("").Funcs(offlineFuncs).Funcs(l10nFuncs).Funcs(linkFuncs).Funcs(paramFuncs).Funcs(stampFuncs).Parse(`
 {{ define "REQUIREMENT" }}
 	{{if ne .Level -1 }}
-		<h3><a name="{{ .ID }}"></a>{{ .ID }} {{ .Title }}</h3>
-		{{ if .Body }}
-			<p>{{ .Body }}</p>
-		{{ end }}
-		{{ if .Attributes }}
+		<h3><a name="{{ .ID }}"></a>{{ .ID }} {{ .Expand .Title }}</h3>
+		{{ with .Translation lang }}
+			<div class="row">
+				<div class="col-md-6" lang="en"><p>{{ $.ExpandedBody }}</p></div>
+				<div class="col-md-6" lang="{{ lang }}"><p>{{ $.Expand . }}</p></div>
+			</div>
+		{{ else }}{{ if .Body }}
+			<p>{{ .ExpandedBody }}</p>
+		{{ end }}{{ end }}
+		{{ if or .Attributes .Inherited }}
 			<ul style="list-style: none; padding: 0; margin: 0;">
 			{{ range $k, $v := .Attributes }}
-				<li><strong>{{ $k }}</strong>: {{ $v }}</li>
+				{{ if not (or (isTranslation $k) (isLink $k) (isParam $k)) }}<li><strong>{{ $k }}</strong>: {{ $v }}</li>{{ end }}
+			{{ end }}
+			{{ range $k, $v := .Inherited }}
+				<li class="text-muted"><strong>{{ $k }}</strong>: {{ $v }} <span class="label label-default">{{ T "inherited" }}</span></li>
 			{{ end }}
 			</ul>
 		{{ end }}
+		{{ if or .Links .Backlinks }}
+			<p>{{ range .Links }}
+				<span class="label label-info">{{ T .Label }}</span> <a href="#{{ .Req.ID }}">{{ .Req.ID }}</a>
+			{{ end }}{{ range .Backlinks }}
+				<span class="label label-default">{{ T .Label }}</span> <a href="#{{ .Req.ID }}">{{ .Req.ID }}</a>
+			{{ end }}</p>
+		{{ end }}
+		{{ with .Suspect }}
+			<p>{{ T "Suspect links:" }}{{ range . }}
+				<span class="label label-warning">{{ T "suspect" }}</span> <a href="#{{ .ID }}">{{ .ID }}</a>
+			{{ end }}</p>
+		{{ end }}
+		{{ with .Findings }}
+			<p>{{ T "Analysis findings:" }}</p>
+			<ul>
+			{{ range . }}
+				<li><span class="label {{ if eq .Level "error" }}label-danger{{ else }}label-warning{{ end }}">{{ .Level }}</span> <code>{{ .File }}:{{ .Line }}</code> {{ .Message }} [{{ .Rule }}]</li>
+			{{ end }}
+			</ul>
+		{{ end }}
+		{{ with .Coverage }}
+			<p class="text-muted">{{ T "Statement coverage" }} {{ printf "%.1f" .StatementPercent }}% ({{ .CoveredStatements }}/{{ .Statements }}), {{ T "branch coverage" }} {{ printf "%.1f" .BranchPercent }}% ({{ .CoveredBranches }}/{{ .Branches }})</p>
+		{{ end }}
+		{{ with .Section }}
+			<p class="text-muted">{{ T "Section" }} {{ $.SectionNumber }} {{ . }}</p>
+		{{ end }}
+		{{ with .Blame }}
+			<p class="text-muted">{{ T "Last changed by" }} {{ .Author }}, {{ .Time.Format "2006-01-02" }}, <code>{{ printf "%.12s" .Commit }}</code> {{ .Summary }}</p>
+		{{ end }}
 		{{ template "STATUSFIELD" . }}
 	{{ else }}
-		<h3><a href="#{{ .ID }}">{{ .ID }} {{ .Title }}</a></h3>
+		<h3><a href="#{{ .ID }}">{{ .ID }} {{ .Expand .Title }}</a></h3>
  	{{end}}
 {{ end }}
 
 {{ define "CODEFILES"}}
-	<p>Code Files:
+	<p>{{ T "Code Files:" }}
 		{{ range . }}
+			{{ with .Kind }}<span class="label label-default">{{ . }}</span>{{ end }}
 			<a href="file://{{ .Path }}" target="_blank">{{ .ID }}</a>
 		{{ else }}
-			<span class="text-danger">No code files</span>
+			<span class="text-danger">{{ T "No code files" }}</span>
 		{{ end }}
 	</p>
 {{ end }}
 
 {{ define "CHANGELIST" }}
-	<p>Changelists:
+	<p>{{ T "Changelists:" }}
 		{{ range $k, $v := . }}
 			<a href="{{ $v }}" target="_blank"><span class="label label-primary">{{ $k }}</span></a>
 		{{ else }}
-			<span class="text-danger">No changelist</span>
+			<span class="text-danger">{{ T "No changelist" }}</span>
 		{{ end }}
 	</p>
 {{ end }}
 
 {{ define "STATUSFIELD" }}
-	<p>Status:
+	<p>{{ T "Status:" }}
 		{{ if eq .Status 0 }}
-			<span class="label label-default">{{ .Status }}</span>
+			<span class="label label-default">{{ T .Status.String }}</span>
 		{{ else if eq .Status 1 }}
-			<span class="label label-primary">{{ .Status }}</span>
+			<span class="label label-primary">{{ T .Status.String }}</span>
 		{{ else }}
-			<span class="label label-success">{{ .Status }}</span>
+			<span class="label label-success">{{ T .Status.String }}</span>
 		{{ end }}
 {{ end }}
 
 {{ define "PROBLEMREPORTS" }}
-	<p>Problem Reports:
+	<p>{{ T "Problem Reports:" }}
 		{{ range $k, $v := . }}
 			{{if $v.IsClosed}}
 				<a href="{{ $v.URI }}" target="_blank"> <span class="label label-success">T{{ $v.DisplayID }}</span></a>
@@ -76,13 +114,67 @@ var reportTmpl = template.Must(template.New("").Parse(`
 				<a href="{{ $v.URI }}" target="_blank"> <span class="label label-danger">T{{ $v.DisplayID }}</span></a>
 			{{end}}
 		{{ else }}
-			<span class="text-danger">No problem reports</span>
+			<span class="text-danger">{{ T "No problem reports" }}</span>
 		{{ end }}
 	</p>
 {{ end }}
 
+{{ define "DOCUMENTS" }}
+	{{ if . }}
+	<table class="table" style="text-align:left;">
+		<tr><th>{{ T "Document" }}</th><th>{{ T "Title" }}</th><th>{{ T "Revision" }}</th><th>{{ T "Date" }}</th><th>{{ T "Approvers" }}</th></tr>
+		{{ range . }}
+		<tr>
+			<td>{{ .ID }}</td>
+			<td>{{ .Title }}</td>
+			<td>{{ .Revision }}</td>
+			<td>{{ .Date }}</td>
+			<td>{{ range $i, $a := .Approvers }}{{ if $i }}<br>{{ end }}{{ $a }}{{ end }}</td>
+		</tr>
+		{{ end }}
+	</table>
+	{{ end }}
+{{ end }}
+
+{{ define "SUPPRESSIONS" }}
+	{{ with . }}
+	<h3>{{ T "Suppressed Findings:" }}</h3>
+	<table class="table table-condensed">
+		<tr><th>{{ T "Requirement" }}</th><th>{{ T "Check" }}</th><th>{{ T "Argument" }}</th><th>{{ T "Document" }}</th><th>{{ T "Line" }}</th></tr>
+		{{ range . }}
+			{{ $r := . }}
+			{{ range .Suppressions }}
+			<tr>
+				<td>{{ $r.ID }}</td>
+				<td>{{ .Check }}</td>
+				<td>{{ .Arg }}</td>
+				<td>{{ if $r.Document }}{{ $r.Document.Path }}{{ end }}</td>
+				<td>{{ .Line }}</td>
+			</tr>
+			{{ end }}
+		{{ end }}
+	</table>
+	{{ end }}
+{{ end }}
+
+{{ define "COTS" }}
+	{{ with . }}
+	<h3>{{ T "COTS Requirements:" }}</h3>
+	<table class="table table-condensed">
+		<tr><th>{{ T "Requirement" }}</th><th>{{ T "Code Files" }}</th><th>{{ T "Reuse Justification" }}</th></tr>
+		{{ range . }}
+		<tr>
+			<td>{{ .ID }} {{ .Expand .Title }}</td>
+			<td>{{ range .CodeFiles }}{{ . }}<br>{{ end }}</td>
+			<td>{{ range .COTSJustifications }}{{ .Name }}: {{ if .Value }}{{ .Value }}{{ else }}<span class="text-danger">{{ T "Missing" }}</span>{{ end }}<br>{{ end }}</td>
+		</tr>
+		{{ end }}
+	</table>
+	{{ end }}
+{{ end }}
+
 {{define "HEADER"}}
-<html lang="en">
+<html lang="{{ lang }}">
 	<head>
 		<meta charset="utf-8">
 	    <meta http-equiv="X-UA-Compatible" content="IE=edge">
@@ -92,8 +184,10 @@ var reportTmpl = template.Must(template.New("").Parse(`
 
 		<title>Reqtraq - Daedalean AG</title>
 
+		{{ if not offline }}
 		<!-- BOOTSTRAP -->
 		<link rel="stylesheet" href="https://maxcdn.bootstrapcdn.com/bootstrap/3.3.7/css/bootstrap.min.css" integrity="sha384-BVYiiSIFeK1dGmJRAkycuHAHRg32OmUcww7on3RYdg4Va+PmSTsz/K68vbdEjh4u" crossorigin="anonymous">
+		{{ end }}
 
 		<!-- CUSTOM -->
 		<style>
@@ -107,29 +201,41 @@ var reportTmpl = template.Must(template.New("").Parse(`
 				text-decoration: none;
 			}
 		</style>
+		{{ if not offline }}
 		<!-- Load MathJax for rendering of equations -->
 		<script type="text/javascript" async
 			src="https://cdnjs.cloudflare.com/ajax/libs/mathjax/2.7.1/MathJax.js?config=TeX-AMS-MML_HTMLorMML">
 		</script>
+		{{ end }}
 
 	</head>
 	<body>
 		<section style="max-width:100%; text-align:center;">
-			<h1>Reqtraq Report</h1>
+			<h1>{{ T "Reqtraq Report" }}</h1>
+			{{ template "DOCUMENTS" . }}
 
 {{end}}
 {{define "FOOTER"}}
+		{{ with stamp }}
+		<hr>
+		<footer class="text-muted"><small>{{ .String }}</small></footer>
+		{{ end }}
 	</body>
 </html>
 {{end}}
 
 {{define "TOPDOWN"}}
-	{{template "HEADER"}}
-		<h2>Top Down Tracing</h2>
+	{{ template "HEADER" .Reqs.Documents }}
+		<h2>{{ T "Top Down Tracing" }}</h2>
 		<hr>
 	</section>
 	<ul style="list-style: none; padding: 0; margin: 0;">
+		{{ $section := "" }}
 		{{ range .Reqs.OrdsByPosition }}
+			{{ if and .Section (ne .Section $section) }}
+				<li><h2>{{ .SectionNumber }} {{ .Section }}</h2></li>
+				{{ $section = .Section }}
+			{{ end }}
 			<li>
 				{{ template "REQUIREMENT" . }}
 				<!-- HLRs -->
@@ -147,24 +253,26 @@ var reportTmpl = template.Must(template.New("").Parse(`
 									{{ template "PROBLEMREPORTS" .Tasklists }}
 								</li>
 							{{ else }}
-								<li class="text-danger">No children</li>
+								<li class="text-danger">{{ T "No children" }}</li>
 							{{ end }}
 							</ul>
 					</li>
 					{{ else }}
-						<li class="text-danger">No children</li>
+						<li class="text-danger">{{ T "No children" }}</li>
 					{{ end }}
 				</ul>
 			</li>
 		{{ else }}
-			<li  class="text-danger">Empty graph</li>
+			<li  class="text-danger">{{ T "Empty graph" }}</li>
 		{{ end }}
 	</ul>
+	{{ template "COTS" .Reqs.COTSReqs }}
+	{{ template "SUPPRESSIONS" .Reqs.SuppressedReqs }}
 	{{template "FOOTER"}}
 {{end}}
 {{define "BOTTOMUP"}}
-	{{template "HEADER"}}
-		<h2>Bottom Up Tracing</h2>
+	{{ template "HEADER" .Reqs.Documents }}
+		<h2>{{ T "Bottom Up Tracing" }}</h2>
 		<hr>
 	</section>
 	<ul style="list-style: none; padding: 0; margin: 0;">
@@ -192,52 +300,178 @@ var reportTmpl = template.Must(template.New("").Parse(`
 											{{ template "REQUIREMENT" ($.Once.Once .) }}
 										</li>
 									{{ else }}
-										<li class="text-danger">No parents</li>
+										<li class="text-danger">{{ T "No parents" }}</li>
 									{{ end }}
 									</ul>
 								</li>
 							{{ else }}
-								<li class="text-danger">No parents</li>
+								<li class="text-danger">{{ T "No parents" }}</li>
 							{{ end }}
 							</ul>
 					</li>
 					{{ else }}
-						<li class="text-danger">No parents</li>
+						<li class="text-danger">{{ T "No parents" }}</li>
 					{{ end }}
 				</ul>
 			</li>
 		{{ else }}
-			<li class="text-danger">Empty graph</li>
+			<li class="text-danger">{{ T "Empty graph" }}</li>
 		{{ end }}
 	</ul>
+	{{ template "COTS" .Reqs.COTSReqs }}
+	{{ template "SUPPRESSIONS" .Reqs.SuppressedReqs }}
 	{{ template "FOOTER" }}
 {{ end }}
 
 
 {{ define "ISSUES" }}
-	{{template "HEADER"}}
-		<h2>Issues</h2>
+	{{ template "HEADER" .Reqs.Documents }}
+		<h2>{{ T "Issues" }}</h2>
 		<hr>
 	</section>
-	<h3>Dangling Requirements:</h3>
+	<h3>{{ T "Dangling Requirements:" }}</h3>
 	<ul>
 	{{ range .Reqs.DanglingReqsByPosition }}
 		<li>
 			{{ template "REQUIREMENT" ($.Once.Once .) }}
 		</li>
 	{{ else }}
-		<li class="text-success">No dangling HLRs or LLRs found.</li>
+		<li class="text-success">{{ T "No dangling HLRs or LLRs found." }}</li>
 	{{ end }}
 	</ul>
+	{{ with .Reqs.WithSuspectLinks }}
+		<h3>{{ T "Suspect Links:" }}</h3>
+		<ul>
+		{{ range . }}
+			<li>
+				{{ template "REQUIREMENT" ($.Once.Once .) }}
+			</li>
+		{{ end }}
+		</ul>
+	{{ end }}
+	{{ with .Reqs.WithFindings }}
+		<h3>{{ T "Analysis Findings:" }}</h3>
+		<ul>
+		{{ range . }}
+			<li>
+				{{ template "REQUIREMENT" ($.Once.Once .) }}
+			</li>
+		{{ end }}
+		</ul>
+	{{ end }}
+	{{ template "SUPPRESSIONS" .Reqs.SuppressedReqs }}
 	{{ template "FOOTER" }}
 {{ end }}
 
-{{ define "TOPDOWNFILT"}}
+{{ define "OWNERS" }}
+	{{template "HEADER"}}
+		<h2>{{ T "Requirements by Owner" }}</h2>
+		<hr>
+	</section>
+	{{ range .Reqs.ByOwner }}
+		<h3>{{ if .Owner }}{{ .Owner }}{{ else }}<span class="text-danger">{{ T "No owner" }}</span>{{ end }} ({{ len .Reqs }})</h3>
+		<table class="table table-condensed">
+			<tr><th>{{ T "Requirement" }}</th><th>{{ T "Reviewers" }}</th><th>{{ T "Status" }}</th></tr>
+			{{ range .Reqs }}
+			<tr>
+				<td>{{ .ID }} {{ .Expand .Title }}</td>
+				<td>{{ range $i, $r := .Reviewers }}{{ if $i }}, {{ end }}{{ $r }}{{ else }}<span class="text-danger">{{ T "None" }}</span>{{ end }}</td>
+				<td>{{ T .Status.String }}</td>
+			</tr>
+			{{ end }}
+		</table>
+	{{ else }}
+		<p class="text-danger">{{ T "Empty graph" }}</p>
+	{{ end }}
+	{{ with .Reqs.ByTeam }}
+		<h2>{{ T "Requirements by Team" }}</h2>
+		<hr>
+		{{ range . }}
+		<h3>{{ if .Owner }}{{ .Owner }}{{ else }}<span class="text-danger">{{ T "No team" }}</span>{{ end }} ({{ len .Reqs }})</h3>
+		<ul>
+			{{ range .Reqs }}
+			<li>{{ .ID }} {{ .Expand .Title }}</li>
+			{{ end }}
+		</ul>
+		{{ end }}
+	{{ end }}
+	{{ template "FOOTER" }}
+{{ end }}
+
+{{ define "TARGETS" }}
+	{{template "HEADER"}}
+		<h2>{{ T "Requirements by Target" }}</h2>
+		<hr>
+	</section>
+	{{ range .Targets }}
+		<h3>{{ .Target.Name }} <small>{{ .Target.Kind }}</small> ({{ len .Reqs }})</h3>
+		<p>{{ T "Code Files:" }}
+			{{ range .Code }}
+				<a href="file://{{ .Path }}" target="_blank">{{ .ID }}</a>
+			{{ else }}
+				<span class="text-danger">{{ T "No code files" }}</span>
+			{{ end }}
+		</p>
+		<table class="table table-condensed">
+			<tr><th>{{ T "Requirement" }}</th><th>{{ T "Status" }}</th></tr>
+			{{ range .Reqs }}
+			<tr>
+				<td>{{ .ID }} {{ .Expand .Title }}</td>
+				<td>{{ T .Status.String }}</td>
+			</tr>
+			{{ end }}
+		</table>
+	{{ else }}
+		<p class="text-danger">{{ T "No targets" }}</p>
+	{{ end }}
+	{{ template "FOOTER" }}
+{{ end }}
+
+{{ define "DASHBOARD" }}
 	{{template "HEADER"}}
-		<h2>Top Down Tracing</h2>
+		<h2>{{ T "Dashboard" }}</h2>
 		<hr>
 	</section>
-	<h3><em>Filter Criteria: {{ $.Filter }} </em></h3>
+	{{ range $i, $b := .Baselines }}
+		{{ if eq $i 1 }}<h3>{{ T "Previous baselines" }}</h3>{{ end }}
+		<h4>{{ $b.Name }}</h4>
+		<table class="table table-condensed">
+			<tr><th>{{ T "Level" }}</th><th>{{ T "Requirements" }}</th><th>{{ T "With children" }}</th><th>{{ T "With code" }}</th><th>{{ T "With tests" }}</th>{{ if and $.TestResults (eq $i 0) }}<th>{{ T "With passing tests" }}</th>{{ end }}</tr>
+			{{ range $b.Levels }}
+			<tr>
+				<td>{{ .Level }}</td>
+				<td>{{ .Total }}</td>
+				<td>{{ .WithChildren }} ({{ .PctChildren }}%)</td>
+				<td>{{ .WithCode }} ({{ .PctCode }}%)</td>
+				<td>{{ .WithTests }} ({{ .PctTests }}%)</td>
+				{{ if and $.TestResults (eq $i 0) }}<td>{{ .WithPassingTests }} ({{ .PctPassingTests }}%)</td>{{ end }}
+			</tr>
+			{{ end }}
+		</table>
+		{{ with $b.Priorities }}
+		<table class="table table-condensed">
+			<tr><th>{{ T "Priority" }}</th><th>{{ T "Requirements" }}</th><th>{{ T "Fully traced" }}</th><th>{{ T "Partially traced" }}</th><th>{{ T "Untraced" }}</th></tr>
+			{{ range . }}
+			<tr>
+				<td>{{ if .Priority }}{{ .Priority }}{{ else }}{{ T "None" }}{{ end }}</td>
+				<td>{{ .Total }}</td>
+				<td>{{ .Traced }} ({{ .PctTraced }}%)</td>
+				<td>{{ .Partial }} ({{ .PctPartial }}%)</td>
+				<td>{{ .Untraced }}</td>
+			</tr>
+			{{ end }}
+		</table>
+		{{ end }}
+	{{ end }}
+	{{ template "FOOTER" }}
+{{ end }}
+
+{{ define "TOPDOWNFILT"}}
+	{{ template "HEADER" .Reqs.Documents }}
+		<h2>{{ T "Top Down Tracing" }}</h2>
+		<hr>
+	</section>
+	<h3><em>{{ T "Filter Criteria:" }} {{ $.Filter }} </em></h3>
 	<ul style="list-style: none; padding: 0; margin: 0;">
 		{{ range .Reqs.OrdsByPosition }}
 			{{ if .Matches $.Filter $.Diffs }}{{ template "REQUIREMENT" ($.Once.Once .) }}{{ end }}
@@ -259,11 +493,11 @@ var reportTmpl = template.Must(template.New("").Parse(`
 {{ end }}
 
 {{ define "BOTTOMUPFILT" }}
-	{{template "HEADER" }}
-		<h2>Bottom Up Tracing</h2>
+	{{ template "HEADER" .Reqs.Documents }}
+		<h2>{{ T "Bottom Up Tracing" }}</h2>
 		<hr>
 	</section>
-	<h3><em>Filter Criteria: {{ $.Filter }} </em></h3>
+	<h3><em>{{ T "Filter Criteria:" }} {{ $.Filter }} </em></h3>
 	<ul style="list-style: none; padding: 0; margin: 0;">
 		{{ range .Reqs.CodeFilesByPosition }}
 			{{ range .Parents }}
@@ -287,12 +521,12 @@ var reportTmpl = template.Must(template.New("").Parse(`
 {{ end }}
 
 {{ define "ISSUESFILT" }}
-	{{template "HEADER"}}
-		<h2>Issues</h2>
+	{{ template "HEADER" .Reqs.Documents }}
+		<h2>{{ T "Issues" }}</h2>
 		<hr>
 	</section>
-	<h3><em>Filter Criteria: {{ $.Filter }} </em></h3>
-	<h3>Dangling Requirements:</h3>
+	<h3><em>{{ T "Filter Criteria:" }} {{ $.Filter }} </em></h3>
+	<h3>{{ T "Dangling Requirements:" }}</h3>
 	<ul>
 	{{ range .Reqs.DanglingReqsByPosition }}
 		<li>
@@ -323,6 +557,17 @@ func (rg reqGraph) ReportIssues(w io.Writer) error {
 	return reportTmpl.ExecuteTemplate(w, "ISSUES", reportData{rg, nil, Oncer{}, nil})
 }
 
+// ReportOwners writes a report listing the requirements owned by each person.
+func (rg reqGraph) ReportOwners(w io.Writer) error {
+	return reportTmpl.ExecuteTemplate(w, "OWNERS", reportData{rg, nil, Oncer{}, nil})
+}
+
+// ReportTargets writes a report listing the requirements implemented by each
+// of the given build targets, see ReqsByTarget.
+func (rg reqGraph) ReportTargets(w io.Writer, targets []Target) error {
+	return reportTmpl.ExecuteTemplate(w, "TARGETS", struct{ Targets []TargetReqs }{rg.ReqsByTarget(targets)})
+}
+
 // @llr REQ-0-DDLN-SWL-006
 func (rg reqGraph) ReportDownFiltered(w io.Writer, f ReqFilter, diffs map[string][]string) error {
 	return reportTmpl.ExecuteTemplate(w, "TOPDOWNFILT", reportData{rg, f, Oncer{}, diffs})
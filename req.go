@@ -7,13 +7,12 @@
 package main
 
 import (
-	"bufio"
 	"crypto/sha1"
 	"fmt"
 	"html/template"
 	"io"
 	"io/ioutil"
-	"log"
+	"log/slog"
 	"os"
 	"path"
 	"path/filepath"
@@ -50,12 +49,13 @@ var (
 
 // Req represenents a Requirement Node in the graph of Requirements.
 // The Attributes map has potential elements;
-//  rationale safety_impact verification urgent important mode provenance
+//  rationale safety_impact verification urgent important mode provenance owner reviewer
 type Req struct {
 	ID         string // code files do not have an ID, use Path as primary key
 	Level      config.RequirementLevel
 	Path       string // certification document or code file this was found in relative to repo root
 	FileHash   string // for code files, the sha1 of the contents
+	Kind       string // for code files, the kind of artifact, e.g. HW-DESIGN, empty for source code
 	ParentIds  []string
 	Parents    []*Req
 	Children   []*Req
@@ -67,6 +67,38 @@ type Req struct {
 	Position   int
 	Seen       bool
 	Status     RequirementStatus
+	Document   *Document // The document defining the requirement, nil for code files.
+	Links      []Link    // The typed links to other requirements, see linkKinds.
+	Backlinks  []Link    // The typed links of other requirements to this one.
+	Suspect    []*Req    // The parents changed since the requirement, see MarkSuspectLinks.
+	Blame      *Blame    // The last change of the requirement, see SetBlame.
+	Coverage   *Coverage // The coverage of the code implementing the requirement, see SetCoverage.
+	Findings   []Finding // The static analysis findings in the code implementing the requirement, see SetFindings.
+	// Inherited are the attributes inherited from the parents, see InheritAttributes.
+	Inherited map[string]string
+	// Section is the path of the headings the requirement appears under in its
+	// document, e.g. "Requirements > Functional", and SectionNumber the number
+	// of the innermost one, e.g. "3.1", see docSections.
+	Section, SectionNumber string
+	// LegacyRefs are the references of a code file to legacy IDs, see CheckAliases.
+	LegacyRefs []legacyRef
+	// RefLines are the lines of the references of a code file, by ParentIds index.
+	RefLines []int
+	// Mentions are the IDs mentioned in the comments of a code file without
+	// @llr tag, linked as references, see referencesKind.
+	Mentions []string
+	// TestCases are the test cases of a C, C++ or Go test file, see testCaseReader.
+	TestCases []TestCase
+	// Suppressions are the findings suppressed by the pragmas of the document, see docPragmas.
+	Suppressions []Suppression
+	// Teams are the owners in CODEOWNERS of the requirement, see SetTeams.
+	Teams []string
+	// External is whether the requirement is only referenced by the component
+	// of an extracted graph, see ExtractComponent.
+	External bool
+	// Typed are the values of the typed attributes, own or inherited, by
+	// name, see parseTypedAttributes.
+	Typed map[string]AttributeValue
 }
 
 // Returns the requirement type for the given requirement, which is one of SYS, SWH, SWL, HWH, HWL or the empty string if
@@ -112,9 +144,19 @@ func (r *Req) CheckAttributes(as []map[string]string) []error {
 		for k, v := range a {
 			switch k {
 			case "name":
-				if _, ok := r.Attributes[strings.ToUpper(v)]; !ok {
-					if !(r.Level == config.SYSTEM && strings.ToUpper(v) == "PARENTS") {
-						errs = append(errs, fmt.Errorf("Requirement '%s' is missing attribute '%s'.\n", r.ID, v))
+				if _, ok := r.attribute(strings.ToUpper(v)); !ok && a["optional"] != "true" {
+					if when := a["when"]; when != "" {
+						// The attribute is mandatory only when the condition holds.
+						c, err := parseAttributeCondition(when)
+						if err != nil {
+							fatal(err)
+						}
+						if c.holds(r) {
+							value, _ := r.attribute(c.name)
+							errs = append(errs, newFinding("attribute", "Requirement '%s' is missing attribute '%s', mandatory when %s (%s is '%s').\n", r.ID, v, strings.TrimSpace(when), c.name, value))
+						}
+					} else if !(r.Level == config.SYSTEM && strings.ToUpper(v) == "PARENTS") {
+						errs = append(errs, newFinding("attribute", "Requirement '%s' is missing attribute '%s'.\n", r.ID, v))
 					}
 				}
 			case "value":
@@ -123,10 +165,21 @@ func (r *Req) CheckAttributes(as []map[string]string) []error {
 					// attribute exists so needs to be valid
 					expr, err := regexp.Compile(v) // TODO(dh) move out so only computed once for each value
 					if err != nil {
-						log.Fatal(err)
+						fatal(err)
 					}
 					if !expr.MatchString(r.Attributes[aName]) {
-						errs = append(errs, fmt.Errorf("Requirement '%s' has invalid value '%s' in attribute '%s'. Expected %s.\n", r.ID, r.Attributes[aName], aName, v))
+						errs = append(errs, newFinding("attribute", "Requirement '%s' has invalid value '%s' in attribute '%s'. Expected %s.\n", r.ID, r.Attributes[aName], aName, v))
+					}
+				}
+			case "type":
+				aName := strings.ToUpper(a["name"])
+				if value, ok := r.Attributes[aName]; ok {
+					var values []string
+					if a["values"] != "" {
+						values = strings.Split(a["values"], "\n")
+					}
+					if _, err := parseAttributeValue(v, values, value); err != nil {
+						errs = append(errs, invalidAttributeValue(r, aName, value, err))
 					}
 				}
 			}
@@ -138,14 +191,17 @@ func (r *Req) CheckAttributes(as []map[string]string) []error {
 func (r *Req) Tasklists() map[string]*taskmgr.Task {
 	m := map[string]*taskmgr.Task{}
 	projectID, err1 := taskmgr.TaskMgr.GetProject(config.ProjectName)
+	if err1 == taskmgr.ErrOffline {
+		return m
+	}
 	if err1 != nil {
-		log.Println(err1)
+		slog.Error("cannot get tasks", "req", r.ID, "err", err1)
 		return m
 	}
 	// Find and add primary task corresponding to Req
 	task, err2 := taskmgr.TaskMgr.FindTask(r.ID, r.Title, projectID)
 	if err2 != nil {
-		log.Println(err2)
+		slog.Error("cannot get tasks", "req", r.ID, "err", err2)
 		return m
 	}
 	m[task.ID] = task
@@ -153,7 +209,7 @@ func (r *Req) Tasklists() map[string]*taskmgr.Task {
 	for _, phid := range task.DependsOnTaskIDs {
 		subTask, e := taskmgr.TaskMgr.FindTaskByID(phid)
 		if e != nil {
-			log.Println(e)
+			slog.Error("cannot get task", "req", r.ID, "task", phid, "err", e)
 			continue
 		}
 		m[subTask.ID] = subTask
@@ -189,19 +245,18 @@ func changelistUrlsForFilepaths(filepaths []string) []string {
 func changelistUrlsForFilepath(filepath string) []string {
 	res, err := linepipes.All(linepipes.Run("git", "-C", path.Dir(filepath), "log", filepath))
 	if err != nil {
-		log.Fatal(err)
+		fatal(err)
 	}
 
 	matches := reDiffRev.FindAllStringSubmatch(res, -1)
 	if len(matches) < 1 {
-		log.Printf("Could not extract differential revision for file: %s", filepath)
-		log.Println("Newly added?")
+		slog.Warn("could not extract differential revision, newly added?", "file", filepath)
 	}
 
 	var urls []string
 	for _, m := range matches {
 		if len(m) != 2 {
-			log.Fatal("Count not extract changelist substring for filepath: ", filepath)
+			fatal("Count not extract changelist substring for filepath: ", filepath)
 		}
 		urls = append(urls, m[1])
 	}
@@ -213,83 +268,224 @@ func changelistUrlsForFilepath(filepath string) []string {
 // A ReqGraph maps IDs and Paths to Req structures.
 type reqGraph map[string]*Req
 
+// splitList returns the comma separated items of s, e.g. the names of an
+// attribute or of a flag, trimmed, without the empty ones.
+func splitList(s string) []string {
+	var res []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			res = append(res, item)
+		}
+	}
+	return res
+}
+
+// listAttribute returns the comma separated items in the given attribute of
+// the requirement, see splitList.
+func (r *Req) listAttribute(attribute string) []string {
+	return splitList(r.Attributes[attribute])
+}
+
+// certdocRoots returns the directories of the certification documents, given
+// separated by commas in the certdoc path, e.g. "docs/requirements,gps/docs".
+// Their documents are merged into one graph.
+func certdocRoots(certdocPath string) []string {
+	res := splitList(certdocPath)
+	if len(res) == 0 {
+		res = append(res, "")
+	}
+	return res
+}
+
 func CreateReqGraph(certdocPath, codePath string) (reqGraph, error) {
+	repoPath, err := git.RepoPath()
+	if err != nil {
+		return nil, err
+	}
+	return createReqGraph(repoPath, certdocPath, codePath)
+}
+
+// createReqGraph creates the requirement graph from the files in repoPath,
+// which is the repository or a directory where its files have been extracted.
+func createReqGraph(repoPath, certdocPath, codePath string) (reqGraph, error) {
 	rg := reqGraph{}
-	errorResult := ""
-
-	_ = filepath.Walk(filepath.Join(git.RepoPath(), certdocPath),
-		func(fileName string, info os.FileInfo, err error) error {
-			var errs []error
-			switch strings.ToLower(path.Ext(fileName)) {
-			case ".lyx", ".md":
-				errs = parseCertdocToGraph(fileName, rg)
-			}
-			if len(errs) > 0 {
-				errorResult += "Problems found while parsing " + fileName + ":\n"
-				for _, v := range errs {
-					errorResult += "\t" + v.Error() + "\n"
+	var problems findingList
+
+	configs, err := loadConfigTree(repoPath, append(certdocRoots(certdocPath), codePath)...)
+	problems.add(err)
+
+	for _, root := range certdocRoots(certdocPath) {
+		_ = filepath.Walk(filepath.Join(repoPath, root),
+			func(fileName string, info os.FileInfo, err error) error {
+				var errs []error
+				switch strings.ToLower(path.Ext(fileName)) {
+				case ".lyx", ".md":
+					if isGenerated(repoPath, fileName) {
+						slog.Debug("skipping generated certdoc", "file", fileName)
+						break
+					}
+					slog.Debug("parsing certdoc", "file", fileName)
+					errs = parseCertdocToGraph(fileName, rg)
 				}
-				errorResult += "\n"
-			}
-			return nil
-		})
+				if len(errs) > 0 {
+					problems.add(findingHeader("Problems found while parsing " + fileName + ":\n"))
+					for _, v := range errs {
+						problems.add(indentedFinding{v})
+					}
+					problems.add(findingHeader("\n"))
+				}
+				return nil
+			})
+	}
+
+	// The typed attributes are parsed as specified by --attributes, if it exists.
+	reportConf, err := ReadJsonConf(*fReportJsonConfPath)
+	if err != nil && !os.IsNotExist(err) {
+		problems.addLine(err)
+	}
+	rg.parseTypedAttributes(configs, reportConf.Attributes)
 
 	// walk the code
-	_ = filepath.Walk(filepath.Join(git.RepoPath(), codePath), func(fileName string, info os.FileInfo, err error) error {
-		switch strings.ToLower(path.Ext(fileName)) {
-		case ".cc", ".c", ".h", ".hh", ".go":
+	_ = filepath.Walk(filepath.Join(repoPath, codePath), func(fileName string, info os.FileInfo, err error) error {
+		id := relativePathToRepo(fileName, repoPath)
+		if err == nil && !info.IsDir() && configs.isSource(id) {
 			// TODO (pk,lb): do that in a nicer way without hard-coded folder names
 			if strings.Contains(codePath, "testdata") || !strings.Contains(fileName, "testdata") {
-				id := relativePathToRepo(fileName, git.RepoPath())
 				if id == "" {
-					log.Fatal("Malformed code file path")
-				}
-				err = parseCode(id, fileName, rg)
-				if err != nil {
-					errorResult += err.Error()
-					errorResult += "\n"
+					fatal("Malformed code file path")
 				}
+				problems.addLine(parseCode(id, fileName, rg))
 			}
 		}
 		return nil
 	})
 
-	err := rg.Resolve()
+	problems.add(rg.Resolve())
+	if err := problems.err(); err != nil {
+		return rg, err
+	}
+	return rg, nil
+}
+
+// CreateReqGraphAt creates the requirement graph at the given commit. The
+// certification documents and the code files are read from the git objects
+// into a temporary directory, the working tree is not touched.
+func CreateReqGraphAt(commit, certdocPath, codePath string) (reqGraph, error) {
+	files, err := git.ListFiles(commit, append(certdocRoots(certdocPath), codePath)...)
 	if err != nil {
-		errorResult += err.Error()
+		return nil, err
 	}
 
-	if errorResult != "" {
-		return rg, fmt.Errorf(errorResult)
+	dir, err := ioutil.TempDir("", "reqtraq")
+	if err != nil {
+		return nil, err
 	}
-	return rg, nil
+	defer os.RemoveAll(dir)
+	extract := func(needed []string) error {
+		return git.ReadFiles(commit, needed, func(f string, contents []byte) error {
+			fileName := filepath.Join(dir, filepath.FromSlash(f))
+			if err := os.MkdirAll(filepath.Dir(fileName), 0755); err != nil {
+				return err
+			}
+			return ioutil.WriteFile(fileName, contents, 0644)
+		})
+	}
+
+	// The configuration fragments tell which are the code files.
+	var fragments []string
+	for _, f := range files {
+		if path.Base(f) == repoConfigName {
+			fragments = append(fragments, f)
+		}
+	}
+	if err := extract(fragments); err != nil {
+		return nil, err
+	}
+	// The errors are reported by createReqGraph.
+	configs, _ := loadConfigTree(dir, append(certdocRoots(certdocPath), codePath)...)
+
+	var needed []string
+	for _, f := range files {
+		switch strings.ToLower(path.Ext(f)) {
+		case ".lyx", ".md":
+			needed = append(needed, f)
+		default:
+			if configs.isSource(f) {
+				needed = append(needed, f)
+			}
+		}
+	}
+	if err := extract(needed); err != nil {
+		return nil, err
+	}
+
+	repoPath, err := git.RepoPath()
+	if err != nil {
+		return nil, err
+	}
+	rg, err := createReqGraph(dir, certdocPath, codePath)
+	// The paths are those the files would have in the repository.
+	res := reqGraph{}
+	for k, r := range rg {
+		if r.Level == config.CODE {
+			r.Path = filepath.Join(repoPath, filepath.FromSlash(r.ID))
+			k = r.Path
+		} else {
+			r.Path = strings.TrimPrefix(r.Path, filepath.ToSlash(dir))
+			if r.Document != nil {
+				r.Document.Path = r.Path
+			}
+		}
+		res[k] = r
+	}
+	return res, replaceInFindings(err, dir, repoPath)
+}
+
+// isCodeFile returns whether the file can contain references to requirements,
+// by its extension, unless configured otherwise, see RepoConfig.Sources.
+func isCodeFile(fileName string) bool {
+	switch strings.ToLower(path.Ext(fileName)) {
+	case ".cc", ".c", ".h", ".hh", ".go":
+		return true
+	}
+	return isHWDesignFile(fileName) || isTestSuiteFile(fileName)
 }
 
 // relativePathToRepo returns filePath relative to repoPath by
 // removing the path to the repository from filePath
 func relativePathToRepo(filePath, repoPath string) string {
-	fields := strings.SplitAfterN(filePath, repoPath, 2)
-	if len(fields) < 2 {
+	rel, err := filepath.Rel(repoPath, filePath)
+	if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
 		return ""
 	}
-	return fields[1][1:] // omit leading slash
+	return filepath.ToSlash(rel)
 }
 
 func (rg reqGraph) AddReq(req *Req, path string) error {
 	if v := rg[req.ID]; v != nil {
-		return fmt.Errorf("Requirement %s in %s already defined in %s", req.ID, path, v.Path)
+		return newFinding("duplicate_requirement", "Requirement %s in %s already defined in %s", req.ID, path, v.Path)
+	}
+	repoPath, err := git.RepoPath()
+	if err != nil {
+		return err
 	}
-	req.Path = strings.TrimPrefix(path, git.RepoPath())
+	req.Path = filepath.ToSlash(strings.TrimPrefix(path, repoPath))
 
 	rg[req.ID] = req
 	return nil
 }
 
 func (rg reqGraph) CheckAttributes(as []map[string]string) []error {
+	return rg.CheckAttributesIn(nil, as)
+}
+
+// CheckAttributesIn checks the attributes of the requirements, as specified by
+// as overridden by the configuration fragments of their directories.
+func (rg reqGraph) CheckAttributesIn(configs configTree, as []map[string]string) []error {
 	var errs []error
 	for _, req := range rg {
 		if req.Level != config.CODE {
-			errs = append(errs, req.CheckAttributes(as)...)
+			errs = append(errs, req.CheckAttributes(configs.attributes(req.Path, as))...)
 		}
 	}
 	return errs
@@ -299,82 +495,99 @@ func (rg reqGraph) CheckAttributes(as []map[string]string) []error {
 func (rg reqGraph) checkReqReferences(certdocPath string) error {
 	reParents := regexp.MustCompile(`Parents: REQ-`)
 
-	errorResult := ""
-
-	err := filepath.Walk(filepath.Join(git.RepoPath(), certdocPath),
-		func(fileName string, info os.FileInfo, err error) error {
-			r, err := os.Open(fileName)
-			if err != nil {
-				return err
-			}
-
-			scan := bufio.NewScanner(r)
-			for lno := 1; scan.Scan(); lno++ {
-				line := scan.Text()
-				// parents have alreay been checked in Resolve(), and we don't throw an eror at the place where the deleted req is defined
-				discardRefToDeleted := reParents.MatchString(line) || ReReqDeleted.MatchString(line)
-				parmatch := ReReqID.FindAllStringSubmatchIndex(line, -1)
-
-				for _, ids := range parmatch {
-					reqID := line[ids[0]:ids[1]]
-					v, reqFound := rg[reqID]
-					if !reqFound {
-						errorResult += "Invalid reference to inexistent requirement " + reqID + " in " + fileName + ":" + strconv.Itoa(lno) + "\n"
-					} else if v.IsDeleted() && !discardRefToDeleted {
-						errorResult += "Invalid reference to deleted requirement " + reqID + " in " + fileName + ":" + strconv.Itoa(lno) + "\n"
-					}
-				}
-			}
-			return nil
-		})
-
+	repoPath, err := git.RepoPath()
 	if err != nil {
 		return err
 	}
+	var problems findingList
 
-	if errorResult != "" {
-		return fmt.Errorf(errorResult)
+	for _, root := range certdocRoots(certdocPath) {
+		err := filepath.Walk(filepath.Join(repoPath, root),
+			func(fileName string, info os.FileInfo, err error) error {
+				if isGenerated(repoPath, fileName) {
+					return nil
+				}
+				r, err := os.Open(fileName)
+				if err != nil {
+					return err
+				}
+				defer r.Close()
+
+				scan := newLineReader(r)
+				for lno := 1; scan.Scan(); lno++ {
+					line := scan.Text()
+					// parents have alreay been checked in Resolve(), and we don't throw an eror at the place where the deleted req is defined
+					discardRefToDeleted := reParents.MatchString(line) || ReReqDeleted.MatchString(line)
+					parmatch := ReReqID.FindAllStringSubmatchIndex(line, -1)
+
+					for _, ids := range parmatch {
+						reqID := line[ids[0]:ids[1]]
+						v, reqFound := rg[reqID]
+						if !reqFound {
+							problems.add(newFinding("invalid_reference", "Invalid reference to inexistent requirement %s in %s:%d\n", reqID, fileName, lno))
+						} else if v.IsDeleted() && !discardRefToDeleted {
+							problems.add(newFinding("invalid_reference", "Invalid reference to deleted requirement %s in %s:%d\n", reqID, fileName, lno))
+						}
+					}
+				}
+				return nil
+			})
+		if err != nil {
+			return err
+		}
 	}
-	return nil
+
+	return problems.err()
 }
 
 func (rg reqGraph) AddCodeRefs(id, fileName, fileHash string, reqIds []string) {
-	rg[fileName] = &Req{ID: id, Path: fileName, FileHash: fileHash, ParentIds: reqIds, Level: config.CODE}
+	_, kind := referenceRegexp(fileName)
+	rg[fileName] = &Req{ID: id, Path: fileName, FileHash: fileHash, ParentIds: reqIds, Level: config.CODE, Kind: kind}
+}
+
+// refLocation returns the file and, when known, the line of the i-th reference
+// of a code file, e.g. "/repo/a.go:12".
+func (r *Req) refLocation(i int) string {
+	if i < len(r.RefLines) {
+		return r.Path + ":" + strconv.Itoa(r.RefLines[i])
+	}
+	return r.Path
 }
 
 // @llr REQ-0-DDLN-SWL-017
 func (rg reqGraph) Resolve() error {
-	errorResult := ""
+	var problems findingList
 
 	for _, req := range rg {
-		if len(req.ParentIds) == 0 && req.Level != config.SYSTEM {
-			errorResult += "Requirement " + req.ID + " in file " + req.Path + " has no parents.\n"
+		if len(req.ParentIds) == 0 && req.Level != config.SYSTEM && req.Level != config.CODE {
+			problems.add(newFinding("missing_parent", "Requirement %s in file %s has no parents.\n", req.ID, req.Path))
 		}
-		for _, parentID := range req.ParentIds {
+		for i, parentID := range req.ParentIds {
 			parent := rg[parentID]
 			if parent != nil {
 				if parent.IsDeleted() && !req.IsDeleted() {
 					if req.Level != config.CODE {
-						errorResult += "Invalid parent of requirement " + req.ID + ": " + parentID + " is deleted.\n"
+						problems.add(newFinding("invalid_parent", "Invalid parent of requirement %s: %s is deleted.\n", req.ID, parentID))
 					} else {
-						errorResult += "Invalid reference in file " + req.Path + ": " + parentID + " is deleted.\n"
+						problems.add(newFinding("invalid_parent", "Invalid reference in file %s: %s is deleted.\n", req.refLocation(i), parentID))
 					}
 				}
 				parent.Children = append(parent.Children, req)
 				req.Parents = append(req.Parents, parent)
 			} else {
 				if req.Level != config.CODE {
-					errorResult += "Invalid parent of requirement " + req.ID + ": " + parentID + " does not exist.\n"
+					problems.add(newFinding("invalid_parent", "Invalid parent of requirement %s: %s does not exist.\n", req.ID, parentID))
 				} else {
-					errorResult += "Invalid reference in file " + req.Path + ": " + parentID + " does not exist.\n"
+					problems.add(newFinding("invalid_parent", "Invalid reference in file %s: %s does not exist.\n", req.refLocation(i), parentID))
 				}
 			}
 		}
 	}
+	problems.add(rg.resolveLinks())
 
-	if errorResult != "" {
-		errorResult += "\n"
-		return fmt.Errorf(errorResult)
+	if len(problems) > 0 {
+		problems.add(findingHeader("\n"))
+		return problems
 	}
 
 	for _, req := range rg {
@@ -391,7 +604,12 @@ func (rg reqGraph) Resolve() error {
 	for _, req := range rg {
 		if req.Level == config.CODE {
 			req.resolveUp()
-			req.Position = req.Parents[0].Position
+			if len(req.Parents) > 0 {
+				req.Position = req.Parents[0].Position
+			} else if len(req.Links) > 0 {
+				// A file only mentioning requirements.
+				req.Position = req.Links[0].Req.Position
+			}
 		}
 	}
 	return nil
@@ -419,6 +637,20 @@ func (rg reqGraph) CodeFilesByPosition() []*Req {
 	return r
 }
 
+// Documents returns the documents defining the requirements, sorted by path.
+func (rg reqGraph) Documents() []*Document {
+	seen := map[*Document]bool{}
+	var docs []*Document
+	for _, v := range rg {
+		if v.Document != nil && !seen[v.Document] {
+			seen[v.Document] = true
+			docs = append(docs, v.Document)
+		}
+	}
+	sort.Slice(docs, func(i, j int) bool { return docs[i].Path < docs[j].Path })
+	return docs
+}
+
 // Updates the tasks associated with each requirement.For each requirement in rg, the method will:
 // - find the task associated with the requirement, by searching for the requirement ID in the task title using the taskmgr API
 // - if a task was found and the requirement was not deleted, its title and description are updated
@@ -435,9 +667,8 @@ func (rg reqGraph) UpdateTasks(filterIDs map[string]bool) error {
 	queue := rg.OrdsByPosition()  // breadth-first traversal queue
 	enqueued := map[string]bool{} // set of elements that have already been enqueued for traversal
 	reqIDToTaskPHID := map[string]string{}
-	const projectNameSYS = config.ProjectName + "-SYS"
-	const projectNameHLR = config.ProjectName + "-HLR"
-	const projectNameLLR = config.ProjectName
+	projectNameSYS := config.ProjectName + "-SYS"
+	projectNameHLR := config.ProjectName + "-HLR"
 	sysProjectID, err := taskmgr.TaskMgr.GetOrCreateProject(projectNameSYS, "")
 	if err != nil {
 		return err
@@ -460,7 +691,7 @@ func (rg reqGraph) UpdateTasks(filterIDs map[string]bool) error {
 	}
 	parentOfAllPHID := ""
 	if parentOfAll == nil {
-		log.Printf("Creating parent of all requirements: '%s'", parentTaskTitle)
+		slog.Info("creating parent of all requirements", "title", parentTaskTitle)
 
 		parentOfAllPHID, err = taskmgr.TaskMgr.CreateTask(parentTaskTitle, "Meta-task that incorporates all tasks needed to implement "+config.ProjectName,
 			sysProjectID, map[string]string{}, []string{})
@@ -501,7 +732,7 @@ func (rg reqGraph) UpdateTasks(filterIDs map[string]bool) error {
 		if filterIDs[currentReq.ID] { // don't update requirements that are filtered
 			if task == nil {
 				if !currentReq.IsDeleted() {
-					log.Printf("Creating task for requirement %s", currentReq.ID)
+					slog.Info("creating task", "req", currentReq.ID)
 
 					taskPHID, err := taskmgr.TaskMgr.CreateTask(currentReq.ID+": "+currentReq.Title, string(currentReq.Body),
 						projectPHID, currentReq.Attributes, parentTaskIDs)
@@ -513,7 +744,7 @@ func (rg reqGraph) UpdateTasks(filterIDs map[string]bool) error {
 			} else {
 				if currentReq.IsDeleted() {
 					if task.Status != "invalid" {
-						log.Printf("Marking task T%s for DELETED requirement %s as invalid", task.ID, currentReq.ID)
+						slog.Info("marking task of deleted requirement as invalid", "task", "T"+task.ID, "req", currentReq.ID)
 
 						err = taskmgr.TaskMgr.DeleteTask(task.ID, currentReq.ID+": "+currentReq.Title, projectPHID)
 						if err != nil {
@@ -521,7 +752,7 @@ func (rg reqGraph) UpdateTasks(filterIDs map[string]bool) error {
 						}
 					}
 				} else {
-					log.Printf("Updating task T%s for requirement %s", task.ID, currentReq.ID)
+					slog.Info("updating task", "task", "T"+task.ID, "req", currentReq.ID)
 					err = taskmgr.TaskMgr.UpdateTask(task.ID, currentReq.ID+": "+currentReq.Title, string(currentReq.Body),
 						projectPHID, currentReq.Attributes, parentTaskIDs)
 					if err != nil {
@@ -566,14 +797,20 @@ func (a byPosition) Len() int           { return len(a) }
 func (a byPosition) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
 func (a byPosition) Less(i, j int) bool { return a[i].Position < a[j].Position }
 
-var reLLRReference = regexp.MustCompile(`//\s*@llr\s*(REQ-\d+-\w+-SWL-\d+).*`)
+// reLLRReference matches the @llr tags of the code, with one or more IDs
+// separated by commas or spaces, see tagReader.
+var reLLRReference = regexp.MustCompile(`//\s*@llr\s*(REQ-\d+-\w+-SWL-\d+(?:\.\d+)?(?:(?:\s*,\s*|\s+)REQ-\d+-\w+-SWL-\d+(?:\.\d+)?)*).*`)
 
 func parseCode(id, fileName string, graph reqGraph) error {
 	f, err := os.Open(fileName)
 	if err != nil {
 		return err
 	}
+	defer f.Close()
 	var refs []string
+	var lines []int
+	var legacy []legacyRef
+	var mentions []string
 	h := sha1.New()
 	// git compatible hash
 	if s, err := f.Stat(); err == nil {
@@ -581,17 +818,46 @@ func parseCode(id, fileName string, graph reqGraph) error {
 		h.Write([]byte{0})
 	}
 
-	scanner := bufio.NewScanner(io.TeeReader(f, h))
-	for scanner.Scan() {
-		if parts := reLLRReference.FindStringSubmatch(scanner.Text()); len(parts) > 0 {
-			refs = append(refs, parts[1])
+	reReference, kind := referenceRegexp(fileName)
+	tags := tagReader{re: reReference, robot: isRobotFile(fileName)}
+	var cases *testCaseReader
+	if isCTestFile(fileName) || isGoTestFile(fileName) {
+		cases = &testCaseReader{}
+	}
+	scanner := newLineReader(io.TeeReader(f, h))
+	for lno := 1; scanner.Scan(); lno++ {
+		ids := tags.ids(scanner.Text())
+		if ids == nil && kind == "" {
+			for _, ref := range commentMentions(scanner.Text()) {
+				id, _ := resolveAlias(ref)
+				mentions = append(mentions, id)
+			}
+		}
+		for _, ref := range ids {
+			slog.Debug("found requirement reference", "file", fileName, "line", lno, "req", ref)
+			id, isLegacy := resolveAlias(ref)
+			if isLegacy {
+				legacy = append(legacy, legacyRef{Line: lno, ID: ref, Alias: id})
+			}
+			refs = append(refs, id)
+			lines = append(lines, lno)
+		}
+		if cases != nil {
+			cases.line(lno, scanner.Text(), ids)
 		}
 	}
 	if err := scanner.Err(); err != nil {
 		return err
 	}
-	if len(refs) > 0 {
+	// The files only mentioning requirements reference them, see resolveLinks.
+	if len(refs) > 0 || len(mentions) > 0 {
 		graph.AddCodeRefs(id, fileName, string(h.Sum(nil)), refs)
+		graph[fileName].LegacyRefs = legacy
+		graph[fileName].RefLines = lines
+		graph[fileName].Mentions = mentions
+		if cases != nil {
+			graph[fileName].TestCases = cases.testCases()
+		}
 	}
 	return nil
 }
@@ -599,24 +865,65 @@ func parseCode(id, fileName string, graph reqGraph) error {
 func parseCertdocToGraph(fileName string, graph reqGraph) []error {
 	reqs, err := ParseCertdoc(fileName)
 	if err != nil {
-		return []error{fmt.Errorf("Error parsing %s: %v", fileName, err)}
+		return []error{newFinding("malformed_requirement", "Error parsing %s: %v", fileName, err)}
+	}
+	contents, err := readCertdoc(fileName)
+	if err != nil {
+		return []error{newFinding("malformed_requirement", "Error parsing %s: %v", fileName, err)}
+	}
+	repoPath, err := git.RepoPath()
+	if err != nil {
+		return []error{err}
+	}
+	doc := ParseDocument(fileName, contents)
+	doc.Path = filepath.ToSlash(strings.TrimPrefix(fileName, repoPath))
+	sections := docSections(contents)
+	var parents map[string][]string
+	if repoConfig.InferParents && strings.ToLower(path.Ext(fileName)) == ".md" {
+		parents = headingParents(fileName, contents)
 	}
 	isReqPresent := make([]bool, len(reqs))
 
-	var errs []error
+	pragmas, errs := docPragmas(fileName, strings.Split(strings.ReplaceAll(string(contents), "\r\n", "\n"), "\n"))
 	for i, v := range reqs {
 		r, err := ParseReq(v)
 		if err != nil {
 			errs = append(errs, err)
 			continue
 		}
+		r.normalizeAttributes()
 		errs2 := lintLyxReq(fileName, len(reqs), isReqPresent, r)
 		if len(errs2) != 0 {
 			errs = append(errs, errs2...)
 			continue
 		}
-		r.Position = i
-		graph.AddReq(r, fileName)
+		if inferred := parents[r.ID]; len(inferred) > 0 {
+			known := map[string]bool{}
+			for _, p := range r.ParentIds {
+				known[p] = true
+			}
+			for _, p := range inferred {
+				if !known[p] {
+					r.ParentIds = append(r.ParentIds, p)
+				}
+			}
+			r.Attributes["PARENTS"] = strings.Join(r.ParentIds, ", ")
+		}
+		section := sections[r.ID]
+		instances, err := r.Instances()
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		suppressions := pragmas[r.ID]
+		for _, r := range instances {
+			r.Position = i
+			r.Document = doc
+			r.Section, r.SectionNumber = section.Path, section.Number
+			r.Suppressions = suppressions
+			slog.Debug("parsed requirement", "file", fileName, "req", r.ID, "position", i)
+			graph.AddReq(r, fileName)
+		}
 	}
 
 	return errs
@@ -628,6 +935,9 @@ const (
 	TitleFilter FilterType = iota
 	IdFilter
 	BodyFilter
+	TagFilter     // Matches the tags, see tagFilter.
+	SectionFilter // Matches the section, see Req.Section.
+	TeamFilter    // Matches the teams, see Req.Teams.
 )
 
 type ReqFilter map[FilterType]*regexp.Regexp
@@ -650,6 +960,18 @@ func (r *Req) Matches(filter ReqFilter, diffs map[string][]string) bool {
 			if !e.MatchString(string(r.Body)) {
 				return false
 			}
+		case TagFilter:
+			if !r.hasTag(e) {
+				return false
+			}
+		case SectionFilter:
+			if !e.MatchString(r.Section) {
+				return false
+			}
+		case TeamFilter:
+			if !r.hasTeam(e) {
+				return false
+			}
 		}
 	}
 	if diffs == nil {
@@ -693,7 +1015,7 @@ func NextId(f string) (string, error) {
 		if err := IsValidDocName(f); err != nil {
 			return "", err
 		}
-		fNameWithExt := path.Base(f)
+		fNameWithExt := filepath.Base(f)
 		extension := filepath.Ext(fNameWithExt)
 		fName := fNameWithExt[0 : len(fNameWithExt)-len(extension)]
 		fNameComps := strings.Split(fName, "-")
@@ -732,7 +1054,7 @@ func IsValidDocName(f string) error {
 	default:
 		return fmt.Errorf("Invalid extension: '%s'. Only '.lyx' and '.md' are supported", strings.ToLower(ext))
 	}
-	filename := strings.TrimSuffix(path.Base(f), ext)
+	filename := strings.TrimSuffix(filepath.Base(f), ext)
 	// check if the structure of the filename is correct
 	if !reCertdoc.MatchString(filename) {
 		return fmt.Errorf("Invalid file name: '%s'. Certification doc file name must match %v", filename, reCertdoc)
@@ -2,10 +2,28 @@
 package main
 
 import (
+	"archive/zip"
+	"bytes"
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"html/template"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
 	"reflect"
 	"regexp"
+	"sort"
 	"strconv"
+	"strings"
 	"testing"
+	"time"
+	"unicode/utf8"
 
 	"github.com/daedaleanai/reqtraq/config"
 	"github.com/stretchr/testify/assert"
@@ -30,6 +48,13 @@ func TestReqGraph_AddCodeRef(t *testing.T) {
 	}
 }
 
+func TestRelativePathToRepo(t *testing.T) {
+	repo := filepath.Join(string(filepath.Separator)+"src", "repo")
+	assert.Equal(t, "code/a.cc", relativePathToRepo(filepath.Join(repo, "code", "a.cc"), repo))
+	assert.Equal(t, "", relativePathToRepo(filepath.Join(repo+"2", "a.cc"), repo))
+	assert.Equal(t, "", relativePathToRepo(repo, repo))
+}
+
 func TestReqGraph_AddReq(t *testing.T) {
 	rg := reqGraph{}
 
@@ -145,6 +170,16 @@ func TestReq_BodyFilter(t *testing.T) {
 	}
 }
 
+func TestReq_SectionFilter(t *testing.T) {
+	r := Req{ID: "REQ-0-DDLN-SWH-001", Body: "thrust control", Section: "Requirements > Functional"}
+	if !r.Matches(ReqFilter{SectionFilter: regexp.MustCompile("Functional$")}, nil) {
+		t.Errorf("expected matching requirement but did not match")
+	}
+	if r.Matches(ReqFilter{SectionFilter: regexp.MustCompile("Performance")}, nil) {
+		t.Errorf("expected mismatching requirement but found match")
+	}
+}
+
 func TestReq_IdAndBodyFilter(t *testing.T) {
 	r := Req{ID: "REQ-0-DDLN-SWL-014", Body: "thrust control"}
 	filter := ReqFilter{IdFilter: regexp.MustCompile("REQ-0-*"), BodyFilter: regexp.MustCompile("thrust")}
@@ -216,3 +251,2579 @@ func TestReq_IsDeleted(t *testing.T) {
 	req := Req{ID: "REQ-123-TEST-SYS-002", Title: "DELETED Requirement", Body: "This is the body"}
 	assert.True(t, req.IsDeleted(), "Requirement with title %s should have status DELETED", req.Body)
 }
+
+func TestReqGraph_Owners(t *testing.T) {
+	rg := reqGraph{
+		"REQ-0-TEST-SWH-001": &Req{ID: "REQ-0-TEST-SWH-001", Level: config.HIGH,
+			Attributes: map[string]string{"OWNER": "alice", "REVIEWER": "bob, carol"}},
+		"REQ-0-TEST-SWH-002": &Req{ID: "REQ-0-TEST-SWH-002", Level: config.HIGH,
+			Attributes: map[string]string{"OWNER": "bob, alice", "REVIEWER": "bob"}},
+		"REQ-0-TEST-SWH-003": &Req{ID: "REQ-0-TEST-SWH-003", Level: config.HIGH, Attributes: map[string]string{}},
+	}
+	errs := rg.CheckOwners(&Roster{Members: []string{"alice", "bob"}})
+	assert.Equal(t, []error{
+		newFinding("owner", "Requirement 'REQ-0-TEST-SWH-001' has reviewer 'carol' who is not in the roster.\n"),
+		newFinding("owner", "Requirement 'REQ-0-TEST-SWH-002' has 'bob' both as owner and reviewer.\n"),
+	}, errs)
+
+	owned := rg.OwnedBy("alice")
+	assert.Equal(t, 2, len(owned))
+	assert.Equal(t, "REQ-0-TEST-SWH-001", owned[0].ID)
+
+	byOwner := rg.ByOwner()
+	assert.Equal(t, []string{"alice", "bob", ""}, []string{byOwner[0].Owner, byOwner[1].Owner, byOwner[2].Owner})
+	assert.Equal(t, 1, len(byOwner[1].Reqs))
+}
+
+func TestReqGraph_Teams(t *testing.T) {
+	co, err := parseCodeOwners(strings.NewReader(`# Owners
+certdocs/            @test/docs
+certdocs/*-SRD.md    @test/systems
+/src/display/        @test/display @test/hmi
+**/vendor/**         @test/cots
+src/display/old.go
+`), "CODEOWNERS")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []string{"@test/systems"}, co.Owners("/certdocs/0-TEST-211-SRD.md"))
+	assert.Equal(t, []string{"@test/docs"}, co.Owners("certdocs/0-TEST-212-SDD.md"))
+	assert.Equal(t, []string{"@test/display", "@test/hmi"}, co.Owners("src/display/lcd/lcd.go"))
+	assert.Equal(t, []string{"@test/cots"}, co.Owners("src/display/vendor/font/font.c"))
+	assert.Empty(t, co.Owners("src/display/old.go"))
+	assert.Empty(t, co.Owners("lib/display/lcd.go"))
+
+	code := &Req{Path: "src/display/lcd.go", Level: config.CODE}
+	rg := reqGraph{
+		"REQ-0-TEST-SWL-001": &Req{ID: "REQ-0-TEST-SWL-001", Level: config.LOW, Path: "certdocs/0-TEST-212-SDD.md",
+			Children: []*Req{code}},
+		"REQ-0-TEST-SWL-002": &Req{ID: "REQ-0-TEST-SWL-002", Level: config.LOW, Path: "other/0-TEST-212-SDD.md"},
+		"src/display/lcd.go": code,
+	}
+	rg.SetTeams(co)
+	assert.Equal(t, []string{"@test/display", "@test/docs", "@test/hmi"}, rg["REQ-0-TEST-SWL-001"].Teams)
+	assert.Equal(t, []error{
+		newFinding("team", "Requirement 'REQ-0-TEST-SWL-002' in other/0-TEST-212-SDD.md has no owner team in CODEOWNERS.\n"),
+	}, rg.CheckTeams())
+	assert.Equal(t, 1, Summarize("precommit", exitFindings, rg.CheckTeams()[0]).Counts["team"])
+	assert.True(t, rg["REQ-0-TEST-SWL-001"].Matches(ReqFilter{TeamFilter: tagFilter("@test/hmi,@test/cots")}, nil))
+	assert.False(t, rg["REQ-0-TEST-SWL-002"].Matches(ReqFilter{TeamFilter: tagFilter("@test/hmi")}, nil))
+
+	byTeam := rg.ByTeam()
+	assert.Equal(t, []string{"@test/display", "@test/docs", "@test/hmi", ""},
+		[]string{byTeam[0].Owner, byTeam[1].Owner, byTeam[2].Owner, byTeam[3].Owner})
+}
+
+func TestSplitList(t *testing.T) {
+	assert.Equal(t, []string{"navigation", "datalink", "Jane Doe"}, splitList(" navigation,datalink, ,Jane Doe ,"))
+	assert.Nil(t, splitList(" , "))
+	r := &Req{Attributes: map[string]string{"TAGS": "navigation, datalink", "OWNER": "Jane Doe,", "REVIEWER": ""}}
+	assert.Equal(t, []string{"navigation", "datalink"}, r.Tags())
+	assert.Equal(t, []string{"Jane Doe"}, r.Owners())
+	assert.Nil(t, r.Reviewers())
+}
+
+func TestReqGraph_Tags(t *testing.T) {
+	rg := reqGraph{
+		"REQ-0-TEST-SWH-001": &Req{ID: "REQ-0-TEST-SWH-001", Level: config.HIGH,
+			Attributes: map[string]string{"TAGS": "navigation, datalink"}},
+		"REQ-0-TEST-SWH-002": &Req{ID: "REQ-0-TEST-SWH-002", Level: config.HIGH,
+			Attributes: map[string]string{"TAGS": "datalink,Display"}},
+		"REQ-0-TEST-SWH-003": &Req{ID: "REQ-0-TEST-SWH-003", Level: config.HIGH, Attributes: map[string]string{}},
+	}
+	assert.Equal(t, []string{"Display", "datalink", "navigation"}, rg.Tags())
+	errs := rg.CheckTags([]string{"navigation", "datalink", "display"})
+	assert.Equal(t, []error{
+		newFinding("tag", "Requirement 'REQ-0-TEST-SWH-002' has unknown tag 'Display'. Expected one of navigation, datalink, display.\n"),
+	}, errs)
+	assert.Equal(t, 1, Summarize("precommit", exitFindings, errs[0]).Counts["tag"])
+
+	tagged := rg.Tagged(tagFilter("datalink"))
+	assert.Equal(t, 2, len(tagged))
+	tagged = rg.Tagged(tagFilter("navigation, nav.*"))
+	if assert.Equal(t, 1, len(tagged)) {
+		assert.Equal(t, "REQ-0-TEST-SWH-001", tagged[0].ID)
+	}
+
+	filter := ReqFilter{TagFilter: tagFilter("Display,other")}
+	assert.False(t, rg["REQ-0-TEST-SWH-001"].Matches(filter, nil))
+	assert.True(t, rg["REQ-0-TEST-SWH-002"].Matches(filter, nil))
+	assert.False(t, rg["REQ-0-TEST-SWH-003"].Matches(filter, nil))
+
+	r, err := ParseReq("REQ-0-TEST-SYS-001 Title\n\nBody.\n\n###### Attributes:\n- Rationale: R\n- Tags: navigation, datalink\n")
+	if assert.Nil(t, err) {
+		assert.Equal(t, []string{"navigation", "datalink"}, r.Tags())
+	}
+}
+
+func TestReqGraph_Checklist(t *testing.T) {
+	sys := &Req{ID: "REQ-0-TEST-SYS-001", Level: config.SYSTEM, Title: "System"}
+	r := &Req{ID: "REQ-0-TEST-SWH-001", Level: config.HIGH, Path: "certdocs/0-TEST-100-ORD.md", Title: "Speed",
+		Body: "The speed shall be shown.", Attributes: map[string]string{"RATIONALE": "R", "VERIFICATION": "Test"},
+		Parents: []*Req{sys}}
+	code := &Req{ID: "code/speed.go", Level: config.CODE, Parents: []*Req{r}}
+	r.Children = []*Req{code}
+	rg := reqGraph{sys.ID: sys, r.ID: r, code.ID: code}
+
+	tmpl, err := LoadChecklistTemplate("")
+	if !assert.Nil(t, err) {
+		return
+	}
+	var b bytes.Buffer
+	if assert.Nil(t, rg.Checklist(&b, tmpl, r.ID, []string{"Checked A.", "Checked B."})) {
+		assert.Equal(t, `# Review checklist: REQ-0-TEST-SWH-001
+
+## REQ-0-TEST-SWH-001 Speed
+
+Document: certdocs/0-TEST-100-ORD.md
+
+The speed shall be shown.
+
+###### Attributes:
+- RATIONALE: R
+- VERIFICATION: Test
+
+###### Parents:
+- REQ-0-TEST-SYS-001 System
+
+###### Code:
+- code/speed.go
+
+## Checklist
+
+- [ ] Checked A.
+- [ ] Checked B.
+
+Reviewer:
+
+Date:
+
+Comments:
+`, b.String())
+	}
+
+	b.Reset()
+	if assert.Nil(t, rg.Checklist(&b, tmpl, sys.ID, nil)) {
+		assert.Contains(t, b.String(), "###### Parents:\n- None\n")
+		assert.Contains(t, b.String(), "- [ ] "+defaultChecklistItems[0]+"\n")
+	}
+	assert.NotNil(t, rg.Checklist(&b, tmpl, "REQ-0-TEST-SWH-002", nil))
+}
+
+func TestReqGraph_Stats(t *testing.T) {
+	sys := &Req{ID: "REQ-0-TEST-SYS-001", Level: config.SYSTEM}
+	hlr := &Req{ID: "REQ-0-TEST-SWH-001", Level: config.HIGH}
+	llr1 := &Req{ID: "REQ-0-TEST-SWL-001", Level: config.LOW}
+	llr2 := &Req{ID: "REQ-0-TEST-SWL-002", Level: config.LOW}
+	code := &Req{ID: "a/parser.go", Path: "a/parser.go", Level: config.CODE}
+	test := &Req{ID: "a/parser_test.go", Path: "a/parser_test.go", Level: config.CODE}
+	sys.Children = []*Req{hlr}
+	hlr.Children = []*Req{llr1, llr2}
+	llr1.Children = []*Req{code, test}
+	llr2.Children = []*Req{code}
+	rg := reqGraph{sys.ID: sys, hlr.ID: hlr, llr1.ID: llr1, llr2.ID: llr2, code.ID: code, test.ID: test}
+
+	assert.Equal(t, []LevelStats{
+		{Level: "system", Total: 1, WithChildren: 1, WithCode: 1, WithTests: 1, PctChildren: 100, PctCode: 100, PctTests: 100},
+		{Level: "high", Total: 1, WithChildren: 1, WithCode: 1, WithTests: 1, PctChildren: 100, PctCode: 100, PctTests: 100},
+		{Level: "low", Total: 2, WithChildren: 2, WithCode: 2, WithTests: 1, PctChildren: 100, PctCode: 100, PctTests: 50},
+	}, rg.Stats())
+}
+
+func TestReqGraph_PriorityStats(t *testing.T) {
+	hlr1 := &Req{ID: "REQ-0-TEST-SWH-001", Level: config.HIGH, Attributes: map[string]string{"PRIORITY": "High"}}
+	hlr2 := &Req{ID: "REQ-0-TEST-SWH-002", Level: config.HIGH, Attributes: map[string]string{"PRIORITY": "High"}}
+	hlr3 := &Req{ID: "REQ-0-TEST-SWH-003", Level: config.HIGH, Attributes: map[string]string{"PRIORITY": "Low"}}
+	hlr4 := &Req{ID: "REQ-0-TEST-SWH-004", Level: config.HIGH, Attributes: map[string]string{"PRIORITY": "Urgent"}}
+	hlr5 := &Req{ID: "REQ-0-TEST-SWH-005", Level: config.HIGH, Attributes: map[string]string{}}
+	llr := &Req{ID: "REQ-0-TEST-SWL-001", Level: config.LOW, Attributes: map[string]string{"PRIORITY": "Low"}}
+	code := &Req{ID: "a/parser.go", Path: "a/parser.go", Level: config.CODE}
+	test := &Req{ID: "a/parser_test.go", Path: "a/parser_test.go", Level: config.CODE}
+	hlr1.Children = []*Req{llr}
+	hlr2.Children = []*Req{code}
+	llr.Children = []*Req{code, test}
+	rg := reqGraph{}
+	for _, r := range []*Req{hlr1, hlr2, hlr3, hlr4, hlr5, llr, code, test} {
+		rg[r.ID] = r
+	}
+
+	assert.Equal(t, []PriorityStats{
+		{Priority: "High", Total: 2, Traced: 1, Partial: 1, PctTraced: 50, PctPartial: 50},
+		{Priority: "Low", Total: 1, Untraced: 1},
+		{Priority: "Urgent", Total: 1, Untraced: 1},
+		{Priority: "", Total: 1, Untraced: 1},
+	}, rg.PriorityStats([]string{"High", "Medium", "Low"}))
+	assert.Equal(t, "High", rg.PriorityStats(nil)[0].Priority)
+	assert.Nil(t, reqGraph{hlr5.ID: hlr5}.PriorityStats(nil))
+
+	assert.Empty(t, rg.CheckPriorities(nil))
+	assert.Equal(t, []error{
+		newFinding("attribute", "Requirement 'REQ-0-TEST-SWH-004' has invalid value 'Urgent' in attribute 'PRIORITY'. Expected one of High, Medium, Low.\n"),
+	}, rg.CheckPriorities([]string{"High", "Medium", "Low"}))
+}
+
+func TestReqGraph_Rollups(t *testing.T) {
+	sys := &Req{ID: "REQ-0-TEST-SYS-001", Level: config.SYSTEM, Path: "SYS.md", Title: "Navigation", Attributes: map[string]string{"EFFORT": "1"}}
+	hlr1 := &Req{ID: "REQ-0-TEST-SWH-001", Level: config.HIGH, Attributes: map[string]string{"EFFORT": "2.5"}}
+	hlr2 := &Req{ID: "REQ-0-TEST-SWH-002", Level: config.HIGH, Attributes: map[string]string{}}
+	llr := &Req{ID: "REQ-0-TEST-SWL-001", Level: config.LOW, Attributes: map[string]string{"EFFORT": "4"}}
+	deleted := &Req{ID: "REQ-0-TEST-SWL-002", Level: config.LOW, Title: "DELETED", Attributes: map[string]string{"EFFORT": "8"}}
+	code := &Req{ID: "a/nav.go", Path: "a/nav.go", Level: config.CODE}
+	sys.Children = []*Req{hlr1, hlr2}
+	hlr1.Children = []*Req{llr, deleted}
+	hlr2.Children = []*Req{llr}
+	llr.Children = []*Req{code}
+	rg := reqGraph{}
+	for _, r := range []*Req{sys, hlr1, hlr2, llr, deleted, code} {
+		rg[r.ID] = r
+	}
+
+	assert.Equal(t, []Rollup{
+		{sys, 1, 7.5},
+		{hlr1, 2.5, 6.5},
+		{hlr2, 0, 4},
+		{llr, 4, 4},
+	}, rg.Rollups("Effort"))
+
+	var b bytes.Buffer
+	WriteRollupsMarkdown(&b, "EFFORT", rg.Rollups("Effort")[:1])
+	assert.Equal(t, "# EFFORT rollup\n\n| Requirement | Title | EFFORT | Total |\n|---|---|---|---|\n| REQ-0-TEST-SYS-001 | Navigation | 1 | 7.5 |\n", b.String())
+
+	r := &Req{ID: "REQ-0-TEST-SWH-003", Level: config.HIGH, Attributes: map[string]string{"EFFORT": "2.5", "COMPLEXITY": "high"}}
+	assert.Equal(t, []error{
+		newFinding("attribute", "Requirement 'REQ-0-TEST-SWH-003' has invalid value '2.5' in attribute 'EFFORT'. Expected an integer.\n"),
+		newFinding("attribute", "Requirement 'REQ-0-TEST-SWH-003' has invalid value 'high' in attribute 'COMPLEXITY'. Expected a number.\n"),
+	}, r.CheckAttributes([]map[string]string{{"name": "Effort", "type": "int"}, {"name": "Complexity", "type": "float"}}))
+}
+
+func TestReq_TypedAttribute(t *testing.T) {
+	as := []map[string]string{
+		{"name": "Priority", "type": "enum", "values": "High\nMedium\nLow"},
+		{"name": "Due", "type": "date"},
+		{"name": "Effort", "type": "int"},
+		{"name": "Verifies", "type": "ref"},
+	}
+	reqs := []*Req{
+		{ID: "REQ-0-TEST-SWH-001", Attributes: map[string]string{"PRIORITY": "low", "DUE": "2025-03-01", "EFFORT": "10"}},
+		{ID: "REQ-0-TEST-SWH-002", Attributes: map[string]string{"PRIORITY": "Urgent", "DUE": "March", "EFFORT": "2"}},
+		{ID: "REQ-0-TEST-SWH-003", Attributes: map[string]string{"PRIORITY": "High", "DUE": "2024-12-31", "EFFORT": "9",
+			"VERIFIES": "REQ-0-TEST-SYS-001, REQ-0-TEST-SYS-002"}},
+		{ID: "REQ-0-TEST-SWH-004", Attributes: map[string]string{"VERIFIES": "REQ-0-TEST-SYS-001 and more"}},
+	}
+	for _, r := range reqs {
+		r.parseTypedAttributes(as)
+	}
+
+	v, ok, err := reqs[2].TypedAttribute("Verifies")
+	assert.True(t, ok)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"REQ-0-TEST-SYS-001", "REQ-0-TEST-SYS-002"}, v.Refs)
+	_, _, err = reqs[3].TypedAttribute("Verifies")
+	assert.EqualError(t, err, "Requirement 'REQ-0-TEST-SWH-004' has invalid value 'REQ-0-TEST-SYS-001 and more' in attribute 'VERIFIES'. Expected requirement IDs separated by commas.\n")
+	_, _, err = reqs[1].TypedAttribute("Priority")
+	assert.EqualError(t, err, "Requirement 'REQ-0-TEST-SWH-002' has invalid value 'Urgent' in attribute 'PRIORITY'. Expected one of High, Medium, Low.\n")
+	_, ok, _ = reqs[3].TypedAttribute("Due")
+	assert.False(t, ok)
+
+	ids := func() []string {
+		var res []string
+		for _, r := range reqs {
+			res = append(res, r.ID)
+		}
+		return res
+	}
+	sortByAttribute(reqs, "Priority")
+	assert.Equal(t, []string{"REQ-0-TEST-SWH-003", "REQ-0-TEST-SWH-001", "REQ-0-TEST-SWH-002", "REQ-0-TEST-SWH-004"}, ids())
+	sortByAttribute(reqs, "effort")
+	assert.Equal(t, []string{"REQ-0-TEST-SWH-002", "REQ-0-TEST-SWH-003", "REQ-0-TEST-SWH-001", "REQ-0-TEST-SWH-004"}, ids())
+	sortByAttribute(reqs, "Due")
+	assert.Equal(t, []string{"REQ-0-TEST-SWH-003", "REQ-0-TEST-SWH-001", "REQ-0-TEST-SWH-002", "REQ-0-TEST-SWH-004"}, ids())
+
+	rg := reqGraph{}
+	for _, r := range reqs {
+		rg[r.ID] = r
+	}
+	matches, err := rg.Query("attrCmp(Effort, >=, 9) & attrCmp(Due, <, 2025-01-01)")
+	assert.Nil(t, err)
+	assert.Equal(t, []*Req{rg["REQ-0-TEST-SWH-003"]}, matches)
+	_, err = rg.Query("attrCmp(Effort, >=, many)")
+	assert.EqualError(t, err, `invalid query at position 26: invalid value "many" of Effort, expected an integer`)
+	_, err = rg.Query("attrCmp(Effort, =, 1)")
+	assert.EqualError(t, err, `invalid query at position 22: unknown comparison "=", expected one of ==, !=, <, <=, >, >=`)
+}
+
+func TestCreateReqGraph_TypedAttributes(t *testing.T) {
+	dir := t.TempDir()
+	cwd, err := os.Getwd()
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Nil(t, os.Chdir(dir))
+	defer os.Chdir(cwd)
+	out, err := exec.Command("git", "init", "-q").CombinedOutput()
+	assert.Nil(t, err, string(out))
+	write := func(name, contents string) {
+		fileName := filepath.Join(dir, filepath.FromSlash(name))
+		assert.Nil(t, os.MkdirAll(filepath.Dir(fileName), 0755))
+		assert.Nil(t, ioutil.WriteFile(fileName, []byte(contents), 0644))
+	}
+	write("reqtraq.yaml", "version: 1\nattributes:\n  - name: Effort\n    type: int\n")
+	write("certdocs/0-TEST-100-ORD.md", "# ORD\n\n## Requirements\n\n"+
+		"### REQ-0-TEST-SYS-001 Speed\n\nThe speed shall be displayed.\n\n###### Attributes:\n- Effort: 3\n\n"+
+		"### REQ-0-TEST-SYS-002 Altitude\n\nThe altitude shall be displayed.\n\n###### Attributes:\n- Effort: 2.5\n")
+	// The fragment overrides the type of the attribute in its directory.
+	write("certdocs/comp/reqtraq.yaml", "version: 1\nattributes:\n  - name: Effort\n    type: float\n")
+	write("certdocs/comp/0-TEST-211-SRD.md", "# SRD\n\n## Requirements\n\n"+
+		"### REQ-0-TEST-SWH-001 Speed in knots\n\nThe speed shall be displayed in knots.\n\n"+
+		"###### Attributes:\n- Parents: REQ-0-TEST-SYS-001\n- Effort: 2.5\n")
+	defer func(c *RepoConfig, s string) { repoConfig, *fReportJsonConfPath = c, s }(repoConfig, *fReportJsonConfPath)
+	if !assert.Nil(t, applyRepoConfig(filepath.Join(dir, "reqtraq.yaml"))) {
+		return
+	}
+
+	rg, err := createReqGraph(dir, "certdocs", "code")
+	if !assert.Nil(t, err) {
+		return
+	}
+	v, ok, err := rg["REQ-0-TEST-SYS-001"].TypedAttribute("Effort")
+	assert.True(t, ok)
+	assert.Nil(t, err)
+	assert.Equal(t, 3.0, v.Number)
+	v, _, err = rg["REQ-0-TEST-SWH-001"].TypedAttribute("Effort")
+	assert.Nil(t, err)
+	assert.Equal(t, 2.5, v.Number)
+
+	// The values are validated as by CheckAttributes.
+	configs, err := loadConfigTree(dir, "certdocs")
+	assert.Nil(t, err)
+	reportConf, err := ReadJsonConf(*fReportJsonConfPath)
+	assert.Nil(t, err)
+	_, _, err = rg["REQ-0-TEST-SYS-002"].TypedAttribute("Effort")
+	assert.Equal(t, []error{err}, rg.CheckAttributesIn(configs, reportConf.Attributes))
+	assert.EqualError(t, err, "Requirement 'REQ-0-TEST-SYS-002' has invalid value '2.5' in attribute 'EFFORT'. Expected an integer.\n")
+
+	matches, err := rg.Query("attrCmp(Effort, >, 2)")
+	assert.Nil(t, err)
+	assert.ElementsMatch(t, []*Req{rg["REQ-0-TEST-SYS-001"], rg["REQ-0-TEST-SWH-001"]}, matches)
+}
+
+func TestReqGraph_Snapshot(t *testing.T) {
+	rg, err := CreateReqGraph("/testdata/TestPreCommitCheckReqReferences", "/testdata/TestPreCommitCheckReqReferences")
+	assert.Nil(t, err)
+	assert.NotEqual(t, 0, len(rg))
+	problems := findingList{
+		newFinding("invalid_parent", "Invalid parent of requirement REQ-0-TEST-SWL-002: REQ-0-TEST-SYS-002 is deleted.\n"),
+		findingHeader("\n"),
+	}
+	hash, err := rg.Hash()
+	assert.Nil(t, err)
+	hlr := rg["REQ-0-TEST-SWH-002"]
+	if assert.NotNil(t, hlr) {
+		hlr.Inherited = map[string]string{"DAL": "B"}
+		hlr.parseTypedAttributes([]map[string]string{{"name": "DAL", "type": "enum", "values": "A\nB\nC"}, {"name": "Weight", "type": "int"}})
+		assert.Equal(t, 1.0, hlr.Typed["DAL"].Number)
+	}
+	derived, err := rg.Hash()
+	assert.Nil(t, err)
+	assert.Equal(t, hash, derived, "the inherited and typed attributes do not change the hash")
+
+	fileName := filepath.Join(t.TempDir(), "snapshot.json")
+	f, err := os.Create(fileName)
+	assert.Nil(t, err)
+	assert.Nil(t, rg.WriteSnapshot(f, problems))
+	f.Close()
+
+	loaded, err := ReadSnapshot(fileName)
+	assert.Equal(t, problems, err)
+	assert.Equal(t, len(rg), len(loaded))
+	for k, r := range rg {
+		l := loaded[k]
+		assert.Equal(t, r.ID, l.ID)
+		assert.Equal(t, r.Body, l.Body)
+		assert.Equal(t, r.Attributes, l.Attributes)
+		assert.Equal(t, r.Inherited, l.Inherited)
+		assert.Equal(t, r.Typed, l.Typed)
+		assert.Equal(t, len(r.Parents), len(l.Parents))
+		for i, p := range r.Parents {
+			assert.Same(t, loaded[p.ID], l.Parents[i])
+		}
+		assert.Equal(t, len(r.Children), len(l.Children))
+	}
+}
+
+func TestReqGraph_ExtractComponent(t *testing.T) {
+	sys := &Req{ID: "REQ-0-TEST-SYS-001", Level: config.SYSTEM, Attributes: map[string]string{}}
+	hlr1 := &Req{ID: "REQ-0-TEST-SWH-001", Level: config.HIGH, Attributes: map[string]string{"COMPONENT": "FMS, Display"}}
+	hlr2 := &Req{ID: "REQ-0-TEST-SWH-002", Level: config.HIGH, Attributes: map[string]string{"COMPONENT": "Display"}}
+	llr1 := &Req{ID: "REQ-0-TEST-SWL-001", Level: config.LOW, Attributes: map[string]string{"COMPONENT": "fms"}}
+	llr2 := &Req{ID: "REQ-0-TEST-SWL-002", Level: config.LOW, Attributes: map[string]string{}}
+	code := &Req{ID: "fms/route.c", Path: "fms/route.c", Level: config.CODE}
+	sys.Children = []*Req{hlr1, hlr2}
+	hlr1.Parents, hlr1.Children = []*Req{sys}, []*Req{llr1, llr2}
+	hlr2.Parents = []*Req{sys}
+	llr1.Parents, llr1.Children = []*Req{hlr1}, []*Req{code}
+	llr2.Parents = []*Req{hlr1}
+	code.Parents = []*Req{llr1}
+	rg := reqGraph{}
+	for _, r := range []*Req{sys, hlr1, hlr2, llr1, llr2} {
+		rg[r.ID] = r
+	}
+	rg[code.Path] = code
+
+	sub, err := rg.ExtractComponent("fms")
+	assert.Nil(t, err)
+	var extracted []string
+	for k := range sub {
+		extracted = append(extracted, k)
+	}
+	assert.ElementsMatch(t, []string{"REQ-0-TEST-SYS-001", "REQ-0-TEST-SWH-001", "REQ-0-TEST-SWL-001", "fms/route.c"}, extracted)
+	assert.True(t, sub["REQ-0-TEST-SYS-001"].External)
+	assert.False(t, sub["REQ-0-TEST-SWH-001"].External)
+	assert.Equal(t, []*Req{sub["REQ-0-TEST-SWH-001"]}, sub["REQ-0-TEST-SYS-001"].Children)
+	assert.Equal(t, []*Req{sub["REQ-0-TEST-SWL-001"]}, sub["REQ-0-TEST-SWH-001"].Children)
+	assert.Equal(t, []*Req{sub["fms/route.c"]}, sub["REQ-0-TEST-SWL-001"].Children)
+	assert.Equal(t, 2, len(sys.Children), "the graph is not changed")
+	_, err = rg.ExtractComponent("nav")
+	assert.NotNil(t, err)
+
+	fileName := filepath.Join(t.TempDir(), "fms.json")
+	f, err := os.Create(fileName)
+	assert.Nil(t, err)
+	assert.Nil(t, sub.WriteComponentSnapshot(f, "fms", nil))
+	f.Close()
+	loaded, err := ReadSnapshot(fileName)
+	assert.Nil(t, err)
+	assert.Equal(t, 4, len(loaded))
+	assert.True(t, loaded["REQ-0-TEST-SYS-001"].External)
+	assert.False(t, loaded["REQ-0-TEST-SWL-001"].External)
+}
+
+func TestReqGraph_CheckStyle(t *testing.T) {
+	rg := reqGraph{
+		"REQ-0-TEST-SWH-001": &Req{ID: "REQ-0-TEST-SWH-001", Level: config.HIGH, Title: "Speed display",
+			Body: "<p>The display shall show the speed.</p>"},
+		"REQ-0-TEST-SWH-002": &Req{ID: "REQ-0-TEST-SWH-002", Level: config.HIGH, Title: "Speed and altitude display",
+			Body: "<p>The display shall show the speed and/or the altitude, and shall blink on errors.</p>"},
+	}
+	warnings, err := rg.CheckStyle(StyleConfig{})
+	assert.Nil(t, err)
+	assert.Empty(t, warnings)
+
+	warnings, err = rg.CheckStyle(StyleConfig{MaxTitleLength: 20, MaxBodyWords: 10, SingleShall: true, Forbidden: []string{"and/or", `\bTBD\b`}})
+	assert.Nil(t, err)
+	assert.Equal(t, []error{
+		fmt.Errorf("Requirement 'REQ-0-TEST-SWH-002' has a title of 26 characters, longer than 20.\n"),
+		fmt.Errorf("Requirement 'REQ-0-TEST-SWH-002' has a body of 14 words, longer than 10.\n"),
+		fmt.Errorf("Requirement 'REQ-0-TEST-SWH-002' states 2 shall, expected a single one.\n"),
+		fmt.Errorf("Requirement 'REQ-0-TEST-SWH-002' contains the forbidden phrase 'and/or'.\n"),
+	}, warnings)
+
+	_, err = rg.CheckStyle(StyleConfig{Forbidden: []string{"(TBD"}})
+	assert.Error(t, err)
+}
+
+func TestReqGraph_CheckTerminology(t *testing.T) {
+	rg := reqGraph{
+		"REQ-0-TEST-SWH-001": &Req{ID: "REQ-0-TEST-SWH-001", Level: config.HIGH, Title: "Pitot unit heating",
+			Body: "<p>The pitot\nunit shall be heated when the <b>ADS probe</b> reads a low temperature.</p>"},
+		"REQ-0-TEST-SWH-002": &Req{ID: "REQ-0-TEST-SWH-002", Level: config.HIGH, Title: "Airspeed sensor",
+			Body: "<p>The airspeed sensor shall be read at 10 Hz.</p>"},
+	}
+	assert.Empty(t, rg.CheckTerminology(nil))
+	assert.Equal(t, []error{
+		fmt.Errorf("Requirement 'REQ-0-TEST-SWH-001' uses 'ADS probe' instead of 'airspeed sensor'.\n"),
+		fmt.Errorf("Requirement 'REQ-0-TEST-SWH-001' uses 'Pitot unit' instead of 'airspeed sensor'.\n"),
+	}, rg.CheckTerminology(map[string][]string{"airspeed sensor": {"ADS probe", "pitot unit"}, "speed": {"velocity"}}))
+}
+
+func TestReqGraph_Suppress(t *testing.T) {
+	rg := reqGraph{
+		"REQ-0-TEST-SWH-001": &Req{ID: "REQ-0-TEST-SWH-001", Level: config.HIGH, Suppressions: []Suppression{
+			{Check: "missing-attribute", Arg: "rationale", Line: 3}, {Check: "owner", Line: 4}}},
+		"REQ-0-TEST-SWH-002": &Req{ID: "REQ-0-TEST-SWH-002", Level: config.HIGH},
+	}
+	findings := findingList{
+		newFinding("attribute", "Requirement 'REQ-0-TEST-SWH-001' is missing attribute 'Rationale'.\n"),
+		newFinding("attribute", "Requirement 'REQ-0-TEST-SWH-001' is missing attribute 'Verification'.\n"),
+		newFinding("owner", "Requirement 'REQ-0-TEST-SWH-001' has owner 'carol' who is not in the roster.\n"),
+		newFinding("owner", "Requirement 'REQ-0-TEST-SWH-002' has owner 'carol' who is not in the roster.\n"),
+	}
+	remaining, n := rg.Suppress(findings)
+	assert.Equal(t, 2, n)
+	assert.EqualError(t, remaining, "Requirement 'REQ-0-TEST-SWH-001' is missing attribute 'Verification'.\n"+
+		"Requirement 'REQ-0-TEST-SWH-002' has owner 'carol' who is not in the roster.\n")
+
+	remaining, n = rg.Suppress(findingList{
+		findingHeader("Problems found while parsing 0-TEST-211-SRD.md:\n"),
+		indentedFinding{newFinding("attribute", "Requirement 'REQ-0-TEST-SWH-001' is missing attribute 'Rationale'.")},
+		findingHeader("\n"),
+	})
+	assert.Equal(t, 1, n)
+	assert.Nil(t, remaining)
+	assert.Equal(t, []*Req{rg["REQ-0-TEST-SWH-001"]}, rg.SuppressedReqs())
+}
+
+func TestReq_CheckAttributesWhen(t *testing.T) {
+	as := []map[string]string{
+		{"name": "Safety Impact"},
+		{"name": "Mitigation", "when": "Safety Impact != None"},
+		{"name": "Test Procedure", "when": "Verification =~ ^[Tt]est"},
+	}
+	r := &Req{ID: "REQ-0-TEST-SWH-001", Level: config.HIGH, Attributes: map[string]string{"SAFETY IMPACT": "none"}}
+	assert.Empty(t, r.CheckAttributes(as))
+	r.Attributes["SAFETY IMPACT"] = "Major"
+	assert.Equal(t, []error{newFinding("attribute", "Requirement 'REQ-0-TEST-SWH-001' is missing attribute 'Mitigation', mandatory when Safety Impact != None (SAFETY IMPACT is 'Major').\n")},
+		r.CheckAttributes(as))
+	r.Attributes["MITIGATION"] = "Monitor"
+	r.Attributes["VERIFICATION"] = "Review"
+	assert.Empty(t, r.CheckAttributes(as))
+	r.Attributes["VERIFICATION"] = "Test"
+	assert.Len(t, r.CheckAttributes(as), 1)
+
+	for _, c := range []string{"Safety Impact", "!= None", "Verification =~ (", ""} {
+		_, err := parseAttributeCondition(c)
+		assert.Error(t, err, c)
+	}
+}
+
+func TestReqGraph_InheritAttributes(t *testing.T) {
+	sys := &Req{ID: "REQ-0-TEST-SYS-001", Level: config.SYSTEM, Path: "/certdocs/0-TEST-100-ORD.md",
+		Attributes: map[string]string{"DAL": "B", "ALLOCATION": "Software"}}
+	hlr := &Req{ID: "REQ-0-TEST-SWH-001", Level: config.HIGH, Path: "/certdocs/0-TEST-211-SRD.md", Parents: []*Req{sys},
+		Attributes: map[string]string{"ALLOCATION": "Hardware"}}
+	llr := &Req{ID: "REQ-0-TEST-SWL-001", Level: config.LOW, Path: "/certdocs/0-TEST-212-SDD.md", Parents: []*Req{hlr},
+		Attributes: map[string]string{}}
+	sys.Children = []*Req{hlr}
+	hlr.Children = []*Req{llr}
+	rg := reqGraph{sys.ID: sys, hlr.ID: hlr, llr.ID: llr}
+
+	as := []map[string]string{{"name": "DAL", "value": "[A-E]", "inherit": "true"}, {"name": "Allocation", "inherit": "true"}, {"name": "Rationale", "optional": "true"}}
+	rg.InheritAttributes(nil, as)
+	assert.Nil(t, sys.Inherited)
+	assert.Equal(t, map[string]string{"DAL": "B"}, hlr.Inherited)
+	assert.Equal(t, map[string]string{"DAL": "B", "ALLOCATION": "Hardware"}, llr.Inherited)
+	assert.Empty(t, rg.CheckAttributes(as))
+
+	hlr.Inherited, llr.Inherited = nil, nil
+	rg.InheritAttributes(nil, []map[string]string{{"name": "DAL", "value": "[A-E]"}})
+	assert.Nil(t, llr.Inherited)
+	assert.Len(t, rg.CheckAttributes(as), 3)
+
+	rg.InheritAttributes(nil, as)
+	var b bytes.Buffer
+	assert.Nil(t, rg.ReportDown(&b))
+	assert.Contains(t, b.String(), `<strong>DAL</strong>: B <span class="label label-default">inherited</span>`)
+}
+
+func TestReqGraph_Query(t *testing.T) {
+	rg := reqGraph{
+		"REQ-0-TEST-SYS-001": &Req{ID: "REQ-0-TEST-SYS-001", Level: config.SYSTEM, Title: "Navigation"},
+		"REQ-0-TEST-SWH-001": &Req{ID: "REQ-0-TEST-SWH-001", Level: config.HIGH, ParentIds: []string{"REQ-0-TEST-SYS-001"}, Title: "Speed, altitude"},
+		"REQ-0-TEST-SWL-001": &Req{ID: "REQ-0-TEST-SWL-001", Level: config.LOW, ParentIds: []string{"REQ-0-TEST-SWH-001"}, Title: "Speed",
+			Attributes: map[string]string{"VERIFICATION": "Test"}},
+		"REQ-0-TEST-SWL-002": &Req{ID: "REQ-0-TEST-SWL-002", Level: config.LOW, ParentIds: []string{"REQ-0-TEST-SWH-001"}, Title: "Altitude",
+			Attributes: map[string]string{"VERIFICATION": "Review"}},
+		"/a.go": &Req{ID: "a.go", Path: "/a.go", Level: config.CODE, ParentIds: []string{"REQ-0-TEST-SWL-001"}},
+	}
+	assert.Nil(t, rg.Resolve())
+	query := func(q string) []string {
+		reqs, err := rg.Query(q)
+		assert.Nil(t, err, q)
+		var ids []string
+		for _, r := range reqs {
+			ids = append(ids, r.ID)
+		}
+		return ids
+	}
+	assert.Equal(t, []string{"REQ-0-TEST-SWL-002"}, query("descendants(REQ-0-TEST-SYS-001) & level(SWL) & !hasCodeRef()"))
+	assert.Equal(t, []string{"REQ-0-TEST-SWH-001", "REQ-0-TEST-SWL-001", "REQ-0-TEST-SWL-002", "a.go"}, query("descendants(REQ-0-TEST-SYS-001)"))
+	assert.Equal(t, []string{"REQ-0-TEST-SWL-001", "REQ-0-TEST-SWL-002"}, query("children(REQ-0-TEST-SWH-001)"))
+	assert.Equal(t, []string{"REQ-0-TEST-SWH-001", "REQ-0-TEST-SYS-001"}, query("ancestors(REQ-0-TEST-SWL-002)"))
+	assert.Equal(t, []string{"REQ-0-TEST-SWL-001", "a.go"}, query(`attr(Verification, "^Test$") | level(code)`))
+	assert.Equal(t, []string{"REQ-0-TEST-SWH-001"}, query(`title("d, a") & (hasParents() & hasChildren())`))
+	assert.Equal(t, []string{"REQ-0-TEST-SYS-001"}, query("!hasParents() & !deleted()"))
+
+	var b bytes.Buffer
+	reqs, err := rg.Query("level(SWL)")
+	assert.Nil(t, err)
+	assert.Nil(t, WriteView(&b, reqs, "md", "lows", "The LLRs."))
+	assert.Equal(t, "# lows\n\nThe LLRs.\n\n| Requirement | Title | Document | Status |\n|---|---|---|---|\n"+
+		"| REQ-0-TEST-SWL-001 | Speed |  | COMPLETED |\n| REQ-0-TEST-SWL-002 | Altitude |  | NOT STARTED |\n", b.String())
+	b.Reset()
+	assert.Nil(t, WriteView(&b, reqs, "json", "", ""))
+	assert.Contains(t, b.String(), `"ID": "REQ-0-TEST-SWL-002",`)
+
+	for q, msg := range map[string]string{
+		"level(SWL) &":                  "invalid query at position 13: unexpected end",
+		"level(SWL) level(SWH)":         `invalid query at position 12: unexpected "level(SWH)"`,
+		"levels(SWL)":                   `invalid query at position 1: unknown function "levels"`,
+		"level()":                       "invalid query at position 8: expected level(TYPE, ...)",
+		"descendants(REQ-0-TEST-SYS-9)": "invalid query at position 30: unknown requirement REQ-0-TEST-SYS-9",
+		"title(()":                      `invalid query at position 9: invalid regular expression "(": error parsing regexp: missing closing ): ` + "`(`",
+		"(level(SWL)":                   "invalid query at position 12: missing )",
+	} {
+		_, err := rg.Query(q)
+		assert.EqualError(t, err, msg, q)
+	}
+}
+
+func TestReqGraph_Similarities(t *testing.T) {
+	rg := reqGraph{
+		"REQ-0-TEST-SWH-001": &Req{ID: "REQ-0-TEST-SWH-001", Level: config.HIGH, Title: "Speed display",
+			Body: "<p>The display shall show the speed of the aircraft in knots.</p>"},
+		"REQ-0-TEST-SWL-001": &Req{ID: "REQ-0-TEST-SWL-001", Level: config.LOW, ParentIds: []string{"REQ-0-TEST-SWH-001"}, Title: "Speed display",
+			Body: "<p>The display shall show the speed of the aircraft in knots.</p>"},
+		"REQ-0-TEST-SWL-002": &Req{ID: "REQ-0-TEST-SWL-002", Level: config.LOW, ParentIds: []string{"REQ-0-TEST-SWH-001"}, Title: "Speed display",
+			Body: "<p>The display <b>shall</b> show the speed of the aircraft, in km/h.</p>"},
+		"REQ-0-TEST-SWL-003": &Req{ID: "REQ-0-TEST-SWL-003", Level: config.LOW, ParentIds: []string{"REQ-0-TEST-SWH-001"}, Title: "Logging",
+			Body: "<p>The errors shall be logged.</p>"},
+		"REQ-0-TEST-SWL-004": &Req{ID: "REQ-0-TEST-SWL-004", Level: config.LOW, ParentIds: []string{"REQ-0-TEST-SWH-001"}, Title: "DELETED Speed display",
+			Body: "<p>The display shall show the speed of the aircraft in knots.</p>"},
+	}
+	rg.Resolve()
+	similar := rg.Similarities(0.7)
+	if assert.Len(t, similar, 3) {
+		assert.Equal(t, "REQ-0-TEST-SWH-001", similar[0].Req.ID)
+		assert.Equal(t, "REQ-0-TEST-SWL-001", similar[0].Other.ID)
+		assert.InDelta(t, 1, similar[0].Score, 1e-9)
+		assert.True(t, similar[0].Linked())
+		assert.Equal(t, "REQ-0-TEST-SWL-002", similar[2].Other.ID)
+		assert.False(t, similar[2].Linked())
+	}
+	assert.Empty(t, rg.Similarities(1.01))
+	assert.Equal(t, 0.0, cosine(shingles(""), shingles("Speed")))
+}
+
+func TestReqGraph_Suggest(t *testing.T) {
+	rg := reqGraph{
+		"REQ-0-TEST-SWH-001": &Req{ID: "REQ-0-TEST-SWH-001", Level: config.HIGH, Title: "Speed display", Body: "<p>The speed shall be displayed.</p>"},
+		"REQ-0-TEST-SWL-001": &Req{ID: "REQ-0-TEST-SWL-001", Level: config.LOW, ParentIds: []string{"REQ-0-TEST-SWH-001"}, Title: "Speed unit"},
+	}
+	rg.Resolve()
+	_, err := rg.Suggest(SuggestConfig{}, "REQ-0-TEST-SWH-001")
+	assert.EqualError(t, err, `No suggestion endpoint configured, see suggest in "reqtraq help config"`)
+
+	var got SuggestRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		assert.Nil(t, json.NewDecoder(r.Body).Decode(&got))
+		fmt.Fprint(w, `{"requirements": [{"title": "Speed refresh", "body": "The speed shall be refreshed at 10 Hz.", "attributes": {"Rationale": "R", "Parents": "X"}}]}`)
+	}))
+	defer server.Close()
+	_, err = rg.Suggest(SuggestConfig{URL: server.URL}, "REQ-0-TEST-SWH-001")
+	assert.EqualError(t, err, "The suggestion endpoint returned 401 Unauthorized: unauthorized")
+	drafts, err := rg.Suggest(SuggestConfig{URL: server.URL, Token: "secret"}, "REQ-0-TEST-SWH-001")
+	assert.Nil(t, err)
+	assert.Equal(t, "Speed display", got.Parent.Title)
+	assert.Equal(t, []suggestReq{{ID: "REQ-0-TEST-SWL-001", Title: "Speed unit"}}, got.Children)
+	var b bytes.Buffer
+	WriteDrafts(&b, rg["REQ-0-TEST-SWH-001"], drafts)
+	assert.Equal(t, "### DRAFT-1 Speed refresh\n\nThe speed shall be refreshed at 10 Hz.\n\n###### Attributes:\n- Parents: REQ-0-TEST-SWH-001\n- Rationale: R\n\n", b.String())
+
+	rg["REQ-0-TEST-SWH-001"].Attributes = map[string]string{"TAGS": "export-controlled"}
+	_, err = rg.Suggest(SuggestConfig{URL: server.URL, Token: "secret", ExportControlled: true}, "REQ-0-TEST-SWH-001")
+	assert.Nil(t, err)
+	assert.Equal(t, "<p>The speed shall be displayed.</p>", got.Parent.Body)
+	_, err = rg.Suggest(SuggestConfig{URL: server.URL, Token: "secret"}, "REQ-0-TEST-SWH-001")
+	assert.Nil(t, err)
+	assert.Equal(t, "<p><em>Omitted, export-controlled.</em></p>", got.Parent.Body)
+
+	*fOffline = true
+	defer func() { *fOffline = false }()
+	_, err = rg.Suggest(SuggestConfig{URL: server.URL, Token: "secret"}, "REQ-0-TEST-SWH-001")
+	assert.EqualError(t, err, "Offline, nothing is sent to the suggestion endpoint")
+}
+
+func TestReqGraph_Hash(t *testing.T) {
+	rg, problems := CreateReqGraph("/testdata/TestPreCommitCheckReqReferences", "/testdata/TestPreCommitCheckReqReferences")
+	assert.Nil(t, problems)
+	hash, err := rg.Hash()
+	assert.Nil(t, err)
+	assert.Regexp(t, reHash, hash)
+	for _, r := range rg {
+		for i, j := 0, len(r.Children)-1; i < j; i, j = i+1, j-1 {
+			r.Children[i], r.Children[j] = r.Children[j], r.Children[i]
+		}
+	}
+	reordered, err := rg.Hash()
+	assert.Nil(t, err)
+	assert.Equal(t, hash, reordered, "the order of the links does not matter")
+
+	dir := t.TempDir()
+	snapshot := filepath.Join(dir, "snapshot.json")
+	f, err := os.Create(snapshot)
+	assert.Nil(t, err)
+	assert.Nil(t, rg.WriteSnapshot(f, nil))
+	f.Close()
+	base, err := baselineHash(snapshot)
+	assert.Nil(t, err)
+	assert.Equal(t, hash, base, "the graph read from a snapshot has the same hash")
+
+	m, err := rg.NewManifest("1a2b3c4d", "v1.0")
+	assert.Nil(t, err)
+	manifest := filepath.Join(dir, "manifest.json")
+	f, err = os.Create(manifest)
+	assert.Nil(t, err)
+	assert.Nil(t, m.Write(f))
+	f.Close()
+	base, err = baselineHash(manifest)
+	assert.Nil(t, err)
+	assert.Equal(t, hash, base)
+
+	base, err = baselineHash(hash)
+	assert.Nil(t, err)
+	assert.Equal(t, hash, base)
+}
+
+func TestReqGraph_BOM(t *testing.T) {
+	doc := &Document{ID: "0-TEST-100-SRD", Path: "certdocs/0-TEST-100-SRD.md", Revision: "2"}
+	hlr := &Req{ID: "REQ-0-TEST-SWH-001", Level: config.HIGH, Title: "Speed", Path: doc.Path, Document: doc, Status: COMPLETED}
+	llr := &Req{ID: "REQ-0-TEST-SWL-001", Level: config.LOW, Title: "Speed computation", Path: doc.Path, Document: doc, Parents: []*Req{hlr}}
+	code := &Req{ID: "nav/speed.cc", Level: config.CODE, Path: "/repo/nav/speed.cc", FileHash: "\x01\xab", Parents: []*Req{llr}}
+	hlr.Children = []*Req{llr}
+	llr.Children = []*Req{code}
+	rg := reqGraph{hlr.ID: hlr, llr.ID: llr, code.Path: code}
+
+	bom, err := rg.BOM("nav", time.Date(2024, 1, 31, 12, 0, 0, 0, time.UTC))
+	if !assert.Nil(t, err) {
+		return
+	}
+	hash, err := rg.Hash()
+	assert.Nil(t, err)
+	assert.Equal(t, "https://spdx.org/spdxdocs/nav-requirements-"+hash, bom.DocumentNamespace)
+	assert.Equal(t, "2024-01-31T12:00:00Z", bom.CreationInfo.Created)
+	assert.Equal(t, []BOMFile{{SPDXID: "SPDXRef-File-nav-speed.cc", FileName: "./nav/speed.cc",
+		Checksums: []bomChecksum{{Algorithm: "SHA1", ChecksumValue: "01ab"}}}}, bom.Files)
+	assert.Equal(t, []BOMRequirement{
+		{SPDXID: "SPDXRef-Req-REQ-0-TEST-SWH-001", ID: hlr.ID, Title: "Speed", Document: doc.Path, DocumentRevision: "2", Status: "COMPLETED"},
+		{SPDXID: "SPDXRef-Req-REQ-0-TEST-SWL-001", ID: llr.ID, Title: "Speed computation", Document: doc.Path, DocumentRevision: "2",
+			Status: "NOT STARTED", ImplementedBy: []string{"SPDXRef-File-nav-speed.cc"}},
+	}, bom.Requirements)
+
+	var b bytes.Buffer
+	assert.Nil(t, bom.Write(&b))
+	assert.Contains(t, b.String(), `"spdxVersion": "SPDX-2.3"`)
+}
+
+func TestReqGraph_Manifest(t *testing.T) {
+	rg, err := CreateReqGraph("/testdata/TestPreCommitCheckReqReferences", "/testdata/TestPreCommitCheckReqReferences")
+	assert.Nil(t, err)
+	rg["REQ-0-TEST-SYS-001"].Status = COMPLETED
+	hash, err := rg.Hash()
+	assert.Nil(t, err)
+	rg2, err := CreateReqGraph("/testdata/TestPreCommitCheckReqReferences", "/testdata/TestPreCommitCheckReqReferences")
+	assert.Nil(t, err)
+	rg2["REQ-0-TEST-SYS-001"].Status = COMPLETED
+	hash2, err := rg2.Hash()
+	assert.Nil(t, err)
+	assert.Equal(t, hash, hash2)
+	rg2["REQ-0-TEST-SWH-001"].Title += " changed"
+	hash2, err = rg2.Hash()
+	assert.Nil(t, err)
+	assert.NotEqual(t, hash, hash2)
+
+	rg["REQ-0-TEST-SWH-001"].Coverage = &Coverage{Statements: 4, CoveredStatements: 3, Branches: 2, CoveredBranches: 1}
+	m, err := rg.NewManifest("1a2b3c4d", "v1.0")
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Equal(t, hash, m.GraphHash)
+	assert.Equal(t, &Coverage{Statements: 4, CoveredStatements: 3, Branches: 2, CoveredBranches: 1}, m.TotalCoverage)
+	satisfied := []string{}
+	for _, r := range rg {
+		if r.Level != config.CODE && r.Status == COMPLETED {
+			satisfied = append(satisfied, r.ID)
+		}
+	}
+	sort.Strings(satisfied)
+	assert.Equal(t, satisfied, m.Satisfied)
+	assert.Contains(t, m.Satisfied, "REQ-0-TEST-SYS-001")
+
+	_, private, err := ed25519.GenerateKey(nil)
+	assert.Nil(t, err)
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	assert.Nil(t, err)
+	keyFile := filepath.Join(t.TempDir(), "key.pem")
+	assert.Nil(t, ioutil.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0600))
+	key, err := ReadSigningKey(keyFile)
+	assert.Nil(t, err)
+	public, err := ReadVerifyingKey(keyFile)
+	assert.Nil(t, err)
+
+	assert.NotNil(t, m.Verify(public))
+	assert.Nil(t, m.Sign(key))
+	fileName := filepath.Join(t.TempDir(), "manifest.json")
+	f, err := os.Create(fileName)
+	assert.Nil(t, err)
+	assert.Nil(t, m.Write(f))
+	f.Close()
+	loaded, err := ReadManifest(fileName)
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Equal(t, m, loaded)
+	assert.Nil(t, loaded.Verify(public))
+	loaded.Satisfied = append(loaded.Satisfied, "REQ-0-TEST-SWL-999")
+	assert.NotNil(t, loaded.Verify(public))
+}
+
+func TestCreateReqGraph_MultipleRoots(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, contents string) {
+		fileName := filepath.Join(dir, filepath.FromSlash(name))
+		assert.Nil(t, os.MkdirAll(filepath.Dir(fileName), 0755))
+		assert.Nil(t, ioutil.WriteFile(fileName, []byte(contents), 0644))
+	}
+	write("docs/requirements/0-TEST-100-ORD.md", "# ORD\n\n### REQ-0-TEST-SYS-001 Speed\n\nThe speed shall be known.\n\n###### Attributes:\n- Rationale: R\n")
+	write("gps/docs/0-TEST-211-SRD.md", "# SRD\n\n### REQ-0-TEST-SWH-001 Display\n\nThe speed shall be displayed.\n\n"+
+		"###### Attributes:\n- Parents: REQ-0-TEST-SYS-001\n- Rationale: R\n")
+	write("other/0-TEST-212-SDD.md", "# SDD\n\n### REQ-0-TEST-SWL-001 Ignored\n\nNot in a root.\n\n###### Attributes:\n- Parents: REQ-0-TEST-SWH-001\n- Rationale: R\n")
+
+	assert.Equal(t, []string{"docs/requirements", "gps/docs"}, certdocRoots("docs/requirements, gps/docs,"))
+	assert.Equal(t, []string{""}, certdocRoots(""))
+
+	rg, err := createReqGraph(dir, "docs/requirements,gps/docs", "code")
+	assert.Nil(t, err)
+	assert.Len(t, rg, 2)
+	if r := rg["REQ-0-TEST-SWH-001"]; assert.NotNil(t, r) && assert.Len(t, r.Parents, 1) {
+		assert.Equal(t, "REQ-0-TEST-SYS-001", r.Parents[0].ID)
+	}
+	assert.Equal(t, []string{"gps/docs/0-TEST-211-SRD.md"},
+		changedCertdocs([]string{"gps/docs/0-TEST-211-SRD.md", "other/0-TEST-212-SDD.md"}, "docs/requirements,gps/docs"))
+}
+
+func TestCreateReqGraph_Generated(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, contents string) {
+		fileName := filepath.Join(dir, filepath.FromSlash(name))
+		assert.Nil(t, os.MkdirAll(filepath.Dir(fileName), 0755))
+		assert.Nil(t, ioutil.WriteFile(fileName, []byte(contents), 0644))
+	}
+	ord := func(id string) string {
+		return "# ORD\n\n### " + id + " Speed\n\nThe speed shall be known.\n\n###### Attributes:\n- Rationale: R\n"
+	}
+	write("certdocs/0-TEST-100-ORD.md", ord("REQ-0-TEST-SYS-001"))
+	write("certdocs/out/0-TEST-100-ORD.md", ord("REQ-0-TEST-SYS-001")+"\n"+strings.TrimPrefix(ord("REQ-0-TEST-SYS-002"), "# ORD\n"))
+	write("certdocs/linked/0-TEST-100-ORD.md", "<!-- "+generatedMarker+" from 0-TEST-100-ORD.md -->\n"+ord("REQ-0-TEST-SYS-001")+
+		"\n"+strings.TrimPrefix(ord("REQ-0-TEST-SYS-002"), "# ORD\n")+"\n"+strings.TrimPrefix(ord("REQ-0-TEST-SYS-003"), "# ORD\n"))
+
+	defer func(c *RepoConfig) { repoConfig = c }(repoConfig)
+	repoConfig = &RepoConfig{Generated: []string{"certdocs/out/*"}}
+	rg, err := createReqGraph(dir, "certdocs", "code")
+	assert.Nil(t, err)
+	assert.Len(t, rg, 1)
+	assert.NotNil(t, rg["REQ-0-TEST-SYS-001"])
+
+	repoConfig = &RepoConfig{Generated: []string{"*-linked.lyx"}}
+	assert.True(t, isGeneratedName("/certdocs/0-TEST-100-ORD-linked.lyx"))
+	assert.False(t, isGeneratedName("certdocs/0-TEST-100-ORD.lyx"))
+	rg, err = createReqGraph(dir, "certdocs", "code")
+	assert.Nil(t, err)
+	assert.Len(t, rg, 2)
+	assert.Nil(t, rg["REQ-0-TEST-SYS-003"])
+}
+
+func TestCreateReqGraphAt(t *testing.T) {
+	const dir = "/testdata/TestPreCommitCheckReqReferences"
+	rg, err := CreateReqGraph(dir, dir)
+	assert.Nil(t, err)
+	at, err := CreateReqGraphAt("HEAD", dir, dir)
+	assert.Nil(t, err)
+	assert.Equal(t, len(rg), len(at))
+	for k, r := range rg {
+		a := at[k]
+		if assert.NotNil(t, a, k) {
+			assert.Equal(t, r.Path, a.Path)
+			assert.Equal(t, r.FileHash, a.FileHash)
+			assert.Equal(t, r.Body, a.Body)
+			assert.Equal(t, len(r.Children), len(a.Children))
+			if r.Document != nil && assert.NotNil(t, a.Document, k) {
+				assert.Equal(t, *r.Document, *a.Document)
+			}
+		}
+	}
+
+	_, err = CreateReqGraphAt("no-such-commit", dir, dir)
+	assert.NotNil(t, err)
+}
+
+func TestNewChangelog(t *testing.T) {
+	prg := reqGraph{
+		"REQ-0-TEST-SWH-001": &Req{ID: "REQ-0-TEST-SWH-001", Level: config.HIGH, Title: "Kept"},
+		"REQ-0-TEST-SWH-002": &Req{ID: "REQ-0-TEST-SWH-002", Level: config.HIGH, Title: "Changed",
+			ParentIds: []string{"REQ-0-TEST-SYS-001"}, Attributes: map[string]string{"RATIONALE": "Old"}},
+		"REQ-0-TEST-SWH-003": &Req{ID: "REQ-0-TEST-SWH-003", Level: config.HIGH, Title: "Removed"},
+	}
+	rg := reqGraph{
+		"REQ-0-TEST-SWH-001": &Req{ID: "REQ-0-TEST-SWH-001", Level: config.HIGH, Title: "Kept"},
+		"REQ-0-TEST-SWH-002": &Req{ID: "REQ-0-TEST-SWH-002", Level: config.HIGH, Title: "Changed",
+			ParentIds: []string{"REQ-0-TEST-SYS-002"}, Attributes: map[string]string{"RATIONALE": "New"}},
+		"REQ-0-TEST-SWH-004": &Req{ID: "REQ-0-TEST-SWH-004", Level: config.HIGH, Title: "Added"},
+		"/repo/a.go":         &Req{ID: "a.go", Level: config.CODE, ParentIds: []string{"REQ-0-TEST-SWH-004"}},
+	}
+	c := NewChangelog(rg, prg, "v1", "v2")
+	assert.Equal(t, []ReqChange{{ID: "REQ-0-TEST-SWH-004", Title: "Added"}}, c.Added)
+	assert.Equal(t, []ReqChange{{ID: "REQ-0-TEST-SWH-003", Title: "Removed"}}, c.Removed)
+	assert.Equal(t, []ReqChange{{ID: "REQ-0-TEST-SWH-002", Title: "Changed", Changes: []string{
+		`Changed "RATIONALE" from "old" to "new"`,
+		`Removed parent "REQ-0-TEST-SYS-001"`,
+		`Added parent "REQ-0-TEST-SYS-002"`,
+	}}}, c.Modified)
+	assert.Equal(t, []ReqChange{{ID: "a.go", Changes: []string{"ADDED"}}}, c.Code)
+
+	assert.True(t, NewChangelog(rg, rg, "v2", "v2").Empty())
+}
+
+func TestChangelog_AppendChangeLog(t *testing.T) {
+	prg := reqGraph{
+		"REQ-0-TEST-SWH-001": &Req{ID: "REQ-0-TEST-SWH-001", Level: config.HIGH, Title: "Pipe | title", Path: "/certdocs/0-TEST-211-SRD.md", Body: "Old"},
+		"REQ-0-TEST-SWH-002": &Req{ID: "REQ-0-TEST-SWH-002", Level: config.HIGH, Title: "Removed", Path: "/certdocs/0-TEST-211-SRD.md"},
+		"REQ-0-TEST-SYS-001": &Req{ID: "REQ-0-TEST-SYS-001", Level: config.SYSTEM, Title: "Other", Path: "/certdocs/0-TEST-100-ORD.md"},
+	}
+	rg := reqGraph{
+		"REQ-0-TEST-SWH-001": &Req{ID: "REQ-0-TEST-SWH-001", Level: config.HIGH, Title: "Pipe | title", Path: "/certdocs/0-TEST-211-SRD.md", Body: "New"},
+		"REQ-0-TEST-SWH-003": &Req{ID: "REQ-0-TEST-SWH-003", Level: config.HIGH, Title: "Added", Path: "/certdocs/0-TEST-211-SRD.md"},
+		"/repo/a.go":         &Req{ID: "a.go", Level: config.CODE, Path: "/repo/a.go"},
+	}
+	doc := "/certdocs/0-TEST-211-SRD.md"
+	c := NewChangelog(rg.documentGraph(doc), prg.documentGraph(doc), "v1", "working tree")
+	assert.Empty(t, c.Code)
+
+	md := appendChangeLog([]byte("# SRD\n\n"), "md", c)
+	assert.Equal(t, "# SRD\n\n## Requirement Change Log\n\nChanges of the requirements from v1 to working tree.\n\n"+
+		"| ID | Title | Change |\n|----|-------|--------|\n"+
+		"| REQ-0-TEST-SWH-001 | Pipe \\| title | Body changed |\n"+
+		"| REQ-0-TEST-SWH-002 | Removed | Removed |\n"+
+		"| REQ-0-TEST-SWH-003 | Added | Added |\n", string(md))
+
+	lyx := string(appendChangeLog([]byte("\\begin_body\n\\end_body\n\\end_document\n"), "lyx", c))
+	assert.True(t, strings.HasPrefix(lyx, "\\begin_body\n\\begin_layout Section\nRequirement Change Log\n\\end_layout\n"))
+	assert.True(t, strings.HasSuffix(lyx, "\\end_layout\n\n\\end_body\n\\end_document\n"))
+	assert.Contains(t, lyx, `<lyxtabular version="3" rows="4" columns="3">`)
+	assert.Contains(t, lyx, "\\begin_layout Plain Layout\nREQ-0-TEST-SWH-003\n\\end_layout\n")
+
+	md = appendChangeLog([]byte("# SRD\n"), "md", NewChangelog(rg.documentGraph(doc), rg.documentGraph(doc), "v2", "working tree"))
+	assert.True(t, strings.HasSuffix(string(md), "\n\nNo changes.\n"))
+}
+
+func TestPackage_Write(t *testing.T) {
+	prg := reqGraph{
+		"REQ-0-TEST-SYS-001": &Req{ID: "REQ-0-TEST-SYS-001", Level: config.SYSTEM, Path: "/certdocs/0-TEST-100-ORD.md", Title: "Kept"},
+		"REQ-0-TEST-SWH-001": &Req{ID: "REQ-0-TEST-SWH-001", Level: config.HIGH, Path: "/certdocs/0-TEST-211-SRD.md", Title: "Speed"},
+	}
+	rg := reqGraph{
+		"REQ-0-TEST-SYS-001": &Req{ID: "REQ-0-TEST-SYS-001", Level: config.SYSTEM, Path: "/certdocs/0-TEST-100-ORD.md", Title: "Kept"},
+		"REQ-0-TEST-SWH-001": &Req{ID: "REQ-0-TEST-SWH-001", Level: config.HIGH, Path: "/certdocs/0-TEST-211-SRD.md", Title: "Speed in knots"},
+		"REQ-0-TEST-SWH-002": &Req{ID: "REQ-0-TEST-SWH-002", Level: config.HIGH, Path: "/certdocs/0-TEST-211-SRD.md", Title: "Altitude"},
+	}
+	docs := rg.ChangedDocuments(prg)
+	assert.Equal(t, []string{"certdocs/0-TEST-211-SRD.md"}, docs)
+
+	m, err := rg.NewManifest("1a2b3c", "SOI3")
+	assert.Nil(t, err)
+	p := &Package{Changelog: NewChangelog(rg, prg, "SOI2", "SOI3"), Graph: rg,
+		Documents: map[string][]byte{docs[0]: []byte("# SRD\n")}, Manifest: m}
+	var b bytes.Buffer
+	assert.Nil(t, p.Write(&b))
+	z, err := zip.NewReader(bytes.NewReader(b.Bytes()), int64(b.Len()))
+	if !assert.Nil(t, err) {
+		return
+	}
+	var names []string
+	for _, f := range z.File {
+		names = append(names, f.Name)
+	}
+	assert.Equal(t, []string{"changes.md", "changes.json", "trace-down.html", "trace-up.html",
+		"documents/certdocs/0-TEST-211-SRD.md", "manifest.json"}, names)
+	f, err := z.File[0].Open()
+	assert.Nil(t, err)
+	changes, _ := ioutil.ReadAll(f)
+	assert.Contains(t, string(changes), "REQ-0-TEST-SWH-002")
+}
+
+func TestReqGraph_ReportLang(t *testing.T) {
+	r, err := ParseReq("REQ-0-TEST-SYS-001 Units\n\nThe speed shall be shown in km/h.\n\n###### Attributes:\n" +
+		"- Rationale: Pilots.\n- Body (de): Die Geschwindigkeit muss in km/h angezeigt werden.\n")
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Equal(t, "Die Geschwindigkeit muss in km/h angezeigt werden.", r.Translation("de"))
+	assert.Equal(t, "", r.Translation("fr"))
+	r.Level = config.SYSTEM
+	rg := reqGraph{r.ID: r}
+
+	var b bytes.Buffer
+	assert.Nil(t, rg.ReportDown(&b))
+	assert.Contains(t, b.String(), "Top Down Tracing")
+	assert.NotContains(t, b.String(), `lang="de"><p>`)
+
+	*fLang = "de"
+	defer func() { *fLang = "en" }()
+	b.Reset()
+	assert.Nil(t, rg.ReportDown(&b))
+	assert.Contains(t, b.String(), "Top-down-Rückverfolgung")
+	assert.Contains(t, b.String(), `<div class="col-md-6" lang="de"><p>Die Geschwindigkeit muss in km/h angezeigt werden.</p></div>`)
+	assert.NotContains(t, b.String(), "BODY (DE)")
+
+	assert.Nil(t, checkLang("de"))
+	assert.EqualError(t, checkLang("fr"), `Unknown language "fr", expected one of de, en`)
+}
+
+func TestReqGraph_Placeholders(t *testing.T) {
+	r, err := ParseReq("REQ-0-TEST-SYS-001 {component} status\n\nThe {component} shall report its status on {interface}.\n\n" +
+		"###### Attributes:\n- Rationale: Monitoring.\n- Param (component): Left <wing>\n- Param (interface): CAN 1\n")
+	if !assert.Nil(t, err) {
+		return
+	}
+	r.Level = config.SYSTEM
+	assert.Equal(t, "Left <wing> status", r.Expand(r.Title))
+	assert.Equal(t, "The Left &lt;wing&gt; shall report its status on CAN 1.", strings.TrimSpace(string(r.ExpandedBody())))
+	noParams := &Req{ID: "REQ-0-TEST-SYS-002", Level: config.SYSTEM, Title: "Format {x}", Body: "Use {x}.", Attributes: map[string]string{}}
+	assert.Equal(t, "Format {x}", noParams.Expand(noParams.Title))
+	rg := reqGraph{r.ID: r, noParams.ID: noParams}
+	assert.Empty(t, rg.CheckPlaceholders())
+
+	var b bytes.Buffer
+	assert.Nil(t, rg.ReportDown(&b))
+	assert.Contains(t, b.String(), "REQ-0-TEST-SYS-001 Left &lt;wing&gt; status")
+	assert.Contains(t, b.String(), "The Left &lt;wing&gt; shall report its status on CAN 1.</p>")
+	assert.NotContains(t, b.String(), "PARAM (")
+
+	delete(r.Attributes, "PARAM (INTERFACE)")
+	errs := rg.CheckPlaceholders()
+	assert.Equal(t, []error{
+		newFinding("placeholder", "Requirement 'REQ-0-TEST-SYS-001' has placeholder '{interface}' without value. Expected attribute 'Param (interface)'.\n"),
+	}, errs)
+	assert.Equal(t, 1, Summarize("precommit", exitFindings, errs[0]).Counts["placeholder"])
+}
+
+func TestReq_Instances(t *testing.T) {
+	f := filepath.Join(t.TempDir(), "0-TEST-100-ORD.md")
+	err := ioutil.WriteFile(f, []byte(`# 0-TEST-100-ORD
+
+## Requirements
+
+### REQ-0-TEST-SYS-001 Sensor channel {channel}
+
+The channel {channel} shall be read at address {address}.
+
+###### Attributes:
+- Rationale: Sensors.
+- Instances: channel=1, address=0x10; channel=2, address=0x20
+
+### REQ-0-TEST-SYS-002 Display
+
+The channels shall be displayed.
+
+###### Attributes:
+- Rationale: Pilots.
+`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rg := reqGraph{}
+	assert.Empty(t, parseCertdocToGraph(f, rg))
+	var ids []string
+	for id := range rg {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	assert.Equal(t, []string{"REQ-0-TEST-SYS-001", "REQ-0-TEST-SYS-001.1", "REQ-0-TEST-SYS-001.2", "REQ-0-TEST-SYS-002"}, ids)
+	r := rg["REQ-0-TEST-SYS-001.2"]
+	assert.Equal(t, "Sensor channel 2", r.Title)
+	assert.Equal(t, "The channel 2 shall be read at address 0x20.", strings.TrimSpace(string(r.Body)))
+	assert.Equal(t, "REQ-0-TEST-SYS-001", r.Attributes[instanceOfAttribute])
+	assert.Equal(t, []string{"REQ-0-TEST-SYS-001"}, r.ParentIds)
+	assert.Equal(t, "Sensors.", r.Attributes["RATIONALE"])
+	assert.Equal(t, 0, r.Position)
+	assert.Equal(t, "Sensor channel {channel}", rg["REQ-0-TEST-SYS-001"].Title)
+	assert.Empty(t, rg.CheckPlaceholders())
+
+	generic := &Req{ID: "REQ-0-TEST-SYS-001", Attributes: map[string]string{"INSTANCES": "channel=1; channel"}}
+	_, err = generic.Instances()
+	assert.EqualError(t, err, `requirement REQ-0-TEST-SYS-001 contains malformed instance "channel", expected name=value pairs separated by commas`)
+	assert.Equal(t, 1, Summarize("precommit", exitFindings, err).Counts["malformed_requirement"])
+}
+
+func TestReq_InstancesReferences(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, contents string) string {
+		f := filepath.Join(dir, name)
+		assert.Nil(t, ioutil.WriteFile(f, []byte(contents), 0644))
+		return f
+	}
+	srd := write("0-TEST-211-SRD.md", `# 0-TEST-211-SRD
+
+## Requirements
+
+### REQ-0-TEST-SWH-001 Sensor
+
+The sensors shall be read.
+
+###### Attributes:
+- Parents: REQ-0-TEST-SYS-001
+`)
+	sdd := write("0-TEST-212-SDD.md", `# 0-TEST-212-SDD
+
+## Requirements
+
+### REQ-0-TEST-SWL-001 Sensor channel {channel}
+
+The channel {channel} shall be read.
+
+###### Attributes:
+- Parents: REQ-0-TEST-SWH-001
+- Instances: channel=1; channel=2
+
+### REQ-0-TEST-SWL-002 Sensor fusion
+
+The channels of REQ-0-TEST-SWL-001 shall be fused.
+
+###### Attributes:
+- Parents: REQ-0-TEST-SWL-001.2
+`)
+	code := write("sensor.go", "// @"+"llr REQ-0-TEST-SWL-001\nfunc read() {}\n\n// @"+"llr REQ-0-TEST-SWL-001.1\nfunc readFirst() {}\n")
+	rg := reqGraph{"REQ-0-TEST-SYS-001": &Req{ID: "REQ-0-TEST-SYS-001", Level: config.SYSTEM}}
+	assert.Empty(t, parseCertdocToGraph(srd, rg))
+	assert.Empty(t, parseCertdocToGraph(sdd, rg))
+	assert.Nil(t, parseCode("sensor.go", code, rg))
+	assert.Equal(t, []string{"REQ-0-TEST-SWL-001", "REQ-0-TEST-SWL-001.1"}, rg[code].ParentIds)
+	if !assert.Nil(t, rg.Resolve()) {
+		return
+	}
+	generic := rg["REQ-0-TEST-SWL-001"]
+	assert.Equal(t, []*Req{rg["REQ-0-TEST-SWH-001"]}, generic.Parents)
+	assert.ElementsMatch(t, []*Req{rg["REQ-0-TEST-SWL-001.1"], rg["REQ-0-TEST-SWL-001.2"], rg[code]}, generic.Children)
+	assert.Equal(t, []*Req{rg[code]}, rg["REQ-0-TEST-SWL-001.1"].Children)
+	assert.Equal(t, []*Req{rg["REQ-0-TEST-SWL-001.2"]}, rg["REQ-0-TEST-SWL-002"].Parents)
+}
+
+func TestParseCode_HWDesign(t *testing.T) {
+	dir := t.TempDir()
+	xdc := filepath.Join(dir, "top.xdc")
+	assert.Nil(t, os.WriteFile(xdc, []byte("# @"+"llr REQ-0-TEST-HWL-001\nset_property PACKAGE_PIN W5 [get_ports clk]\n"+
+		"// @"+"llr REQ-0-TEST-SWL-001\n"), 0644))
+	net := filepath.Join(dir, "board.NET")
+	assert.Nil(t, os.WriteFile(net, []byte("(comp (ref U1)\n  (fields\n    (field (name Req) \"@"+"llr REQ-0-TEST-HWL-002\")))\n"), 0644))
+	rg := reqGraph{}
+	assert.Nil(t, parseCode("top.xdc", xdc, rg))
+	assert.Nil(t, parseCode("board.NET", net, rg))
+	if assert.NotNil(t, rg[xdc]) {
+		assert.Equal(t, []string{"REQ-0-TEST-HWL-001"}, rg[xdc].ParentIds)
+		assert.Equal(t, hwDesignKind, rg[xdc].Kind)
+	}
+	if assert.NotNil(t, rg[net]) {
+		assert.Equal(t, []string{"REQ-0-TEST-HWL-002"}, rg[net].ParentIds)
+	}
+	assert.True(t, isCodeFile("fpga/top.xdc"))
+	assert.False(t, isCodeFile("fpga/top.bit"))
+
+	code := filepath.Join(dir, "a.go")
+	assert.Nil(t, os.WriteFile(code, []byte("// @"+"llr REQ-0-TEST-SWL-001\n"), 0644))
+	assert.Nil(t, parseCode("a.go", code, rg))
+	assert.Equal(t, "", rg[code].Kind)
+}
+
+func TestParseCode_TestSuites(t *testing.T) {
+	dir := t.TempDir()
+	robot := filepath.Join(dir, "navigation.robot")
+	assert.Nil(t, os.WriteFile(robot, []byte("*** Settings ***\nTest Tags    hil    REQ-0-TEST-SWH-001\n\n*** Test Cases ***\n"+
+		"Reach Waypoint\n    [Tags]    smoke    REQ-0-TEST-SWL-001    REQ-0-TEST-SWL-002\n    Fly To    A\n"+
+		"Mentions REQ-0-TEST-SWL-003\n    Log    REQ-0-TEST-SWL-003\n"), 0644))
+	py := filepath.Join(dir, "test_gps.py")
+	assert.Nil(t, os.WriteFile(py, []byte("import pytest\n\npytestmark = pytest.mark.req(\"REQ-0-TEST-SWH-001\")\n\n"+
+		"@pytest.mark.req(\"REQ-0-TEST-SWL-001\", 'REQ-0-TEST-SWL-002')\n@pytest.mark.slow\ndef test_fix():\n    pass\n"), 0644))
+	rg := reqGraph{}
+	assert.Nil(t, parseCode("navigation.robot", robot, rg))
+	assert.Nil(t, parseCode("test_gps.py", py, rg))
+	if assert.NotNil(t, rg[robot]) {
+		assert.Equal(t, []string{"REQ-0-TEST-SWH-001", "REQ-0-TEST-SWL-001", "REQ-0-TEST-SWL-002"}, rg[robot].ParentIds)
+		assert.Equal(t, []int{2, 6, 6}, rg[robot].RefLines)
+		assert.Equal(t, testKind, rg[robot].Kind)
+	}
+	if assert.NotNil(t, rg[py]) {
+		assert.Equal(t, []string{"REQ-0-TEST-SWH-001", "REQ-0-TEST-SWL-001", "REQ-0-TEST-SWL-002"}, rg[py].ParentIds)
+		assert.Equal(t, testKind, rg[py].Kind)
+	}
+	assert.True(t, isCodeFile("hil/navigation.robot"))
+	assert.True(t, isCodeFile("tests/gps_test.py"))
+	assert.False(t, isCodeFile("tools/gps.py"))
+
+	rg["REQ-0-TEST-SWL-001"] = &Req{ID: "REQ-0-TEST-SWL-001", Level: config.LOW, Children: []*Req{rg[robot]}}
+	code, tests := rg["REQ-0-TEST-SWL-001"].tracedToCode()
+	assert.False(t, code)
+	assert.True(t, tests)
+}
+
+func TestParseCode_RobotContinuation(t *testing.T) {
+	robot := filepath.Join(t.TempDir(), "navigation.robot")
+	assert.Nil(t, os.WriteFile(robot, []byte("*** Settings ***\nTest Tags    hil\n...    REQ-0-TEST-SWH-001\n"+
+		"Documentation    Reach REQ-0-TEST-SWH-002\n...    REQ-0-TEST-SWH-002\n\n*** Test Cases ***\n"+
+		"Reach Waypoint\n    [Tags]    smoke    REQ-0-TEST-SWL-001\n    ...    REQ-0-TEST-SWL-002\n    ...\n"+
+		"    ...    REQ-0-TEST-SWL-003\n    Fly To    A\n    ...    REQ-0-TEST-SWL-004\n"), 0644))
+	rg := reqGraph{}
+	assert.Nil(t, parseCode("navigation.robot", robot, rg))
+	if assert.NotNil(t, rg[robot]) {
+		assert.Equal(t, []string{"REQ-0-TEST-SWH-001", "REQ-0-TEST-SWL-001", "REQ-0-TEST-SWL-002", "REQ-0-TEST-SWL-003"}, rg[robot].ParentIds)
+		assert.Equal(t, []int{3, 9, 10, 12}, rg[robot].RefLines)
+	}
+}
+
+func TestParseCode_TestCases(t *testing.T) {
+	dir := t.TempDir()
+	cc := filepath.Join(dir, "parser_test.cc")
+	tag := "// @" + "llr "
+	assert.Nil(t, os.WriteFile(cc, []byte(tag+"REQ-0-TEST-SWL-001\n#include <gtest/gtest.h>\n\n"+
+		"// @"+"tests @llr REQ-0-TEST-SWL-002\nTEST_F(Parser, RejectsLongLines) {\n"+
+		"  EXPECT_FALSE(parse(line));  "+tag+"REQ-0-TEST-SWL-003\n}\n\n"+
+		"TEST_CASE(\"Empty \\\"lines\\\" are skipped\", \"[parser]\") {\n  // @"+"tests REQ-0-TEST-SWL-002, REQ-0-TEST-SWL-004\n"+
+		"  REQUIRE(parse(\"\"));\n}\n"), 0644))
+	rg := reqGraph{}
+	assert.Nil(t, parseCode("parser_test.cc", cc, rg))
+	if assert.NotNil(t, rg[cc]) {
+		assert.Equal(t, []string{"REQ-0-TEST-SWL-001", "REQ-0-TEST-SWL-002", "REQ-0-TEST-SWL-003", "REQ-0-TEST-SWL-002", "REQ-0-TEST-SWL-004"}, rg[cc].ParentIds)
+		assert.Equal(t, []TestCase{
+			{Name: "Parser.RejectsLongLines", Line: 5, IDs: []string{"REQ-0-TEST-SWL-001", "REQ-0-TEST-SWL-002", "REQ-0-TEST-SWL-003"}},
+			{Name: `Empty \"lines\" are skipped`, Line: 9, IDs: []string{"REQ-0-TEST-SWL-001", "REQ-0-TEST-SWL-002", "REQ-0-TEST-SWL-004"}},
+		}, rg[cc].TestCases)
+	}
+	// The @tests tags are only read in the test files.
+	code := filepath.Join(dir, "parser.cc")
+	assert.Nil(t, os.WriteFile(code, []byte("// @"+"tests REQ-0-TEST-SWL-001\nTEST(Parser, Inline) {}\n"), 0644))
+	assert.Nil(t, parseCode("parser.cc", code, rg))
+	if assert.NotNil(t, rg[code], "mentioning REQ-0-TEST-SWL-001") {
+		assert.Empty(t, rg[code].ParentIds)
+		assert.Empty(t, rg[code].TestCases)
+	}
+
+	for _, id := range []string{"REQ-0-TEST-SWL-001", "REQ-0-TEST-SWL-002", "REQ-0-TEST-SWL-003", "REQ-0-TEST-SWL-004"} {
+		rg[id] = &Req{ID: id, Level: config.LOW, Title: "Title " + id[len(id)-1:], Children: []*Req{rg[cc]}}
+	}
+	rg["REQ-0-TEST-SWL-005"] = &Req{ID: "REQ-0-TEST-SWL-005", Level: config.LOW, Title: "DELETED"}
+	rg["REQ-0-TEST-SWL-006"] = &Req{ID: "REQ-0-TEST-SWL-006", Level: config.LOW, Title: "Title 6"}
+	matrix := rg.TestCaseMatrix()
+	var b bytes.Buffer
+	WriteTestCaseMatrixMarkdown(&b, matrix)
+	assert.Equal(t, `# Test cases
+
+| Requirement | Title | Test case | Location |
+|---|---|---|---|
+| REQ-0-TEST-SWL-001 | Title 1 | Parser.RejectsLongLines | parser_test.cc:5 |
+| REQ-0-TEST-SWL-001 | Title 1 | Empty \"lines\" are skipped | parser_test.cc:9 |
+| REQ-0-TEST-SWL-002 | Title 2 | Parser.RejectsLongLines | parser_test.cc:5 |
+| REQ-0-TEST-SWL-002 | Title 2 | Empty \"lines\" are skipped | parser_test.cc:9 |
+| REQ-0-TEST-SWL-003 | Title 3 | Parser.RejectsLongLines | parser_test.cc:5 |
+| REQ-0-TEST-SWL-004 | Title 4 | Empty \"lines\" are skipped | parser_test.cc:9 |
+| REQ-0-TEST-SWL-006 | Title 6 | none | |
+`, b.String())
+	b.Reset()
+	assert.Nil(t, WriteTestCaseMatrixJSON(&b, matrix[len(matrix)-1:]))
+	assert.JSONEq(t, `[{"Req": "REQ-0-TEST-SWL-006", "Title": "Title 6", "TestCases": []}]`, b.String())
+}
+
+func TestParseCode_TestCasesMultilineHead(t *testing.T) {
+	cc := filepath.Join(t.TempDir(), "parser_test.cc")
+	assert.Nil(t, os.WriteFile(cc, []byte("TEST(Parser, Empty) {\n  // @"+"tests REQ-0-TEST-SWL-001\n}\n\n"+
+		"TEST_F(ParserFixture,\n       RejectsLongLines) {\n  // @"+"tests REQ-0-TEST-SWL-002\n}\n\n"+
+		"TEST_CASE(\n    \"Long names\",\n    \"[parser]\") {\n  // @"+"tests REQ-0-TEST-SWL-003\n}\n"), 0644))
+	rg := reqGraph{}
+	assert.Nil(t, parseCode("parser_test.cc", cc, rg))
+	if assert.NotNil(t, rg[cc]) {
+		assert.Equal(t, []TestCase{
+			{Name: "Parser.Empty", Line: 1, IDs: []string{"REQ-0-TEST-SWL-001"}},
+			{Name: "ParserFixture.RejectsLongLines", Line: 5, IDs: []string{"REQ-0-TEST-SWL-002"}},
+			{Name: "Long names", Line: 10, IDs: []string{"REQ-0-TEST-SWL-003"}},
+		}, rg[cc].TestCases)
+	}
+}
+
+func TestParseCode_TestCasesFileTags(t *testing.T) {
+	cc := filepath.Join(t.TempDir(), "parser_test.cc")
+	assert.Nil(t, os.WriteFile(cc, []byte("// @"+"tests @llr REQ-0-TEST-SWL-001\n#include <gtest/gtest.h>\n\n"+
+		"TEST(Parser, Empty) {}\n\n// @"+"tests REQ-0-TEST-SWL-002\nTEST(Parser, Long) {\n  // @"+"tests REQ-0-TEST-SWL-001\n}\n"), 0644))
+	rg := reqGraph{}
+	assert.Nil(t, parseCode("parser_test.cc", cc, rg))
+	if assert.NotNil(t, rg[cc]) {
+		assert.Equal(t, []TestCase{
+			{Name: "Parser.Empty", Line: 4, IDs: []string{"REQ-0-TEST-SWL-001"}},
+			{Name: "Parser.Long", Line: 7, IDs: []string{"REQ-0-TEST-SWL-001", "REQ-0-TEST-SWL-002"}},
+		}, rg[cc].TestCases)
+	}
+}
+
+func TestParseCode_TestCasesAnyLevel(t *testing.T) {
+	cc := filepath.Join(t.TempDir(), "nav_test.cc")
+	assert.Nil(t, os.WriteFile(cc, []byte("// @"+"tests REQ-0-TEST-SYS-001\n\n"+
+		"// @"+"tests REQ-0-TEST-SWH-002, REQ-0-TEST-SWL-003\nTEST(Nav, Waypoint) {}\n"), 0644))
+	rg := reqGraph{}
+	assert.Nil(t, parseCode("nav_test.cc", cc, rg))
+	if assert.NotNil(t, rg[cc]) {
+		assert.Equal(t, []string{"REQ-0-TEST-SYS-001", "REQ-0-TEST-SWH-002", "REQ-0-TEST-SWL-003"}, rg[cc].ParentIds)
+		assert.Equal(t, []TestCase{
+			{Name: "Nav.Waypoint", Line: 4, IDs: []string{"REQ-0-TEST-SYS-001", "REQ-0-TEST-SWH-002", "REQ-0-TEST-SWL-003"}},
+		}, rg[cc].TestCases)
+	}
+}
+
+func TestParseCode_TestCasesGo(t *testing.T) {
+	goTest := filepath.Join(t.TempDir(), "parser_test.go")
+	assert.Nil(t, os.WriteFile(goTest, []byte("package parser\n\n// @"+"tests @llr REQ-0-TEST-SWL-001\n"+
+		"func TestParse(t *testing.T) {\n}\n\nfunc helper(t *testing.T) {}\n\n"+
+		"func TestLong(t *testing.T) {\n\t// @"+"tests REQ-0-TEST-SWL-002\n}\n"), 0644))
+	rg := reqGraph{}
+	assert.Nil(t, parseCode("parser_test.go", goTest, rg))
+	if assert.NotNil(t, rg[goTest]) {
+		assert.Equal(t, []TestCase{
+			{Name: "TestParse", Line: 4, IDs: []string{"REQ-0-TEST-SWL-001"}},
+			{Name: "TestLong", Line: 9, IDs: []string{"REQ-0-TEST-SWL-002"}},
+		}, rg[goTest].TestCases)
+	}
+}
+
+func TestReadTestResults(t *testing.T) {
+	dir := t.TempDir()
+	goJSON := filepath.Join(dir, "go.json")
+	assert.Nil(t, os.WriteFile(goJSON, []byte(`{"Action":"run","Test":"TestParse"}
+{"Action":"pass","Test":"TestParse/empty"}
+{"Action":"pass","Test":"TestParse"}
+{"Action":"fail","Test":"TestLong"}
+{"Action":"skip","Test":"TestSlow"}
+{"Action":"pass","Package":"parser"}
+`), 0644))
+	junit := filepath.Join(dir, "gtest.xml")
+	assert.Nil(t, os.WriteFile(junit, []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<testsuites>
+  <testsuite name="Parser">
+    <testcase name="Empty" classname="Parser"/>
+    <testcase name="RejectsLongLines" classname="Parser"><failure message="expected"/></testcase>
+    <testcase name="Disabled" classname="Parser"><skipped/></testcase>
+  </testsuite>
+</testsuites>
+`), 0644))
+
+	res, err := ReadTestResults(goJSON + ", " + junit)
+	assert.Nil(t, err)
+	assert.Equal(t, TestResults{
+		"TestParse":               "pass",
+		"TestLong":                "fail",
+		"Empty":                   "pass",
+		"Parser.Empty":            "pass",
+		"RejectsLongLines":        "fail",
+		"Parser.RejectsLongLines": "fail",
+	}, res)
+
+	_, err = ReadTestResults(filepath.Join(dir, "missing.xml"))
+	assert.Error(t, err)
+}
+
+func TestReqGraph_StatsPassingTests(t *testing.T) {
+	hlr := &Req{ID: "REQ-0-TEST-SWH-001", Level: config.HIGH}
+	llr1 := &Req{ID: "REQ-0-TEST-SWL-001", Level: config.LOW}
+	llr2 := &Req{ID: "REQ-0-TEST-SWL-002", Level: config.LOW}
+	test := &Req{ID: "a/parser_test.go", Path: "a/parser_test.go", Level: config.CODE, TestCases: []TestCase{
+		{Name: "TestParse", Line: 4, IDs: []string{"REQ-0-TEST-SWL-001"}},
+		{Name: "TestLong", Line: 9, IDs: []string{"REQ-0-TEST-SWL-002"}},
+	}}
+	hlr.Children = []*Req{llr1, llr2}
+	llr1.Children = []*Req{test}
+	llr2.Children = []*Req{test}
+	rg := reqGraph{hlr.ID: hlr, llr1.ID: llr1, llr2.ID: llr2, test.ID: test}
+
+	rg.SetTestResults(TestResults{"TestParse": "pass", "TestLong": "fail"})
+	assert.Equal(t, "pass", test.TestCases[0].Result)
+	assert.Equal(t, []LevelStats{
+		{Level: "system"},
+		{Level: "high", Total: 1, WithChildren: 1, WithTests: 1, PctChildren: 100, PctTests: 100},
+		{Level: "low", Total: 2, WithChildren: 2, WithTests: 2, WithPassingTests: 1, PctChildren: 100, PctTests: 100, PctPassingTests: 50},
+	}, rg.Stats())
+
+	rg.SetTestResults(TestResults{"TestParse": "pass", "TestLong": "pass"})
+	assert.Equal(t, 1, rg.Stats()[1].WithPassingTests)
+}
+
+func TestReqGraph_ResolveDeadRefs(t *testing.T) {
+	code := filepath.Join(t.TempDir(), "a.go")
+	assert.Nil(t, os.WriteFile(code, []byte("// @"+"llr REQ-0-TEST-SWL-001\nfunc f() {}\n\n// @"+"llr REQ-0-TEST-SWL-002\n"), 0644))
+	rg := reqGraph{"REQ-0-TEST-SWL-001": &Req{ID: "REQ-0-TEST-SWL-001", Level: config.SYSTEM}}
+	assert.Nil(t, parseCode("a.go", code, rg))
+	err := rg.Resolve()
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "Invalid reference in file "+code+":4: REQ-0-TEST-SWL-002 does not exist.\n")
+	}
+}
+
+func TestReqGraph_CodeMentions(t *testing.T) {
+	code := filepath.Join(t.TempDir(), "a.go")
+	tag := "// @" + "llr REQ-0-TEST-SWL-001"
+	assert.Nil(t, os.WriteFile(code, []byte(tag+"\n// f complements REQ-0-TEST-SWH-002, see REQ-0-TEST-SWL-001\n"+
+		"// and REQ-0-TEST-SWL-009.\nfunc f() {\n\tg(\"REQ-0-TEST-SWH-003\") /* REQ-0-TEST-SWH-002 */\n}\n"), 0644))
+	rg := reqGraph{
+		"REQ-0-TEST-SWL-001": &Req{ID: "REQ-0-TEST-SWL-001", Level: config.LOW},
+		"REQ-0-TEST-SWH-002": &Req{ID: "REQ-0-TEST-SWH-002", Level: config.HIGH},
+		"REQ-0-TEST-SWH-003": &Req{ID: "REQ-0-TEST-SWH-003", Level: config.HIGH},
+	}
+	assert.Nil(t, parseCode("a.go", code, rg))
+	assert.Equal(t, []string{"REQ-0-TEST-SWL-001"}, rg[code].ParentIds)
+	assert.Equal(t, []string{"REQ-0-TEST-SWH-002", "REQ-0-TEST-SWL-001", "REQ-0-TEST-SWL-009", "REQ-0-TEST-SWH-002"}, rg[code].Mentions)
+
+	assert.Nil(t, rg.resolveLinks())
+	if assert.Len(t, rg[code].Links, 1) {
+		assert.Equal(t, "references", rg[code].Links[0].Label())
+		assert.Equal(t, rg["REQ-0-TEST-SWH-002"], rg[code].Links[0].Req)
+	}
+	if assert.Len(t, rg["REQ-0-TEST-SWH-002"].Backlinks, 1) {
+		assert.Equal(t, "referenced by", rg["REQ-0-TEST-SWH-002"].Backlinks[0].Label())
+	}
+	assert.Empty(t, rg["REQ-0-TEST-SWL-001"].Backlinks)
+	assert.Empty(t, rg["REQ-0-TEST-SWH-003"].Backlinks)
+}
+
+func TestReqGraph_CodeMentionsOnly(t *testing.T) {
+	code := filepath.Join(t.TempDir(), "note.go")
+	assert.Nil(t, os.WriteFile(code, []byte("// Design note: see REQ-0-TEST-SWL-001\n"), 0644))
+	rg := reqGraph{"REQ-0-TEST-SWL-001": &Req{ID: "REQ-0-TEST-SWL-001", Level: config.LOW, ParentIds: []string{"REQ-0-TEST-SWH-001"}},
+		"REQ-0-TEST-SWH-001": &Req{ID: "REQ-0-TEST-SWH-001", Level: config.HIGH, ParentIds: []string{"REQ-0-TEST-SYS-001"}},
+		"REQ-0-TEST-SYS-001": &Req{ID: "REQ-0-TEST-SYS-001", Level: config.SYSTEM}}
+	assert.Nil(t, parseCode("note.go", code, rg))
+	if !assert.Contains(t, rg, code) {
+		return
+	}
+	assert.Empty(t, rg[code].ParentIds)
+	assert.Equal(t, []string{"REQ-0-TEST-SWL-001"}, rg[code].Mentions)
+
+	assert.Nil(t, rg.Resolve(), "the file has no parents")
+	if assert.Len(t, rg[code].Links, 1) {
+		assert.Equal(t, "references", rg[code].Links[0].Label())
+		assert.Equal(t, rg["REQ-0-TEST-SWL-001"], rg[code].Links[0].Req)
+	}
+	assert.Empty(t, rg["REQ-0-TEST-SWL-001"].Children)
+
+	plain := filepath.Join(t.TempDir(), "plain.go")
+	assert.Nil(t, os.WriteFile(plain, []byte("package main\n"), 0644))
+	assert.Nil(t, parseCode("plain.go", plain, rg))
+	assert.NotContains(t, rg, plain)
+}
+
+func TestReqGraph_Mentions(t *testing.T) {
+	rg := reqGraph{
+		"REQ-0-TEST-SWH-001": &Req{ID: "REQ-0-TEST-SWH-001", Level: config.HIGH, ParentIds: []string{"REQ-0-TEST-SYS-001"},
+			Title: "Speed as REQ-0-TEST-SWH-002",
+			Body: template.HTML(`<p>Unlike REQ-0-TEST-SYS-001 and REQ-0-TEST-SWH-003, see <a href="https://a/REQ-0-TEST-SWH-004">REQ-0-TEST-SWH-004</a>,` +
+				` REQ-0-TEST-SWH-002, REQ-0-TEST-SWH-009, REQ-0-TEST-SWH-005 or REQ-0-TEST-SWH-001.</p>`),
+			Attributes: map[string]string{"SATISFIES": "REQ-0-TEST-SWH-003"}},
+		"REQ-0-TEST-SYS-001": &Req{ID: "REQ-0-TEST-SYS-001", Level: config.SYSTEM},
+		"REQ-0-TEST-SWH-002": &Req{ID: "REQ-0-TEST-SWH-002", Level: config.HIGH, Body: "<p>Mentioning REQ-0-TEST-SWH-001</p>"},
+		"REQ-0-TEST-SWH-003": &Req{ID: "REQ-0-TEST-SWH-003", Level: config.HIGH},
+		"REQ-0-TEST-SWH-004": &Req{ID: "REQ-0-TEST-SWH-004", Level: config.HIGH},
+		"REQ-0-TEST-SWH-005": &Req{ID: "REQ-0-TEST-SWH-005", Level: config.HIGH, Title: "DELETED"},
+	}
+	r := rg["REQ-0-TEST-SWH-001"]
+	assert.Equal(t, []string{"REQ-0-TEST-SWH-002", "REQ-0-TEST-SWH-004", "REQ-0-TEST-SWH-009", "REQ-0-TEST-SWH-005"}, r.mentions())
+
+	// The unknown and deleted ones are reported by checkReqReferences.
+	assert.Nil(t, rg.resolveLinks())
+	var links []string
+	for _, l := range r.Links {
+		links = append(links, l.Label()+" "+l.Req.ID)
+	}
+	assert.Equal(t, []string{"satisfies REQ-0-TEST-SWH-003", "mentions REQ-0-TEST-SWH-002", "mentions REQ-0-TEST-SWH-004"}, links)
+	if assert.Len(t, rg["REQ-0-TEST-SWH-004"].Backlinks, 1) {
+		assert.Equal(t, "mentioned by", rg["REQ-0-TEST-SWH-004"].Backlinks[0].Label())
+		assert.Equal(t, r, rg["REQ-0-TEST-SWH-004"].Backlinks[0].Req)
+	}
+	assert.Empty(t, rg["REQ-0-TEST-SYS-001"].Backlinks)
+
+	assert.Equal(t, template.HTML(`<p>Unlike REQ-0-TEST-SYS-001 and REQ-0-TEST-SWH-003, see <a href="https://a/REQ-0-TEST-SWH-004">REQ-0-TEST-SWH-004</a>,`+
+		` <a href="#REQ-0-TEST-SWH-002">REQ-0-TEST-SWH-002</a>, REQ-0-TEST-SWH-009, REQ-0-TEST-SWH-005 or REQ-0-TEST-SWH-001.</p>`), r.ExpandedBody())
+	assert.Equal(t, template.HTML(`<p>Mentioning <a href="#REQ-0-TEST-SWH-001">REQ-0-TEST-SWH-001</a></p>`), rg["REQ-0-TEST-SWH-002"].ExpandedBody())
+}
+
+func TestReqGraph_CheckTagPlacement(t *testing.T) {
+	code := filepath.Join(t.TempDir(), "a.go")
+	tag := "// @" + "llr REQ-0-TEST-SWL-00"
+	assert.Nil(t, os.WriteFile(code, []byte(tag+"1\n\npackage a\n\n// f does.\n"+tag+"2\nfunc f() {\n\t"+tag+"3\n\tg()\n}\n"), 0644))
+	rg := reqGraph{}
+	assert.Nil(t, parseCode("a.go", code, rg))
+	assert.Empty(t, rg.CheckTagPlacement(nil))
+	assert.Equal(t, []error{
+		fmt.Errorf("File 'a.go' has the tag of 'REQ-0-TEST-SWL-003' on line 8, expected in the header of the file or immediately above a function.\n"),
+	}, rg.CheckTagPlacement([]string{"header", "function"}))
+	assert.Equal(t, []error{
+		fmt.Errorf("File 'a.go' has the tag of 'REQ-0-TEST-SWL-001' on line 1, expected immediately above a function.\n"),
+		fmt.Errorf("File 'a.go' has the tag of 'REQ-0-TEST-SWL-003' on line 8, expected immediately above a function.\n"),
+	}, rg.CheckTagPlacement([]string{"function"}))
+}
+
+func TestParseCode_MultipleIDs(t *testing.T) {
+	code := filepath.Join(t.TempDir(), "a.cc")
+	tag := "// @" + "llr "
+	assert.Nil(t, os.WriteFile(code, []byte(tag+"REQ-0-TEST-SWL-001, REQ-0-TEST-SWL-002 REQ-0-TEST-SWL-003,\n"+
+		"//      REQ-0-TEST-SWL-004,\n//      REQ-0-TEST-SWL-005\n// REQ-0-TEST-SWL-006\nvoid f();\n"+
+		tag+"REQ-0-TEST-SWL-007 implements REQ-0-TEST-SWL-008\n"), 0644))
+	rg := reqGraph{}
+	assert.Nil(t, parseCode("a.cc", code, rg))
+	if assert.NotNil(t, rg[code]) {
+		assert.Equal(t, []string{"REQ-0-TEST-SWL-001", "REQ-0-TEST-SWL-002", "REQ-0-TEST-SWL-003", "REQ-0-TEST-SWL-004", "REQ-0-TEST-SWL-005", "REQ-0-TEST-SWL-007"}, rg[code].ParentIds)
+		assert.Equal(t, []int{1, 1, 1, 2, 3, 6}, rg[code].RefLines)
+	}
+}
+
+func TestReqGraph_Annotate(t *testing.T) {
+	code := filepath.Join(t.TempDir(), "a.py")
+	tag := "# @" + "llr REQ-0-TEST-SWL-00"
+	assert.Nil(t, os.WriteFile(code, []byte("#!/usr/bin/env python\n"+tag+"2\n"), 0644))
+	rg := reqGraph{
+		"REQ-0-TEST-SWL-001": &Req{ID: "REQ-0-TEST-SWL-001", Level: config.LOW, Title: "Speed"},
+		code:                 &Req{ID: "a.py", Path: code, Level: config.CODE, ParentIds: []string{"REQ-0-TEST-SWL-001", "REQ-0-TEST-SWL-002", "REQ-0-TEST-SWL-001"}},
+	}
+	assert.Empty(t, rg.CheckSummaries())
+	assert.Nil(t, rg.Annotate(code))
+	summary := "# reqtraq:summary Generated by \"reqtraq annotate\", do not edit.\n# Implements:\n" +
+		"#   REQ-0-TEST-SWL-001 Speed\n#   REQ-0-TEST-SWL-002 (unknown requirement)\n# reqtraq:end\n"
+	b, err := os.ReadFile(code)
+	assert.Nil(t, err)
+	assert.Equal(t, "#!/usr/bin/env python\n"+summary+tag+"2\n", string(b))
+	assert.Empty(t, rg.CheckSummaries())
+
+	rg["REQ-0-TEST-SWL-001"].Title = "Altitude"
+	assert.Equal(t, []error{
+		fmt.Errorf("File 'a.py' has an outdated requirements summary, run \"reqtraq annotate a.py\".\n"),
+	}, rg.CheckSummaries())
+	assert.Nil(t, rg.Annotate(code))
+	b, err = os.ReadFile(code)
+	assert.Nil(t, err)
+	assert.Equal(t, "#!/usr/bin/env python\n"+strings.Replace(summary, "Speed", "Altitude", 1)+tag+"2\n", string(b))
+	assert.Error(t, rg.Annotate(filepath.Join(filepath.Dir(code), "b.py")))
+}
+
+func TestParseCode_Aliases(t *testing.T) {
+	defer func(c *RepoConfig) { repoConfig = c }(repoConfig)
+	repoConfig = &RepoConfig{Aliases: map[string]string{"REQ-0-TEST-SWL-017": "REQ-0-TEST-SWL-117"}}
+	code := filepath.Join(t.TempDir(), "a.go")
+	assert.Nil(t, os.WriteFile(code, []byte("// @"+"llr REQ-0-TEST-SWL-001\n\n// @"+"llr REQ-0-TEST-SWL-017\n"), 0644))
+	rg := reqGraph{}
+	assert.Nil(t, parseCode("a.go", code, rg))
+	if assert.NotNil(t, rg[code]) {
+		assert.Equal(t, []string{"REQ-0-TEST-SWL-001", "REQ-0-TEST-SWL-117"}, rg[code].ParentIds)
+	}
+	assert.Equal(t, []error{
+		fmt.Errorf("File 'a.go' references the legacy ID 'REQ-0-TEST-SWL-017' on line 3, instead of 'REQ-0-TEST-SWL-117'.\n"),
+	}, rg.CheckAliases())
+}
+
+func TestReqGraph_Coverage(t *testing.T) {
+	dir := t.TempDir()
+	cwd, err := os.Getwd()
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Nil(t, os.Chdir(dir))
+	defer os.Chdir(cwd)
+	out, err := exec.Command("git", "init", "-q").CombinedOutput()
+	assert.Nil(t, err, string(out))
+
+	assert.Nil(t, os.MkdirAll(filepath.Join(dir, "code"), 0755))
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "code", "nav.c"), []byte("#include <nav.h>\n\n"+
+		"// @"+"llr REQ-0-TEST-SWL-001\nint speed(int v) {\n\tif (v < 0)\n\t\treturn 0;\n\treturn v;\n}\n\n"+
+		"// @"+"llr REQ-0-TEST-SWL-002\n// @"+"llr REQ-0-TEST-SWL-001\nint altitude(void) {\n\treturn 1;\n}\n"), 0644))
+	speed := &Req{ID: "REQ-0-TEST-SWL-001", Level: config.LOW}
+	altitude := &Req{ID: "REQ-0-TEST-SWL-002", Level: config.LOW}
+	rg := reqGraph{speed.ID: speed, altitude.ID: altitude,
+		"code/nav.c": &Req{ID: "code/nav.c", Level: config.CODE}}
+
+	// lcov, e.g. from llvm-cov export -format=lcov, with absolute paths.
+	lcov := filepath.Join(dir, "coverage.info")
+	assert.Nil(t, ioutil.WriteFile(lcov, []byte("TN:\nSF:/build/src/code/nav.c\nDA:4,3\nDA:5,3\nDA:6,0\nDA:7,3\n"+
+		"BRDA:5,0,0,0\nBRDA:5,0,1,3\nDA:12,0\nDA:13,0\nend_of_record\n"), 0644))
+	d, err := ReadCoverage(lcov)
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Nil(t, rg.SetCoverage(d))
+	assert.Equal(t, &Coverage{Statements: 6, CoveredStatements: 3, Branches: 2, CoveredBranches: 1}, speed.Coverage)
+	assert.Equal(t, &Coverage{Statements: 2}, altitude.Coverage)
+	assert.Equal(t, 50.0, speed.Coverage.StatementPercent())
+	assert.Equal(t, 0.0, altitude.Coverage.StatementPercent())
+	assert.Equal(t, 100.0, altitude.Coverage.BranchPercent())
+
+	errs := rg.CheckCoverage(50, 60)
+	assert.Equal(t, []error{
+		newFinding("coverage", "Requirement 'REQ-0-TEST-SWL-001' has branch coverage 50.0%% (1/2), below 60%%.\n"),
+		newFinding("coverage", "Requirement 'REQ-0-TEST-SWL-002' has statement coverage 0.0%% (0/2), below 50%%.\n"),
+	}, errs)
+	assert.Equal(t, 2, Summarize("precommit", exitFindings, findingList{errs[0], errs[1]}).Counts["coverage"])
+
+	// A gcov file covering altitude, merged with the lcov tracefile.
+	gcov := filepath.Join(dir, "nav.c.gcov")
+	assert.Nil(t, ioutil.WriteFile(gcov, []byte("        -:    0:Source:code/nav.c\n        -:    1:#include <nav.h>\n"+
+		"        3:    4:int speed(int v) {\n        3:    5:\tif (v < 0)\nbranch  0 taken 1\nbranch  1 taken 2\n"+
+		"        1:    6:\t\treturn 0;\n        1:   12:int altitude(void) {\n    #####:   13:\treturn 1;\n"), 0644))
+	d, err = ReadCoverage(lcov + "," + gcov)
+	if !assert.Nil(t, err) {
+		return
+	}
+	speed.Coverage, altitude.Coverage = nil, nil
+	assert.Nil(t, rg.SetCoverage(d))
+	assert.Equal(t, &Coverage{Statements: 6, CoveredStatements: 5, Branches: 2, CoveredBranches: 2}, speed.Coverage)
+	assert.Equal(t, &Coverage{Statements: 2, CoveredStatements: 1}, altitude.Coverage)
+
+	// A go cover profile, with the blocks repeated for several packages.
+	profile := filepath.Join(dir, "cover.out")
+	assert.Nil(t, ioutil.WriteFile(profile, []byte("mode: set\nexample.com/nav/code/nav.c:4.20,5.12 2 1\n"+
+		"example.com/nav/code/nav.c:6.3,6.12 1 0\nexample.com/nav/code/nav.c:6.3,6.12 1 1\nexample.com/nav/code/nav.c:12.22,13.11 1 0\n"), 0644))
+	d, err = ReadCoverage(profile)
+	if !assert.Nil(t, err) {
+		return
+	}
+	speed.Coverage, altitude.Coverage = nil, nil
+	assert.Nil(t, rg.SetCoverage(d))
+	assert.Equal(t, &Coverage{Statements: 4, CoveredStatements: 3}, speed.Coverage)
+	assert.Equal(t, &Coverage{Statements: 1}, altitude.Coverage)
+}
+
+func TestReqGraph_Findings(t *testing.T) {
+	dir := t.TempDir()
+	cwd, err := os.Getwd()
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Nil(t, os.Chdir(dir))
+	defer os.Chdir(cwd)
+	out, err := exec.Command("git", "init", "-q").CombinedOutput()
+	assert.Nil(t, err, string(out))
+
+	assert.Nil(t, os.MkdirAll(filepath.Join(dir, "code"), 0755))
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "code", "nav.cc"), []byte("#include <nav.h>\n\n"+
+		"// @"+"llr REQ-0-TEST-SWL-001\nint speed(int v) {\n\treturn v;\n}\n\n"+
+		"// @"+"llr REQ-0-TEST-SWL-002\nint altitude() {\n\treturn 1;\n}\n"), 0644))
+	speed := &Req{ID: "REQ-0-TEST-SWL-001", Level: config.LOW, Position: 1}
+	altitude := &Req{ID: "REQ-0-TEST-SWL-002", Level: config.LOW, Position: 2}
+	rg := reqGraph{speed.ID: speed, altitude.ID: altitude,
+		"code/nav.cc": &Req{ID: "code/nav.cc", Level: config.CODE}}
+
+	sarif := filepath.Join(dir, "findings.sarif")
+	assert.Nil(t, ioutil.WriteFile(sarif, []byte(`{"version": "2.1.0", "runs": [{"tool": {"driver": {"name": "clang-tidy"}},
+		"results": [{"ruleId": "readability-magic-numbers", "message": {"text": "1 is a magic number"},
+			"locations": [{"physicalLocation": {"artifactLocation": {"uri": "file:///src/code/nav.cc"}, "region": {"startLine": 10}}}]}]}]}`), 0644))
+	tidy := filepath.Join(dir, "clang-tidy.txt")
+	assert.Nil(t, ioutil.WriteFile(tidy, []byte("code/nav.cc:1:1: warning: header not found [clang-diagnostic-error]\n"+
+		"code/nav.cc:5:2: error: implicit conversion [bugprone-narrowing-conversions]\n   return v;\n"), 0644))
+	findings, err := ReadFindings(sarif + "," + tidy)
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Equal(t, []Finding{
+		{Tool: "clang-tidy", Rule: "readability-magic-numbers", Level: "warning", Message: "1 is a magic number", File: "/src/code/nav.cc", Line: 10},
+		{Tool: "clang-tidy", Rule: "clang-diagnostic-error", Level: "warning", Message: "header not found", File: "code/nav.cc", Line: 1},
+		{Tool: "clang-tidy", Rule: "bugprone-narrowing-conversions", Level: "error", Message: "implicit conversion", File: "code/nav.cc", Line: 5},
+	}, findings)
+
+	assert.Nil(t, rg.SetFindings(findings))
+	assert.Equal(t, []Finding{findings[2]}, speed.Findings)
+	assert.Equal(t, []Finding{findings[0]}, altitude.Findings)
+	assert.Equal(t, []*Req{speed, altitude}, rg.WithFindings())
+
+	var b bytes.Buffer
+	assert.Nil(t, rg.ReportIssues(&b))
+	assert.Contains(t, b.String(), "Analysis Findings:")
+	assert.Contains(t, b.String(), `<span class="label label-danger">error</span> <code>code/nav.cc:5</code> implicit conversion [bugprone-narrowing-conversions]`)
+}
+
+func TestReqGraph_ReqsByTarget(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, contents string) {
+		fileName := filepath.Join(dir, name)
+		assert.Nil(t, os.MkdirAll(filepath.Dir(fileName), 0755))
+		assert.Nil(t, ioutil.WriteFile(fileName, []byte(contents), 0644))
+	}
+	write("nav/BUILD.bazel", `load("@rules_cc//cc:defs.bzl", "cc_binary", "cc_library")
+
+cc_library(
+    name = "speed",
+    srcs = ["speed.cc"],
+    hdrs = ["speed.h"] + glob(["*.inc"]),
+)
+
+cc_binary(
+    name = "nav",
+    srcs = ["main.cc"],
+    deps = [":speed"],
+)
+`)
+	write("display/CMakeLists.txt", "add_library(display STATIC display.cc\n  ${EXTRA_SOURCES})\nadd_executable(\"show\" main.cc)\n")
+	write("bazel-out/BUILD", "cc_binary(name = \"ignored\", srcs = [\"x.cc\"])\n")
+	targets, err := ReadTargets(dir)
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Equal(t, []Target{
+		{Name: "//nav:nav", Kind: "cc_binary", Sources: []string{"nav/main.cc"}},
+		{Name: "//nav:speed", Kind: "cc_library", Sources: []string{"nav/speed.cc", "nav/speed.h"}},
+		{Name: "display", Kind: "add_library", Sources: []string{"display/display.cc"}},
+		{Name: "show", Kind: "add_executable", Sources: []string{"display/main.cc"}},
+	}, targets)
+
+	sys := &Req{ID: "REQ-0-TEST-SYS-001", Level: config.SYSTEM, Title: "Navigation"}
+	hlr := &Req{ID: "REQ-0-TEST-SWH-001", Level: config.HIGH, Title: "Speed", Parents: []*Req{sys}}
+	llr := &Req{ID: "REQ-0-TEST-SWL-001", Level: config.LOW, Title: "Speed computation", Parents: []*Req{hlr}}
+	speed := &Req{ID: "nav/speed.cc", Level: config.CODE, Parents: []*Req{llr}}
+	header := &Req{ID: "nav/speed.h", Level: config.CODE, Parents: []*Req{llr}}
+	rg := reqGraph{sys.ID: sys, hlr.ID: hlr, llr.ID: llr, speed.ID: speed, header.ID: header}
+	byTarget := rg.ReqsByTarget(targets)
+	if assert.Len(t, byTarget, 4) {
+		assert.Empty(t, byTarget[0].Reqs)
+		assert.Equal(t, []*Req{speed, header}, byTarget[1].Code)
+		assert.Equal(t, []*Req{hlr, llr, sys}, byTarget[1].Reqs)
+	}
+
+	var b bytes.Buffer
+	assert.Nil(t, rg.ReportTargets(&b, targets))
+	assert.Contains(t, b.String(), "Requirements by Target")
+	assert.Contains(t, b.String(), "//nav:speed <small>cc_library</small> (3)")
+	assert.Contains(t, b.String(), "REQ-0-TEST-SWL-001 Speed computation")
+}
+
+func TestReqGraph_Links(t *testing.T) {
+	sys := &Req{ID: "REQ-0-TEST-SYS-001", Level: config.SYSTEM, Attributes: map[string]string{}}
+	hlr1 := &Req{ID: "REQ-0-TEST-SWH-001", Level: config.HIGH, ParentIds: []string{sys.ID}, Attributes: map[string]string{}}
+	hlr2, err := ParseReq("REQ-0-TEST-SWH-002 Display\n\nThe speed shall be displayed.\n\n###### Attributes:\n" +
+		"- Parents: REQ-0-TEST-SYS-001\n- Satisfies: REQ-0-TEST-SWH-001\n- Conflicts-With: REQ-0-TEST-SWH-001, REQ-0-TEST-SWH-009\n")
+	if !assert.Nil(t, err) {
+		return
+	}
+	rg := reqGraph{sys.ID: sys, hlr1.ID: hlr1, hlr2.ID: hlr2}
+	err = rg.Resolve()
+	assert.EqualError(t, err, "Invalid conflicts-with link of requirement REQ-0-TEST-SWH-002: REQ-0-TEST-SWH-009 does not exist.\n\n")
+	assert.Equal(t, 1, Summarize("precommit", exitFindings, err).Counts["invalid_link"])
+
+	delete(hlr2.Attributes, "CONFLICTS-WITH")
+	for _, r := range rg {
+		r.Parents, r.Children = nil, nil
+	}
+	assert.Nil(t, rg.Resolve())
+	assert.Equal(t, []Link{{Kind: "SATISFIES", Req: hlr1}}, hlr2.Links)
+	if assert.Len(t, hlr1.Backlinks, 1) {
+		assert.Equal(t, "satisfied by", hlr1.Backlinks[0].Label())
+		assert.Equal(t, hlr2, hlr1.Backlinks[0].Req)
+	}
+
+	var b bytes.Buffer
+	assert.Nil(t, rg.ReportDown(&b))
+	assert.Contains(t, b.String(), `<span class="label label-info">satisfies</span> <a href="#REQ-0-TEST-SWH-001">REQ-0-TEST-SWH-001</a>`)
+	assert.Contains(t, b.String(), `<span class="label label-default">satisfied by</span> <a href="#REQ-0-TEST-SWH-002">REQ-0-TEST-SWH-002</a>`)
+	assert.NotContains(t, b.String(), "<strong>SATISFIES</strong>")
+
+	// The links survive a snapshot.
+	fileName := filepath.Join(t.TempDir(), "snapshot.json")
+	f, err := os.Create(fileName)
+	if assert.Nil(t, err) {
+		assert.Nil(t, rg.WriteSnapshot(f, nil))
+		f.Close()
+	}
+	rg2, err := ReadSnapshot(fileName)
+	if assert.Nil(t, err) {
+		assert.Equal(t, "REQ-0-TEST-SWH-001", rg2[hlr2.ID].Links[0].Req.ID)
+		assert.Equal(t, "REQ-0-TEST-SWH-002", rg2[hlr1.ID].Backlinks[0].Req.ID)
+	}
+}
+
+func TestReqGraph_DependencyOrder(t *testing.T) {
+	rg := reqGraph{}
+	llr := func(n int, deps string) {
+		id := fmt.Sprintf("REQ-0-TEST-SWL-%03d", n)
+		rg[id] = &Req{ID: id, Title: fmt.Sprint("LLR ", n), Level: config.LOW, Attributes: map[string]string{}}
+		if deps != "" {
+			rg[id].Attributes["DEPENDS-ON"] = deps
+		}
+	}
+	llr(1, "")
+	llr(2, "REQ-0-TEST-SWL-001")
+	llr(3, "REQ-0-TEST-SWL-001")
+	llr(4, "REQ-0-TEST-SWL-002, REQ-0-TEST-SWL-003")
+	llr(5, "REQ-0-TEST-SWL-006")
+	llr(6, "REQ-0-TEST-SWL-007")
+	llr(7, "REQ-0-TEST-SWL-005")
+	llr(8, "REQ-0-TEST-SWL-007, REQ-0-TEST-SWL-001")
+	assert.Nil(t, rg.resolveLinks())
+
+	order := rg.DependencyOrder()
+	var steps [][]string
+	for _, s := range order.Steps {
+		steps = append(steps, reqIDsOf(s))
+	}
+	assert.Equal(t, [][]string{{"REQ-0-TEST-SWL-001"}, {"REQ-0-TEST-SWL-002", "REQ-0-TEST-SWL-003"}, {"REQ-0-TEST-SWL-004"}}, steps)
+	if assert.Len(t, order.Cycles, 1) {
+		assert.Equal(t, []string{"REQ-0-TEST-SWL-005", "REQ-0-TEST-SWL-006", "REQ-0-TEST-SWL-007"}, reqIDsOf(order.Cycles[0]))
+	}
+	assert.Equal(t, []string{"REQ-0-TEST-SWL-008"}, reqIDsOf(order.Blocked))
+	assert.EqualError(t, order.Err(), "Circular dependency between requirements REQ-0-TEST-SWL-005, REQ-0-TEST-SWL-006, REQ-0-TEST-SWL-007.\n")
+
+	var b bytes.Buffer
+	WriteDepOrderMarkdown(&b, order)
+	assert.Contains(t, b.String(), "| 3 | REQ-0-TEST-SWL-004 | LLR 4 | REQ-0-TEST-SWL-002, REQ-0-TEST-SWL-003 |\n")
+	assert.Contains(t, b.String(), "| blocked | REQ-0-TEST-SWL-008 | LLR 8 | REQ-0-TEST-SWL-007, REQ-0-TEST-SWL-001 |\n")
+	assert.Contains(t, b.String(), "- REQ-0-TEST-SWL-005 → REQ-0-TEST-SWL-006 → REQ-0-TEST-SWL-007 → REQ-0-TEST-SWL-005\n")
+}
+
+func TestReqGraph_SuspectLinks(t *testing.T) {
+	dir := t.TempDir()
+	cwd, err := os.Getwd()
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Nil(t, os.Chdir(dir))
+	defer os.Chdir(cwd)
+
+	date := "2020-01-01T00:00:00"
+	run := func(args ...string) {
+		cmd := exec.Command("git", args...)
+		cmd.Env = append(os.Environ(), "GIT_AUTHOR_DATE="+date, "GIT_COMMITTER_DATE="+date)
+		out, err := cmd.CombinedOutput()
+		assert.Nil(t, err, string(out))
+	}
+	write := func(name, contents string) {
+		fileName := filepath.Join(dir, "certdocs", name)
+		assert.Nil(t, os.MkdirAll(filepath.Dir(fileName), 0755))
+		assert.Nil(t, ioutil.WriteFile(fileName, []byte(contents), 0644))
+	}
+	ord := func(body string) string {
+		return "# ORD\n\n### REQ-0-TEST-SYS-001 Speed\n\n" + body + "\n\n###### Attributes:\n- Rationale: R\n"
+	}
+	srd := func(rationale string) string {
+		return "# SRD\n\n### REQ-0-TEST-SWH-001 Display\n\nThe speed shall be displayed.\n\n###### Attributes:\n" +
+			"- Parents: REQ-0-TEST-SYS-001\n- Rationale: " + rationale + "\n"
+	}
+	suspect := func(commit string) []*Req {
+		rg, err := createReqGraph(dir, "certdocs", "code")
+		if !assert.Nil(t, err) {
+			return nil
+		}
+		assert.Nil(t, rg.MarkSuspectLinks(commit, "certdocs"))
+		return rg["REQ-0-TEST-SWH-001"].Suspect
+	}
+
+	run("init", "-q")
+	run("config", "user.email", "test@example.com")
+	run("config", "user.name", "Test")
+	write("0-TEST-100-ORD.md", ord("The speed shall be known."))
+	write("0-TEST-211-SRD.md", srd("R"))
+	run("add", ".")
+	run("commit", "-q", "-m", "Add the requirements")
+	assert.Empty(t, suspect(""))
+
+	// The parent changes after the child.
+	date = "2020-01-02T00:00:00"
+	write("0-TEST-100-ORD.md", ord("The speed shall be known in km/h."))
+	run("commit", "-q", "-a", "-m", "Change the units")
+	if s := suspect(""); assert.Len(t, s, 1) {
+		assert.Equal(t, "REQ-0-TEST-SYS-001", s[0].ID)
+	}
+
+	// A signoff clears the mark.
+	date = "2020-01-03T00:00:00"
+	run("commit", "-q", "--allow-empty", "-m", "Review the display\n\nConfirms: REQ-0-TEST-SWH-001")
+	assert.Empty(t, suspect(""))
+
+	// As does changing the child, here in the working tree after another change of the parent.
+	date = "2020-01-04T00:00:00"
+	write("0-TEST-100-ORD.md", ord("The speed shall be known in knots."))
+	run("commit", "-q", "-a", "-m", "Change the units again")
+	assert.Len(t, suspect(""), 1)
+	write("0-TEST-211-SRD.md", srd("Knots are used in aviation."))
+	assert.Empty(t, suspect(""))
+	assert.Len(t, suspect("HEAD"), 1)
+}
+
+func TestCheckRange(t *testing.T) {
+	dir := t.TempDir()
+	cwd, err := os.Getwd()
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Nil(t, os.Chdir(dir))
+	defer os.Chdir(cwd)
+
+	run := func(args ...string) {
+		out, err := exec.Command("git", args...).CombinedOutput()
+		assert.Nil(t, err, string(out))
+	}
+	commit := func(parent, message string) {
+		fileName := filepath.Join(dir, "certdocs", "0-TEST-211-SRD.md")
+		assert.Nil(t, os.MkdirAll(filepath.Dir(fileName), 0755))
+		assert.Nil(t, ioutil.WriteFile(fileName, []byte("# SRD\n\n### REQ-0-TEST-SWH-001 Display\n\nThe speed shall be displayed.\n\n"+
+			"###### Attributes:\n- Parents: "+parent+"\n- Rationale: R\n"), 0644))
+		run("add", ".")
+		run("commit", "-q", "-m", message)
+	}
+
+	run("init", "-q")
+	run("config", "user.email", "test@example.com")
+	run("config", "user.name", "Test")
+	assert.Nil(t, os.MkdirAll(filepath.Join(dir, "certdocs"), 0755))
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "certdocs", "0-TEST-100-ORD.md"),
+		[]byte("# ORD\n\n### REQ-0-TEST-SYS-001 Speed\n\nThe speed shall be known.\n\n###### Attributes:\n- Rationale: R\n"), 0644))
+	commit("REQ-0-TEST-SYS-001", "Add the requirements")
+	run("tag", "v1.0")
+	commit("REQ-0-TEST-SYS-002", "Break the parent")
+	commit("REQ-0-TEST-SYS-001", "Fix the parent")
+	commit("REQ-0-TEST-SYS-003", "Break the parent again")
+	commit("REQ-0-TEST-SYS-004", "Break the parent differently")
+
+	checks, err := CheckRange("HEAD", false, "certdocs", "code")
+	if !assert.Nil(t, err) || !assert.Len(t, checks, 5) {
+		return
+	}
+	assert.Nil(t, checks[0].Err)
+	assert.NotNil(t, checks[1].Err)
+	assert.Nil(t, checks[2].Err)
+	assert.Equal(t, 3, FirstInvalid(checks))
+	assert.Equal(t, "Break the parent again", checks[3].Subject())
+	assert.Equal(t, 1, Summarize("check", exitFindings, checks[3].Err).Counts["invalid_parent"])
+
+	var b bytes.Buffer
+	WriteRangeCheck(&b, checks)
+	lines := strings.Split(b.String(), "\n")
+	assert.Regexp(t, `^[0-9a-f]{12} ok +Add the requirements$`, lines[0])
+	assert.Regexp(t, `^[0-9a-f]{12} 1 problems +Break the parent$`, lines[1])
+	assert.Contains(t, b.String(), "The requirements became invalid at "+checks[3].Commit.ID[:12]+" Break the parent again")
+
+	assert.Equal(t, -1, FirstInvalid(checks[:3]))
+	assert.Equal(t, 0, FirstInvalid(checks[3:]))
+	checks, err = CheckRange("v1.0..HEAD", true, "certdocs", "code")
+	assert.Nil(t, err)
+	assert.Empty(t, checks)
+}
+
+func TestCheckRange_Reused(t *testing.T) {
+	dir := t.TempDir()
+	cwd, err := os.Getwd()
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Nil(t, os.Chdir(dir))
+	defer os.Chdir(cwd)
+
+	run := func(args ...string) {
+		out, err := exec.Command("git", args...).CombinedOutput()
+		assert.Nil(t, err, string(out))
+	}
+	commit := func(message string, reqs ...string) {
+		doc := "# ORD\n"
+		for _, r := range reqs {
+			doc += "\n### " + r + "\n\nThe speed shall be known.\n\n###### Attributes:\n- Rationale: R\n"
+		}
+		assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "certdocs", "0-TEST-100-ORD.md"), []byte(doc), 0644))
+		run("add", ".")
+		run("commit", "-q", "-m", message)
+	}
+
+	run("init", "-q")
+	run("config", "user.email", "test@example.com")
+	run("config", "user.name", "Test")
+	assert.Nil(t, os.MkdirAll(filepath.Join(dir, "certdocs"), 0755))
+	commit("Add the requirements", "REQ-0-TEST-SYS-001 Speed", "REQ-0-TEST-SYS-002 Altitude")
+	commit("Delete the requirements", "REQ-0-TEST-SYS-001 DELETED Speed")
+	commit("Restore the speed", "REQ-0-TEST-SYS-001 Speed")
+	commit("Reuse the altitude", "REQ-0-TEST-SYS-001 Speed", "REQ-0-TEST-SYS-002 Heading")
+
+	checks, err := CheckRange("HEAD", false, "certdocs", "code")
+	if !assert.Nil(t, err) || !assert.Len(t, checks, 4) {
+		return
+	}
+	assert.Empty(t, checks[2].Reused)
+	reused := Reused(checks)
+	if assert.Len(t, reused, 1) {
+		assert.Equal(t, "Requirement 'REQ-0-TEST-SYS-002' in /certdocs/0-TEST-100-ORD.md reuses the ID of the deleted requirement 'Altitude'.\n", reused[0].Error())
+		assert.Equal(t, 1, Summarize("check", exitFindings, reused[0]).Counts["id_reuse"])
+	}
+	assert.Equal(t, reused, checks[3].Reused)
+
+	var b bytes.Buffer
+	WriteRangeCheck(&b, checks)
+	assert.Contains(t, b.String(), "Reuse the altitude\n\tRequirement 'REQ-0-TEST-SYS-002'")
+
+	// The deletions before the range are found in the history.
+	checks, err = CheckRange("HEAD~1..HEAD", false, "certdocs", "code")
+	if assert.Nil(t, err) && assert.Len(t, checks, 1) {
+		assert.Equal(t, reused, checks[0].Reused)
+	}
+	checks, err = CheckRange("HEAD~2..HEAD~1", false, "certdocs", "code")
+	if assert.Nil(t, err) && assert.Len(t, checks, 1) {
+		assert.Empty(t, checks[0].Reused, "the restored requirement has the same title")
+	}
+
+	// As are those before the working tree.
+	commit("Delete the heading", "REQ-0-TEST-SYS-001 Speed")
+	commit("Delete the speed", "REQ-0-TEST-SYS-001 DELETED Speed")
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "certdocs", "0-TEST-100-ORD.md"), []byte("# ORD\n\n"+
+		"### REQ-0-TEST-SYS-001 Speed\n\nThe speed shall be known.\n\n###### Attributes:\n- Rationale: R\n\n"+
+		"### REQ-0-TEST-SYS-002 Pressure\n\nThe pressure shall be known.\n\n###### Attributes:\n- Rationale: R\n"), 0644))
+	rg, _ := CreateReqGraph("certdocs", "code")
+	reused, err = rg.CheckReusedIDs("certdocs", "code")
+	assert.Nil(t, err)
+	if assert.Len(t, reused, 1) {
+		assert.Contains(t, reused[0].Error(), "Requirement 'REQ-0-TEST-SYS-002' in ")
+		assert.Contains(t, reused[0].Error(), "reuses the ID of the deleted requirement 'Heading'.\n")
+	}
+}
+
+func TestReqGraph_Blame(t *testing.T) {
+	dir := t.TempDir()
+	cwd, err := os.Getwd()
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Nil(t, os.Chdir(dir))
+	defer os.Chdir(cwd)
+
+	date := "2020-01-01T00:00:00"
+	run := func(author string, args ...string) {
+		cmd := exec.Command("git", args...)
+		cmd.Env = append(os.Environ(), "GIT_AUTHOR_DATE="+date, "GIT_COMMITTER_DATE="+date,
+			"GIT_AUTHOR_NAME="+author, "GIT_COMMITTER_NAME="+author)
+		out, err := cmd.CombinedOutput()
+		assert.Nil(t, err, string(out))
+	}
+	srd := func(body1, body2 string) {
+		fileName := filepath.Join(dir, "certdocs", "0-TEST-211-SRD.md")
+		assert.Nil(t, os.MkdirAll(filepath.Dir(fileName), 0755))
+		assert.Nil(t, ioutil.WriteFile(fileName, []byte("# SRD\n\n## Display\n\n"+
+			"### REQ-0-TEST-SWH-001 Speed\n\n"+body1+"\n\n###### Attributes:\n- Rationale: R\n\n"+
+			"### REQ-0-TEST-SWH-002 Altitude\n\n"+body2+"\n\n###### Attributes:\n- Rationale: R\n\n"+
+			"## Other\n\nText.\n"), 0644))
+	}
+
+	run("Ann", "init", "-q")
+	run("Ann", "config", "user.email", "test@example.com")
+	srd("The speed shall be displayed.", "The altitude shall be displayed.")
+	run("Ann", "add", ".")
+	run("Ann", "commit", "-q", "-m", "Add the requirements")
+	date = "2020-01-02T00:00:00"
+	srd("The speed shall be displayed.", "The altitude shall be displayed in feet.")
+	run("Bob", "commit", "-q", "-a", "-m", "Use feet\n\nDetails.")
+
+	// The missing parents do not matter here.
+	rg, _ := createReqGraph(dir, "certdocs", "code")
+	if !assert.NotNil(t, rg) || !assert.Nil(t, rg.SetBlame("")) {
+		return
+	}
+	b := rg["REQ-0-TEST-SWH-001"].Blame
+	if assert.NotNil(t, b) {
+		assert.Equal(t, "Ann", b.Author)
+		assert.Equal(t, "Add the requirements", b.Summary)
+	}
+	b = rg["REQ-0-TEST-SWH-002"].Blame
+	if assert.NotNil(t, b) {
+		assert.Equal(t, "Bob", b.Author)
+		assert.Regexp(t, `^Bob on 2020-01-02 in [0-9a-f]{12} Use feet$`, b.String())
+	}
+
+	// The heading of the next section is not part of the requirement.
+	ranges := reqLineRanges("0-TEST-211-SRD.md", strings.Split("# SRD\n### REQ-0-TEST-SWH-001 A\nA.\n#### Note\nB.\n## Other\n", "\n"))
+	assert.Equal(t, map[string][2]int{"REQ-0-TEST-SWH-001": {1, 5}}, ranges)
+	ranges = reqLineRanges("0-TEST-211-SRD.lyx", []string{"x", "req:", "REQ-0-TEST-SWH-001 A", "Parents: REQ-0-TEST-SYS-001", "/req", "y"})
+	assert.Equal(t, map[string][2]int{"REQ-0-TEST-SWH-001": {1, 5}}, ranges)
+
+	srd("The speed shall be displayed in knots.", "The altitude shall be displayed in feet.")
+	assert.Nil(t, rg.SetBlame(""))
+	assert.Equal(t, "not committed yet", rg["REQ-0-TEST-SWH-001"].Blame.String())
+	assert.Nil(t, rg.SetBlame("HEAD~1"))
+	assert.Equal(t, "Ann", rg["REQ-0-TEST-SWH-002"].Blame.Author)
+}
+
+func TestReqGraph_Staleness(t *testing.T) {
+	dir := t.TempDir()
+	cwd, err := os.Getwd()
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Nil(t, os.Chdir(dir))
+	defer os.Chdir(cwd)
+
+	date := "2020-01-01T00:00:00"
+	run := func(args ...string) {
+		cmd := exec.Command("git", args...)
+		cmd.Env = append(os.Environ(), "GIT_AUTHOR_DATE="+date, "GIT_COMMITTER_DATE="+date)
+		out, err := cmd.CombinedOutput()
+		assert.Nil(t, err, string(out))
+	}
+	write := func(name, contents string) {
+		fileName := filepath.Join(dir, name)
+		assert.Nil(t, os.MkdirAll(filepath.Dir(fileName), 0755))
+		assert.Nil(t, ioutil.WriteFile(fileName, []byte(contents), 0644))
+	}
+	sdd := func(body string) {
+		write("certdocs/0-TEST-212-SDD.md", "# SDD\n\n### REQ-0-TEST-SWL-001 Speed\n\n"+body+"\n\n###### Attributes:\n- Rationale: R\n\n"+
+			"### REQ-0-TEST-SWL-002 Altitude\n\nThe altitude shall be computed.\n\n###### Attributes:\n- Rationale: R\n")
+	}
+	code := func(name, llr, contents string) {
+		write("code/"+name, "// @"+"llr "+llr+"\n"+contents+"\n")
+	}
+
+	run("init", "-q")
+	run("config", "user.email", "test@example.com")
+	run("config", "user.name", "Test")
+	sdd("The speed shall be computed.")
+	code("speed.go", "REQ-0-TEST-SWL-001", "package speed")
+	code("altitude.go", "REQ-0-TEST-SWL-002", "package altitude")
+	run("add", ".")
+	run("commit", "-q", "-m", "Add the requirements and the code")
+	date = "2020-03-01T00:00:00"
+	code("altitude.go", "REQ-0-TEST-SWL-002", "package altitude // In feet.")
+	run("commit", "-q", "-a", "-m", "Change the altitude")
+	date = "2020-06-01T00:00:00"
+	sdd("The speed shall be computed in knots.")
+	run("commit", "-q", "-a", "-m", "Change the speed")
+
+	// The missing parents do not matter here.
+	rg, _ := createReqGraph(dir, "certdocs", "code")
+	if !assert.NotNil(t, rg) {
+		return
+	}
+	stale, err := rg.Staleness("", 30*24*time.Hour)
+	if !assert.Nil(t, err) || !assert.Len(t, stale, 2) {
+		return
+	}
+	assert.Equal(t, "REQ-0-TEST-SWL-002", stale[0].Req.ID)
+	assert.Equal(t, 60, days(stale[0].Drift()))
+	assert.Equal(t, "REQ-0-TEST-SWL-001", stale[1].Req.ID)
+	assert.Equal(t, "code/speed.go", strings.TrimPrefix(stale[1].Code.ID, "/"))
+	assert.Equal(t, -152, days(stale[1].Drift()))
+
+	var b bytes.Buffer
+	WriteStalenessMarkdown(&b, stale, 30*24*time.Hour)
+	assert.Contains(t, b.String(), "| REQ-0-TEST-SWL-002 | 2020-01-01 | code/altitude.go | 2020-03-01 | code, 60 days later |\n")
+	assert.Contains(t, b.String(), "| REQ-0-TEST-SWL-001 | 2020-06-01 | code/speed.go | 2020-01-01 | requirement, 152 days later |\n")
+
+	stale, err = rg.Staleness("HEAD~1", 90*24*time.Hour)
+	assert.Nil(t, err)
+	assert.Empty(t, stale)
+}
+
+func TestReqGraph_Churn(t *testing.T) {
+	dir := t.TempDir()
+	cwd, err := os.Getwd()
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Nil(t, os.Chdir(dir))
+	defer os.Chdir(cwd)
+
+	now := time.Now()
+	date := now.AddDate(-2, 0, 0).Format(time.RFC3339)
+	run := func(args ...string) {
+		cmd := exec.Command("git", args...)
+		cmd.Env = append(os.Environ(), "GIT_AUTHOR_DATE="+date, "GIT_COMMITTER_DATE="+date)
+		out, err := cmd.CombinedOutput()
+		assert.Nil(t, err, string(out))
+	}
+	write := func(name, contents string) {
+		fileName := filepath.Join(dir, name)
+		assert.Nil(t, os.MkdirAll(filepath.Dir(fileName), 0755))
+		assert.Nil(t, ioutil.WriteFile(fileName, []byte(contents), 0644))
+	}
+	sdd := func(body string) {
+		write("certdocs/0-TEST-212-SDD.md", "# SDD\n\n### REQ-0-TEST-SWL-001 Speed\n\n"+body+"\n\n###### Attributes:\n- Rationale: R\n\n"+
+			"### REQ-0-TEST-SWL-002 Altitude\n\nThe altitude shall be computed.\n\n###### Attributes:\n- Rationale: R\n")
+	}
+	code := func(speed, altitude string) {
+		write("code/nav.go", "package nav\n\n// @"+"llr REQ-0-TEST-SWL-001\nfunc Speed() int {\n\treturn "+speed+"\n}\n\n"+
+			"// Altitude returns the altitude.\n// @"+"llr REQ-0-TEST-SWL-002\n// @"+"llr REQ-0-TEST-SWL-001\nfunc Altitude() int {\n\treturn "+altitude+"\n}\n")
+	}
+
+	run("init", "-q")
+	run("config", "user.email", "test@example.com")
+	run("config", "user.name", "Test")
+	sdd("The speed shall be computed.")
+	code("0", "0")
+	run("add", ".")
+	run("commit", "-q", "-m", "Add the requirements and the code")
+	date = now.AddDate(0, 0, -10).Format(time.RFC3339)
+	code("1", "1")
+	run("commit", "-q", "-a", "-m", "Change the code")
+	date = now.AddDate(0, 0, -5).Format(time.RFC3339)
+	code("2", "1")
+	run("commit", "-q", "-a", "-m", "Change the speed")
+
+	// The missing parents do not matter here.
+	rg, _ := createReqGraph(dir, "certdocs", "code")
+	if !assert.NotNil(t, rg) {
+		return
+	}
+	churn, err := rg.Churn(now.AddDate(0, 0, -30), now.AddDate(-1, 0, 0))
+	if !assert.Nil(t, err) || !assert.Len(t, churn, 2) {
+		return
+	}
+	assert.Equal(t, "code/nav.go", churn[0].File)
+	assert.Equal(t, 3, churn[0].Line)
+	assert.Equal(t, "func Speed() int {", churn[0].Function)
+	assert.Equal(t, 2, churn[0].Commits)
+	assert.Equal(t, 1, churn[0].Lines)
+	assert.Equal(t, 9, churn[1].Line)
+	assert.Equal(t, []string{"REQ-0-TEST-SWL-002", "REQ-0-TEST-SWL-001"}, reqIDsOf(churn[1].Reqs))
+	assert.Equal(t, 1, churn[1].Commits)
+
+	var b bytes.Buffer
+	WriteChurnMarkdown(&b, churn, now.AddDate(0, 0, -30), now.AddDate(-1, 0, 0))
+	assert.Contains(t, b.String(), "| code/nav.go:3 | func Speed() int { | 2 | 1 | REQ-0-TEST-SWL-001 | ")
+
+	// A change of the requirement removes its code.
+	sdd("The speed shall be computed in knots.")
+	churn, err = rg.Churn(now.AddDate(0, 0, -30), now.AddDate(-1, 0, 0))
+	assert.Nil(t, err)
+	assert.Empty(t, churn)
+}
+
+func TestParsing_International(t *testing.T) {
+	assert.Equal(t, "Café, naïve, Ærø, Straße, µs, 90°", lyxText(`Caf\'e, na\"{\i}ve, \AE r\o, Stra\ss{}e, \textmu s, 90\textdegree`))
+	assert.Equal(t, "Façade, Dvořák", lyxText(`Fa\c cade, Dvo\v{r}\'ak`))
+	assert.Equal(t, `\begin_layout Standard`, lyxText(`\begin_layout Standard`))
+	assert.Equal(t, "Geschwindigkeit in °C", lyxText("Geschwindigkeit in \xb0C"))
+	assert.Equal(t, "Ωμ already UTF-8", toUTF8("Ωμ already UTF-8"))
+
+	// The error message is truncated to characters, not bytes.
+	_, err := ParseReq("Überschrift ÄÖÜ äöü ÄÖÜ äöü ÄÖÜ äöü ÄÖÜ äöü ÄÖÜ")
+	assert.EqualError(t, err, `malformed requirement: missing ID in first 40 characters: "Überschrift ÄÖÜ äöü ÄÖÜ äöü ÄÖÜ äöü ÄÖÜ "`)
+
+	dir := t.TempDir()
+	lyx := filepath.Join(dir, "123-TEST-100-ORD.lyx")
+	assert.Nil(t, os.WriteFile(lyx, []byte("\\begin_body\n\\begin_layout Subsection\n\\begin_inset Note Note\nstatus collapsed\n\n"+
+		"\\begin_layout Plain Layout\nreq:\n\\end_layout\n\n\\end_inset\n\nREQ-123-TEST-SYS-001 Temp\\'erature\n\\end_layout\n\n"+
+		"\\begin_layout Standard\nThe range shall be \xb1 40 \\textdegree C in \\textmu s steps.\n\\end_layout\n\n"+
+		"\\begin_layout Standard\nRationale: Caf\\'e\n\\end_layout\n\n"+
+		"\\begin_layout Standard\n\\begin_inset Note Note\nstatus collapsed\n\n\\begin_layout Plain Layout\n/req\n\\end_layout\n\n\\end_inset\n\n\n\\end_layout\n\\end_body\n"), 0644))
+	md := filepath.Join(dir, "123-TEST-211-SRD.md")
+	assert.Nil(t, os.WriteFile(md, []byte("# SRD\n\n## Requirements\n\n### REQ-123-TEST-SWH-001 Geschwindigkeit\n\n"+
+		"Die Anzeige in \xb5s.\n\n###### Attributes:\n- Rationale: Gr\xfcn.\n"), 0644))
+	for _, tc := range []struct {
+		file string
+		want []string
+	}{
+		{lyx, []string{"REQ-123-TEST-SYS-001 Température", "The range shall be ± 40 °C in µs steps.", "Rationale: Café"}},
+		{md, []string{"Die Anzeige in µs.", "Rationale: Grün."}},
+	} {
+		reqs, err := ParseCertdoc(tc.file)
+		if assert.Nil(t, err) && assert.Len(t, reqs, 1) {
+			for _, w := range tc.want {
+				assert.Contains(t, reqs[0], w)
+			}
+			assert.True(t, utf8.ValidString(reqs[0]))
+		}
+	}
+}
+
+func TestParsing_LongLines(t *testing.T) {
+	long := strings.Repeat("QUJD", 100000) // Longer than the 64KB limit of bufio.Scanner.
+	dir := t.TempDir()
+	lyx := filepath.Join(dir, "123-TEST-100-ORD.lyx")
+	assert.Nil(t, os.WriteFile(lyx, []byte("\\begin_body\n\\begin_layout Subsection\n\\begin_inset Note Note\nstatus collapsed\n\n"+
+		"\\begin_layout Plain Layout\nreq:\n\\end_layout\n\n\\end_inset\n\nREQ-123-TEST-SYS-001 Image\n\\end_layout\n\n"+
+		"\\begin_layout Standard\nThe logo:\n\\begin_inset Graphics\n\tfilename data:image/png;base64,"+long+"\n\\begin_layout Fake\n"+
+		"\\end_inset\n\n\\end_layout\n\n\\begin_layout Standard\nRationale: Branding.\n\\end_layout\n\n"+
+		"\\begin_layout Standard\n\\begin_inset Note Note\nstatus collapsed\n\n\\begin_layout Plain Layout\n/req\n\\end_layout\n\n\\end_inset\n\n\n\\end_layout\n\\end_body\n"), 0644))
+	reqs, err := ParseLyx(lyx, ioutil.Discard)
+	if assert.Nil(t, err) && assert.Len(t, reqs, 1) {
+		assert.Contains(t, reqs[0], "Rationale: Branding.")
+		assert.False(t, strings.Contains(reqs[0], "QUJD"), "the inset payload is not part of the requirement")
+	}
+
+	md := filepath.Join(dir, "123-TEST-211-SRD.md")
+	assert.Nil(t, os.WriteFile(md, []byte("# SRD\n\n## Requirements\n\n### REQ-123-TEST-SWH-001 Image\n\n"+
+		"![logo](data:image/png;base64,"+long+")\n\n###### Attributes:\n- Rationale: Branding.\n"), 0644))
+	reqs, err = ParseMarkdown(md)
+	if assert.Nil(t, err) && assert.Len(t, reqs, 1) {
+		assert.Contains(t, reqs[0], "Rationale: Branding.")
+	}
+
+	code := filepath.Join(dir, "min.js")
+	assert.Nil(t, os.WriteFile(code, []byte("var a = \""+long+"\";\n// @"+"llr REQ-123-TEST-SWL-001\n"), 0644))
+	rg := reqGraph{}
+	assert.Nil(t, parseCode("min.js", code, rg))
+	if assert.NotNil(t, rg[code]) {
+		assert.Equal(t, []string{"REQ-123-TEST-SWL-001"}, rg[code].ParentIds)
+	}
+
+	var out bytes.Buffer
+	assert.Nil(t, linkifyMarkdown(strings.NewReader("# SRD\n\n"+long+"\n"), &out, "repo", "certdocs"))
+	assert.True(t, out.Len() > len(long))
+}
+
+func TestReqGraph_COTSReqs(t *testing.T) {
+	defer func(c *RepoConfig) { repoConfig = c }(repoConfig)
+	repoConfig = &RepoConfig{Version: repoConfigVersion, COTS: COTSConfig{
+		Roots: []string{"third_party/", "/lib/rtos"}, Attributes: []string{"Service History", "Reuse Justification"}}}
+	zlib := &Req{ID: "third_party/zlib/inflate.c", Path: "third_party/zlib/inflate.c", Level: config.CODE}
+	rtos := &Req{ID: "lib/rtos/sched.c", Path: "lib/rtos/sched.c", Level: config.CODE}
+	own := &Req{ID: "src/parser.c", Path: "src/parser.c", Level: config.CODE}
+	other := &Req{ID: "lib/rtos2/timer.c", Path: "lib/rtos2/timer.c", Level: config.CODE}
+	llr1 := &Req{ID: "REQ-0-TEST-SWL-001", Level: config.LOW, Children: []*Req{zlib, rtos},
+		Attributes: map[string]string{"SERVICE HISTORY": "10 years in avionics", "REUSE JUSTIFICATION": "PDS"}}
+	llr2 := &Req{ID: "REQ-0-TEST-SWL-002", Level: config.LOW, Children: []*Req{rtos},
+		Attributes: map[string]string{"SERVICE HISTORY": "In service since 2015"}}
+	llr3 := &Req{ID: "REQ-0-TEST-SWL-003", Level: config.LOW, Children: []*Req{zlib, own}, Attributes: map[string]string{}}
+	llr4 := &Req{ID: "REQ-0-TEST-SWL-004", Level: config.LOW, Children: []*Req{other}, Attributes: map[string]string{}}
+	llr5 := &Req{ID: "REQ-0-TEST-SWL-005", Level: config.LOW, Attributes: map[string]string{}}
+	rg := reqGraph{}
+	for _, r := range []*Req{zlib, rtos, own, other, llr1, llr2, llr3, llr4, llr5} {
+		rg[r.ID] = r
+	}
+
+	assert.Equal(t, []*Req{llr1, llr2}, rg.COTSReqs())
+	assert.Equal(t, []string{"lib/rtos/sched.c", "third_party/zlib/inflate.c"}, llr1.CodeFiles())
+	assert.Equal(t, []COTSJustification{{"Service History", "In service since 2015"}, {"Reuse Justification", ""}},
+		llr2.COTSJustifications())
+	assert.Equal(t, []error{
+		newFinding("cots", "Requirement 'REQ-0-TEST-SWL-002' implemented only by COTS code is missing the reuse justification attribute 'Reuse Justification'.\n"),
+	}, rg.CheckCOTS())
+	assert.Equal(t, 1, Summarize("precommit", exitFindings, rg.CheckCOTS()[0]).Counts["cots"])
+}
+
+func TestReqGraph_MergeComponent(t *testing.T) {
+	sys := &Req{ID: "REQ-0-TEST-SYS-001", Level: config.SYSTEM, Path: "SYS.md", Title: "Navigation", Attributes: map[string]string{}}
+	hlr := &Req{ID: "REQ-0-TEST-SWH-001", Level: config.HIGH, Title: "Route", ParentIds: []string{"REQ-0-TEST-SYS-001"},
+		Attributes: map[string]string{"COMPONENT": "fms"}}
+	other := &Req{ID: "REQ-0-TEST-SWH-002", Level: config.HIGH, Path: "SRD.md", Title: "Map", ParentIds: []string{"REQ-0-TEST-SYS-001"},
+		Attributes: map[string]string{"COMPONENT": "display"}}
+	rg := reqGraph{sys.ID: sys, hlr.ID: hlr, other.ID: other}
+	assert.Nil(t, rg.Resolve())
+	supplier, err := rg.ExtractComponent("fms")
+	assert.Nil(t, err)
+
+	// The supplier adds an LLR and its code.
+	llr := &Req{ID: "REQ-0-TEST-SWL-001", Level: config.LOW, Title: "Waypoints", ParentIds: []string{"REQ-0-TEST-SWH-001"},
+		Attributes: map[string]string{"COMPONENT": "fms"}}
+	code := &Req{ID: "fms/route.c", Path: "/supplier/fms/route.c", Level: config.CODE, ParentIds: []string{"REQ-0-TEST-SWL-001"}}
+	supplier[llr.ID], supplier[code.Path] = llr, code
+
+	merged, conflicts, problems := rg.MergeComponent(supplier, "fms")
+	assert.Empty(t, conflicts)
+	assert.Nil(t, problems)
+	assert.Equal(t, 5, len(merged))
+	assert.Equal(t, COMPLETED, merged["REQ-0-TEST-SWH-001"].Status)
+	assert.Equal(t, []*Req{merged["/supplier/fms/route.c"]}, merged["REQ-0-TEST-SWL-001"].Children)
+	assert.Empty(t, sys.Children[0].Children, "the graph is not changed")
+
+	// The integrator changed the system requirement and added one to the
+	// component, the supplier used the ID of another component.
+	supplier[other.ID] = &Req{ID: other.ID, Level: config.HIGH, Title: "Map", Attributes: map[string]string{}}
+	sys.Title = "Navigation and guidance"
+	rg["REQ-0-TEST-SWH-003"] = &Req{ID: "REQ-0-TEST-SWH-003", Level: config.HIGH, Path: "SRD.md",
+		Attributes: map[string]string{"COMPONENT": "fms"}}
+	merged, conflicts, _ = rg.MergeComponent(supplier, "fms")
+	assert.Nil(t, merged)
+	assert.Equal(t, []error{
+		newFinding("merge_conflict", "Requirement 'REQ-0-TEST-SWH-002' of the supplier collides with the one in SRD.md, which is not of component fms.\n"),
+		newFinding("merge_conflict", "External requirement 'REQ-0-TEST-SYS-001' of the supplier diverged from the one in SYS.md.\n"),
+		newFinding("merge_conflict", "Requirement 'REQ-0-TEST-SWH-003' of component fms in SRD.md is missing from the supplier graph.\n"),
+	}, conflicts)
+	assert.Equal(t, 3, Summarize("merge-graph", exitFindings, findingList(conflicts[:3])).Counts["merge_conflict"])
+}
+
+func TestReqGraph_OmitExportControlled(t *testing.T) {
+	rg := reqGraph{
+		"REQ-0-TEST-SWH-001": &Req{ID: "REQ-0-TEST-SWH-001", Level: config.HIGH, Path: "/certdocs/0-TEST-211-SRD.md",
+			Body: "<p>The seeker shall ...</p>", Attributes: map[string]string{"TAGS": "navigation, export-controlled", "BODY (DE)": "Der Sucher muss ..."}},
+		"REQ-0-TEST-SWH-002": &Req{ID: "REQ-0-TEST-SWH-002", Level: config.HIGH, Path: "/certdocs/0-TEST-212-SRD.md",
+			Body: "<p>The speed shall ...</p>", Attributes: map[string]string{"TAGS": "navigation"}},
+	}
+	assert.Equal(t, map[string]bool{"certdocs/0-TEST-211-SRD.md": true}, rg.ExportControlledDocuments())
+	rg.OmitExportControlled()
+	assert.Equal(t, template.HTML("<p><em>Omitted, export-controlled.</em></p>"), rg["REQ-0-TEST-SWH-001"].Body)
+	assert.Equal(t, map[string]string{"TAGS": "navigation, export-controlled"}, rg["REQ-0-TEST-SWH-001"].Attributes)
+	assert.Equal(t, template.HTML("<p>The speed shall ...</p>"), rg["REQ-0-TEST-SWH-002"].Body)
+}
+
+func TestReqGraph_Redact(t *testing.T) {
+	defer func(c *RepoConfig) { repoConfig = c }(repoConfig)
+	repoConfig = &RepoConfig{Version: repoConfigVersion, Attributes: []AttributeSpec{{Name: "Mitigation", Sensitive: true}, {Name: "DAL"}}}
+
+	sys := &Req{ID: "REQ-0-TEST-SYS-001", Level: config.SYSTEM, Title: "Speed", Body: "<p>The speed shall ...</p>",
+		Attributes: map[string]string{"MITIGATION": "Redundant sensor.", "DAL": "A", "BODY (DE)": "Die Geschwindigkeit muss ..."}}
+	swh := &Req{ID: "REQ-0-TEST-SWH-001", Level: config.HIGH, Title: "Speed display", Parents: []*Req{sys},
+		Attributes: map[string]string{"DAL": "A"}, Inherited: map[string]string{"MITIGATION": "Redundant sensor."}}
+	sys.Children = []*Req{swh}
+	rg := reqGraph{sys.ID: sys, swh.ID: swh}
+	rg.Redact()
+
+	assert.Equal(t, "Speed", sys.Title)
+	assert.Equal(t, template.HTML("<p><code>"+redactedHash("<p>The speed shall ...</p>")+"</code></p>"), sys.Body)
+	assert.Equal(t, map[string]string{
+		"MITIGATION": redactedHash("Redundant sensor."),
+		"DAL":        "A",
+		"BODY (DE)":  redactedHash("Die Geschwindigkeit muss ..."),
+	}, sys.Attributes)
+	assert.Equal(t, map[string]string{"MITIGATION": redactedHash("Redundant sensor.")}, swh.Inherited)
+	assert.Equal(t, template.HTML(""), swh.Body)
+	assert.Equal(t, []*Req{swh}, sys.Children)
+	assert.NotEqual(t, redactedHash("a"), redactedHash("b"))
+}
+
+func TestValidateDocument(t *testing.T) {
+	fileName := filepath.Join("certdocs", "0-DDLN-211-SRD.md")
+	contents, err := ioutil.ReadFile(fileName)
+	if !assert.Nil(t, err) {
+		return
+	}
+	conf, err := ReadJsonConf(filepath.Join("certdocs", "attributes.json"))
+	assert.Nil(t, err)
+
+	findings, err := ValidateDocument(fileName, contents, "", "certdocs", "", conf.Attributes)
+	assert.Nil(t, err)
+	assert.Nil(t, validationErrors(findings))
+
+	edited := strings.Replace(string(contents), "- Parents: REQ-0-DDLN-SYS-001, REQ-0-DDLN-SYS-002, REQ-0-DDLN-SYS-003, REQ-0-DDLN-SYS-004\n- Verification: Demonstration\n",
+		"- Parents: REQ-0-DDLN-SYS-099\n", 1)
+	findings, err = ValidateDocument("certdocs/0-DDLN-211-SRD.md", []byte(edited), "md", "certdocs", "", conf.Attributes)
+	assert.Nil(t, err)
+	assert.Contains(t, findings, ValidationFinding{"invalid_parent", "error", "Invalid parent of requirement REQ-0-DDLN-SWH-001: REQ-0-DDLN-SYS-099 does not exist."})
+	assert.Contains(t, findings, ValidationFinding{"attribute", "error", "Requirement 'REQ-0-DDLN-SWH-001' is missing attribute 'Verification'."})
+	for _, f := range findings {
+		assert.NotContains(t, f.Message, "REQ-0-DDLN-SWL-")
+	}
+	after, err := ioutil.ReadFile(fileName)
+	assert.Nil(t, err)
+	assert.Equal(t, contents, after)
+
+	var b bytes.Buffer
+	assert.Nil(t, WriteValidationJSON(&b, nil))
+	assert.Equal(t, "[]\n", b.String())
+}
+
+func TestIDHeader(t *testing.T) {
+	rg := reqGraph{
+		"REQ-0-TEST-SWH-001": &Req{ID: "REQ-0-TEST-SWH-001", Level: config.HIGH, Status: COMPLETED, Attributes: map[string]string{"COMPONENT": "fms"}},
+		"REQ-0-TEST-SWH-002": &Req{ID: "REQ-0-TEST-SWH-002", Level: config.HIGH, Status: STARTED, Attributes: map[string]string{"COMPONENT": "fms"}},
+		"REQ-0-TEST-SWL-001": &Req{ID: "REQ-0-TEST-SWL-001", Level: config.LOW, Status: COMPLETED, Attributes: map[string]string{"COMPONENT": "fms, gps"}},
+		"REQ-0-TEST-SWL-002": &Req{ID: "REQ-0-TEST-SWL-002", Level: config.LOW, Status: COMPLETED},
+		"REQ-0-TEST-SWL-003": &Req{ID: "REQ-0-TEST-SWL-003", Level: config.LOW, Status: COMPLETED, Title: "DELETED", Attributes: map[string]string{"COMPONENT": "fms"}},
+		"a.go":               &Req{ID: "a.go", Level: config.CODE, Status: COMPLETED},
+	}
+	ids, err := rg.SatisfiedIDs("fms")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"REQ-0-TEST-SWH-001", "REQ-0-TEST-SWL-001"}, ids)
+	ids, err = rg.SatisfiedIDs("")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"REQ-0-TEST-SWH-001", "REQ-0-TEST-SWL-001", "REQ-0-TEST-SWL-002"}, ids)
+	_, err = rg.SatisfiedIDs("radio")
+	assert.EqualError(t, err, "No requirement of component radio")
+
+	h := &IDHeader{Component: "fms", Ref: "v1.0-2-gabc", GraphHash: "1234", IDs: []string{"REQ-0-TEST-SWH-001", "REQ-0-TEST-SWL-001"}}
+	var b bytes.Buffer
+	assert.Nil(t, h.WriteGo(&b, "fms"))
+	assert.Equal(t, `// Code generated by reqtraq idheader. DO NOT EDIT.
+
+package fms
+
+// Component is the component whose requirements are listed, empty for all.
+const Component = "fms"
+
+// Ref is the commit the requirements were read from, as described by git.
+const Ref = "v1.0-2-gabc"
+
+// GraphHash is the hash of the requirement graph, see "reqtraq help hash".
+const GraphHash = "1234"
+
+// Requirements are the IDs of the requirements implemented, sorted.
+var Requirements = []string{
+	"REQ-0-TEST-SWH-001",
+	"REQ-0-TEST-SWL-001",
+}
+`, b.String())
+	assert.EqualError(t, h.WriteGo(&b, "fms-v2"), `Invalid Go package name "fms-v2"`)
+
+	b.Reset()
+	assert.Nil(t, h.WriteC(&b, "reqids.h"))
+	assert.Equal(t, `/* Generated by reqtraq idheader. DO NOT EDIT. */
+#ifndef REQIDS_H
+#define REQIDS_H
+
+/* The component whose requirements are listed, empty for all. */
+#define REQTRAQ_COMPONENT "fms"
+/* The commit the requirements were read from, as described by git. */
+#define REQTRAQ_REF "v1.0-2-gabc"
+/* The hash of the requirement graph, see "reqtraq help hash". */
+#define REQTRAQ_GRAPH_HASH "1234"
+
+/* The IDs of the requirements implemented, sorted. */
+#define REQTRAQ_REQUIREMENT_COUNT 2
+static const char *const reqtraq_requirements[REQTRAQ_REQUIREMENT_COUNT + 1] = {
+	"REQ-0-TEST-SWH-001",
+	"REQ-0-TEST-SWL-001",
+	0,
+};
+
+#endif /* REQIDS_H */
+`, b.String())
+
+	assert.Equal(t, "go", idHeaderFormat("cmd/fms/reqids.go"))
+	assert.Equal(t, "c", idHeaderFormat("include/reqids.h"))
+	dir := filepath.Join(t.TempDir(), "fms")
+	assert.Nil(t, os.Mkdir(dir, 0755))
+	assert.Equal(t, "fms", goPackage(dir))
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "main_test.go"), []byte("package main_test\n"), 0644))
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "main.go"), []byte("// Command fms.\npackage main\n"), 0644))
+	assert.Equal(t, "main", goPackage(dir))
+}
+
+func TestGenTests(t *testing.T) {
+	rg := reqGraph{
+		"REQ-0-TEST-SWH-001": &Req{ID: "REQ-0-TEST-SWH-001", Level: config.HIGH},
+		"REQ-0-TEST-SWL-001": &Req{ID: "REQ-0-TEST-SWL-001", Level: config.LOW, Title: "Parse the input",
+			Body:       "<p>The parser shall reject the lines longer than &lt;80&gt; characters.</p>",
+			Attributes: map[string]string{"PARENTS": "REQ-0-TEST-SWH-001", "VERIFICATION": "Test", "PARAM": "  line\nlength "}},
+		"REQ-0-TEST-SWL-002": &Req{ID: "REQ-0-TEST-SWL-002", Level: config.LOW, Title: "DELETED"},
+	}
+	_, err := rg.testSkeletonRequirements([]string{"REQ-0-TEST-SWL-002"})
+	assert.EqualError(t, err, "Requirement REQ-0-TEST-SWL-002 does not exist")
+	_, err = rg.testSkeletonRequirements([]string{"REQ-0-TEST-SWH-001"})
+	assert.EqualError(t, err, "Requirement REQ-0-TEST-SWH-001 is not a low-level requirement")
+	reqs, err := rg.testSkeletonRequirements([]string{"REQ-0-TEST-SWL-001"})
+	assert.Nil(t, err)
+
+	var b bytes.Buffer
+	assert.Nil(t, WriteGoTestSkeleton(&b, "parser", reqs))
+	// Split, so this file is not tagged.
+	assert.Equal(t, `// Skeleton generated by reqtraq gen-tests, to be completed.
+
+package parser
+
+import "testing"
+
+// @`+`tests @`+`llr REQ-0-TEST-SWL-001
+// TestREQ_0_TEST_SWL_001 verifies: Parse the input
+//
+// The parser shall reject the lines longer than <80> characters.
+func TestREQ_0_TEST_SWL_001(t *testing.T) {
+	// TODO PARAM: line length
+	// TODO VERIFICATION: Test
+	t.Skip("not implemented: REQ-0-TEST-SWL-001")
+}
+`, b.String())
+	// The test file traces to the requirement.
+	fileName := filepath.Join(t.TempDir(), "parser_test.go")
+	assert.Nil(t, ioutil.WriteFile(fileName, b.Bytes(), 0644))
+	code := reqGraph{}
+	assert.Nil(t, parseCode("parser_test.go", fileName, code))
+	if assert.NotNil(t, code[fileName]) {
+		assert.Equal(t, []string{"REQ-0-TEST-SWL-001"}, code[fileName].ParentIds)
+	}
+	assert.EqualError(t, WriteGoTestSkeleton(&b, "parser-v2", reqs), `Invalid Go package name "parser-v2"`)
+
+	b.Reset()
+	assert.Nil(t, WriteRobotSkeleton(&b, reqs))
+	assert.Equal(t, `*** Settings ***
+Documentation    Skeleton generated by reqtraq gen-tests, to be completed.
+
+*** Test Cases ***
+REQ-0-TEST-SWL-001 Parse the input
+    [Documentation]    Parse the input
+    ...    The parser shall reject the lines longer than <80> characters.
+    [Tags]    REQ-0-TEST-SWL-001
+    # TODO PARAM: line length
+    # TODO VERIFICATION: Test
+    Skip    not implemented: REQ-0-TEST-SWL-001
+`, b.String())
+}
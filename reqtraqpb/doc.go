@@ -0,0 +1,5 @@
+// Package reqtraqpb is the gRPC service of reqtraq, generated from
+// reqtraq.proto, for the tools embedding its checks in other languages.
+package reqtraqpb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative reqtraq.proto
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/daedaleanai/reqtraq/config"
+)
+
+// numericAttributes returns the names of the attributes declared as numbers,
+// in upper case, for the rollup command.
+func (c *RepoConfig) numericAttributes() []string {
+	var res []string
+	for _, a := range c.Attributes {
+		if a.Type == attributeInt || a.Type == attributeFloat {
+			res = append(res, strings.ToUpper(a.Name))
+		}
+	}
+	sort.Strings(res)
+	return res
+}
+
+// Rollup is the value of a numeric attribute of a requirement, and its total
+// with the values of its descendants, each counted once.
+type Rollup struct {
+	Req   *Req
+	Value float64
+	Total float64
+}
+
+// Rollups returns the values of the given numeric attribute of the requirements
+// which are not deleted, and their totals, by level from the system
+// requirements down, and by ID. The requirements without the attribute or with an invalid value
+// count as zero, precommit reporting the invalid values.
+func (rg reqGraph) Rollups(attribute string) []Rollup {
+	attribute = strings.ToUpper(attribute)
+	value := func(r *Req) float64 {
+		v, err := strconv.ParseFloat(strings.TrimSpace(r.Attributes[attribute]), 64)
+		if err != nil {
+			return 0
+		}
+		return v
+	}
+	var res []Rollup
+	for _, r := range rg {
+		if r.Level == config.CODE || r.IsDeleted() {
+			continue
+		}
+		seen := map[*Req]bool{}
+		var total float64
+		var visit func(r *Req)
+		visit = func(r *Req) {
+			if seen[r] || r.Level == config.CODE || r.IsDeleted() {
+				return
+			}
+			seen[r] = true
+			total += value(r)
+			for _, c := range r.Children {
+				visit(c)
+			}
+		}
+		visit(r)
+		res = append(res, Rollup{r, value(r), total})
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Req.Level != res[j].Req.Level {
+			return res[i].Req.Level < res[j].Req.Level
+		}
+		return res[i].Req.ID < res[j].Req.ID
+	})
+	return res
+}
+
+func formatNumber(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// WriteRollupsMarkdown writes the values of the attribute and their totals as a Markdown table.
+func WriteRollupsMarkdown(w io.Writer, attribute string, rollups []Rollup) {
+	fmt.Fprintf(w, "# %s rollup\n\n", attribute)
+	if len(rollups) == 0 {
+		fmt.Fprintf(w, "No requirements.\n")
+		return
+	}
+	fmt.Fprintf(w, "| Requirement | Title | %s | Total |\n", attribute)
+	fmt.Fprintf(w, "|---|---|---|---|\n")
+	for _, r := range rollups {
+		fmt.Fprintf(w, "| %s | %s | %s | %s |\n", r.Req.ID, r.Req.Title, formatNumber(r.Value), formatNumber(r.Total))
+	}
+}
+
+// WriteRollupsJSON writes the values of the attribute and their totals as json.
+func WriteRollupsJSON(w io.Writer, rollups []Rollup) error {
+	type entry struct {
+		ID, Title    string
+		Value, Total float64
+	}
+	res := []entry{}
+	for _, r := range rollups {
+		res = append(res, entry{r.Req.ID, r.Req.Title, r.Value, r.Total})
+	}
+	b, err := json.MarshalIndent(res, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(b, '\n'))
+	return err
+}
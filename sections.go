@@ -0,0 +1,136 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// docSection is the section of a document a requirement appears under.
+type docSection struct {
+	Path   string // The titles of the enclosing headings, e.g. "Requirements > Functional".
+	Number string // The number of the innermost enclosing heading, e.g. "3.1".
+}
+
+// lyxSectionLevels are the levels of the LyX layouts of the headings.
+var lyxSectionLevels = map[string]int{"Part": 0, "Chapter": 1, "Section": 2, "Subsection": 3, "Subsubsection": 4, "Paragraph": 5, "Subparagraph": 6}
+
+// sectionTracker follows the headings of a document, numbering them.
+type sectionTracker struct {
+	top      int      // The level of the outermost heading, numbered 1.
+	titles   []string // The titles of the current headings, by level.
+	counters []int    // The numbers of the current headings, by level.
+}
+
+// heading enters a new heading of the given level.
+func (t *sectionTracker) heading(level int, title string) {
+	if len(t.titles) == 0 || level < t.top {
+		t.top = level
+	}
+	for len(t.titles) <= level {
+		t.titles = append(t.titles, "")
+		t.counters = append(t.counters, 0)
+	}
+	t.titles = t.titles[:level+1]
+	t.counters = t.counters[:level+1]
+	t.titles[level] = strings.TrimSpace(title)
+	t.counters[level]++
+}
+
+// section returns the section enclosing a requirement heading of the given
+// level, or any content when level is -1.
+func (t *sectionTracker) section(level int) docSection {
+	if level < 0 || level > len(t.titles) {
+		level = len(t.titles)
+	}
+	// The levels below the innermost heading are not numbered.
+	for level > t.top && t.titles[level-1] == "" {
+		level--
+	}
+	var titles, numbers []string
+	for l := t.top; l < level; l++ {
+		if t.titles[l] != "" {
+			titles = append(titles, t.titles[l])
+		}
+		numbers = append(numbers, fmt.Sprint(t.counters[l]))
+	}
+	return docSection{Path: strings.Join(titles, " > "), Number: strings.Join(numbers, ".")}
+}
+
+// docSections returns the sections the requirements of the given certification
+// document appear under, by requirement ID. The requirement headings are
+// numbered as the other headings, and the title of a Markdown document, its
+// first heading when of level 1, is not a section.
+func docSections(contents []byte) map[string]docSection {
+	res := map[string]docSection{}
+	var t sectionTracker
+	scan := newLineReader(bytes.NewReader(contents))
+	if !bytes.Contains(contents, []byte("\\begin_body")) {
+		first := true
+		for scan.Scan() {
+			parts := reATXHeading.FindStringSubmatch(toUTF8(scan.Text()))
+			if parts == nil {
+				continue
+			}
+			level := len(parts[1])
+			if first && level == 1 {
+				first = false
+				continue
+			}
+			first = false
+			if id := ReReqID.FindString(parts[3]); id != "" {
+				res[id] = t.section(level)
+			}
+			t.heading(level, parts[3])
+		}
+		return res
+	}
+
+	// In LyX, the requirements start with "req:", in a note of the paragraph
+	// containing their ID and title, or of the previous one.
+	type layout struct {
+		name   string
+		insets int // The depth of the insets the layout is in.
+		text   []string
+	}
+	var (
+		stack   []*layout
+		insets  int  // The depth of the current inset, e.g. a note.
+		pending bool // Whether a requirement started, its ID being expected.
+	)
+	for scan.Scan() {
+		line := toUTF8(strings.TrimRight(scan.Text(), "\r"))
+		switch {
+		case strings.HasPrefix(line, "\\begin_inset"):
+			insets++
+		case line == "\\end_inset":
+			insets--
+		case reLyxLayout.MatchString(line):
+			stack = append(stack, &layout{name: reLyxLayout.FindStringSubmatch(line)[1], insets: insets})
+		case line == "\\end_layout" && len(stack) > 0:
+			l := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			text := strings.TrimSpace(strings.Join(l.text, ""))
+			level, isHeading := lyxSectionLevels[strings.TrimSuffix(l.name, "*")]
+			switch {
+			case reStart.MatchString(text):
+				pending = true
+			case pending && strings.HasPrefix(text, "REQ-") && ReReqID.MatchString(text):
+				if isHeading {
+					res[ReReqID.FindString(text)] = t.section(level)
+					t.heading(level, text)
+				} else {
+					res[ReReqID.FindString(text)] = t.section(-1)
+				}
+				pending = false
+			case isHeading:
+				t.heading(level, text)
+			}
+		case len(stack) > 0 && !strings.HasPrefix(line, "\\") && stack[len(stack)-1].insets == insets:
+			// The parameters of the insets, e.g. "status collapsed", are not text.
+			l := stack[len(stack)-1]
+			l.text = append(l.text, lyxText(line))
+		}
+	}
+	return res
+}
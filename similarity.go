@@ -0,0 +1,141 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/daedaleanai/reqtraq/config"
+)
+
+// shingleSize is the number of words of the shingles the texts of the
+// requirements are compared by.
+const shingleSize = 3
+
+// reWord matches the words of the texts of the requirements.
+var reWord = regexp.MustCompile(`[\pL\pN]+`)
+
+// Similarity is a pair of requirements whose texts are similar, hinting at a
+// copy-paste, the requirements to be merged or one to be the parent of the other.
+type Similarity struct {
+	Req, Other *Req
+	Score      float64 // The cosine similarity of the shingles of their texts, in [0, 1].
+}
+
+// Linked returns whether one of the requirements is a parent of the other.
+func (s Similarity) Linked() bool {
+	for _, p := range s.Req.Parents {
+		if p == s.Other {
+			return true
+		}
+	}
+	for _, p := range s.Other.Parents {
+		if p == s.Req {
+			return true
+		}
+	}
+	return false
+}
+
+// shingles returns the number of occurrences of the shingles of the given
+// text, the sequences of shingleSize consecutive words, or the text when it
+// is shorter.
+func shingles(text string) map[string]float64 {
+	words := reWord.FindAllString(strings.ToLower(reHTMLTag.ReplaceAllString(text, " ")), -1)
+	res := map[string]float64{}
+	if len(words) < shingleSize {
+		if len(words) > 0 {
+			res[strings.Join(words, " ")]++
+		}
+		return res
+	}
+	for i := 0; i+shingleSize <= len(words); i++ {
+		res[strings.Join(words[i:i+shingleSize], " ")]++
+	}
+	return res
+}
+
+// cosine returns the cosine similarity of the given vectors.
+func cosine(a, b map[string]float64) float64 {
+	var dot, na, nb float64
+	for k, v := range a {
+		dot += v * b[k]
+		na += v * v
+	}
+	for _, v := range b {
+		nb += v * v
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / math.Sqrt(na*nb)
+}
+
+// Similarities returns the pairs of requirements whose titles and bodies have
+// a similarity of at least threshold, the most similar first.
+func (rg reqGraph) Similarities(threshold float64) []Similarity {
+	var reqs []*Req
+	for _, r := range rg {
+		if r.Level != config.CODE && !r.IsDeleted() {
+			reqs = append(reqs, r)
+		}
+	}
+	sort.Sort(byIDs(reqs))
+	vectors := make([]map[string]float64, len(reqs))
+	for i, r := range reqs {
+		vectors[i] = shingles(r.Title + "\n" + string(r.Body))
+	}
+	var res []Similarity
+	for i := range reqs {
+		for j := i + 1; j < len(reqs); j++ {
+			if score := cosine(vectors[i], vectors[j]); score >= threshold {
+				res = append(res, Similarity{Req: reqs[i], Other: reqs[j], Score: score})
+			}
+		}
+	}
+	sort.SliceStable(res, func(i, j int) bool { return res[i].Score > res[j].Score })
+	return res
+}
+
+// WriteSimilaritiesMarkdown writes the similar requirements as a Markdown table.
+func WriteSimilaritiesMarkdown(w io.Writer, similar []Similarity, threshold float64) {
+	fmt.Fprintf(w, "# Similar requirements\n\n")
+	if len(similar) == 0 {
+		fmt.Fprintf(w, "No requirements with a similarity of %.0f%% or more.\n", threshold*100)
+		return
+	}
+	fmt.Fprintf(w, "Requirements with a similarity of %.0f%% or more.\n\n", threshold*100)
+	fmt.Fprintf(w, "| Similarity | Requirement | Document | Requirement | Document | Linked |\n")
+	fmt.Fprintf(w, "|---|---|---|---|---|---|\n")
+	for _, s := range similar {
+		linked := "no"
+		if s.Linked() {
+			linked = "yes"
+		}
+		fmt.Fprintf(w, "| %.0f%% | %s | %s | %s | %s | %s |\n", s.Score*100, s.Req.ID, strings.TrimPrefix(s.Req.Path, "/"),
+			s.Other.ID, strings.TrimPrefix(s.Other.Path, "/"), linked)
+	}
+}
+
+// WriteSimilaritiesJSON writes the similar requirements as json.
+func WriteSimilaritiesJSON(w io.Writer, similar []Similarity) error {
+	type entry struct {
+		Req, Other string
+		Score      float64
+		Linked     bool
+	}
+	res := []entry{}
+	for _, s := range similar {
+		res = append(res, entry{s.Req.ID, s.Other.ID, math.Round(s.Score*1000) / 1000, s.Linked()})
+	}
+	b, err := json.MarshalIndent(res, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(b, '\n'))
+	return err
+}
@@ -0,0 +1,247 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"io/ioutil"
+	"sort"
+
+	"github.com/daedaleanai/reqtraq/config"
+)
+
+// snapshotVersion is the version of the snapshot format, to be increased when
+// the format changes in ways older versions of reqtraq cannot read.
+const snapshotVersion = 3
+
+// Snapshot is a resolved requirement graph, written by the snapshot command so
+// it can be used by the other commands without parsing the documents again.
+type Snapshot struct {
+	Version int
+	// Problems are the problems found while building the graph, if any.
+	Problems []snapshotProblem `json:",omitempty"`
+	// Component is the component of an extracted graph, see ExtractComponent.
+	Component string `json:",omitempty"`
+	Nodes     []snapshotNode
+}
+
+// snapshotNode is a Req, with the keys of its parents and children in the graph instead of pointers.
+type snapshotNode struct {
+	Key           string
+	ID            string
+	Level         config.RequirementLevel
+	Path          string
+	FileHash      string     `json:",omitempty"`
+	Kind          string     `json:",omitempty"`
+	ParentIds     []string   `json:",omitempty"`
+	Mentions      []string   `json:",omitempty"`
+	TestCases     []TestCase `json:",omitempty"`
+	Parents       []string   `json:",omitempty"`
+	Children      []string   `json:",omitempty"`
+	Title         string     `json:",omitempty"`
+	Body          string     `json:",omitempty"`
+	Attributes    map[string]string
+	Inherited     map[string]string       `json:",omitempty"`
+	Typed         map[string]snapshotType `json:",omitempty"`
+	Position      int
+	Seen          bool
+	Status        RequirementStatus
+	Document      *Document `json:",omitempty"`
+	Section       string    `json:",omitempty"`
+	SectionNumber string    `json:",omitempty"`
+	External      bool      `json:",omitempty"`
+}
+
+// snapshotProblem is a finding recorded in a snapshot, with its type, see
+// findingType, or a header such as "Problems found while parsing ...", with
+// no type.
+type snapshotProblem struct {
+	Type    string `json:",omitempty"`
+	Message string
+}
+
+// snapshotType is the type of a typed attribute of a node, its value being
+// parsed again from the text when read, see Req.Typed.
+type snapshotType struct {
+	Type   string
+	Values []string `json:",omitempty"`
+}
+
+// WriteSnapshot writes the graph, with the problems found while building it, as a json snapshot.
+func (rg reqGraph) WriteSnapshot(w io.Writer, problems error) error {
+	return writeSnapshot(w, rg.snapshot(problems))
+}
+
+// WriteComponentSnapshot writes the graph extracted for the given component,
+// see ExtractComponent, with the problems found while building the whole
+// graph, as a json snapshot.
+func (rg reqGraph) WriteComponentSnapshot(w io.Writer, component string, problems error) error {
+	s := rg.snapshot(problems)
+	s.Component = component
+	return writeSnapshot(w, s)
+}
+
+func writeSnapshot(w io.Writer, s Snapshot) error {
+	b, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(b, '\n'))
+	return err
+}
+
+// Hash returns the sha256 of the resolved graph, in hex. It depends only on
+// the contents of the requirements and the code referencing them, and on
+// their links, so the graphs of two checkouts have the same hash when the
+// traceability data is identical.
+func (rg reqGraph) Hash() (string, error) {
+	s := rg.snapshot(nil)
+	// The code files are keyed and located by absolute path, which depends on
+	// the checkout, use their ID instead, the path relative to the repo root.
+	ids := map[string]string{}
+	for _, n := range s.Nodes {
+		ids[n.Key] = n.ID
+	}
+	for i := range s.Nodes {
+		n := &s.Nodes[i]
+		if n.Level == config.CODE {
+			n.Key, n.Path = n.ID, n.ID
+		}
+		// Derived from the configuration, which may not have been applied.
+		n.Inherited, n.Typed = nil, nil
+		// The links to code files are added in no particular order.
+		for _, keys := range [][]string{n.Parents, n.Children} {
+			for j, k := range keys {
+				keys[j] = ids[k]
+			}
+			sort.Strings(keys)
+		}
+	}
+	sort.Slice(s.Nodes, func(i, j int) bool { return s.Nodes[i].Key < s.Nodes[j].Key })
+	b, err := json.Marshal(s)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", sha256.Sum256(b)), nil
+}
+
+// snapshot returns the graph as a Snapshot, with its nodes sorted by key.
+func (rg reqGraph) snapshot(problems error) Snapshot {
+	keys := map[*Req]string{}
+	for k, r := range rg {
+		keys[r] = k
+	}
+	keysOf := func(reqs []*Req) []string {
+		var res []string
+		for _, r := range reqs {
+			res = append(res, keys[r])
+		}
+		return res
+	}
+
+	s := Snapshot{Version: snapshotVersion}
+	for _, e := range flattenFindings(problems) {
+		p := snapshotProblem{Message: e.Error()}
+		if _, ok := e.(findingHeader); !ok {
+			p.Type = findingType(e)
+		}
+		s.Problems = append(s.Problems, p)
+	}
+	for k, r := range rg {
+		var typed map[string]snapshotType
+		for name, v := range r.Typed {
+			if typed == nil {
+				typed = map[string]snapshotType{}
+			}
+			typed[name] = snapshotType{Type: v.Type, Values: v.Values}
+		}
+		s.Nodes = append(s.Nodes, snapshotNode{
+			Key: k, ID: r.ID, Level: r.Level, Path: r.Path, FileHash: r.FileHash, Kind: r.Kind, ParentIds: r.ParentIds,
+			Mentions: r.Mentions, Parents: keysOf(r.Parents), Children: keysOf(r.Children), Title: r.Title, Body: string(r.Body),
+			Attributes: r.Attributes, Inherited: r.Inherited, Typed: typed, Position: r.Position, Seen: r.Seen, Status: r.Status,
+			Document: r.Document, Section: r.Section, SectionNumber: r.SectionNumber, External: r.External, TestCases: r.TestCases,
+		})
+	}
+	sort.Slice(s.Nodes, func(i, j int) bool { return s.Nodes[i].Key < s.Nodes[j].Key })
+	return s
+}
+
+// ReadSnapshot reads a graph from a json snapshot. As for CreateReqGraph, the
+// problems recorded in the snapshot are returned as an error along with the graph.
+func ReadSnapshot(fileName string) (reqGraph, error) {
+	rg, _, err := ReadComponentSnapshot(fileName)
+	return rg, err
+}
+
+// ReadComponentSnapshot reads a graph from a json snapshot, as ReadSnapshot,
+// and returns its component, empty unless written by WriteComponentSnapshot.
+func ReadComponentSnapshot(fileName string) (reqGraph, string, error) {
+	b, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return nil, "", err
+	}
+	var s Snapshot
+	if err := json.Unmarshal(b, &s); err != nil {
+		return nil, "", fmt.Errorf("Error while parsing snapshot %s: %v", fileName, err)
+	}
+	if s.Version != snapshotVersion {
+		return nil, "", fmt.Errorf("Snapshot %s has version %d, this reqtraq reads version %d", fileName, s.Version, snapshotVersion)
+	}
+
+	rg := reqGraph{}
+	docs := map[string]*Document{} // The requirements of a document share it.
+	for _, n := range s.Nodes {
+		if d := n.Document; d != nil {
+			if docs[d.Path] == nil {
+				docs[d.Path] = d
+			}
+			n.Document = docs[d.Path]
+		}
+		r := &Req{ID: n.ID, Level: n.Level, Path: n.Path, FileHash: n.FileHash, Kind: n.Kind, ParentIds: n.ParentIds,
+			Title: n.Title, Body: template.HTML(n.Body), Attributes: n.Attributes, Inherited: n.Inherited, Position: n.Position,
+			Seen: n.Seen, Status: n.Status, Document: n.Document, Section: n.Section, SectionNumber: n.SectionNumber,
+			External: n.External, Mentions: n.Mentions, TestCases: n.TestCases}
+		for name, t := range n.Typed {
+			text, _ := r.attribute(name)
+			r.setTyped(name, t.Type, t.Values, text)
+		}
+		rg[n.Key] = r
+	}
+	reqsOf := func(keys []string) ([]*Req, error) {
+		var res []*Req
+		for _, k := range keys {
+			r := rg[k]
+			if r == nil {
+				return nil, fmt.Errorf("Snapshot %s is corrupt, %s is missing", fileName, k)
+			}
+			res = append(res, r)
+		}
+		return res, nil
+	}
+	for _, n := range s.Nodes {
+		r := rg[n.Key]
+		if r.Parents, err = reqsOf(n.Parents); err != nil {
+			return nil, "", err
+		}
+		if r.Children, err = reqsOf(n.Children); err != nil {
+			return nil, "", err
+		}
+	}
+	// The invalid links are among the recorded problems.
+	_ = rg.resolveLinks()
+	var problems findingList
+	for _, p := range s.Problems {
+		switch p.Type {
+		case "":
+			problems.add(findingHeader(p.Message))
+		case "other":
+			problems.add(errors.New(p.Message))
+		default:
+			problems.add(&findingError{Type: p.Type, msg: p.Message})
+		}
+	}
+	return rg, s.Component, problems.err()
+}
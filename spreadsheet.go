@@ -0,0 +1,169 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/daedaleanai/reqtraq/config"
+	"github.com/daedaleanai/reqtraq/git"
+	"github.com/daedaleanai/reqtraq/xlsx"
+)
+
+// The columns preceding the attribute columns in exported spreadsheets.
+var spreadsheetColumns = []string{"ID", "Document", "Title"}
+
+// attributeColumns returns the names of the editable attributes: the ones in
+// the specification, in order, followed by any other attributes found in the
+// requirements. Parents are not editable since they are part of the trace.
+func (rg reqGraph) attributeColumns(as []map[string]string) []string {
+	var names []string
+	seen := map[string]bool{"PARENTS": true}
+	for _, a := range as {
+		if name := a["name"]; !seen[normalizeAttribute(name)] {
+			seen[normalizeAttribute(name)] = true
+			names = append(names, name)
+		}
+	}
+	var others []string
+	for _, r := range rg {
+		for k := range r.Attributes {
+			if !seen[k] {
+				seen[k] = true
+				others = append(others, k)
+			}
+		}
+	}
+	sort.Strings(others)
+	return append(names, others...)
+}
+
+// ExportXlsx writes a spreadsheet to w with one row per requirement and one
+// column per attribute, to be edited and re-imported with ApplyXlsx.
+func (rg reqGraph) ExportXlsx(w io.Writer, as []map[string]string) error {
+	attrs := rg.attributeColumns(as)
+	rows := [][]string{append(append([]string{}, spreadsheetColumns...), attrs...)}
+	var reqs []*Req
+	for _, r := range rg {
+		if r.Level != config.CODE {
+			reqs = append(reqs, r)
+		}
+	}
+	sort.Sort(byIDs(reqs))
+	for _, r := range reqs {
+		row := []string{r.ID, path.Base(r.Path), r.Title}
+		for _, a := range attrs {
+			row = append(row, r.Attributes[normalizeAttribute(a)])
+		}
+		rows = append(rows, row)
+	}
+	return xlsx.Write(w, config.ProjectName, rows)
+}
+
+// ApplyXlsx reads a spreadsheet created by ExportXlsx and updates the
+// attributes which have been changed in the certification documents. All the
+// changes are validated against the attribute specification before any
+// document is modified.
+func (rg reqGraph) ApplyXlsx(fileName string, as []map[string]string) error {
+	repoPath, err := git.RepoPath()
+	if err != nil {
+		return err
+	}
+	f, err := os.Open(fileName)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	rows, err := xlsx.Read(f, info.Size())
+	if err != nil {
+		return fmt.Errorf("Error while reading %s: %v", fileName, err)
+	}
+	if len(rows) == 0 || len(rows[0]) < len(spreadsheetColumns) ||
+		strings.Join(rows[0][:len(spreadsheetColumns)], ",") != strings.Join(spreadsheetColumns, ",") {
+		return fmt.Errorf("%s does not start with the columns %s", fileName, strings.Join(spreadsheetColumns, ", "))
+	}
+	header := rows[0]
+
+	changes := map[string]map[string]map[string]string{} // by file, requirement ID, attribute name
+	var errs []string
+	for i, row := range rows[1:] {
+		id := strings.TrimSpace(row[0])
+		if id == "" {
+			continue
+		}
+		r, ok := rg[id]
+		if !ok || r.Level == config.CODE {
+			errs = append(errs, fmt.Sprintf("Row %d: requirement %s does not exist.\n", i+2, id))
+			continue
+		}
+		for j := len(spreadsheetColumns); j < len(header); j++ {
+			name := strings.TrimSpace(header[j])
+			key := normalizeAttribute(name)
+			v := strings.TrimSpace(row[j])
+			if name == "" || key == "PARENTS" || v == r.Attributes[key] {
+				continue
+			}
+			if !keywordsRegexp().MatchString(name + ":") {
+				errs = append(errs, fmt.Sprintf("Row %d: unknown attribute '%s'.\n", i+2, name))
+				continue
+			}
+			changed := &Req{ID: r.ID, Level: r.Level, Attributes: map[string]string{}}
+			if v != "" {
+				changed.Attributes[key] = v
+			}
+			for _, e := range changed.CheckAttributes(attributeSpec(as, key)) {
+				errs = append(errs, fmt.Sprintf("Row %d: %s", i+2, e.Error()))
+			}
+			p := filepath.Join(repoPath, r.Path)
+			if changes[p] == nil {
+				changes[p] = map[string]map[string]string{}
+			}
+			if changes[p][id] == nil {
+				changes[p][id] = map[string]string{}
+			}
+			changes[p][id][name] = v
+		}
+	}
+	for p := range changes {
+		if strings.ToLower(path.Ext(p)) != ".md" {
+			errs = append(errs, fmt.Sprintf("Cannot update %s: only Markdown documents can be updated.\n", p))
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf(strings.Join(errs, ""))
+	}
+
+	for p, c := range changes {
+		slog.Info("updating requirements", "file", p, "count", len(c))
+		if err := SetMarkdownAttributes(p, c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// attributeSpec returns the specification of the given attribute.
+func attributeSpec(as []map[string]string, key string) []map[string]string {
+	var res []map[string]string
+	for _, a := range as {
+		if normalizeAttribute(a["name"]) == key {
+			res = append(res, a)
+		}
+	}
+	return res
+}
+
+type byIDs []*Req
+
+func (a byIDs) Len() int           { return len(a) }
+func (a byIDs) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+func (a byIDs) Less(i, j int) bool { return a[i].ID < a[j].ID }
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/daedaleanai/reqtraq/config"
+	"github.com/daedaleanai/reqtraq/git"
+)
+
+// Staleness is a requirement and a code file implementing it, with the times
+// they were last modified.
+type Staleness struct {
+	Req      *Req
+	Code     *Req
+	ReqTime  time.Time // The last change of the requirement, see SetBlame.
+	CodeTime time.Time // The last commit changing the code file.
+}
+
+// Drift returns how long after the requirement the code was last modified,
+// negative when the requirement was modified last.
+func (s Staleness) Drift() time.Duration { return s.CodeTime.Sub(s.ReqTime) }
+
+// days returns the duration in whole days.
+func days(d time.Duration) int { return int(d.Hours() / 24) }
+
+// lastCommitTimes returns the time of the last commit changing each of the
+// given files, relative to the repo root, up to the given commit, HEAD when empty.
+func lastCommitTimes(commit string, files []string) (map[string]time.Time, error) {
+	res := map[string]time.Time{}
+	for _, f := range files {
+		commits, err := git.Log(commit, "", f)
+		if err != nil {
+			return nil, err
+		}
+		if len(commits) > 0 {
+			res[f] = commits[0].Time
+		}
+	}
+	return res, nil
+}
+
+// Staleness returns the requirements and the code files implementing them
+// which were last modified more than threshold apart, hinting that one
+// diverged from the other, according to the git history up to the given
+// commit, or up to the working tree when empty. The code modified long after
+// its requirement comes first, the largest drifts first.
+func (rg reqGraph) Staleness(commit string, threshold time.Duration) ([]Staleness, error) {
+	if err := rg.SetBlame(commit); err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, r := range rg {
+		if r.Level == config.CODE {
+			files = append(files, strings.TrimPrefix(r.ID, "/"))
+		}
+	}
+	sort.Strings(files)
+	times, err := lastCommitTimes(commit, files)
+	if err != nil {
+		return nil, err
+	}
+
+	var res []Staleness
+	for _, r := range rg {
+		if r.Level == config.CODE || r.IsDeleted() || r.Blame == nil {
+			continue
+		}
+		for _, c := range r.Children {
+			t, ok := times[strings.TrimPrefix(c.ID, "/")]
+			if c.Level != config.CODE || !ok {
+				continue
+			}
+			s := Staleness{Req: r, Code: c, ReqTime: r.Blame.Time, CodeTime: t}
+			if s.Drift() > threshold || -s.Drift() > threshold {
+				res = append(res, s)
+			}
+		}
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Drift() != res[j].Drift() {
+			return res[i].Drift() > res[j].Drift()
+		}
+		if res[i].Req.ID != res[j].Req.ID {
+			return res[i].Req.ID < res[j].Req.ID
+		}
+		return res[i].Code.ID < res[j].Code.ID
+	})
+	return res, nil
+}
+
+// WriteStalenessMarkdown writes the stale links as a Markdown table.
+func WriteStalenessMarkdown(w io.Writer, stale []Staleness, threshold time.Duration) {
+	fmt.Fprintf(w, "# Staleness\n\n")
+	if len(stale) == 0 {
+		fmt.Fprintf(w, "No requirement and code modified more than %d days apart.\n", days(threshold))
+		return
+	}
+	fmt.Fprintf(w, "Requirements and code modified more than %d days apart.\n\n", days(threshold))
+	fmt.Fprintf(w, "| Requirement | Modified | Code | Modified | Modified last |\n")
+	fmt.Fprintf(w, "|---|---|---|---|---|\n")
+	for _, s := range stale {
+		last := fmt.Sprintf("code, %d days later", days(s.Drift()))
+		if s.Drift() < 0 {
+			last = fmt.Sprintf("requirement, %d days later", days(-s.Drift()))
+		}
+		fmt.Fprintf(w, "| %s | %s | %s | %s | %s |\n", s.Req.ID, s.ReqTime.Format("2006-01-02"),
+			strings.TrimPrefix(s.Code.ID, "/"), s.CodeTime.Format("2006-01-02"), last)
+	}
+}
+
+// WriteStalenessJSON writes the stale links as json, with the drift in days.
+func WriteStalenessJSON(w io.Writer, stale []Staleness) error {
+	type entry struct {
+		Req       string
+		ReqTime   time.Time
+		Code      string
+		CodeTime  time.Time
+		DriftDays int
+	}
+	res := []entry{}
+	for _, s := range stale {
+		res = append(res, entry{s.Req.ID, s.ReqTime, strings.TrimPrefix(s.Code.ID, "/"), s.CodeTime, days(s.Drift())})
+	}
+	b, err := json.MarshalIndent(res, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(b, '\n'))
+	return err
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/daedaleanai/reqtraq/git"
+)
+
+// Stamp identifies what a generated artifact was generated from, so the
+// archived evidence is self-describing for configuration management.
+type Stamp struct {
+	Ref       string // The source commit as described by git, see git.Describe.
+	GraphHash string // See reqGraph.Hash.
+	Generated time.Time
+	Version   string // The version of reqtraq, see reqtraqVersion.
+}
+
+// artifactStamp is the stamp of the artifacts being generated: the HTML
+// reports, the published documents and the CSV files. They are not stamped
+// when it is nil, e.g. in the web app.
+var artifactStamp *Stamp
+
+// stampFuncs allow the report templates to show the stamp in their footer.
+var stampFuncs = template.FuncMap{"stamp": func() *Stamp { return artifactStamp }}
+
+// NewStamp returns the stamp of the artifacts generated now from the given
+// graph of the given commit, the working tree when empty.
+func NewStamp(rg reqGraph, commit string) (*Stamp, error) {
+	hash, err := rg.Hash()
+	if err != nil {
+		return nil, err
+	}
+	ref, err := git.Describe(commit)
+	if err != nil {
+		return nil, err
+	}
+	return &Stamp{Ref: ref, GraphHash: hash, Generated: time.Now().UTC().Truncate(time.Second), Version: reqtraqVersion()}, nil
+}
+
+// String returns the stamp as a sentence, e.g. "Generated by reqtraq v1.4.0
+// from v2.1-3-g1a2b3c4, requirement graph 5f0c..., on 2024-05-01T12:00:00Z".
+func (s *Stamp) String() string {
+	return fmt.Sprintf("Generated by reqtraq %s from %s, requirement graph %s, on %s",
+		s.Version, s.Ref, s.GraphHash, s.Generated.Format(time.RFC3339))
+}
+
+// reLyxPdfSubject matches the PDF subject in the header of a LyX document.
+var reLyxPdfSubject = regexp.MustCompile(`(?m)^\\pdf_subject .*\r?\n`)
+
+// stampDocument returns the given linkified document, in the given format,
+// with the stamp as the subject of the metadata of its PDF: with a YAML
+// metadata block for pandoc, or in the hyperref settings of LyX, which only
+// apply when the document uses hyperref.
+func stampDocument(contents []byte, format string, s *Stamp) []byte {
+	subject := s.String()
+	if format == "md" {
+		var b bytes.Buffer
+		b.Write(bytes.TrimRight(contents, "\n"))
+		// The last metadata block has precedence.
+		fmt.Fprintf(&b, "\n\n---\nsubject: '%s'\n...\n", strings.ReplaceAll(subject, "'", "''"))
+		return b.Bytes()
+	}
+	nl := "\n"
+	if bytes.Contains(contents, []byte("\r\n")) {
+		nl = "\r\n"
+	}
+	line := []byte(`\pdf_subject "` + strings.ReplaceAll(subject, `"`, `'`) + `"` + nl)
+	contents = reLyxPdfSubject.ReplaceAllLiteral(contents, nil)
+	end := bytes.Index(contents, []byte(`\end_header`))
+	if end < 0 {
+		return contents
+	}
+	return append(append(append([]byte{}, contents[:end]...), line...), contents[end:]...)
+}
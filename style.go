@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/daedaleanai/reqtraq/config"
+)
+
+// StyleConfig configures the checks keeping the requirements atomic and
+// testable, see CheckStyle. The checks are disabled by default.
+type StyleConfig struct {
+	// MaxTitleLength is the maximum number of characters of the titles.
+	MaxTitleLength int `yaml:"max_title_length,omitempty"`
+	// MaxBodyWords is the maximum number of words of the bodies.
+	MaxBodyWords int `yaml:"max_body_words,omitempty"`
+	// SingleShall is whether a requirement can state only one "shall".
+	SingleShall bool `yaml:"single_shall,omitempty"`
+	// Forbidden are the regular expressions of the forbidden phrases, e.g.
+	// compound conjunctions such as "and/or", matched ignoring the case.
+	Forbidden []string `yaml:"forbidden,omitempty"`
+}
+
+var (
+	// reShall matches the statements of the requirements.
+	reShall = regexp.MustCompile(`(?i)\bshall\b`)
+	// reHTMLTag matches the tags of the bodies, which are not words.
+	reHTMLTag = regexp.MustCompile(`<[^>]*>`)
+)
+
+// CheckStyle checks the length of the titles and of the bodies of the
+// requirements, that they state a single "shall", and that they do not
+// contain forbidden phrases. The problems are meant as warnings.
+func (rg reqGraph) CheckStyle(c StyleConfig) ([]error, error) {
+	var forbidden []*regexp.Regexp
+	for _, f := range c.Forbidden {
+		re, err := regexp.Compile("(?i)" + f)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid forbidden phrase %q: %v", f, err)
+		}
+		forbidden = append(forbidden, re)
+	}
+	var reqs []*Req
+	for _, r := range rg {
+		if r.Level != config.CODE {
+			reqs = append(reqs, r)
+		}
+	}
+	sort.Sort(byIDs(reqs))
+	var errs []error
+	for _, r := range reqs {
+		body := reHTMLTag.ReplaceAllString(string(r.Body), " ")
+		if n := len([]rune(r.Title)); c.MaxTitleLength > 0 && n > c.MaxTitleLength {
+			errs = append(errs, fmt.Errorf("Requirement '%s' has a title of %d characters, longer than %d.\n", r.ID, n, c.MaxTitleLength))
+		}
+		if n := len(strings.Fields(body)); c.MaxBodyWords > 0 && n > c.MaxBodyWords {
+			errs = append(errs, fmt.Errorf("Requirement '%s' has a body of %d words, longer than %d.\n", r.ID, n, c.MaxBodyWords))
+		}
+		if n := len(reShall.FindAllString(r.Title+"\n"+body, -1)); c.SingleShall && n > 1 {
+			errs = append(errs, fmt.Errorf("Requirement '%s' states %d shall, expected a single one.\n", r.ID, n))
+		}
+		for _, re := range forbidden {
+			if m := re.FindString(r.Title + "\n" + body); m != "" {
+				errs = append(errs, fmt.Errorf("Requirement '%s' contains the forbidden phrase '%s'.\n", r.ID, m))
+			}
+		}
+	}
+	return errs, nil
+}
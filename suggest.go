@@ -0,0 +1,134 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// defaultSuggestTimeout is how long suggest waits for the endpoint by default.
+const defaultSuggestTimeout = 60 * time.Second
+
+// SuggestConfig configures the endpoint drafting the children of the
+// requirements, e.g. an internal language model service, see Suggest. Nothing
+// is sent anywhere when the URL is empty.
+type SuggestConfig struct {
+	// URL is the endpoint the requests are posted to.
+	URL string `yaml:"url,omitempty"`
+	// Token is sent as a bearer token, better given with a variable.
+	Token string `yaml:"token,omitempty"`
+	// TimeoutSeconds is how long to wait for the endpoint, 60 by default.
+	TimeoutSeconds int `yaml:"timeout_seconds,omitempty"`
+	// ExportControlled allows sending the bodies of the EXPORT-CONTROLLED
+	// requirements, e.g. to an endpoint cleared for them. They are omitted
+	// by default, see OmitExportControlled.
+	ExportControlled bool `yaml:"export_controlled,omitempty"`
+}
+
+// suggestReq is a requirement sent to or received from the endpoint.
+type suggestReq struct {
+	ID         string            `json:"id,omitempty"`
+	Title      string            `json:"title"`
+	Body       string            `json:"body,omitempty"`
+	Attributes map[string]string `json:"attributes,omitempty"`
+}
+
+// SuggestRequest is posted to the endpoint: the requirement whose children
+// are drafted, and its existing children, not to be repeated.
+type SuggestRequest struct {
+	Parent   suggestReq   `json:"parent"`
+	Children []suggestReq `json:"children"`
+}
+
+// SuggestResponse is returned by the endpoint: the drafts of the children.
+type SuggestResponse struct {
+	Requirements []suggestReq `json:"requirements"`
+}
+
+// Suggest returns the drafts of children of the given requirement, returned by
+// the configured endpoint for the author to edit. Nothing is sent when offline,
+// and the bodies of the export-controlled requirements are omitted from the
+// graph unless the configuration allows sending them.
+func (rg reqGraph) Suggest(c SuggestConfig, id string) ([]suggestReq, error) {
+	if c.URL == "" {
+		return nil, fmt.Errorf("No suggestion endpoint configured, see suggest in \"reqtraq help config\"")
+	}
+	if *fOffline {
+		return nil, fmt.Errorf("Offline, nothing is sent to the suggestion endpoint")
+	}
+	r, ok := rg[id]
+	if !ok {
+		return nil, fmt.Errorf("Requirement %s not found", id)
+	}
+	if !c.ExportControlled {
+		rg.OmitExportControlled()
+	}
+	req := SuggestRequest{Parent: suggestReq{ID: r.ID, Title: r.Title, Body: string(r.Body), Attributes: r.Attributes}, Children: []suggestReq{}}
+	children := append([]*Req{}, r.Children...)
+	sort.Sort(byIDs(children))
+	for _, c := range children {
+		if !c.IsDeleted() && c.ID != "" && ReReqID.MatchString(c.ID) {
+			req.Children = append(req.Children, suggestReq{ID: c.ID, Title: c.Title})
+		}
+	}
+	b, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	hr, err := http.NewRequest(http.MethodPost, c.URL, bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+	hr.Header.Set("Content-Type", "application/json")
+	if c.Token != "" {
+		hr.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+	timeout := defaultSuggestTimeout
+	if c.TimeoutSeconds > 0 {
+		timeout = time.Duration(c.TimeoutSeconds) * time.Second
+	}
+	resp, err := (&http.Client{Timeout: timeout}).Do(hr)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("The suggestion endpoint returned %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	var res SuggestResponse
+	if err := json.Unmarshal(body, &res); err != nil {
+		return nil, fmt.Errorf("Invalid response of the suggestion endpoint: %v", err)
+	}
+	return res.Requirements, nil
+}
+
+// WriteDrafts writes the drafts of the children of the given requirement as
+// Markdown requirements, with DRAFT IDs to be replaced, see nextid.
+func WriteDrafts(w io.Writer, parent *Req, drafts []suggestReq) {
+	for i, d := range drafts {
+		attributes := map[string]string{"Parents": parent.ID}
+		for k, v := range d.Attributes {
+			if !strings.EqualFold(k, "Parents") {
+				attributes[k] = v
+			}
+		}
+		names := []string{"Parents"}
+		for k := range attributes {
+			if k != "Parents" {
+				names = append(names, k)
+			}
+		}
+		sort.Strings(names[1:])
+		fmt.Fprint(w, FormatMarkdownReq(fmt.Sprintf("DRAFT-%d", i+1), d.Title, d.Body, names, attributes))
+	}
+}
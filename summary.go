@@ -0,0 +1,215 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strings"
+)
+
+// The exit codes of reqtraq.
+const (
+	exitOK       = 0 // The command ran and found no problems.
+	exitFindings = 1 // Problems were found in the requirements, e.g. by precommit.
+	exitUsage    = 2 // The command line is invalid.
+	exitInternal = 3 // The command could not be run, e.g. because of I/O errors.
+)
+
+// findingTypes are the types of the findings reported by the checks, as
+// counted in the --summary-file, see newFinding. The findings of the errors
+// created without a type are counted as "other".
+var findingTypes = []string{
+	"malformed_requirement",
+	"document_structure",
+	"requirement_id",
+	"sequence_number",
+	"duplicate_requirement",
+	"id_reuse",
+	"missing_parent",
+	"invalid_parent",
+	"circular_dependency",
+	"invalid_link",
+	"invalid_reference",
+	"attribute",
+	"owner",
+	"team",
+	"cots",
+	"tag",
+	"coverage",
+	"placeholder",
+	"document_revision",
+	"commit_message",
+	"merge_conflict",
+	"broken_link",
+	"anchor",
+	"waiver",
+}
+
+// findingError is a finding of a check, a problem of the requirements, with
+// its type, one of findingTypes.
+type findingError struct {
+	Type string
+	msg  string
+}
+
+func (e *findingError) Error() string {
+	return e.msg
+}
+
+// newFinding returns the finding of the given type, formatted as by fmt.Errorf.
+func newFinding(typ, format string, a ...interface{}) error {
+	return &findingError{Type: typ, msg: fmt.Sprintf(format, a...)}
+}
+
+// findingType returns the type of the finding, "other" when it has none.
+func findingType(err error) string {
+	var f *findingError
+	if errors.As(err, &f) {
+		return f.Type
+	}
+	return "other"
+}
+
+// findingHeader is a line of the findings reported by a check which is not a
+// finding, e.g. "Problems found while parsing ...".
+type findingHeader string
+
+func (h findingHeader) Error() string {
+	return string(h)
+}
+
+// findingList is the findings reported by one or several checks, one per
+// line, with their headers. They are written as they are, in order, so the
+// findings are given with their newline.
+type findingList []error
+
+func (l findingList) Error() string {
+	var b strings.Builder
+	for _, e := range l {
+		b.WriteString(e.Error())
+	}
+	return b.String()
+}
+
+// add appends the findings of err, if any.
+func (l *findingList) add(err error) {
+	if err != nil {
+		*l = append(*l, err)
+	}
+}
+
+// addLine appends the finding err, if any, with a newline.
+func (l *findingList) addLine(err error) {
+	if err != nil {
+		*l = append(*l, findingLine{err})
+	}
+}
+
+// err returns the list as an error, nil when empty.
+func (l findingList) err() error {
+	if len(l) == 0 {
+		return nil
+	}
+	return l
+}
+
+// findingLine is a finding given without its newline, e.g. by a parser.
+type findingLine struct {
+	error
+}
+
+func (l findingLine) Error() string {
+	return l.error.Error() + "\n"
+}
+
+func (l findingLine) Unwrap() error {
+	return l.error
+}
+
+// indentedFinding is a finding listed below a header.
+type indentedFinding struct {
+	error
+}
+
+func (f indentedFinding) Error() string {
+	return "\t" + f.error.Error() + "\n"
+}
+
+func (f indentedFinding) Unwrap() error {
+	return f.error
+}
+
+// flattenFindings returns the findings and the headers of the error of a
+// check, in order.
+func flattenFindings(err error) findingList {
+	l, ok := err.(findingList)
+	if !ok {
+		if err == nil {
+			return nil
+		}
+		return findingList{err}
+	}
+	var res findingList
+	for _, e := range l {
+		res = append(res, flattenFindings(e)...)
+	}
+	return res
+}
+
+// findingsOf returns the findings of the error of a check, without the headers.
+func findingsOf(err error) []error {
+	var res []error
+	for _, e := range flattenFindings(err) {
+		if _, ok := e.(findingHeader); !ok && strings.TrimSpace(e.Error()) != "" {
+			res = append(res, e)
+		}
+	}
+	return res
+}
+
+// replaceInFindings returns the findings and the headers of the error of a
+// check with old replaced by new in their messages, keeping their types.
+func replaceInFindings(err error, old, new string) error {
+	var res findingList
+	for _, e := range flattenFindings(err) {
+		msg := strings.ReplaceAll(e.Error(), old, new)
+		var f *findingError
+		if _, ok := e.(findingHeader); ok {
+			res = append(res, findingHeader(msg))
+		} else if errors.As(e, &f) {
+			res = append(res, &findingError{Type: f.Type, msg: msg})
+		} else {
+			res = append(res, errors.New(msg))
+		}
+	}
+	return res.err()
+}
+
+// Summary is the machine-readable outcome of a command, written to the --summary-file.
+type Summary struct {
+	Command  string         `json:"command"`
+	ExitCode int            `json:"exit_code"`
+	Findings int            `json:"findings"`
+	Counts   map[string]int `json:"counts"`
+}
+
+// Summarize counts the findings in the given error of a check, by type. The
+// headers such as "Problems found while parsing ..." are not counted.
+func Summarize(command string, exitCode int, findings error) Summary {
+	s := Summary{Command: command, ExitCode: exitCode, Counts: map[string]int{}}
+	for _, f := range findingsOf(findings) {
+		s.Counts[findingType(f)]++
+		s.Findings++
+	}
+	return s
+}
+
+// WriteSummary writes the summary as json to the given file.
+func WriteSummary(fileName string, s Summary) error {
+	b, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(fileName, append(b, '\n'), 0644)
+}
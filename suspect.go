@@ -0,0 +1,258 @@
+package main
+
+import (
+	"io/ioutil"
+	"log/slog"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/daedaleanai/reqtraq/config"
+	"github.com/daedaleanai/reqtraq/git"
+)
+
+// reConfirms matches the commit message lines signing off that the given
+// requirements are still valid after changes of their parents, e.g.
+// "Confirms: REQ-0-DDLN-SWL-003, REQ-0-DDLN-SWL-004".
+var reConfirms = regexp.MustCompile(`(?m)^Confirms:(.*)$`)
+
+// reqHistory records when the requirements changed last.
+type reqHistory struct {
+	modified map[string]time.Time // Any change, or a confirmation.
+	text     map[string]time.Time // A change of the title or of the body.
+}
+
+// record records the changes of the requirements between before and after,
+// made at the given time, unless more recent changes have been recorded.
+func (h reqHistory) record(t time.Time, after, before map[string]*Req) {
+	for id, r := range after {
+		p := before[id]
+		if _, ok := h.modified[id]; !ok && r.ChangedSince(p) != nil {
+			h.modified[id] = t
+		}
+		if _, ok := h.text[id]; !ok && (p == nil || onlyLetters(r.Title) != onlyLetters(p.Title) ||
+			onlyLetters(string(r.Body)) != onlyLetters(string(p.Body))) {
+			h.text[id] = t
+		}
+	}
+}
+
+// readCertdocReqs returns the requirements defined in the given certification
+// documents, relative to the repo root, with the given contents.
+func readCertdocReqs(files map[string][]byte) (map[string]*Req, error) {
+	dir, err := ioutil.TempDir("", "reqtraq")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+	res := map[string]*Req{}
+	for f, contents := range files {
+		if isGeneratedContents(f, contents) {
+			continue
+		}
+		fileName := filepath.Join(dir, path.Base(f))
+		if err := ioutil.WriteFile(fileName, contents, 0644); err != nil {
+			return nil, err
+		}
+		reqs, err := ParseCertdoc(fileName)
+		if err != nil {
+			// The documents which cannot be parsed are not part of the history.
+			continue
+		}
+		for _, txt := range reqs {
+			if r, err := ParseReq(txt); err == nil {
+				res[r.ID] = r
+			}
+		}
+		os.Remove(fileName)
+	}
+	return res, nil
+}
+
+// readCertdocsAt returns the requirements defined at the given commit in the
+// given certification documents, ignoring those which do not exist.
+func readCertdocsAt(commit, certdocPath string, docs []string) (map[string]*Req, error) {
+	existing, err := git.ListFiles(commit, certdocRoots(certdocPath)...)
+	if err != nil {
+		// E.g. the parent of the first commit.
+		return map[string]*Req{}, nil
+	}
+	exists := map[string]bool{}
+	for _, f := range existing {
+		exists[f] = true
+	}
+	var found []string
+	for _, f := range docs {
+		if exists[f] {
+			found = append(found, f)
+		}
+	}
+	files := map[string][]byte{}
+	err = git.ReadFiles(commit, found, func(f string, contents []byte) error {
+		files[f] = contents
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return readCertdocReqs(files)
+}
+
+// changedCertdocs returns the certification documents among the given files.
+func changedCertdocs(files []string, certdocPath string) []string {
+	var res []string
+	for _, f := range files {
+		if IsValidDocName(f) != nil {
+			continue
+		}
+		for _, root := range certdocRoots(certdocPath) {
+			prefix := strings.Trim(filepath.ToSlash(root), "/")
+			if prefix == "" || strings.HasPrefix(f, prefix+"/") {
+				res = append(res, f)
+				break
+			}
+		}
+	}
+	return res
+}
+
+// history returns when the requirements changed last, up to the given
+// commit, or up to the working tree when empty. The history of the
+// certification documents is walked back until the last change of the text
+// of each requirement is found.
+func (rg reqGraph) history(commit, certdocPath string) (reqHistory, error) {
+	h := reqHistory{modified: map[string]time.Time{}, text: map[string]time.Time{}}
+	repoPath, err := git.RepoPath()
+	if err != nil {
+		return h, err
+	}
+	pending := map[string]bool{}
+	for id, r := range rg {
+		if r.Level != config.CODE {
+			pending[id] = true
+		}
+	}
+	done := func() bool {
+		for id := range pending {
+			if _, ok := h.text[id]; ok {
+				delete(pending, id)
+			}
+		}
+		return len(pending) == 0
+	}
+
+	if commit == "" {
+		changed, _, err := git.FilesChanged("HEAD")
+		if err != nil {
+			return h, err
+		}
+		docs := changedCertdocs(changed, certdocPath)
+		files := map[string][]byte{}
+		for _, f := range docs {
+			contents, err := ioutil.ReadFile(filepath.Join(repoPath, filepath.FromSlash(f)))
+			if err != nil {
+				return h, err
+			}
+			files[f] = contents
+		}
+		after, err := readCertdocReqs(files)
+		if err != nil {
+			return h, err
+		}
+		before, err := readCertdocsAt("HEAD", certdocPath, docs)
+		if err != nil {
+			return h, err
+		}
+		h.record(time.Now(), after, before)
+	}
+
+	commits, err := git.Log(commit, "", certdocRoots(certdocPath)...)
+	if err != nil {
+		return h, err
+	}
+	for _, c := range commits {
+		if done() {
+			break
+		}
+		changed, err := git.FilesChangedInCommit(c.ID)
+		if err != nil {
+			return h, err
+		}
+		docs := changedCertdocs(changed, certdocPath)
+		after, err := readCertdocsAt(c.ID, certdocPath, docs)
+		if err != nil {
+			return h, err
+		}
+		before, err := readCertdocsAt(c.ID+"^", certdocPath, docs)
+		if err != nil {
+			return h, err
+		}
+		h.record(c.Time, after, before)
+	}
+
+	// The signoffs confirm the requirements as much as changing them.
+	signoffs, err := git.Log(commit, "^Confirms:")
+	if err != nil {
+		return h, err
+	}
+	for _, c := range signoffs {
+		for _, m := range reConfirms.FindAllStringSubmatch(c.Message, -1) {
+			for _, id := range ReReqID.FindAllString(m[1], -1) {
+				if c.Time.After(h.modified[id]) {
+					h.modified[id] = c.Time
+				}
+			}
+		}
+	}
+	return h, nil
+}
+
+// MarkSuspectLinks sets the Suspect parents of the requirements: those whose
+// title or body changed, according to the git history up to the given commit
+// or up to the working tree when empty, after the requirement was last
+// modified. Any change of the requirement clears the mark, e.g. of its
+// Confirmed attribute, as does a commit message with a "Confirms: <ID>" line.
+func (rg reqGraph) MarkSuspectLinks(commit, certdocPath string) error {
+	h, err := rg.history(commit, certdocPath)
+	if err != nil {
+		return err
+	}
+	for _, r := range rg {
+		r.Suspect = nil
+		if r.Level == config.CODE || r.IsDeleted() {
+			continue
+		}
+		for _, p := range r.Parents {
+			if h.text[p.ID].After(h.modified[r.ID]) {
+				r.Suspect = append(r.Suspect, p)
+			}
+		}
+	}
+	return nil
+}
+
+// markSuspectLinks marks the suspect links of the graph built by buildGraph
+// for the given commit, except for snapshots which have no history.
+func markSuspectLinks(rg reqGraph, commit string) error {
+	if commit == "" && *fSnapshot != "" {
+		slog.Warn("the snapshots have no history, the suspect links are not marked")
+		return nil
+	}
+	return rg.MarkSuspectLinks(commit, *fCertdocPath)
+}
+
+// WithSuspectLinks returns the requirements having suspect links, by position.
+func (rg reqGraph) WithSuspectLinks() []*Req {
+	var res []*Req
+	for _, r := range rg {
+		if len(r.Suspect) > 0 {
+			res = append(res, r)
+		}
+	}
+	sort.Sort(byPosition(res))
+	return res
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/daedaleanai/reqtraq/config"
+)
+
+// Tags returns the comma separated tags in the TAGS attribute of the
+// requirement, e.g. "Tags: navigation, datalink", to slice the graph by
+// feature area independently of the documents.
+func (r *Req) Tags() []string { return r.listAttribute("TAGS") }
+
+// hasTag returns whether one of the tags of the requirement matches the tag filter.
+func (r *Req) hasTag(e *regexp.Regexp) bool {
+	for _, t := range r.Tags() {
+		if e.MatchString(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// tagFilter returns the TagFilter matching any of the given comma separated tags.
+func tagFilter(tags string) *regexp.Regexp {
+	var alternatives []string
+	for _, t := range splitList(tags) {
+		alternatives = append(alternatives, regexp.QuoteMeta(t))
+	}
+	return regexp.MustCompile("^(?:" + strings.Join(alternatives, "|") + ")$")
+}
+
+// Tagged returns the requirements having one of the tags matching the tag filter, by ID.
+func (rg reqGraph) Tagged(e *regexp.Regexp) []*Req {
+	var res []*Req
+	for _, r := range rg {
+		if r.Level != config.CODE && r.hasTag(e) {
+			res = append(res, r)
+		}
+	}
+	sort.Sort(byIDs(res))
+	return res
+}
+
+// CheckTags checks that the tags of the requirements are among the allowed
+// ones, see RepoConfig.Tags.
+func (rg reqGraph) CheckTags(allowed []string) []error {
+	known := map[string]bool{}
+	for _, t := range allowed {
+		known[t] = true
+	}
+	var reqs []*Req
+	for _, r := range rg {
+		if r.Level != config.CODE {
+			reqs = append(reqs, r)
+		}
+	}
+	sort.Sort(byIDs(reqs))
+	var errs []error
+	for _, r := range reqs {
+		for _, t := range r.Tags() {
+			if !known[t] {
+				errs = append(errs, newFinding("tag", "Requirement '%s' has unknown tag '%s'. Expected one of %s.\n", r.ID, t, strings.Join(allowed, ", ")))
+			}
+		}
+	}
+	return errs
+}
+
+// Tags returns the tags used by the requirements, sorted.
+func (rg reqGraph) Tags() []string {
+	seen := map[string]bool{}
+	var res []string
+	for _, r := range rg {
+		for _, t := range r.Tags() {
+			if !seen[t] {
+				seen[t] = true
+				res = append(res, t)
+			}
+		}
+	}
+	sort.Strings(res)
+	return res
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/daedaleanai/reqtraq/config"
+)
+
+// Target is a deliverable binary or library, or a test, defined in a Bazel
+// BUILD file or in a CMakeLists.txt.
+type Target struct {
+	Name    string   // e.g. //nav:speed for Bazel, speed for CMake.
+	Kind    string   // The rule, e.g. cc_binary or add_library.
+	Sources []string // Relative to the repo root.
+}
+
+var (
+	// reBazelRule matches the start of the rules building binaries, libraries and tests.
+	reBazelRule = regexp.MustCompile(`(?m)^\s*(\w+_(?:binary|library|test))\(`)
+	// reBazelName and reBazelSrcs match the name and the lists of sources of a rule.
+	reBazelName = regexp.MustCompile(`\bname\s*=\s*"([^"]+)"`)
+	reBazelSrcs = regexp.MustCompile(`\b(?:srcs|hdrs)\s*=\s*\[([^\]]*)\]`)
+	reString    = regexp.MustCompile(`"([^"]+)"`)
+	// reCMakeTarget matches the commands defining the executables and the libraries.
+	reCMakeTarget = regexp.MustCompile(`(?is)\b(add_executable|add_library)\s*\(([^)]*)\)`)
+	// reCMakeKeyword matches the options of add_executable and add_library.
+	reCMakeKeyword = regexp.MustCompile(`^(STATIC|SHARED|MODULE|OBJECT|INTERFACE|EXCLUDE_FROM_ALL|WIN32|MACOSX_BUNDLE|ALIAS|IMPORTED|GLOBAL)$`)
+)
+
+// parseBazelTargets returns the targets of the given BUILD file, in the
+// given package, relative to the repo root. Only the sources listed as
+// strings are found, not those found with glob.
+func parseBazelTargets(pkg string, contents string) []Target {
+	var res []Target
+	starts := reBazelRule.FindAllStringSubmatchIndex(contents, -1)
+	for i, m := range starts {
+		end := len(contents)
+		if i+1 < len(starts) {
+			end = starts[i+1][0]
+		}
+		rule := contents[m[1]:end]
+		name := reBazelName.FindStringSubmatch(rule)
+		if name == nil {
+			continue
+		}
+		t := Target{Name: "//" + pkg + ":" + name[1], Kind: contents[m[2]:m[3]]}
+		for _, srcs := range reBazelSrcs.FindAllStringSubmatch(rule, -1) {
+			for _, s := range reString.FindAllStringSubmatch(srcs[1], -1) {
+				if strings.HasPrefix(s[1], ":") || strings.HasPrefix(s[1], "//") {
+					// Another target.
+					continue
+				}
+				t.Sources = append(t.Sources, path.Join(pkg, s[1]))
+			}
+		}
+		res = append(res, t)
+	}
+	return res
+}
+
+// parseCMakeTargets returns the targets of the given CMakeLists.txt, in the
+// given directory, relative to the repo root. The sources given with
+// variables are not found.
+func parseCMakeTargets(dir string, contents string) []Target {
+	var res []Target
+	for _, m := range reCMakeTarget.FindAllStringSubmatch(contents, -1) {
+		args := strings.Fields(m[2])
+		if len(args) == 0 {
+			continue
+		}
+		t := Target{Name: strings.Trim(args[0], `"`), Kind: strings.ToLower(m[1])}
+		for _, a := range args[1:] {
+			a = strings.Trim(a, `"`)
+			if reCMakeKeyword.MatchString(a) || strings.Contains(a, "${") || strings.Contains(a, "$<") {
+				continue
+			}
+			t.Sources = append(t.Sources, path.Join(dir, a))
+		}
+		res = append(res, t)
+	}
+	return res
+}
+
+// ReadTargets returns the targets defined in the Bazel BUILD files and in
+// the CMakeLists.txt files of the repo, sorted by name.
+func ReadTargets(repoPath string) ([]Target, error) {
+	var res []Target
+	err := filepath.Walk(repoPath, func(fileName string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if strings.HasPrefix(info.Name(), ".") || strings.HasPrefix(info.Name(), "bazel-") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		base := info.Name()
+		if base != "BUILD" && base != "BUILD.bazel" && base != "CMakeLists.txt" {
+			return nil
+		}
+		b, err := ioutil.ReadFile(fileName)
+		if err != nil {
+			return err
+		}
+		dir := relativePathToRepo(filepath.Dir(fileName), repoPath)
+		if base == "CMakeLists.txt" {
+			res = append(res, parseCMakeTargets(dir, string(b))...)
+		} else {
+			res = append(res, parseBazelTargets(dir, string(b))...)
+		}
+		return nil
+	})
+	sort.Slice(res, func(i, j int) bool { return res[i].Name < res[j].Name })
+	return res, err
+}
+
+// TargetReqs are the requirements implemented by the sources of a target.
+type TargetReqs struct {
+	Target Target
+	Code   []*Req // The code files of the target referencing requirements, by ID.
+	Reqs   []*Req // The requirements implemented by the code and their ancestors, by ID.
+}
+
+// ReqsByTarget returns the requirements implemented by each of the targets,
+// to scope the verification per deliverable.
+func (rg reqGraph) ReqsByTarget(targets []Target) []TargetReqs {
+	code := map[string]*Req{}
+	for _, r := range rg {
+		if r.Level == config.CODE {
+			code[strings.TrimPrefix(r.ID, "/")] = r
+		}
+	}
+	var res []TargetReqs
+	for _, t := range targets {
+		tr := TargetReqs{Target: t}
+		seen := map[*Req]bool{}
+		var add func(r *Req)
+		add = func(r *Req) {
+			if seen[r] {
+				return
+			}
+			seen[r] = true
+			tr.Reqs = append(tr.Reqs, r)
+			for _, p := range r.Parents {
+				add(p)
+			}
+		}
+		for _, s := range t.Sources {
+			c, ok := code[s]
+			if !ok || seen[c] {
+				continue
+			}
+			seen[c] = true
+			tr.Code = append(tr.Code, c)
+			for _, p := range c.Parents {
+				add(p)
+			}
+		}
+		sort.Sort(byIDs(tr.Code))
+		sort.Sort(byIDs(tr.Reqs))
+		res = append(res, tr)
+	}
+	return res
+}
@@ -20,6 +20,9 @@ func getApiToken() (string, error) {
 	if cachedApiToken != "" {
 		return cachedApiToken, nil
 	}
+	if APIToken != "" {
+		return APIToken, nil
+	}
 
 	// The Phabricator API token comes from https://p.daedalean.ai/settings/user/git/page/apitokens/ (the already
 	// generated Standard Api Token is good to use
@@ -33,6 +33,9 @@ func (tmgr *PhabricatorTaskManager) getApiToken() (string, error) {
 	if tmgr.cachedApiToken != "" {
 		return tmgr.cachedApiToken, nil
 	}
+	if APIToken != "" {
+		return APIToken, nil
+	}
 
 	// The Phabricator API token comes from https://p.daedalean.ai/settings/user/git/page/apitokens/ (the already
 	// generated Standard Api Token is good to use
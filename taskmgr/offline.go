@@ -0,0 +1,37 @@
+package taskmgr
+
+import "errors"
+
+// ErrOffline is returned by all the methods of OfflineTaskManager.
+var ErrOffline = errors.New("the task manager cannot be reached in offline mode")
+
+// OfflineTaskManager is the task manager used when there is no network access. It does not know any task.
+type OfflineTaskManager struct{}
+
+func (OfflineTaskManager) GetProject(name string) (string, error) { return "", ErrOffline }
+
+func (OfflineTaskManager) CreateProject(name, parentID string) (string, error) { return "", ErrOffline }
+
+func (OfflineTaskManager) GetOrCreateProject(name, parentID string) (string, error) {
+	return "", ErrOffline
+}
+
+func (OfflineTaskManager) FindTaskByID(id string) (*Task, error) { return nil, ErrOffline }
+
+func (OfflineTaskManager) FindTaskByTitle(taskTitle, projectID string) (*Task, error) {
+	return nil, ErrOffline
+}
+
+func (OfflineTaskManager) FindTask(requirementID, requirementTitle, projectID string) (*Task, error) {
+	return nil, ErrOffline
+}
+
+func (OfflineTaskManager) UpdateTask(taskID, title, taskBody, projectID string, attributes map[string]string, parentTaskIDs []string) error {
+	return ErrOffline
+}
+
+func (OfflineTaskManager) DeleteTask(taskID, title, projectID string) error { return ErrOffline }
+
+func (OfflineTaskManager) CreateTask(title, taskBody, projectID string, attributes map[string]string, parentTaskIDs []string) (string, error) {
+	return "", ErrOffline
+}
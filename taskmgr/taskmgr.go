@@ -2,6 +2,10 @@
 // We expect specific implementation of this interface to be marked with a build tag (see maniphest.go for more details)
 package taskmgr
 
+// APIToken is the token authenticating with the task manager, e.g. set in reqtraq.yaml. When empty,
+// the implementations look for it themselves, e.g. in the git config.
+var APIToken string
+
 // Task represents a single task in Maniphest, JIRA, Bugzilla, etc.
 type Task struct {
 	ID string
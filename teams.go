@@ -0,0 +1,247 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/daedaleanai/reqtraq/config"
+	"github.com/daedaleanai/reqtraq/git"
+)
+
+// codeOwnersPaths are the locations of the CODEOWNERS file in the repository,
+// in the order GitHub looks for it.
+var codeOwnersPaths = []string{".github/CODEOWNERS", "CODEOWNERS", "docs/CODEOWNERS"}
+
+// CodeOwners are the rules of a CODEOWNERS file, for example:
+//
+//	certdocs/*-SRD.md    @daedalean/systems
+//	/src/display/        @daedalean/display @daedalean/hmi
+type CodeOwners struct {
+	rules []codeOwnersRule
+}
+
+type codeOwnersRule struct {
+	pattern *regexp.Regexp
+	owners  []string
+}
+
+// ReadCodeOwners reads the first CODEOWNERS file found in the repository, see
+// codeOwnersPaths. The error satisfies os.IsNotExist when there is none.
+func ReadCodeOwners(repoPath string) (*CodeOwners, error) {
+	var err error
+	for _, p := range codeOwnersPaths {
+		var f *os.File
+		if f, err = os.Open(filepath.Join(repoPath, p)); err == nil {
+			defer f.Close()
+			return parseCodeOwners(f, p)
+		}
+		if !os.IsNotExist(err) {
+			return nil, err
+		}
+	}
+	return nil, err
+}
+
+func parseCodeOwners(f io.Reader, fileName string) (*CodeOwners, error) {
+	var co CodeOwners
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		l := scanner.Text()
+		if i := strings.Index(l, "#"); i >= 0 {
+			l = l[:i]
+		}
+		fields := strings.Fields(l)
+		if len(fields) == 0 {
+			continue
+		}
+		pattern, err := codeOwnersPattern(fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("Invalid pattern %q on line %d of %s: %v", fields[0], line, fileName, err)
+		}
+		co.rules = append(co.rules, codeOwnersRule{pattern, fields[1:]})
+	}
+	return &co, scanner.Err()
+}
+
+// codeOwnersPattern returns the regular expression matching the paths, relative
+// to the repository root, matched by the given gitignore style pattern.
+func codeOwnersPattern(pattern string) (*regexp.Regexp, error) {
+	anchored := strings.Contains(strings.TrimSuffix(pattern, "/"), "/")
+	pattern = strings.Trim(pattern, "/")
+	var b strings.Builder
+	if !anchored {
+		b.WriteString("^(.*/)?")
+	} else {
+		b.WriteString("^")
+	}
+	for i := 0; i < len(pattern); i++ {
+		switch {
+		case strings.HasPrefix(pattern[i:], "**/"):
+			b.WriteString("(.*/)?")
+			i += 2
+		case strings.HasPrefix(pattern[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case pattern[i] == '*':
+			b.WriteString("[^/]*")
+		case pattern[i] == '?':
+			b.WriteString("[^/]")
+		default:
+			b.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+		}
+	}
+	// A directory owns the files under it.
+	b.WriteString("(/.*)?$")
+	return regexp.Compile(b.String())
+}
+
+// repoRelative returns the path of a requirement, see Req.Path, relative to
+// the repository root.
+func repoRelative(path string) string {
+	if filepath.IsAbs(path) {
+		if repoPath, err := git.RepoPath(); err == nil {
+			if rel := relativePathToRepo(path, repoPath); rel != "" {
+				return rel
+			}
+		}
+	}
+	return strings.TrimPrefix(filepath.ToSlash(path), "/")
+}
+
+// Owners returns the owners of the given path of a requirement, those of the
+// last matching rule.
+func (co *CodeOwners) Owners(path string) []string {
+	path = repoRelative(path)
+	for i := len(co.rules) - 1; i >= 0; i-- {
+		if co.rules[i].pattern.MatchString(path) {
+			return co.rules[i].owners
+		}
+	}
+	return nil
+}
+
+// SetTeams sets the Teams of the requirements: the owners of their certification
+// document and of the code files implementing them.
+func (rg reqGraph) SetTeams(co *CodeOwners) {
+	for _, r := range rg {
+		teams := map[string]bool{}
+		for _, t := range co.Owners(r.Path) {
+			teams[t] = true
+		}
+		for _, c := range r.Children {
+			if c.Level != config.CODE {
+				continue
+			}
+			for _, t := range co.Owners(c.Path) {
+				teams[t] = true
+			}
+		}
+		r.Teams = nil
+		for t := range teams {
+			r.Teams = append(r.Teams, t)
+		}
+		sort.Strings(r.Teams)
+	}
+}
+
+// setTeams sets the Teams of the requirements from the CODEOWNERS file of the
+// repository, if any, and returns whether there is one.
+func setTeams(rg reqGraph) (bool, error) {
+	repoPath, err := git.RepoPath()
+	if err != nil {
+		return false, err
+	}
+	co, err := ReadCodeOwners(repoPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	rg.SetTeams(co)
+	return true, nil
+}
+
+// hasTeam returns whether one of the teams of the requirement matches the given
+// filter, see tagFilter.
+func (r *Req) hasTeam(filter *regexp.Regexp) bool {
+	for _, t := range r.Teams {
+		if filter.MatchString(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// OwnedByTeam returns the requirements owned by one of the teams matching the
+// given filter, see tagFilter, by ID.
+func (rg reqGraph) OwnedByTeam(filter *regexp.Regexp) []*Req {
+	var res []*Req
+	for _, r := range rg {
+		if r.Level != config.CODE && r.hasTeam(filter) {
+			res = append(res, r)
+		}
+	}
+	sort.Sort(byIDs(res))
+	return res
+}
+
+// CheckTeams checks that every requirement is owned by at least one team, see
+// SetTeams.
+func (rg reqGraph) CheckTeams() []error {
+	var reqs []*Req
+	for _, r := range rg {
+		if r.Level != config.CODE && !r.IsDeleted() && len(r.Teams) == 0 {
+			reqs = append(reqs, r)
+		}
+	}
+	sort.Sort(byIDs(reqs))
+	var errs []error
+	for _, r := range reqs {
+		errs = append(errs, newFinding("team", "Requirement '%s' in %s has no owner team in CODEOWNERS.\n", r.ID, r.Path))
+	}
+	return errs
+}
+
+// ByTeam returns the requirements grouped by team, see SetTeams, sorted by team
+// name, followed by the requirements without team, or nothing when no
+// requirement has a team.
+func (rg reqGraph) ByTeam() []Ownership {
+	byTeam := map[string][]*Req{}
+	owned := false
+	for _, r := range rg {
+		if r.Level == config.CODE || r.IsDeleted() {
+			continue
+		}
+		teams := r.Teams
+		if len(teams) == 0 {
+			teams = []string{""}
+		}
+		for _, t := range teams {
+			byTeam[t] = append(byTeam[t], r)
+			owned = owned || t != ""
+		}
+	}
+	if !owned {
+		return nil
+	}
+	var res []Ownership
+	for t, reqs := range byTeam {
+		sort.Sort(byIDs(reqs))
+		res = append(res, Ownership{t, reqs})
+	}
+	sort.Slice(res, func(i, j int) bool {
+		// The requirements without team go last.
+		if res[i].Owner == "" || res[j].Owner == "" {
+			return res[j].Owner == ""
+		}
+		return res[i].Owner < res[j].Owner
+	})
+	return res
+}
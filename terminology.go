@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/daedaleanai/reqtraq/config"
+)
+
+// termRegexp returns the regexp matching the given term, ignoring the case
+// and the spacing.
+func termRegexp(term string) *regexp.Regexp {
+	words := strings.Fields(term)
+	for i, w := range words {
+		words[i] = regexp.QuoteMeta(w)
+	}
+	return regexp.MustCompile(`(?i)\b` + strings.Join(words, `\s+`) + `\b`)
+}
+
+// CheckTerminology checks that the requirements use the canonical terms of
+// the given terminology, which maps the canonical terms to their synonyms,
+// e.g. "airspeed sensor" to "ADS probe" and "pitot unit". The problems are
+// meant as warnings.
+func (rg reqGraph) CheckTerminology(terminology map[string][]string) []error {
+	type synonym struct {
+		re        *regexp.Regexp
+		canonical string
+	}
+	var canonicals []string
+	for c := range terminology {
+		canonicals = append(canonicals, c)
+	}
+	sort.Strings(canonicals)
+	var synonyms []synonym
+	for _, c := range canonicals {
+		for _, s := range terminology[c] {
+			synonyms = append(synonyms, synonym{termRegexp(s), c})
+		}
+	}
+	if len(synonyms) == 0 {
+		return nil
+	}
+	var reqs []*Req
+	for _, r := range rg {
+		if r.Level != config.CODE && !r.IsDeleted() {
+			reqs = append(reqs, r)
+		}
+	}
+	sort.Sort(byIDs(reqs))
+	var errs []error
+	for _, r := range reqs {
+		text := r.Title + "\n" + reHTMLTag.ReplaceAllString(string(r.Body), " ")
+		for _, s := range synonyms {
+			if m := s.re.FindString(text); m != "" {
+				errs = append(errs, fmt.Errorf("Requirement '%s' uses '%s' instead of '%s'.\n", r.ID, m, s.canonical))
+			}
+		}
+	}
+	return errs
+}
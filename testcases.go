@@ -0,0 +1,244 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/daedaleanai/reqtraq/config"
+)
+
+// TestCase is a GoogleTest or Catch2 test case of a C or C++ test file, or a
+// test function of a Go test file, with the requirements it verifies.
+type TestCase struct {
+	Name string   // E.g. "Parser.RejectsLongLines", the Catch2 name or "TestParser".
+	Line int      // The line of the TEST or TEST_CASE macro, or of the func.
+	IDs  []string // The IDs of the tags of the test case, see testCaseReader.
+	// Result is "pass" or "fail" when the result of the test case is known,
+	// see SetTestResults.
+	Result string `json:",omitempty"`
+}
+
+var (
+	// reTestsReference matches the tags of the C, C++ and Go test files,
+	// which can also be @tests, e.g. "// @tests @llr REQ-0-DDLN-SWL-001" or
+	// "// @tests REQ-0-DDLN-SWH-001".
+	reTestsReference = regexp.MustCompile(`//\s*@(?:tests\s+@llr|tests|llr)\s*(` + reReqIdStr + `(?:(?:\s*,\s*|\s+)` + reReqIdStr + `)*).*`)
+	// reCTestCase matches the macros starting a GoogleTest test case, e.g.
+	// "TEST_F(Parser, RejectsLongLines) {", or a Catch2 test case, e.g.
+	// `TEST_CASE("Long lines are rejected", "[parser]") {`.
+	reCTestCase = regexp.MustCompile(`^\s*(?:(?:TEST|TEST_F|TEST_P|TYPED_TEST|TYPED_TEST_P)\s*\(\s*(\w+)\s*,\s*(\w+)\s*\)|` +
+		`(?:TEST_CASE|SCENARIO|TEST_CASE_METHOD|TEMPLATE_TEST_CASE|TEMPLATE_PRODUCT_TEST_CASE)\s*\(\s*(?:\w+\s*,\s*)?"((?:[^"\\]|\\.)*)")`)
+	// reCTestCaseHead matches the start of the macros of reCTestCase, whose
+	// arguments can continue on the next lines.
+	reCTestCaseHead = regexp.MustCompile(`^\s*(?:TEST|TEST_F|TEST_P|TYPED_TEST|TYPED_TEST_P|TEST_CASE|SCENARIO|TEST_CASE_METHOD|TEMPLATE_TEST_CASE|TEMPLATE_PRODUCT_TEST_CASE)\s*\(`)
+	// reGoTestCase matches the functions of the Go tests, e.g.
+	// "func TestParser(t *testing.T) {".
+	reGoTestCase = regexp.MustCompile(`^func\s+(Test\w*)\s*\(\s*\w+\s+\*testing\.T\s*\)`)
+)
+
+// isCTestFile returns whether the file is a C or C++ test file, by its name,
+// e.g. parser_test.cc or test_parser.cpp.
+func isCTestFile(fileName string) bool {
+	switch strings.ToLower(path.Ext(fileName)) {
+	case ".c", ".cc", ".cpp", ".cxx":
+		return reTestFile.MatchString(filepath.Base(fileName))
+	}
+	return false
+}
+
+// isGoTestFile returns whether the file is a Go test file, e.g. parser_test.go.
+func isGoTestFile(fileName string) bool {
+	return strings.HasSuffix(fileName, "_test.go")
+}
+
+// testCaseReader finds the test cases of the lines of a C, C++ or Go test file
+// and assigns them the IDs of the tags in the comments immediately above them
+// or in their body. The tags above the first test case, but those immediately
+// above it, are those of the file, assigned to all the test cases.
+type testCaseReader struct {
+	cases   []TestCase
+	file    []string // The IDs of the tags of the file.
+	pending []string // The IDs of the tags of the current comment block.
+	// head is the start of a macro of a test case whose arguments continue
+	// on the next lines, read from the line headLine, with the IDs headIDs.
+	head     string
+	headLine int
+	headIDs  []string
+}
+
+// line reads the next line, with the IDs of its tags.
+func (t *testCaseReader) line(lno int, line string, ids []string) {
+	if m := reGoTestCase.FindStringSubmatch(line); m != nil {
+		t.cases = append(t.cases, TestCase{Name: m[1], Line: lno})
+		t.add(t.pending)
+		t.add(ids)
+		t.pending = nil
+		return
+	}
+	if t.head != "" || reCTestCaseHead.MatchString(line) {
+		if t.head == "" {
+			t.headLine = lno
+		}
+		t.head += line + "\n"
+		t.headIDs = append(t.headIDs, ids...)
+		if m := reCTestCase.FindStringSubmatch(t.head); m != nil {
+			name := m[3]
+			if m[1] != "" {
+				name = m[1] + "." + m[2]
+			}
+			t.cases = append(t.cases, TestCase{Name: name, Line: t.headLine})
+			t.add(t.pending)
+			t.add(t.headIDs)
+			t.pending, t.head, t.headIDs = nil, "", nil
+			return
+		}
+		if !strings.ContainsAny(line, "{;") {
+			return
+		}
+		// Not a test case, e.g. a call of a TEST macro defined by the file.
+		ids, t.head, t.headIDs = t.headIDs, "", nil
+	}
+	if isCommentLine(line) {
+		t.pending = append(t.pending, ids...)
+		return
+	}
+	// The comment block is in the body of the current test case, or ends it.
+	t.add(t.pending)
+	t.pending = nil
+	t.add(ids)
+}
+
+// add adds the given IDs to the current test case, or to the file before the
+// first test case, once.
+func (t *testCaseReader) add(ids []string) {
+	if len(t.cases) == 0 {
+		t.file = appendNew(t.file, ids)
+		return
+	}
+	c := &t.cases[len(t.cases)-1]
+	c.IDs = appendNew(c.IDs, ids)
+}
+
+// appendNew appends to ids those of the given IDs which it does not contain.
+func appendNew(ids, more []string) []string {
+next:
+	for _, id := range more {
+		for _, known := range ids {
+			if known == id {
+				continue next
+			}
+		}
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// testCases returns the test cases read, after the last line, with the IDs
+// of the file first.
+func (t *testCaseReader) testCases() []TestCase {
+	t.add(t.headIDs)
+	t.add(t.pending)
+	t.pending, t.head, t.headIDs = nil, "", nil
+	for i, c := range t.cases {
+		t.cases[i].IDs = appendNew(append([]string{}, t.file...), c.IDs)
+	}
+	return t.cases
+}
+
+// TestCaseTrace is a low-level requirement with the test cases verifying it.
+type TestCaseTrace struct {
+	Req       *Req
+	TestCases []TestCaseRef
+}
+
+// TestCaseRef is a test case of a test file.
+type TestCaseRef struct {
+	File string // The test file, relative to the repo root.
+	TestCase
+}
+
+// TestCaseMatrix returns the low-level requirements, sorted by ID, with the
+// test cases of the C, C++ and Go test files tagged with their ID, sorted by file
+// and line, including those without test case.
+func (rg reqGraph) TestCaseMatrix() []TestCaseTrace {
+	var res []TestCaseTrace
+	for _, r := range rg {
+		if r.Level != config.LOW || r.IsDeleted() {
+			continue
+		}
+		t := TestCaseTrace{Req: r}
+		for _, c := range r.Children {
+			for _, tc := range c.TestCases {
+				for _, id := range tc.IDs {
+					if id == r.ID {
+						t.TestCases = append(t.TestCases, TestCaseRef{File: strings.TrimPrefix(c.ID, "/"), TestCase: tc})
+					}
+				}
+			}
+		}
+		sort.Slice(t.TestCases, func(i, j int) bool {
+			a, b := t.TestCases[i], t.TestCases[j]
+			if a.File != b.File {
+				return a.File < b.File
+			}
+			return a.Line < b.Line
+		})
+		res = append(res, t)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Req.ID < res[j].Req.ID })
+	return res
+}
+
+// WriteTestCaseMatrixMarkdown writes the matrix as a Markdown table, a row per
+// requirement and test case.
+func WriteTestCaseMatrixMarkdown(w io.Writer, matrix []TestCaseTrace) {
+	fmt.Fprintf(w, "# Test cases\n\n")
+	if len(matrix) == 0 {
+		fmt.Fprintf(w, "No low-level requirement.\n")
+		return
+	}
+	fmt.Fprintf(w, "| Requirement | Title | Test case | Location |\n")
+	fmt.Fprintf(w, "|---|---|---|---|\n")
+	for _, t := range matrix {
+		if len(t.TestCases) == 0 {
+			fmt.Fprintf(w, "| %s | %s | none | |\n", t.Req.ID, t.Req.Title)
+		}
+		for _, tc := range t.TestCases {
+			fmt.Fprintf(w, "| %s | %s | %s | %s:%d |\n", t.Req.ID, t.Req.Title, tc.Name, tc.File, tc.Line)
+		}
+	}
+}
+
+// WriteTestCaseMatrixJSON writes the matrix as json.
+func WriteTestCaseMatrixJSON(w io.Writer, matrix []TestCaseTrace) error {
+	type testCase struct {
+		Name string
+		File string
+		Line int
+	}
+	type entry struct {
+		Req       string
+		Title     string
+		TestCases []testCase
+	}
+	res := []entry{}
+	for _, t := range matrix {
+		e := entry{Req: t.Req.ID, Title: t.Req.Title, TestCases: []testCase{}}
+		for _, tc := range t.TestCases {
+			e.TestCases = append(e.TestCases, testCase{tc.Name, tc.File, tc.Line})
+		}
+		res = append(res, e)
+	}
+	b, err := json.MarshalIndent(res, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(b, '\n'))
+	return err
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+
+	"github.com/daedaleanai/reqtraq/config"
+)
+
+// TestResults are the results of the test cases, "pass" or "fail", by name,
+// as in TestCase.Name.
+type TestResults map[string]string
+
+// add records the result of a test case, a failure winning over a pass of a
+// test case of the same name.
+func (t TestResults) add(name string, passed bool) {
+	if !passed {
+		t[name] = "fail"
+	} else if t[name] == "" {
+		t[name] = "pass"
+	}
+}
+
+// goTestEvent is an event of go test -json, see go doc test2json.
+type goTestEvent struct {
+	Action string
+	Test   string
+}
+
+// junitTestCase is a test case of a JUnit XML report.
+type junitTestCase struct {
+	ClassName string    `xml:"classname,attr"`
+	Name      string    `xml:"name,attr"`
+	Failure   *struct{} `xml:"failure"`
+	Error     *struct{} `xml:"error"`
+	Skipped   *struct{} `xml:"skipped"`
+}
+
+// ReadTestResults reads the results of the tests from the given comma
+// separated files, the outputs of go test -json or JUnit XML reports, e.g.
+// from GoogleTest with --gtest_output=xml or from Catch2 with --reporter
+// junit. The skipped test cases have no result.
+func ReadTestResults(fileNames string) (TestResults, error) {
+	res := TestResults{}
+	for _, fileName := range splitList(fileNames) {
+		b, err := ioutil.ReadFile(fileName)
+		if err != nil {
+			return nil, err
+		}
+		if strings.HasPrefix(strings.TrimSpace(string(b)), "<") {
+			err = readJUnit(bytes.NewReader(b), res)
+		} else {
+			err = readGoTestJSON(bytes.NewReader(b), res)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("Error while reading test results %s: %v", fileName, err)
+		}
+	}
+	return res, nil
+}
+
+// readGoTestJSON reads the results of the top-level tests in the output of
+// go test -json, the subtests failing their test.
+func readGoTestJSON(r io.Reader, res TestResults) error {
+	scan := bufio.NewScanner(r)
+	scan.Buffer(nil, 1<<20)
+	for lno := 1; scan.Scan(); lno++ {
+		line := strings.TrimSpace(scan.Text())
+		if !strings.HasPrefix(line, "{") {
+			// E.g. the build errors.
+			continue
+		}
+		var e goTestEvent
+		if err := json.Unmarshal([]byte(line), &e); err != nil {
+			return fmt.Errorf("line %d: %v", lno, err)
+		}
+		if e.Test == "" || strings.Contains(e.Test, "/") {
+			continue
+		}
+		switch e.Action {
+		case "pass", "fail":
+			res.add(e.Test, e.Action == "pass")
+		}
+	}
+	return scan.Err()
+}
+
+// readJUnit reads the results of the test cases of a JUnit XML report, by
+// name and by class name and name, e.g. Parser.RejectsLongLines.
+func readJUnit(r io.Reader, res TestResults) error {
+	d := xml.NewDecoder(r)
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		start, ok := tok.(xml.StartElement)
+		if !ok || start.Name.Local != "testcase" {
+			continue
+		}
+		var tc junitTestCase
+		if err := d.DecodeElement(&tc, &start); err != nil {
+			return err
+		}
+		if tc.Skipped != nil {
+			continue
+		}
+		passed := tc.Failure == nil && tc.Error == nil
+		res.add(tc.Name, passed)
+		if tc.ClassName != "" {
+			res.add(tc.ClassName+"."+tc.Name, passed)
+		}
+	}
+}
+
+// SetTestResults sets the Result of the test cases of the test files.
+func (rg reqGraph) SetTestResults(results TestResults) {
+	for _, r := range rg {
+		if r.Level != config.CODE {
+			continue
+		}
+		for i, tc := range r.TestCases {
+			r.TestCases[i].Result = results[tc.Name]
+		}
+	}
+}
+
+// testResults returns whether the test cases verifying r, those of the test
+// files among its descendants tagged with the ID of their parent, have known
+// results, and whether they all passed.
+func (r *Req) testResults() (known, passed bool) {
+	failed := false
+	for _, c := range r.Children {
+		if c.Level != config.CODE {
+			ck, cp := c.testResults()
+			known = known || ck
+			failed = failed || ck && !cp
+			continue
+		}
+		for _, tc := range c.TestCases {
+			for _, id := range tc.IDs {
+				if id == r.ID && tc.Result != "" {
+					known = true
+					failed = failed || tc.Result != "pass"
+				}
+			}
+		}
+	}
+	return known, known && !failed
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// testKind is the Kind of the test suites in the graph, which reference the
+// requirements they verify with the tags of their test framework rather than
+// with @llr tags.
+const testKind = "TEST"
+
+var (
+	// reRobotReference matches the tags of the Robot Framework test cases,
+	// "[Tags]    REQ-0-DDLN-SWL-001    smoke", and of the suites, e.g.
+	// "Test Tags    REQ-0-DDLN-SWH-001". The other tags are ignored.
+	reRobotReference = regexp.MustCompile(`(?i)^(?:\s+\[Tags\]|(?:Force|Default|Test) Tags)(?:\s+(.*)|\s*$)`)
+	// reRobotContinuation matches the lines continuing the previous setting
+	// of a Robot Framework file, e.g. "...    REQ-0-DDLN-SWL-002".
+	reRobotContinuation = regexp.MustCompile(`^\s*\.\.\.(?:\s+(.*)|\s*$)`)
+	// rePytestReference matches the req markers of the pytest tests, e.g.
+	// `@pytest.mark.req("REQ-0-DDLN-SWL-001", "REQ-0-DDLN-SWL-002")`, also
+	// when applied to a module with pytestmark.
+	rePytestReference = regexp.MustCompile(`\bpytest\.mark\.req\(([^)]*)\)`)
+)
+
+// isTestSuiteFile returns whether the file is a test suite whose requirements
+// are tagged with its test framework, by its name: a Robot Framework suite, or
+// a Python file collected by pytest, e.g. test_parser.py.
+func isTestSuiteFile(fileName string) bool {
+	switch strings.ToLower(path.Ext(fileName)) {
+	case ".robot":
+		return true
+	case ".py":
+		return reTestFile.MatchString(filepath.Base(fileName))
+	}
+	return false
+}
+
+// isRobotFile returns whether the file is a Robot Framework suite, whose
+// settings continue on the "..." lines.
+func isRobotFile(fileName string) bool {
+	return strings.ToLower(path.Ext(fileName)) == ".robot"
+}
+
+// testSuiteReference returns the regexp matching the tags of the test
+// framework of the given file, nil when it has none.
+func testSuiteReference(fileName string) *regexp.Regexp {
+	switch strings.ToLower(path.Ext(fileName)) {
+	case ".robot":
+		return reRobotReference
+	case ".py":
+		return rePytestReference
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/daedaleanai/reqtraq/git"
+)
+
+// trendSteps are the intervals between the points of a trend, by name.
+var trendSteps = map[string]func(time.Time) time.Time{
+	"daily":   func(t time.Time) time.Time { return t.AddDate(0, 0, 1) },
+	"weekly":  func(t time.Time) time.Time { return t.AddDate(0, 0, 7) },
+	"monthly": func(t time.Time) time.Time { return t.AddDate(0, 1, 0) },
+}
+
+// TrendPoint is the progress of the requirements at a date.
+type TrendPoint struct {
+	Date   time.Time
+	Commit string // The last commit before Date.
+	Levels []LevelStats
+}
+
+// trendDates returns the dates from since until until, at the given step.
+func trendDates(since, until time.Time, step string) ([]time.Time, error) {
+	next, ok := trendSteps[step]
+	if !ok {
+		return nil, fmt.Errorf("Unknown step %q, expected daily, weekly or monthly", step)
+	}
+	var dates []time.Time
+	for d := since; !d.After(until); d = next(d) {
+		dates = append(dates, d)
+	}
+	return dates, nil
+}
+
+// Trend returns the progress of the requirements at the given dates, as of the
+// last commit of HEAD before each of them, and the graph of the last point.
+// The dates before the first commit are skipped. The working tree is not
+// touched.
+func Trend(dates []time.Time) ([]TrendPoint, reqGraph, error) {
+	var points []TrendPoint
+	for _, d := range dates {
+		commit, err := git.CommitBefore(d)
+		if err != nil {
+			return nil, nil, err
+		}
+		if commit != "" {
+			points = append(points, TrendPoint{Date: d, Commit: commit})
+		}
+	}
+	stats := map[string][]LevelStats{}
+	var last reqGraph
+	for i, p := range points {
+		if stats[p.Commit] == nil {
+			rg, err := CreateReqGraphAt(p.Commit, *fCertdocPath, *fCodePath)
+			if rg == nil {
+				return nil, nil, err
+			}
+			if err != nil {
+				slog.Warn("problems found in the requirements, counting the ones which could be parsed",
+					"commit", p.Commit, "findings", Summarize(command, exitFindings, err).Findings)
+			}
+			stats[p.Commit] = rg.Stats()
+			// The commits are in chronological order.
+			last = rg
+		}
+		points[i].Levels = stats[p.Commit]
+	}
+	return points, last, nil
+}
+
+// WriteTrendCSV writes the trend as CSV, one row per date and level, with the
+// stamp in the last column when the artifacts are stamped, so the file stays
+// plain CSV.
+func WriteTrendCSV(w io.Writer, points []TrendPoint) error {
+	stamp := ""
+	if artifactStamp != nil {
+		stamp = artifactStamp.String()
+	}
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"date", "commit", "level", "total", "with_children", "with_code", "with_tests",
+		"pct_with_children", "pct_with_code", "pct_with_tests", "stamp"})
+	for _, p := range points {
+		for _, s := range p.Levels {
+			cw.Write([]string{p.Date.Format("2006-01-02"), p.Commit, s.Level,
+				strconv.Itoa(s.Total), strconv.Itoa(s.WithChildren), strconv.Itoa(s.WithCode), strconv.Itoa(s.WithTests),
+				strconv.FormatFloat(s.PctChildren, 'f', 1, 64), strconv.FormatFloat(s.PctCode, 'f', 1, 64),
+				strconv.FormatFloat(s.PctTests, 'f', 1, 64), stamp})
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,354 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/daedaleanai/reqtraq/config"
+	"github.com/daedaleanai/reqtraq/git"
+	"golang.org/x/term"
+)
+
+const tuiHelp = "↑/↓ move  enter open  ←/backspace back  / search  o edit  q quit"
+
+// view is what the browser shows: a list of requirements, optionally below the details of a requirement.
+type view struct {
+	list    []*Req
+	current *Req // The requirement whose details are shown, if any.
+	cursor  int
+}
+
+// browser is the state of the interactive terminal browser of a requirement graph.
+type browser struct {
+	view
+	all       []*Req // All the requirements and code files, sorted by ID.
+	query     string
+	searching bool
+	offset    int
+	history   []view // The views to go back to.
+	bodies    map[*Req]string
+	status    string
+}
+
+// newBrowser returns the browser of rg, listing all the requirements and code files.
+func newBrowser(rg reqGraph) *browser {
+	b := &browser{bodies: map[*Req]string{}}
+	for _, r := range rg {
+		b.all = append(b.all, r)
+	}
+	sort.Sort(byIDs(b.all))
+	b.list = b.all
+	return b
+}
+
+// Browse runs the interactive terminal browser until the user quits.
+func (rg reqGraph) Browse() error {
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return fmt.Errorf("the browser needs an interactive terminal")
+	}
+	b := newBrowser(rg)
+
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		return err
+	}
+	defer func() { term.Restore(fd, state) }()
+	defer fmt.Print("\x1b[2J\x1b[H")
+
+	in := bufio.NewReader(os.Stdin)
+	for {
+		width, height, err := term.GetSize(int(os.Stdout.Fd()))
+		if err != nil {
+			width, height = 80, 24
+		}
+		b.draw(os.Stdout, width, height)
+		key, err := readKey(in)
+		if err != nil {
+			return err
+		}
+		quit, r := b.handle(key)
+		if quit {
+			return nil
+		}
+		if r != nil {
+			term.Restore(fd, state)
+			if err := edit(r); err != nil {
+				b.status = err.Error()
+			}
+			if state, err = term.MakeRaw(fd); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// readKey reads a key press, translating the escape sequences of the arrow keys to "up", "down", "left", "right".
+func readKey(in *bufio.Reader) (string, error) {
+	r, _, err := in.ReadRune()
+	if err != nil {
+		return "", err
+	}
+	if r != 0x1b {
+		return string(r), nil
+	}
+	if in.Buffered() == 0 {
+		return "esc", nil
+	}
+	seq := make([]byte, 2)
+	if _, err := io.ReadFull(in, seq); err != nil {
+		return "", err
+	}
+	switch string(seq) {
+	case "[A":
+		return "up", nil
+	case "[B":
+		return "down", nil
+	case "[C":
+		return "right", nil
+	case "[D":
+		return "left", nil
+	}
+	return "esc", nil
+}
+
+// handle updates the state according to the pressed key and returns whether
+// to quit, or the requirement to open in the editor, if any.
+func (b *browser) handle(key string) (quit bool, editing *Req) {
+	b.status = ""
+	if b.searching {
+		switch key {
+		case "\r", "esc":
+			b.searching = false
+		case "\x7f", "\b":
+			if r := []rune(b.query); len(r) > 0 {
+				b.query = string(r[:len(r)-1])
+			}
+		default:
+			if r := []rune(key); len(r) == 1 && unicode.IsPrint(r[0]) {
+				b.query += key
+			}
+		}
+		b.filter()
+		return false, nil
+	}
+	switch key {
+	case "q", "\x03":
+		return true, nil
+	case "up", "k":
+		if b.cursor > 0 {
+			b.cursor--
+		}
+	case "down", "j":
+		if b.cursor < len(b.list)-1 {
+			b.cursor++
+		}
+	case "\r", "right", "l":
+		if b.cursor < len(b.list) {
+			b.open(b.list[b.cursor])
+		}
+	case "left", "h", "\x7f", "\b", "esc":
+		b.back()
+	case "/":
+		b.current = nil
+		b.history = nil
+		b.searching = true
+		b.filter()
+	case "o":
+		editing = b.current
+		if b.cursor < len(b.list) {
+			editing = b.list[b.cursor]
+		}
+	}
+	return false, editing
+}
+
+// filter lists the requirements fuzzy-matching the query.
+func (b *browser) filter() {
+	b.list = nil
+	for _, r := range b.all {
+		if fuzzyMatch(b.query, r.ID+" "+r.Title) {
+			b.list = append(b.list, r)
+		}
+	}
+	b.cursor = 0
+	b.offset = 0
+}
+
+// fuzzyMatch returns whether the characters of query appear in s in order, ignoring case.
+func fuzzyMatch(query, s string) bool {
+	s = strings.ToLower(s)
+	for _, c := range strings.ToLower(query) {
+		i := strings.IndexRune(s, c)
+		if i < 0 {
+			return false
+		}
+		s = s[i+len(string(c)):]
+	}
+	return true
+}
+
+// open shows the details of r, listing its parents, its children and the
+// requirements it has typed links with.
+func (b *browser) open(r *Req) {
+	b.history = append(b.history, b.view)
+	list := append(append([]*Req{}, r.Parents...), r.Children...)
+	for _, l := range append(append([]Link{}, r.Links...), r.Backlinks...) {
+		list = append(list, l.Req)
+	}
+	b.view = view{list: list, current: r}
+	b.offset = 0
+}
+
+func (b *browser) back() {
+	if len(b.history) == 0 {
+		return
+	}
+	b.view = b.history[len(b.history)-1]
+	b.history = b.history[:len(b.history)-1]
+	b.offset = 0
+}
+
+// draw writes the screen of the given size.
+func (b *browser) draw(w io.Writer, width, height int) {
+	fmt.Fprint(w, "\x1b[H\x1b[2J"+strings.Join(b.lines(width, height), "\r\n"))
+}
+
+// lines returns the lines of the screen of the given size, scrolling the list
+// to show the cursor.
+func (b *browser) lines(width, height int) []string {
+	var lines []string
+	if b.current != nil {
+		r := b.current
+		lines = append(lines, "\x1b[1m"+r.ID+" "+r.Title+"\x1b[0m", r.Path)
+		if _, ok := b.bodies[r]; !ok {
+			// Not formatHTMLAsText, which would exit leaving the terminal raw.
+			text, err := runPandoc(string(r.Body), "-f", "html", "-t", "plain")
+			if err != nil {
+				b.status = err.Error()
+				text = []byte(r.Body)
+			}
+			b.bodies[r] = strings.TrimSpace(string(text))
+		}
+		for _, l := range strings.Split(b.bodies[r], "\n") {
+			lines = append(lines, "  "+l)
+		}
+		var keys []string
+		for k := range r.Attributes {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			lines = append(lines, fmt.Sprintf("  %s: %s", k, r.Attributes[k]))
+		}
+		lines = append(lines, "", "Parents, children and links:")
+	} else if b.searching || b.query != "" {
+		lines = append(lines, "Search: "+b.query)
+	} else {
+		lines = append(lines, config.ProjectName+" requirements")
+	}
+
+	rows := height - len(lines) - 2
+	if rows < 1 {
+		rows = 1
+	}
+	if b.cursor < b.offset {
+		b.offset = b.cursor
+	} else if b.cursor >= b.offset+rows {
+		b.offset = b.cursor - rows + 1
+	}
+	for i := b.offset; i < len(b.list) && i < b.offset+rows; i++ {
+		r := b.list[i]
+		kind := "  "
+		if c := b.current; c != nil {
+			kind = "↓ "
+			if i < len(c.Parents) {
+				kind = "↑ "
+			} else if j := i - len(c.Parents) - len(c.Children); j >= len(c.Links) {
+				kind = "← " + c.Backlinks[j-len(c.Links)].Label() + ": "
+			} else if j >= 0 {
+				kind = "→ " + c.Links[j].Label() + ": "
+			}
+		}
+		line := kind + r.ID + " " + r.Title
+		if r.Level == config.CODE {
+			line = kind + "code: " + r.ID
+		}
+		line = truncate(line, width)
+		if i == b.cursor {
+			line = "\x1b[7m" + line + "\x1b[0m"
+		}
+		lines = append(lines, line)
+	}
+	if len(b.list) == 0 {
+		lines = append(lines, "  (none)")
+	}
+	for len(lines) < height-1 {
+		lines = append(lines, "")
+	}
+	footer := tuiHelp
+	if b.status != "" {
+		footer = b.status
+	}
+	return append(lines[:height-1], "\x1b[2m"+truncate(footer, width)+"\x1b[0m")
+}
+
+func truncate(s string, width int) string {
+	if r := []rune(s); len(r) > width {
+		return string(r[:width])
+	}
+	return s
+}
+
+// edit opens the file defining r in the editor set in $EDITOR (vi by default), at the line defining r.
+func edit(r *Req) error {
+	editor := os.Getenv("EDITOR")
+	if editor == "" {
+		editor = "vi"
+	}
+	fileName := r.Path
+	if !filepath.IsAbs(fileName) || !fileExists(fileName) {
+		repoPath, err := git.RepoPath()
+		if err != nil {
+			return err
+		}
+		fileName = filepath.Join(repoPath, r.Path)
+	}
+	line := 1
+	if r.Level != config.CODE {
+		line = findLine(fileName, r.ID)
+	} else if r.Parents != nil {
+		line = findLine(fileName, r.Parents[0].ID)
+	}
+	cmd := exec.Command(editor, fmt.Sprintf("+%d", line), fileName)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	return cmd.Run()
+}
+
+func fileExists(fileName string) bool {
+	_, err := os.Stat(fileName)
+	return err == nil
+}
+
+// findLine returns the number of the first line of the file containing s, or 1 if not found.
+func findLine(fileName, s string) int {
+	f, err := os.Open(fileName)
+	if err != nil {
+		return 1
+	}
+	defer f.Close()
+	scan := bufio.NewScanner(f)
+	for lno := 1; scan.Scan(); lno++ {
+		if strings.Contains(scan.Text(), s) {
+			return lno
+		}
+	}
+	return 1
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+
+	"github.com/daedaleanai/reqtraq/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func testBrowser() (*browser, *Req, *Req, *Req) {
+	hlr := &Req{ID: "REQ-0-TEST-SWH-001", Title: "Parse the input", Level: config.HIGH}
+	llr1 := &Req{ID: "REQ-0-TEST-SWL-001", Title: "Reject long lines", Level: config.LOW, Parents: []*Req{hlr}}
+	llr2 := &Req{ID: "REQ-0-TEST-SWL-002", Title: "Skip comments", Level: config.LOW, Parents: []*Req{hlr}}
+	hlr.Children = []*Req{llr1, llr2}
+	return newBrowser(reqGraph{llr2.ID: llr2, hlr.ID: hlr, llr1.ID: llr1}), hlr, llr1, llr2
+}
+
+func TestReadKey(t *testing.T) {
+	in := bufio.NewReader(strings.NewReader("j\x1b[A\x1b[D/\r"))
+	var keys []string
+	for {
+		key, err := readKey(in)
+		if err != nil {
+			break
+		}
+		keys = append(keys, key)
+	}
+	assert.Equal(t, []string{"j", "up", "left", "/", "\r"}, keys)
+}
+
+func TestBrowser_Handle(t *testing.T) {
+	b, hlr, llr1, llr2 := testBrowser()
+	assert.Equal(t, []*Req{hlr, llr1, llr2}, b.list)
+
+	for _, tc := range []struct {
+		key     string
+		current *Req
+		list    []*Req
+		cursor  int
+	}{
+		{"down", nil, []*Req{hlr, llr1, llr2}, 1},
+		{"k", nil, []*Req{hlr, llr1, llr2}, 0},
+		{"up", nil, []*Req{hlr, llr1, llr2}, 0},
+		{"\r", hlr, []*Req{llr1, llr2}, 0},
+		{"j", hlr, []*Req{llr1, llr2}, 1},
+		{"j", hlr, []*Req{llr1, llr2}, 1},
+		{"right", llr2, []*Req{hlr}, 0},
+		{"left", hlr, []*Req{llr1, llr2}, 1},
+		{"esc", nil, []*Req{hlr, llr1, llr2}, 0},
+		{"esc", nil, []*Req{hlr, llr1, llr2}, 0},
+	} {
+		quit, editing := b.handle(tc.key)
+		assert.False(t, quit, tc.key)
+		assert.Nil(t, editing, tc.key)
+		assert.Equal(t, tc.current, b.current, tc.key)
+		assert.Equal(t, tc.list, b.list, tc.key)
+		assert.Equal(t, tc.cursor, b.cursor, tc.key)
+	}
+
+	b.handle("j")
+	quit, editing := b.handle("o")
+	assert.False(t, quit)
+	assert.Equal(t, llr1, editing)
+	quit, _ = b.handle("q")
+	assert.True(t, quit)
+}
+
+func TestBrowser_Search(t *testing.T) {
+	b, hlr, llr1, llr2 := testBrowser()
+	b.handle("\r")
+	b.handle("/")
+	assert.True(t, b.searching)
+	assert.Nil(t, b.current)
+	assert.Nil(t, b.history)
+
+	for _, key := range []string{"S", "W", "L", "q", "\x7f"} {
+		quit, _ := b.handle(key)
+		assert.False(t, quit, "keys are typed into the query while searching")
+	}
+	assert.Equal(t, "SWL", b.query)
+	assert.Equal(t, []*Req{llr1, llr2}, b.list)
+
+	for _, key := range []string{" ", "c", "m", "t", "\r"} {
+		b.handle(key)
+	}
+	assert.False(t, b.searching)
+	assert.Equal(t, "SWL cmt", b.query)
+	assert.Equal(t, []*Req{llr2}, b.list)
+
+	b.handle("/")
+	for range b.query {
+		b.handle("\b")
+	}
+	assert.Equal(t, []*Req{hlr, llr1, llr2}, b.list)
+}
+
+func TestFuzzyMatch(t *testing.T) {
+	for _, tc := range []struct {
+		query, s string
+		match    bool
+	}{
+		{"", "REQ-0-TEST-SWL-001 Reject long lines", true},
+		{"swl1", "REQ-0-TEST-SWL-001 Reject long lines", true},
+		{"rll", "REQ-0-TEST-SWL-001 Reject long lines", true},
+		{"lines long", "REQ-0-TEST-SWL-001 Reject long lines", false},
+		{"ü", "REQ-0-TEST-SWL-001 Über", true},
+	} {
+		assert.Equal(t, tc.match, fuzzyMatch(tc.query, tc.s), tc.query)
+	}
+}
+
+func TestBrowser_Lines(t *testing.T) {
+	b, hlr, _, _ := testBrowser()
+	lines := b.lines(30, 5)
+	assert.Equal(t, []string{
+		config.ProjectName + " requirements",
+		"\x1b[7m  REQ-0-TEST-SWH-001 Parse the\x1b[0m",
+		"  REQ-0-TEST-SWL-001 Reject lo",
+		"",
+		"\x1b[2m" + truncate(tuiHelp, 30) + "\x1b[0m",
+	}, lines)
+
+	// The list scrolls to show the cursor.
+	b.handle("j")
+	b.handle("j")
+	lines = b.lines(30, 5)
+	assert.Equal(t, "\x1b[7m  REQ-0-TEST-SWL-002 Skip comm\x1b[0m", lines[2])
+	assert.Equal(t, 1, b.offset)
+
+	b.cursor = 0
+	b.open(hlr)
+	lines = b.lines(80, 10)
+	assert.Equal(t, "\x1b[1mREQ-0-TEST-SWH-001 Parse the input\x1b[0m", lines[0])
+	assert.Contains(t, lines, "↓ REQ-0-TEST-SWL-002 Skip comments")
+	assert.Len(t, lines, 10)
+
+	// Without pandoc, the body is shown as it is, and the error in the footer.
+	t.Setenv("PATH", t.TempDir())
+	hlr.Body = "<p>Body</p>"
+	b.bodies = map[*Req]string{}
+	lines = b.lines(80, 10)
+	assert.Equal(t, "  <p>Body</p>", lines[2])
+	assert.Contains(t, lines[9], "Error while running pandoc")
+}
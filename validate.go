@@ -0,0 +1,145 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/daedaleanai/reqtraq/config"
+	"github.com/daedaleanai/reqtraq/git"
+)
+
+// ValidationFinding is a problem of a certification document being edited,
+// see ValidateDocument.
+type ValidationFinding struct {
+	Type     string `json:"type"`     // As in the --summary-file, see findingType.
+	Severity string `json:"severity"` // error, or warning for the style checks.
+	Message  string `json:"message"`
+}
+
+// ValidateDocument returns the problems of the certification document at the
+// given path, relative to the repository root, having the given contents
+// instead of those in the working tree, e.g. unsaved in an editor. The format
+// is the extension of the contents, md or lyx, that of the path when empty.
+// The requirements of the document are checked against those of the other
+// documents and the code in the working tree, which is not changed.
+func ValidateDocument(docPath string, contents []byte, format, certdocPath, codePath string, as []map[string]string) ([]ValidationFinding, error) {
+	repoPath, err := git.RepoPath()
+	if err != nil {
+		return nil, err
+	}
+	rg, err := CreateReqGraph(certdocPath, codePath)
+	if rg == nil {
+		return nil, err
+	}
+	docPath = "/" + repoRelative(docPath)
+	if format == "" {
+		format = strings.TrimPrefix(filepath.Ext(docPath), ".")
+	}
+
+	// The IDs of the document, before and after the changes.
+	ids := map[string]bool{}
+	for k, r := range rg {
+		if r.Level != config.CODE && r.Path == docPath {
+			ids[r.ID] = true
+			delete(rg, k)
+		}
+	}
+	dir, err := ioutil.TempDir("", "reqtraq")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+	base := filepath.Base(docPath)
+	fileName := filepath.Join(dir, strings.TrimSuffix(base, filepath.Ext(base))+"."+format)
+	if err := ioutil.WriteFile(fileName, contents, 0644); err != nil {
+		return nil, err
+	}
+	var problems findingList
+	for _, e := range parseCertdocToGraph(fileName, rg) {
+		problems.addLine(e)
+	}
+	doc := reqGraph{}
+	for k, r := range rg {
+		if r.Level != config.CODE && r.Path == filepath.ToSlash(fileName) {
+			r.Path = docPath
+			r.Document.Path = docPath
+			ids[r.ID] = true
+			doc[k] = r
+		}
+		r.Parents, r.Children = nil, nil
+	}
+
+	// The problems of the graph concerning the document, e.g. the references
+	// to its requirements which were removed.
+	for _, f := range findingsOf(rg.Resolve()) {
+		for _, id := range ReReqID.FindAllString(f.Error(), -1) {
+			if ids[id] {
+				problems.add(f)
+				break
+			}
+		}
+	}
+	configs, _ := loadConfigTree(repoPath, append(certdocRoots(certdocPath), codePath)...)
+	rg.InheritAttributes(configs, as)
+	errs := doc.CheckAttributesIn(configs, as)
+	if len(repoConfig.Tags) > 0 {
+		errs = append(errs, doc.CheckTags(repoConfig.Tags)...)
+	}
+	errs = append(errs, doc.CheckPlaceholders()...)
+	sort.Slice(errs, func(i, j int) bool { return errs[i].Error() < errs[j].Error() })
+	problems = append(problems, errs...)
+	remaining, _ := doc.Suppress(problems)
+
+	var res []ValidationFinding
+	for _, f := range findingsOf(remaining) {
+		msg := strings.ReplaceAll(f.Error(), filepath.ToSlash(fileName), docPath)
+		res = append(res, ValidationFinding{Type: findingType(f), Severity: "error", Message: strings.TrimSpace(msg)})
+	}
+	style, err := doc.CheckStyle(repoConfig.Style)
+	if err != nil {
+		return nil, err
+	}
+	for _, tw := range []struct {
+		typ      string
+		warnings []error
+	}{
+		{"style", style},
+		{"terminology", doc.CheckTerminology(repoConfig.Terminology)},
+	} {
+		sort.Slice(tw.warnings, func(i, j int) bool { return tw.warnings[i].Error() < tw.warnings[j].Error() })
+		for _, w := range tw.warnings {
+			res = append(res, ValidationFinding{Type: tw.typ, Severity: "warning", Message: strings.TrimSpace(w.Error())})
+		}
+	}
+	return res, nil
+}
+
+// validationErrors returns the errors among the findings, one per line, as
+// reported by the checks, for the --summary-file, nil when none.
+func validationErrors(findings []ValidationFinding) error {
+	var res findingList
+	for _, f := range findings {
+		if f.Severity == "error" {
+			res.add(newFinding(f.Type, "%s\n", f.Message))
+		}
+	}
+	return res.err()
+}
+
+// WriteValidationJSON writes the findings as a json list, empty when none.
+func WriteValidationJSON(w io.Writer, findings []ValidationFinding) error {
+	if findings == nil {
+		findings = []ValidationFinding{}
+	}
+	b, err := json.MarshalIndent(findings, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(b, '\n'))
+	return err
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"runtime/debug"
+)
+
+// buildDate is the date the binary was built, set when linking it, e.g. with
+// -ldflags "-X main.buildDate=2024-05-01T12:00:00Z", as Go does not record it.
+var buildDate string
+
+// BuildInfo is the provenance of the reqtraq binary, to be recorded with the
+// outputs of each qualified run.
+type BuildInfo struct {
+	Version    string // The version of the module, when installed with go install.
+	Commit     string // The commit it was built from, when built in a git checkout.
+	Modified   bool   // Whether the working tree of the commit was modified.
+	CommitDate string // The date of the commit.
+	BuildDate  string // See buildDate.
+	GoVersion  string
+}
+
+// readBuildInfo returns the provenance of the running binary, as recorded by
+// the go command.
+func readBuildInfo() BuildInfo {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return BuildInfo{BuildDate: buildDate}
+	}
+	b := BuildInfo{Version: info.Main.Version, BuildDate: buildDate, GoVersion: info.GoVersion}
+	if b.Version == "(devel)" {
+		b.Version = ""
+	}
+	for _, s := range info.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			b.Commit = s.Value
+		case "vcs.modified":
+			b.Modified = s.Value == "true"
+		case "vcs.time":
+			b.CommitDate = s.Value
+		}
+	}
+	return b
+}
+
+// reqtraqVersion returns the version of reqtraq: the version of its module
+// when installed with go install, else the commit it was built from, with a
+// +dirty suffix when the working tree was modified.
+func reqtraqVersion() string {
+	b := readBuildInfo()
+	if b.Version != "" {
+		return b.Version
+	}
+	if b.Commit == "" {
+		return "(devel)"
+	}
+	commit := b.Commit
+	if len(commit) > 12 {
+		commit = commit[:12]
+	}
+	if b.Modified {
+		commit += "+dirty"
+	}
+	return commit
+}
+
+// WriteVersion writes the version of reqtraq and, when verbose, the
+// provenance of the binary and the versions of the formats it reads.
+func WriteVersion(w io.Writer, verbose bool) {
+	fmt.Fprintf(w, "reqtraq %s\n", reqtraqVersion())
+	if !verbose {
+		return
+	}
+	b := readBuildInfo()
+	unknown := func(s string) string {
+		if s == "" {
+			return "unknown"
+		}
+		return s
+	}
+	commit := unknown(b.Commit)
+	if b.Modified {
+		commit += " (modified)"
+	}
+	fmt.Fprintf(w, "module version:  %s\n", unknown(b.Version))
+	fmt.Fprintf(w, "commit:          %s\n", commit)
+	fmt.Fprintf(w, "commit date:     %s\n", unknown(b.CommitDate))
+	fmt.Fprintf(w, "build date:      %s\n", unknown(b.BuildDate))
+	fmt.Fprintf(w, "go version:      %s\n", unknown(b.GoVersion))
+	fmt.Fprintf(w, "config schema:   %d, the older versions upgraded by config migrate\n", repoConfigVersion)
+	fmt.Fprintf(w, "snapshot format: %d\n", snapshotVersion)
+	fmt.Fprintf(w, "manifest format: %d\n", manifestVersion)
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ViewConfig is a named query of the configuration, printed by the view
+// command, for the recurring audits to be reproducible and reviewed.
+type ViewConfig struct {
+	// Description describes the view, e.g. the audit it is for.
+	Description string `yaml:"description,omitempty"`
+	// Query selects the requirements, see ParseQuery.
+	Query string `yaml:"query"`
+	// Format is the default output format: ids, md or json.
+	Format string `yaml:"format,omitempty"`
+}
+
+// viewFormats are the output formats of the queries and of the views.
+var viewFormats = []string{"ids", "md", "json"}
+
+// isViewFormat returns whether the given format is an output format of the
+// queries, the empty one being ids.
+func isViewFormat(format string) bool {
+	if format == "" {
+		return true
+	}
+	for _, f := range viewFormats {
+		if f == format {
+			return true
+		}
+	}
+	return false
+}
+
+// WriteView writes the requirements matching a query in the given format.
+// The title and the description are written with the md format.
+func WriteView(w io.Writer, reqs []*Req, format, title, description string) error {
+	switch format {
+	case "json":
+		type viewReq struct {
+			ID       string
+			Title    string `json:",omitempty"`
+			Document string `json:",omitempty"`
+			Status   string `json:",omitempty"`
+		}
+		res := []viewReq{}
+		for _, r := range reqs {
+			res = append(res, viewReq{ID: r.ID, Title: r.Title, Document: strings.TrimPrefix(r.Path, "/"), Status: r.Status.String()})
+		}
+		b, err := json.MarshalIndent(res, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(append(b, '\n'))
+		return err
+	case "md":
+		fmt.Fprintf(w, "# %s\n\n", title)
+		if description != "" {
+			fmt.Fprintf(w, "%s\n\n", description)
+		}
+		fmt.Fprintf(w, "| Requirement | Title | Document | Status |\n")
+		fmt.Fprintf(w, "|---|---|---|---|\n")
+		for _, r := range reqs {
+			fmt.Fprintf(w, "| %s | %s | %s | %s |\n", r.ID, strings.ReplaceAll(r.Title, "|", "\\|"), strings.TrimPrefix(r.Path, "/"), r.Status)
+		}
+	default:
+		for _, r := range reqs {
+			fmt.Fprintln(w, r.ID)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"time"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// Waiver records an intentional traceability gap, e.g. an HLR without LLRs
+// because it is allocated to hardware, whose findings are not reported until
+// it expires.
+type Waiver struct {
+	// ID is the requirement the findings are about.
+	ID string `yaml:"id"`
+	// Finding is the type of the waived findings, see findingTypes, e.g. missing_parent.
+	Finding       string `yaml:"finding"`
+	Justification string `yaml:"justification"`
+	Owner         string `yaml:"owner"`
+	// Expires is the date the waiver expires, e.g. 2025-12-31.
+	Expires string `yaml:"expires"`
+
+	expires time.Time
+}
+
+// ReadWaivers reads and validates the waivers of the given file, a YAML list.
+func ReadWaivers(fileName string) ([]Waiver, error) {
+	b, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+	var doc yaml.Node
+	if err := yaml.NewDecoder(bytes.NewReader(b)).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("%s: %v", fileName, strings.TrimPrefix(err.Error(), "yaml: "))
+	}
+	if len(doc.Content) == 0 {
+		return nil, nil
+	}
+	list := doc.Content[0]
+	if list.Kind != yaml.SequenceNode {
+		return nil, configError(fileName, list.Line, "expected a list of waivers")
+	}
+	types := map[string]bool{}
+	for _, t := range findingTypes {
+		types[t] = true
+	}
+	var res []Waiver
+	var errs []string
+	for _, n := range list.Content {
+		var w Waiver
+		if err := n.Decode(&w); err != nil {
+			errs = append(errs, configError(fileName, n.Line, "%v", strings.TrimPrefix(err.Error(), "yaml: ")).Error())
+			continue
+		}
+		for _, f := range []struct{ key, value string }{{"id", w.ID}, {"finding", w.Finding}, {"justification", w.Justification}, {"owner", w.Owner}, {"expires", w.Expires}} {
+			if f.value == "" {
+				errs = append(errs, configError(fileName, n.Line, "waiver without %s", f.key).Error())
+			}
+		}
+		if w.Finding != "" && !types[w.Finding] {
+			errs = append(errs, configError(fileName, configValue(n, "finding").Line, "unknown finding type %q", w.Finding).Error())
+		}
+		if w.Expires != "" {
+			if w.expires, err = time.ParseInLocation("2006-01-02", w.Expires, time.Local); err != nil {
+				errs = append(errs, configError(fileName, configValue(n, "expires").Line, "invalid expiry date %q, expected e.g. 2025-12-31", w.Expires).Error())
+			}
+		}
+		res = append(res, w)
+	}
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("%s", strings.Join(errs, "\n"))
+	}
+	return res, nil
+}
+
+// expired returns whether the waiver expired at the given time: it is valid
+// through its expiry date.
+func (w Waiver) expired(now time.Time) bool {
+	return !now.Before(w.expires.AddDate(0, 0, 1))
+}
+
+// ApplyWaivers removes from the findings reported by a check those waived at
+// the given time, and adds the expired waivers as findings. It returns the
+// remaining findings, nil when none, and the number of waived ones.
+func ApplyWaivers(findings error, waivers []Waiver, now time.Time) (error, int) {
+	waived := map[string]bool{} // By type and requirement ID.
+	var expired []error
+	for _, w := range waivers {
+		if w.expired(now) {
+			expired = append(expired, newFinding("waiver", "Waiver of the %s findings of '%s' by %s expired on %s.\n", w.Finding, w.ID, w.Owner, w.Expires))
+		} else {
+			waived[w.Finding+" "+w.ID] = true
+		}
+	}
+	sort.Slice(expired, func(i, j int) bool { return expired[i].Error() < expired[j].Error() })
+	var res findingList
+	n := 0
+	for _, f := range flattenFindings(findings) {
+		if _, ok := f.(findingHeader); !ok && waived[findingType(f)+" "+ReReqID.FindString(f.Error())] {
+			n++
+			continue
+		}
+		res = append(res, f)
+	}
+	if len(findingsOf(res)) == 0 {
+		res = nil
+	}
+	return append(res, expired...).err(), n
+}
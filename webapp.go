@@ -2,30 +2,98 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"html/template"
-	"log"
+	"log/slog"
+	"net"
 	"net/http"
 	"os"
+	"os/signal"
 	"regexp"
 	"strings"
+	"sync/atomic"
+	"syscall"
+	"time"
 
 	"github.com/daedaleanai/reqtraq/git"
+	"github.com/daedaleanai/reqtraq/linepipes"
 )
 
+// shutdownTimeout is how long the server waits for the requests being handled when asked to stop.
+const shutdownTimeout = 20 * time.Second
+
+// ready is set while the server accepts requests, see readyz.
+var ready atomic.Bool
+
+// serve runs the web server until it receives SIGINT or SIGTERM, then waits
+// for the requests being handled before returning.
 func serve(addr string) error {
 	if strings.HasPrefix(addr, ":") {
 		addr = "localhost" + addr
 	}
+	webGraphs = newGraphCache(*fCacheSize)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", healthz)
+	mux.HandleFunc("/readyz", readyz)
+	mux.HandleFunc("/metrics", metrics)
+	mux.HandleFunc("/cache/invalidate", invalidateCache)
+	mux.HandleFunc("/", handler)
+	srv := &http.Server{Handler: instrument(mux)}
+
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	errs := make(chan error, 1)
+	go func() {
+		errs <- srv.Serve(ln)
+	}()
+	ready.Store(true)
 	fmt.Printf("Server started on http://%s\n", addr)
-	return http.ListenAndServe(addr, http.HandlerFunc(handler))
+
+	select {
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+	}
+	ready.Store(false)
+	slog.Info("shutting down", "timeout", shutdownTimeout)
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	return srv.Shutdown(ctx)
+}
+
+// healthz answers the liveness probes: the server is able to handle requests.
+func healthz(w http.ResponseWriter, r *http.Request) {
+	fmt.Fprintln(w, "ok")
+}
+
+// readyz answers the readiness probes: the server is not shutting down and
+// the repository the reports are created from can be read.
+func readyz(w http.ResponseWriter, r *http.Request) {
+	if !ready.Load() {
+		http.Error(w, "shutting down", http.StatusServiceUnavailable)
+		return
+	}
+	repoPath, err := git.RepoPath()
+	if err == nil {
+		_, err = linepipes.Single(linepipes.Run("git", "-C", repoPath, "rev-parse", "--verify", "HEAD"))
+	}
+	if err != nil {
+		http.Error(w, "cannot read the repository: "+err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	fmt.Fprintln(w, "ok")
 }
 
 var errorTemplate *template.Template = template.Must(template.New("error").Parse(
 	`<html>OOPS, {{.Error}}`))
 
 func handler(w http.ResponseWriter, r *http.Request) {
-	log.Print(r.Method, r.URL)
+	slog.Info("request", "method", r.Method, "url", r.URL.String())
 	var err error
 	switch r.Method {
 	case "GET":
@@ -38,7 +106,10 @@ func handler(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-var indexTemplate *template.Template = template.Must(template.New("index").Parse(
+// offlineFuncs allows templates to leave out the resources which cannot be loaded with --offline.
+var offlineFuncs = template.FuncMap{"offline": func() bool { return *fOffline }}
+
+var indexTemplate *template.Template = template.Must(template.New("index").Funcs(offlineFuncs).Parse(
 	`<!DOCTYPE html>
 <html lang="en">
 <head>
@@ -61,7 +132,7 @@ var indexTemplate *template.Template = template.Must(template.New("index").Parse
 </head>
 
 <body>
-<h1><img src="https://www.daedalean.ai/favicon-32x32.png"> {{.RepoName}}</h1>
+<h1>{{ if not offline }}<img src="https://www.daedalean.ai/favicon-32x32.png"> {{ end }}{{.RepoName}}</h1>
 
 <form action="/report" method="get">
 <p>Filter by:
@@ -79,6 +150,10 @@ var indexTemplate *template.Template = template.Must(template.New("index").Parse
 <div class="rTableCell"><input name="body_filter" type="text"></div>
 </div>
 <div class="rTableRow">
+<div class="rTableCell">Tags:</div>
+<div class="rTableCell"><input name="tag" type="text"></div>
+</div>
+<div class="rTableRow">
 <div class="rTableCell">Since:</div>
 <div class="rTableCell"><select name="since_commit">
 <option value="">Beginning</option>
@@ -109,7 +184,10 @@ type indexData struct {
 }
 
 func get(w http.ResponseWriter, r *http.Request) error {
-	repoName := git.RepoName()
+	repoName, err := git.RepoName()
+	if err != nil {
+		return err
+	}
 	path := r.URL.Path
 	switch {
 	case path == "/":
@@ -125,11 +203,11 @@ func get(w http.ResponseWriter, r *http.Request) error {
 		if at != "" {
 			atCommit = strings.Split(at, " ")[0]
 		}
-		rg, dir, err := buildGraph(atCommit)
+		g, err := cachedBuildGraph(atCommit)
 		if err != nil {
 			return err
 		}
-		defer os.RemoveAll(dir)
+		rg := g.rg
 		filter := ReqFilter{}
 		if len(r.FormValue("title_filter")) > 0 {
 			filter[TitleFilter], err = regexp.Compile(r.FormValue("title_filter"))
@@ -149,15 +227,22 @@ func get(w http.ResponseWriter, r *http.Request) error {
 				return err
 			}
 		}
+		if len(r.FormValue("tag")) > 0 {
+			filter[TagFilter] = tagFilter(r.FormValue("tag"))
+		}
 		var prg reqGraph
+		var pg *webGraph
 		since := r.FormValue("since_commit")
 		if since != "" {
 			sinceCommit := strings.Split(since, " ")[0]
-			prg, dir, err = buildGraph(sinceCommit)
+			pg, err = cachedBuildGraph(sinceCommit)
 			if err != nil {
 				return err
 			}
-			defer os.RemoveAll(dir)
+			prg = pg.rg
+		}
+		if reportHeaders(w, r, g, pg) {
+			return nil
 		}
 		diffs := rg.ChangedSince(prg)
 		switch r.FormValue("report-type") {
@@ -0,0 +1,263 @@
+package main
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/daedaleanai/reqtraq/git"
+)
+
+// graphCache keeps the last used requirement graphs of the web server, by
+// commit, so browsing the reports does not rebuild them. The least recently
+// used graph is evicted when full.
+type graphCache struct {
+	mu      sync.Mutex
+	size    int
+	entries map[string]*list.Element // Of *cachedGraph, by key.
+	lru     *list.List               // The most recently used first.
+}
+
+// cachedGraph is a graph being built or built, see graphCache.get.
+type cachedGraph struct {
+	key  string
+	done chan struct{} // Closed when built.
+	g    *webGraph
+	err  error
+}
+
+// webGraph is a graph of the web server, with what the caching headers of its
+// reports are derived from, see reportHeaders.
+type webGraph struct {
+	rg          reqGraph
+	hash        string // See reqGraph.Hash.
+	built       time.Time
+	fingerprint string // Of the working tree when built, see worktreeFingerprint.
+}
+
+func newGraphCache(size int) *graphCache {
+	return &graphCache{size: size, entries: map[string]*list.Element{}, lru: list.New()}
+}
+
+// webGraphs are the graphs of the web server, see cachedBuildGraph.
+var webGraphs = newGraphCache(0)
+
+// get returns the graph with the given key, built with build unless cached.
+// The concurrent requests of the same graph wait for the same build. The
+// graphs which could not be built at all are not kept.
+func (c *graphCache) get(key string, build func() (*webGraph, error)) (*webGraph, error) {
+	if c.size <= 0 {
+		return build()
+	}
+	c.mu.Lock()
+	if e, ok := c.entries[key]; ok {
+		c.lru.MoveToFront(e)
+		g := e.Value.(*cachedGraph)
+		c.mu.Unlock()
+		<-g.done
+		webMetrics.observeCacheHit()
+		return g.g, g.err
+	}
+	g := &cachedGraph{key: key, done: make(chan struct{})}
+	c.entries[key] = c.lru.PushFront(g)
+	c.mu.Unlock()
+
+	g.g, g.err = build()
+	close(g.done)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if g.g == nil {
+		if e, ok := c.entries[key]; ok && e.Value == g {
+			c.remove(e)
+		}
+		return g.g, g.err
+	}
+	// Evicting only once built, so failing builds do not evict.
+	for c.lru.Len() > c.size {
+		c.remove(c.lru.Back())
+	}
+	return g.g, g.err
+}
+
+func (c *graphCache) remove(e *list.Element) {
+	c.lru.Remove(e)
+	delete(c.entries, e.Value.(*cachedGraph).key)
+}
+
+// invalidate removes the graph with the given key, or all the graphs when
+// all, and returns the number of graphs removed.
+func (c *graphCache) invalidate(key string, all bool) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if all {
+		n := c.lru.Len()
+		c.entries = map[string]*list.Element{}
+		c.lru.Init()
+		return n
+	}
+	if e, ok := c.entries[key]; ok {
+		c.remove(e)
+		return 1
+	}
+	return 0
+}
+
+// graphKey returns the key of the graph at the given commit, as passed to
+// buildGraph: the commit ID, so the graphs of the branches are rebuilt when
+// they move, or empty for the working tree. Anything else than a commit is
+// refused, the requests cannot read other files.
+func graphKey(commit string) (string, error) {
+	if commit == "" {
+		return "", nil
+	}
+	return git.ResolveCommit(commit)
+}
+
+// cachedBuildGraph returns the graph at the given commit, see buildGraph, with
+// the suspect links and the blame requested on the command line, from the
+// cache of the web server if there.
+func cachedBuildGraph(commit string) (*webGraph, error) {
+	key, err := graphKey(commit)
+	if err != nil {
+		return nil, err
+	}
+	var fingerprint string
+	if key == "" {
+		if fingerprint, err = worktreeFingerprint(); err != nil {
+			return nil, err
+		}
+	}
+	build := func() (*webGraph, error) {
+		start := time.Now()
+		rg, err := buildGraph(key)
+		webMetrics.observeBuild(start, rg, err)
+		if err != nil {
+			// The web server does not show the graphs having problems.
+			return nil, err
+		}
+		if *fSuspect {
+			if err := markSuspectLinks(rg, key); err != nil {
+				return nil, err
+			}
+		}
+		if *fBlame {
+			if err := setBlame(rg, key); err != nil {
+				return nil, err
+			}
+		}
+		hash, err := rg.Hash()
+		if err != nil {
+			return nil, err
+		}
+		return &webGraph{rg: rg, hash: hash, built: time.Now(), fingerprint: fingerprint}, nil
+	}
+	g, err := webGraphs.get(key, build)
+	if g != nil && g.fingerprint != fingerprint {
+		// The working tree changed since its graph was built.
+		webGraphs.invalidate(key, false)
+		g, err = webGraphs.get(key, build)
+	}
+	return g, err
+}
+
+// worktreeFingerprint returns a digest of the sizes and modification times of
+// the files the graph of the working tree is built from, or of the --snapshot,
+// so its cached graph is rebuilt when they change, without reading them.
+func worktreeFingerprint() (string, error) {
+	roots := []string{*fSnapshot}
+	if *fSnapshot == "" {
+		repoPath, err := git.RepoPath()
+		if err != nil {
+			return "", err
+		}
+		roots = []string{*fReportJsonConfPath}
+		for _, root := range append(certdocRoots(*fCertdocPath), *fCodePath) {
+			roots = append(roots, filepath.Join(repoPath, root))
+		}
+	}
+	h := sha256.New()
+	for _, root := range roots {
+		_ = filepath.Walk(root, func(fileName string, info os.FileInfo, err error) error {
+			switch {
+			case err != nil:
+			case info.IsDir() && info.Name() == ".git":
+				return filepath.SkipDir
+			case !info.IsDir():
+				fmt.Fprintf(h, "%s %d %d\n", fileName, info.Size(), info.ModTime().UnixNano())
+			}
+			return nil
+		})
+	}
+	return fmt.Sprintf("%x", h.Sum(nil)), nil
+}
+
+// invalidateCache removes a graph from the cache, that of the given "at" ref,
+// "worktree" for the working tree, or all of them when none is given, e.g.
+// after the configuration changed:
+//
+//	curl -X POST localhost:8080/cache/invalidate?at=worktree
+func invalidateCache(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "use POST", http.StatusMethodNotAllowed)
+		return
+	}
+	at := r.FormValue("at")
+	key := ""
+	if at != "" && at != "worktree" {
+		var err error
+		if key, err = graphKey(at); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	n := webGraphs.invalidate(key, at == "")
+	slog.Info("cache invalidated", "at", at, "graphs", n)
+	fmt.Fprintf(w, "%d graphs removed\n", n)
+}
+
+// reportHeaders sets the caching headers of a report of the given graph,
+// changed since prev when not nil, and returns whether the client has it
+// already, in which case the report is not sent. The ETag is derived from the
+// hashes of the graphs and from the query, selecting the report and filters.
+// The graphs being cached, the reports are revalidated each time but not
+// downloaded again until the requirements change.
+func reportHeaders(w http.ResponseWriter, r *http.Request, g, prev *webGraph) bool {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\n%s\n%t %t\n", r.URL.RawQuery, g.hash, *fSuspect, *fBlame)
+	modified := g.built
+	if prev != nil {
+		fmt.Fprintf(h, "%s\n", prev.hash)
+		if prev.built.After(modified) {
+			modified = prev.built
+		}
+	}
+	etag := fmt.Sprintf(`"%x"`, h.Sum(nil)[:16])
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Last-Modified", modified.UTC().Format(http.TimeFormat))
+	w.Header().Set("Cache-Control", "no-cache")
+
+	// As in RFC 7232, If-Modified-Since is ignored when If-None-Match is given.
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		for _, t := range strings.Split(inm, ",") {
+			t = strings.TrimPrefix(strings.TrimSpace(t), "W/")
+			if t == etag || t == "*" {
+				w.WriteHeader(http.StatusNotModified)
+				return true
+			}
+		}
+		return false
+	}
+	if ims, err := http.ParseTime(r.Header.Get("If-Modified-Since")); err == nil && !modified.Truncate(time.Second).After(ims) {
+		w.WriteHeader(http.StatusNotModified)
+		return true
+	}
+	return false
+}
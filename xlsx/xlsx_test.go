@@ -0,0 +1,37 @@
+package xlsx
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestWriteRead(t *testing.T) {
+	rows := [][]string{
+		{"ID", "Title", "Safety Impact"},
+		{"REQ-0-TEST-SYS-001", "Uses <, > & \"quotes\"", ""},
+		{"REQ-0-TEST-SYS-002", "Multiple\nlines", "None"},
+	}
+	var b bytes.Buffer
+	if err := Write(&b, "Test", rows); err != nil {
+		t.Fatal(err)
+	}
+	got, err := Read(bytes.NewReader(b.Bytes()), int64(b.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(rows, got) {
+		t.Errorf("expected %q,\n     got %q", rows, got)
+	}
+}
+
+func TestColumns(t *testing.T) {
+	for i, name := range map[int]string{0: "A", 25: "Z", 26: "AA", 27: "AB", 701: "ZZ", 702: "AAA"} {
+		if got := columnName(i); got != name {
+			t.Errorf("columnName(%d): expected %s, got %s", i, name, got)
+		}
+		if got, err := columnIndex(name + "12"); err != nil || got != i {
+			t.Errorf("columnIndex(%s12): expected %d, got %d, %v", name, i, got, err)
+		}
+	}
+}